@@ -26,6 +26,7 @@ var (
 	_ DDLNode = &DropDatabaseStmt{}
 	_ DDLNode = &DropIndexStmt{}
 	_ DDLNode = &DropTableStmt{}
+	_ DDLNode = &RecoverTableStmt{}
 	_ DDLNode = &RenameTableStmt{}
 	_ DDLNode = &TruncateTableStmt{}
 
@@ -100,11 +101,13 @@ func (n *DropDatabaseStmt) Accept(v Visitor) (Node, bool) {
 }
 
 // IndexColName is used for parsing index column name from SQL.
+// For an expression index part, Column is nil and Expr is the indexed expression.
 type IndexColName struct {
 	node
 
 	Column *ColumnName
 	Length int
+	Expr   ExprNode
 }
 
 // Accept implements Node Accept interface.
@@ -114,6 +117,14 @@ func (n *IndexColName) Accept(v Visitor) (Node, bool) {
 		return v.Leave(newNode)
 	}
 	n = newNode.(*IndexColName)
+	if n.Expr != nil {
+		node, ok := n.Expr.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.Expr = node.(ExprNode)
+		return v.Leave(n)
+	}
 	node, ok := n.Column.Accept(v)
 	if !ok {
 		return n, false
@@ -240,6 +251,7 @@ const (
 	ColumnOptionFulltext
 	ColumnOptionComment
 	ColumnOptionGenerated
+	ColumnOptionCheck
 )
 
 // ColumnOption is used for parsing column constraint info from SQL.
@@ -249,6 +261,7 @@ type ColumnOption struct {
 	Tp ColumnOptionType
 	// For ColumnOptionDefaultValue or ColumnOptionOnUpdate, it's the target value.
 	// For ColumnOptionGenerated, it's the target expression.
+	// For ColumnOptionCheck, it's the check expression.
 	Expr ExprNode
 	// Stored is only for ColumnOptionGenerated, default is false.
 	Stored bool
@@ -283,8 +296,19 @@ type IndexOption struct {
 	KeyBlockSize uint64
 	Tp           model.IndexType
 	Comment      string
+	Visibility   IndexVisibility
 }
 
+// IndexVisibility is the visibility of an index, an invisible index is maintained but ignored by the optimizer.
+type IndexVisibility int
+
+// IndexVisibility types.
+const (
+	IndexVisibilityDefault IndexVisibility = iota
+	IndexVisibilityVisible
+	IndexVisibilityInvisible
+)
+
 // Accept implements Node Accept interface.
 func (n *IndexOption) Accept(v Visitor) (Node, bool) {
 	newNode, skipChildren := v.Enter(n)
@@ -309,6 +333,7 @@ const (
 	ConstraintUniqIndex
 	ConstraintForeignKey
 	ConstraintFulltext
+	ConstraintCheck
 )
 
 // Constraint is constraint for table definition.
@@ -323,6 +348,8 @@ type Constraint struct {
 	Refer *ReferenceDef // Used for foreign key.
 
 	Option *IndexOption // Index Options
+
+	Expr ExprNode // Used for CHECK.
 }
 
 // Accept implements Node Accept interface.
@@ -353,6 +380,13 @@ func (n *Constraint) Accept(v Visitor) (Node, bool) {
 		}
 		n.Option = node.(*IndexOption)
 	}
+	if n.Expr != nil {
+		node, ok := n.Expr.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.Expr = node.(ExprNode)
+	}
 	return v.Leave(n)
 }
 
@@ -690,6 +724,8 @@ const (
 	AlterTableRenameTable
 	AlterTableAlterColumn
 	AlterTableLock
+	AlterTableIndexInvisible
+	AlterTableDropCheck
 
 // TODO: Add more actions
 )
@@ -719,6 +755,7 @@ type AlterTableSpec struct {
 	OldColumnName *ColumnName
 	Position      *ColumnPosition
 	LockType      LockType
+	Visibility    IndexVisibility
 }
 
 // Accept implements Node Accept interface.
@@ -797,6 +834,33 @@ func (n *AlterTableStmt) Accept(v Visitor) (Node, bool) {
 	return v.Leave(n)
 }
 
+// RecoverTableStmt is a statement to recover a dropped or truncated table.
+// The syntaxes are:
+//	RECOVER TABLE tbl_name
+//	FLASHBACK TABLE tbl_name TO new_tbl_name
+type RecoverTableStmt struct {
+	ddlNode
+
+	Table *TableName
+	// NewName is the name of the recovered table, it is empty if the original name is kept.
+	NewName model.CIStr
+}
+
+// Accept implements Node Accept interface.
+func (n *RecoverTableStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*RecoverTableStmt)
+	node, ok := n.Table.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.Table = node.(*TableName)
+	return v.Leave(n)
+}
+
 // TruncateTableStmt is a statement to empty a table completely.
 // See https://dev.mysql.com/doc/refman/5.7/en/truncate-table.html
 type TruncateTableStmt struct {
@@ -117,6 +117,8 @@ type TableName struct {
 	TableInfo *model.TableInfo
 
 	IndexHints []*IndexHint
+	// AsOf is set when the table is read at a historical timestamp.
+	AsOf *AsOfClause
 }
 
 // IndexHintType is the type for index hint use, ignore or force.
@@ -157,6 +159,30 @@ func (n *TableName) Accept(v Visitor) (Node, bool) {
 	return v.Leave(n)
 }
 
+// AsOfClause is the clause to read the historical data at a timestamp.
+// The timestamp expression is evaluated before the statement is planned, so it's
+// not visited with the table name.
+type AsOfClause struct {
+	node
+
+	TsExpr ExprNode
+}
+
+// Accept implements Node Accept interface.
+func (n *AsOfClause) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*AsOfClause)
+	node, ok := n.TsExpr.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.TsExpr = node.(ExprNode)
+	return v.Leave(n)
+}
+
 // DeleteTableList is the tablelist used in delete statement multi-table mode.
 type DeleteTableList struct {
 	node
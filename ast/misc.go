@@ -38,6 +38,8 @@ var (
 	_ StmtNode = &GrantStmt{}
 	_ StmtNode = &PrepareStmt{}
 	_ StmtNode = &RollbackStmt{}
+	_ StmtNode = &SavepointStmt{}
+	_ StmtNode = &ReleaseSavepointStmt{}
 	_ StmtNode = &SetPwdStmt{}
 	_ StmtNode = &SetStmt{}
 	_ StmtNode = &UseStmt{}
@@ -187,10 +189,23 @@ func (n *ExecuteStmt) Accept(v Visitor) (Node, bool) {
 	return v.Leave(n)
 }
 
+// Transaction modes of BeginStmt.
+const (
+	Pessimistic = "PESSIMISTIC"
+	Optimistic  = "OPTIMISTIC"
+)
+
 // BeginStmt is a statement to start a new transaction.
 // See https://dev.mysql.com/doc/refman/5.7/en/commit.html
 type BeginStmt struct {
 	stmtNode
+
+	// Mode is Pessimistic or Optimistic, the empty value means the mode in tidb_txn_mode.
+	Mode string
+	// ReadOnly is set by START TRANSACTION READ ONLY, the writes are not allowed.
+	ReadOnly bool
+	// AsOf is set when the transaction reads the historical data at a timestamp.
+	AsOf *AsOfClause
 }
 
 // Accept implements Node Accept interface.
@@ -241,6 +256,10 @@ func (n *CommitStmt) Accept(v Visitor) (Node, bool) {
 // See https://dev.mysql.com/doc/refman/5.7/en/commit.html
 type RollbackStmt struct {
 	stmtNode
+
+	// SavepointName is the savepoint to roll back to, the whole transaction is
+	// rolled back if it's empty.
+	SavepointName string
 }
 
 // Accept implements Node Accept interface.
@@ -253,6 +272,42 @@ func (n *RollbackStmt) Accept(v Visitor) (Node, bool) {
 	return v.Leave(n)
 }
 
+// SavepointStmt is a statement to set a savepoint in the current transaction.
+// See https://dev.mysql.com/doc/refman/5.7/en/savepoint.html
+type SavepointStmt struct {
+	stmtNode
+
+	Name string
+}
+
+// Accept implements Node Accept interface.
+func (n *SavepointStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*SavepointStmt)
+	return v.Leave(n)
+}
+
+// ReleaseSavepointStmt is a statement to remove a savepoint and the ones set after it.
+// See https://dev.mysql.com/doc/refman/5.7/en/savepoint.html
+type ReleaseSavepointStmt struct {
+	stmtNode
+
+	Name string
+}
+
+// Accept implements Node Accept interface.
+func (n *ReleaseSavepointStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*ReleaseSavepointStmt)
+	return v.Leave(n)
+}
+
 // UseStmt is a statement to use the DBName database as the current database.
 // See https://dev.mysql.com/doc/refman/5.7/en/use.html
 type UseStmt struct {
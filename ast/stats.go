@@ -18,6 +18,7 @@ import "github.com/pingcap/tidb/model"
 var (
 	_ StmtNode = &AnalyzeTableStmt{}
 	_ StmtNode = &DropStatsStmt{}
+	_ StmtNode = &LoadStatsStmt{}
 )
 
 // AnalyzeTableStmt is used to create table statistics.
@@ -26,6 +27,9 @@ type AnalyzeTableStmt struct {
 
 	TableNames []*TableName
 	IndexNames []model.CIStr
+	// ColumnGroups are the column groups declared to collect the extended statistics on, they
+	// are kept and collected again by the later analyze of the table.
+	ColumnGroups [][]*ColumnName
 }
 
 // Accept implements Node Accept interface.
@@ -66,3 +70,20 @@ func (n *DropStatsStmt) Accept(v Visitor) (Node, bool) {
 	n.Table = node.(*TableName)
 	return v.Leave(n)
 }
+
+// LoadStatsStmt is the statement node for loading the statistics from a JSON file.
+type LoadStatsStmt struct {
+	stmtNode
+
+	Path string
+}
+
+// Accept implements Node Accept interface.
+func (n *LoadStatsStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*LoadStatsStmt)
+	return v.Leave(n)
+}
@@ -159,6 +159,7 @@ const (
 		null_count bigint(64) NOT NULL DEFAULT 0,
 		modify_count bigint(64) NOT NULL DEFAULT 0,
 		version bigint(64) unsigned NOT NULL DEFAULT 0,
+		cm_sketch blob,
 		unique index tbl(table_id, is_index, hist_id)
 	);`
 
@@ -185,6 +186,31 @@ const (
 		UNIQUE KEY (element_id),
 		KEY (job_id, element_id)
 	);`
+
+	// CreateAnalyzeHistoryTable stores the history of the tables analyzed automatically.
+	CreateAnalyzeHistoryTable = `CREATE TABLE IF NOT EXISTS mysql.analyze_history (
+		table_id bigint(64) NOT NULL,
+		table_schema varchar(64) NOT NULL,
+		table_name varchar(64) NOT NULL,
+		job_info varchar(256) NOT NULL,
+		reason varchar(256) NOT NULL,
+		start_time datetime NOT NULL,
+		end_time datetime NOT NULL,
+		state varchar(16) NOT NULL COMMENT "finished or failed",
+		fail_reason text,
+		index idx_table(table_id),
+		index idx_end_time(end_time)
+	);`
+
+	// CreateStatsExtendedTable stores the extended statistics of the declared column groups.
+	CreateStatsExtendedTable = `CREATE TABLE IF NOT EXISTS mysql.stats_extended (
+		table_id bigint(64) NOT NULL,
+		column_ids varchar(256) NOT NULL COMMENT "the column IDs separated by commas",
+		ndv bigint(64) NOT NULL DEFAULT 0,
+		dependency double NOT NULL DEFAULT 0,
+		version bigint(64) unsigned NOT NULL DEFAULT 0,
+		unique index tbl(table_id, column_ids)
+	);`
 )
 
 // bootstrap initiates system DB for a store.
@@ -226,6 +252,9 @@ const (
 	version13 = 13
 	version14 = 14
 	version15 = 15
+	version16 = 16
+	version17 = 17
+	version18 = 18
 )
 
 func checkBootstrapped(s Session) (bool, error) {
@@ -340,6 +369,18 @@ func upgrade(s Session) {
 		upgradeToVer15(s)
 	}
 
+	if ver < version16 {
+		upgradeToVer16(s)
+	}
+
+	if ver < version17 {
+		upgradeToVer17(s)
+	}
+
+	if ver < version18 {
+		upgradeToVer18(s)
+	}
+
 	updateBootstrapVer(s)
 	_, err = s.Execute("COMMIT")
 
@@ -547,6 +588,29 @@ func upgradeToVer15(s Session) {
 	}
 }
 
+func upgradeToVer16(s Session) {
+	mustExecute(s, CreateAnalyzeHistoryTable)
+	// Version 16 adds the system variables of the auto analyze.
+	autoAnalyzeVars := []string{variable.TiDBAutoAnalyzeRatio, variable.TiDBAutoAnalyzeStartTime,
+		variable.TiDBAutoAnalyzeEndTime, variable.TiDBAutoAnalyzeConcurrency}
+	values := make([]string, 0, len(autoAnalyzeVars))
+	for _, v := range autoAnalyzeVars {
+		value := fmt.Sprintf(`("%s", "%s")`, v, variable.SysVars[v].Value)
+		values = append(values, value)
+	}
+	sql := fmt.Sprintf("INSERT IGNORE INTO %s.%s VALUES %s;", mysql.SystemDB, mysql.GlobalVariablesTable,
+		strings.Join(values, ", "))
+	mustExecute(s, sql)
+}
+
+func upgradeToVer17(s Session) {
+	doReentrantDDL(s, "ALTER TABLE mysql.stats_histograms ADD COLUMN cm_sketch blob", infoschema.ErrColumnExists)
+}
+
+func upgradeToVer18(s Session) {
+	mustExecute(s, CreateStatsExtendedTable)
+}
+
 // updateBootstrapVer updates bootstrap version variable in mysql.TiDB table.
 func updateBootstrapVer(s Session) {
 	// Update bootstrap version.
@@ -593,6 +657,10 @@ func doDDLWorks(s Session) {
 	mustExecute(s, CreateStatsBucketsTable)
 	// Create gc_delete_range table.
 	mustExecute(s, CreateGCDeleteRangeTable)
+	// Create analyze_history table.
+	mustExecute(s, CreateAnalyzeHistoryTable)
+	// Create stats_extended table.
+	mustExecute(s, CreateStatsExtendedTable)
 }
 
 // doDMLWorks executes DML statements in bootstrap stage.
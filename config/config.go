@@ -29,12 +29,17 @@ type Config struct {
 	BinlogSocket string `toml:"binlog-socket" json:"binlog-socket"`
 	Lease        string `toml:"lease" json:"lease"`
 	RunDDL       bool   `toml:"run-ddl" json:"run-ddl"`
-
-	Log         Log         `toml:"log" json:"log"`
-	Security    Security    `toml:"security" json:"security"`
-	Status      Status      `toml:"status" json:"status"`
-	Performance Performance `toml:"performance" json:"performance"`
-	XProtocol   XProtocol   `toml:"xprotocol" json:"xprotocol"`
+	// Labels are the location labels of the server, e.g. its zone. The closest replica
+	// reads prefer the replicas on the TiKV stores with the same labels.
+	Labels map[string]string `toml:"labels" json:"labels"`
+
+	Log              Log              `toml:"log" json:"log"`
+	Security         Security         `toml:"security" json:"security"`
+	Status           Status           `toml:"status" json:"status"`
+	Performance      Performance      `toml:"performance" json:"performance"`
+	XProtocol        XProtocol        `toml:"xprotocol" json:"xprotocol"`
+	CoprocessorCache CoprocessorCache `toml:"coprocessor-cache" json:"coprocessor-cache"`
+	TiKVClient       TiKVClient       `toml:"tikv-client" json:"tikv-client"`
 }
 
 // Log is the log section of config.
@@ -76,6 +81,34 @@ type Performance struct {
 	JoinConcurrency int    `toml:"join-concurrency" json:"join-concurrency"`
 	CrossJoin       bool   `toml:"cross-join" json:"cross-join"`
 	StatsLease      string `toml:"stats-lease" json:"stats-lease"`
+	// TxnTotalSizeLimit is the max total size in bytes of the keys and values written by a transaction.
+	TxnTotalSizeLimit uint64 `toml:"txn-total-size-limit" json:"txn-total-size-limit"`
+	// MemBufferSpillSize is the size in bytes above which the writes of a transaction are spilled to disk.
+	MemBufferSpillSize uint64 `toml:"membuffer-spill-size" json:"membuffer-spill-size"`
+}
+
+// CoprocessorCache is the coprocessor-cache section of the config.
+type CoprocessorCache struct {
+	// Enable enables the cache of the coprocessor responses.
+	Enable bool `toml:"enable" json:"enable"`
+	// CapacityMB is the max total size in MB of the cached responses.
+	CapacityMB float64 `toml:"capacity-mb" json:"capacity-mb"`
+	// AdmissionMaxResultMB is the max size in MB of a cached response.
+	AdmissionMaxResultMB float64 `toml:"admission-max-result-mb" json:"admission-max-result-mb"`
+	// AdmissionMinProcessMs is the min processing time in ms of a cached response.
+	AdmissionMinProcessMs uint64 `toml:"admission-min-process-ms" json:"admission-min-process-ms"`
+}
+
+// TiKVClient is the tikv-client section of the config.
+type TiKVClient struct {
+	// MaxBatchSize is the max number of the point gets to a store sent in a batch, the
+	// batching is disabled if it's 0.
+	MaxBatchSize uint `toml:"max-batch-size" json:"max-batch-size"`
+	// MaxBatchWaitTime is the max time to wait for more requests of a batch.
+	MaxBatchWaitTime string `toml:"max-batch-wait-time" json:"max-batch-wait-time"`
+	// BatchWaitSize is the average size of the recent batches above which a batch waits
+	// for more requests, so the requests aren't delayed when the load is low.
+	BatchWaitSize uint `toml:"batch-wait-size" json:"batch-wait-size"`
 }
 
 // XProtocol is the XProtocol section of the config.
@@ -113,11 +146,26 @@ var defaultConf = Config{
 		JoinConcurrency: 5,
 		CrossJoin:       true,
 		StatsLease:      "3s",
+		// 10GB
+		TxnTotalSizeLimit: 10 * 1024 * 1024 * 1024,
+		// 256MB
+		MemBufferSpillSize: 256 * 1024 * 1024,
 	},
 	XProtocol: XProtocol{
 		XHost: "0.0.0.0",
 		XPort: 14000,
 	},
+	CoprocessorCache: CoprocessorCache{
+		Enable:                false,
+		CapacityMB:            1000,
+		AdmissionMaxResultMB:  10,
+		AdmissionMinProcessMs: 5,
+	},
+	TiKVClient: TiKVClient{
+		MaxBatchSize:     0,
+		MaxBatchWaitTime: "200us",
+		BatchWaitSize:    8,
+	},
 }
 
 var globalConf = defaultConf
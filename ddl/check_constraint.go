@@ -0,0 +1,356 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ddl
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/meta"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/table"
+	"github.com/pingcap/tidb/table/tables"
+	"github.com/pingcap/tidb/tablecodec"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// checkConstraintDisallowedFuncs contains the functions that can't be used in a check constraint,
+// because their results are not decided by the row.
+var checkConstraintDisallowedFuncs = map[string]struct{}{
+	ast.Benchmark: {}, ast.ConnectionID: {}, ast.CurrentUser: {}, ast.Database: {}, ast.FoundRows: {},
+	ast.LastInsertId: {}, ast.RowCount: {}, ast.Schema: {}, ast.SessionUser: {}, ast.SystemUser: {},
+	ast.User: {}, ast.Version: {}, ast.TiDBVersion: {}, ast.Rand: {}, ast.UUID: {}, ast.UUIDShort: {},
+	ast.Sleep: {}, ast.GetLock: {}, ast.ReleaseLock: {}, ast.LoadFile: {},
+	ast.Curdate: {}, ast.CurrentDate: {}, ast.CurrentTime: {}, ast.CurrentTimestamp: {}, ast.Curtime: {},
+	ast.LocalTime: {}, ast.LocalTimestamp: {}, ast.Now: {}, ast.Sysdate: {},
+	ast.UTCDate: {}, ast.UTCTime: {}, ast.UTCTimestamp: {},
+}
+
+// checkConstraintChecker checks whether the expression of a check constraint is valid.
+type checkConstraintChecker struct {
+	name    string
+	tblInfo *model.TableInfo
+	err     error
+}
+
+func (c *checkConstraintChecker) Enter(inNode ast.Node) (outNode ast.Node, skipChildren bool) {
+	switch x := inNode.(type) {
+	case *ast.SubqueryExpr:
+		c.err = ErrCheckConstraintFunctionIsNotAllowed.GenByArgs(c.name, "subquery")
+	case *ast.AggregateFuncExpr:
+		c.err = ErrCheckConstraintFunctionIsNotAllowed.GenByArgs(c.name, x.F)
+	case *ast.VariableExpr:
+		c.err = ErrCheckConstraintVariables.GenByArgs(c.name)
+	case *ast.FuncCallExpr:
+		fnName := x.FnName.L
+		if _, ok := checkConstraintDisallowedFuncs[fnName]; ok || (fnName == ast.UnixTimestamp && len(x.Args) == 0) {
+			c.err = ErrCheckConstraintFunctionIsNotAllowed.GenByArgs(c.name, fnName)
+		}
+	case *ast.ColumnName:
+		col := findCol(c.tblInfo.Columns, x.Name.L)
+		if col == nil || col.State != model.StatePublic || col.Hidden {
+			c.err = ErrCheckConstraintRefersUnknownColumn.GenByArgs(c.name, x.Name.O)
+		} else if mysql.HasAutoIncrementFlag(col.Flag) {
+			c.err = ErrCheckConstraintRefersAutoIncrementColumn.GenByArgs(c.name)
+		}
+	}
+	return inNode, c.err != nil
+}
+
+func (c *checkConstraintChecker) Leave(inNode ast.Node) (node ast.Node, ok bool) {
+	return inNode, c.err == nil
+}
+
+// buildCheckConstraintInfo builds the check constraint info from the constraint definition.
+// The constraint name is generated if it's not specified.
+func buildCheckConstraintInfo(ctx context.Context, tblInfo *model.TableInfo, constr *ast.Constraint) (*model.CheckConstraintInfo, error) {
+	name := constr.Name
+	if name == "" {
+		for i := 1; ; i++ {
+			name = fmt.Sprintf("%s_chk_%d", tblInfo.Name.O, i)
+			if findCheckConstraint(tblInfo, name) == nil {
+				break
+			}
+		}
+	} else if findCheckConstraint(tblInfo, name) != nil {
+		return nil, ErrCheckConstraintDupName.GenByArgs(name)
+	}
+
+	checker := &checkConstraintChecker{name: name, tblInfo: tblInfo}
+	constr.Expr.Accept(checker)
+	if checker.err != nil {
+		return nil, errors.Trace(checker.err)
+	}
+
+	// Parse the expression text again, so we are sure that it can be restored from the table info.
+	exprStr := strings.TrimSpace(constr.Expr.Text())
+	expr, err := tables.ParseGeneratedExpr(exprStr, tblInfo)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	cols := expression.ColumnInfos2Columns(tblInfo.Name, tblInfo.Columns)
+	if _, err = expression.RewriteAstExpr(expr, expression.NewSchema(cols...), ctx); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &model.CheckConstraintInfo{
+		Name:       model.NewCIStr(name),
+		ExprString: exprStr,
+	}, nil
+}
+
+// buildCheckConstraintInfos builds the check constraints of a table which is being created, they are public at once.
+func buildCheckConstraintInfos(ctx context.Context, tblInfo *model.TableInfo, constraints []*ast.Constraint) error {
+	for _, constr := range constraints {
+		if constr.Tp != ast.ConstraintCheck {
+			continue
+		}
+		info, err := buildCheckConstraintInfo(ctx, tblInfo, constr)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		info.State = model.StatePublic
+		tblInfo.CheckConstraints = append(tblInfo.CheckConstraints, info)
+	}
+	return nil
+}
+
+func findCheckConstraint(tblInfo *model.TableInfo, name string) *model.CheckConstraintInfo {
+	for _, check := range tblInfo.CheckConstraints {
+		if check.Name.L == strings.ToLower(name) {
+			return check
+		}
+	}
+	return nil
+}
+
+func removeCheckConstraint(tblInfo *model.TableInfo, name model.CIStr) {
+	checks := tblInfo.CheckConstraints[:0]
+	for _, check := range tblInfo.CheckConstraints {
+		if check.Name.L != name.L {
+			checks = append(checks, check)
+		}
+	}
+	tblInfo.CheckConstraints = checks
+}
+
+// checkColumnReferredByCheckConstraint returns an error if the column is referred by any check constraint.
+func checkColumnReferredByCheckConstraint(tblInfo *model.TableInfo, colName model.CIStr) error {
+	for _, check := range tblInfo.CheckConstraints {
+		expr, err := tables.ParseGeneratedExpr(check.ExprString, tblInfo)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		for _, col := range findColumnNamesInExpr(expr) {
+			if col.Name.L == colName.L {
+				return ErrDependentByCheckConstraint.GenByArgs(check.Name.O, colName.O)
+			}
+		}
+	}
+	return nil
+}
+
+func (d *ddl) onAddCheckConstraint(t *meta.Meta, job *model.Job) (ver int64, err error) {
+	schemaID := job.SchemaID
+	tblInfo, err := getTableInfo(t, job, schemaID)
+	if err != nil {
+		return ver, errors.Trace(err)
+	}
+
+	var checkInfo model.CheckConstraintInfo
+	err = job.DecodeArgs(&checkInfo)
+	if err != nil {
+		job.State = model.JobCancelled
+		return ver, errors.Trace(err)
+	}
+
+	check := findCheckConstraint(tblInfo, checkInfo.Name.L)
+	if check != nil && check.State == model.StatePublic {
+		job.State = model.JobCancelled
+		return ver, ErrCheckConstraintDupName.GenByArgs(checkInfo.Name.O)
+	}
+	if check == nil {
+		check = &checkInfo
+		check.State = model.StateNone
+		tblInfo.CheckConstraints = append(tblInfo.CheckConstraints, check)
+	}
+
+	originalState := check.State
+	switch check.State {
+	case model.StateNone:
+		// none -> write only
+		// The new rows are checked before the existing rows are validated.
+		job.SchemaState = model.StateWriteOnly
+		check.State = model.StateWriteOnly
+		ver, err = updateTableInfo(t, job, tblInfo, originalState)
+	case model.StateWriteOnly:
+		// write only -> reorganization
+		job.SchemaState = model.StateWriteReorganization
+		check.State = model.StateWriteReorganization
+		// Initialize SnapshotVer to 0 for later reorganization check.
+		job.SnapshotVer = 0
+		ver, err = updateTableInfo(t, job, tblInfo, originalState)
+	case model.StateWriteReorganization:
+		// reorganization -> public
+		var reorgInfo *reorgInfo
+		reorgInfo, err = d.getReorgInfo(t, job)
+		if err != nil || reorgInfo.first {
+			// If we run reorg firstly, we should update the job snapshot version
+			// and then run the reorg next time.
+			return ver, errors.Trace(err)
+		}
+
+		var tbl table.Table
+		tbl, err = d.getTable(schemaID, tblInfo)
+		if err != nil {
+			return ver, errors.Trace(err)
+		}
+
+		err = d.runReorgJob(job, func() error {
+			return d.validateCheckConstraint(tbl, check, reorgInfo)
+		})
+		if err != nil {
+			if errWaitReorgTimeout.Equal(err) {
+				// if timeout, we should return, check for the owner and re-wait job done.
+				return ver, nil
+			}
+			if table.ErrCheckConstraintViolated.Equal(err) {
+				// Some existing rows violate the constraint, so we remove it.
+				removeCheckConstraint(tblInfo, check.Name)
+				job.SchemaState = model.StateNone
+				var err1 error
+				ver, err1 = updateTableInfo(t, job, tblInfo, originalState)
+				if err1 != nil {
+					return ver, errors.Trace(err1)
+				}
+				job.State = model.JobRollbackDone
+				job.BinlogInfo.AddTableInfo(ver, tblInfo)
+			}
+			return ver, errors.Trace(err)
+		}
+
+		check.State = model.StatePublic
+		job.SchemaState = model.StatePublic
+		ver, err = updateTableInfo(t, job, tblInfo, originalState)
+		if err != nil {
+			return ver, errors.Trace(err)
+		}
+
+		// Finish this job.
+		job.State = model.JobDone
+		job.BinlogInfo.AddTableInfo(ver, tblInfo)
+	default:
+		err = ErrInvalidCheckConstraintState.Gen("invalid check constraint state %v", check.State)
+	}
+
+	return ver, errors.Trace(err)
+}
+
+func (d *ddl) onDropCheckConstraint(t *meta.Meta, job *model.Job) (ver int64, _ error) {
+	schemaID := job.SchemaID
+	tblInfo, err := getTableInfo(t, job, schemaID)
+	if err != nil {
+		return ver, errors.Trace(err)
+	}
+
+	var name model.CIStr
+	err = job.DecodeArgs(&name)
+	if err != nil {
+		job.State = model.JobCancelled
+		return ver, errors.Trace(err)
+	}
+
+	check := findCheckConstraint(tblInfo, name.L)
+	if check == nil {
+		job.State = model.JobCancelled
+		return ver, ErrCheckConstraintNotFound.GenByArgs(name)
+	}
+
+	originalState := check.State
+	switch check.State {
+	case model.StatePublic:
+		// The check constraint has no data, so we just make it none.
+		// public -> none
+		job.SchemaState = model.StateNone
+		check.State = model.StateNone
+		removeCheckConstraint(tblInfo, name)
+		ver, err = updateTableInfo(t, job, tblInfo, originalState)
+		if err != nil {
+			return ver, errors.Trace(err)
+		}
+		// Finish this job.
+		job.State = model.JobDone
+		job.BinlogInfo.AddTableInfo(ver, tblInfo)
+		return ver, nil
+	default:
+		job.State = model.JobCancelled
+		return ver, ErrInvalidCheckConstraintState.Gen("invalid check constraint state %v", check.State)
+	}
+}
+
+// validateCheckConstraint checks all the rows of the snapshot against the check constraint.
+// The rows written after the snapshot are checked by the DML, because the constraint is in write only state already.
+func (d *ddl) validateCheckConstraint(t table.Table, check *model.CheckConstraintInfo, reorgInfo *reorgInfo) error {
+	ctx := d.newContext()
+	checkExpr, err := tables.BuildCheckConstraintExpr(ctx, t, check)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	checks := []*tables.CheckConstraintExpr{checkExpr}
+	genExprs, err := d.buildAllVirtualColumnExprs(t)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	cols := t.Cols()
+	colMap := make(map[int64]*types.FieldType, len(cols))
+	for _, col := range cols {
+		if col.IsGenerated() && !col.GeneratedStored {
+			continue
+		}
+		colMap[col.ID] = &col.FieldType
+	}
+	defaultVals := make([]types.Datum, len(cols))
+	row := make([]types.Datum, len(cols))
+	var count int64
+	err = d.iterateSnapshotRows(t, reorgInfo.SnapshotVer, reorgInfo.Handle,
+		func(h int64, rowKey kv.Key, rawRecord []byte) (bool, error) {
+			rowMap, err := tablecodec.DecodeRow(rawRecord, colMap, time.UTC)
+			if err != nil {
+				return false, errors.Trace(err)
+			}
+			for _, col := range cols {
+				row[col.Offset], err = getColumnValue(ctx, t, col, h, rowMap, defaultVals)
+				if err != nil {
+					return false, errors.Trace(err)
+				}
+			}
+			if err = fillVirtualColumns(ctx, cols, genExprs, row); err != nil {
+				return false, errors.Trace(err)
+			}
+			if err = tables.VerifyCheckConstraints(ctx, checks, row); err != nil {
+				return false, errors.Trace(err)
+			}
+			count++
+			d.setReorgRowCount(count)
+			return true, nil
+		})
+	return errors.Trace(err)
+}
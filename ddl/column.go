@@ -74,7 +74,14 @@ func (d *ddl) createColumnInfo(tblInfo *model.TableInfo, colInfo *model.ColumnIn
 		}
 
 		// Insert position is after the mentioned column.
-		position = c.Offset + 1
+		// The column may be added by the same multi-schema change and not be public yet,
+		// so we use its position in the column list instead of its offset.
+		for i, col := range cols {
+			if col == c {
+				position = i + 1
+				break
+			}
+		}
 	}
 	colInfo.ID = allocateColumnID(tblInfo)
 	colInfo.State = model.StateNone
@@ -144,6 +151,8 @@ func (d *ddl) onAddColumn(t *meta.Meta, job *model.Job) (ver int64, _ error) {
 		job.SchemaState = model.StateWriteReorganization
 		columnInfo.State = model.StateWriteReorganization
 		ver, err = updateTableInfo(t, job, tblInfo, originalState)
+		// The column added by a multi-schema change is made public with the other changes together.
+		job.MarkNonRevertible()
 	case model.StateWriteReorganization:
 		// reorganization -> public
 		// Adjust column offset.
@@ -63,6 +63,8 @@ var (
 	errInvalidDDLJob         = terror.ClassDDL.New(codeInvalidDDLJob, "invalid ddl job")
 	errInvalidJobFlag        = terror.ClassDDL.New(codeInvalidJobFlag, "invalid job flag")
 	errRunMultiSchemaChanges = terror.ClassDDL.New(codeRunMultiSchemaChanges, "can't run multi schema change")
+	errOperateSameColumn     = terror.ClassDDL.New(codeOperateSameColumn, "can't operate the same column %s in one multi schema change")
+	errOperateSameIndex      = terror.ClassDDL.New(codeOperateSameIndex, "can't operate the same index %s in one multi schema change")
 	errWaitReorgTimeout      = terror.ClassDDL.New(codeWaitReorgTimeout, "wait for reorganization timeout")
 	errInvalidStoreVer       = terror.ClassDDL.New(codeInvalidStoreVer, "invalid storage current version")
 
@@ -101,6 +103,10 @@ var (
 	errJSONUsedAsKey = terror.ClassDDL.New(codeJSONUsedAsKey, mysql.MySQLErrName[mysql.ErrJSONUsedAsKey])
 	// errBlobCantHaveDefault forbiddens to give not null default value to TEXT/BLOB/JSON.
 	errBlobCantHaveDefault = terror.ClassDDL.New(codeBlobCantHaveDefault, mysql.MySQLErrName[mysql.ErrBlobCantHaveDefault])
+	// errKeyDoesNotExist is for altering an index which doesn't exist.
+	errKeyDoesNotExist = terror.ClassDDL.New(codeKeyDoesNotExist, mysql.MySQLErrName[mysql.ErrKeyDoesNotExits])
+	// errPKIndexCantBeInvisible forbiddens to make the primary key invisible.
+	errPKIndexCantBeInvisible = terror.ClassDDL.New(codePKIndexCantBeInvisible, mysql.MySQLErrName[mysql.ErrPKIndexCantBeInvisible])
 
 	// ErrInvalidDBState returns for invalid database state.
 	ErrInvalidDBState = terror.ClassDDL.New(codeInvalidDBState, "invalid database state")
@@ -112,6 +118,8 @@ var (
 	ErrInvalidIndexState = terror.ClassDDL.New(codeInvalidIndexState, "invalid index state")
 	// ErrInvalidForeignKeyState returns for invalid foreign key state.
 	ErrInvalidForeignKeyState = terror.ClassDDL.New(codeInvalidForeignKeyState, "invalid foreign key state")
+	// ErrInvalidCheckConstraintState returns for invalid check constraint state.
+	ErrInvalidCheckConstraintState = terror.ClassDDL.New(codeInvalidCheckConstraintState, "invalid check constraint state")
 	// ErrUnsupportedModifyPrimaryKey returns an error when add or drop the primary key.
 	// It's exported for testing.
 	ErrUnsupportedModifyPrimaryKey = terror.ClassDDL.New(codeUnsupportedModifyPrimaryKey, "unsupported %s primary key")
@@ -134,6 +142,30 @@ var (
 	ErrWrongColumnName = terror.ClassDDL.New(codeWrongColumnName, mysql.MySQLErrName[mysql.ErrWrongColumnName])
 	// ErrWrongNameForIndex returns for wrong index name.
 	ErrWrongNameForIndex = terror.ClassDDL.New(codeWrongNameForIndex, mysql.MySQLErrName[mysql.ErrWrongNameForIndex])
+	// ErrUnsupportedExpressionIndex returns for an expression index which isn't supported in the statement.
+	ErrUnsupportedExpressionIndex = terror.ClassDDL.New(codeUnsupportedExpressionIndex, "unsupported expression index in %s")
+	// ErrFunctionalIndexPrimaryKey returns for a primary key with expression parts.
+	ErrFunctionalIndexPrimaryKey = terror.ClassDDL.New(codeFunctionalIndexPrimaryKey, mysql.MySQLErrName[mysql.ErrFunctionalIndexPrimaryKey])
+	// ErrFunctionalIndexOnField returns for an expression index part which is a plain column.
+	ErrFunctionalIndexOnField = terror.ClassDDL.New(codeFunctionalIndexOnField, mysql.MySQLErrName[mysql.ErrFunctionalIndexOnField])
+	// ErrDroppedTableNotFound returns for recovering a table which isn't dropped or truncated.
+	ErrDroppedTableNotFound = terror.ClassDDL.New(codeDroppedTableNotFound, "can't find dropped or truncated table '%s' in DDL history jobs")
+	// ErrDroppedTableDataDeleted returns for recovering a table whose data may have been deleted by GC.
+	ErrDroppedTableDataDeleted = terror.ClassDDL.New(codeDroppedTableDataDeleted, "can't recover table '%s', its data may have been deleted by GC")
+	// ErrCheckConstraintDupName returns for a duplicate check constraint name in the table.
+	ErrCheckConstraintDupName = terror.ClassDDL.New(codeCheckConstraintDupName, mysql.MySQLErrName[mysql.ErrCheckConstraintDupName])
+	// ErrCheckConstraintNotFound returns for dropping a check constraint which doesn't exist.
+	ErrCheckConstraintNotFound = terror.ClassDDL.New(codeCheckConstraintNotFound, mysql.MySQLErrName[mysql.ErrCheckConstraintNotFound])
+	// ErrCheckConstraintRefersUnknownColumn returns for a check constraint which refers to an unknown column.
+	ErrCheckConstraintRefersUnknownColumn = terror.ClassDDL.New(codeCheckConstraintRefersUnknownColumn, mysql.MySQLErrName[mysql.ErrCheckConstraintRefersUnknownColumn])
+	// ErrCheckConstraintRefersAutoIncrementColumn returns for a check constraint which refers to an auto-increment column.
+	ErrCheckConstraintRefersAutoIncrementColumn = terror.ClassDDL.New(codeCheckConstraintRefersAutoIncrementColumn, mysql.MySQLErrName[mysql.ErrCheckConstraintRefersAutoIncrementColumn])
+	// ErrCheckConstraintFunctionIsNotAllowed returns for a check constraint which contains a non-deterministic function or a subquery.
+	ErrCheckConstraintFunctionIsNotAllowed = terror.ClassDDL.New(codeCheckConstraintFunctionIsNotAllowed, mysql.MySQLErrName[mysql.ErrCheckConstraintFunctionIsNotAllowed])
+	// ErrCheckConstraintVariables returns for a check constraint which refers to a variable.
+	ErrCheckConstraintVariables = terror.ClassDDL.New(codeCheckConstraintVariables, mysql.MySQLErrName[mysql.ErrCheckConstraintVariables])
+	// ErrDependentByCheckConstraint returns for dropping or renaming a column which is used by a check constraint.
+	ErrDependentByCheckConstraint = terror.ClassDDL.New(codeDependentByCheckConstraint, mysql.MySQLErrName[mysql.ErrDependentByCheckConstraint])
 )
 
 // DDL is responsible for updating schema in data store and maintaining in-memory InfoSchema cache.
@@ -150,6 +182,7 @@ type DDL interface {
 	GetInformationSchema() infoschema.InfoSchema
 	AlterTable(ctx context.Context, tableIdent ast.Ident, spec []*ast.AlterTableSpec) error
 	TruncateTable(ctx context.Context, tableIdent ast.Ident) error
+	RecoverTable(ctx context.Context, tableIdent ast.Ident, newName model.CIStr) error
 	RenameTable(ctx context.Context, oldTableIdent, newTableIdent ast.Ident) error
 	// SetLease will reset the lease time for online DDL change,
 	// it's a very dangerous function and you must guarantee that all servers have the same lease time.
@@ -417,7 +450,7 @@ func (d *ddl) OwnerManager() owner.Manager {
 func checkJobMaxInterval(job *model.Job) time.Duration {
 	// The job of adding index takes more time to process.
 	// So it uses the longer time.
-	if job.Type == model.ActionAddIndex {
+	if job.Type == model.ActionAddIndex || job.Type == model.ActionMultiSchemaChange {
 		return 3 * time.Second
 	}
 	return 1 * time.Second
@@ -527,12 +560,19 @@ const (
 	codeInvalidIndexState      = 103
 	codeInvalidForeignKeyState = 104
 
+	codeInvalidCheckConstraintState = 105
+
 	codeCantDropColWithIndex        = 201
 	codeUnsupportedAddColumn        = 202
 	codeUnsupportedModifyColumn     = 203
 	codeUnsupportedDropPKHandle     = 204
 	codeUnsupportedCharset          = 205
 	codeUnsupportedModifyPrimaryKey = 206
+	codeOperateSameColumn           = 207
+	codeOperateSameIndex            = 208
+	codeUnsupportedExpressionIndex  = 209
+	codeDroppedTableNotFound        = 210
+	codeDroppedTableDataDeleted     = 211
 
 	codeFileNotFound                 = 1017
 	codeErrorOnRename                = 1025
@@ -553,12 +593,24 @@ const (
 	codeWrongColumnName              = 1166
 	codeWrongKeyColumn               = 1167
 	codeBlobKeyWithoutLength         = 1170
+	codeKeyDoesNotExist              = 1176
 	codeInvalidOnUpdate              = 1294
 	codeUnsupportedOnGeneratedColumn = 3106
 	codeGeneratedColumnNonPrior      = 3107
 	codeDependentByGeneratedColumn   = 3108
 	codeJSONUsedAsKey                = 3152
+	codePKIndexCantBeInvisible       = 3522
+	codeFunctionalIndexPrimaryKey    = 3756
+	codeFunctionalIndexOnField       = 3762
 	codeWrongNameForIndex            = terror.ErrCode(mysql.ErrWrongNameForIndex)
+
+	codeCheckConstraintFunctionIsNotAllowed      = 3814
+	codeCheckConstraintVariables                 = 3816
+	codeCheckConstraintRefersAutoIncrementColumn = 3818
+	codeCheckConstraintRefersUnknownColumn       = 3820
+	codeCheckConstraintNotFound                  = 3821
+	codeCheckConstraintDupName                   = 3822
+	codeDependentByCheckConstraint               = 3959
 )
 
 func init() {
@@ -588,6 +640,21 @@ func init() {
 		codeWrongKeyColumn:               mysql.ErrWrongKeyColumn,
 		codeWrongNameForIndex:            mysql.ErrWrongNameForIndex,
 		codeTooManyFields:                mysql.ErrTooManyFields,
+		codeKeyDoesNotExist:              mysql.ErrKeyDoesNotExits,
+		codePKIndexCantBeInvisible:       mysql.ErrPKIndexCantBeInvisible,
+		codeFunctionalIndexPrimaryKey:    mysql.ErrFunctionalIndexPrimaryKey,
+		codeFunctionalIndexOnField:       mysql.ErrFunctionalIndexOnField,
+		codeUnsupportedExpressionIndex:   mysql.ErrNotSupportedYet,
+		codeDroppedTableNotFound:         mysql.ErrNoSuchTable,
+		codeDroppedTableDataDeleted:      mysql.ErrNoSuchTable,
+
+		codeCheckConstraintFunctionIsNotAllowed:      mysql.ErrCheckConstraintFunctionIsNotAllowed,
+		codeCheckConstraintVariables:                 mysql.ErrCheckConstraintVariables,
+		codeCheckConstraintRefersAutoIncrementColumn: mysql.ErrCheckConstraintRefersAutoIncrementColumn,
+		codeCheckConstraintRefersUnknownColumn:       mysql.ErrCheckConstraintRefersUnknownColumn,
+		codeCheckConstraintNotFound:                  mysql.ErrCheckConstraintNotFound,
+		codeCheckConstraintDupName:                   mysql.ErrCheckConstraintDupName,
+		codeDependentByCheckConstraint:               mysql.ErrDependentByCheckConstraint,
 	}
 	terror.ErrClassToMySQLCodes[terror.ClassDDL] = ddlMySQLErrCodes
 }
@@ -27,6 +27,8 @@ import (
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/infoschema"
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/meta"
 	"github.com/pingcap/tidb/meta/autoid"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
@@ -313,6 +315,10 @@ func columnDefToCol(ctx context.Context, offset int, colDef *ast.ColumnDef) (*ta
 				col.GeneratedStored = v.Stored
 				_, dependColNames := findDependedColumnNames(colDef)
 				col.Dependences = dependColNames
+			case ast.ColumnOptionCheck:
+				// A column check constraint is the same as a table check constraint.
+				constraint := &ast.Constraint{Tp: ast.ConstraintCheck, Expr: v.Expr}
+				constraints = append(constraints, constraint)
 			case ast.ColumnOptionFulltext:
 				// TODO: Support this type.
 			}
@@ -548,7 +554,11 @@ func checkConstraintNames(constraints []*ast.Constraint) error {
 	fkNames := map[string]bool{}
 
 	// Check not empty constraint name whether is duplicated.
+	// The names of check constraints are checked when they are built.
 	for _, constr := range constraints {
+		if constr.Tp == ast.ConstraintCheck {
+			continue
+		}
 		if constr.Tp == ast.ConstraintForeignKey {
 			err := checkDuplicateConstraint(fkNames, constr.Name, true)
 			if err != nil {
@@ -564,6 +574,9 @@ func checkConstraintNames(constraints []*ast.Constraint) error {
 
 	// Set empty constraint names.
 	for _, constr := range constraints {
+		if constr.Tp == ast.ConstraintCheck {
+			continue
+		}
 		if constr.Tp == ast.ConstraintForeignKey {
 			setEmptyConstraintName(fkNames, constr, true)
 		} else {
@@ -587,6 +600,10 @@ func (d *ddl) buildTableInfo(tableName model.CIStr, cols []*table.Column, constr
 		tbInfo.Columns = append(tbInfo.Columns, v.ToInfo())
 	}
 	for _, constr := range constraints {
+		if constr.Tp == ast.ConstraintCheck {
+			// Check constraints are built after all the columns are built.
+			continue
+		}
 		if constr.Tp == ast.ConstraintForeignKey {
 			for _, fk := range tbInfo.ForeignKeys {
 				if fk.Name.L == strings.ToLower(constr.Name) {
@@ -658,6 +675,12 @@ func (d *ddl) buildTableInfo(tableName model.CIStr, cols []*table.Column, constr
 		// set index type.
 		if constr.Option != nil {
 			idxInfo.Comment = constr.Option.Comment
+			if constr.Option.Visibility == ast.IndexVisibilityInvisible {
+				if idxInfo.Primary {
+					return nil, errors.Trace(errPKIndexCantBeInvisible)
+				}
+				idxInfo.Invisible = true
+			}
 			if constr.Option.Tp == model.IndexTypeInvalid {
 				// Use btree as default index type.
 				idxInfo.Tp = model.IndexTypeBtree
@@ -753,6 +776,9 @@ func (d *ddl) CreateTable(ctx context.Context, ident ast.Ident, colDefs []*ast.C
 	if err != nil {
 		return errors.Trace(err)
 	}
+	if err = buildCheckConstraintInfos(ctx, tbInfo, newConstraints); err != nil {
+		return errors.Trace(err)
+	}
 
 	job := &model.Job{
 		SchemaID:   schema.ID,
@@ -823,10 +849,13 @@ func (d *ddl) AlterTable(ctx context.Context, ident ast.Ident, specs []*ast.Alte
 		validSpecs = append(validSpecs, spec)
 	}
 
-	if len(validSpecs) != 1 {
-		// TODO: Hanlde len(validSpecs) == 0.
-		// Now we only allow one schema changing at the same time.
-		return errRunMultiSchemaChanges
+	if len(validSpecs) == 0 {
+		// There are only lock options, nothing to change.
+		return nil
+	}
+	if len(validSpecs) > 1 {
+		err = d.multiSchemaChange(ctx, ident, validSpecs)
+		return errors.Trace(err)
 	}
 
 	for _, spec := range validSpecs {
@@ -846,6 +875,8 @@ func (d *ddl) AlterTable(ctx context.Context, ident ast.Ident, specs []*ast.Alte
 				err = d.CreateIndex(ctx, ident, true, model.NewCIStr(constr.Name), spec.Constraint.Keys, constr.Option)
 			case ast.ConstraintForeignKey:
 				err = d.CreateForeignKey(ctx, ident, model.NewCIStr(constr.Name), spec.Constraint.Keys, spec.Constraint.Refer)
+			case ast.ConstraintCheck:
+				err = d.CreateCheckConstraint(ctx, ident, constr)
 			case ast.ConstraintPrimaryKey:
 				err = ErrUnsupportedModifyPrimaryKey.GenByArgs("add")
 			default:
@@ -853,6 +884,8 @@ func (d *ddl) AlterTable(ctx context.Context, ident ast.Ident, specs []*ast.Alte
 			}
 		case ast.AlterTableDropForeignKey:
 			err = d.DropForeignKey(ctx, ident, model.NewCIStr(spec.Name))
+		case ast.AlterTableDropCheck:
+			err = d.DropCheckConstraint(ctx, ident, model.NewCIStr(spec.Name))
 		case ast.AlterTableModifyColumn:
 			err = d.ModifyColumn(ctx, ident, spec)
 		case ast.AlterTableChangeColumn:
@@ -864,6 +897,8 @@ func (d *ddl) AlterTable(ctx context.Context, ident ast.Ident, specs []*ast.Alte
 			err = d.RenameTable(ctx, ident, newIdent)
 		case ast.AlterTableDropPrimaryKey:
 			err = ErrUnsupportedModifyPrimaryKey.GenByArgs("drop")
+		case ast.AlterTableIndexInvisible:
+			err = d.AlterIndexVisibility(ctx, ident, model.NewCIStr(spec.Name), spec.Visibility)
 		default:
 			// Nothing to do now.
 		}
@@ -879,7 +914,7 @@ func (d *ddl) AlterTable(ctx context.Context, ident ast.Ident, specs []*ast.Alte
 func checkColumnConstraint(constraints []*ast.ColumnOption) error {
 	for _, constraint := range constraints {
 		switch constraint.Tp {
-		case ast.ColumnOptionAutoIncrement, ast.ColumnOptionPrimaryKey, ast.ColumnOptionUniqKey:
+		case ast.ColumnOptionAutoIncrement, ast.ColumnOptionPrimaryKey, ast.ColumnOptionUniqKey, ast.ColumnOptionCheck:
 			return errUnsupportedAddColumn.Gen("unsupported add column constraint - %v", constraint.Tp)
 		}
 	}
@@ -889,12 +924,6 @@ func checkColumnConstraint(constraints []*ast.ColumnOption) error {
 
 // AddColumn will add a new column to the table.
 func (d *ddl) AddColumn(ctx context.Context, ti ast.Ident, spec *ast.AlterTableSpec) error {
-	// Check whether the added column constraints are supported.
-	err := checkColumnConstraint(spec.NewColumn.Options)
-	if err != nil {
-		return errors.Trace(err)
-	}
-
 	is := d.infoHandle.Get()
 	schema, ok := is.SchemaByName(ti.Schema)
 	if !ok {
@@ -905,11 +934,38 @@ func (d *ddl) AddColumn(ctx context.Context, ti ast.Ident, spec *ast.AlterTableS
 		return errors.Trace(infoschema.ErrTableNotExists.GenByArgs(ti.Schema, ti.Name))
 	}
 
+	col, err := checkAndCreateNewColumn(ctx, t.Cols(), spec)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	job := &model.Job{
+		SchemaID:   schema.ID,
+		TableID:    t.Meta().ID,
+		Type:       model.ActionAddColumn,
+		BinlogInfo: &model.HistoryInfo{},
+		Args:       []interface{}{col, spec.Position, 0},
+	}
+
+	err = d.doDDLJob(ctx, job)
+	err = d.callHookOnChanged(err)
+	return errors.Trace(err)
+}
+
+// checkAndCreateNewColumn checks whether the column in spec can be added to a table with the columns cols,
+// and builds the new column.
+func checkAndCreateNewColumn(ctx context.Context, cols []*table.Column, spec *ast.AlterTableSpec) (*table.Column, error) {
+	// Check whether the added column constraints are supported.
+	err := checkColumnConstraint(spec.NewColumn.Options)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
 	// Check whether added column has existed.
 	colName := spec.NewColumn.Name.Name.O
-	col := table.FindCol(t.Cols(), colName)
+	col := table.FindCol(cols, colName)
 	if col != nil {
-		return infoschema.ErrColumnExists.GenByArgs(colName)
+		return nil, infoschema.ErrColumnExists.GenByArgs(colName)
 	}
 
 	// If new column is a generated column, do validation.
@@ -918,34 +974,34 @@ func (d *ddl) AddColumn(ctx context.Context, ti ast.Ident, spec *ast.AlterTableS
 	// generated columns occurring later in table.
 	for _, option := range spec.NewColumn.Options {
 		if option.Tp == ast.ColumnOptionGenerated {
-			referableColNames := make(map[string]struct{}, len(t.Cols()))
-			for _, col := range t.Cols() {
+			referableColNames := make(map[string]struct{}, len(cols))
+			for _, col := range cols {
 				referableColNames[col.Name.L] = struct{}{}
 			}
 			_, dependColNames := findDependedColumnNames(spec.NewColumn)
 			if err = columnNamesCover(referableColNames, dependColNames); err != nil {
-				return errors.Trace(err)
+				return nil, errors.Trace(err)
 			}
 		}
 	}
 
 	if len(colName) > mysql.MaxColumnNameLength {
-		return ErrTooLongIdent.Gen("too long column %s", colName)
+		return nil, ErrTooLongIdent.Gen("too long column %s", colName)
 	}
 
 	// Ingore table constraints now, maybe return error later.
-	// We use length(t.Cols()) as the default offset firstly, we will change the
+	// We use length(cols) as the default offset firstly, we will change the
 	// column's offset later.
-	col, _, err = buildColumnAndConstraint(ctx, len(t.Cols()), spec.NewColumn)
+	col, _, err = buildColumnAndConstraint(ctx, len(cols), spec.NewColumn)
 	if err != nil {
-		return errors.Trace(err)
+		return nil, errors.Trace(err)
 	}
 	col.OriginDefaultValue = col.DefaultValue
 	if col.OriginDefaultValue == nil && mysql.HasNotNullFlag(col.Flag) {
 		zeroVal := table.GetZeroValue(col.ToInfo())
 		col.OriginDefaultValue, err = zeroVal.ToString()
 		if err != nil {
-			return errors.Trace(err)
+			return nil, errors.Trace(err)
 		}
 	}
 
@@ -953,18 +1009,7 @@ func (d *ddl) AddColumn(ctx context.Context, ti ast.Ident, spec *ast.AlterTableS
 		(col.Tp == mysql.TypeTimestamp || col.Tp == mysql.TypeDatetime) {
 		col.OriginDefaultValue = time.Now().Format(types.TimeFormat)
 	}
-
-	job := &model.Job{
-		SchemaID:   schema.ID,
-		TableID:    t.Meta().ID,
-		Type:       model.ActionAddColumn,
-		BinlogInfo: &model.HistoryInfo{},
-		Args:       []interface{}{col, spec.Position, 0},
-	}
-
-	err = d.doDDLJob(ctx, job)
-	err = d.callHookOnChanged(err)
-	return errors.Trace(err)
+	return col, nil
 }
 
 // DropColumn will drop a column from the table, now we don't support drop the column with index covered.
@@ -1206,6 +1251,12 @@ func (d *ddl) getModifiableColumnJob(ctx context.Context, ident ast.Ident, origi
 	if err = checkModifyGeneratedColumn(t.Cols(), col, newCol); err != nil {
 		return nil, errors.Trace(err)
 	}
+	// The column which is used by check constraints can't be renamed.
+	if newCol.Name.L != col.Name.L {
+		if err = checkColumnReferredByCheckConstraint(t.Meta(), col.Name); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
 
 	job := &model.Job{
 		SchemaID:   schema.ID,
@@ -1359,6 +1410,90 @@ func (d *ddl) TruncateTable(ctx context.Context, ti ast.Ident) error {
 	return errors.Trace(err)
 }
 
+// RecoverTable recovers the table dropped or truncated by the latest DDL job on it, the recovered table
+// keeps its original table ID, so the data which hasn't been deleted by GC can be accessed again.
+// If newName is not empty, the table is recovered with the new name.
+func (d *ddl) RecoverTable(ctx context.Context, ti ast.Ident, newName model.CIStr) error {
+	is := d.GetInformationSchema()
+	schema, ok := is.SchemaByName(ti.Schema)
+	if !ok {
+		return infoschema.ErrDatabaseNotExists.GenByArgs(ti.Schema)
+	}
+	dropJob, tblInfo, err := d.getDroppedTableJob(schema.ID, ti.Name)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if dropJob == nil {
+		return ErrDroppedTableNotFound.GenByArgs(ti.Name)
+	}
+	if tb, ok := is.TableByID(tblInfo.ID); ok {
+		return infoschema.ErrTableExists.GenByArgs(tb.Meta().Name)
+	}
+	if newName.L != "" {
+		tblInfo.Name = newName
+	}
+	if is.TableExists(ti.Schema, tblInfo.Name) {
+		return infoschema.ErrTableExists.GenByArgs(tblInfo.Name)
+	}
+
+	// The auto ID is deleted with the table, so we get it from the snapshot of the transaction which
+	// finished the job, the table isn't dropped yet in this snapshot.
+	snapshot, err := d.store.GetSnapshot(kv.NewVersion(uint64(dropJob.LastUpdateTS)))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	autoID, err := meta.NewSnapshotMeta(snapshot).GetAutoTableID(schema.ID, tblInfo.ID)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	job := &model.Job{
+		SchemaID:   schema.ID,
+		TableID:    tblInfo.ID,
+		Type:       model.ActionRecoverTable,
+		BinlogInfo: &model.HistoryInfo{},
+		Args:       []interface{}{tblInfo, autoID, dropJob.ID},
+	}
+	err = d.doDDLJob(ctx, job)
+	err = d.callHookOnChanged(err)
+	return errors.Trace(err)
+}
+
+// getDroppedTableJob finds the latest history job which drops or truncates the table of the schema,
+// and returns the job with the table information before it's dropped or truncated.
+func (d *ddl) getDroppedTableJob(schemaID int64, tableName model.CIStr) (*model.Job, *model.TableInfo, error) {
+	var jobs []*model.Job
+	err := kv.RunInNewTxn(d.store, false, func(txn kv.Transaction) error {
+		var err error
+		jobs, err = meta.NewMeta(txn).GetAllHistoryDDLJobs()
+		return errors.Trace(err)
+	})
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+
+	var dropJob *model.Job
+	for _, job := range jobs {
+		if job.Type != model.ActionDropTable && job.Type != model.ActionTruncateTable {
+			continue
+		}
+		if !job.IsSynced() || job.SchemaID != schemaID || job.BinlogInfo == nil ||
+			job.BinlogInfo.TableInfo == nil || job.BinlogInfo.TableInfo.Name.L != tableName.L {
+			continue
+		}
+		if dropJob == nil || job.ID > dropJob.ID {
+			dropJob = job
+		}
+	}
+	if dropJob == nil {
+		return nil, nil, nil
+	}
+	tblInfo := dropJob.BinlogInfo.TableInfo.Clone()
+	// The table information of truncate table job is the new table's.
+	tblInfo.ID = dropJob.TableID
+	return dropJob, tblInfo, nil
+}
+
 func (d *ddl) RenameTable(ctx context.Context, oldIdent, newIdent ast.Ident) error {
 	is := d.GetInformationSchema()
 	oldSchema, ok := is.SchemaByName(oldIdent.Schema)
@@ -1390,12 +1525,16 @@ func (d *ddl) RenameTable(ctx context.Context, oldIdent, newIdent ast.Ident) err
 	return errors.Trace(err)
 }
 
-func getAnonymousIndex(t table.Table, colName model.CIStr) model.CIStr {
+func getAnonymousIndex(indices []*model.IndexInfo, idxColNames []*ast.IndexColName) model.CIStr {
+	colName := model.NewCIStr("functional_index")
+	if idxColNames[0].Column != nil {
+		colName = idxColNames[0].Column.Name
+	}
 	id := 2
-	l := len(t.Indices())
+	l := len(indices)
 	indexName := colName
 	for i := 0; i < l; i++ {
-		if t.Indices()[i].Meta().Name.L == indexName.L {
+		if indices[i].Name.L == indexName.L {
 			indexName = model.NewCIStr(fmt.Sprintf("%s_%d", colName.O, id))
 			i = -1
 			id++
@@ -1418,19 +1557,25 @@ func (d *ddl) CreateIndex(ctx context.Context, ti ast.Ident, unique bool, indexN
 
 	// Deal with anonymous index.
 	if len(indexName.L) == 0 {
-		indexName = getAnonymousIndex(t, idxColNames[0].Column.Name)
+		indexName = getAnonymousIndex(t.Meta().Indices, idxColNames)
 	}
 
 	if indexInfo := findIndexByName(indexName.L, t.Meta().Indices); indexInfo != nil {
 		return errDupKeyName.Gen("index already exist %s", indexName)
 	}
 
+	// The expression parts are stored by hidden virtual generated columns.
+	idxColNames, hiddenCols, err := buildHiddenColumnsForIndex(ctx, t.Meta(), indexName, idxColNames)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
 	job := &model.Job{
 		SchemaID:   schema.ID,
 		TableID:    t.Meta().ID,
 		Type:       model.ActionAddIndex,
 		BinlogInfo: &model.HistoryInfo{},
-		Args:       []interface{}{unique, indexName, idxColNames, indexOption},
+		Args:       []interface{}{unique, indexName, idxColNames, indexOption, hiddenCols},
 	}
 
 	err = d.doDDLJob(ctx, job)
@@ -1516,6 +1661,64 @@ func (d *ddl) DropForeignKey(ctx context.Context, ti ast.Ident, fkName model.CIS
 	return errors.Trace(err)
 }
 
+func (d *ddl) CreateCheckConstraint(ctx context.Context, ti ast.Ident, constr *ast.Constraint) error {
+	is := d.infoHandle.Get()
+	schema, ok := is.SchemaByName(ti.Schema)
+	if !ok {
+		return infoschema.ErrDatabaseNotExists.GenByArgs(ti.Schema)
+	}
+
+	t, err := is.TableByName(ti.Schema, ti.Name)
+	if err != nil {
+		return errors.Trace(infoschema.ErrTableNotExists.GenByArgs(ti.Schema, ti.Name))
+	}
+
+	checkInfo, err := buildCheckConstraintInfo(ctx, t.Meta(), constr)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	job := &model.Job{
+		SchemaID:   schema.ID,
+		TableID:    t.Meta().ID,
+		Type:       model.ActionAddCheckConstraint,
+		BinlogInfo: &model.HistoryInfo{},
+		Args:       []interface{}{checkInfo},
+	}
+
+	err = d.doDDLJob(ctx, job)
+	err = d.callHookOnChanged(err)
+	return errors.Trace(err)
+}
+
+func (d *ddl) DropCheckConstraint(ctx context.Context, ti ast.Ident, name model.CIStr) error {
+	is := d.infoHandle.Get()
+	schema, ok := is.SchemaByName(ti.Schema)
+	if !ok {
+		return infoschema.ErrDatabaseNotExists.GenByArgs(ti.Schema)
+	}
+
+	t, err := is.TableByName(ti.Schema, ti.Name)
+	if err != nil {
+		return errors.Trace(infoschema.ErrTableNotExists.GenByArgs(ti.Schema, ti.Name))
+	}
+	if findCheckConstraint(t.Meta(), name.L) == nil {
+		return ErrCheckConstraintNotFound.GenByArgs(name)
+	}
+
+	job := &model.Job{
+		SchemaID:   schema.ID,
+		TableID:    t.Meta().ID,
+		Type:       model.ActionDropCheckConstraint,
+		BinlogInfo: &model.HistoryInfo{},
+		Args:       []interface{}{name},
+	}
+
+	err = d.doDDLJob(ctx, job)
+	err = d.callHookOnChanged(err)
+	return errors.Trace(err)
+}
+
 func (d *ddl) DropIndex(ctx context.Context, ti ast.Ident, indexName model.CIStr) error {
 	is := d.infoHandle.Get()
 	schema, ok := is.SchemaByName(ti.Schema)
@@ -1544,6 +1747,43 @@ func (d *ddl) DropIndex(ctx context.Context, ti ast.Ident, indexName model.CIStr
 	return errors.Trace(err)
 }
 
+// AlterIndexVisibility makes the index visible or invisible to the optimizer.
+func (d *ddl) AlterIndexVisibility(ctx context.Context, ti ast.Ident, indexName model.CIStr, visibility ast.IndexVisibility) error {
+	is := d.infoHandle.Get()
+	schema, ok := is.SchemaByName(ti.Schema)
+	if !ok {
+		return errors.Trace(infoschema.ErrDatabaseNotExists)
+	}
+	t, err := is.TableByName(ti.Schema, ti.Name)
+	if err != nil {
+		return errors.Trace(infoschema.ErrTableNotExists.GenByArgs(ti.Schema, ti.Name))
+	}
+
+	indexInfo := findIndexByName(indexName.L, t.Meta().Indices)
+	if indexInfo == nil {
+		return errKeyDoesNotExist.GenByArgs(indexName, ti.Name)
+	}
+	invisible := visibility == ast.IndexVisibilityInvisible
+	if indexInfo.Primary && invisible {
+		return errors.Trace(errPKIndexCantBeInvisible)
+	}
+	if indexInfo.Invisible == invisible {
+		return nil
+	}
+
+	job := &model.Job{
+		SchemaID:   schema.ID,
+		TableID:    t.Meta().ID,
+		Type:       model.ActionAlterIndexVisibility,
+		BinlogInfo: &model.HistoryInfo{},
+		Args:       []interface{}{indexName, invisible},
+	}
+
+	err = d.doDDLJob(ctx, job)
+	err = d.callHookOnChanged(err)
+	return errors.Trace(err)
+}
+
 // findCol finds column in cols by name.
 func findCol(cols []*model.ColumnInfo, name string) *model.ColumnInfo {
 	name = strings.ToLower(name)
@@ -1565,6 +1805,9 @@ func isDroppableColumn(tblInfo *model.TableInfo, colName model.CIStr) error {
 			}
 		}
 	}
+	if err := checkColumnReferredByCheckConstraint(tblInfo, colName); err != nil {
+		return errors.Trace(err)
+	}
 	if len(tblInfo.Columns) == 1 {
 		return ErrCantRemoveAllFields.Gen("can't drop only column %s in table %s",
 			colName, tblInfo.Name)
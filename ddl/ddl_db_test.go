@@ -1115,6 +1115,7 @@ func (s *testDBSuite) TestCreateTableWithLike(c *C) {
 	// for the same database
 	s.tk.MustExec("use test")
 	s.tk.MustExec("create table tt(id int primary key)")
+	s.tk.MustExec("insert into tt values (1)")
 	s.tk.MustExec("create table t (c1 int not null auto_increment, c2 int, constraint cc foreign key (c2) references tt(id), primary key(c1)) auto_increment = 10")
 	s.tk.MustExec("insert into t set c2=1")
 	s.tk.MustExec("create table t1 like test.t")
@@ -1502,3 +1503,261 @@ func (s *testDBSuite) TestGeneratedColumnDDL(c *C) {
 	result = s.tk.MustQuery(`DESC test_gv_ddl`)
 	result.Check(testkit.Rows(`a int(11) YES  <nil> `, `b bigint(20) YES  <nil> VIRTUAL GENERATED`, `cnew bigint(20) YES  <nil> `))
 }
+
+func (s *testDBSuite) TestMultiSchemaChange(c *C) {
+	defer testleak.AfterTest(c)()
+	s.tk = testkit.NewTestKit(c, s.store)
+	s.tk.MustExec("use " + s.schemaName)
+
+	s.tk.MustExec("create table t_multi (a int, b int, key idx_b(b))")
+	s.tk.MustExec("insert into t_multi values (1, 1), (2, 2)")
+	s.tk.MustExec("alter table t_multi add column c int default 3, add column d int first, add index idx_c(c), add unique index idx_a(a)")
+	s.tk.MustQuery("select * from t_multi").Check(testkit.Rows("<nil> 1 1 3", "<nil> 2 2 3"))
+	s.tk.MustQuery("select a from t_multi where c = 3").Check(testkit.Rows("1", "2"))
+	s.tk.MustQuery("select c from t_multi where a = 2").Check(testkit.Rows("3"))
+	t := s.testGetTable(c, "t_multi")
+	c.Assert(t.Meta().Indices, HasLen, 3)
+	for i, col := range t.Cols() {
+		c.Assert(col.Offset, Equals, i)
+	}
+
+	// Add a column after another added column.
+	s.tk.MustExec("alter table t_multi add column e int default 5 after c, add column f int default 6 after e")
+	s.tk.MustQuery("select * from t_multi").Check(testkit.Rows("<nil> 1 1 3 5 6", "<nil> 2 2 3 5 6"))
+
+	// Drop an index together with its column.
+	s.tk.MustExec("alter table t_multi drop index idx_b, drop column b, drop column d")
+	s.tk.MustQuery("select * from t_multi").Check(testkit.Rows("1 3 5 6", "2 3 5 6"))
+	s.tk.MustExec("insert into t_multi values (3, 4, 5, 6)")
+	s.tk.MustQuery("select a from t_multi where c = 4").Check(testkit.Rows("3"))
+	t = s.testGetTable(c, "t_multi")
+	c.Assert(t.Meta().Indices, HasLen, 2)
+	c.Assert(t.Cols(), HasLen, 4)
+
+	// The specs are validated before the job runs.
+	s.testErrorCode(c, "alter table t_multi add column g int, add column g int", tmysql.ErrDupFieldName)
+	s.testErrorCode(c, "alter table t_multi add index idx_x(a), add index idx_x(c)", tmysql.ErrDupKeyName)
+	s.testErrorCode(c, "alter table t_multi drop column e, drop column z", tmysql.ErrCantDropFieldOrKey)
+	s.testErrorCode(c, "alter table t_multi add column g int, add index idx_g(h)", tmysql.ErrKeyColumnDoesNotExits)
+	_, err := s.tk.Exec("alter table t_multi add column g int, drop column g")
+	c.Assert(err, NotNil)
+	_, err = s.tk.Exec("alter table t_multi drop column e, drop column c")
+	c.Assert(err, NotNil)
+	_, err = s.tk.Exec("alter table t_multi add column g int, modify column a bigint")
+	c.Assert(err, NotNil)
+	s.tk.MustQuery("select * from t_multi where a = 3").Check(testkit.Rows("3 4 5 6"))
+
+	// All the changes are rolled back if one of them fails.
+	s.testErrorCode(c, "alter table t_multi add column g int default 7, add index idx_g(g), add unique index idx_e(e)", tmysql.ErrDupEntry)
+	t = s.testGetTable(c, "t_multi")
+	c.Assert(t.Meta().Columns, HasLen, 4)
+	c.Assert(t.Meta().Indices, HasLen, 2)
+	s.tk.MustExec("alter table t_multi add column g int default 7, add index idx_g(g)")
+	s.tk.MustQuery("select a from t_multi where g = 7").Check(testkit.Rows("1", "2", "3"))
+	s.tk.MustExec("drop table t_multi")
+
+	// The specs are checked against the original table, a column can be dropped before its index.
+	s.tk.MustExec("create table t_multi (a int, b int, c int, key i(b))")
+	s.tk.MustExec("insert into t_multi values (1, 2, 3)")
+	s.tk.MustExec("alter table t_multi drop column b, drop index i")
+	s.tk.MustQuery("select * from t_multi").Check(testkit.Rows("1 3"))
+	_, err = s.tk.Exec("alter table t_multi drop column c, add index i(c)")
+	c.Assert(err, NotNil)
+	_, err = s.tk.Exec("alter table t_multi drop column a, drop column c")
+	c.Assert(err, NotNil)
+	s.tk.MustExec("alter table t_multi lock = none")
+	s.tk.MustExec("drop table t_multi")
+}
+
+func (s *testDBSuite) TestExpressionIndex(c *C) {
+	defer testleak.AfterTest(c)()
+	s.tk = testkit.NewTestKit(c, s.store)
+	s.tk.MustExec("use " + s.schemaName)
+
+	s.tk.MustExec("create table t_expr (a int, b varchar(20))")
+	s.tk.MustExec("insert into t_expr values (1, 'Alice'), (2, 'BOB'), (3, 'bob')")
+	s.tk.MustExec("create index idx_lower on t_expr ((lower(b)))")
+	// The hidden column is invisible to the users.
+	s.tk.MustQuery("select * from t_expr where a = 1").Check(testkit.Rows("1 Alice"))
+	c.Assert(s.tk.MustQuery("show columns from t_expr").Rows(), HasLen, 2)
+	s.tk.MustQuery("show create table t_expr").Check(testkit.Rows("t_expr CREATE TABLE `t_expr` (\n" +
+		"  `a` int(11) DEFAULT NULL,\n" +
+		"  `b` varchar(20) DEFAULT NULL,\n" +
+		"  KEY `idx_lower` ((lower(b)))\n" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_bin"))
+
+	s.tk.MustQuery("select a from t_expr where lower(b) = 'bob' order by a").Check(testkit.Rows("2", "3"))
+	s.tk.MustQuery("select a from t_expr use index(idx_lower) where lower(b) = 'alice' and a > 0").Check(testkit.Rows("1"))
+
+	// The index is maintained by DML.
+	s.tk.MustExec("insert into t_expr values (4, 'Bob')")
+	s.tk.MustExec("insert into t_expr (a, b) values (5, 'carol')")
+	s.tk.MustExec("update t_expr set b = 'Carol' where a = 1")
+	s.tk.MustExec("delete from t_expr where a = 3")
+	s.tk.MustQuery("select a from t_expr use index(idx_lower) where lower(b) = 'bob' order by a").Check(testkit.Rows("2", "4"))
+	s.tk.MustQuery("select a from t_expr use index(idx_lower) where lower(b) = 'carol' order by a").Check(testkit.Rows("1", "5"))
+
+	// A unique expression index checks the duplicated values.
+	s.testErrorCode(c, "alter table t_expr add unique index idx_u ((a % 3))", tmysql.ErrDupEntry)
+	s.tk.MustExec("alter table t_expr add unique index idx_u ((a + 1), a)")
+	s.testErrorCode(c, "insert into t_expr values (5, 'dave')", tmysql.ErrDupEntry)
+	t := s.testGetTable(c, "t_expr")
+	c.Assert(t.Meta().Columns, HasLen, 4)
+
+	s.testErrorCode(c, "create index idx_a on t_expr ((a))", tmysql.ErrFunctionalIndexOnField)
+	s.testErrorCode(c, "create index idx_c on t_expr ((c + 1))", tmysql.ErrBadField)
+	s.testErrorCode(c, "alter table t_expr drop column b", tmysql.ErrDependentByGeneratedColumn)
+	s.testErrorCode(c, "create table t_expr_1 (a int, key ((a + 1)))", tmysql.ErrNotSupportedYet)
+	s.testErrorCode(c, "create table t_expr_1 (a int, primary key ((a + 1)))", tmysql.ErrFunctionalIndexPrimaryKey)
+
+	// The hidden columns are dropped with the index.
+	s.tk.MustExec("drop index idx_lower on t_expr")
+	s.tk.MustExec("alter table t_expr drop index idx_u")
+	t = s.testGetTable(c, "t_expr")
+	c.Assert(t.Meta().Columns, HasLen, 2)
+	s.tk.MustExec("alter table t_expr drop column b")
+	s.tk.MustExec("drop table t_expr")
+}
+
+func (s *testDBSuite) TestInvisibleIndex(c *C) {
+	defer testleak.AfterTest(c)()
+	s.tk = testkit.NewTestKit(c, s.store)
+	s.tk.MustExec("use " + s.schemaName)
+
+	s.tk.MustExec("create table t_invisible (a int, b int, primary key (a, b), unique key idx_b(b) invisible)")
+	s.tk.MustExec("insert into t_invisible values (1, 1), (2, 2)")
+	// The invisible index is still maintained.
+	s.testErrorCode(c, "insert into t_invisible values (3, 1)", tmysql.ErrDupEntry)
+	s.tk.MustQuery("select a from t_invisible where b = 1").Check(testkit.Rows("1"))
+
+	s.tk.MustExec("alter table t_invisible alter index idx_b visible")
+	t := s.testGetTable(c, "t_invisible")
+	c.Assert(t.Meta().Indices[1].Invisible, IsFalse)
+	s.tk.MustQuery("select a from t_invisible where b = 2").Check(testkit.Rows("2"))
+	s.tk.MustExec("alter table t_invisible alter index idx_b invisible")
+	t = s.testGetTable(c, "t_invisible")
+	c.Assert(t.Meta().Indices[1].Invisible, IsTrue)
+	s.tk.MustQuery("show create table t_invisible").Check(testkit.Rows("t_invisible CREATE TABLE `t_invisible` (\n" +
+		"  `a` int(11) NOT NULL,\n" +
+		"  `b` int(11) NOT NULL,\n" +
+		"  PRIMARY KEY (`a`,`b`),\n" +
+		"  UNIQUE KEY `idx_b` (`b`) /*!80000 INVISIBLE */\n" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_bin"))
+
+	s.tk.MustExec("create index idx_a on t_invisible (a) invisible")
+	t = s.testGetTable(c, "t_invisible")
+	c.Assert(t.Meta().Indices[2].Invisible, IsTrue)
+	s.testErrorCode(c, "alter table t_invisible alter index `primary` invisible", tmysql.ErrPKIndexCantBeInvisible)
+	s.testErrorCode(c, "alter table t_invisible alter index idx_z invisible", tmysql.ErrKeyDoesNotExits)
+	s.tk.MustExec("drop table t_invisible")
+}
+
+func (s *testDBSuite) TestRecoverTable(c *C) {
+	defer testleak.AfterTest(c)()
+	s.tk = testkit.NewTestKit(c, s.store)
+	s.tk.MustExec("use " + s.schemaName)
+	// Keep the dropped data from being deleted by the delete-range emulator.
+	ddl.SetEmulatorGCEnable(false)
+	defer ddl.SetEmulatorGCEnable(true)
+
+	s.tk.MustExec("create table t_recover (a int auto_increment primary key, b int, index idx_b(b))")
+	s.tk.MustExec("insert into t_recover (b) values (1), (2)")
+	tblID := s.testGetTable(c, "t_recover").Meta().ID
+	s.tk.MustExec("drop table t_recover")
+	s.tk.MustExec("recover table t_recover")
+	c.Assert(s.testGetTable(c, "t_recover").Meta().ID, Equals, tblID)
+	// The delete-range record is removed after the table is recovered.
+	s.tk.MustQuery(fmt.Sprintf("select count(*) from mysql.gc_delete_range where element_id = %d", tblID)).Check(testkit.Rows("0"))
+	s.tk.MustQuery("select a, b from t_recover use index(idx_b) where b > 0").Check(testkit.Rows("1 1", "2 2"))
+	// The auto ID is recovered too.
+	s.tk.MustExec("insert into t_recover (b) values (3)")
+	s.tk.MustQuery("select a > 2 from t_recover where b = 3").Check(testkit.Rows("1"))
+
+	// The truncated table is recovered with a new name.
+	s.tk.MustExec("truncate table t_recover")
+	_, err := s.tk.Exec("recover table t_recover")
+	c.Assert(terror.ErrorEqual(err, infoschema.ErrTableExists), IsTrue, Commentf("err %v", err))
+	s.tk.MustExec("flashback table t_recover to t_recover2")
+	c.Assert(s.testGetTable(c, "t_recover2").Meta().ID, Equals, tblID)
+	s.tk.MustQuery("select b from t_recover2 order by b").Check(testkit.Rows("1", "2", "3"))
+	s.tk.MustQuery("select count(*) from t_recover").Check(testkit.Rows("0"))
+	_, err = s.tk.Exec("flashback table t_recover to t_recover3")
+	c.Assert(terror.ErrorEqual(err, infoschema.ErrTableExists), IsTrue, Commentf("err %v", err))
+	_, err = s.tk.Exec("recover table t_recover_not_exist")
+	c.Assert(terror.ErrorEqual(err, ddl.ErrDroppedTableNotFound), IsTrue, Commentf("err %v", err))
+
+	// The table can't be recovered if a GC round starts after it's dropped.
+	s.tk.MustExec("drop table t_recover2")
+	lastRunTime := time.Now().Add(time.Minute).Format(ddl.GCTimeFormat)
+	s.tk.MustExec(fmt.Sprintf(`insert into mysql.tidb values ("tikv_gc_last_run_time", "%s", "")`, lastRunTime))
+	_, err = s.tk.Exec("recover table t_recover2")
+	c.Assert(terror.ErrorEqual(err, ddl.ErrDroppedTableDataDeleted), IsTrue, Commentf("err %v", err))
+	s.tk.MustExec(`delete from mysql.tidb where VARIABLE_NAME = "tikv_gc_last_run_time"`)
+	s.tk.MustExec("recover table t_recover2")
+
+	// The table can't be recovered after its data is deleted.
+	ddl.SetEmulatorGCEnable(true)
+	s.tk.MustExec("drop table t_recover2")
+	for i := 0; i < 100; i++ {
+		rows := s.tk.MustQuery("select count(*) from mysql.gc_delete_range").Rows()
+		if rows[0][0] == "0" {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	_, err = s.tk.Exec("recover table t_recover2")
+	c.Assert(terror.ErrorEqual(err, ddl.ErrDroppedTableDataDeleted), IsTrue, Commentf("err %v", err))
+	s.tk.MustExec("drop table t_recover")
+}
+
+func (s *testDBSuite) TestCheckConstraint(c *C) {
+	defer testleak.AfterTest(c)()
+	s.tk = testkit.NewTestKit(c, s.store)
+	s.tk.MustExec("use " + s.schemaName)
+
+	s.tk.MustExec("create table t_check (a int check (a > 0), b int, c int auto_increment key, constraint chk_b check (b < 10))")
+	t := s.testGetTable(c, "t_check")
+	c.Assert(t.Meta().CheckConstraints, HasLen, 2)
+	c.Assert(t.Meta().CheckConstraints[0].Name.O, Equals, "chk_b")
+	c.Assert(t.Meta().CheckConstraints[1].Name.O, Equals, "t_check_chk_1")
+	s.tk.MustQuery("show create table t_check").Check(testkit.Rows("t_check CREATE TABLE `t_check` (\n" +
+		"  `a` int(11) DEFAULT NULL,\n" +
+		"  `b` int(11) DEFAULT NULL,\n" +
+		"  `c` int(11) NOT NULL AUTO_INCREMENT,\n" +
+		"  PRIMARY KEY (`c`),\n" +
+		"  CONSTRAINT `chk_b` CHECK (b < 10),\n" +
+		"  CONSTRAINT `t_check_chk_1` CHECK (a > 0)\n" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_bin"))
+	s.testErrorCode(c, "insert into t_check (a, b) values (0, 1)", tmysql.ErrCheckConstraintViolated)
+
+	// Invalid check constraints.
+	s.testErrorCode(c, "alter table t_check add constraint chk_b check (b > 0)", tmysql.ErrCheckConstraintDupName)
+	s.testErrorCode(c, "alter table t_check add check (d > 0)", tmysql.ErrCheckConstraintRefersUnknownColumn)
+	s.testErrorCode(c, "alter table t_check add check (c > 0)", tmysql.ErrCheckConstraintRefersAutoIncrementColumn)
+	s.testErrorCode(c, "alter table t_check add check (a > rand())", tmysql.ErrCheckConstraintFunctionIsNotAllowed)
+	s.testErrorCode(c, "alter table t_check add check (a > (select 1))", tmysql.ErrCheckConstraintFunctionIsNotAllowed)
+	s.testErrorCode(c, "alter table t_check add check (a > @a)", tmysql.ErrCheckConstraintVariables)
+
+	// The existing rows are validated when a check constraint is added.
+	s.tk.MustExec("insert into t_check (a, b) values (1, 1), (5, 5)")
+	s.testErrorCode(c, "alter table t_check add constraint chk_ab check (a + b < 10)", tmysql.ErrCheckConstraintViolated)
+	t = s.testGetTable(c, "t_check")
+	c.Assert(t.Meta().CheckConstraints, HasLen, 2)
+	s.tk.MustExec("alter table t_check add constraint chk_ab check (a + b <= 10)")
+	t = s.testGetTable(c, "t_check")
+	c.Assert(t.Meta().CheckConstraints, HasLen, 3)
+	c.Assert(t.Meta().CheckConstraints[2].State, Equals, model.StatePublic)
+	s.testErrorCode(c, "insert into t_check (a, b) values (6, 5)", tmysql.ErrCheckConstraintViolated)
+
+	// The columns used by check constraints can't be dropped or renamed.
+	s.testErrorCode(c, "alter table t_check drop column b", tmysql.ErrDependentByCheckConstraint)
+	s.testErrorCode(c, "alter table t_check change b d int", tmysql.ErrDependentByCheckConstraint)
+	s.tk.MustExec("alter table t_check modify b bigint")
+
+	s.tk.MustExec("alter table t_check drop check chk_ab")
+	s.tk.MustExec("alter table t_check drop check chk_b")
+	s.testErrorCode(c, "alter table t_check drop check chk_b", tmysql.ErrCheckConstraintNotFound)
+	s.tk.MustExec("alter table t_check drop column b")
+	s.tk.MustExec("insert into t_check (a) values (6)")
+	s.tk.MustExec("drop table t_check")
+}
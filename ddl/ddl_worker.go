@@ -126,7 +126,8 @@ func (d *ddl) updateDDLJob(t *meta.Meta, job *model.Job, updateTS uint64) error
 // If the DDL job need to handle in background, it will prepare a background job.
 func (d *ddl) finishDDLJob(t *meta.Meta, job *model.Job) (err error) {
 	switch job.Type {
-	case model.ActionDropSchema, model.ActionDropTable, model.ActionTruncateTable, model.ActionDropIndex:
+	case model.ActionDropSchema, model.ActionDropTable, model.ActionTruncateTable, model.ActionDropIndex,
+		model.ActionMultiSchemaChange:
 		if job.Version <= currentVersion {
 			err = d.delRangeManager.addDelRangeJob(job)
 		} else {
@@ -135,6 +136,13 @@ func (d *ddl) finishDDLJob(t *meta.Meta, job *model.Job) (err error) {
 		if err != nil {
 			return errors.Trace(err)
 		}
+	case model.ActionRecoverTable:
+		if job.IsSynced() {
+			err = d.removeRecoveredDelRange(job)
+			if err != nil {
+				return errors.Trace(err)
+			}
+		}
 	}
 
 	_, err = t.DeQueueDDLJob()
@@ -273,16 +281,26 @@ func (d *ddl) runDDLJob(t *meta.Meta, job *model.Job) (ver int64) {
 		ver, err = d.onCreateIndex(t, job)
 	case model.ActionDropIndex:
 		ver, err = d.onDropIndex(t, job)
+	case model.ActionAlterIndexVisibility:
+		ver, err = d.onAlterIndexVisibility(t, job)
 	case model.ActionAddForeignKey:
 		ver, err = d.onCreateForeignKey(t, job)
 	case model.ActionDropForeignKey:
 		ver, err = d.onDropForeignKey(t, job)
+	case model.ActionAddCheckConstraint:
+		ver, err = d.onAddCheckConstraint(t, job)
+	case model.ActionDropCheckConstraint:
+		ver, err = d.onDropCheckConstraint(t, job)
 	case model.ActionTruncateTable:
 		ver, err = d.onTruncateTable(t, job)
+	case model.ActionRecoverTable:
+		ver, err = d.onRecoverTable(t, job)
 	case model.ActionRenameTable:
 		ver, err = d.onRenameTable(t, job)
 	case model.ActionSetDefaultValue:
 		ver, err = d.onSetDefaultValue(t, job)
+	case model.ActionMultiSchemaChange:
+		ver, err = d.onMultiSchemaChange(t, job)
 	default:
 		// Invalid job, cancel it.
 		job.State = model.JobCancelled
@@ -18,6 +18,8 @@ import (
 	"encoding/hex"
 	"fmt"
 	"math"
+	"sync/atomic"
+	"time"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/juju/errors"
@@ -29,6 +31,7 @@ import (
 	"github.com/pingcap/tidb/store/tikv/oracle"
 	"github.com/pingcap/tidb/tablecodec"
 	"github.com/pingcap/tidb/util/sqlexec"
+	"github.com/pingcap/tidb/util/types"
 )
 
 const (
@@ -36,6 +39,15 @@ const (
 	loadDeleteRangeSQL     = `SELECT job_id, element_id, start_key, end_key FROM mysql.gc_delete_range WHERE ts < %v ORDER BY ts`
 	completeDeleteRangeSQL = `DELETE FROM mysql.gc_delete_range WHERE job_id = %d AND element_id = %d`
 	updateDeleteRangeSQL   = `UPDATE mysql.gc_delete_range SET start_key = "%s" WHERE job_id = %d AND element_id = %d AND start_key = "%s"`
+	loadDeleteRangeTSSQL   = `SELECT ts FROM mysql.gc_delete_range WHERE job_id = %d AND element_id = %d`
+	updateDeleteRangeTSSQL = `UPDATE mysql.gc_delete_range SET ts = %d WHERE job_id = %d AND element_id = %d`
+	loadGCLastRunTimeSQL   = `SELECT VARIABLE_VALUE FROM mysql.tidb WHERE VARIABLE_NAME = "tikv_gc_last_run_time"`
+
+	// GCTimeFormat is the time format used by the GC worker to save time into mysql.tidb.
+	GCTimeFormat = "20060102-15:04:05 -0700 MST"
+
+	// holdDeleteRangeTS is the ts of the held delete-range records, they are never loaded by GC.
+	holdDeleteRangeTS = math.MaxInt64
 
 	delBatchSize int = 65536
 	delBackLog       = 128
@@ -44,6 +56,13 @@ const (
 type delRangeManager interface {
 	// addDelRangeJob add a DDL job into gc_delete_range table.
 	addDelRangeJob(job *model.Job) error
+	// holdDelRangeJob keeps the data of an element of the DDL job from being deleted by GC.
+	// It returns false if the data of the element may have been deleted. It can be called again
+	// on the held element.
+	holdDelRangeJob(jobID, elementID int64) (bool, error)
+	// removeDelRangeJob removes the delete-range record of an element of the DDL job from
+	// gc_delete_range table, it does nothing if the record doesn't exist.
+	removeDelRangeJob(jobID, elementID int64) error
 	start()
 	clear()
 }
@@ -92,6 +111,44 @@ func (dr *delRange) addDelRangeJob(job *model.Job) error {
 	return nil
 }
 
+// holdDelRangeJob implements delRangeManager interface.
+func (dr *delRange) holdDelRangeJob(jobID, elementID int64) (bool, error) {
+	resource, err := dr.ctxPool.Get()
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	defer dr.ctxPool.Put(resource)
+	ctx := resource.(context.Context)
+	ctx.GetSessionVars().SetStatusFlag(mysql.ServerStatusAutocommit, true)
+	ctx.GetSessionVars().InRestrictedSQL = true
+
+	held, err := holdInDeleteRangeTable(ctx, jobID, elementID)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	log.Infof("[ddl] hold job (%d,%d) in delete-range table, held %t", jobID, elementID, held)
+	return held, nil
+}
+
+// removeDelRangeJob implements delRangeManager interface.
+func (dr *delRange) removeDelRangeJob(jobID, elementID int64) error {
+	resource, err := dr.ctxPool.Get()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer dr.ctxPool.Put(resource)
+	ctx := resource.(context.Context)
+	ctx.GetSessionVars().SetStatusFlag(mysql.ServerStatusAutocommit, true)
+	ctx.GetSessionVars().InRestrictedSQL = true
+
+	_, err = ctx.(sqlexec.SQLExecutor).Execute(fmt.Sprintf(completeDeleteRangeSQL, jobID, elementID))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	log.Infof("[ddl] remove job (%d,%d) from delete-range table", jobID, elementID)
+	return nil
+}
+
 // start implements delRangeManager interface.
 func (dr *delRange) start() {
 	if !dr.storeSupport {
@@ -118,10 +175,17 @@ func (dr *delRange) startEmulator() {
 		case <-dr.d.quitCh:
 			return
 		}
+		if atomic.LoadInt32(&emulatorGCEnable) == 0 {
+			continue
+		}
 		dr.doDelRangeWork()
 	}
 }
 
+// emulatorGCEnable indicates whether the delete-range emulator deletes the data, it's only
+// changed by the tests.
+var emulatorGCEnable int32 = 1
+
 func (dr *delRange) doDelRangeWork() error {
 	resource, err := dr.ctxPool.Get()
 	if err != nil {
@@ -243,6 +307,19 @@ func insertJobIntoDeleteRangeTable(ctx context.Context, job *model.Job) error {
 		startKey := tablecodec.EncodeTableIndexPrefix(tableID, indexID)
 		endKey := tablecodec.EncodeTableIndexPrefix(tableID, indexID+1)
 		return doInsert(s, job.ID, indexID, startKey, endKey, now)
+	case model.ActionMultiSchemaChange:
+		tableID := job.TableID
+		var indexIDs []int64
+		if err := job.DecodeArgs(&indexIDs); err != nil {
+			return errors.Trace(err)
+		}
+		for _, indexID := range indexIDs {
+			startKey := tablecodec.EncodeTableIndexPrefix(tableID, indexID)
+			endKey := tablecodec.EncodeTableIndexPrefix(tableID, indexID+1)
+			if err := doInsert(s, job.ID, indexID, startKey, endKey, now); err != nil {
+				return errors.Trace(err)
+			}
+		}
 	}
 	return nil
 }
@@ -256,6 +333,63 @@ func doInsert(s sqlexec.SQLExecutor, jobID int64, elementID int64, startKey, end
 	return errors.Trace(err)
 }
 
+// holdInDeleteRangeTable sets the ts of the record of the job's element in gc_delete_range table
+// to holdDeleteRangeTS. If the record doesn't exist, or a GC round has started after the record
+// was inserted, the data may have been deleted and the record isn't held.
+func holdInDeleteRangeTable(ctx context.Context, jobID, elementID int64) (bool, error) {
+	s := ctx.(sqlexec.SQLExecutor)
+	d, err := loadSingleValue(s, fmt.Sprintf(loadDeleteRangeTSSQL, jobID, elementID))
+	if err != nil || d == nil {
+		return false, errors.Trace(err)
+	}
+	ts := d.GetInt64()
+	if ts == holdDeleteRangeTS {
+		return true, nil
+	}
+	if started, err := gcStartedAfter(s, ts); err != nil || started {
+		return false, errors.Trace(err)
+	}
+	_, err = s.Execute(fmt.Sprintf(updateDeleteRangeTSSQL, int64(holdDeleteRangeTS), jobID, elementID))
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	// A GC round may have loaded the record before it's held, the record is given back to it.
+	started, err := gcStartedAfter(s, ts)
+	if err != nil || started {
+		if err == nil {
+			_, err = s.Execute(fmt.Sprintf(updateDeleteRangeTSSQL, ts, jobID, elementID))
+		}
+		return false, errors.Trace(err)
+	}
+	return true, nil
+}
+
+// gcStartedAfter checks if the last GC round started at or after ts.
+func gcStartedAfter(s sqlexec.SQLExecutor, ts int64) (bool, error) {
+	lastRunTime, err := loadSingleValue(s, loadGCLastRunTimeSQL)
+	if err != nil || lastRunTime == nil {
+		return false, errors.Trace(err)
+	}
+	t, err := time.Parse(GCTimeFormat, lastRunTime.GetString())
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	return t.Unix() >= ts, nil
+}
+
+// loadSingleValue returns the first column of the first row of the sql result, or nil if there is no row.
+func loadSingleValue(s sqlexec.SQLExecutor, sql string) (*types.Datum, error) {
+	rss, err := s.Execute(sql)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	row, err := rss[0].Next()
+	if err != nil || row == nil {
+		return nil, errors.Trace(err)
+	}
+	return &row.Data[0], nil
+}
+
 // DelRangeTask is for run delete-range command in gc_worker.
 type DelRangeTask struct {
 	jobID, elementID int64
@@ -0,0 +1,26 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ddl
+
+import "sync/atomic"
+
+// SetEmulatorGCEnable enables or disables the delete-range emulator, the dropped data is
+// kept if it's disabled. It's only used by the tests out of this package.
+func SetEmulatorGCEnable(enable bool) {
+	v := int32(0)
+	if enable {
+		v = 1
+	}
+	atomic.StoreInt32(&emulatorGCEnable, v)
+}
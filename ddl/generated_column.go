@@ -14,9 +14,19 @@
 package ddl
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/infoschema"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/table"
+	"github.com/pingcap/tidb/table/tables"
+	"github.com/pingcap/tidb/util/types"
 )
 
 // columnGenerationInDDL is a struct for validating generated columns in DDL.
@@ -153,3 +163,118 @@ func checkModifyGeneratedColumn(originCols []*table.Column, oldCol, newCol *tabl
 	}
 	return nil
 }
+
+// expressionIndexColumnPrefix is the name prefix of the hidden virtual generated
+// columns which hold the expression parts of expression indices.
+const expressionIndexColumnPrefix = "_V$_"
+
+// buildHiddenColumnsForIndex builds a hidden virtual generated column for every
+// expression part of an index. It returns the index column names in which the
+// expression parts are replaced by the hidden columns.
+func buildHiddenColumnsForIndex(ctx context.Context, tblInfo *model.TableInfo, indexName model.CIStr,
+	idxColNames []*ast.IndexColName) ([]*ast.IndexColName, []*model.ColumnInfo, error) {
+	newIdxColNames := make([]*ast.IndexColName, 0, len(idxColNames))
+	var hiddenCols []*model.ColumnInfo
+	for i, idxColName := range idxColNames {
+		if idxColName.Expr == nil {
+			newIdxColNames = append(newIdxColNames, idxColName)
+			continue
+		}
+		if _, ok := idxColName.Expr.(*ast.ColumnNameExpr); ok {
+			return nil, nil, ErrFunctionalIndexOnField
+		}
+		dependences := make(map[string]struct{})
+		for _, depCol := range findColumnNamesInExpr(idxColName.Expr) {
+			col := findCol(tblInfo.Columns, depCol.Name.L)
+			if col == nil || col.State != model.StatePublic || col.Hidden {
+				return nil, nil, errBadField.GenByArgs(depCol.Name.O, "expression index")
+			}
+			dependences[col.Name.L] = struct{}{}
+		}
+
+		// Parse the expression text again, so we are sure that it can be restored from the column info.
+		exprStr := strings.TrimSpace(idxColName.Expr.Text())
+		expr, err := tables.ParseGeneratedExpr(exprStr, tblInfo)
+		if err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+		cols := expression.ColumnInfos2Columns(tblInfo.Name, tblInfo.Columns)
+		newExpr, err := expression.RewriteAstExpr(expr, expression.NewSchema(cols...), ctx)
+		if err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+
+		colName := model.NewCIStr(fmt.Sprintf("%s%s_%d", expressionIndexColumnPrefix, indexName.O, i))
+		if findCol(tblInfo.Columns, colName.L) != nil {
+			return nil, nil, infoschema.ErrColumnExists.GenByArgs(colName)
+		}
+		colInfo := &model.ColumnInfo{
+			Name:                colName,
+			FieldType:           *newExpr.GetType(),
+			GeneratedExprString: exprStr,
+			Dependences:         dependences,
+			Hidden:              true,
+		}
+		colInfo.Flag &= mysql.UnsignedFlag | mysql.BinaryFlag
+		// The collation of the expression result is decided by its charset.
+		colInfo.Collate = ""
+		if err = setCharsetCollationFlenDecimal(&colInfo.FieldType); err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+		hiddenCols = append(hiddenCols, colInfo)
+		newIdxColNames = append(newIdxColNames, &ast.IndexColName{
+			Column: &ast.ColumnName{Name: colName},
+			Length: types.UnspecifiedLength,
+		})
+	}
+	return newIdxColNames, hiddenCols, nil
+}
+
+// addHiddenColumns adds the hidden columns of an expression index to the table as public columns.
+func addHiddenColumns(tblInfo *model.TableInfo, hiddenCols []*model.ColumnInfo) error {
+	if len(hiddenCols) == 0 {
+		return nil
+	}
+	for _, col := range hiddenCols {
+		if findCol(tblInfo.Columns, col.Name.L) != nil {
+			return infoschema.ErrColumnExists.GenByArgs(col.Name)
+		}
+		col.ID = allocateColumnID(tblInfo)
+		col.Offset = len(tblInfo.Columns)
+		col.State = model.StatePublic
+		tblInfo.Columns = append(tblInfo.Columns, col)
+	}
+	resetColumnOffsets(tblInfo)
+	return nil
+}
+
+// dropHiddenColumns drops the hidden columns which are only used by the index.
+func dropHiddenColumns(tblInfo *model.TableInfo, indexInfo *model.IndexInfo) {
+	dropped := false
+	for _, idxCol := range indexInfo.Columns {
+		col := findCol(tblInfo.Columns, idxCol.Name.L)
+		if col == nil || !col.Hidden || isColumnIndexed(tblInfo, col.Name, indexInfo.Name) {
+			continue
+		}
+		removeColumnInfo(tblInfo, col.Name)
+		dropped = true
+	}
+	if dropped {
+		resetColumnOffsets(tblInfo)
+	}
+}
+
+// isColumnIndexed checks whether the column is used by any index except the excluded one.
+func isColumnIndexed(tblInfo *model.TableInfo, colName, excludedIndex model.CIStr) bool {
+	for _, idx := range tblInfo.Indices {
+		if idx.Name.L == excludedIndex.L {
+			continue
+		}
+		for _, idxCol := range idx.Columns {
+			if idxCol.Name.L == colName.L {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -21,6 +21,8 @@ import (
 	log "github.com/Sirupsen/logrus"
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/meta"
 	"github.com/pingcap/tidb/model"
@@ -139,22 +141,27 @@ func buildIndexInfo(tblInfo *model.TableInfo, indexName model.CIStr, idxColNames
 }
 
 func addIndexColumnFlag(tblInfo *model.TableInfo, indexInfo *model.IndexInfo) {
-	col := indexInfo.Columns[0]
+	col := findCol(tblInfo.Columns, indexInfo.Columns[0].Name.L)
 
 	if indexInfo.Unique && len(indexInfo.Columns) == 1 {
-		tblInfo.Columns[col.Offset].Flag |= mysql.UniqueKeyFlag
+		col.Flag |= mysql.UniqueKeyFlag
 	} else {
-		tblInfo.Columns[col.Offset].Flag |= mysql.MultipleKeyFlag
+		col.Flag |= mysql.MultipleKeyFlag
 	}
 }
 
 func dropIndexColumnFlag(tblInfo *model.TableInfo, indexInfo *model.IndexInfo) {
 	col := indexInfo.Columns[0]
+	colInfo := findCol(tblInfo.Columns, col.Name.L)
+	if colInfo == nil {
+		// The column is dropped together with the index.
+		return
+	}
 
 	if indexInfo.Unique && len(indexInfo.Columns) == 1 {
-		tblInfo.Columns[col.Offset].Flag &= ^uint(mysql.UniqueKeyFlag)
+		colInfo.Flag &= ^uint(mysql.UniqueKeyFlag)
 	} else {
-		tblInfo.Columns[col.Offset].Flag &= ^uint(mysql.MultipleKeyFlag)
+		colInfo.Flag &= ^uint(mysql.MultipleKeyFlag)
 	}
 
 	// other index may still cover this col
@@ -193,8 +200,9 @@ func (d *ddl) onCreateIndex(t *meta.Meta, job *model.Job) (ver int64, err error)
 		indexName   model.CIStr
 		idxColNames []*ast.IndexColName
 		indexOption *ast.IndexOption
+		hiddenCols  []*model.ColumnInfo
 	)
-	err = job.DecodeArgs(&unique, &indexName, &idxColNames, &indexOption)
+	err = job.DecodeArgs(&unique, &indexName, &idxColNames, &indexOption, &hiddenCols)
 	if err != nil {
 		job.State = model.JobCancelled
 		return ver, errors.Trace(err)
@@ -207,6 +215,11 @@ func (d *ddl) onCreateIndex(t *meta.Meta, job *model.Job) (ver int64, err error)
 	}
 
 	if indexInfo == nil {
+		// The hidden columns of an expression index are virtual, so they are public at once.
+		if err = addHiddenColumns(tblInfo, hiddenCols); err != nil {
+			job.State = model.JobCancelled
+			return ver, errors.Trace(err)
+		}
 		indexInfo, err = buildIndexInfo(tblInfo, indexName, idxColNames, model.StateNone)
 		if err != nil {
 			job.State = model.JobCancelled
@@ -214,6 +227,7 @@ func (d *ddl) onCreateIndex(t *meta.Meta, job *model.Job) (ver int64, err error)
 		}
 		if indexOption != nil {
 			indexInfo.Comment = indexOption.Comment
+			indexInfo.Invisible = indexOption.Visibility == ast.IndexVisibilityInvisible
 			if indexOption.Tp == model.IndexTypeInvalid {
 				// Use btree as default index type.
 				indexInfo.Tp = model.IndexTypeBtree
@@ -280,6 +294,12 @@ func (d *ddl) onCreateIndex(t *meta.Meta, job *model.Job) (ver int64, err error)
 			return ver, errors.Trace(err)
 		}
 
+		if job.IsMultiSchemaSubJob() {
+			// The index added by a multi-schema change is made public with the other changes together.
+			job.MarkNonRevertible()
+			return ver, nil
+		}
+
 		indexInfo.State = model.StatePublic
 		// Set column index flag.
 		addIndexColumnFlag(tblInfo, indexInfo)
@@ -364,6 +384,7 @@ func (d *ddl) onDropIndex(t *meta.Meta, job *model.Job) (ver int64, _ error) {
 		tblInfo.Indices = newIndices
 		// Set column index flag.
 		dropIndexColumnFlag(tblInfo, indexInfo)
+		dropHiddenColumns(tblInfo, indexInfo)
 
 		job.SchemaState = model.StateNone
 		ver, err = updateTableInfo(t, job, tblInfo, originalState)
@@ -386,6 +407,47 @@ func (d *ddl) onDropIndex(t *meta.Meta, job *model.Job) (ver int64, _ error) {
 	return ver, errors.Trace(err)
 }
 
+func (d *ddl) onAlterIndexVisibility(t *meta.Meta, job *model.Job) (ver int64, _ error) {
+	schemaID := job.SchemaID
+	tblInfo, err := getTableInfo(t, job, schemaID)
+	if err != nil {
+		return ver, errors.Trace(err)
+	}
+
+	var (
+		indexName model.CIStr
+		invisible bool
+	)
+	if err = job.DecodeArgs(&indexName, &invisible); err != nil {
+		job.State = model.JobCancelled
+		return ver, errors.Trace(err)
+	}
+
+	indexInfo := findIndexByName(indexName.L, tblInfo.Indices)
+	if indexInfo == nil || indexInfo.State != model.StatePublic {
+		job.State = model.JobCancelled
+		return ver, errKeyDoesNotExist.GenByArgs(indexName, tblInfo.Name)
+	}
+	if indexInfo.Primary && invisible {
+		job.State = model.JobCancelled
+		return ver, errors.Trace(errPKIndexCantBeInvisible)
+	}
+
+	indexInfo.Invisible = invisible
+	originalState := job.SchemaState
+	job.SchemaState = model.StatePublic
+	ver, err = updateTableInfo(t, job, tblInfo, originalState)
+	if err != nil {
+		job.State = model.JobCancelled
+		return ver, errors.Trace(err)
+	}
+
+	// Finish this job.
+	job.State = model.JobDone
+	job.BinlogInfo.AddTableInfo(ver, tblInfo)
+	return ver, nil
+}
+
 func (d *ddl) fetchRowColVals(txn kv.Transaction, t table.Table, taskOpInfo *indexTaskOpInfo, handleInfo *handleInfo) (
 	[]*indexRecord, *taskResult) {
 	startTime := time.Now()
@@ -435,7 +497,7 @@ func (d *ddl) fetchRowColVals(txn kv.Transaction, t table.Table, taskOpInfo *ind
 }
 
 func (d *ddl) getIndexRecords(t table.Table, taskOpInfo *indexTaskOpInfo, rawRecords [][]byte, idxRecords []*indexRecord) error {
-	cols := t.Cols()
+	cols := t.WritableCols()
 	ctx := d.newContext()
 	idxInfo := taskOpInfo.tblIndex.Meta()
 	defaultVals := make([]types.Datum, len(cols))
@@ -444,33 +506,82 @@ func (d *ddl) getIndexRecords(t table.Table, taskOpInfo *indexTaskOpInfo, rawRec
 		if err != nil {
 			return errors.Trace(err)
 		}
+		getColumnVal := func(col *table.Column) (types.Datum, error) {
+			return getColumnValue(ctx, t, col, idxRecord.handle, rowMap, defaultVals)
+		}
+
 		idxVal := make([]types.Datum, len(idxInfo.Columns))
-		for j, v := range idxInfo.Columns {
-			col := cols[v.Offset]
-			if col.IsPKHandleColumn(t.Meta()) {
-				if mysql.HasUnsignedFlag(col.Flag) {
-					idxVal[j].SetUint64(uint64(idxRecord.handle))
-				} else {
-					idxVal[j].SetInt64(idxRecord.handle)
+		if len(taskOpInfo.genExprs) == 0 {
+			for j, v := range idxInfo.Columns {
+				idxVal[j], err = getColumnVal(cols[v.Offset])
+				if err != nil {
+					return errors.Trace(err)
 				}
-				continue
 			}
-			idxColumnVal := rowMap[col.ID]
-			if _, ok := rowMap[col.ID]; ok {
-				idxVal[j] = idxColumnVal
+			idxRecord.vals = idxVal
+			continue
+		}
+
+		// The index has virtual generated columns, so we calculate them from the whole row.
+		row := make([]types.Datum, len(cols))
+		for _, col := range cols {
+			if _, ok := taskOpInfo.genExprs[col.Offset]; ok {
 				continue
 			}
-			idxColumnVal, err = tables.GetColDefaultValue(ctx, col, defaultVals)
+			row[col.Offset], err = getColumnVal(col)
 			if err != nil {
 				return errors.Trace(err)
 			}
-			idxVal[j] = idxColumnVal
+		}
+		if err = fillVirtualColumns(ctx, cols, taskOpInfo.genExprs, row); err != nil {
+			return errors.Trace(err)
+		}
+		for j, v := range idxInfo.Columns {
+			idxVal[j] = row[v.Offset]
 		}
 		idxRecord.vals = idxVal
 	}
 	return nil
 }
 
+// getColumnValue gets the value of the column from the decoded row.
+func getColumnValue(ctx context.Context, t table.Table, col *table.Column, handle int64,
+	rowMap map[int64]types.Datum, defaultVals []types.Datum) (types.Datum, error) {
+	if col.IsPKHandleColumn(t.Meta()) {
+		if mysql.HasUnsignedFlag(col.Flag) {
+			return types.NewUintDatum(uint64(handle)), nil
+		}
+		return types.NewIntDatum(handle), nil
+	}
+	if val, ok := rowMap[col.ID]; ok {
+		return val, nil
+	}
+	if col.State != model.StatePublic {
+		// The column is added by the same multi-schema change, so its value is the origin default value.
+		return table.GetColOriginDefaultValue(ctx, col.ToInfo())
+	}
+	return tables.GetColDefaultValue(ctx, col, defaultVals)
+}
+
+// fillVirtualColumns calculates the virtual generated columns in the row from the other columns.
+func fillVirtualColumns(ctx context.Context, cols []*table.Column, genExprs map[int]expression.Expression, row []types.Datum) error {
+	for _, col := range cols {
+		expr, ok := genExprs[col.Offset]
+		if !ok {
+			continue
+		}
+		val, err := expr.Eval(row)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		row[col.Offset], err = table.CastValue(ctx, val, col.ToInfo())
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
 const (
 	defaultBatchCnt      = 1024
 	defaultSmallBatchCnt = 128
@@ -501,9 +612,49 @@ type indexRecord struct {
 // indexTaskOpInfo records the information that is needed in the task.
 type indexTaskOpInfo struct {
 	tblIndex  table.Index
-	colMap    map[int64]*types.FieldType // It's the index columns map.
-	taskRetCh chan *taskResult           // Get the results of all tasks.
-	nextCh    chan int64                 // It notifies to start the next task.
+	colMap    map[int64]*types.FieldType    // It's the index columns map.
+	genExprs  map[int]expression.Expression // It's the virtual generated columns map, the key is the column offset.
+	taskRetCh chan *taskResult              // Get the results of all tasks.
+	nextCh    chan int64                    // It notifies to start the next task.
+}
+
+// buildVirtualColumnExprs builds the generation expressions of the virtual generated columns
+// if the index contains any of them.
+func (d *ddl) buildVirtualColumnExprs(t table.Table, indexInfo *model.IndexInfo) (map[int]expression.Expression, error) {
+	cols := t.WritableCols()
+	hasVirtualCol := false
+	for _, v := range indexInfo.Columns {
+		if col := cols[v.Offset]; col.IsGenerated() && !col.GeneratedStored {
+			hasVirtualCol = true
+			break
+		}
+	}
+	if !hasVirtualCol {
+		return nil, nil
+	}
+	return d.buildAllVirtualColumnExprs(t)
+}
+
+// buildAllVirtualColumnExprs builds the generation expressions of all the virtual generated columns.
+func (d *ddl) buildAllVirtualColumnExprs(t table.Table) (map[int]expression.Expression, error) {
+	cols := t.WritableCols()
+	colInfos := make([]*model.ColumnInfo, 0, len(cols))
+	for _, col := range cols {
+		colInfos = append(colInfos, col.ToInfo())
+	}
+	schema := expression.NewSchema(expression.ColumnInfos2Columns(t.Meta().Name, colInfos)...)
+	genExprs := make(map[int]expression.Expression)
+	for _, col := range cols {
+		if !col.IsGenerated() || col.GeneratedStored {
+			continue
+		}
+		expr, err := expression.RewriteAstExpr(col.GeneratedExpr, schema, d.newContext())
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		genExprs[col.Offset] = expr
+	}
+	return genExprs, nil
 }
 
 // addTableIndex adds index into table.
@@ -526,16 +677,29 @@ type indexTaskOpInfo struct {
 // an error message is displayed, exit the traversal.
 // Finally, update the concurrent processing of the total number of rows, and store the completed handle value.
 func (d *ddl) addTableIndex(t table.Table, indexInfo *model.IndexInfo, reorgInfo *reorgInfo, job *model.Job) error {
-	cols := t.Cols()
+	cols := t.WritableCols()
 	colMap := make(map[int64]*types.FieldType)
 	for _, v := range indexInfo.Columns {
 		col := cols[v.Offset]
 		colMap[col.ID] = &col.FieldType
 	}
+	genExprs, err := d.buildVirtualColumnExprs(t, indexInfo)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if len(genExprs) > 0 {
+		// The virtual generated columns are calculated from the other columns.
+		for _, col := range cols {
+			if _, ok := genExprs[col.Offset]; !ok {
+				colMap[col.ID] = &col.FieldType
+			}
+		}
+	}
 	taskCnt := defaultTaskCnt
 	taskOpInfo := &indexTaskOpInfo{
 		tblIndex:  tables.NewIndex(t.Meta(), indexInfo),
 		colMap:    colMap,
+		genExprs:  genExprs,
 		nextCh:    make(chan int64, 1),
 		taskRetCh: make(chan *taskResult, taskCnt),
 	}
@@ -110,6 +110,16 @@ func (dr *mockDelRange) addDelRangeJob(job *model.Job) error {
 	return nil
 }
 
+// holdDelRangeJob implements delRangeManager interface.
+func (dr *mockDelRange) holdDelRangeJob(jobID, elementID int64) (bool, error) {
+	return true, nil
+}
+
+// removeDelRangeJob implements delRangeManager interface.
+func (dr *mockDelRange) removeDelRangeJob(jobID, elementID int64) error {
+	return nil
+}
+
 // start implements delRangeManager interface.
 func (dr *mockDelRange) start() {
 	return
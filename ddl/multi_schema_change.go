@@ -0,0 +1,572 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ddl
+
+import (
+	log "github.com/Sirupsen/logrus"
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/infoschema"
+	"github.com/pingcap/tidb/meta"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/table"
+	"github.com/pingcap/tidb/terror"
+)
+
+// multiSchemaChange runs several alter table specs as one DDL job.
+// All the specs are validated against the table before the job is queued, and the
+// job makes all the changes visible at the same schema version.
+//
+// How does a multi-schema change job run?
+//  1. The sub-jobs run their revertible steps one by one. An added column stops in write
+//     reorganization state and an added index stops after its data is backfilled.
+//  2. All added columns and indices become public, and all dropped columns and indices
+//     become write only in one schema version. The job can't be rolled back after that.
+//  3. The dropped columns and indices go through the remaining states together.
+//
+// If a sub-job fails in step 1, all the columns and indices added by the job are removed.
+func (d *ddl) multiSchemaChange(ctx context.Context, ti ast.Ident, specs []*ast.AlterTableSpec) error {
+	is := d.infoHandle.Get()
+	schema, ok := is.SchemaByName(ti.Schema)
+	if !ok {
+		return errors.Trace(infoschema.ErrDatabaseNotExists)
+	}
+	t, err := is.TableByName(ti.Schema, ti.Name)
+	if err != nil {
+		return errors.Trace(infoschema.ErrTableNotExists.GenByArgs(ti.Schema, ti.Name))
+	}
+
+	subJobs, err := buildMultiSchemaSubJobs(ctx, t.Meta(), specs)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	job := &model.Job{
+		SchemaID:   schema.ID,
+		TableID:    t.Meta().ID,
+		Type:       model.ActionMultiSchemaChange,
+		BinlogInfo: &model.HistoryInfo{},
+		MultiSchemaInfo: &model.MultiSchemaInfo{
+			SubJobs:    subJobs,
+			Revertible: true,
+		},
+	}
+
+	err = d.doDDLJob(ctx, job)
+	err = d.callHookOnChanged(err)
+	return errors.Trace(err)
+}
+
+// buildMultiSchemaSubJobs checks every spec and the conflicts between the specs, and builds a
+// sub-job for every spec. The dropped elements are checked against tblInfo, the added elements
+// are checked against a copy of tblInfo which has the elements added by the previous specs.
+func buildMultiSchemaSubJobs(ctx context.Context, tblInfo *model.TableInfo, specs []*ast.AlterTableSpec) ([]*model.SubJob, error) {
+	newTblInfo := tblInfo.Clone()
+	// An element can only be changed once in a multi-schema change.
+	changedCols := make(map[string]struct{}, len(specs))
+	changedIndices := make(map[string]struct{}, len(specs))
+	markChanged := func(changed map[string]struct{}, name model.CIStr, err *terror.Error) error {
+		if _, ok := changed[name.L]; ok {
+			return err.GenByArgs(name)
+		}
+		changed[name.L] = struct{}{}
+		return nil
+	}
+
+	// A column can be dropped with the indices covering it, so the columns are checked against
+	// the indices that are kept.
+	droppedIndices := make(map[string]struct{}, len(specs))
+	for _, spec := range specs {
+		if spec.Tp == ast.AlterTableDropIndex {
+			droppedIndices[model.NewCIStr(spec.Name).L] = struct{}{}
+		}
+	}
+	keptTblInfo := *tblInfo
+	keptTblInfo.Indices = make([]*model.IndexInfo, 0, len(tblInfo.Indices))
+	for _, idx := range tblInfo.Indices {
+		if _, ok := droppedIndices[idx.Name.L]; !ok {
+			keptTblInfo.Indices = append(keptTblInfo.Indices, idx)
+		}
+	}
+
+	var (
+		droppedCols  []model.CIStr
+		relativeCols []model.CIStr
+		addedIndices []*model.IndexInfo
+	)
+	subJobs := make([]*model.SubJob, 0, len(specs))
+	for _, spec := range specs {
+		var sub *model.SubJob
+		switch spec.Tp {
+		case ast.AlterTableAddColumn:
+			cols := make([]*table.Column, 0, len(newTblInfo.Columns))
+			for _, col := range newTblInfo.Columns {
+				cols = append(cols, table.ToColumn(col))
+			}
+			col, err := checkAndCreateNewColumn(ctx, cols, spec)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			if err = markChanged(changedCols, col.Name, errOperateSameColumn); err != nil {
+				return nil, errors.Trace(err)
+			}
+			if pos := spec.Position; pos != nil && pos.Tp == ast.ColumnPositionAfter {
+				if findCol(newTblInfo.Columns, pos.RelativeColumn.Name.L) == nil {
+					return nil, infoschema.ErrColumnNotExists.GenByArgs(pos.RelativeColumn, tblInfo.Name)
+				}
+				relativeCols = append(relativeCols, pos.RelativeColumn.Name)
+			}
+			colInfo := col.ToInfo().Clone()
+			colInfo.Offset = len(newTblInfo.Columns)
+			newTblInfo.Columns = append(newTblInfo.Columns, colInfo)
+			sub = &model.SubJob{
+				Type:       model.ActionAddColumn,
+				Args:       []interface{}{col, spec.Position, 0},
+				Revertible: true,
+			}
+		case ast.AlterTableDropColumn:
+			colName := spec.OldColumnName.Name
+			col := findCol(tblInfo.Columns, colName.L)
+			if col == nil {
+				return nil, ErrCantDropFieldOrKey.Gen("column %s doesn't exist", colName)
+			}
+			if err := markChanged(changedCols, colName, errOperateSameColumn); err != nil {
+				return nil, errors.Trace(err)
+			}
+			if err := isDroppableColumn(&keptTblInfo, colName); err != nil {
+				return nil, errors.Trace(err)
+			}
+			if table.ToColumn(col).IsPKHandleColumn(tblInfo) {
+				return nil, errUnsupportedPKHandle
+			}
+			droppedCols = append(droppedCols, colName)
+			sub = &model.SubJob{
+				Type: model.ActionDropColumn,
+				Args: []interface{}{colName},
+			}
+		case ast.AlterTableDropIndex:
+			indexName := model.NewCIStr(spec.Name)
+			if findIndexByName(indexName.L, tblInfo.Indices) == nil {
+				return nil, ErrCantDropFieldOrKey.Gen("index %s doesn't exist", indexName)
+			}
+			if err := markChanged(changedIndices, indexName, errOperateSameIndex); err != nil {
+				return nil, errors.Trace(err)
+			}
+			sub = &model.SubJob{
+				Type: model.ActionDropIndex,
+				Args: []interface{}{indexName},
+			}
+		case ast.AlterTableAddConstraint:
+			constr := spec.Constraint
+			var unique bool
+			switch constr.Tp {
+			case ast.ConstraintKey, ast.ConstraintIndex:
+			case ast.ConstraintUniq, ast.ConstraintUniqIndex, ast.ConstraintUniqKey:
+				unique = true
+			default:
+				return nil, errRunMultiSchemaChanges
+			}
+			indexName := model.NewCIStr(constr.Name)
+			if len(indexName.L) == 0 {
+				indexName = getAnonymousIndex(newTblInfo.Indices, constr.Keys)
+			}
+			if findIndexByName(indexName.L, newTblInfo.Indices) != nil {
+				return nil, errDupKeyName.Gen("index already exist %s", indexName)
+			}
+			if err := markChanged(changedIndices, indexName, errOperateSameIndex); err != nil {
+				return nil, errors.Trace(err)
+			}
+			// The expression parts can only depend on the columns which exist before the multi-schema change.
+			keys, hiddenCols, err := buildHiddenColumnsForIndex(ctx, newTblInfo, indexName, constr.Keys)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			clonedCols := make([]*model.ColumnInfo, 0, len(hiddenCols))
+			for _, col := range hiddenCols {
+				clonedCols = append(clonedCols, col.Clone())
+			}
+			if err = addHiddenColumns(newTblInfo, clonedCols); err != nil {
+				return nil, errors.Trace(err)
+			}
+			indexInfo, err := buildIndexInfo(newTblInfo, indexName, keys, model.StateNone)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			newTblInfo.Indices = append(newTblInfo.Indices, indexInfo)
+			addedIndices = append(addedIndices, indexInfo)
+			sub = &model.SubJob{
+				Type:       model.ActionAddIndex,
+				Args:       []interface{}{unique, indexName, keys, constr.Option, hiddenCols},
+				Revertible: true,
+			}
+		default:
+			// Other schema changes can't run with any others now.
+			return nil, errRunMultiSchemaChanges
+		}
+		subJobs = append(subJobs, sub)
+	}
+
+	// The added elements can't depend on the dropped columns.
+	for _, colName := range droppedCols {
+		if isColumnWithIndex(colName.L, addedIndices) {
+			return nil, errCantDropColWithIndex.Gen("can't drop column %s with index covered now", colName)
+		}
+		for _, relative := range relativeCols {
+			if relative.L == colName.L {
+				return nil, infoschema.ErrColumnNotExists.GenByArgs(relative, tblInfo.Name)
+			}
+		}
+	}
+	if len(droppedCols) > 0 && len(droppedCols) == len(newTblInfo.Columns) {
+		return nil, ErrCantRemoveAllFields.Gen("can't drop all columns in table %s", tblInfo.Name)
+	}
+	return subJobs, nil
+}
+
+func removeColumnInfo(tblInfo *model.TableInfo, colName model.CIStr) {
+	newColumns := make([]*model.ColumnInfo, 0, len(tblInfo.Columns))
+	for _, col := range tblInfo.Columns {
+		if col.Name.L != colName.L {
+			newColumns = append(newColumns, col)
+		}
+	}
+	tblInfo.Columns = newColumns
+}
+
+func removeIndexInfo(tblInfo *model.TableInfo, indexName model.CIStr) {
+	newIndices := make([]*model.IndexInfo, 0, len(tblInfo.Indices))
+	for _, idx := range tblInfo.Indices {
+		if idx.Name.L != indexName.L {
+			newIndices = append(newIndices, idx)
+		}
+	}
+	tblInfo.Indices = newIndices
+}
+
+func (d *ddl) onMultiSchemaChange(t *meta.Meta, job *model.Job) (ver int64, _ error) {
+	if job.State == model.JobRollback {
+		return d.rollbackMultiSchemaChange(t, job)
+	}
+
+	info := job.MultiSchemaInfo
+	if info.Revertible {
+		// Run one revertible step of the first unfinished sub-job.
+		for _, sub := range info.SubJobs {
+			if !sub.Revertible {
+				continue
+			}
+			proxyJob := sub.ToProxyJob(job)
+			ver, err := d.runMultiSchemaSubJob(t, proxyJob)
+			job.SchemaState = proxyJob.SchemaState
+			job.SetRowCount(proxyJob.GetRowCount())
+			if proxyJob.State == model.JobCancelled || proxyJob.State == model.JobRollback {
+				// The args of the proxy job may be changed for its own rollback, so we don't keep them.
+				log.Warnf("[ddl] run multi-schema change sub-job %v err %v, convert job to rollback job", sub.Type, err)
+				job.State = model.JobRollback
+				return ver, errors.Trace(err)
+			}
+			sub.FromProxyJob(proxyJob)
+			return ver, errors.Trace(err)
+		}
+		return d.publishMultiSchemaChange(t, job)
+	}
+
+	return d.dropMultiSchemaChangeElements(t, job)
+}
+
+func (d *ddl) runMultiSchemaSubJob(t *meta.Meta, proxyJob *model.Job) (ver int64, err error) {
+	switch proxyJob.Type {
+	case model.ActionAddColumn:
+		ver, err = d.onAddColumn(t, proxyJob)
+	case model.ActionAddIndex:
+		ver, err = d.onCreateIndex(t, proxyJob)
+	default:
+		proxyJob.State = model.JobCancelled
+		err = errInvalidDDLJob.Gen("invalid multi-schema change sub-job %v", proxyJob)
+	}
+	return ver, errors.Trace(err)
+}
+
+// multiSchemaElements are the columns and indices changed by a multi-schema change job.
+type multiSchemaElements struct {
+	addedCols      []*model.ColumnInfo
+	droppedCols    []*model.ColumnInfo
+	addedIndices   []*model.IndexInfo
+	droppedIndices []*model.IndexInfo
+}
+
+// getMultiSchemaElements finds the elements changed by the sub-jobs in tblInfo.
+// The elements that haven't been created by the sub-jobs are ignored, and the public elements
+// with the same names aren't treated as added elements before the job is made public.
+func getMultiSchemaElements(tblInfo *model.TableInfo, info *model.MultiSchemaInfo) (*multiSchemaElements, error) {
+	elems := &multiSchemaElements{}
+	for _, sub := range info.SubJobs {
+		switch sub.Type {
+		case model.ActionAddColumn:
+			col := &model.ColumnInfo{}
+			pos := &ast.ColumnPosition{}
+			offset := 0
+			if err := sub.DecodeArgs(col, pos, &offset); err != nil {
+				return nil, errors.Trace(err)
+			}
+			colInfo := findCol(tblInfo.Columns, col.Name.L)
+			if colInfo != nil && colInfo.ID == col.ID && (colInfo.State != model.StatePublic || !info.Revertible) {
+				elems.addedCols = append(elems.addedCols, colInfo)
+			}
+		case model.ActionDropColumn:
+			var colName model.CIStr
+			if err := sub.DecodeArgs(&colName); err != nil {
+				return nil, errors.Trace(err)
+			}
+			if colInfo := findCol(tblInfo.Columns, colName.L); colInfo != nil {
+				elems.droppedCols = append(elems.droppedCols, colInfo)
+			}
+		case model.ActionAddIndex:
+			var (
+				unique      bool
+				indexName   model.CIStr
+				idxColNames []*ast.IndexColName
+				indexOption *ast.IndexOption
+			)
+			if err := sub.DecodeArgs(&unique, &indexName, &idxColNames, &indexOption); err != nil {
+				return nil, errors.Trace(err)
+			}
+			indexInfo := findIndexByName(indexName.L, tblInfo.Indices)
+			if indexInfo != nil && (indexInfo.State != model.StatePublic || !info.Revertible) {
+				elems.addedIndices = append(elems.addedIndices, indexInfo)
+			}
+		case model.ActionDropIndex:
+			var indexName model.CIStr
+			if err := sub.DecodeArgs(&indexName); err != nil {
+				return nil, errors.Trace(err)
+			}
+			if indexInfo := findIndexByName(indexName.L, tblInfo.Indices); indexInfo != nil {
+				elems.droppedIndices = append(elems.droppedIndices, indexInfo)
+			}
+		}
+	}
+	return elems, nil
+}
+
+// publishMultiSchemaChange makes the added elements public and the dropped elements write only at the same time.
+func (d *ddl) publishMultiSchemaChange(t *meta.Meta, job *model.Job) (ver int64, _ error) {
+	tblInfo, err := getTableInfo(t, job, job.SchemaID)
+	if err != nil {
+		return ver, errors.Trace(err)
+	}
+	elems, err := getMultiSchemaElements(tblInfo, job.MultiSchemaInfo)
+	if err != nil {
+		job.State = model.JobCancelled
+		return ver, errors.Trace(err)
+	}
+
+	for _, col := range elems.addedCols {
+		col.State = model.StatePublic
+	}
+	for _, col := range elems.droppedCols {
+		col.State = model.StateWriteOnly
+	}
+	// Added columns are placed in their final positions and dropped columns are moved to the end.
+	resetColumnOffsets(tblInfo)
+	for _, idx := range elems.addedIndices {
+		idx.State = model.StatePublic
+		addIndexColumnFlag(tblInfo, idx)
+	}
+	for _, idx := range elems.droppedIndices {
+		idx.State = model.StateWriteOnly
+	}
+	for _, sub := range job.MultiSchemaInfo.SubJobs {
+		switch sub.Type {
+		case model.ActionAddColumn, model.ActionAddIndex:
+			sub.SchemaState = model.StatePublic
+		default:
+			sub.SchemaState = model.StateWriteOnly
+		}
+	}
+	job.MultiSchemaInfo.Revertible = false
+
+	originalState := job.SchemaState
+	job.SchemaState = model.StatePublic
+	if len(elems.droppedCols) > 0 || len(elems.droppedIndices) > 0 {
+		job.SchemaState = model.StateWriteOnly
+	}
+	ver, err = updateTableInfo(t, job, tblInfo, originalState)
+	if err != nil {
+		return ver, errors.Trace(err)
+	}
+	for _, col := range elems.addedCols {
+		d.asyncNotifyEvent(&Event{Tp: model.ActionAddColumn, TableInfo: tblInfo, ColumnInfo: col})
+	}
+	if job.SchemaState == model.StatePublic {
+		// Finish this job.
+		job.State = model.JobDone
+		job.BinlogInfo.AddTableInfo(ver, tblInfo)
+	}
+	return ver, nil
+}
+
+// dropMultiSchemaChangeElements moves all the dropped elements to the next state together.
+func (d *ddl) dropMultiSchemaChangeElements(t *meta.Meta, job *model.Job) (ver int64, _ error) {
+	tblInfo, err := getTableInfo(t, job, job.SchemaID)
+	if err != nil {
+		return ver, errors.Trace(err)
+	}
+	elems, err := getMultiSchemaElements(tblInfo, job.MultiSchemaInfo)
+	if err != nil {
+		job.State = model.JobCancelled
+		return ver, errors.Trace(err)
+	}
+
+	var nextState model.SchemaState
+	originalState := job.SchemaState
+	switch job.SchemaState {
+	case model.StateWriteOnly:
+		// write only -> delete only
+		nextState = model.StateDeleteOnly
+	case model.StateDeleteOnly:
+		// delete only -> reorganization
+		nextState = model.StateDeleteReorganization
+	case model.StateDeleteReorganization:
+		// reorganization -> absent
+		nextState = model.StateNone
+	default:
+		return ver, ErrInvalidTableState.Gen("invalid multi-schema change state %v", job.SchemaState)
+	}
+
+	for _, col := range elems.droppedCols {
+		col.State = nextState
+	}
+	for _, idx := range elems.droppedIndices {
+		idx.State = nextState
+	}
+	indexIDs := make([]int64, 0, len(elems.droppedIndices))
+	if nextState == model.StateNone {
+		for _, idx := range elems.droppedIndices {
+			removeIndexInfo(tblInfo, idx.Name)
+			dropIndexColumnFlag(tblInfo, idx)
+			dropHiddenColumns(tblInfo, idx)
+			indexIDs = append(indexIDs, idx.ID)
+		}
+		for _, col := range elems.droppedCols {
+			removeColumnInfo(tblInfo, col.Name)
+		}
+	}
+	for _, sub := range job.MultiSchemaInfo.SubJobs {
+		if sub.Type == model.ActionDropColumn || sub.Type == model.ActionDropIndex {
+			sub.SchemaState = nextState
+		}
+	}
+
+	job.SchemaState = nextState
+	ver, err = updateTableInfo(t, job, tblInfo, originalState)
+	if err != nil {
+		return ver, errors.Trace(err)
+	}
+	if nextState != model.StateNone {
+		return ver, nil
+	}
+
+	// Finish this job.
+	job.SchemaState = model.StatePublic
+	job.State = model.JobDone
+	job.BinlogInfo.AddTableInfo(ver, tblInfo)
+	// The dropped index IDs are used by the delete-range manager.
+	job.Args = []interface{}{indexIDs}
+	for _, col := range elems.droppedCols {
+		d.asyncNotifyEvent(&Event{Tp: model.ActionDropColumn, TableInfo: tblInfo, ColumnInfo: col})
+	}
+	for _, idx := range elems.droppedIndices {
+		d.asyncNotifyEvent(&Event{Tp: model.ActionDropIndex, TableInfo: tblInfo, IndexInfo: idx})
+	}
+	return ver, nil
+}
+
+// rollbackMultiSchemaChange removes all the columns and indices added by the job.
+// These elements are moved to delete only state first, and then they are removed.
+func (d *ddl) rollbackMultiSchemaChange(t *meta.Meta, job *model.Job) (ver int64, _ error) {
+	tblInfo, err := getTableInfo(t, job, job.SchemaID)
+	if err != nil {
+		return ver, errors.Trace(err)
+	}
+	elems, err := getMultiSchemaElements(tblInfo, job.MultiSchemaInfo)
+	if err != nil {
+		return ver, errors.Trace(err)
+	}
+
+	deleteOnly := true
+	for _, col := range elems.addedCols {
+		if col.State != model.StateNone && col.State != model.StateDeleteOnly {
+			col.State = model.StateDeleteOnly
+			deleteOnly = false
+		}
+	}
+	for _, idx := range elems.addedIndices {
+		if idx.State != model.StateNone && idx.State != model.StateDeleteOnly {
+			idx.State = model.StateDeleteOnly
+			deleteOnly = false
+		}
+	}
+	originalState := job.SchemaState
+	if !deleteOnly {
+		job.SchemaState = model.StateDeleteOnly
+		ver, err = updateTableInfo(t, job, tblInfo, originalState)
+		return ver, errors.Trace(err)
+	}
+
+	indexIDs := make([]int64, 0, len(elems.addedIndices))
+	for _, idx := range elems.addedIndices {
+		removeIndexInfo(tblInfo, idx.Name)
+		dropHiddenColumns(tblInfo, idx)
+		indexIDs = append(indexIDs, idx.ID)
+	}
+	for _, col := range elems.addedCols {
+		removeColumnInfo(tblInfo, col.Name)
+	}
+	job.SchemaState = model.StateNone
+	ver, err = updateTableInfo(t, job, tblInfo, originalState)
+	if err != nil {
+		return ver, errors.Trace(err)
+	}
+	job.State = model.JobRollbackDone
+	job.BinlogInfo.AddTableInfo(ver, tblInfo)
+	// The index data that has been backfilled is cleaned up by the delete-range manager.
+	job.Args = []interface{}{indexIDs}
+	return ver, nil
+}
+
+// resetColumnOffsets sets the offsets of the public columns in the order of tblInfo.Columns,
+// and then sets the offsets of the other columns after them.
+func resetColumnOffsets(tblInfo *model.TableInfo) {
+	offsetChanged := make(map[int]int, len(tblInfo.Columns))
+	offset := 0
+	for _, public := range []bool{true, false} {
+		for _, col := range tblInfo.Columns {
+			if (col.State == model.StatePublic) != public {
+				continue
+			}
+			offsetChanged[col.Offset] = offset
+			col.Offset = offset
+			offset++
+		}
+	}
+
+	for _, idx := range tblInfo.Indices {
+		for _, col := range idx.Columns {
+			if newOffset, ok := offsetChanged[col.Offset]; ok {
+				col.Offset = newOffset
+			}
+		}
+	}
+}
@@ -130,6 +130,9 @@ func (d *ddl) getReorgInfo(t *meta.Meta, job *model.Job) (*reorgInfo, error) {
 		}
 
 		job.SnapshotVer = ver.Ver
+		// The handle is recorded by the job ID, and a multi-schema change job may run several reorganizations,
+		// so we reset the handle for a new reorganization.
+		err = t.UpdateDDLReorgHandle(job, 0)
 	} else {
 		info.Handle, err = t.GetDDLReorgHandle(job)
 		if err != nil {
@@ -124,6 +124,74 @@ func (d *ddl) onDropTable(t *meta.Meta, job *model.Job) (ver int64, _ error) {
 	return ver, errors.Trace(err)
 }
 
+// onRecoverTable recreates the dropped or truncated table with its original table ID, and removes its
+// data from the delete-range table, so the data won't be deleted by GC.
+func (d *ddl) onRecoverTable(t *meta.Meta, job *model.Job) (ver int64, _ error) {
+	schemaID := job.SchemaID
+	tblInfo := &model.TableInfo{}
+	var autoID, dropJobID int64
+	if err := job.DecodeArgs(tblInfo, &autoID, &dropJobID); err != nil {
+		// Invalid arguments, cancel this job.
+		job.State = model.JobCancelled
+		return ver, errors.Trace(err)
+	}
+
+	err := checkTableNotExists(t, job, schemaID, tblInfo.Name.L)
+	if err != nil {
+		return ver, errors.Trace(err)
+	}
+	oldTblInfo, err := t.GetTable(schemaID, tblInfo.ID)
+	if err != nil {
+		job.State = model.JobCancelled
+		return ver, errors.Trace(err)
+	}
+	if oldTblInfo != nil {
+		job.State = model.JobCancelled
+		return ver, infoschema.ErrTableExists.GenByArgs(oldTblInfo.Name)
+	}
+
+	// The delete-range record is held until the job is done, then it's removed in finishDDLJob.
+	held, err := d.delRangeManager.holdDelRangeJob(dropJobID, tblInfo.ID)
+	if err != nil {
+		return ver, errors.Trace(err)
+	}
+	if !held {
+		job.State = model.JobCancelled
+		return ver, ErrDroppedTableDataDeleted.GenByArgs(tblInfo.Name)
+	}
+
+	ver, err = updateSchemaVersion(t, job)
+	if err != nil {
+		return ver, errors.Trace(err)
+	}
+	// none -> public
+	job.SchemaState = model.StatePublic
+	tblInfo.State = model.StatePublic
+	err = t.CreateTable(schemaID, tblInfo)
+	if err != nil {
+		return ver, errors.Trace(err)
+	}
+	_, err = t.GenAutoTableID(schemaID, tblInfo.ID, autoID)
+	if err != nil {
+		return ver, errors.Trace(err)
+	}
+	// Finish this job.
+	job.State = model.JobDone
+	job.BinlogInfo.AddTableInfo(ver, tblInfo)
+	d.asyncNotifyEvent(&Event{Tp: model.ActionCreateTable, TableInfo: tblInfo})
+	return ver, nil
+}
+
+// removeRecoveredDelRange removes the delete-range record of the recovered table, which is held by onRecoverTable.
+func (d *ddl) removeRecoveredDelRange(job *model.Job) error {
+	tblInfo := &model.TableInfo{}
+	var autoID, dropJobID int64
+	if err := job.DecodeArgs(tblInfo, &autoID, &dropJobID); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(d.delRangeManager.removeDelRangeJob(dropJobID, tblInfo.ID))
+}
+
 // Maximum number of keys to delete for each reorg table job run.
 var reorgTableDeleteLimit = 65536
 
@@ -198,7 +198,8 @@ func (pr *partialResult) Close() error {
 // concurrency: The max concurrency for underlying coprocessor request.
 // keepOrder: If the result should returned in key order. For example if we need keep data in order by
 //            scan index, we should set keepOrder to true.
-func Select(client kv.Client, ctx goctx.Context, req *tipb.SelectRequest, keyRanges []kv.KeyRange, concurrency int, keepOrder bool, isolationLevel kv.IsoLevel, priority int) (SelectResult, error) {
+// replicaRead: The replicas the request is sent to.
+func Select(client kv.Client, ctx goctx.Context, req *tipb.SelectRequest, keyRanges []kv.KeyRange, concurrency int, keepOrder bool, isolationLevel kv.IsoLevel, priority int, replicaRead kv.ReplicaReadType) (SelectResult, error) {
 	var err error
 	defer func() {
 		// Add metrics
@@ -210,7 +211,7 @@ func Select(client kv.Client, ctx goctx.Context, req *tipb.SelectRequest, keyRan
 	}()
 
 	// Convert tipb.*Request to kv.Request.
-	kvReq, err1 := composeRequest(req, keyRanges, concurrency, keepOrder, isolationLevel, priority)
+	kvReq, err1 := composeRequest(req, keyRanges, concurrency, keepOrder, isolationLevel, priority, replicaRead)
 	if err1 != nil {
 		err = errors.Trace(err1)
 		return nil, err
@@ -242,7 +243,8 @@ func Select(client kv.Client, ctx goctx.Context, req *tipb.SelectRequest, keyRan
 // concurrency: The max concurrency for underlying coprocessor request.
 // keepOrder: If the result should returned in key order. For example if we need keep data in order by
 //            scan index, we should set keepOrder to true.
-func SelectDAG(client kv.Client, ctx goctx.Context, dag *tipb.DAGRequest, keyRanges []kv.KeyRange, concurrency int, keepOrder bool, desc bool, isolationLevel kv.IsoLevel, priority int) (SelectResult, error) {
+// replicaRead: The replicas the request is sent to.
+func SelectDAG(client kv.Client, ctx goctx.Context, dag *tipb.DAGRequest, keyRanges []kv.KeyRange, concurrency int, keepOrder bool, desc bool, isolationLevel kv.IsoLevel, priority int, replicaRead kv.ReplicaReadType) (SelectResult, error) {
 	var err error
 	defer func() {
 		// Add metrics.
@@ -261,6 +263,7 @@ func SelectDAG(client kv.Client, ctx goctx.Context, dag *tipb.DAGRequest, keyRan
 		Desc:           desc,
 		IsolationLevel: isolationLevel,
 		Priority:       priority,
+		ReplicaRead:    replicaRead,
 	}
 	kvReq.Data, err = dag.Marshal()
 	if err != nil {
@@ -319,13 +322,14 @@ func Analyze(client kv.Client, ctx goctx.Context, req *tipb.AnalyzeReq, keyRange
 }
 
 // Convert tipb.Request to kv.Request.
-func composeRequest(req *tipb.SelectRequest, keyRanges []kv.KeyRange, concurrency int, keepOrder bool, isolationLevel kv.IsoLevel, priority int) (*kv.Request, error) {
+func composeRequest(req *tipb.SelectRequest, keyRanges []kv.KeyRange, concurrency int, keepOrder bool, isolationLevel kv.IsoLevel, priority int, replicaRead kv.ReplicaReadType) (*kv.Request, error) {
 	kvReq := &kv.Request{
 		Concurrency:    concurrency,
 		KeepOrder:      keepOrder,
 		KeyRanges:      keyRanges,
 		IsolationLevel: isolationLevel,
 		Priority:       priority,
+		ReplicaRead:    replicaRead,
 	}
 	if req.IndexInfo != nil {
 		kvReq.Tp = kv.ReqTypeIndex
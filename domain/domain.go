@@ -543,6 +543,10 @@ func (do *Domain) UpdateTableStatsLoop(ctx context.Context) error {
 	}
 	do.wg.Add(1)
 	go do.updateStatsWorker(ctx, lease)
+	if RunAutoAnalyze {
+		do.wg.Add(1)
+		go do.autoAnalyzeWorker(lease)
+	}
 	return nil
 }
 
@@ -570,14 +574,25 @@ func (do *Domain) updateStatsWorker(ctx context.Context, lease time.Duration) {
 				log.Error("[stats] handle ddl event fail: ", errors.ErrorStack(err))
 			}
 		case t := <-statsHandle.AnalyzeResultCh():
-			for _, hg := range t.Hist {
-				err := hg.SaveToStorage(ctx, t.TableID, t.Count, t.IsIndex)
+			for i, hg := range t.Hist {
+				var cms *statistics.CMSketch
+				if i < len(t.Cms) {
+					cms = t.Cms[i]
+				}
+				err := statistics.SaveStatsToStorage(ctx, t.TableID, t.Count, t.IsIndex, hg, cms)
 				if err != nil {
 					log.Error("[stats] save histogram to storage fail: ", errors.ErrorStack(err))
 				}
 			}
+			if len(t.Extended) > 0 {
+				err := statistics.SaveExtendedStatsToStorage(ctx, t.TableID, t.Extended)
+				if err != nil {
+					log.Error("[stats] save extended stats to storage fail: ", errors.ErrorStack(err))
+				}
+			}
 		case <-deltaUpdateTicker.C:
 			statsHandle.DumpStatsDeltaToKV()
+			statsHandle.UpdateStatsByLocalFeedback()
 		}
 	}
 }
@@ -594,17 +609,25 @@ func (do *Domain) autoAnalyzeWorker(lease time.Duration) {
 	// TODO: Need to do something when err is not nil.
 	err := statsOwner.CampaignOwner(cancelCtx)
 	if err != nil {
-		log.Warnf("[stats] campaign owner fail:", errors.ErrorStack(err))
+		log.Warn("[stats] campaign owner fail: ", errors.ErrorStack(err))
 	}
+	analyzeTicker := time.NewTicker(lease)
+	defer analyzeTicker.Stop()
 	statsHandle := do.StatsHandle()
 	for {
-		if statsOwner.IsOwner() {
-			err := statsHandle.HandleAutoAnalyze(do.InfoSchema())
-			if err != nil {
-				log.Error("[stats] auto analyze fail:", errors.ErrorStack(err))
+		select {
+		case <-analyzeTicker.C:
+			if statsOwner.IsOwner() {
+				err := statsHandle.HandleAutoAnalyze(do.InfoSchema())
+				if err != nil {
+					log.Error("[stats] auto analyze fail: ", errors.ErrorStack(err))
+				}
 			}
+		case <-do.exit:
+			cancelFunc()
+			do.wg.Done()
+			return
 		}
-		time.Sleep(lease)
 	}
 }
 
@@ -628,6 +651,9 @@ const (
 	codeInfoSchemaChanged terror.ErrCode = 2
 )
 
+// RunAutoAnalyze indicates if this TiDB server starts auto analyze worker and can run auto analyze job.
+var RunAutoAnalyze = true
+
 var (
 	// ErrInfoSchemaExpired returns the error that information schema is out of date.
 	ErrInfoSchemaExpired = terror.ClassDomain.New(codeInfoSchemaExpired, "Information schema is out of date.")
@@ -116,6 +116,9 @@ func (a *statement) IsPrepared() bool {
 func (a *statement) Exec(ctx context.Context) (ast.RecordSet, error) {
 	a.startTime = time.Now()
 	a.ctx = ctx
+	if ctx.GetSessionVars().TxnCtx.ReadOnly && isWritePlan(a.plan) {
+		return nil, errors.Trace(ErrCantExecuteInReadOnlyTxn)
+	}
 
 	if _, ok := a.plan.(*plan.Analyze); ok && ctx.GetSessionVars().InRestrictedSQL {
 		oriStats := ctx.GetSessionVars().Systems[variable.TiDBBuildStatsConcurrency]
@@ -139,7 +142,17 @@ func (a *statement) Exec(ctx context.Context) (ast.RecordSet, error) {
 		return nil, errors.Trace(err)
 	}
 
-	if err := e.Open(); err != nil {
+	if txn, ok := ctx.Txn().(kv.PessimisticTxn); ok && isPessimisticLockStmt(ctx, a.plan) {
+		// The statement is executed in execPessimistic, e is rebuilt there.
+		e, err = a.execPessimistic(ctx, txn)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if e == nil {
+			a.logSlowQuery()
+			return nil, nil
+		}
+	} else if err := e.Open(); err != nil {
 		return nil, errors.Trace(err)
 	}
 
@@ -281,7 +281,7 @@ func (s *testSuite) TestAggregation(c *C) {
 
 	result = tk.MustQuery("select count(*) from information_schema.columns")
 	// When adding new memory table in information_schema, please update this variable.
-	columnCountOfAllInformationSchemaTables := "742"
+	columnCountOfAllInformationSchemaTables := "757"
 	result.Check(testkit.Rows(columnCountOfAllInformationSchemaTables))
 
 	tk.MustExec("drop table if exists t1")
@@ -353,4 +353,17 @@ func (s *testSuite) TestAggPushDown(c *C) {
 	tk.MustExec("insert into tt values(1, 2, 1)")
 	tk.MustQuery("select max(a.b), max(b.b) from t a join tt b on a.a = b.a group by a.c").Check(testkit.Rows("1 2"))
 	tk.MustQuery("select a, count(b) from (select * from t union all select * from tt) k group by a").Check(testkit.Rows("1 2", "2 1"))
+
+	// The aggregate functions which aren't decomposable are not pushed down across the union all.
+	tk.MustExec("drop table if exists t, tt")
+	tk.MustExec("create table t(a int, b int)")
+	tk.MustExec("create table tt(a int, b int)")
+	tk.MustExec("insert into t values(1, 1), (1, 3), (2, 1)")
+	tk.MustExec("insert into tt values(1, 1), (1, 2)")
+	tk.MustQuery("select a, count(distinct b) from (select * from t union all select * from tt) k group by a").Check(testkit.Rows("1 3", "2 1"))
+	tk.MustQuery("select a, avg(b) from (select * from t union all select * from tt) k group by a").Check(testkit.Rows("1 1.7500", "2 1.0000"))
+	tk.MustQuery("select a, sum(distinct b) from (select * from t union all select * from tt) k group by a").Check(testkit.Rows("1 6", "2 1"))
+	// The partial aggregations are pushed down across the outer joins.
+	tk.MustQuery("select t.a, count(tt.b), sum(t.b) from t left join tt on t.a = tt.a group by t.a").Check(testkit.Rows("1 4 8", "2 0 1"))
+	tk.MustQuery("select tt.a, count(t.b) from t right join tt on t.a = tt.a group by tt.a").Check(testkit.Rows("1 4"))
 }
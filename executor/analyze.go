@@ -80,8 +80,8 @@ func (e *AnalyzeExec) Next() (Row, error) {
 		for i := 0; i < len(e.tasks); i++ {
 			result := <-resultCh
 			if result.Err != nil {
-				err1 = err
-				log.Error(errors.ErrorStack(err))
+				err1 = result.Err
+				log.Error(errors.ErrorStack(result.Err))
 				continue
 			}
 			dom.StatsHandle().AnalyzeResultCh() <- &result
@@ -95,8 +95,8 @@ func (e *AnalyzeExec) Next() (Row, error) {
 	for i := 0; i < len(e.tasks); i++ {
 		result := <-resultCh
 		if result.Err != nil {
-			err1 = err
-			log.Error(errors.ErrorStack(err))
+			err1 = result.Err
+			log.Error(errors.ErrorStack(result.Err))
 			continue
 		}
 		results = append(results, result)
@@ -105,8 +105,18 @@ func (e *AnalyzeExec) Next() (Row, error) {
 		return nil, errors.Trace(err1)
 	}
 	for _, result := range results {
-		for _, hg := range result.Hist {
-			err = hg.SaveToStorage(e.ctx, result.TableID, result.Count, result.IsIndex)
+		for i, hg := range result.Hist {
+			var cms *statistics.CMSketch
+			if i < len(result.Cms) {
+				cms = result.Cms[i]
+			}
+			err = statistics.SaveStatsToStorage(e.ctx, result.TableID, result.Count, result.IsIndex, hg, cms)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+		if len(result.Extended) > 0 {
+			err = statistics.SaveExtendedStatsToStorage(e.ctx, result.TableID, result.Extended)
 			if err != nil {
 				return nil, errors.Trace(err)
 			}
@@ -134,6 +144,7 @@ type taskType int
 const (
 	colTask taskType = iota
 	idxTask
+	extendedTask
 )
 
 type analyzeTask struct {
@@ -142,9 +153,11 @@ type analyzeTask struct {
 	indexInfo *model.IndexInfo
 	Columns   []*model.ColumnInfo
 	PKInfo    *model.ColumnInfo
-	src       Executor
-	idxExec   *AnalyzeIndexExec
-	colExec   *AnalyzeColumnsExec
+	// ColumnGroups are the column groups of the extended statistics, their columns are in Columns.
+	ColumnGroups [][]*model.ColumnInfo
+	src          Executor
+	idxExec      *AnalyzeIndexExec
+	colExec      *AnalyzeColumnsExec
 }
 
 func (e *AnalyzeExec) analyzeWorker(taskCh <-chan *analyzeTask, resultCh chan<- statistics.AnalyzeResult) {
@@ -162,6 +175,8 @@ func (e *AnalyzeExec) analyzeWorker(taskCh <-chan *analyzeTask, resultCh chan<-
 			} else {
 				resultCh <- e.analyzeIndex(task)
 			}
+		case extendedTask:
+			resultCh <- e.analyzeExtended(task)
 		}
 	}
 }
@@ -211,9 +226,32 @@ func (e *AnalyzeExec) analyzeIndex(task *analyzeTask) statistics.AnalyzeResult {
 	if e := task.src.Open(); e != nil {
 		return statistics.AnalyzeResult{Err: e}
 	}
-	count, hg, err := statistics.BuildIndex(e.ctx, maxBucketSize, task.indexInfo.ID, &recordSet{executor: task.src})
+	count, hg, cms, err := statistics.BuildIndex(e.ctx, maxBucketSize, task.indexInfo.ID, &recordSet{executor: task.src})
+	if e := task.src.Close(); e != nil {
+		return statistics.AnalyzeResult{Err: e}
+	}
+	return statistics.AnalyzeResult{TableID: task.tableInfo.ID, Hist: []*statistics.Histogram{hg}, Cms: []*statistics.CMSketch{cms}, Count: count, IsIndex: 1, Err: err}
+}
+
+func (e *AnalyzeExec) analyzeExtended(task *analyzeTask) statistics.AnalyzeResult {
+	if e := task.src.Open(); e != nil {
+		return statistics.AnalyzeResult{Err: e}
+	}
+	cols := make([]int64, 0, len(task.Columns))
+	for _, col := range task.Columns {
+		cols = append(cols, col.ID)
+	}
+	groups := make([][]int64, 0, len(task.ColumnGroups))
+	for _, group := range task.ColumnGroups {
+		ids := make([]int64, 0, len(group))
+		for _, col := range group {
+			ids = append(ids, col.ID)
+		}
+		groups = append(groups, ids)
+	}
+	stats, err := statistics.BuildExtendedStats(&recordSet{executor: task.src}, cols, groups, maxSketchSize)
 	if e := task.src.Close(); e != nil {
 		return statistics.AnalyzeResult{Err: e}
 	}
-	return statistics.AnalyzeResult{TableID: task.tableInfo.ID, Hist: []*statistics.Histogram{hg}, Count: count, IsIndex: 1, Err: err}
+	return statistics.AnalyzeResult{TableID: task.tableInfo.ID, Extended: stats, Err: err}
 }
@@ -15,6 +15,7 @@ package executor
 
 import (
 	"math"
+	"math/rand"
 	"time"
 
 	"github.com/juju/errors"
@@ -28,6 +29,7 @@ import (
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/plan"
 	"github.com/pingcap/tidb/sessionctx"
+	"github.com/pingcap/tidb/statistics"
 	"github.com/pingcap/tidb/table"
 	"github.com/pingcap/tidb/util/types"
 	"github.com/pingcap/tipb/go-tipb"
@@ -136,6 +138,8 @@ func (b *executorBuilder) build(p plan.Plan) Executor {
 		return b.buildIndexReader(v)
 	case *plan.PhysicalIndexLookUpReader:
 		return b.buildIndexLookUpReader(v)
+	case *plan.PhysicalIndexMergeReader:
+		return b.buildIndexMergeReader(v)
 	default:
 		b.err = ErrUnknownPlan.Gen("Unknown Plan %T", p)
 		return nil
@@ -623,9 +627,20 @@ func (b *executorBuilder) buildTableDual(v *plan.TableDual) Executor {
 }
 
 func (b *executorBuilder) getStartTS() uint64 {
+	// The stale reads read the historical data at the ts of the AS OF clause.
+	if ts := b.ctx.GetSessionVars().StmtCtx.StaleReadTS; ts != 0 {
+		return ts
+	}
+	if ts := b.ctx.GetSessionVars().TxnCtx.StaleReadTS; ts != 0 {
+		return ts
+	}
 	startTS := b.ctx.GetSessionVars().SnapshotTS
 	if startTS == 0 {
 		startTS = b.ctx.Txn().StartTS()
+		// The statements acquiring pessimistic locks read the latest data.
+		if forUpdateTS := b.ctx.GetSessionVars().TxnCtx.ForUpdateTS; forUpdateTS != 0 {
+			startTS = forUpdateTS
+		}
 	}
 	return startTS
 }
@@ -1053,6 +1068,11 @@ func (b *executorBuilder) buildAnalyzeColumnsPushdown(task plan.AnalyzeColumnsTa
 		SketchSize:  maxSketchSize,
 		ColumnsInfo: distsql.ColumnsToProto(cols, task.TableInfo.PKIsHandle),
 	}
+	// The rows written before a column is added don't have its value, the coprocessor fills the default.
+	b.err = setPBColumnsDefaultValue(b.ctx, e.analyzePB.ColReq.ColumnsInfo, cols)
+	if b.err != nil {
+		return nil
+	}
 	return e
 }
 
@@ -1092,9 +1112,36 @@ func (b *executorBuilder) buildAnalyze(v *plan.Analyze) Executor {
 			})
 		}
 	}
+	for _, task := range v.ExtendedTasks {
+		// The columns of all the groups are read by one table scan.
+		var cols []*model.ColumnInfo
+		for _, group := range task.ColumnGroups {
+			for _, col := range group {
+				if findColumnInfoByID(cols, col.ID) == nil {
+					cols = append(cols, col)
+				}
+			}
+		}
+		e.tasks = append(e.tasks, &analyzeTask{
+			taskType:     extendedTask,
+			src:          b.buildTableScanForAnalyze(task.TableInfo, nil, cols),
+			tableInfo:    task.TableInfo,
+			Columns:      cols,
+			ColumnGroups: task.ColumnGroups,
+		})
+	}
 	return e
 }
 
+func findColumnInfoByID(cols []*model.ColumnInfo, id int64) *model.ColumnInfo {
+	for _, col := range cols {
+		if col.ID == id {
+			return col
+		}
+	}
+	return nil
+}
+
 func (b *executorBuilder) constructDAGReq(plans []plan.PhysicalPlan) *tipb.DAGRequest {
 	dagReq := &tipb.DAGRequest{}
 	dagReq.StartTs = b.getStartTS()
@@ -1154,6 +1201,7 @@ func (b *executorBuilder) buildTableReader(v *plan.PhysicalTableReader) Executor
 		columns:   ts.Columns,
 		handleCol: handleCol,
 		priority:  b.priority,
+		feedback:  b.newTableScanFeedback(ts, len(v.TablePlans)),
 	}
 
 	for i := range v.Schema().Columns {
@@ -1190,6 +1238,7 @@ func (b *executorBuilder) buildIndexReader(v *plan.PhysicalIndexReader) Executor
 		columns:   is.Columns,
 		handleCol: handleCol,
 		priority:  b.priority,
+		feedback:  b.newIndexScanFeedback(is, len(v.IndexPlans)),
 	}
 
 	for _, col := range v.OutputColumns {
@@ -1219,6 +1268,7 @@ func (b *executorBuilder) buildIndexLookUpReader(v *plan.PhysicalIndexLookUpRead
 		handleCol = v.Schema().TblID2Handle[is.Table.ID][0]
 	}
 
+	feedback := b.newIndexScanFeedback(is, len(v.IndexPlans))
 	len := v.Schema().Len()
 	if handleIsExtra(handleCol) {
 		len--
@@ -1242,6 +1292,97 @@ func (b *executorBuilder) buildIndexLookUpReader(v *plan.PhysicalIndexLookUpRead
 		columns:      is.Columns,
 		handleCol:    handleCol,
 		priority:     b.priority,
+		feedback:     feedback,
+	}
+	return e
+}
+
+func (b *executorBuilder) buildIndexMergeReader(v *plan.PhysicalIndexMergeReader) Executor {
+	partialReqs := make([]*tipb.DAGRequest, 0, len(v.PartialPlans))
+	indices := make([]*model.IndexInfo, 0, len(v.PartialPlans))
+	ranges := make([][]*types.IndexRange, 0, len(v.PartialPlans))
+	for _, partialPlans := range v.PartialPlans {
+		partialReq := b.constructDAGReq(partialPlans)
+		if b.err != nil {
+			return nil
+		}
+		is := partialPlans[0].(*plan.PhysicalIndexScan)
+		partialReqs = append(partialReqs, partialReq)
+		indices = append(indices, is.Index)
+		ranges = append(ranges, is.Ranges)
+	}
+	tableReq := b.constructDAGReq(v.TablePlans)
+	if b.err != nil {
+		return nil
+	}
+	ts := v.TablePlans[0].(*plan.PhysicalTableScan)
+	table, _ := b.is.TableByID(ts.Table.ID)
+	var handleCol *expression.Column
+	if v.NeedColHandle {
+		handleCol = v.Schema().TblID2Handle[ts.Table.ID][0]
+	}
+
+	for i := range v.Schema().Columns {
+		if v.Schema().Columns[i].ID == model.ExtraHandleID {
+			break
+		}
+		tableReq.OutputOffsets = append(tableReq.OutputOffsets, uint32(i))
+	}
+
+	e := &IndexMergeReaderExecutor{
+		ctx:            b.ctx,
+		schema:         v.Schema(),
+		dagPBs:         partialReqs,
+		tableID:        ts.Table.ID,
+		table:          table,
+		indices:        indices,
+		ranges:         ranges,
+		isIntersection: v.IsIntersection,
+		tableRequest:   tableReq,
+		handleCol:      handleCol,
+		priority:       b.priority,
 	}
 	return e
 }
+
+// sampleFeedback decides if the scan collects the query feedback by tidb_feedback_probability.
+func (b *executorBuilder) sampleFeedback() bool {
+	prob := b.ctx.GetSessionVars().FeedbackProbability
+	return prob > 0 && rand.Float64() < prob
+}
+
+// newTableScanFeedback returns the feedback of the table scan if it's sampled. The rows are
+// counted by the ranges of the integer handle, so the feedback is only collected if the handle is
+// the primary key and the rows are not filtered in the coprocessor.
+func (b *executorBuilder) newTableScanFeedback(ts *plan.PhysicalTableScan, numCopPlans int) *statistics.QueryFeedback {
+	if numCopPlans > 1 || !ts.Table.PKIsHandle || !b.sampleFeedback() {
+		return nil
+	}
+	pk := ts.Table.GetPkColInfo()
+	if pk == nil {
+		return nil
+	}
+	statsTbl := sessionctx.GetDomain(b.ctx).StatsHandle().GetTableStats(ts.Table.ID)
+	col, ok := statsTbl.Columns[pk.ID]
+	if statsTbl.Pseudo || !ok {
+		return nil
+	}
+	q := statistics.NewQueryFeedback(ts.Table.ID, &col.Histogram, false)
+	q.SetIntRanges(ts.Ranges)
+	return q
+}
+
+// newIndexScanFeedback returns the feedback of the index scan if it's sampled. The index rows
+// returned only tell the count of all the ranges, so the feedback is only collected if the scan
+// has a single range and the rows are not filtered in the coprocessor.
+func (b *executorBuilder) newIndexScanFeedback(is *plan.PhysicalIndexScan, numCopPlans int) *statistics.QueryFeedback {
+	if numCopPlans > 1 || len(is.Ranges) != 1 || !b.sampleFeedback() {
+		return nil
+	}
+	statsTbl := sessionctx.GetDomain(b.ctx).StatsHandle().GetTableStats(is.Table.ID)
+	idx, ok := statsTbl.Indices[is.Index.ID]
+	if statsTbl.Pseudo || !ok {
+		return nil
+	}
+	return statistics.NewQueryFeedback(is.Table.ID, &idx.Histogram, true)
+}
@@ -0,0 +1,54 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/table"
+	"github.com/pingcap/tidb/table/tables"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// checkConstraintChecker checks the check constraints for the written rows.
+// The expressions of the check constraints are built once for every table in a statement.
+type checkConstraintChecker struct {
+	ctx    context.Context
+	checks map[int64][]*tables.CheckConstraintExpr
+}
+
+func newCheckConstraintChecker(ctx context.Context) *checkConstraintChecker {
+	return &checkConstraintChecker{
+		ctx:    ctx,
+		checks: make(map[int64][]*tables.CheckConstraintExpr),
+	}
+}
+
+// verify returns an error if the row to be written into `t` violates any check constraint of `t`.
+func (c *checkConstraintChecker) verify(t table.Table, row []types.Datum) error {
+	if len(t.Meta().CheckConstraints) == 0 {
+		return nil
+	}
+	tblID := t.Meta().ID
+	checks, ok := c.checks[tblID]
+	if !ok {
+		var err error
+		checks, err = tables.BuildCheckConstraintExprs(c.ctx, t)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		c.checks[tblID] = checks
+	}
+	return errors.Trace(tables.VerifyCheckConstraints(c.ctx, checks, row))
+}
@@ -31,11 +31,18 @@ type Compiler struct {
 // then wrappped to an adapter *statement as stmt.Statement.
 func (c *Compiler) Compile(ctx context.Context, node ast.StmtNode) (ast.Statement, error) {
 	is := GetInfoSchema(ctx)
-	if err := plan.Preprocess(node, is, ctx); err != nil {
+	staleIS, err := prepareStaleRead(ctx, node)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if staleIS != nil {
+		is = staleIS
+	}
+	if err = plan.Preprocess(node, is, ctx); err != nil {
 		return nil, errors.Trace(err)
 	}
 	// Validate should be after NameResolve.
-	if err := plan.Validate(node, false); err != nil {
+	if err = plan.Validate(node, false); err != nil {
 		return nil, errors.Trace(err)
 	}
 	p, err := plan.Optimize(ctx, node, is)
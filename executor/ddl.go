@@ -68,6 +68,8 @@ func (e *DDLExec) Next() (Row, error) {
 		err = e.executeAlterTable(x)
 	case *ast.RenameTableStmt:
 		err = e.executeRenameTable(x)
+	case *ast.RecoverTableStmt:
+		err = e.executeRecoverTable(x)
 	}
 	if err != nil {
 		return nil, errors.Trace(err)
@@ -101,6 +103,12 @@ func (e *DDLExec) executeTruncateTable(s *ast.TruncateTableStmt) error {
 	return errors.Trace(err)
 }
 
+func (e *DDLExec) executeRecoverTable(s *ast.RecoverTableStmt) error {
+	ident := ast.Ident{Schema: s.Table.Schema, Name: s.Table.Name}
+	err := sessionctx.GetDomain(e.ctx).DDL().RecoverTable(e.ctx, ident, s.NewName)
+	return errors.Trace(err)
+}
+
 func (e *DDLExec) executeRenameTable(s *ast.RenameTableStmt) error {
 	if len(s.TableToTables) != 1 {
 		// Now we only allow one schema changing at the same time.
@@ -67,6 +67,16 @@ var (
 	ErrBuildExecutor        = terror.ClassExecutor.New(codeErrBuildExec, "Failed to build executor")
 	ErrBatchInsertFail      = terror.ClassExecutor.New(codeBatchInsertFail, "Batch insert failed, please clean the table and try again.")
 	ErrWrongValueCountOnRow = terror.ClassExecutor.New(codeWrongValueCountOnRow, "Column count doesn't match value count at row %d")
+	ErrFKDepthExceeded      = terror.ClassExecutor.New(codeFKDepthExceeded, "Foreign key cascade delete/update exceeds max depth of %d.")
+	ErrRowIsReferenced      = terror.ClassExecutor.New(codeRowIsReferenced, mysql.MySQLErrName[mysql.ErrRowIsReferenced2])
+	ErrNoReferencedRow      = terror.ClassExecutor.New(codeNoReferencedRow, mysql.MySQLErrName[mysql.ErrNoReferencedRow2])
+	ErrFKNoIndexParent      = terror.ClassExecutor.New(codeFKNoIndexParent, "Missing index for constraint '%s' in the referenced table '%s'")
+	ErrSavepointNotExists   = terror.ClassExecutor.New(codeSavepointNotExists, mysql.MySQLErrName[mysql.ErrSpDoesNotExist])
+	ErrAsOfTimestamp        = terror.ClassExecutor.New(codeAsOfTimestamp, "invalid AS OF TIMESTAMP")
+	ErrStoreNotSupported    = terror.ClassExecutor.New(codeStoreNotSupported, "Variable '%s' can't be set to '%s', the storage doesn't support it")
+	ErrPessimisticTxn       = terror.ClassExecutor.New(codePessimisticTxn, "Pessimistic transactions are not supported by the storage")
+
+	ErrCantExecuteInReadOnlyTxn = terror.ClassExecutor.New(codeCantExecuteInReadOnlyTxn, mysql.MySQLErrName[mysql.ErrCantExecuteInReadOnlyTransaction])
 )
 
 // Error codes.
@@ -81,9 +91,19 @@ const (
 	codeResultIsEmpty        terror.ErrCode = 8
 	codeErrBuildExec         terror.ErrCode = 9
 	codeBatchInsertFail      terror.ErrCode = 10
+	codeFKDepthExceeded      terror.ErrCode = 11
+	codeAsOfTimestamp        terror.ErrCode = 12
+	codeStoreNotSupported    terror.ErrCode = 13
+	codePessimisticTxn       terror.ErrCode = 14
 	CodePasswordNoMatch      terror.ErrCode = 1133 // MySQL error code
 	CodeCannotUser           terror.ErrCode = 1396 // MySQL error code
 	codeWrongValueCountOnRow terror.ErrCode = 1136 // MySQL error code
+	codeRowIsReferenced      terror.ErrCode = 1451 // MySQL error code
+	codeNoReferencedRow      terror.ErrCode = 1452 // MySQL error code
+	codeFKNoIndexParent      terror.ErrCode = 1822 // MySQL error code
+	codeSavepointNotExists   terror.ErrCode = 1305 // MySQL error code
+
+	codeCantExecuteInReadOnlyTxn terror.ErrCode = 1792 // MySQL error code
 )
 
 // Row represents a result set row, it may be returned from a table, a join, or a projection.
@@ -363,6 +383,12 @@ func init() {
 		CodeCannotUser:           mysql.ErrCannotUser,
 		CodePasswordNoMatch:      mysql.ErrPasswordNoMatch,
 		codeWrongValueCountOnRow: mysql.ErrWrongValueCountOnRow,
+		codeRowIsReferenced:      mysql.ErrRowIsReferenced2,
+		codeNoReferencedRow:      mysql.ErrNoReferencedRow2,
+		codeFKNoIndexParent:      mysql.ErrFkNoIndexParent,
+		codeSavepointNotExists:   mysql.ErrSpDoesNotExist,
+
+		codeCantExecuteInReadOnlyTxn: mysql.ErrCantExecuteInReadOnlyTransaction,
 	}
 	terror.ErrClassToMySQLCodes[terror.ClassExecutor] = tableMySQLErrCodes
 }
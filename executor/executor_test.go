@@ -1033,6 +1033,41 @@ func (s *testSuite) TestIndexReverseOrder(c *C) {
 	result.Check(testkit.Rows("0 2", "0 1", "0 0", "1 2", "1 1", "1 0", "2 2", "2 1", "2 0"))
 }
 
+func (s *testSuite) TestIndexMerge(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (a int, b int, c int, index ia (a), index ib (b))")
+	tk.MustExec("insert t values (1, 1, 1), (1, 2, 2), (2, 1, 3), (2, 2, 4), (3, 3, 5), (1, 1, 6)")
+	result := tk.MustQuery("select /*+ TIDB_INDEX_MERGE(t) */ c from t where a = 1 or b = 1 order by c")
+	result.Check(testkit.Rows("1", "2", "3", "6"))
+	result = tk.MustQuery("select /*+ TIDB_INDEX_MERGE(t) */ c from t where a = 1 and b = 1 order by c")
+	result.Check(testkit.Rows("1", "6"))
+	result = tk.MustQuery("select /*+ TIDB_INDEX_MERGE(t) */ c from t where (a = 1 or b = 3) and c > 1 order by c")
+	result.Check(testkit.Rows("2", "5", "6"))
+	result = tk.MustQuery("select /*+ TIDB_INDEX_MERGE(t) */ c from t where a = 3 and b = 1")
+	result.Check(testkit.Rows())
+	result = tk.MustQuery("select /*+ TIDB_INDEX_MERGE(t) */ c from t where a = 4 or b = 4")
+	result.Check(testkit.Rows())
+	result = tk.MustQuery("select /*+ TIDB_INDEX_MERGE(t) */ count(*) from t where a in (1, 2) or b > 1")
+	result.Check(testkit.Rows("6"))
+
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (a int primary key, b int, c int, index ib (b), index ic (c))")
+	tk.MustExec("insert t values (1, 1, 1), (2, 1, 2), (3, 2, 1), (4, 2, 2)")
+	result = tk.MustQuery("select /*+ TIDB_INDEX_MERGE(t) */ a from t where b = 1 or c = 1 order by a")
+	result.Check(testkit.Rows("1", "2", "3"))
+	result = tk.MustQuery("select /*+ TIDB_INDEX_MERGE(t) */ * from t where b = 2 and c = 2")
+	result.Check(testkit.Rows("4 2 2"))
+
+	// The index merge isn't used on the dirty table, but the result is the same.
+	tk.MustExec("begin")
+	tk.MustExec("insert t values (5, 1, 3)")
+	result = tk.MustQuery("select /*+ TIDB_INDEX_MERGE(t) */ a from t where b = 1 or c = 1 order by a")
+	result.Check(testkit.Rows("1", "2", "3", "5"))
+	tk.MustExec("rollback")
+}
+
 func (s *testSuite) TestTableReverseOrder(c *C) {
 	tk := testkit.NewTestKit(c, s.store)
 	tk.MustExec("use test")
@@ -1693,6 +1728,80 @@ func (s *testSuite) TestHistoryRead(c *C) {
 	tk.MustQuery("select * from history_read order by a").Check(testkit.Rows("2 <nil>", "4 <nil>", "8 8", "9 9"))
 }
 
+func (s *testSuite) TestStaleRead(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists stale_read")
+	tk.MustExec("create table stale_read (a int primary key, b int)")
+	tk.MustExec("insert stale_read values (1, 1)")
+
+	// For mocktikv, safe point is not initialized, we manually insert it for snapshot to use.
+	updateSafePoint := fmt.Sprintf(`INSERT INTO mysql.tidb VALUES ('tikv_gc_safe_point', '%[1]s', '')
+	ON DUPLICATE KEY
+	UPDATE variable_value = '%[1]s'`, "20060102-15:04:05 -0700 MST")
+	tk.MustExec(updateSafePoint)
+	_, err := tk.Exec("select * from stale_read as of timestamp '2006-01-01 15:04:05'")
+	c.Assert(terror.ErrorEqual(err, variable.ErrSnapshotTooOld), IsTrue)
+	_, err = tk.Exec("select * from stale_read as of timestamp date_add(now(), interval 1 day)")
+	c.Assert(terror.ErrorEqual(err, executor.ErrAsOfTimestamp), IsTrue)
+
+	time.Sleep(time.Millisecond)
+	ts1 := time.Now().Format("2006-01-02 15:04:05.999999")
+	time.Sleep(time.Millisecond)
+	tk.MustExec("insert stale_read values (2, 2)")
+	tk.MustExec("alter table stale_read add column c int")
+	tk.MustExec("update stale_read set c = 3")
+	time.Sleep(time.Millisecond)
+	ts2 := time.Now().Format("2006-01-02 15:04:05.999999")
+	time.Sleep(time.Millisecond)
+	tk.MustExec("delete from stale_read where a = 1")
+
+	// The statements read the data and the schema at the timestamp.
+	tk.MustQuery("select * from stale_read as of timestamp '" + ts1 + "'").Check(testkit.Rows("1 1"))
+	tk.MustQuery("select * from stale_read as of timestamp '" + ts2 + "' t where t.a > 0").Check(testkit.Rows("1 1 3", "2 2 3"))
+	tk.MustQuery("select * from stale_read where a = 2").Check(testkit.Rows("2 2 3"))
+	tk.MustQuery("select * from stale_read as of timestamp '" + ts1 + "' where a = 2").Check(testkit.Rows())
+	tk.MustQuery("select count(*) from stale_read as of timestamp '" + ts2 + "' t1 join stale_read as of timestamp '" + ts2 + "' t2").Check(testkit.Rows("4"))
+	_, err = tk.Exec("select * from stale_read as of timestamp '" + ts1 + "' t1, stale_read as of timestamp '" + ts2 + "' t2")
+	c.Assert(terror.ErrorEqual(err, executor.ErrAsOfTimestamp), IsTrue)
+	_, err = tk.Exec("select * from stale_read as of timestamp '" + ts1 + "' for update")
+	c.Assert(terror.ErrorEqual(err, executor.ErrAsOfTimestamp), IsTrue)
+	_, err = tk.Exec("insert stale_read select a + 10, b, c from stale_read as of timestamp '" + ts2 + "'")
+	c.Assert(terror.ErrorEqual(err, executor.ErrAsOfTimestamp), IsTrue)
+	tk.MustExec("begin")
+	_, err = tk.Exec("select * from stale_read as of timestamp '" + ts1 + "'")
+	c.Assert(terror.ErrorEqual(err, executor.ErrAsOfTimestamp), IsTrue)
+	tk.MustExec("rollback")
+	_, err = tk.Exec("prepare stmt from \"select * from stale_read as of timestamp '" + ts1 + "'\"")
+	c.Assert(terror.ErrorEqual(err, executor.ErrAsOfTimestamp), IsTrue)
+	_, err = tk.Exec("prepare stmt from 'select * from stale_read as of timestamp ?'")
+	c.Assert(terror.ErrorEqual(err, executor.ErrAsOfTimestamp), IsTrue)
+
+	// The READ ONLY transaction reads at the timestamp.
+	tk.MustExec("prepare stmt from 'select * from stale_read where a = ?'")
+	tk.MustExec("set @a = 1")
+	tk.MustExec("start transaction read only as of timestamp '" + ts1 + "'")
+	tk.MustQuery("select * from stale_read").Check(testkit.Rows("1 1"))
+	tk.MustQuery("execute stmt using @a").Check(testkit.Rows("1 1"))
+	tk.MustQuery("select * from stale_read where a = 1").Check(testkit.Rows("1 1"))
+	_, err = tk.Exec("insert stale_read values (3, 3)")
+	c.Assert(terror.ErrorEqual(err, executor.ErrCantExecuteInReadOnlyTxn), IsTrue)
+	_, err = tk.Exec("select * from stale_read for update")
+	c.Assert(terror.ErrorEqual(err, executor.ErrCantExecuteInReadOnlyTxn), IsTrue)
+	tk.MustExec("commit")
+	tk.MustQuery("select * from stale_read").Check(testkit.Rows("2 2 3"))
+
+	tk.MustExec("start transaction read only")
+	tk.MustQuery("select * from stale_read").Check(testkit.Rows("2 2 3"))
+	_, err = tk.Exec("update stale_read set b = 3")
+	c.Assert(terror.ErrorEqual(err, executor.ErrCantExecuteInReadOnlyTxn), IsTrue)
+	// BEGIN implicitly commits the READ ONLY transaction.
+	tk.MustExec("begin")
+	tk.MustExec("update stale_read set b = 3")
+	tk.MustExec("commit")
+	tk.MustQuery("select * from stale_read").Check(testkit.Rows("2 3 3"))
+}
+
 func (s *testSuite) TestScanControlSelection(c *C) {
 	tk := testkit.NewTestKit(c, s.store)
 	tk.MustExec("use test")
@@ -0,0 +1,498 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/infoschema"
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/table"
+	"github.com/pingcap/tidb/tablecodec"
+	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/codec"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// fkCascadeMaxDepth is the max depth of the cascading foreign key actions, it's the same as MySQL.
+const fkCascadeMaxDepth = 15
+
+// fkChecker checks the foreign key constraints for the written rows. When a parent row is
+// deleted or updated, it applies the referential actions to the child rows referring to it.
+// The parent table and the child table are always in the same schema.
+type fkChecker struct {
+	ctx context.Context
+	is  infoschema.InfoSchema
+	// dbNames maps the table ID to its schema name.
+	dbNames map[int64]model.CIStr
+	// childFKs maps the table ID to the foreign keys referring to the table.
+	childFKs map[int64][]*childFK
+}
+
+// childFK is a foreign key of the child table.
+type childFK struct {
+	tbl table.Table
+	fk  *model.FKInfo
+}
+
+func newFKChecker(ctx context.Context) *fkChecker {
+	return &fkChecker{
+		ctx:      ctx,
+		dbNames:  make(map[int64]model.CIStr),
+		childFKs: make(map[int64][]*childFK),
+	}
+}
+
+func (c *fkChecker) enabled() bool {
+	return c.ctx.GetSessionVars().ForeignKeyChecks
+}
+
+func (c *fkChecker) infoSchema() infoschema.InfoSchema {
+	if c.is == nil {
+		c.is = GetInfoSchema(c.ctx)
+	}
+	return c.is
+}
+
+// onInsert checks the parent rows of the row to be inserted into `t` exist.
+func (c *fkChecker) onInsert(t table.Table, row []types.Datum) error {
+	if !c.enabled() {
+		return nil
+	}
+	for _, fk := range t.Meta().ForeignKeys {
+		if fk.State != model.StatePublic {
+			continue
+		}
+		if err := c.checkParentExists(t, fk, row); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// onUpdate checks the parent rows of the updated row exist, and applies the ON UPDATE actions
+// to the child rows referring to the old row. `modified` means which columns are really modified.
+func (c *fkChecker) onUpdate(t table.Table, h int64, oldRow, newRow []types.Datum, modified []bool) error {
+	if !c.enabled() {
+		return nil
+	}
+	for _, fk := range t.Meta().ForeignKeys {
+		if fk.State != model.StatePublic {
+			continue
+		}
+		cols, err := table.FindCols(t.Cols(), cistrsToStrings(fk.Cols))
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if !isAnyColumnModified(cols, modified) {
+			continue
+		}
+		if err = c.checkParentExists(t, fk, newRow); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return errors.Trace(c.onParentChanged(t, h, oldRow, newRow, 0))
+}
+
+// onDelete applies the ON DELETE actions to the child rows referring to the row to be deleted.
+func (c *fkChecker) onDelete(t table.Table, h int64, row []types.Datum) error {
+	if !c.enabled() {
+		return nil
+	}
+	return errors.Trace(c.onParentChanged(t, h, row, nil, 0))
+}
+
+func (c *fkChecker) checkParentExists(t table.Table, fk *model.FKInfo, row []types.Datum) error {
+	vals, err := columnValues(t, fk.Cols, row)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	// A row with NULL foreign key values doesn't refer to any parent row.
+	if hasNullDatum(vals) {
+		return nil
+	}
+	dbName, err := c.dbName(t)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	desc := fkDescription(dbName, t, fk)
+	parent, err := c.infoSchema().TableByName(dbName, fk.RefTable)
+	if err != nil {
+		return ErrNoReferencedRow.GenByArgs(desc)
+	}
+	// The parent rows are looked up by index like MySQL, the table isn't scanned for every written row.
+	handles, err := c.fetchHandles(parent, fk.RefCols, vals, 1, false)
+	if err != nil {
+		if terror.ErrorEqual(err, errNoIndex) {
+			return ErrFKNoIndexParent.GenByArgs(fk.Name, fk.RefTable)
+		}
+		return errors.Trace(err)
+	}
+	if len(handles) == 0 {
+		return ErrNoReferencedRow.GenByArgs(desc)
+	}
+	// Lock the parent row, so the transaction conflicts with the ones deleting or updating it.
+	return errors.Trace(c.ctx.Txn().LockKeys(parent.RecordKey(handles[0])))
+}
+
+// onParentChanged applies the referential actions to the child rows referring to the row `h` of `t`.
+// `newRow` is nil if the row is deleted.
+func (c *fkChecker) onParentChanged(t table.Table, h int64, oldRow, newRow []types.Datum, depth int) error {
+	childFKs, err := c.getChildFKs(t)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if len(childFKs) > 0 && depth >= fkCascadeMaxDepth {
+		return ErrFKDepthExceeded.GenByArgs(fkCascadeMaxDepth)
+	}
+	sc := c.ctx.GetSessionVars().StmtCtx
+	for _, cfk := range childFKs {
+		oldVals, err := columnValues(t, cfk.fk.RefCols, oldRow)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if hasNullDatum(oldVals) {
+			continue
+		}
+		var newVals []types.Datum
+		opt := ast.ReferOptionType(cfk.fk.OnDelete)
+		if newRow != nil {
+			newVals, err = columnValues(t, cfk.fk.RefCols, newRow)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			unchanged, err := types.EqualDatums(sc, oldVals, newVals)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			if unchanged {
+				continue
+			}
+			opt = ast.ReferOptionType(cfk.fk.OnUpdate)
+		}
+		handles, err := c.fetchHandles(cfk.tbl, cfk.fk.Cols, oldVals, 0, true)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		for _, ch := range handles {
+			// The self-referencing row doesn't block itself.
+			if cfk.tbl.Meta().ID == t.Meta().ID && ch == h {
+				continue
+			}
+			switch opt {
+			case ast.ReferOptionCascade:
+				if newRow == nil {
+					err = c.cascadeDelete(cfk.tbl, ch, depth)
+				} else {
+					err = c.cascadeUpdate(cfk.tbl, cfk.fk, ch, newVals, depth)
+				}
+			case ast.ReferOptionSetNull:
+				nullVals := make([]types.Datum, len(cfk.fk.Cols))
+				err = c.cascadeUpdate(cfk.tbl, cfk.fk, ch, nullVals, depth)
+			default:
+				// RESTRICT, NO ACTION and no option are the same, the check isn't deferred.
+				dbName, err1 := c.dbName(cfk.tbl)
+				if err1 != nil {
+					return errors.Trace(err1)
+				}
+				return ErrRowIsReferenced.GenByArgs(fkDescription(dbName, cfk.tbl, cfk.fk))
+			}
+			if err != nil {
+				return errors.Trace(err)
+			}
+		}
+	}
+	return nil
+}
+
+func (c *fkChecker) cascadeDelete(t table.Table, h int64, depth int) error {
+	row, err := t.RowWithCols(c.ctx, h, t.WritableCols())
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err = c.onParentChanged(t, h, row, nil, depth+1); err != nil {
+		return errors.Trace(err)
+	}
+	if err = t.RemoveRecord(c.ctx, h, row); err != nil {
+		return errors.Trace(err)
+	}
+	getDirtyDB(c.ctx).deleteRow(t.Meta().ID, h)
+	c.ctx.GetSessionVars().TxnCtx.UpdateDeltaForTable(t.Meta().ID, -1, 1)
+	return nil
+}
+
+// cascadeUpdate sets the foreign key columns of the child row `h` to `vals`.
+func (c *fkChecker) cascadeUpdate(t table.Table, fk *model.FKInfo, h int64, vals []types.Datum, depth int) error {
+	oldRow, err := t.RowWithCols(c.ctx, h, t.WritableCols())
+	if err != nil {
+		return errors.Trace(err)
+	}
+	cols, err := table.FindCols(t.Cols(), cistrsToStrings(fk.Cols))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	newRow := make([]types.Datum, len(oldRow))
+	copy(newRow, oldRow)
+	touched := make([]bool, len(oldRow))
+	handleChanged := false
+	for i, col := range cols {
+		v, err := table.CastValue(c.ctx, vals[i], col.ToInfo())
+		if err != nil {
+			return errors.Trace(err)
+		}
+		newRow[col.Offset] = v
+		touched[col.Offset] = true
+		if col.IsPKHandleColumn(t.Meta()) {
+			handleChanged = true
+		}
+	}
+	if err = table.CheckNotNull(t.Cols(), newRow); err != nil {
+		return errors.Trace(err)
+	}
+	if err = c.onParentChanged(t, h, oldRow, newRow, depth+1); err != nil {
+		return errors.Trace(err)
+	}
+	newHandle := h
+	if handleChanged {
+		newHandle, err = t.AddRecord(c.ctx, newRow)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		err = t.RemoveRecord(c.ctx, h, oldRow)
+	} else {
+		err = t.UpdateRecord(c.ctx, h, oldRow, newRow, touched)
+	}
+	if err != nil {
+		return errors.Trace(err)
+	}
+	dirtyDB := getDirtyDB(c.ctx)
+	dirtyDB.deleteRow(t.Meta().ID, h)
+	dirtyDB.addRow(t.Meta().ID, newHandle, newRow)
+	c.ctx.GetSessionVars().TxnCtx.UpdateDeltaForTable(t.Meta().ID, 0, 1)
+	return nil
+}
+
+// errNoIndex is returned by fetchHandles if there is no index on the columns and the table can't be scanned.
+var errNoIndex = errors.New("no index on the columns")
+
+// fetchHandles returns the handles of the rows in `t` whose columns `colNames` equal to `vals`.
+// If limit is positive, at most limit handles are returned. If there is no index on the columns,
+// the table is scanned if allowScan is true.
+func (c *fkChecker) fetchHandles(t table.Table, colNames []model.CIStr, vals []types.Datum, limit int, allowScan bool) ([]int64, error) {
+	cols, err := table.FindCols(t.Cols(), cistrsToStrings(colNames))
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	casted := make([]types.Datum, len(vals))
+	for i, col := range cols {
+		casted[i], err = table.CastValue(c.ctx, vals[i], col.ToInfo())
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	if len(cols) == 1 && cols[0].IsPKHandleColumn(t.Meta()) {
+		h := casted[0].GetInt64()
+		_, err = c.ctx.Txn().Get(t.RecordKey(h))
+		if err != nil {
+			if kv.IsErrNotFound(err) {
+				return nil, nil
+			}
+			return nil, errors.Trace(err)
+		}
+		return []int64{h}, nil
+	}
+	if idx, idxVals := findIndexByColumns(t, cols, casted); idx != nil {
+		return c.fetchHandlesByIndex(t, idx, idxVals, limit)
+	}
+	if !allowScan {
+		return nil, errNoIndex
+	}
+	return c.fetchHandlesByScan(t, cols, casted, limit)
+}
+
+// fetchHandlesByIndex seeks the index by the values of its leading columns.
+func (c *fkChecker) fetchHandlesByIndex(t table.Table, idx *model.IndexInfo, vals []types.Datum, limit int) ([]int64, error) {
+	encoded, err := codec.EncodeKey(nil, vals...)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	prefix := tablecodec.EncodeIndexSeekKey(t.Meta().ID, idx.ID, encoded)
+	it, err := c.ctx.Txn().Seek(prefix)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer it.Close()
+	var handles []int64
+	for it.Valid() && it.Key().HasPrefix(prefix) {
+		var remain []types.Datum
+		if suffix := it.Key()[len(prefix):]; len(suffix) > 0 {
+			remain, err = codec.Decode(suffix, len(idx.Columns)-len(vals)+1)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+		// If the index is unique and contains no NULL value, the handle is stored in the value,
+		// otherwise it's the last datum of the key.
+		var h int64
+		if len(remain) > len(idx.Columns)-len(vals) {
+			h = remain[len(remain)-1].GetInt64()
+		} else {
+			h = int64(binary.BigEndian.Uint64(it.Value()))
+		}
+		handles = append(handles, h)
+		if limit > 0 && len(handles) >= limit {
+			break
+		}
+		if err = it.Next(); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	return handles, nil
+}
+
+// fetchHandlesByScan scans the whole table, it's used when there is no index on the columns.
+func (c *fkChecker) fetchHandlesByScan(t table.Table, cols []*table.Column, vals []types.Datum, limit int) ([]int64, error) {
+	sc := c.ctx.GetSessionVars().StmtCtx
+	var handles []int64
+	err := t.IterRecords(c.ctx, t.FirstKey(), t.Cols(), func(h int64, rec []types.Datum, _ []*table.Column) (bool, error) {
+		for i, col := range cols {
+			cmp, err := rec[col.Offset].CompareDatum(sc, vals[i])
+			if err != nil {
+				return false, errors.Trace(err)
+			}
+			if cmp != 0 {
+				return true, nil
+			}
+		}
+		handles = append(handles, h)
+		return limit <= 0 || len(handles) < limit, nil
+	})
+	return handles, errors.Trace(err)
+}
+
+// getChildFKs gets the foreign keys referring to `t`.
+func (c *fkChecker) getChildFKs(t table.Table) ([]*childFK, error) {
+	tblInfo := t.Meta()
+	if fks, ok := c.childFKs[tblInfo.ID]; ok {
+		return fks, nil
+	}
+	dbName, err := c.dbName(t)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	var fks []*childFK
+	for _, tbl := range c.infoSchema().SchemaTables(dbName) {
+		for _, fk := range tbl.Meta().ForeignKeys {
+			if fk.State == model.StatePublic && fk.RefTable.L == tblInfo.Name.L {
+				fks = append(fks, &childFK{tbl: tbl, fk: fk})
+			}
+		}
+	}
+	c.childFKs[tblInfo.ID] = fks
+	return fks, nil
+}
+
+func (c *fkChecker) dbName(t table.Table) (model.CIStr, error) {
+	id := t.Meta().ID
+	if name, ok := c.dbNames[id]; ok {
+		return name, nil
+	}
+	for _, db := range c.infoSchema().AllSchemas() {
+		for _, tblInfo := range db.Tables {
+			if tblInfo.ID == id {
+				c.dbNames[id] = db.Name
+				return db.Name, nil
+			}
+		}
+	}
+	return model.CIStr{}, errors.Errorf("can't find the schema of table %s", t.Meta().Name)
+}
+
+// findIndexByColumns finds a public index whose leading columns are `cols`, in any order.
+// It returns the index and the values ordered by the index columns.
+func findIndexByColumns(t table.Table, cols []*table.Column, vals []types.Datum) (*model.IndexInfo, []types.Datum) {
+	for _, idx := range t.Indices() {
+		idxInfo := idx.Meta()
+		if idxInfo.State != model.StatePublic || len(idxInfo.Columns) < len(cols) {
+			continue
+		}
+		idxVals := make([]types.Datum, 0, len(cols))
+		for _, idxCol := range idxInfo.Columns[:len(cols)] {
+			if idxCol.Length != types.UnspecifiedLength {
+				break
+			}
+			for i, col := range cols {
+				if col.Name.L == idxCol.Name.L {
+					idxVals = append(idxVals, vals[i])
+					break
+				}
+			}
+		}
+		if len(idxVals) == len(cols) {
+			return idxInfo, idxVals
+		}
+	}
+	return nil, nil
+}
+
+func columnValues(t table.Table, colNames []model.CIStr, row []types.Datum) ([]types.Datum, error) {
+	cols, err := table.FindCols(t.Cols(), cistrsToStrings(colNames))
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	vals := make([]types.Datum, len(cols))
+	for i, col := range cols {
+		vals[i] = row[col.Offset]
+	}
+	return vals, nil
+}
+
+func isAnyColumnModified(cols []*table.Column, modified []bool) bool {
+	for _, col := range cols {
+		if modified[col.Offset] {
+			return true
+		}
+	}
+	return false
+}
+
+func hasNullDatum(vals []types.Datum) bool {
+	for _, v := range vals {
+		if v.IsNull() {
+			return true
+		}
+	}
+	return false
+}
+
+func cistrsToStrings(names []model.CIStr) []string {
+	strs := make([]string, len(names))
+	for i, name := range names {
+		strs[i] = name.O
+	}
+	return strs
+}
+
+// fkDescription describes the foreign key in the same format as MySQL error messages.
+func fkDescription(dbName model.CIStr, t table.Table, fk *model.FKInfo) string {
+	return fmt.Sprintf("`%s`.`%s`, CONSTRAINT `%s` FOREIGN KEY (`%s`) REFERENCES `%s` (`%s`)",
+		dbName.O, t.Meta().Name.O, fk.Name.O, strings.Join(cistrsToStrings(fk.Cols), "`, `"),
+		fk.RefTable.O, strings.Join(cistrsToStrings(fk.RefCols), "`, `"))
+}
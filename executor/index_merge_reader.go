@@ -0,0 +1,204 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"sync"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/distsql"
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/sessionctx/varsutil"
+	"github.com/pingcap/tidb/table"
+	"github.com/pingcap/tidb/util/types"
+	"github.com/pingcap/tipb/go-tipb"
+	goctx "golang.org/x/net/context"
+)
+
+var _ Executor = &IndexMergeReaderExecutor{}
+
+// IndexMergeReaderExecutor reads the table by the handles of several index scans. The index scans run
+// concurrently, then their handles are unioned or intersected, and the table is read by the merged handles once.
+type IndexMergeReaderExecutor struct {
+	table          table.Table
+	tableID        int64
+	indices        []*model.IndexInfo
+	ranges         [][]*types.IndexRange
+	dagPBs         []*tipb.DAGRequest
+	isIntersection bool
+	ctx            context.Context
+	schema         *expression.Schema
+	// This is the column that represent the handle, we can use handleCol.Index to know its position.
+	handleCol    *expression.Column
+	tableRequest *tipb.DAGRequest
+	priority     int
+	// All fields above is immutable.
+
+	// partialHandles and partialErrs are the results of the index scans, they are read after wg is done.
+	partialHandles [][]int64
+	partialErrs    []error
+	wg             sync.WaitGroup
+	cancel         goctx.CancelFunc
+
+	merged      bool
+	tableReader *TableReaderExecutor
+}
+
+// Schema implements the Executor Schema interface.
+func (e *IndexMergeReaderExecutor) Schema() *expression.Schema {
+	return e.schema
+}
+
+// Open implements the Executor Open interface.
+func (e *IndexMergeReaderExecutor) Open() error {
+	kvRangesList := make([][]kv.KeyRange, 0, len(e.indices))
+	for i, index := range e.indices {
+		fieldTypes := make([]*types.FieldType, len(index.Columns))
+		for j, v := range index.Columns {
+			fieldTypes[j] = &(e.table.Cols()[v.Offset].FieldType)
+		}
+		kvRanges, err := indexRangesToKVRanges(e.ctx.GetSessionVars().StmtCtx, e.tableID, index.ID, e.ranges[i], fieldTypes)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		kvRangesList = append(kvRangesList, kvRanges)
+	}
+	goCtx, cancel := goctx.WithCancel(e.ctx.GoCtx())
+	e.cancel = cancel
+	e.merged = false
+	e.partialHandles = make([][]int64, len(e.indices))
+	e.partialErrs = make([]error, len(e.indices))
+	e.wg.Add(len(e.indices))
+	for i := range e.indices {
+		go func(i int) {
+			e.partialHandles[i], e.partialErrs[i] = e.fetchHandles(e.dagPBs[i], kvRangesList[i], goCtx)
+			e.wg.Done()
+		}(i)
+	}
+	return nil
+}
+
+// fetchHandles fetches all the handles of an index scan.
+func (e *IndexMergeReaderExecutor) fetchHandles(dagPB *tipb.DAGRequest, kvRanges []kv.KeyRange, goCtx goctx.Context) ([]int64, error) {
+	result, err := distsql.SelectDAG(e.ctx.GetClient(), goCtx, dagPB, kvRanges, e.ctx.GetSessionVars().DistSQLScanConcurrency,
+		false, false, getIsolationLevel(e.ctx.GetSessionVars()), e.priority, varsutil.GetReplicaRead(e.ctx.GetSessionVars()))
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	result.Fetch(goCtx)
+	defer func() {
+		if err := result.Close(); err != nil {
+			log.Error("close SelectDAG result failed:", errors.ErrorStack(err))
+		}
+	}()
+	var handles []int64
+	for {
+		partialHandles, finish, err := extractHandlesFromIndexResult(result)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if finish {
+			return handles, nil
+		}
+		handles = append(handles, partialHandles...)
+	}
+}
+
+// mergeHandles waits for the index scans, and unions or intersects their handles.
+func (e *IndexMergeReaderExecutor) mergeHandles() ([]int64, error) {
+	e.wg.Wait()
+	for _, err := range e.partialErrs {
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	// For intersection, seen[h] is the number of the leading index scans returning h, so a handle is counted
+	// once even if an index scan returns it repeatedly.
+	seen := make(map[int64]int)
+	var handles []int64
+	for i, partialHandles := range e.partialHandles {
+		for _, h := range partialHandles {
+			count, ok := seen[h]
+			if !e.isIntersection {
+				if !ok {
+					seen[h] = 1
+					handles = append(handles, h)
+				}
+			} else if count == i {
+				seen[h] = i + 1
+			}
+		}
+	}
+	if e.isIntersection {
+		for h, count := range seen {
+			if count == len(e.partialHandles) {
+				handles = append(handles, h)
+			}
+		}
+	}
+	return handles, nil
+}
+
+// Next implements the Executor Next interface.
+func (e *IndexMergeReaderExecutor) Next() (Row, error) {
+	if !e.merged {
+		e.merged = true
+		handles, err := e.mergeHandles()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if len(handles) == 0 {
+			return nil, nil
+		}
+		e.tableReader = &TableReaderExecutor{
+			table:     e.table,
+			tableID:   e.tableID,
+			dagPB:     e.tableRequest,
+			schema:    e.schema,
+			ctx:       e.ctx,
+			handleCol: e.handleCol,
+			priority:  e.priority,
+		}
+		err = e.tableReader.doRequestForHandles(handles, e.ctx.GoCtx())
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	if e.tableReader == nil {
+		return nil, nil
+	}
+	row, err := e.tableReader.Next()
+	return row, errors.Trace(err)
+}
+
+// Close implements the Executor Close interface.
+func (e *IndexMergeReaderExecutor) Close() error {
+	if e.cancel != nil {
+		e.cancel()
+		e.wg.Wait()
+		e.cancel = nil
+	}
+	e.partialHandles = nil
+	e.partialErrs = nil
+	if e.tableReader != nil {
+		err := e.tableReader.Close()
+		e.tableReader = nil
+		return errors.Trace(err)
+	}
+	return nil
+}
@@ -456,6 +456,26 @@ AND b44=a42`)
 	result.Check(testkit.Rows("7 7 7 7 7 7 7 7 7 7 7 7 7 7 7 7 7 7 7 7 7"))
 }
 
+func (s *testSuite) TestOuterJoinReorder(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t1, t2, t3")
+	tk.MustExec("create table t1 (a int, b int)")
+	tk.MustExec("create table t2 (a int, b int)")
+	tk.MustExec("create table t3 (a int, b int)")
+	tk.MustExec("insert into t1 values (1, 1), (2, 2), (3, null)")
+	tk.MustExec("insert into t2 values (1, 10), (1, 11), (3, 12)")
+	tk.MustExec("insert into t3 values (1, 1), (2, 10), (12, 12)")
+	tk.MustQuery("select t1.a, t2.b, t3.b from t1 left join t2 on t1.a = t2.a left join t3 on t1.b = t3.a order by t1.a, t2.b").
+		Check(testkit.Rows("1 10 1", "1 11 1", "2 <nil> 10", "3 12 <nil>"))
+	tk.MustQuery("select t1.a, t2.b, t3.b from t1 left join t2 on t1.a = t2.a left join t3 on t2.b = t3.a order by t1.a, t2.b").
+		Check(testkit.Rows("1 10 <nil>", "1 11 <nil>", "2 <nil> <nil>", "3 12 12"))
+	tk.MustQuery("select t1.a, t2.b, t3.b from t1 left join t3 on t1.b = t3.a left join t2 on t1.a = t2.a where t2.b > 10 order by t1.a").
+		Check(testkit.Rows("1 11 1", "3 12 <nil>"))
+	tk.MustQuery("select t1.a, t2.b, t3.b from t1 left join t3 on t1.b = t3.a, t2 where t1.a = t2.a and t2.b < 12 order by t2.b").
+		Check(testkit.Rows("1 10 1", "1 11 1"))
+}
+
 func (s *testSuite) TestSubquerySameTable(c *C) {
 	tk := testkit.NewTestKit(c, s.store)
 	tk.MustExec("use test")
@@ -272,6 +272,16 @@ func (pa *stmtAttributes) fromPlan(p plan.Plan) {
 			pa.fromPlan(child)
 		}
 		pa.hasIndexDouble = true
+	case *plan.PhysicalIndexMergeReader:
+		for _, partialPlans := range x.PartialPlans {
+			for _, child := range partialPlans {
+				pa.fromPlan(child)
+			}
+		}
+		for _, child := range x.TablePlans {
+			pa.fromPlan(child)
+		}
+		pa.hasIndexDouble = true
 	}
 	children := p.Children()
 	for _, child := range children {
@@ -27,13 +27,14 @@ import (
 )
 
 func analyzeIndexPushdown(idxExec *AnalyzeIndexExec) statistics.AnalyzeResult {
-	hist, err := idxExec.buildHistogram()
+	hist, cms, err := idxExec.buildStats()
 	if err != nil {
 		return statistics.AnalyzeResult{Err: err}
 	}
 	result := statistics.AnalyzeResult{
 		TableID: idxExec.tblInfo.ID,
 		Hist:    []*statistics.Histogram{hist},
+		Cms:     []*statistics.CMSketch{cms},
 		IsIndex: 1,
 	}
 	if len(hist.Buckets) > 0 {
@@ -71,21 +72,25 @@ func (e *AnalyzeIndexExec) open() error {
 	return nil
 }
 
-func (e *AnalyzeIndexExec) buildHistogram() (hist *statistics.Histogram, err error) {
+// buildStats builds the histogram and the CM sketch of the index. The CM sketch is nil if any
+// region doesn't return one.
+func (e *AnalyzeIndexExec) buildStats() (hist *statistics.Histogram, cms *statistics.CMSketch, err error) {
 	if err = e.open(); err != nil {
-		return nil, errors.Trace(err)
+		return nil, nil, errors.Trace(err)
 	}
 	defer func() {
 		if err1 := e.result.Close(); err1 != nil {
 			hist = nil
+			cms = nil
 			err = errors.Trace(err1)
 		}
 	}()
 	hist = &statistics.Histogram{}
+	first := true
 	for {
 		data, err := e.result.NextRaw()
 		if err != nil {
-			return nil, errors.Trace(err)
+			return nil, nil, errors.Trace(err)
 		}
 		if data == nil {
 			break
@@ -93,25 +98,41 @@ func (e *AnalyzeIndexExec) buildHistogram() (hist *statistics.Histogram, err err
 		resp := &tipb.AnalyzeIndexResp{}
 		err = resp.Unmarshal(data)
 		if err != nil {
-			return nil, errors.Trace(err)
+			return nil, nil, errors.Trace(err)
 		}
 		hist, err = statistics.MergeHistograms(e.ctx.GetSessionVars().StmtCtx, hist, statistics.HistogramFromProto(resp.Hist), maxBucketSize)
 		if err != nil {
-			return nil, errors.Trace(err)
+			return nil, nil, errors.Trace(err)
 		}
+		respCMS, err := statistics.IndexRespCMSketch(resp)
+		if err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+		if first {
+			cms = respCMS
+		} else if cms != nil && respCMS != nil {
+			err = cms.MergeCMSketch(respCMS)
+			if err != nil {
+				return nil, nil, errors.Trace(err)
+			}
+		} else {
+			cms = nil
+		}
+		first = false
 	}
 	hist.ID = e.idxInfo.ID
-	return hist, nil
+	return hist, cms, nil
 }
 
 func analyzeColumnsPushdown(colExec *AnalyzeColumnsExec) statistics.AnalyzeResult {
-	hists, err := colExec.buildHistograms()
+	hists, cms, err := colExec.buildStats()
 	if err != nil {
 		return statistics.AnalyzeResult{Err: err}
 	}
 	result := statistics.AnalyzeResult{
 		TableID: colExec.tblInfo.ID,
 		Hist:    hists,
+		Cms:     cms,
 	}
 	hist := hists[0]
 	result.Count = hist.NullCount
@@ -146,13 +167,16 @@ func (e *AnalyzeColumnsExec) open() error {
 	return nil
 }
 
-func (e *AnalyzeColumnsExec) buildHistograms() (hists []*statistics.Histogram, err error) {
+// buildStats builds the histograms and the CM sketches of the columns, the CM sketch of the
+// primary key is always nil because its equal conditions are converted to point ranges.
+func (e *AnalyzeColumnsExec) buildStats() (hists []*statistics.Histogram, cms []*statistics.CMSketch, err error) {
 	if err = e.open(); err != nil {
-		return nil, errors.Trace(err)
+		return nil, nil, errors.Trace(err)
 	}
 	defer func() {
 		if err1 := e.result.Close(); err1 != nil {
 			hists = nil
+			cms = nil
 			err = errors.Trace(err1)
 		}
 	}()
@@ -161,13 +185,14 @@ func (e *AnalyzeColumnsExec) buildHistograms() (hists []*statistics.Histogram, e
 	for i := range collectors {
 		collectors[i] = &statistics.SampleCollector{
 			Sketch:        statistics.NewFMSketch(maxSketchSize),
+			CMSketch:      statistics.NewCMSketch(statistics.DefaultCMSketchDepth, statistics.DefaultCMSketchWidth),
 			MaxSampleSize: maxSampleSize,
 		}
 	}
 	for {
 		data, err := e.result.NextRaw()
 		if err != nil {
-			return nil, errors.Trace(err)
+			return nil, nil, errors.Trace(err)
 		}
 		if data == nil {
 			break
@@ -175,16 +200,23 @@ func (e *AnalyzeColumnsExec) buildHistograms() (hists []*statistics.Histogram, e
 		resp := &tipb.AnalyzeColumnsResp{}
 		err = resp.Unmarshal(data)
 		if err != nil {
-			return nil, errors.Trace(err)
+			return nil, nil, errors.Trace(err)
 		}
 		if e.pkInfo != nil {
 			pkHist, err = statistics.MergeHistograms(e.ctx.GetSessionVars().StmtCtx, pkHist, statistics.HistogramFromProto(resp.PkHist), maxBucketSize)
 			if err != nil {
-				return nil, errors.Trace(err)
+				return nil, nil, errors.Trace(err)
 			}
 		}
 		for i, rc := range resp.Collectors {
-			collectors[i].MergeSampleCollector(statistics.SampleCollectorFromProto(rc))
+			collector, err := statistics.SampleCollectorFromProto(rc)
+			if err != nil {
+				return nil, nil, errors.Trace(err)
+			}
+			err = collectors[i].MergeSampleCollector(collector)
+			if err != nil {
+				return nil, nil, errors.Trace(err)
+			}
 		}
 	}
 	timeZone := e.ctx.GetSessionVars().GetTimeZone()
@@ -193,27 +225,29 @@ func (e *AnalyzeColumnsExec) buildHistograms() (hists []*statistics.Histogram, e
 		for i, bkt := range pkHist.Buckets {
 			pkHist.Buckets[i].LowerBound, err = tablecodec.DecodeColumnValue(bkt.LowerBound.GetBytes(), &e.pkInfo.FieldType, timeZone)
 			if err != nil {
-				return nil, errors.Trace(err)
+				return nil, nil, errors.Trace(err)
 			}
 			pkHist.Buckets[i].UpperBound, err = tablecodec.DecodeColumnValue(bkt.UpperBound.GetBytes(), &e.pkInfo.FieldType, timeZone)
 			if err != nil {
-				return nil, errors.Trace(err)
+				return nil, nil, errors.Trace(err)
 			}
 		}
 		hists = append(hists, pkHist)
+		cms = append(cms, nil)
 	}
 	for i, col := range e.colsInfo {
 		for j, s := range collectors[i].Samples {
 			collectors[i].Samples[j], err = tablecodec.DecodeColumnValue(s.GetBytes(), &col.FieldType, timeZone)
 			if err != nil {
-				return nil, errors.Trace(err)
+				return nil, nil, errors.Trace(err)
 			}
 		}
 		hg, err := statistics.BuildColumn(e.ctx, maxBucketSize, col.ID, collectors[i])
 		if err != nil {
-			return nil, errors.Trace(err)
+			return nil, nil, errors.Trace(err)
 		}
 		hists = append(hists, hg)
+		cms = append(cms, collectors[i].CMSketch)
 	}
-	return hists, nil
+	return hists, cms, nil
 }
@@ -25,7 +25,11 @@ import (
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/sessionctx"
+	"github.com/pingcap/tidb/sessionctx/varsutil"
+	"github.com/pingcap/tidb/statistics"
 	"github.com/pingcap/tidb/table"
+	"github.com/pingcap/tidb/tablecodec"
 	"github.com/pingcap/tidb/util/codec"
 	"github.com/pingcap/tidb/util/types"
 	"github.com/pingcap/tipb/go-tipb"
@@ -72,6 +76,8 @@ type TableReaderExecutor struct {
 	result        distsql.SelectResult
 	partialResult distsql.PartialResult
 	priority      int
+	// feedback is the actual row counts of the ranges, it's nil if the scan isn't sampled.
+	feedback *statistics.QueryFeedback
 }
 
 // Schema implements the Executor Schema interface.
@@ -81,6 +87,8 @@ func (e *TableReaderExecutor) Schema() *expression.Schema {
 
 // Close implements the Executor Close interface.
 func (e *TableReaderExecutor) Close() error {
+	// The feedback of the unfinished scan is dropped.
+	e.feedback.Invalidate()
 	err := closeAll(e.result, e.partialResult)
 	e.result = nil
 	e.partialResult = nil
@@ -99,6 +107,8 @@ func (e *TableReaderExecutor) Next() (Row, error) {
 			}
 			if e.partialResult == nil {
 				// Finished.
+				storeQueryFeedback(e.ctx, e.feedback)
+				e.feedback = nil
 				return nil, nil
 			}
 		}
@@ -113,6 +123,7 @@ func (e *TableReaderExecutor) Next() (Row, error) {
 			e.partialResult = nil
 			continue
 		}
+		e.feedback.UpdateByHandle(h)
 		values := make([]types.Datum, e.schema.Len())
 		if handleIsExtra(e.handleCol) {
 			err = codec.SetRawValues(rowData, values[:len(values)-1])
@@ -135,7 +146,7 @@ func (e *TableReaderExecutor) Next() (Row, error) {
 func (e *TableReaderExecutor) Open() error {
 	kvRanges := tableRangesToKVRanges(e.tableID, e.ranges)
 	var err error
-	e.result, err = distsql.SelectDAG(e.ctx.GetClient(), goctx.Background(), e.dagPB, kvRanges, e.ctx.GetSessionVars().DistSQLScanConcurrency, e.keepOrder, e.desc, getIsolationLevel(e.ctx.GetSessionVars()), e.priority)
+	e.result, err = distsql.SelectDAG(e.ctx.GetClient(), goctx.Background(), e.dagPB, kvRanges, e.ctx.GetSessionVars().DistSQLScanConcurrency, e.keepOrder, e.desc, getIsolationLevel(e.ctx.GetSessionVars()), e.priority, varsutil.GetReplicaRead(e.ctx.GetSessionVars()))
 	if err != nil {
 		return errors.Trace(err)
 	}
@@ -145,10 +156,11 @@ func (e *TableReaderExecutor) Open() error {
 
 // doRequestForHandles constructs kv ranges by handles. It is used by index look up executor.
 func (e *TableReaderExecutor) doRequestForHandles(handles []int64, goCtx goctx.Context) error {
+	e.feedback.Invalidate()
 	sort.Sort(int64Slice(handles))
 	kvRanges := tableHandlesToKVRanges(e.tableID, handles)
 	var err error
-	e.result, err = distsql.SelectDAG(e.ctx.GetClient(), goCtx, e.dagPB, kvRanges, e.ctx.GetSessionVars().DistSQLScanConcurrency, e.keepOrder, e.desc, getIsolationLevel(e.ctx.GetSessionVars()), e.priority)
+	e.result, err = distsql.SelectDAG(e.ctx.GetClient(), goCtx, e.dagPB, kvRanges, e.ctx.GetSessionVars().DistSQLScanConcurrency, e.keepOrder, e.desc, getIsolationLevel(e.ctx.GetSessionVars()), e.priority, varsutil.GetReplicaRead(e.ctx.GetSessionVars()))
 	if err != nil {
 		return errors.Trace(err)
 	}
@@ -186,6 +198,8 @@ type IndexReaderExecutor struct {
 	// columns are only required by union scan.
 	columns  []*model.ColumnInfo
 	priority int
+	// feedback is the actual row count of the range, it's nil if the scan isn't sampled.
+	feedback *statistics.QueryFeedback
 }
 
 // Schema implements the Executor Schema interface.
@@ -195,6 +209,8 @@ func (e *IndexReaderExecutor) Schema() *expression.Schema {
 
 // Close implements the Executor Close interface.
 func (e *IndexReaderExecutor) Close() error {
+	// The feedback of the unfinished scan is dropped.
+	e.feedback.Invalidate()
 	err := closeAll(e.result, e.partialResult)
 	e.result = nil
 	e.partialResult = nil
@@ -213,6 +229,8 @@ func (e *IndexReaderExecutor) Next() (Row, error) {
 			}
 			if e.partialResult == nil {
 				// Finished.
+				storeQueryFeedback(e.ctx, e.feedback)
+				e.feedback = nil
 				return nil, nil
 			}
 		}
@@ -227,6 +245,7 @@ func (e *IndexReaderExecutor) Next() (Row, error) {
 			e.partialResult = nil
 			continue
 		}
+		e.feedback.Update(1)
 		values := make([]types.Datum, e.schema.Len())
 		if handleIsExtra(e.handleCol) {
 			err = codec.SetRawValues(rowData, values[:len(values)-1])
@@ -255,7 +274,8 @@ func (e *IndexReaderExecutor) Open() error {
 	if err != nil {
 		return errors.Trace(err)
 	}
-	e.result, err = distsql.SelectDAG(e.ctx.GetClient(), e.ctx.GoCtx(), e.dagPB, kvRanges, e.ctx.GetSessionVars().DistSQLScanConcurrency, e.keepOrder, e.desc, getIsolationLevel(e.ctx.GetSessionVars()), e.priority)
+	setIndexRangeFeedback(e.feedback, e.tableID, e.index.ID, kvRanges)
+	e.result, err = distsql.SelectDAG(e.ctx.GetClient(), e.ctx.GoCtx(), e.dagPB, kvRanges, e.ctx.GetSessionVars().DistSQLScanConcurrency, e.keepOrder, e.desc, getIsolationLevel(e.ctx.GetSessionVars()), e.priority, varsutil.GetReplicaRead(e.ctx.GetSessionVars()))
 	if err != nil {
 		return errors.Trace(err)
 	}
@@ -265,11 +285,12 @@ func (e *IndexReaderExecutor) Open() error {
 
 // doRequestForDatums constructs kv ranges by datums. It is used by index look up executor.
 func (e *IndexReaderExecutor) doRequestForDatums(values [][]types.Datum, goCtx goctx.Context) error {
+	e.feedback.Invalidate()
 	kvRanges, err := indexValuesToKVRanges(e.tableID, e.index.ID, values)
 	if err != nil {
 		return errors.Trace(err)
 	}
-	e.result, err = distsql.SelectDAG(e.ctx.GetClient(), e.ctx.GoCtx(), e.dagPB, kvRanges, e.ctx.GetSessionVars().DistSQLScanConcurrency, e.keepOrder, e.desc, getIsolationLevel(e.ctx.GetSessionVars()), e.priority)
+	e.result, err = distsql.SelectDAG(e.ctx.GetClient(), e.ctx.GoCtx(), e.dagPB, kvRanges, e.ctx.GetSessionVars().DistSQLScanConcurrency, e.keepOrder, e.desc, getIsolationLevel(e.ctx.GetSessionVars()), e.priority, varsutil.GetReplicaRead(e.ctx.GetSessionVars()))
 	if err != nil {
 		return errors.Trace(err)
 	}
@@ -294,6 +315,8 @@ type IndexLookUpExecutor struct {
 	// columns are only required by union scan.
 	columns  []*model.ColumnInfo
 	priority int
+	// feedback is the actual row count of the index range, it's nil if the scan isn't sampled.
+	feedback *statistics.QueryFeedback
 	// All fields above is immutable.
 
 	indexWorker
@@ -312,7 +335,7 @@ type indexWorker struct {
 // startIndexWorker launch a background goroutine to fetch handles, send the results to workCh.
 func (e *IndexLookUpExecutor) startIndexWorker(kvRanges []kv.KeyRange, workCh chan<- *lookupTableTask, finished <-chan struct{}) error {
 	result, err := distsql.SelectDAG(e.ctx.GetClient(), e.ctx.GoCtx(), e.dagPB, kvRanges,
-		e.ctx.GetSessionVars().DistSQLScanConcurrency, e.keepOrder, e.desc, getIsolationLevel(e.ctx.GetSessionVars()), e.priority)
+		e.ctx.GetSessionVars().DistSQLScanConcurrency, e.keepOrder, e.desc, getIsolationLevel(e.ctx.GetSessionVars()), e.priority, varsutil.GetReplicaRead(e.ctx.GetSessionVars()))
 	if err != nil {
 		return errors.Trace(err)
 	}
@@ -348,8 +371,11 @@ func (worker *indexWorker) fetchHandles(e *IndexLookUpExecutor, result distsql.S
 			return
 		}
 		if finish {
+			storeQueryFeedback(e.ctx, e.feedback)
+			e.feedback = nil
 			return
 		}
+		e.feedback.Update(int64(len(handles)))
 		tasks := e.buildTableTasks(handles)
 		for _, task := range tasks {
 			select {
@@ -416,6 +442,7 @@ func (e *IndexLookUpExecutor) Open() error {
 	if err != nil {
 		return errors.Trace(err)
 	}
+	setIndexRangeFeedback(e.feedback, e.tableID, e.index.ID, kvRanges)
 	return e.open(kvRanges)
 }
 
@@ -446,6 +473,7 @@ func (e *IndexLookUpExecutor) indexRangesToKVRanges() ([]kv.KeyRange, error) {
 
 // doRequestForDatums constructs kv ranges by datums. It is used by index look up join.
 func (e *IndexLookUpExecutor) doRequestForDatums(values [][]types.Datum, goCtx goctx.Context) error {
+	e.feedback.Invalidate()
 	kvRanges, err := indexValuesToKVRanges(e.tableID, e.index.ID, values)
 	if err != nil {
 		return errors.Trace(err)
@@ -557,6 +585,8 @@ func (e *IndexLookUpExecutor) Close() error {
 		e.indexWorker.close()
 		e.tableWorker.close()
 		e.finished = nil
+		// The feedback is stored by the index worker if the index scan is finished.
+		e.feedback.Invalidate()
 	}
 	return nil
 }
@@ -581,3 +611,19 @@ func (e *IndexLookUpExecutor) Next() (Row, error) {
 		e.resultCurr = nil
 	}
 }
+
+// setIndexRangeFeedback sets the range of the index feedback by the key range scanned.
+func setIndexRangeFeedback(q *statistics.QueryFeedback, tableID, indexID int64, kvRanges []kv.KeyRange) {
+	prefixLen := len(tablecodec.EncodeTableIndexPrefix(tableID, indexID))
+	if len(kvRanges) != 1 || len(kvRanges[0].StartKey) < prefixLen || len(kvRanges[0].EndKey) < prefixLen {
+		q.Invalidate()
+		return
+	}
+	q.SetIndexRange(kvRanges[0].StartKey[prefixLen:], kvRanges[0].EndKey[prefixLen:])
+}
+
+func storeQueryFeedback(ctx context.Context, q *statistics.QueryFeedback) {
+	if q != nil {
+		sessionctx.GetDomain(ctx).StatsHandle().StoreQueryFeedback(q)
+	}
+}
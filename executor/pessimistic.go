@@ -0,0 +1,152 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/plan"
+)
+
+// isPessimisticLockStmt checks whether the statement acquires pessimistic locks, that's
+// a DML or SELECT FOR UPDATE statement in a pessimistic transaction.
+func isPessimisticLockStmt(ctx context.Context, p plan.Plan) bool {
+	sessVars := ctx.GetSessionVars()
+	if !sessVars.TxnCtx.IsPessimistic || sessVars.SnapshotTS != 0 {
+		return false
+	}
+	switch p.(type) {
+	case *plan.Insert, *plan.Update, *plan.Delete:
+		return true
+	}
+	return hasSelectForUpdate(p)
+}
+
+func hasSelectForUpdate(p plan.Plan) bool {
+	if lock, ok := p.(*plan.SelectLock); ok && lock.Lock == ast.SelectLockForUpdate {
+		return true
+	}
+	for _, child := range p.Children() {
+		if hasSelectForUpdate(child) {
+			return true
+		}
+	}
+	return false
+}
+
+// execPessimistic executes a statement of a pessimistic transaction. The statement
+// reads the latest data at forUpdateTS, and the keys it writes or locks by SELECT FOR
+// UPDATE are locked at forUpdateTS before the writes are staged. When a key is written
+// by another transaction after forUpdateTS, the statement is executed again with a new ts.
+func (a *statement) execPessimistic(ctx context.Context, txn kv.PessimisticTxn) (Executor, error) {
+	sessVars := ctx.GetSessionVars()
+	deadline := time.Now().Add(sessVars.LockWaitTimeout)
+	for {
+		forUpdateTS, err := ctx.GetStore().CurrentVersion()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		state := saveTxnState(sessVars.TxnCtx)
+		txn.StartStmt(forUpdateTS.Ver, deadline)
+		sessVars.TxnCtx.ForUpdateTS = forUpdateTS.Ver
+
+		var e Executor
+		e, err = a.runPessimisticStmt(ctx)
+		sessVars.TxnCtx.ForUpdateTS = 0
+		if err == nil {
+			if err = txn.FinishStmt(true); err != nil {
+				return nil, errors.Trace(err)
+			}
+			return e, nil
+		}
+		if err1 := txn.FinishStmt(false); err1 != nil {
+			return nil, errors.Trace(err1)
+		}
+		state.restore(sessVars.TxnCtx)
+
+		if kv.ErrDeadlock.Equal(err) {
+			// Like MySQL, the transaction is rolled back when a deadlock is found.
+			log.Infof("[%d] rollback txn %d for deadlock", sessVars.ConnectionID, ctx.Txn().StartTS())
+			sessVars.SetStatusFlag(mysql.ServerStatusInTrans, false)
+			return nil, errors.Trace(err)
+		}
+		if !kv.IsRetryableError(err) || time.Now().After(deadline) {
+			return nil, errors.Trace(err)
+		}
+		log.Debugf("[%d] retry pessimistic statement for write conflict: %v", sessVars.ConnectionID, err)
+		sessVars.StmtCtx.ResetForRetry()
+	}
+}
+
+// runPessimisticStmt builds and runs the executor of the statement. The rows of a
+// SELECT FOR UPDATE statement are buffered in the returned executor, because they can't
+// be returned before all the locks are acquired, the statement may be executed again when
+// a lock fails. It returns nil for DML statements.
+func (a *statement) runPessimisticStmt(ctx context.Context) (Executor, error) {
+	e, err := a.buildExecutor(ctx)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if err = e.Open(); err != nil {
+		return nil, errors.Trace(err)
+	}
+	var rows []Row
+	for {
+		row, err1 := e.Next()
+		if err1 != nil {
+			err = errors.Trace(err1)
+			break
+		}
+		if row == nil {
+			break
+		}
+		if e.Schema().Len() > 0 {
+			rows = append(rows, row)
+		}
+	}
+	if err1 := e.Close(); err == nil {
+		err = errors.Trace(err1)
+	}
+	if err != nil || e.Schema().Len() == 0 {
+		return nil, err
+	}
+	return &bufferedRowsExec{
+		baseExecutor: newBaseExecutor(e.Schema(), ctx),
+		rows:         rows,
+	}, nil
+}
+
+// bufferedRowsExec returns the buffered rows of a SELECT FOR UPDATE statement.
+type bufferedRowsExec struct {
+	baseExecutor
+
+	rows   []Row
+	cursor int
+}
+
+// Next implements the Executor Next interface.
+func (e *bufferedRowsExec) Next() (Row, error) {
+	if e.cursor >= len(e.rows) {
+		return nil, nil
+	}
+	row := e.rows[e.cursor]
+	e.cursor++
+	return row, nil
+}
@@ -143,6 +143,14 @@ func (e *PrepareExec) DoPrepare() {
 		e.Err = errors.Trace(ErrPrepareDDL)
 		return
 	}
+	// The historical data and schema of the AS OF clause are resolved when the
+	// statement is compiled, the prepared plans can't be built with them.
+	var asOf asOfCollector
+	stmt.Accept(&asOf)
+	if len(asOf.tables) > 0 {
+		e.Err = ErrAsOfTimestamp.Gen("AS OF TIMESTAMP can not be used in prepared statements")
+		return
+	}
 	var extractor paramMarkerExtractor
 	stmt.Accept(&extractor)
 	err = plan.Preprocess(stmt, e.IS, e.Ctx)
@@ -114,6 +114,10 @@ func (e *SetExecutor) executeSet() error {
 			if err != nil {
 				return errors.Trace(err)
 			}
+			err = checkStoreSupport(e.ctx, name, svalue)
+			if err != nil {
+				return errors.Trace(err)
+			}
 			err = sessionVars.GlobalVarsAccessor.SetGlobalSysVar(name, svalue)
 			if err != nil {
 				return errors.Trace(err)
@@ -127,6 +131,16 @@ func (e *SetExecutor) executeSet() error {
 			if err != nil {
 				return errors.Trace(err)
 			}
+			if !value.IsNull() {
+				svalue, err1 := value.ToString()
+				if err1 != nil {
+					return errors.Trace(err1)
+				}
+				err = checkStoreSupport(e.ctx, name, svalue)
+				if err != nil {
+					return errors.Trace(err)
+				}
+			}
 			oldSnapshotTS := sessionVars.SnapshotTS
 			err = varsutil.SetSessionSystemVar(sessionVars, name, value)
 			if err != nil {
@@ -177,6 +191,33 @@ func validateSnapshot(ctx context.Context, snapshotTS uint64) error {
 	return nil
 }
 
+// checkStoreSupport returns an error if the value of the system variable turns on a feature
+// that the storage doesn't support.
+func checkStoreSupport(ctx context.Context, name, value string) error {
+	var reqType int64
+	switch name {
+	case variable.TiDBReplicaRead:
+		if strings.ToLower(value) != variable.ReplicaReadLeader {
+			reqType = kv.ReqTypeReplicaRead
+		}
+	case variable.TiDBTxnMode:
+		if strings.ToLower(value) == variable.PessimisticTxnMode {
+			reqType = kv.ReqTypePessimisticTxn
+		}
+	case variable.TiDBEnableAsyncCommit, variable.TiDBEnable1PC:
+		if strings.EqualFold(value, "ON") || value == "1" {
+			reqType = kv.ReqTypeAsyncCommit
+			if name == variable.TiDBEnable1PC {
+				reqType = kv.ReqTypeOnePC
+			}
+		}
+	}
+	if reqType != 0 && !ctx.GetClient().IsRequestTypeSupported(reqType, kv.ReqSubTypeBasic) {
+		return ErrStoreNotSupported.GenByArgs(name, value)
+	}
+	return nil
+}
+
 func (e *SetExecutor) setCharset(cs, co string) error {
 	var err error
 	if len(co) == 0 {
@@ -234,6 +234,9 @@ func (e *ShowExec) fetchShowColumns() error {
 		if e.Column != nil && e.Column.Name.L != col.Name.L {
 			continue
 		}
+		if col.Hidden {
+			continue
+		}
 
 		desc := table.NewColDesc(col)
 
@@ -309,12 +312,17 @@ func (e *ShowExec) fetchShowIndex() error {
 			if col.Length != types.UnspecifiedLength {
 				subPart = col.Length
 			}
+			var colName interface{} = col.Name.O
+			if c := table.FindCol(tb.Cols(), col.Name.L); c != nil && c.Hidden {
+				// The expression parts of an expression index don't have column names.
+				colName = nil
+			}
 			data := types.MakeDatums(
 				tb.Meta().Name.O,  // Table
 				nonUniq,           // Non_unique
 				idx.Meta().Name.O, // Key_name
 				i+1,               // Seq_in_index
-				col.Name.O,        // Column_name
+				colName,           // Column_name
 				"A",               // Collation
 				0,                 // Cardinality
 				subPart,           // Sub_part
@@ -400,7 +408,13 @@ func (e *ShowExec) fetchShowCreateTable() error {
 	var buf bytes.Buffer
 	buf.WriteString(fmt.Sprintf("CREATE TABLE `%s` (\n", tb.Meta().Name.O))
 	var pkCol *table.Column
-	for i, col := range tb.Cols() {
+	visibleCols := make([]*table.Column, 0, len(tb.Cols()))
+	for _, col := range tb.Cols() {
+		if !col.Hidden {
+			visibleCols = append(visibleCols, col)
+		}
+	}
+	for i, col := range visibleCols {
 		buf.WriteString(fmt.Sprintf("  `%s` %s", col.Name.O, col.GetTypeDesc()))
 		if col.IsGenerated() {
 			// It's a generated column.
@@ -445,7 +459,7 @@ func (e *ShowExec) fetchShowCreateTable() error {
 		if len(col.Comment) > 0 {
 			buf.WriteString(fmt.Sprintf(" COMMENT '%s'", format.OutputFormat(col.Comment)))
 		}
-		if i != len(tb.Cols())-1 {
+		if i != len(visibleCols)-1 {
 			buf.WriteString(",\n")
 		}
 		if tb.Meta().PKIsHandle && mysql.HasPriKeyFlag(col.Flag) {
@@ -475,9 +489,17 @@ func (e *ShowExec) fetchShowCreateTable() error {
 
 		cols := make([]string, 0, len(idxInfo.Columns))
 		for _, c := range idxInfo.Columns {
-			cols = append(cols, c.Name.O)
+			// The expression parts of an expression index are shown as the generation expressions of the hidden columns.
+			if col := table.FindCol(tb.Cols(), c.Name.L); col != nil && col.Hidden {
+				cols = append(cols, fmt.Sprintf("(%s)", col.GeneratedExprString))
+			} else {
+				cols = append(cols, fmt.Sprintf("`%s`", c.Name.O))
+			}
+		}
+		buf.WriteString(fmt.Sprintf("(%s)", strings.Join(cols, ",")))
+		if idxInfo.Invisible {
+			buf.WriteString(" /*!80000 INVISIBLE */")
 		}
-		buf.WriteString(fmt.Sprintf("(`%s`)", strings.Join(cols, "`,`")))
 		if i != len(tb.Indices())-1 {
 			buf.WriteString(",\n")
 		}
@@ -517,6 +539,14 @@ func (e *ShowExec) fetchShowCreateTable() error {
 			buf.WriteString(fmt.Sprintf(" ON UPDATE %s", ast.ReferOptionType(fk.OnUpdate)))
 		}
 	}
+
+	for _, check := range tb.Meta().CheckConstraints {
+		if check.State != model.StatePublic {
+			continue
+		}
+		buf.WriteString(",\n")
+		buf.WriteString(fmt.Sprintf("  CONSTRAINT `%s` CHECK (%s)", check.Name.O, check.ExprString))
+	}
 	buf.WriteString("\n")
 
 	buf.WriteString(") ENGINE=InnoDB")
@@ -14,7 +14,9 @@
 package executor
 
 import (
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"strings"
 
 	log "github.com/Sirupsen/logrus"
@@ -23,10 +25,12 @@ import (
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/ddl"
 	"github.com/pingcap/tidb/infoschema"
+	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/sessionctx"
 	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/statistics"
 	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/auth"
 	"github.com/pingcap/tidb/util/sqlexec"
@@ -35,7 +39,7 @@ import (
 // SimpleExec represents simple statement executor.
 // For statements do simple execution.
 // includes `UseStmt`, 'SetStmt`, `DoStmt`,
-// `BeginStmt`, `CommitStmt`, `RollbackStmt`.
+// `BeginStmt`, `CommitStmt`, `RollbackStmt`, `SavepointStmt`, `ReleaseSavepointStmt`.
 // TODO: list all simple statements.
 type SimpleExec struct {
 	baseExecutor
@@ -62,6 +66,10 @@ func (e *SimpleExec) Next() (Row, error) {
 		e.executeCommit(x)
 	case *ast.RollbackStmt:
 		err = e.executeRollback(x)
+	case *ast.SavepointStmt:
+		err = e.executeSavepoint(x)
+	case *ast.ReleaseSavepointStmt:
+		err = e.executeReleaseSavepoint(x)
 	case *ast.CreateUserStmt:
 		err = e.executeCreateUser(x)
 	case *ast.AlterUserStmt:
@@ -77,6 +85,8 @@ func (e *SimpleExec) Next() (Row, error) {
 		return nil, nil
 	case *ast.DropStatsStmt:
 		err = e.executeDropStats(x)
+	case *ast.LoadStatsStmt:
+		err = e.executeLoadStats(x)
 	}
 	if err != nil {
 		return nil, errors.Trace(err)
@@ -110,11 +120,64 @@ func (e *SimpleExec) executeBegin(s *ast.BeginStmt) error {
 		if err != nil {
 			return errors.Trace(err)
 		}
+		// The new transaction doesn't inherit the states of a READ ONLY transaction.
+		if txnCtx.ReadOnly {
+			is := sessionctx.GetDomain(e.ctx).InfoSchema()
+			txnCtx.ReadOnly, txnCtx.StaleReadTS = false, 0
+			txnCtx.InfoSchema, txnCtx.SchemaVersion = is, is.SchemaMetaVersion()
+		}
+	}
+	if s.ReadOnly {
+		return errors.Trace(e.executeBeginReadOnly(s))
 	}
 	// With START TRANSACTION, autocommit remains disabled until you end
 	// the transaction with COMMIT or ROLLBACK. The autocommit mode then
 	// reverts to its previous state.
-	e.ctx.GetSessionVars().SetStatusFlag(mysql.ServerStatusInTrans, true)
+	sessVars := e.ctx.GetSessionVars()
+	pessimistic := sessVars.TxnMode == variable.PessimisticTxnMode
+	if s.Mode != "" {
+		pessimistic = s.Mode == ast.Pessimistic
+	}
+	if pessimistic && !e.ctx.GetClient().IsRequestTypeSupported(kv.ReqTypePessimisticTxn, kv.ReqSubTypeBasic) {
+		return ErrPessimisticTxn
+	}
+	sessVars.SetStatusFlag(mysql.ServerStatusInTrans, true)
+	sessVars.TxnCtx.IsPessimistic = pessimistic
+	// The pending transaction takes the mode when it's activated.
+	if txn := e.ctx.Txn(); txn != nil && txn.Valid() {
+		if pessimistic {
+			txn.SetOption(kv.Pessimistic, true)
+		} else {
+			txn.DelOption(kv.Pessimistic)
+		}
+	}
+	return nil
+}
+
+// executeBeginReadOnly starts a READ ONLY transaction. With the AS OF clause, the
+// transaction reads the historical data and the schema at the timestamp.
+func (e *SimpleExec) executeBeginReadOnly(s *ast.BeginStmt) error {
+	sessVars := e.ctx.GetSessionVars()
+	txnCtx := sessVars.TxnCtx
+	if s.AsOf != nil {
+		ts, err := calculateAsOfTS(e.ctx, s.AsOf)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		is, err := staleReadInfoSchema(e.ctx, ts)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		txnCtx.StaleReadTS = ts
+		txnCtx.InfoSchema = is
+		txnCtx.SchemaVersion = is.SchemaMetaVersion()
+	}
+	sessVars.SetStatusFlag(mysql.ServerStatusInTrans, true)
+	txnCtx.ReadOnly = true
+	txnCtx.IsPessimistic = false
+	if txn := e.ctx.Txn(); txn != nil && txn.Valid() {
+		txn.DelOption(kv.Pessimistic)
+	}
 	return nil
 }
 
@@ -123,6 +186,9 @@ func (e *SimpleExec) executeCommit(s *ast.CommitStmt) {
 }
 
 func (e *SimpleExec) executeRollback(s *ast.RollbackStmt) error {
+	if s.SavepointName != "" {
+		return e.executeRollbackToSavepoint(s)
+	}
 	sessVars := e.ctx.GetSessionVars()
 	log.Infof("[%d] execute rollback statement", sessVars.ConnectionID)
 	sessVars.SetStatusFlag(mysql.ServerStatusInTrans, false)
@@ -132,6 +198,74 @@ func (e *SimpleExec) executeRollback(s *ast.RollbackStmt) error {
 	return nil
 }
 
+func (e *SimpleExec) txnStager() (kv.Stager, error) {
+	txn := e.ctx.Txn()
+	if txn == nil || !txn.Valid() {
+		return nil, errors.Trace(kv.ErrInvalidTxn)
+	}
+	stager, ok := txn.(kv.Stager)
+	if !ok {
+		return nil, errors.Errorf("savepoint is not supported by the transaction %T", txn)
+	}
+	return stager, nil
+}
+
+func (e *SimpleExec) executeSavepoint(s *ast.SavepointStmt) error {
+	stager, err := e.txnStager()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	txnCtx := e.ctx.GetSessionVars().TxnCtx
+	// The savepoint with the same name is replaced. Its staging layer is kept, it's
+	// merged or discarded with the layer of an earlier savepoint.
+	if i := txnCtx.FindSavepoint(s.Name); i >= 0 {
+		txnCtx.Savepoints = append(txnCtx.Savepoints[:i], txnCtx.Savepoints[i+1:]...)
+	}
+	txnCtx.Savepoints = append(txnCtx.Savepoints, variable.Savepoint{
+		Name:   s.Name,
+		Handle: int(stager.Staging()),
+		State:  saveTxnState(txnCtx),
+	})
+	return nil
+}
+
+// executeRollbackToSavepoint rolls back the writes after the savepoint, the savepoints
+// set after it are removed. Like MySQL, the locks are not released.
+func (e *SimpleExec) executeRollbackToSavepoint(s *ast.RollbackStmt) error {
+	stager, err := e.txnStager()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	txnCtx := e.ctx.GetSessionVars().TxnCtx
+	i := txnCtx.FindSavepoint(s.SavepointName)
+	if i < 0 {
+		return ErrSavepointNotExists.GenByArgs("SAVEPOINT", s.SavepointName)
+	}
+	sp := &txnCtx.Savepoints[i]
+	if err = stager.CleanupStaging(kv.StagingHandle(sp.Handle)); err != nil {
+		return errors.Trace(err)
+	}
+	sp.Handle = int(stager.Staging())
+	sp.State.(*txnState).restore(txnCtx)
+	txnCtx.Savepoints = txnCtx.Savepoints[:i+1]
+	return nil
+}
+
+func (e *SimpleExec) executeReleaseSavepoint(s *ast.ReleaseSavepointStmt) error {
+	stager, err := e.txnStager()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	txnCtx := e.ctx.GetSessionVars().TxnCtx
+	i := txnCtx.FindSavepoint(s.Name)
+	if i < 0 {
+		return ErrSavepointNotExists.GenByArgs("SAVEPOINT", s.Name)
+	}
+	stager.ReleaseStaging(kv.StagingHandle(txnCtx.Savepoints[i].Handle))
+	txnCtx.Savepoints = txnCtx.Savepoints[:i]
+	return nil
+}
+
 func (e *SimpleExec) executeCreateUser(s *ast.CreateUserStmt) error {
 	users := make([]string, 0, len(s.Specs))
 	for _, spec := range s.Specs {
@@ -325,3 +459,19 @@ func (e *SimpleExec) executeDropStats(s *ast.DropStatsStmt) error {
 	h.DDLEventCh() <- &ddl.Event{Tp: model.ActionDropTable, TableInfo: s.Table.TableInfo}
 	return nil
 }
+
+// executeLoadStats loads the statistics dumped by the status server, the file is read by the
+// tidb-server which executes the statement.
+func (e *SimpleExec) executeLoadStats(s *ast.LoadStatsStmt) error {
+	data, err := ioutil.ReadFile(s.Path)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	jsonTbl := &statistics.JSONTable{}
+	err = json.Unmarshal(data, jsonTbl)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	h := sessionctx.GetDomain(e.ctx).StatsHandle()
+	return errors.Trace(h.LoadStatsFromJSON(e.ctx, e.is, jsonTbl))
+}
@@ -81,6 +81,85 @@ func (s *testSuite) TestTransaction(c *C) {
 	tk.MustQuery("select * from txn").Check(testkit.Rows("1", "2"))
 }
 
+func (s *testSuite) TestSavepoint(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("create table sp (a int primary key, b int, unique key(b))")
+	tk.MustExec("insert sp values (1, 1)")
+
+	tk.MustExec("begin")
+	tk.MustExec("insert sp values (2, 2)")
+	tk.MustExec("savepoint s1")
+	tk.MustExec("insert sp values (3, 3)")
+	tk.MustExec("delete from sp where a = 1")
+	tk.MustExec("savepoint s2")
+	tk.MustExec("update sp set b = 20 where a = 2")
+	tk.MustQuery("select * from sp").Check(testkit.Rows("2 20", "3 3"))
+	tk.MustExec("rollback to savepoint s2")
+	tk.MustQuery("select * from sp").Check(testkit.Rows("2 2", "3 3"))
+	tk.MustQuery("select b from sp use index(b)").Check(testkit.Rows("2", "3"))
+	// The savepoints can be rolled back to more than once.
+	tk.MustExec("insert sp values (4, 4)")
+	tk.MustExec("rollback to s2")
+	tk.MustQuery("select * from sp").Check(testkit.Rows("2 2", "3 3"))
+	tk.MustExec("rollback to S1")
+	tk.MustQuery("select * from sp").Check(testkit.Rows("1 1", "2 2"))
+	// The savepoints after s1 are removed.
+	_, err := tk.Exec("rollback to s2")
+	c.Assert(terror.ErrorEqual(err, executor.ErrSavepointNotExists), IsTrue)
+	tk.MustExec("insert sp values (3, 30)")
+	tk.MustExec("commit")
+	tk.MustQuery("select * from sp").Check(testkit.Rows("1 1", "2 2", "3 30"))
+
+	// Release the savepoint and the ones after it.
+	tk.MustExec("begin")
+	tk.MustExec("savepoint s1")
+	tk.MustExec("delete from sp where a = 3")
+	tk.MustExec("savepoint s2")
+	tk.MustExec("delete from sp where a = 2")
+	tk.MustExec("release savepoint s1")
+	_, err = tk.Exec("rollback to s2")
+	c.Assert(terror.ErrorEqual(err, executor.ErrSavepointNotExists), IsTrue)
+	_, err = tk.Exec("release savepoint s1")
+	c.Assert(terror.ErrorEqual(err, executor.ErrSavepointNotExists), IsTrue)
+	tk.MustQuery("select * from sp").Check(testkit.Rows("1 1"))
+	tk.MustExec("rollback")
+	tk.MustQuery("select * from sp").Check(testkit.Rows("1 1", "2 2", "3 30"))
+
+	// The savepoint with the same name is replaced.
+	tk.MustExec("begin")
+	tk.MustExec("savepoint s1")
+	tk.MustExec("insert sp values (4, 4)")
+	tk.MustExec("savepoint s1")
+	tk.MustExec("insert sp values (5, 5)")
+	tk.MustExec("rollback to s1")
+	tk.MustExec("commit")
+	tk.MustQuery("select a from sp").Check(testkit.Rows("1", "2", "3", "4"))
+
+	// The unique key check of the rolled back rows is discarded.
+	tk.MustExec("begin")
+	tk.MustExec("savepoint s1")
+	tk.MustExec("insert sp values (6, 6)")
+	tk.MustExec("rollback to s1")
+	tk.MustExec("insert sp values (6, 6)")
+	tk.MustExec("commit")
+	tk.MustQuery("select a from sp where b = 6").Check(testkit.Rows("6"))
+
+	// The savepoints are removed when the transaction ends.
+	_, err = tk.Exec("rollback to s1")
+	c.Assert(terror.ErrorEqual(err, executor.ErrSavepointNotExists), IsTrue)
+
+	// Savepoints in the pessimistic mode.
+	tk.MustExec("begin pessimistic")
+	tk.MustExec("update sp set b = 10 where a = 1")
+	tk.MustExec("savepoint s1")
+	tk.MustExec("update sp set b = 20 where a = 2")
+	tk.MustExec("rollback to s1")
+	tk.MustQuery("select a, b from sp where a < 3").Check(testkit.Rows("1 10", "2 2"))
+	tk.MustExec("commit")
+	tk.MustQuery("select a, b from sp where a < 3").Check(testkit.Rows("1 10", "2 2"))
+}
+
 func inTxn(ctx context.Context) bool {
 	return (ctx.GetSessionVars().Status & mysql.ServerStatusInTrans) > 0
 }
@@ -0,0 +1,139 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/infoschema"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/plan"
+	"github.com/pingcap/tidb/sessionctx"
+	"github.com/pingcap/tidb/sessionctx/varsutil"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// calculateAsOfTS evaluates the timestamp of the AS OF clause, the timestamp should be
+// between the GC safe point and now.
+func calculateAsOfTS(ctx context.Context, asOf *ast.AsOfClause) (uint64, error) {
+	sessVars := ctx.GetSessionVars()
+	if sessVars.SnapshotTS != 0 {
+		return 0, ErrAsOfTimestamp.Gen("AS OF TIMESTAMP can not be used when tidb_snapshot is set")
+	}
+	d, err := expression.EvalAstExpr(asOf.TsExpr, ctx)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	if d.IsNull() {
+		return 0, ErrAsOfTimestamp.Gen("AS OF TIMESTAMP can not be NULL")
+	}
+	ft := types.NewFieldType(mysql.TypeDatetime)
+	ft.Decimal = types.MaxFsp
+	d, err = d.ConvertTo(sessVars.StmtCtx, ft)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	t, err := d.GetMysqlTime().Time.GoTime(sessVars.GetTimeZone())
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	ts := varsutil.GoTimeToTS(t)
+	ver, err := ctx.GetStore().CurrentVersion()
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	if ts > ver.Ver {
+		return 0, ErrAsOfTimestamp.Gen("AS OF TIMESTAMP can not be a future time")
+	}
+	if err = validateSnapshot(ctx, ts); err != nil {
+		return 0, errors.Trace(err)
+	}
+	return ts, nil
+}
+
+// staleReadInfoSchema returns the information schema at the ts.
+func staleReadInfoSchema(ctx context.Context, ts uint64) (infoschema.InfoSchema, error) {
+	is, err := sessionctx.GetDomain(ctx).GetSnapshotInfoSchema(ts)
+	return is, errors.Trace(err)
+}
+
+// asOfCollector collects the table names with the AS OF clause.
+type asOfCollector struct {
+	tables []*ast.TableName
+}
+
+// Enter implements ast.Visitor interface.
+func (c *asOfCollector) Enter(in ast.Node) (ast.Node, bool) {
+	if tn, ok := in.(*ast.TableName); ok && tn.AsOf != nil {
+		c.tables = append(c.tables, tn)
+	}
+	return in, false
+}
+
+// Leave implements ast.Visitor interface.
+func (c *asOfCollector) Leave(in ast.Node) (ast.Node, bool) {
+	return in, true
+}
+
+// prepareStaleRead sets the ts the statement reads data at if the tables in the
+// statement are read with the AS OF clause, it returns the information schema at
+// the ts, or nil if the statement doesn't read the historical data.
+func prepareStaleRead(ctx context.Context, node ast.StmtNode) (infoschema.InfoSchema, error) {
+	c := &asOfCollector{}
+	node.Accept(c)
+	if len(c.tables) == 0 {
+		return nil, nil
+	}
+	switch x := node.(type) {
+	case *ast.SelectStmt:
+		if x.LockTp == ast.SelectLockForUpdate {
+			return nil, ErrAsOfTimestamp.Gen("AS OF TIMESTAMP can not be used with SELECT FOR UPDATE")
+		}
+	case *ast.UnionStmt:
+	default:
+		return nil, ErrAsOfTimestamp.Gen("AS OF TIMESTAMP can only be used in SELECT statements")
+	}
+	sessVars := ctx.GetSessionVars()
+	if sessVars.InTxn() {
+		return nil, ErrAsOfTimestamp.Gen("AS OF TIMESTAMP can not be used in a transaction")
+	}
+	var ts uint64
+	for _, tn := range c.tables {
+		tableTS, err := calculateAsOfTS(ctx, tn.AsOf)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if ts != 0 && ts != tableTS {
+			return nil, ErrAsOfTimestamp.Gen("the tables in a statement can not be read at different timestamps")
+		}
+		ts = tableTS
+	}
+	is, err := staleReadInfoSchema(ctx, ts)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	sessVars.StmtCtx.StaleReadTS = ts
+	return is, nil
+}
+
+// isWritePlan checks whether the plan writes data or acquires locks.
+func isWritePlan(p plan.Plan) bool {
+	switch p.(type) {
+	case *plan.Insert, *plan.Update, *plan.Delete, *plan.LoadData:
+		return true
+	}
+	return hasSelectForUpdate(p)
+}
@@ -0,0 +1,87 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tipb/go-binlog"
+)
+
+// txnState saves the transaction scope states kept out of the transaction buffer,
+// they are restored when the buffered writes are partially rolled back, that's when
+// a pessimistic statement fails to acquire the locks, or ROLLBACK TO SAVEPOINT.
+type txnState struct {
+	dirtyDB       *dirtyDB
+	tableDeltaMap map[int64]variable.TableDelta
+	binlog        *binlog.PrewriteValue
+}
+
+func saveTxnState(txnCtx *variable.TransactionContext) *txnState {
+	s := &txnState{}
+	if udb, ok := txnCtx.DirtyDB.(*dirtyDB); ok {
+		s.dirtyDB = udb.clone()
+	}
+	s.tableDeltaMap = cloneTableDeltaMap(txnCtx.TableDeltaMap)
+	if bin, ok := txnCtx.Binlog.(*binlog.PrewriteValue); ok {
+		s.binlog = clonePrewriteValue(bin)
+	}
+	return s
+}
+
+// restore restores the saved states, the state can be restored more than once.
+func (s *txnState) restore(txnCtx *variable.TransactionContext) {
+	if s.dirtyDB != nil {
+		txnCtx.DirtyDB = s.dirtyDB.clone()
+	} else {
+		txnCtx.DirtyDB = nil
+	}
+	txnCtx.TableDeltaMap = cloneTableDeltaMap(s.tableDeltaMap)
+	if s.binlog != nil {
+		txnCtx.Binlog = clonePrewriteValue(s.binlog)
+	} else {
+		txnCtx.Binlog = nil
+	}
+}
+
+func cloneTableDeltaMap(m map[int64]variable.TableDelta) map[int64]variable.TableDelta {
+	if m == nil {
+		return nil
+	}
+	c := make(map[int64]variable.TableDelta, len(m))
+	for id, delta := range m {
+		c[id] = delta
+	}
+	return c
+}
+
+// clonePrewriteValue clones the binlog mutations. The rows are only appended to the
+// mutations, so the slices are shared with their capacities limited.
+func clonePrewriteValue(bin *binlog.PrewriteValue) *binlog.PrewriteValue {
+	c := &binlog.PrewriteValue{
+		SchemaVersion: bin.SchemaVersion,
+		Mutations:     make([]binlog.TableMutation, len(bin.Mutations)),
+	}
+	for i, m := range bin.Mutations {
+		c.Mutations[i] = binlog.TableMutation{
+			TableId:      m.TableId,
+			InsertedRows: m.InsertedRows[:len(m.InsertedRows):len(m.InsertedRows)],
+			UpdatedRows:  m.UpdatedRows[:len(m.UpdatedRows):len(m.UpdatedRows)],
+			DeletedIds:   m.DeletedIds[:len(m.DeletedIds):len(m.DeletedIds)],
+			DeletedPks:   m.DeletedPks[:len(m.DeletedPks):len(m.DeletedPks)],
+			DeletedRows:  m.DeletedRows[:len(m.DeletedRows):len(m.DeletedRows)],
+			Sequence:     m.Sequence[:len(m.Sequence):len(m.Sequence)],
+		}
+	}
+	return c
+}
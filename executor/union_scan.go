@@ -53,6 +53,26 @@ func (udb *dirtyDB) truncateTable(tid int64) {
 	dt.truncated = true
 }
 
+// clone returns a copy of udb which is used to restore udb after a failed statement.
+func (udb *dirtyDB) clone() *dirtyDB {
+	c := &dirtyDB{tables: make(map[int64]*dirtyTable, len(udb.tables))}
+	for tid, dt := range udb.tables {
+		ct := &dirtyTable{
+			addedRows:   make(map[int64]Row, len(dt.addedRows)),
+			deletedRows: make(map[int64]struct{}, len(dt.deletedRows)),
+			truncated:   dt.truncated,
+		}
+		for h, row := range dt.addedRows {
+			ct.addedRows[h] = row
+		}
+		for h := range dt.deletedRows {
+			ct.deletedRows[h] = struct{}{}
+		}
+		c.tables[tid] = ct
+	}
+	return c
+}
+
 func (udb *dirtyDB) getDirtyTable(tid int64) *dirtyTable {
 	dt, ok := udb.tables[tid]
 	if !ok {
@@ -40,7 +40,9 @@ var (
 // updateRecord updates the row specified by the handle `h`, from `oldData` to `newData`.
 // `modified` means which columns are really modified. It's used for secondary indices.
 // Length of `oldData` and `newData` equals to length of `t.WritableCols()`.
-func updateRecord(ctx context.Context, h int64, oldData, newData []types.Datum, modified []bool, t table.Table, onDup bool) (bool, error) {
+// `fkc` checks the foreign key constraints and `cc` checks the check constraints before the row is written.
+func updateRecord(ctx context.Context, h int64, oldData, newData []types.Datum, modified []bool, t table.Table, onDup bool,
+	fkc *fkChecker, cc *checkConstraintChecker) (bool, error) {
 	var sc = ctx.GetSessionVars().StmtCtx
 	var changed, handleChanged = false, false
 	// onUpdateSpecified is for "UPDATE SET ts_field = old_value", the
@@ -115,6 +117,15 @@ func updateRecord(ctx context.Context, h int64, oldData, newData []types.Datum,
 		}
 	}
 
+	err = cc.verify(t, newData)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	err = fkc.onUpdate(t, h, oldData, newData, modified)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+
 	if handleChanged {
 		_, err = t.AddRecord(ctx, newData)
 		if err != nil {
@@ -154,6 +165,7 @@ type DeleteExec struct {
 	Tables       []*ast.TableName
 	IsMultiTable bool
 	tblID2Table  map[int64]table.Table
+	fkChecker    *fkChecker
 
 	finished bool
 }
@@ -283,7 +295,14 @@ func (e *DeleteExec) deleteSingleTable() error {
 }
 
 func (e *DeleteExec) removeRow(ctx context.Context, t table.Table, h int64, data []types.Datum) error {
-	err := t.RemoveRecord(ctx, h, data)
+	if e.fkChecker == nil {
+		e.fkChecker = newFKChecker(ctx)
+	}
+	err := e.fkChecker.onDelete(t, h, data)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	err = t.RemoveRecord(ctx, h, data)
 	if err != nil {
 		return errors.Trace(err)
 	}
@@ -567,6 +586,18 @@ func (e *LoadDataInfo) insertData(cols []string) {
 		e.insertVal.handleLoadDataWarnings(err, warnLog)
 		return
 	}
+	err = e.insertVal.getCheckConstraintChecker().verify(e.Table, row)
+	if err != nil {
+		warnLog := fmt.Sprintf("Load Data: insert data:%v failed:%v", row, errors.ErrorStack(err))
+		e.insertVal.handleLoadDataWarnings(err, warnLog)
+		return
+	}
+	err = e.insertVal.getFKChecker().onInsert(e.Table, row)
+	if err != nil {
+		warnLog := fmt.Sprintf("Load Data: insert data:%v failed:%v", row, errors.ErrorStack(err))
+		e.insertVal.handleLoadDataWarnings(err, warnLog)
+		return
+	}
 	_, err = e.Table.AddRecord(e.insertVal.ctx, row)
 	if err != nil {
 		warnLog := fmt.Sprintf("Load Data: insert data:%v failed:%v", row, errors.ErrorStack(err))
@@ -653,6 +684,23 @@ type InsertValues struct {
 
 	GenColumns []*ast.ColumnName
 	GenExprs   []expression.Expression
+
+	fkChecker    *fkChecker
+	checkChecker *checkConstraintChecker
+}
+
+func (e *InsertValues) getFKChecker() *fkChecker {
+	if e.fkChecker == nil {
+		e.fkChecker = newFKChecker(e.ctx)
+	}
+	return e.fkChecker
+}
+
+func (e *InsertValues) getCheckConstraintChecker() *checkConstraintChecker {
+	if e.checkChecker == nil {
+		e.checkChecker = newCheckConstraintChecker(e.ctx)
+	}
+	return e.checkChecker
 }
 
 // InsertExec represents an insert executor.
@@ -714,7 +762,25 @@ func (e *InsertExec) Next() (Row, error) {
 			txn = e.ctx.Txn()
 			rowCount = 0
 		}
-		if len(e.OnDuplicate) == 0 && !e.IgnoreErr {
+		if err = e.getCheckConstraintChecker().verify(e.Table, row); err != nil {
+			// With IGNORE, the rows which violate the check constraints are discarded.
+			if e.IgnoreErr {
+				e.ctx.GetSessionVars().StmtCtx.AppendWarning(err)
+				continue
+			}
+			return nil, errors.Trace(err)
+		}
+		if err = e.getFKChecker().onInsert(e.Table, row); err != nil {
+			// With IGNORE, the rows which violate the foreign key constraints are discarded.
+			if e.IgnoreErr {
+				e.ctx.GetSessionVars().StmtCtx.AppendWarning(err)
+				continue
+			}
+			return nil, errors.Trace(err)
+		}
+		// A pessimistic transaction reads the keys at the latest ts and locks them, so
+		// the existence is checked now instead of being presumed until the commit.
+		if len(e.OnDuplicate) == 0 && !e.IgnoreErr && !e.ctx.GetSessionVars().TxnCtx.IsPessimistic {
 			txn.SetOption(kv.PresumeKeyNotExists, nil)
 		}
 		h, err := e.Table.AddRecord(e.ctx, row)
@@ -1073,7 +1139,7 @@ func (e *InsertExec) onDuplicateUpdate(row []types.Datum, h int64, cols []*expre
 		newData[col.Col.Index] = val
 		assignFlag[col.Col.Index] = true
 	}
-	if _, err = updateRecord(e.ctx, h, data, newData, assignFlag, e.Table, true); err != nil {
+	if _, err = updateRecord(e.ctx, h, data, newData, assignFlag, e.Table, true, e.getFKChecker(), e.getCheckConstraintChecker()); err != nil {
 		return errors.Trace(err)
 	}
 	return nil
@@ -1159,6 +1225,12 @@ func (e *ReplaceExec) Next() (Row, error) {
 			break
 		}
 		row := rows[idx]
+		if err1 := e.getCheckConstraintChecker().verify(e.Table, row); err1 != nil {
+			return nil, errors.Trace(err1)
+		}
+		if err1 := e.getFKChecker().onInsert(e.Table, row); err1 != nil {
+			return nil, errors.Trace(err1)
+		}
 		h, err1 := e.Table.AddRecord(e.ctx, row)
 		if err1 == nil {
 			getDirtyDB(e.ctx).addRow(e.Table.Meta().ID, h, row)
@@ -1183,6 +1255,10 @@ func (e *ReplaceExec) Next() (Row, error) {
 			continue
 		}
 		// Remove current row and try replace again.
+		err1 = e.getFKChecker().onDelete(e.Table, h, oldRow)
+		if err1 != nil {
+			return nil, errors.Trace(err1)
+		}
 		err1 = e.Table.RemoveRecord(e.ctx, h, oldRow)
 		if err1 != nil {
 			return nil, errors.Trace(err1)
@@ -1209,6 +1285,8 @@ type UpdateExec struct {
 	// updatedRowKeys is a map for unique (Table, handle) pair.
 	updatedRowKeys map[int64]map[int64]struct{}
 	tblID2table    map[int64]table.Table
+	fkChecker      *fkChecker
+	checkChecker   *checkConstraintChecker
 
 	rows        []Row           // The rows fetched from TableExec.
 	newRowsData [][]types.Datum // The new values to be set.
@@ -1236,6 +1314,12 @@ func (e *UpdateExec) Next() (Row, error) {
 	if e.updatedRowKeys == nil {
 		e.updatedRowKeys = make(map[int64]map[int64]struct{})
 	}
+	if e.fkChecker == nil {
+		e.fkChecker = newFKChecker(e.ctx)
+	}
+	if e.checkChecker == nil {
+		e.checkChecker = newCheckConstraintChecker(e.ctx)
+	}
 	row := e.rows[e.cursor]
 	newData := e.newRowsData[e.cursor]
 	for id, cols := range e.SelectExec.Schema().TblID2Handle {
@@ -1256,7 +1340,7 @@ func (e *UpdateExec) Next() (Row, error) {
 				continue
 			}
 			// Update row
-			changed, err1 := updateRecord(e.ctx, handle, oldData, newTableData, flags, tbl, false)
+			changed, err1 := updateRecord(e.ctx, handle, oldData, newTableData, flags, tbl, false, e.fkChecker, e.checkChecker)
 			if err1 == nil {
 				if changed {
 					e.updatedRowKeys[id][handle] = struct{}{}
@@ -1264,7 +1348,7 @@ func (e *UpdateExec) Next() (Row, error) {
 				continue
 			}
 
-			if kv.ErrKeyExists.Equal(err1) && e.IgnoreErr {
+			if (kv.ErrKeyExists.Equal(err1) || table.ErrCheckConstraintViolated.Equal(err1)) && e.IgnoreErr {
 				e.ctx.GetSessionVars().StmtCtx.AppendWarning(err1)
 				continue
 			}
@@ -26,6 +26,7 @@ import (
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/sessionctx"
 	"github.com/pingcap/tidb/table"
+	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/testkit"
 	"github.com/pingcap/tidb/util/types"
 )
@@ -1171,3 +1172,144 @@ func (s *testSuite) TestIssue4067(c *C) {
 	tk.MustExec("delete from t1 where id in (select id from t2)")
 	tk.MustQuery("select * from t1").Check(nil)
 }
+
+func (s *testSuite) TestForeignKey(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists fk_child, fk_parent")
+	tk.MustExec("create table fk_parent(id int primary key, code int, unique index idx_code(code))")
+	tk.MustExec(`create table fk_child(id int primary key, pid int, code int, index idx_pid(pid),
+		foreign key fk_pid(pid) references fk_parent(id),
+		foreign key fk_code(code) references fk_parent(code) on delete cascade on update cascade)`)
+	tk.MustExec("insert into fk_parent values (1, 10), (2, 20), (3, 30)")
+
+	// Insert and update child rows.
+	tk.MustExec("insert into fk_child values (1, 1, 10), (2, null, 20), (3, 2, null)")
+	_, err := tk.Exec("insert into fk_child values (4, 4, 10)")
+	c.Assert(terror.ErrorEqual(err, executor.ErrNoReferencedRow), IsTrue, Commentf("err %v", err))
+	c.Assert(err.Error(), Equals, "[executor:1452]Cannot add or update a child row: a foreign key constraint fails "+
+		"(`test`.`fk_child`, CONSTRAINT `fk_pid` FOREIGN KEY (`pid`) REFERENCES `fk_parent` (`id`))")
+	_, err = tk.Exec("insert into fk_child values (4, 1, 40)")
+	c.Assert(terror.ErrorEqual(err, executor.ErrNoReferencedRow), IsTrue, Commentf("err %v", err))
+	tk.MustExec("insert ignore into fk_child values (4, 4, 10)")
+	tk.CheckExecResult(0, 0)
+	_, err = tk.Exec("update fk_child set pid = 5 where id = 1")
+	c.Assert(terror.ErrorEqual(err, executor.ErrNoReferencedRow), IsTrue, Commentf("err %v", err))
+	tk.MustExec("update fk_child set pid = 3 where id = 1")
+	_, err = tk.Exec("replace into fk_child values (1, 6, 10)")
+	c.Assert(terror.ErrorEqual(err, executor.ErrNoReferencedRow), IsTrue, Commentf("err %v", err))
+
+	// RESTRICT by default.
+	_, err = tk.Exec("delete from fk_parent where id = 2")
+	c.Assert(terror.ErrorEqual(err, executor.ErrRowIsReferenced), IsTrue, Commentf("err %v", err))
+	_, err = tk.Exec("update fk_parent set id = 5 where id = 3")
+	c.Assert(terror.ErrorEqual(err, executor.ErrRowIsReferenced), IsTrue, Commentf("err %v", err))
+	tk.MustExec("update fk_parent set code = 11 where id = 3")
+	tk.MustQuery("select * from fk_parent").Check(testkit.Rows("1 10", "2 20", "3 11"))
+
+	// CASCADE.
+	tk.MustExec("update fk_parent set code = 21 where id = 2")
+	tk.MustQuery("select * from fk_child").Check(testkit.Rows("1 3 10", "2 <nil> 21", "3 2 <nil>"))
+	tk.MustExec("delete from fk_parent where id = 1")
+	tk.MustQuery("select * from fk_child").Check(testkit.Rows("2 <nil> 21", "3 2 <nil>"))
+
+	// foreign_key_checks = 0 disables the checks.
+	tk.MustExec("set foreign_key_checks = 0")
+	tk.MustExec("insert into fk_child values (5, 100, 100)")
+	tk.MustExec("delete from fk_parent where id = 2")
+	tk.MustQuery("select * from fk_child").Check(testkit.Rows("2 <nil> 21", "3 2 <nil>", "5 100 100"))
+	tk.MustExec("set foreign_key_checks = 1")
+}
+
+func (s *testSuite) TestForeignKeySetNull(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists fk_child, fk_parent")
+	tk.MustExec("create table fk_parent(id int, name varchar(10), index idx_id_name(id, name))")
+	tk.MustExec(`create table fk_child(id int, pid int, name varchar(10),
+		foreign key fk_1(pid, name) references fk_parent(id, name) on delete set null on update no action)`)
+	tk.MustExec("insert into fk_parent values (1, 'a'), (2, 'b')")
+	tk.MustExec("insert into fk_child values (1, 1, 'a'), (2, 2, 'b'), (3, 1, 'a')")
+	_, err := tk.Exec("insert into fk_child values (4, 1, 'b')")
+	c.Assert(terror.ErrorEqual(err, executor.ErrNoReferencedRow), IsTrue, Commentf("err %v", err))
+	_, err = tk.Exec("update fk_parent set name = 'c' where id = 1")
+	c.Assert(terror.ErrorEqual(err, executor.ErrRowIsReferenced), IsTrue, Commentf("err %v", err))
+
+	tk.MustExec("begin")
+	tk.MustExec("delete from fk_parent where id = 1")
+	tk.MustQuery("select * from fk_child").Check(testkit.Rows("1 <nil> <nil>", "2 2 b", "3 <nil> <nil>"))
+	tk.MustExec("commit")
+	tk.MustQuery("select * from fk_child").Check(testkit.Rows("1 <nil> <nil>", "2 2 b", "3 <nil> <nil>"))
+
+	// SET NULL fails on NOT NULL columns.
+	tk.MustExec("drop table if exists fk_child")
+	tk.MustExec(`create table fk_child(id int, pid int not null,
+		foreign key fk_1(pid) references fk_parent(id) on delete set null)`)
+	tk.MustExec("insert into fk_child values (1, 2)")
+	_, err = tk.Exec("delete from fk_parent where id = 2")
+	c.Assert(err, NotNil)
+	tk.MustQuery("select * from fk_parent").Check(testkit.Rows("2 b"))
+
+	// Cascading delete on a self-referencing table.
+	tk.MustExec("drop table if exists fk_self")
+	tk.MustExec(`create table fk_self(id int primary key, pid int, index idx_pid(pid),
+		foreign key fk_1(pid) references fk_self(id) on delete cascade)`)
+	tk.MustExec("insert into fk_self values (1, null), (2, 1), (3, 2), (4, null)")
+	tk.MustExec("delete from fk_self where id = 1")
+	tk.MustQuery("select * from fk_self").Check(testkit.Rows("4 <nil>"))
+}
+
+func (s *testSuite) TestForeignKeyParentLock(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists fk_child, fk_parent")
+	tk.MustExec("create table fk_parent(id int primary key, code int)")
+	tk.MustExec("create table fk_child(id int, pid int, foreign key fk_1(pid) references fk_parent(id))")
+	tk.MustExec("insert into fk_parent values (1, 10), (2, 20)")
+
+	// The parent row is locked, the transaction conflicts with the one deleting it.
+	tk1 := testkit.NewTestKit(c, s.store)
+	tk1.MustExec("use test")
+	tk.MustExec("begin")
+	tk.MustExec("insert into fk_child values (1, 1)")
+	tk1.MustExec("delete from fk_parent where id = 1")
+	_, err := tk.Exec("commit")
+	c.Assert(err, NotNil)
+	tk.MustQuery("select * from fk_child").Check(nil)
+
+	// The referenced columns must be indexed.
+	tk.MustExec("drop table fk_child")
+	tk.MustExec("create table fk_child(id int, code int, foreign key fk_1(code) references fk_parent(code))")
+	_, err = tk.Exec("insert into fk_child values (1, 20)")
+	c.Assert(terror.ErrorEqual(err, executor.ErrFKNoIndexParent), IsTrue, Commentf("err %v", err))
+	tk.MustExec("insert into fk_child values (1, null)")
+}
+
+func (s *testSuite) TestCheckConstraint(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t_check")
+	tk.MustExec("create table t_check(id int primary key, a int check (a > 0), b int, c int as (a + b), check (c < 10))")
+
+	tk.MustExec("insert into t_check(id, a, b) values (1, 1, 1), (2, null, 20)")
+	_, err := tk.Exec("insert into t_check(id, a, b) values (3, 0, 1)")
+	c.Assert(terror.ErrorEqual(err, table.ErrCheckConstraintViolated), IsTrue, Commentf("err %v", err))
+	// The generated columns are checked too.
+	_, err = tk.Exec("insert into t_check(id, a, b) values (3, 5, 5)")
+	c.Assert(terror.ErrorEqual(err, table.ErrCheckConstraintViolated), IsTrue, Commentf("err %v", err))
+	tk.MustExec("insert ignore into t_check(id, a, b) values (3, 0, 1), (4, 4, 5)")
+	tk.MustQuery("show warnings").Check(testkit.Rows("Warning 3819 Check constraint 't_check_chk_2' is violated."))
+	tk.MustQuery("select id from t_check").Check(testkit.Rows("1", "2", "4"))
+
+	_, err = tk.Exec("update t_check set a = a - 1 where id = 1")
+	c.Assert(terror.ErrorEqual(err, table.ErrCheckConstraintViolated), IsTrue, Commentf("err %v", err))
+	tk.MustExec("update ignore t_check set a = a + 1")
+	tk.MustQuery("select id, a from t_check").Check(testkit.Rows("1 2", "2 <nil>", "4 4"))
+
+	_, err = tk.Exec("replace into t_check(id, a, b) values (4, -1, 1)")
+	c.Assert(terror.ErrorEqual(err, table.ErrCheckConstraintViolated), IsTrue, Commentf("err %v", err))
+	tk.MustExec("replace into t_check(id, a, b) values (4, 1, 1)")
+	_, err = tk.Exec("insert into t_check(id, a, b) values (4, 1, 1) on duplicate key update b = 10")
+	c.Assert(terror.ErrorEqual(err, table.ErrCheckConstraintViolated), IsTrue, Commentf("err %v", err))
+	tk.MustQuery("select * from t_check").Check(testkit.Rows("1 2 1 3", "2 <nil> 20 <nil>", "4 1 1 2"))
+}
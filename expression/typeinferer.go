@@ -40,9 +40,19 @@ type typeInferrer struct {
 }
 
 func (v *typeInferrer) Enter(in ast.Node) (out ast.Node, skipChildren bool) {
-	switch in.(type) {
+	switch x := in.(type) {
 	case *ast.ColumnOption:
 		return in, true
+	case *ast.IndexColName:
+		// The expression parts of an index are inferred in ddl.
+		if x.Expr != nil {
+			return in, true
+		}
+	case *ast.Constraint:
+		// The expressions of check constraints are inferred in ddl.
+		if x.Tp == ast.ConstraintCheck {
+			return in, true
+		}
 	}
 	return in, false
 }
@@ -66,6 +66,38 @@ func ColumnSubstitute(expr Expression, schema *Schema, newExprs []Expression) Ex
 	return expr
 }
 
+// ExpressionSubstitute substitutes the sub-expressions of expr which are equal to oldExprs
+// by the corresponding newExprs.
+func ExpressionSubstitute(expr Expression, oldExprs, newExprs []Expression, ctx context.Context) (Expression, error) {
+	for i, oldExpr := range oldExprs {
+		if expr.Equal(oldExpr, ctx) {
+			return newExprs[i].Clone(), nil
+		}
+	}
+	if v, ok := expr.(*ScalarFunction); ok {
+		if v.FuncName.L == ast.Cast {
+			newFunc := v.Clone().(*ScalarFunction)
+			arg, err := ExpressionSubstitute(newFunc.GetArgs()[0], oldExprs, newExprs, ctx)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			newFunc.GetArgs()[0] = arg
+			return newFunc, nil
+		}
+		newArgs := make([]Expression, 0, len(v.GetArgs()))
+		for _, arg := range v.GetArgs() {
+			newArg, err := ExpressionSubstitute(arg, oldExprs, newExprs, ctx)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			newArgs = append(newArgs, newArg)
+		}
+		fun, err := NewFunction(v.GetCtx(), v.FuncName.L, v.RetType, newArgs...)
+		return fun, errors.Trace(err)
+	}
+	return expr, nil
+}
+
 func datumsToConstants(datums []types.Datum) []Expression {
 	constants := make([]Expression, 0, len(datums))
 	for _, d := range datums {
@@ -103,3 +103,33 @@ func (s *BufferStore) SaveTo(m Mutator) error {
 	})
 	return errors.Trace(err)
 }
+
+// Release releases the resources held by the MemBuffer.
+func (s *BufferStore) Release() {
+	if lmb, ok := s.MemBuffer.(*lazyMemBuffer); ok {
+		lmb.Release()
+	}
+}
+
+// Staging implements the Stager Staging interface.
+func (s *BufferStore) Staging() StagingHandle {
+	if lmb, ok := s.MemBuffer.(*lazyMemBuffer); ok {
+		return lmb.Staging()
+	}
+	return 0
+}
+
+// ReleaseStaging implements the Stager ReleaseStaging interface.
+func (s *BufferStore) ReleaseStaging(h StagingHandle) {
+	if lmb, ok := s.MemBuffer.(*lazyMemBuffer); ok {
+		lmb.ReleaseStaging(h)
+	}
+}
+
+// CleanupStaging implements the Stager CleanupStaging interface.
+func (s *BufferStore) CleanupStaging(h StagingHandle) error {
+	if lmb, ok := s.MemBuffer.(*lazyMemBuffer); ok {
+		return errors.Trace(lmb.CleanupStaging(h))
+	}
+	return nil
+}
@@ -35,6 +35,9 @@ const (
 	codeTxnTooLarge                               = 11
 	codeEntryTooLarge                             = 12
 
+	codeLockWaitTimeout = 1205
+	codeDeadlock        = 1213
+
 	codeKeyExists = 1062
 )
 
@@ -69,11 +72,17 @@ var (
 	ErrKeyExists = terror.ClassKV.New(codeKeyExists, "key already exist")
 	// ErrNotImplemented returns when a function is not implemented yet.
 	ErrNotImplemented = terror.ClassKV.New(codeNotImplemented, "not implemented")
+	// ErrLockWaitTimeout is returned when a pessimistic lock waits longer than innodb_lock_wait_timeout.
+	ErrLockWaitTimeout = terror.ClassKV.New(codeLockWaitTimeout, "Lock wait timeout exceeded; try restarting transaction")
+	// ErrDeadlock is returned when a pessimistic lock request forms a deadlock.
+	ErrDeadlock = terror.ClassKV.New(codeDeadlock, "Deadlock found when trying to get lock; try restarting transaction")
 )
 
 func init() {
 	kvMySQLErrCodes := map[terror.ErrCode]uint16{
-		codeKeyExists: mysql.ErrDupEntry,
+		codeKeyExists:       mysql.ErrDupEntry,
+		codeLockWaitTimeout: mysql.ErrLockWaitTimeout,
+		codeDeadlock:        mysql.ErrLockDeadlock,
 	}
 	terror.ErrClassToMySQLCodes[terror.ClassKV] = kvMySQLErrCodes
 }
@@ -14,6 +14,9 @@
 package kv
 
 import (
+	"math"
+	"time"
+
 	"github.com/pingcap/tidb/store/tikv/oracle"
 	goctx "golang.org/x/net/context"
 )
@@ -38,6 +41,17 @@ const (
 	IsolationLevel
 	// Priority marks the priority of this transaction.
 	Priority
+	// Pessimistic makes the transaction acquire locks during the execution of statements
+	// instead of detecting conflicts at commit.
+	Pessimistic
+	// EnableAsyncCommit commits the transaction once all its keys are prewritten if the
+	// transaction is small enough.
+	EnableAsyncCommit
+	// Enable1PC commits the transaction by the prewrite if all its keys are in one region.
+	Enable1PC
+	// ReplicaRead sets the replicas the reads of the transaction are sent to, the value
+	// is a ReplicaReadType.
+	ReplicaRead
 )
 
 // Priority value for transaction priority.
@@ -57,14 +71,38 @@ const (
 	RC
 )
 
+// ReplicaReadType is the type of the replicas a read request is sent to.
+type ReplicaReadType byte
+
+const (
+	// ReplicaReadLeader sends the reads to the leader.
+	ReplicaReadLeader ReplicaReadType = iota
+	// ReplicaReadFollower sends the reads to the followers, or the leader if there is no
+	// available follower.
+	ReplicaReadFollower
+	// ReplicaReadMixed sends the reads to the leader and the followers in turn.
+	ReplicaReadMixed
+	// ReplicaReadClosest sends the reads to the replicas on the stores with the same labels
+	// as the server, or the leader if there is no such replica.
+	ReplicaReadClosest
+)
+
+// IsFollowerRead checks if the reads may be sent to the followers.
+func (r ReplicaReadType) IsFollowerRead() bool {
+	return r != ReplicaReadLeader
+}
+
 // Those limits is enforced to make sure the transaction can be well handled by TiKV.
 var (
 	// TxnEntrySizeLimit is limit of single entry size (len(key) + len(value)).
 	TxnEntrySizeLimit = 6 * 1024 * 1024
 	// TxnEntryCountLimit  is limit of number of entries in the MemBuffer.
-	TxnEntryCountLimit uint64 = 300 * 1000
+	TxnEntryCountLimit uint64 = math.MaxUint64
 	// TxnTotalSizeLimit is limit of the sum of all entry size.
-	TxnTotalSizeLimit = 100 * 1024 * 1024
+	TxnTotalSizeLimit uint64 = 10 * 1024 * 1024 * 1024
+	// TxnMemBufferSpillSize is the size of a MemBuffer above which its entries are
+	// spilled to disk.
+	TxnMemBufferSpillSize = 256 * 1024 * 1024
 )
 
 // Retriever is the interface wraps the basic Get and Seek methods.
@@ -107,6 +145,22 @@ type MemBuffer interface {
 	Len() int
 }
 
+// StagingHandle is the handle of a staging layer, the first layer is 1.
+type StagingHandle int
+
+// Stager is implemented by the buffers and transactions whose writes can be staged
+// in layers, so the writes after a layer is created can be rolled back. It's used for
+// savepoints.
+type Stager interface {
+	// Staging creates a new staging layer on top of the existing ones.
+	Staging() StagingHandle
+	// ReleaseStaging merges the layer h and the layers above it into the layer below.
+	ReleaseStaging(h StagingHandle)
+	// CleanupStaging discards the writes in the layer h and the layers above it,
+	// then removes the layers.
+	CleanupStaging(h StagingHandle) error
+}
+
 // Transaction defines the interface for operations inside a Transaction.
 // This is not thread safe.
 type Transaction interface {
@@ -133,6 +187,27 @@ type Transaction interface {
 	Valid() bool
 }
 
+// PessimisticTxn is the interface implemented by the transactions that support
+// the pessimistic mode. The keys written or locked by a statement are locked at
+// forUpdateTS before the writes are staged, and the writes are staged until the
+// statement finishes, so a statement that fails to acquire its locks can be retried
+// or discarded without affecting the earlier statements.
+type PessimisticTxn interface {
+	// StartStmt starts a statement which reads data at forUpdateTS, it waits for the
+	// locks held by other transactions until lockWaitDeadline.
+	StartStmt(forUpdateTS uint64, lockWaitDeadline time.Time)
+	// FinishStmt finishes the current statement, the staged writes are applied
+	// to the transaction if commit is true, or discarded otherwise.
+	FinishStmt(commit bool) error
+}
+
+// BatchGetter is implemented by the transactions that get the values of a batch of
+// keys together, the keys not in the buffer are got from the storage in batches.
+type BatchGetter interface {
+	// BatchGet gets the values of the keys, the keys not found are not in the result.
+	BatchGet(keys []Key) (map[string][]byte, error)
+}
+
 // Client is used to send request to KV layer.
 type Client interface {
 	// Send sends request to KV layer, returns a Response.
@@ -158,6 +233,21 @@ const (
 	ReqSubTypeAnalyzeCol = 10005
 )
 
+// ReqTypes of the features that the storage may not support, they are only used to
+// check the support by IsRequestTypeSupported.
+const (
+	// ReqTypeReplicaRead is the reads served by the followers.
+	ReqTypeReplicaRead = 201
+	// ReqTypePessimisticTxn is the pessimistic lock and rollback requests of the
+	// pessimistic transactions.
+	ReqTypePessimisticTxn = 202
+	// ReqTypeAsyncCommit is the async prewrite and the txn status requests of the
+	// async commit transactions.
+	ReqTypeAsyncCommit = 203
+	// ReqTypeOnePC is the prewrite requests which commit the transactions by 1PC.
+	ReqTypeOnePC = 204
+)
+
 // Request represents a kv request.
 type Request struct {
 	// Tp is the request type.
@@ -176,6 +266,8 @@ type Request struct {
 	IsolationLevel IsoLevel
 	// Priority is the priority of this KV request, its value may be PriorityNormal/PriorityLow/PriorityHigh.
 	Priority int
+	// ReplicaRead is the type of the replicas the request is sent to.
+	ReplicaRead ReplicaReadType
 }
 
 // Response represents the response returned from KV layer.
@@ -18,6 +18,7 @@ package kv
 import (
 	"fmt"
 	"math/rand"
+	"os"
 	"testing"
 
 	. "github.com/pingcap/check"
@@ -240,6 +241,68 @@ func (s *testKVSuite) TestBufferLimit(c *C) {
 	c.Assert(err, NotNil) // buffer len limit
 }
 
+func (s *testKVSuite) TestBufferSpill(c *C) {
+	buffer := NewMemDbBuffer().(*memDbBuffer)
+	buffer.spillSize = 100
+	for i := 0; i < 10; i++ {
+		val := encodeInt(i)
+		c.Assert(buffer.Set(val, val), IsNil)
+	}
+	c.Assert(buffer.disk, NotNil)
+	dir := buffer.diskDir
+	_, err := os.Stat(dir)
+	c.Assert(err, IsNil)
+	c.Assert(buffer.Len(), Equals, 10)
+	c.Assert(buffer.Size(), Equals, 200)
+
+	// Overwrite and delete after the spill.
+	c.Assert(buffer.Set(encodeInt(0), []byte("v")), IsNil)
+	c.Assert(buffer.Delete(encodeInt(1)), IsNil)
+	c.Assert(buffer.Set(encodeInt(10), encodeInt(10)), IsNil)
+	// The overwrites after the spill are counted as new entries.
+	c.Assert(buffer.Len(), Equals, 13)
+	c.Assert(buffer.Size(), Equals, 241)
+	v, err := buffer.Get(encodeInt(0))
+	c.Assert(err, IsNil)
+	c.Assert(v, BytesEquals, []byte("v"))
+	v, err = buffer.Get(encodeInt(1))
+	c.Assert(err, IsNil)
+	c.Assert(v, HasLen, 0)
+	_, err = buffer.Get(encodeInt(11))
+	c.Assert(IsErrNotFound(err), IsTrue)
+
+	iter, err := buffer.Seek(encodeInt(2))
+	c.Assert(err, IsNil)
+	for i := 2; i <= 10; i++ {
+		c.Assert(iter.Valid(), IsTrue)
+		c.Assert([]byte(iter.Key()), BytesEquals, encodeInt(i))
+		c.Assert(iter.Next(), IsNil)
+	}
+	c.Assert(iter.Valid(), IsFalse)
+	iter.Close()
+	iter, err = buffer.SeekReverse(nil)
+	c.Assert(err, IsNil)
+	c.Assert([]byte(iter.Key()), BytesEquals, encodeInt(10))
+	iter.Close()
+
+	// Rolls back the writes after the spill.
+	h := buffer.Staging()
+	c.Assert(buffer.Set(encodeInt(2), []byte("v")), IsNil)
+	c.Assert(buffer.Set(encodeInt(11), encodeInt(11)), IsNil)
+	c.Assert(buffer.CleanupStaging(h), IsNil)
+	c.Assert(buffer.Len(), Equals, 16)
+	c.Assert(buffer.Size(), Equals, 292)
+	_, err = buffer.Get(encodeInt(11))
+	c.Assert(IsErrNotFound(err), IsTrue)
+	v, err = buffer.Get(encodeInt(2))
+	c.Assert(err, IsNil)
+	c.Assert(v, BytesEquals, encodeInt(2))
+
+	buffer.Release()
+	_, err = os.Stat(dir)
+	c.Assert(os.IsNotExist(err), IsTrue)
+}
+
 var opCnt = 100000
 
 func BenchmarkMemDbBufferSequential(b *testing.B) {
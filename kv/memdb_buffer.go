@@ -16,22 +16,54 @@
 package kv
 
 import (
+	"io/ioutil"
+	"os"
 	"sync/atomic"
 
+	log "github.com/Sirupsen/logrus"
 	"github.com/juju/errors"
 	"github.com/pingcap/goleveldb/leveldb"
 	"github.com/pingcap/goleveldb/leveldb/comparer"
 	"github.com/pingcap/goleveldb/leveldb/iterator"
 	"github.com/pingcap/goleveldb/leveldb/memdb"
+	"github.com/pingcap/goleveldb/leveldb/opt"
 	"github.com/pingcap/goleveldb/leveldb/util"
 	"github.com/pingcap/tidb/terror"
 )
 
+// memDbBuffer keeps the entries in memory until its size exceeds spillSize, then
+// all the entries are moved to a leveldb in a temporary directory, so a large
+// transaction doesn't use up the memory.
 type memDbBuffer struct {
 	db              *memdb.DB
 	entrySizeLimit  int
 	bufferLenLimit  uint64
-	bufferSizeLimit int
+	bufferSizeLimit uint64
+	spillSize       int
+
+	// disk is the spilled storage, when it's not nil db is not used any more.
+	// The writes after spilling are counted in diskLen and diskSize without
+	// reading the disk, so an overwritten key is counted again, and they are the
+	// upper bounds of the number and size of the entries.
+	disk     *leveldb.DB
+	diskDir  string
+	diskLen  int
+	diskSize int
+
+	// stages are the undo logs of the staging layers.
+	stages []*undoLog
+}
+
+// undoLog records the original entries of the keys written in a staging layer.
+type undoLog struct {
+	keys    map[string]struct{}
+	entries []undoEntry
+}
+
+type undoEntry struct {
+	key    []byte
+	value  []byte
+	exists bool
 }
 
 type memDbIter struct {
@@ -45,17 +77,25 @@ func NewMemDbBuffer() MemBuffer {
 		db:              memdb.New(comparer.DefaultComparer, 4*1024),
 		entrySizeLimit:  TxnEntrySizeLimit,
 		bufferLenLimit:  atomic.LoadUint64(&TxnEntryCountLimit),
-		bufferSizeLimit: TxnTotalSizeLimit,
+		bufferSizeLimit: atomic.LoadUint64(&TxnTotalSizeLimit),
+		spillSize:       TxnMemBufferSpillSize,
+	}
+}
+
+func (m *memDbBuffer) newIterator(slice *util.Range) iterator.Iterator {
+	if m.disk != nil {
+		return m.disk.NewIterator(slice, nil)
 	}
+	return m.db.NewIterator(slice)
 }
 
 // Seek creates an Iterator.
 func (m *memDbBuffer) Seek(k Key) (Iterator, error) {
 	var i Iterator
 	if k == nil {
-		i = &memDbIter{iter: m.db.NewIterator(&util.Range{}), reverse: false}
+		i = &memDbIter{iter: m.newIterator(&util.Range{}), reverse: false}
 	} else {
-		i = &memDbIter{iter: m.db.NewIterator(&util.Range{Start: []byte(k)}), reverse: false}
+		i = &memDbIter{iter: m.newIterator(&util.Range{Start: []byte(k)}), reverse: false}
 	}
 	i.Next()
 	return i, nil
@@ -64,9 +104,9 @@ func (m *memDbBuffer) Seek(k Key) (Iterator, error) {
 func (m *memDbBuffer) SeekReverse(k Key) (Iterator, error) {
 	var i *memDbIter
 	if k == nil {
-		i = &memDbIter{iter: m.db.NewIterator(&util.Range{}), reverse: true}
+		i = &memDbIter{iter: m.newIterator(&util.Range{}), reverse: true}
 	} else {
-		i = &memDbIter{iter: m.db.NewIterator(&util.Range{Limit: []byte(k)}), reverse: true}
+		i = &memDbIter{iter: m.newIterator(&util.Range{Limit: []byte(k)}), reverse: true}
 	}
 	i.iter.Last()
 	return i, nil
@@ -74,7 +114,13 @@ func (m *memDbBuffer) SeekReverse(k Key) (Iterator, error) {
 
 // Get returns the value associated with key.
 func (m *memDbBuffer) Get(k Key) ([]byte, error) {
-	v, err := m.db.Get(k)
+	var v []byte
+	var err error
+	if m.disk != nil {
+		v, err = m.disk.Get(k, nil)
+	} else {
+		v, err = m.db.Get(k)
+	}
 	if terror.ErrorEqual(err, leveldb.ErrNotFound) {
 		return nil, ErrNotExist
 	}
@@ -90,11 +136,11 @@ func (m *memDbBuffer) Set(k Key, v []byte) error {
 		return ErrEntryTooLarge.Gen("entry too large, size: %d", len(k)+len(v))
 	}
 
-	err := m.db.Put(k, v)
-	if m.Size() > m.bufferSizeLimit {
+	err := m.put(k, v)
+	if uint64(m.Size()) > m.bufferSizeLimit {
 		return ErrTxnTooLarge.Gen("transaction too large, size:%d", m.Size())
 	}
-	if m.Len() > int(m.bufferLenLimit) {
+	if uint64(m.Len()) > m.bufferLenLimit {
 		return ErrTxnTooLarge.Gen("transaction too large, len:%d", m.Len())
 	}
 	return errors.Trace(err)
@@ -102,17 +148,190 @@ func (m *memDbBuffer) Set(k Key, v []byte) error {
 
 // Delete removes the entry from buffer with provided key.
 func (m *memDbBuffer) Delete(k Key) error {
-	err := m.db.Put(k, nil)
+	err := m.put(k, nil)
 	return errors.Trace(err)
 }
 
+func (m *memDbBuffer) put(k Key, v []byte) error {
+	if err := m.logUndo(k); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(m.write(k, v))
+}
+
+func (m *memDbBuffer) write(k Key, v []byte) error {
+	if m.disk == nil {
+		if err := m.db.Put(k, v); err != nil {
+			return errors.Trace(err)
+		}
+		if m.db.Size() <= m.spillSize {
+			return nil
+		}
+		return errors.Trace(m.spill())
+	}
+	m.diskLen++
+	m.diskSize += len(k) + len(v)
+	return errors.Trace(m.disk.Put(k, v, nil))
+}
+
+// remove drops the entry of the key, unlike Delete it doesn't leave a tombstone.
+// The removed entry is still counted after spilling.
+func (m *memDbBuffer) remove(k Key) error {
+	if m.disk == nil {
+		return errors.Trace(m.db.Delete(k))
+	}
+	return errors.Trace(m.disk.Delete(k, nil))
+}
+
+// logUndo records the original entry of the key in the top staging layer, only
+// the first write of a key in a layer needs to be recorded.
+func (m *memDbBuffer) logUndo(k Key) error {
+	if len(m.stages) == 0 {
+		return nil
+	}
+	ul := m.stages[len(m.stages)-1]
+	if _, ok := ul.keys[string(k)]; ok {
+		return nil
+	}
+	entry := undoEntry{key: append([]byte(nil), k...)}
+	v, err := m.Get(k)
+	if err == nil {
+		entry.value = append([]byte(nil), v...)
+		entry.exists = true
+	} else if !IsErrNotFound(err) {
+		return errors.Trace(err)
+	}
+	ul.keys[string(k)] = struct{}{}
+	ul.entries = append(ul.entries, entry)
+	return nil
+}
+
+// Staging implements the Stager Staging interface.
+func (m *memDbBuffer) Staging() StagingHandle {
+	m.stages = append(m.stages, &undoLog{keys: make(map[string]struct{})})
+	return StagingHandle(len(m.stages))
+}
+
+// ReleaseStaging implements the Stager ReleaseStaging interface.
+func (m *memDbBuffer) ReleaseStaging(h StagingHandle) {
+	if int(h) <= 0 || int(h) > len(m.stages) {
+		return
+	}
+	if h > 1 {
+		// The original entries of the merged layers are the ones before the layer
+		// below was created, unless the key is already written in that layer.
+		parent := m.stages[h-2]
+		for _, ul := range m.stages[h-1:] {
+			for _, entry := range ul.entries {
+				if _, ok := parent.keys[string(entry.key)]; ok {
+					continue
+				}
+				parent.keys[string(entry.key)] = struct{}{}
+				parent.entries = append(parent.entries, entry)
+			}
+		}
+	}
+	m.stages = m.stages[:h-1]
+}
+
+// CleanupStaging implements the Stager CleanupStaging interface.
+func (m *memDbBuffer) CleanupStaging(h StagingHandle) error {
+	if int(h) <= 0 || int(h) > len(m.stages) {
+		return nil
+	}
+	for i := len(m.stages) - 1; i >= int(h)-1; i-- {
+		entries := m.stages[i].entries
+		for j := len(entries) - 1; j >= 0; j-- {
+			var err error
+			if entries[j].exists {
+				err = m.write(entries[j].key, entries[j].value)
+			} else {
+				err = m.remove(entries[j].key)
+			}
+			if err != nil {
+				return errors.Trace(err)
+			}
+		}
+		m.stages = m.stages[:i]
+	}
+	return nil
+}
+
+// spill moves all the entries to a leveldb in a temporary directory.
+func (m *memDbBuffer) spill() error {
+	dir, err := ioutil.TempDir("", "tidb-membuffer")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	disk, err := leveldb.OpenFile(dir, &opt.Options{NoSync: true})
+	if err != nil {
+		m.closeDisk(nil, dir)
+		return errors.Trace(err)
+	}
+	batch := new(leveldb.Batch)
+	iter := m.db.NewIterator(&util.Range{})
+	for iter.Next() {
+		batch.Put(iter.Key(), iter.Value())
+		if batch.Len() >= spillBatchCount {
+			if err = disk.Write(batch, nil); err != nil {
+				break
+			}
+			batch.Reset()
+		}
+	}
+	iter.Release()
+	if err == nil {
+		err = disk.Write(batch, nil)
+	}
+	if err != nil {
+		m.closeDisk(disk, dir)
+		return errors.Trace(err)
+	}
+	log.Infof("[kv] membuffer spills %d entries (%d bytes) to %s", m.db.Len(), m.db.Size(), dir)
+	m.disk, m.diskDir = disk, dir
+	m.diskLen, m.diskSize = m.db.Len(), m.db.Size()
+	m.db = nil
+	return nil
+}
+
+// spillBatchCount is the number of entries written to the disk in a batch when spilling.
+const spillBatchCount = 1024
+
+// Release removes the spilled storage of the buffer.
+func (m *memDbBuffer) Release() {
+	m.stages = nil
+	if m.disk == nil {
+		return
+	}
+	m.closeDisk(m.disk, m.diskDir)
+	m.disk = nil
+	m.db = memdb.New(comparer.DefaultComparer, 4*1024)
+}
+
+func (m *memDbBuffer) closeDisk(disk *leveldb.DB, dir string) {
+	if disk != nil {
+		if err := disk.Close(); err != nil {
+			log.Warnf("[kv] close membuffer db %s err: %v", dir, err)
+		}
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		log.Warnf("[kv] remove membuffer dir %s err: %v", dir, err)
+	}
+}
+
 // Size returns sum of keys and values length.
 func (m *memDbBuffer) Size() int {
+	if m.disk != nil {
+		return m.diskSize
+	}
 	return m.db.Size()
 }
 
 // Len returns the number of entries in the DB.
 func (m *memDbBuffer) Len() int {
+	if m.disk != nil {
+		return m.diskLen
+	}
 	return m.db.Len()
 }
 
@@ -23,11 +23,15 @@ import (
 // Also, it provides some transaction related utilities.
 type UnionStore interface {
 	MemBuffer
+	Stager
 	// CheckLazyConditionPairs loads all lazy values from store then checks if all values are matched.
 	// Lazy condition pairs should be checked before transaction commit.
 	CheckLazyConditionPairs() error
 	// WalkBuffer iterates all buffered kv pairs.
 	WalkBuffer(f func(k Key, v []byte) error) error
+	// BatchGet gets the values of the keys from the buffer and the snapshot, the keys
+	// not found are not in the result.
+	BatchGet(keys []Key) (map[string][]byte, error)
 	// SetOption sets an option with a value, when val is nil, uses the default
 	// value of this option.
 	SetOption(opt Option, val interface{})
@@ -35,6 +39,9 @@ type UnionStore interface {
 	DelOption(opt Option)
 	// GetOption gets an option.
 	GetOption(opt Option) interface{}
+	// Release releases the resources held by the buffer, such as the files it
+	// spills to. The buffer should not be used after it's released.
+	Release()
 }
 
 // Option is used for customizing kv store's behaviors during a transaction.
@@ -61,6 +68,9 @@ type unionStore struct {
 	snapshot           Snapshot                    // for read
 	lazyConditionPairs map[string](*conditionPair) // for delay check
 	opts               options
+	// stagingCondKeys are the keys of the lazy condition pairs marked in each
+	// staging layer.
+	stagingCondKeys [][]string
 }
 
 // NewUnionStore builds a new UnionStore.
@@ -151,6 +161,35 @@ func (lmb *lazyMemBuffer) Len() int {
 	return lmb.mb.Len()
 }
 
+func (lmb *lazyMemBuffer) Release() {
+	if mb, ok := lmb.mb.(*memDbBuffer); ok {
+		mb.Release()
+	}
+}
+
+func (lmb *lazyMemBuffer) Staging() StagingHandle {
+	if lmb.mb == nil {
+		lmb.mb = NewMemDbBuffer()
+	}
+	if mb, ok := lmb.mb.(*memDbBuffer); ok {
+		return mb.Staging()
+	}
+	return 0
+}
+
+func (lmb *lazyMemBuffer) ReleaseStaging(h StagingHandle) {
+	if mb, ok := lmb.mb.(*memDbBuffer); ok {
+		mb.ReleaseStaging(h)
+	}
+}
+
+func (lmb *lazyMemBuffer) CleanupStaging(h StagingHandle) error {
+	if mb, ok := lmb.mb.(*memDbBuffer); ok {
+		return errors.Trace(mb.CleanupStaging(h))
+	}
+	return nil
+}
+
 // Get implements the Retriever interface.
 func (us *unionStore) Get(k Key) ([]byte, error) {
 	v, err := us.MemBuffer.Get(k)
@@ -177,9 +216,51 @@ func (us *unionStore) Get(k Key) ([]byte, error) {
 	return v, nil
 }
 
+// BatchGet implements the UnionStore BatchGet interface.
+func (us *unionStore) BatchGet(keys []Key) (map[string][]byte, error) {
+	values, err := BatchGetWithBuffer(us.MemBuffer, keys, us.snapshot.BatchGet)
+	return values, errors.Trace(err)
+}
+
+// BatchGetWithBuffer gets the values of the keys from the buffer, the keys not in the
+// buffer are got by batchGet. The keys deleted in the buffer are not in the result.
+func BatchGetWithBuffer(buffer MemBuffer, keys []Key, batchGet func(keys []Key) (map[string][]byte, error)) (map[string][]byte, error) {
+	values := make(map[string][]byte, len(keys))
+	var missKeys []Key
+	for _, k := range keys {
+		v, err := buffer.Get(k)
+		if IsErrNotFound(err) {
+			missKeys = append(missKeys, k)
+			continue
+		}
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if len(v) > 0 {
+			values[string(k)] = v
+		}
+	}
+	if len(missKeys) == 0 {
+		return values, nil
+	}
+	missValues, err := batchGet(missKeys)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	for k, v := range missValues {
+		values[k] = v
+	}
+	return values, nil
+}
+
 // markLazyConditionPair marks a kv pair for later check.
 // If condition not match, should return e as error.
 func (us *unionStore) markLazyConditionPair(k Key, v []byte, e error) {
+	if n := len(us.stagingCondKeys); n > 0 {
+		if _, ok := us.lazyConditionPairs[string(k)]; !ok {
+			us.stagingCondKeys[n-1] = append(us.stagingCondKeys[n-1], string(k))
+		}
+	}
 	us.lazyConditionPairs[string(k)] = &conditionPair{
 		key:   k.Clone(),
 		value: v,
@@ -187,6 +268,41 @@ func (us *unionStore) markLazyConditionPair(k Key, v []byte, e error) {
 	}
 }
 
+// Staging implements the Stager Staging interface.
+func (us *unionStore) Staging() StagingHandle {
+	h := us.BufferStore.Staging()
+	us.stagingCondKeys = append(us.stagingCondKeys[:h-1], nil)
+	return h
+}
+
+// ReleaseStaging implements the Stager ReleaseStaging interface.
+func (us *unionStore) ReleaseStaging(h StagingHandle) {
+	if int(h) <= 0 || int(h) > len(us.stagingCondKeys) {
+		return
+	}
+	if h > 1 {
+		for _, keys := range us.stagingCondKeys[h-1:] {
+			us.stagingCondKeys[h-2] = append(us.stagingCondKeys[h-2], keys...)
+		}
+	}
+	us.stagingCondKeys = us.stagingCondKeys[:h-1]
+	us.BufferStore.ReleaseStaging(h)
+}
+
+// CleanupStaging implements the Stager CleanupStaging interface.
+func (us *unionStore) CleanupStaging(h StagingHandle) error {
+	if int(h) <= 0 || int(h) > len(us.stagingCondKeys) {
+		return nil
+	}
+	for _, keys := range us.stagingCondKeys[h-1:] {
+		for _, k := range keys {
+			delete(us.lazyConditionPairs, k)
+		}
+	}
+	us.stagingCondKeys = us.stagingCondKeys[:h-1]
+	return errors.Trace(us.BufferStore.CleanupStaging(h))
+}
+
 // CheckLazyConditionPairs implements the UnionStore interface.
 func (us *unionStore) CheckLazyConditionPairs() error {
 	if len(us.lazyConditionPairs) == 0 {
@@ -57,6 +57,24 @@ func (s *testUnionStoreSuite) TestDelete(c *C) {
 	c.Assert(v, BytesEquals, []byte("2"))
 }
 
+func (s *testUnionStoreSuite) TestBatchGet(c *C) {
+	defer testleak.AfterTest(c)()
+	s.store.Set([]byte("1"), []byte("1"))
+	s.store.Set([]byte("2"), []byte("2"))
+	s.store.Set([]byte("3"), []byte("3"))
+	s.us.Set([]byte("2"), []byte("4"))
+	s.us.Delete([]byte("3"))
+	s.us.Set([]byte("5"), []byte("5"))
+
+	values, err := s.us.BatchGet([]Key{Key("1"), Key("2"), Key("3"), Key("4"), Key("5")})
+	c.Assert(err, IsNil)
+	c.Assert(values, DeepEquals, map[string][]byte{
+		"1": []byte("1"),
+		"2": []byte("4"),
+		"5": []byte("5"),
+	})
+}
+
 func (s *testUnionStoreSuite) TestSeek(c *C) {
 	defer testleak.AfterTest(c)()
 	s.store.Set([]byte("1"), []byte("1"))
@@ -125,6 +143,56 @@ func (s *testUnionStoreSuite) TestLazyConditionCheck(c *C) {
 	c.Assert(err, NotNil)
 }
 
+func (s *testUnionStoreSuite) TestStaging(c *C) {
+	defer testleak.AfterTest(c)()
+	s.store.Set([]byte("1"), []byte("1"))
+	s.store.Set([]byte("2"), []byte("2"))
+	s.us.Set([]byte("1"), []byte("11"))
+
+	h1 := s.us.Staging()
+	c.Assert(h1, Equals, StagingHandle(1))
+	s.us.Set([]byte("1"), []byte("12"))
+	s.us.Set([]byte("3"), []byte("3"))
+	h2 := s.us.Staging()
+	s.us.Delete([]byte("2"))
+	s.us.Set([]byte("3"), []byte("33"))
+	s.us.SetOption(PresumeKeyNotExists, nil)
+	_, err := s.us.Get([]byte("4"))
+	c.Assert(IsErrNotFound(err), IsTrue)
+	s.us.DelOption(PresumeKeyNotExists)
+	c.Assert(s.us.CheckLazyConditionPairs(), IsNil)
+	s.store.Set([]byte("4"), []byte("4"))
+	c.Assert(s.us.CheckLazyConditionPairs(), NotNil)
+
+	// Rolls back the second layer.
+	c.Assert(s.us.CleanupStaging(h2), IsNil)
+	c.Assert(s.us.CheckLazyConditionPairs(), IsNil)
+	v, err := s.us.Get([]byte("2"))
+	c.Assert(err, IsNil)
+	c.Assert(v, BytesEquals, []byte("2"))
+	v, err = s.us.Get([]byte("3"))
+	c.Assert(err, IsNil)
+	c.Assert(v, BytesEquals, []byte("3"))
+
+	// Merges the second layer into the first one, then rolls back the first one.
+	h2 = s.us.Staging()
+	c.Assert(h2, Equals, StagingHandle(2))
+	s.us.Set([]byte("1"), []byte("13"))
+	s.us.Delete([]byte("3"))
+	s.us.ReleaseStaging(h2)
+	v, err = s.us.Get([]byte("1"))
+	c.Assert(err, IsNil)
+	c.Assert(v, BytesEquals, []byte("13"))
+	c.Assert(s.us.CleanupStaging(h1), IsNil)
+	v, err = s.us.Get([]byte("1"))
+	c.Assert(err, IsNil)
+	c.Assert(v, BytesEquals, []byte("11"))
+	_, err = s.us.Get([]byte("3"))
+	c.Assert(IsErrNotFound(err), IsTrue)
+	c.Assert(s.us.Len(), Equals, 1)
+	c.Assert(s.us.CleanupStaging(h1), IsNil)
+}
+
 func checkIterator(c *C, iter Iterator, keys [][]byte, values [][]byte) {
 	defer iter.Close()
 	c.Assert(len(keys), Equals, len(values))
@@ -42,6 +42,11 @@ const (
 	ActionModifyColumn
 	ActionRenameTable
 	ActionSetDefaultValue
+	ActionMultiSchemaChange
+	ActionAlterIndexVisibility
+	ActionRecoverTable
+	ActionAddCheckConstraint
+	ActionDropCheckConstraint
 )
 
 func (action ActionType) String() string {
@@ -74,6 +79,16 @@ func (action ActionType) String() string {
 		return "rename table"
 	case ActionSetDefaultValue:
 		return "set default value"
+	case ActionMultiSchemaChange:
+		return "multi schema change"
+	case ActionAlterIndexVisibility:
+		return "alter index visibility"
+	case ActionRecoverTable:
+		return "recover table"
+	case ActionAddCheckConstraint:
+		return "add check constraint"
+	case ActionDropCheckConstraint:
+		return "drop check constraint"
 	default:
 		return "none"
 	}
@@ -135,6 +150,70 @@ type Job struct {
 
 	// Version indicates the DDL job version. For old jobs, it will be 0.
 	Version int64 `json:"version"`
+
+	// MultiSchemaInfo keeps the sub-jobs of a multi-schema change job, it's nil for other jobs.
+	MultiSchemaInfo *MultiSchemaInfo `json:"multi_schema_info"`
+}
+
+// MultiSchemaInfo keeps the information of a multi-schema change job.
+type MultiSchemaInfo struct {
+	SubJobs []*SubJob `json:"sub_jobs"`
+	// Revertible is true before all the sub-jobs are made public together.
+	// Once it's false, the job can't be rolled back anymore.
+	Revertible bool `json:"revertible"`
+}
+
+// SubJob is a single schema change of a multi-schema change job.
+type SubJob struct {
+	Type        ActionType      `json:"type"`
+	Args        []interface{}   `json:"-"`
+	RawArgs     json.RawMessage `json:"raw_args"`
+	SchemaState SchemaState     `json:"schema_state"`
+	SnapshotVer uint64          `json:"snapshot_ver"`
+	// Revertible is true if the sub-job still has steps to run before it's made public.
+	Revertible bool `json:"revertible"`
+}
+
+// ToProxyJob converts a sub-job to a job, so that it can be run by the handler of a single schema change.
+func (sub *SubJob) ToProxyJob(parent *Job) *Job {
+	return &Job{
+		ID:          parent.ID,
+		Type:        sub.Type,
+		SchemaID:    parent.SchemaID,
+		TableID:     parent.TableID,
+		State:       parent.State,
+		RowCount:    parent.GetRowCount(),
+		Args:        sub.Args,
+		RawArgs:     sub.RawArgs,
+		SchemaState: sub.SchemaState,
+		SnapshotVer: sub.SnapshotVer,
+		BinlogInfo:  parent.BinlogInfo,
+		Version:     parent.Version,
+		MultiSchemaInfo: &MultiSchemaInfo{
+			Revertible: sub.Revertible,
+		},
+	}
+}
+
+// FromProxyJob updates the sub-job with the proxy job after it runs a step.
+func (sub *SubJob) FromProxyJob(proxyJob *Job) {
+	sub.Args = proxyJob.Args
+	sub.RawArgs = proxyJob.RawArgs
+	sub.SchemaState = proxyJob.SchemaState
+	sub.SnapshotVer = proxyJob.SnapshotVer
+	sub.Revertible = proxyJob.MultiSchemaInfo.Revertible
+}
+
+// IsMultiSchemaSubJob returns whether the job is a proxy job of a multi-schema change sub-job.
+func (job *Job) IsMultiSchemaSubJob() bool {
+	return job.Type != ActionMultiSchemaChange && job.MultiSchemaInfo != nil
+}
+
+// MarkNonRevertible marks a proxy job as ready to be made public by its multi-schema change job.
+func (job *Job) MarkNonRevertible() {
+	if job.MultiSchemaInfo != nil {
+		job.MultiSchemaInfo.Revertible = false
+	}
 }
 
 // SetRowCount sets the number of rows. Make sure it can pass `make race`.
@@ -162,6 +241,18 @@ func (job *Job) Encode(updateRawArgs bool) ([]byte, error) {
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
+		if job.MultiSchemaInfo != nil {
+			for _, sub := range job.MultiSchemaInfo.SubJobs {
+				// Only update the args of the sub-jobs that have been decoded or newly built.
+				if sub.Args == nil {
+					continue
+				}
+				sub.RawArgs, err = json.Marshal(sub.Args)
+				if err != nil {
+					return nil, errors.Trace(err)
+				}
+			}
+		}
 	}
 
 	var b []byte
@@ -186,6 +277,13 @@ func (job *Job) DecodeArgs(args ...interface{}) error {
 	return errors.Trace(err)
 }
 
+// DecodeArgs decodes sub-job args.
+func (sub *SubJob) DecodeArgs(args ...interface{}) error {
+	sub.Args = args
+	err := json.Unmarshal(sub.RawArgs, &sub.Args)
+	return errors.Trace(err)
+}
+
 // String implements fmt.Stringer interface.
 func (job *Job) String() string {
 	rowCount := job.GetRowCount()
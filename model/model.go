@@ -70,6 +70,9 @@ type ColumnInfo struct {
 	types.FieldType     `json:"type"`
 	State               SchemaState `json:"state"`
 	Comment             string      `json:"comment"`
+	// Hidden is true for the virtual generated columns that are created for expression indices,
+	// they can't be seen or referenced by users.
+	Hidden bool `json:"hidden"`
 }
 
 // Clone clones ColumnInfo.
@@ -108,6 +111,8 @@ type TableInfo struct {
 	// We need to save original schemaID to keep autoID unchanged
 	// while renaming a table from one database to another.
 	OldSchemaID int64 `json:"old_schema_id,omitempty"`
+	// CheckConstraints are the CHECK constraints of the table.
+	CheckConstraints []*CheckConstraintInfo `json:"check_constraints,omitempty"`
 }
 
 // Clone clones TableInfo.
@@ -129,6 +134,13 @@ func (t *TableInfo) Clone() *TableInfo {
 		nt.ForeignKeys[i] = t.ForeignKeys[i].Clone()
 	}
 
+	if len(t.CheckConstraints) > 0 {
+		nt.CheckConstraints = make([]*CheckConstraintInfo, len(t.CheckConstraints))
+		for i := range t.CheckConstraints {
+			nt.CheckConstraints[i] = t.CheckConstraints[i].Clone()
+		}
+	}
+
 	return &nt
 }
 
@@ -218,6 +230,8 @@ type IndexInfo struct {
 	State   SchemaState    `json:"state"`
 	Comment string         `json:"comment"`    // Comment
 	Tp      IndexType      `json:"index_type"` // Index type: Btree or Hash
+	// Invisible indices are maintained by DML, but they are ignored by the optimizer.
+	Invisible bool `json:"is_invisible"`
 }
 
 // Clone clones IndexInfo.
@@ -264,6 +278,19 @@ func (fk *FKInfo) Clone() *FKInfo {
 	return &nfk
 }
 
+// CheckConstraintInfo provides meta data describing a CHECK constraint.
+type CheckConstraintInfo struct {
+	Name       CIStr       `json:"name"`
+	ExprString string      `json:"expr_string"`
+	State      SchemaState `json:"state"`
+}
+
+// Clone clones CheckConstraintInfo.
+func (c *CheckConstraintInfo) Clone() *CheckConstraintInfo {
+	nc := *c
+	return &nc
+}
+
 // DBInfo provides meta data describing a DB.
 type DBInfo struct {
 	ID      int64        `json:"id"`      // Database ID
@@ -184,6 +184,43 @@ func (*testModelSuite) TestJobCodec(c *C) {
 	c.Assert(job.GetRowCount(), Equals, int64(3))
 }
 
+func (*testModelSuite) TestMultiSchemaJobCodec(c *C) {
+	job := &Job{
+		ID:   1,
+		Type: ActionMultiSchemaChange,
+		MultiSchemaInfo: &MultiSchemaInfo{
+			SubJobs: []*SubJob{
+				{Type: ActionDropColumn, Args: []interface{}{NewCIStr("a")}},
+				{Type: ActionDropIndex, Args: []interface{}{NewCIStr("idx")}, Revertible: true},
+			},
+			Revertible: true,
+		},
+	}
+	b, err := job.Encode(true)
+	c.Assert(err, IsNil)
+	newJob := &Job{}
+	err = newJob.Decode(b)
+	c.Assert(err, IsNil)
+	c.Assert(newJob.MultiSchemaInfo.Revertible, IsTrue)
+	c.Assert(newJob.MultiSchemaInfo.SubJobs, HasLen, 2)
+
+	sub := newJob.MultiSchemaInfo.SubJobs[1]
+	name := CIStr{}
+	err = sub.DecodeArgs(&name)
+	c.Assert(err, IsNil)
+	c.Assert(name, DeepEquals, NewCIStr("idx"))
+
+	proxyJob := sub.ToProxyJob(newJob)
+	c.Assert(proxyJob.Type, Equals, ActionDropIndex)
+	c.Assert(proxyJob.IsMultiSchemaSubJob(), IsTrue)
+	c.Assert(newJob.IsMultiSchemaSubJob(), IsFalse)
+	proxyJob.SchemaState = StateWriteOnly
+	proxyJob.MarkNonRevertible()
+	sub.FromProxyJob(proxyJob)
+	c.Assert(sub.SchemaState, Equals, StateWriteOnly)
+	c.Assert(sub.Revertible, IsFalse)
+}
+
 func (testModelSuite) TestState(c *C) {
 	schemaTbl := []SchemaState{
 		StateDeleteOnly,
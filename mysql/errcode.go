@@ -890,4 +890,15 @@ const (
 	ErrInvalidJSONPath                                              = 3143
 	ErrInvalidJSONData                                              = 3146
 	ErrJSONUsedAsKey                                                = 3152
+	ErrPKIndexCantBeInvisible                                       = 3522
+	ErrFunctionalIndexPrimaryKey                                    = 3756
+	ErrFunctionalIndexOnField                                       = 3762
+	ErrCheckConstraintFunctionIsNotAllowed                          = 3814
+	ErrCheckConstraintVariables                                     = 3816
+	ErrCheckConstraintRefersAutoIncrementColumn                     = 3818
+	ErrCheckConstraintViolated                                      = 3819
+	ErrCheckConstraintRefersUnknownColumn                           = 3820
+	ErrCheckConstraintNotFound                                      = 3821
+	ErrCheckConstraintDupName                                       = 3822
+	ErrDependentByCheckConstraint                                   = 3959
 )
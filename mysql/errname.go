@@ -887,4 +887,15 @@ var MySQLErrName = map[uint16]string{
 	ErrInvalidJSONPath:                                       "Invalid JSON path expression %s.",
 	ErrInvalidJSONData:                                       "Invalid data type for JSON data",
 	ErrJSONUsedAsKey:                                         "JSON column '%-.192s' cannot be used in key specification.",
+	ErrPKIndexCantBeInvisible:                                "A primary key index cannot be invisible",
+	ErrFunctionalIndexPrimaryKey:                             "The primary key cannot be an expression index",
+	ErrFunctionalIndexOnField:                                "Expression index on a column is not supported. Consider using a regular index instead.",
+	ErrCheckConstraintFunctionIsNotAllowed:                   "An expression of a check constraint '%-.192s' contains disallowed function: %s.",
+	ErrCheckConstraintVariables:                              "An expression of a check constraint '%-.192s' cannot refer to a user or system variable.",
+	ErrCheckConstraintRefersAutoIncrementColumn:              "Check constraint '%-.192s' cannot refer to an auto-increment column.",
+	ErrCheckConstraintViolated:                               "Check constraint '%-.192s' is violated.",
+	ErrCheckConstraintRefersUnknownColumn:                    "Check constraint '%-.192s' refers to non-existing column '%-.192s'.",
+	ErrCheckConstraintNotFound:                               "Check constraint '%-.192s' is not found in the table.",
+	ErrCheckConstraintDupName:                                "Duplicate check constraint name '%-.192s'.",
+	ErrDependentByCheckConstraint:                            "Check constraint '%-.192s' uses column '%-.192s', hence column cannot be dropped or renamed.",
 }
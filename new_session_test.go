@@ -563,6 +563,176 @@ func (s *testSessionSuite) TestPrepare(c *C) {
 	c.Assert(err, IsNil)
 }
 
+func (s *testSessionSuite) TestPessimisticTxn(c *C) {
+	tk1 := testkit.NewTestKitWithInit(c, s.store)
+	tk2 := testkit.NewTestKitWithInit(c, s.store)
+	tk1.MustExec("create table pessimistic (id int primary key, c int)")
+	tk1.MustExec("insert into pessimistic values (1, 1), (2, 2)")
+
+	// SELECT FOR UPDATE blocks the other transaction until the lock is released.
+	tk1.MustExec("begin pessimistic")
+	tk1.MustQuery("select c from pessimistic where id = 1 for update").Check(testkit.Rows("1"))
+	tk2.MustExec("begin pessimistic")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// It waits for tk1 and reads the latest value after that.
+		tk2.MustExec("update pessimistic set c = c + 10 where id = 1")
+	}()
+	select {
+	case <-done:
+		c.Fatal("update isn't blocked by select for update")
+	case <-time.After(100 * time.Millisecond):
+	}
+	tk1.MustExec("update pessimistic set c = c + 1 where id = 1")
+	tk1.MustExec("commit")
+	<-done
+	// The conflict is resolved before commit.
+	tk2.MustExec("commit")
+	tk1.MustQuery("select c from pessimistic where id = 1").Check(testkit.Rows("12"))
+
+	// Concurrent inserts of the same key are checked when the lock is acquired.
+	tk1.MustExec("begin pessimistic")
+	tk2.MustExec("begin pessimistic")
+	tk1.MustExec("insert into pessimistic values (3, 3)")
+	done = make(chan struct{})
+	var err error
+	go func() {
+		defer close(done)
+		_, err = tk2.Exec("insert into pessimistic values (3, 4)")
+	}()
+	tk1.MustExec("commit")
+	<-done
+	c.Assert(terror.ErrorEqual(err, kv.ErrKeyExists), IsTrue, Commentf("err %v", err))
+	tk2.MustExec("commit")
+	tk1.MustQuery("select c from pessimistic where id = 3").Check(testkit.Rows("3"))
+}
+
+func (s *testSessionSuite) TestPessimisticLockWaitTimeout(c *C) {
+	tk1 := testkit.NewTestKitWithInit(c, s.store)
+	tk2 := testkit.NewTestKitWithInit(c, s.store)
+	tk1.MustExec("create table pessimistic (id int primary key, c int)")
+	tk1.MustExec("insert into pessimistic values (1, 1)")
+
+	tk1.MustExec("begin pessimistic")
+	tk1.MustExec("update pessimistic set c = 2 where id = 1")
+	tk2.MustExec("set innodb_lock_wait_timeout = 1")
+	tk2.MustExec("begin pessimistic")
+	_, err := tk2.Exec("update pessimistic set c = 3 where id = 1")
+	c.Assert(terror.ErrorEqual(err, kv.ErrLockWaitTimeout), IsTrue, Commentf("err %v", err))
+	// The timeout only fails the statement.
+	tk2.MustQuery("select c from pessimistic where id = 1").Check(testkit.Rows("1"))
+	tk2.MustExec("rollback")
+	tk1.MustExec("commit")
+	tk1.MustQuery("select c from pessimistic").Check(testkit.Rows("2"))
+}
+
+func (s *testSessionSuite) TestPessimisticDeadlock(c *C) {
+	tk1 := testkit.NewTestKitWithInit(c, s.store)
+	tk2 := testkit.NewTestKitWithInit(c, s.store)
+	tk1.MustExec("create table pessimistic (id int primary key, c int)")
+	tk1.MustExec("insert into pessimistic values (1, 1), (2, 2)")
+
+	tk1.MustExec("begin pessimistic")
+	tk2.MustExec("begin pessimistic")
+	tk1.MustExec("update pessimistic set c = 10 where id = 1")
+	tk2.MustExec("update pessimistic set c = 20 where id = 2")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		tk1.MustExec("update pessimistic set c = 10 where id = 2")
+	}()
+	time.Sleep(100 * time.Millisecond)
+	_, err := tk2.Exec("update pessimistic set c = 20 where id = 1")
+	c.Assert(terror.ErrorEqual(err, kv.ErrDeadlock), IsTrue, Commentf("err %v", err))
+	// The transaction of tk2 is rolled back, so tk1 gets the lock.
+	<-done
+	tk1.MustExec("commit")
+	tk2.MustQuery("select c from pessimistic").Check(testkit.Rows("10", "10"))
+}
+
+func (s *testSessionSuite) TestTxnMode(c *C) {
+	tk1 := testkit.NewTestKitWithInit(c, s.store)
+	tk2 := testkit.NewTestKitWithInit(c, s.store)
+	tk1.MustExec("create table pessimistic (id int primary key, c int)")
+	tk1.MustExec("insert into pessimistic values (1, 1)")
+
+	_, err := tk1.Exec("set tidb_txn_mode = 'unknown'")
+	c.Assert(terror.ErrorEqual(err, variable.ErrWrongValueForVar), IsTrue, Commentf("err %v", err))
+
+	// The transactions started by BEGIN are pessimistic.
+	tk1.MustExec("set tidb_txn_mode = 'pessimistic'")
+	tk1.MustExec("begin")
+	tk1.MustExec("update pessimistic set c = c + 1 where id = 1")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// The optimistic transaction is blocked by the lock and retried after tk1 commits.
+		tk2.MustExec("update pessimistic set c = c + 1 where id = 1")
+	}()
+	tk1.MustExec("commit")
+	<-done
+	tk1.MustQuery("select c from pessimistic").Check(testkit.Rows("3"))
+
+	// BEGIN OPTIMISTIC overrides tidb_txn_mode, the conflict fails the commit.
+	tk1.MustExec("begin optimistic")
+	tk1.MustExec("update pessimistic set c = c + 1 where id = 1")
+	tk1.MustQuery("select c from pessimistic for update")
+	tk2.MustExec("update pessimistic set c = c + 1 where id = 1")
+	_, err = tk1.Exec("commit")
+	c.Assert(err, NotNil)
+	tk1.MustQuery("select c from pessimistic").Check(testkit.Rows("4"))
+}
+
+func (s *testSessionSuite) TestAsyncCommitAnd1PC(c *C) {
+	tk := testkit.NewTestKitWithInit(c, s.store)
+	tk.MustExec("create table tk (k int primary key, c int, index i(c))")
+	tk.MustExec("set tidb_enable_async_commit = 1")
+	tk.MustExec("insert into tk values (1, 1), (2, 2)")
+	tk.MustExec("begin")
+	tk.MustExec("update tk set c = c + 10 where k = 1")
+	tk.MustExec("delete from tk where k = 2")
+	tk.MustExec("commit")
+	tk.MustQuery("select * from tk").Check(testkit.Rows("1 11"))
+
+	tk.MustExec("set tidb_enable_async_commit = 0")
+	tk.MustExec("set tidb_enable_1pc = 1")
+	tk.MustExec("insert into tk values (3, 3)")
+	tk.MustExec("update tk set c = c + 10")
+	tk.MustQuery("select * from tk").Check(testkit.Rows("1 21", "3 13"))
+	tk.MustQuery("select c from tk use index(i) where c > 15").Check(testkit.Rows("21"))
+}
+
+func (s *testSessionSuite) TestReplicaRead(c *C) {
+	tk := testkit.NewTestKitWithInit(c, s.store)
+	tk.MustExec("create table replica (id int primary key, c int, index i(c))")
+	tk.MustExec("insert into replica values (1, 1), (2, 2), (3, 3)")
+
+	_, err := tk.Exec("set tidb_replica_read = 'unknown'")
+	c.Assert(terror.ErrorEqual(err, variable.ErrWrongValueForVar), IsTrue, Commentf("err %v", err))
+
+	// Add a follower to every region.
+	ids := s.cluster.AllocIDs(1)
+	s.cluster.AddStore(ids[0], fmt.Sprintf("store%d", ids[0]))
+	defer s.cluster.RemoveStore(ids[0])
+	for _, region := range s.cluster.GetAllRegions() {
+		peerID := s.cluster.AllocID()
+		s.cluster.AddPeer(region.Meta.Id, ids[0], peerID)
+		defer s.cluster.RemovePeer(region.Meta.Id, peerID)
+	}
+
+	for _, replicaRead := range []string{"follower", "leader-and-follower", "closest", "leader"} {
+		tk.MustExec("set tidb_replica_read = '" + replicaRead + "'")
+		tk.MustQuery("select c from replica where id = 2").Check(testkit.Rows("2"))
+		tk.MustQuery("select c from replica use index(i) where c > 1").Check(testkit.Rows("2", "3"))
+		tk.MustExec("begin")
+		tk.MustQuery("select * from replica").Check(testkit.Rows("1 1", "2 2", "3 3"))
+		tk.MustExec("update replica set c = c + 1 where id = 3")
+		tk.MustExec("update replica set c = c - 1 where id = 3")
+		tk.MustExec("commit")
+	}
+}
+
 var _ = Suite(&testSchemaSuite{})
 
 type testSchemaSuite struct {
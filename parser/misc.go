@@ -250,6 +250,7 @@ var tokenMap = map[string]int{
 	"FIRST":               first,
 	"FIXED":               fixed,
 	"FLOAT":               floatType,
+	"FLASHBACK":           flashback,
 	"FLUSH":               flush,
 	"FOR":                 forKwd,
 	"FORCE":               force,
@@ -286,6 +287,7 @@ var tokenMap = map[string]int{
 	"INTEGER":             integerType,
 	"INTERVAL":            interval,
 	"INTO":                into,
+	"INVISIBLE":           invisible,
 	"IS":                  is,
 	"ISOLATION":           isolation,
 	"JOBS":                jobs,
@@ -337,9 +339,11 @@ var tokenMap = map[string]int{
 	"NULL":                null,
 	"NUMERIC":             numericType,
 	"NVARCHAR":            nvarcharType,
+	"OF":                  of,
 	"OFFSET":              offset,
 	"ON":                  on,
 	"ONLY":                only,
+	"OPTIMISTIC":          optimistic,
 	"OPTION":              option,
 	"OR":                  or,
 	"ORDER":               order,
@@ -347,6 +351,7 @@ var tokenMap = map[string]int{
 	"PARTITION":           partition,
 	"PARTITIONS":          partitions,
 	"PASSWORD":            password,
+	"PESSIMISTIC":         pessimistic,
 	"PLUGINS":             plugins,
 	"POSITION":            position,
 	"PRECISION":           precisionType,
@@ -362,9 +367,11 @@ var tokenMap = map[string]int{
 	"RANGE":               rangeKwd,
 	"READ":                read,
 	"REAL":                realType,
+	"RECOVER":             recover,
 	"REDUNDANT":           redundant,
 	"REFERENCES":          references,
 	"REGEXP":              regexpKwd,
+	"RELEASE":             release,
 	"RENAME":              rename,
 	"REPEAT":              repeat,
 	"REPEATABLE":          repeatable,
@@ -378,6 +385,7 @@ var tokenMap = map[string]int{
 	"ROW":                 row,
 	"ROW_COUNT":           rowCount,
 	"ROW_FORMAT":          rowFormat,
+	"SAVEPOINT":           savepoint,
 	"SCHEMA":              database,
 	"SCHEMAS":             databases,
 	"SECOND":              second,
@@ -417,6 +425,7 @@ var tokenMap = map[string]int{
 	"THAN":                than,
 	"THEN":                then,
 	"TIDB":                tidb,
+	"TIDB_INDEX_MERGE":    tidbIndexMerge,
 	"TIDB_INLJ":           tidbINLJ,
 	"TIDB_SMJ":            tidbSMJ,
 	"TIME":                timeType,
@@ -454,6 +463,7 @@ var tokenMap = map[string]int{
 	"VARIABLES":           variables,
 	"VIEW":                view,
 	"VIRTUAL":             virtual,
+	"VISIBLE":             visible,
 	"WARNINGS":            warnings,
 	"WEEK":                week,
 	"WHEN":                when,
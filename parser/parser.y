@@ -166,6 +166,7 @@ import (
 	null			"NULL"
 	numericType		"NUMERIC"
 	nvarcharType		"NVARCHAR"
+	of			"OF"
 	on			"ON"
 	option			"OPTION"
 	or			"OR"
@@ -180,6 +181,7 @@ import (
 	realType		"REAL"
 	references		"REFERENCES"
 	regexpKwd		"REGEXP"
+	release			"RELEASE"
 	rename         		"RENAME"
 	repeat			"REPEAT"
 	replace			"REPLACE"
@@ -279,6 +281,7 @@ import (
 	fields		"FIELDS"
 	first		"FIRST"
 	fixed		"FIXED"
+	flashback	"FLASHBACK"
 	flush		"FLUSH"
 	format		"FORMAT"
 	full		"FULL"
@@ -287,6 +290,7 @@ import (
 	hash		"HASH"
 	hour		"HOUR"
 	identified	"IDENTIFIED"
+	invisible	"INVISIBLE"
 	isolation	"ISOLATION"
 	indexes		"INDEXES"
 	jsonType	"JSON"
@@ -307,8 +311,10 @@ import (
 	none		"NONE"
 	offset		"OFFSET"
 	only		"ONLY"
+	optimistic	"OPTIMISTIC"
 	password	"PASSWORD"
 	partitions	"PARTITIONS"
+	pessimistic	"PESSIMISTIC"
 	plugins		"PLUGINS"
 	prepare		"PREPARE"
 	privileges	"PRIVILEGES"
@@ -317,6 +323,7 @@ import (
 	quarter		"QUARTER"
 	query		"QUERY"
 	quick		"QUICK"
+	recover		"RECOVER"
 	redundant	"REDUNDANT"
 	repeatable	"REPEATABLE"
 	reverse		"REVERSE"
@@ -324,6 +331,7 @@ import (
 	row 		"ROW"
 	rowCount	"ROW_COUNT"
 	rowFormat	"ROW_FORMAT"
+	savepoint	"SAVEPOINT"
 	second		"SECOND"
 	serializable	"SERIALIZABLE"
 	session		"SESSION"
@@ -353,6 +361,7 @@ import (
 	value		"VALUE"
 	variables	"VARIABLES"
 	view		"VIEW"
+	visible		"VISIBLE"
 	warnings	"WARNINGS"
 	week		"WEEK"
 	yearType	"YEAR"
@@ -390,6 +399,7 @@ import (
 	tidb		"TIDB"
 	tidbSMJ		"TIDB_SMJ"
 	tidbINLJ	"TIDB_INLJ"
+	tidbIndexMerge	"TIDB_INDEX_MERGE"
 
 %token	<item>
 
@@ -422,6 +432,7 @@ import (
 	AnalyzeTableStmt		"Analyze table statement"
 	AnyOrAll			"Any or All for subquery"
 	Assignment			"assignment"
+	AsOfClause			"AS OF clause"
 	AssignmentList			"assignment list"
 	AssignmentListOpt		"assignment list opt"
 	AuthOption			"User auth option"
@@ -432,6 +443,7 @@ import (
 	CastType			"Cast function target type"
 	CharsetName			"Character set name"
 	ColumnDef			"table column definition"
+	ColumnGroupList			"column group list"
 	ColumnName			"column name"
 	ColumnNameList			"column name list"
 	ColumnNameListOpt		"column name list opt"
@@ -521,6 +533,7 @@ import (
 	IndexOptionList			"Index Option List or empty"
 	IndexType			"index type"
 	IndexTypeOpt			"Optional index type"
+	IndexVisibility			"Index visibility"
 	InsertIntoStmt			"INSERT INTO statement"
 	InsertValues			"Rest part of INSERT/REPLACE INTO statement"
 	JoinTable 			"join table"
@@ -534,6 +547,7 @@ import (
 	LinesTerminated			"Lines terminated by"
 	Literal				"literal value"
 	LoadDataStmt			"Load data statement"
+	LoadStatsStmt			"Load statistics statement"
 	LocalOpt			"Local opt"
 	LockTablesStmt			"Lock tables statement"
 	LockClause         		"Alter table lock clause"
@@ -573,12 +587,15 @@ import (
 	OnDeleteOpt			"optional ON DELETE clause"
 	OnUpdateOpt			"optional ON UPDATE clause"
 	ReferOpt			"reference option"
+	RecoverTableStmt		"recover table statement"
+	ReleaseSavepointStmt		"RELEASE SAVEPOINT statement"
 	RenameTableStmt         	"rename table statement"
 	ReplaceIntoStmt			"REPLACE INTO statement"
 	ReplacePriority			"replace statement priority"
 	RevokeStmt			"Revoke statement"
 	RollbackStmt			"ROLLBACK statement"
 	RowFormat			"Row format option"
+	SavepointStmt			"SAVEPOINT statement"
 	SelectLockOpt			"FOR UPDATE or LOCK IN SHARE MODE,"
 	SelectStmt			"SELECT statement"
 	SelectStmtCalcFoundRows		"SELECT statement optional SQL_CALC_FOUND_ROWS"
@@ -841,6 +858,13 @@ AlterTableSpec:
 			Name: $4.(string),
 		}
 	}
+|	"DROP" "CHECK" Symbol
+	{
+		$$ = &ast.AlterTableSpec{
+			Tp: ast.AlterTableDropCheck,
+			Name: $3.(string),
+		}
+	}
 |	"DISABLE" "KEYS"
 	{
 		$$ = &ast.AlterTableSpec{}
@@ -886,6 +910,14 @@ AlterTableSpec:
 			},
 		}
 	}
+|	"ALTER" "INDEX" Identifier IndexVisibility
+	{
+		$$ = &ast.AlterTableSpec{
+			Tp:		ast.AlterTableIndexInvisible,
+			Name:		$3,
+			Visibility:	$4.(ast.IndexVisibility),
+		}
+	}
 |	"RENAME" "TO" TableName
 	{
 		$$ = &ast.AlterTableSpec{
@@ -989,6 +1021,23 @@ Symbol:
 		$$ = $1
 	}
 
+/**************************************RecoverTableStmt***************************************
+ * RECOVER TABLE tbl_name
+ * FLASHBACK TABLE tbl_name TO new_tbl_name
+ *******************************************************************************************/
+RecoverTableStmt:
+	"RECOVER" "TABLE" TableName
+	{
+		$$ = &ast.RecoverTableStmt{Table: $3.(*ast.TableName)}
+	}
+|	"FLASHBACK" "TABLE" TableName "TO" Identifier
+	{
+		$$ = &ast.RecoverTableStmt{
+			Table:		$3.(*ast.TableName),
+			NewName:	model.NewCIStr($5),
+		}
+	}
+
 /**************************************RenameTableStmt***************************************
  * See http://dev.mysql.com/doc/refman/5.7/en/rename-table.html
  *
@@ -1036,6 +1085,20 @@ AnalyzeTableStmt:
     {
         $$ = &ast.AnalyzeTableStmt{TableNames: []*ast.TableName{$3.(*ast.TableName)}, IndexNames: $5.([]model.CIStr)}
     }
+|	"ANALYZE" "TABLE" TableName "COLUMNS" ColumnGroupList
+	{
+		$$ = &ast.AnalyzeTableStmt{TableNames: []*ast.TableName{$3.(*ast.TableName)}, ColumnGroups: $5.([][]*ast.ColumnName)}
+	}
+
+ColumnGroupList:
+	'(' ColumnNameList ')'
+	{
+		$$ = [][]*ast.ColumnName{$2.([]*ast.ColumnName)}
+	}
+|	ColumnGroupList ',' '(' ColumnNameList ')'
+	{
+		$$ = append($1.([][]*ast.ColumnName), $4.([]*ast.ColumnName))
+	}
 
 /*******************************************************************************************/
 Assignment:
@@ -1066,6 +1129,14 @@ BeginTransactionStmt:
 	{
 		$$ = &ast.BeginStmt{}
 	}
+|	"BEGIN" "PESSIMISTIC"
+	{
+		$$ = &ast.BeginStmt{Mode: ast.Pessimistic}
+	}
+|	"BEGIN" "OPTIMISTIC"
+	{
+		$$ = &ast.BeginStmt{Mode: ast.Optimistic}
+	}
 |	"START" "TRANSACTION"
 	{
 		$$ = &ast.BeginStmt{}
@@ -1074,6 +1145,14 @@ BeginTransactionStmt:
 	{
 		$$ = &ast.BeginStmt{}
 	}
+|	"START" "TRANSACTION" "READ" "ONLY"
+	{
+		$$ = &ast.BeginStmt{ReadOnly: true}
+	}
+|	"START" "TRANSACTION" "READ" "ONLY" AsOfClause
+	{
+		$$ = &ast.BeginStmt{ReadOnly: true, AsOf: $5.(*ast.AsOfClause)}
+	}
 
 BinlogStmt:
 	"BINLOG" stringLit
@@ -1184,9 +1263,11 @@ ColumnOption:
 	}
 |	"CHECK" '(' Expression ')'
 	{
-		// See https://dev.mysql.com/doc/refman/5.7/en/create-table.html
-		// The CHECK clause is parsed but ignored by all storage engines.
-		$$ = &ast.ColumnOption{}
+		startOffset := parser.startOffset(&yyS[yypt-1])
+		endOffset := parser.endOffset(&yyS[yypt])
+		expr := $3.(ast.ExprNode)
+		expr.SetText(parser.src[startOffset:endOffset])
+		$$ = &ast.ColumnOption{Tp: ast.ColumnOptionCheck, Expr: expr}
 	}
 |	GeneratedAlways "AS" '(' Expression ')' VirtualOrStored
 	{
@@ -1237,7 +1318,18 @@ ColumnOptionListOpt:
 	}
 
 ConstraintElem:
-	"PRIMARY" "KEY" IndexName IndexTypeOpt '(' IndexColNameList ')' IndexOptionList
+	"CHECK" '(' Expression ')'
+	{
+		startOffset := parser.startOffset(&yyS[yypt-1])
+		endOffset := parser.endOffset(&yyS[yypt])
+		expr := $3.(ast.ExprNode)
+		expr.SetText(parser.src[startOffset:endOffset])
+		$$ = &ast.Constraint{
+			Tp:	ast.ConstraintCheck,
+			Expr:	expr,
+		}
+	}
+|	"PRIMARY" "KEY" IndexName IndexTypeOpt '(' IndexColNameList ')' IndexOptionList
 	{
 		c := &ast.Constraint{
 			Tp: ast.ConstraintPrimaryKey,
@@ -1466,6 +1558,14 @@ IndexColName:
 		//Order is parsed but just ignored as MySQL did
 		$$ = &ast.IndexColName{Column: $1.(*ast.ColumnName), Length: $2.(int)}
 	}
+|	'(' Expression ')' Order
+	{
+		startOffset := parser.startOffset(&yyS[yypt-2])
+		endOffset := parser.endOffset(&yyS[yypt-1])
+		expr := $2.(ast.ExprNode)
+		expr.SetText(parser.src[startOffset:endOffset])
+		$$ = &ast.IndexColName{Expr: expr, Length: types.UnspecifiedLength}
+	}
 
 IndexColNameList:
 	IndexColName
@@ -2212,6 +2312,8 @@ IndexOptionList:
 				opt1.Comment = opt2.Comment
 			} else if opt2.Tp != 0 {
 				opt1.Tp = opt2.Tp
+			} else if opt2.Visibility != ast.IndexVisibilityDefault {
+				opt1.Visibility = opt2.Visibility
 			}
 			$$ = opt1
 		}
@@ -2238,6 +2340,22 @@ IndexOption:
 			Comment: $2,
 		}
 	}
+|	IndexVisibility
+	{
+		$$ = &ast.IndexOption {
+			Visibility: $1.(ast.IndexVisibility),
+		}
+	}
+
+IndexVisibility:
+	"VISIBLE"
+	{
+		$$ = ast.IndexVisibilityVisible
+	}
+|	"INVISIBLE"
+	{
+		$$ = ast.IndexVisibilityInvisible
+	}
 
 IndexType:
 	"USING" "BTREE"
@@ -2274,10 +2392,11 @@ UnReservedKeyword:
 | "REPEATABLE" | "COMMITTED" | "UNCOMMITTED" | "ONLY" | "SERIALIZABLE" | "LEVEL" | "VARIABLES" | "SQL_CACHE" | "INDEXES" | "PROCESSLIST"
 | "SQL_NO_CACHE" | "DISABLE"  | "ENABLE" | "REVERSE" | "PRIVILEGES" | "NO" | "BINLOG" | "FUNCTION" | "VIEW" | "MODIFY" | "EVENTS" | "PARTITIONS"
 | "NONE" | "SUPER" | "EXCLUSIVE" | "STATS_PERSISTENT" | "ROW_COUNT" | "COALESCE" | "MONTH" | "PROCESS"
-| "MICROSECOND" | "MINUTE" | "PLUGINS" | "QUERY" | "SECOND" | "SHARE" | "SHARED"
+| "MICROSECOND" | "MINUTE" | "PLUGINS" | "QUERY" | "SECOND" | "SHARE" | "SHARED" | "VISIBLE" | "INVISIBLE" | "RECOVER" | "FLASHBACK"
+| "PESSIMISTIC" | "OPTIMISTIC" | "SAVEPOINT"
 
 TiDBKeyword:
-"ADMIN" | "DDL" | "JOBS" | "STATS" | "STATS_META" | "STATS_HISTOGRAMS" | "STATS_BUCKETS" | "TIDB" | "TIDB_SMJ" | "TIDB_INLJ"
+"ADMIN" | "DDL" | "JOBS" | "STATS" | "STATS_META" | "STATS_HISTOGRAMS" | "STATS_BUCKETS" | "TIDB" | "TIDB_SMJ" | "TIDB_INLJ" | "TIDB_INDEX_MERGE"
 
 NotKeywordToken:
  "ADDDATE" | "BIT_XOR" | "CAST" | "COUNT" | "CURTIME" | "DATE_ADD" | "DATE_SUB" | "EXTRACT" | "GET_FORMAT" | "GROUP_CONCAT" | "MIN" | "MAX" | "NOW" | "POSITION"
@@ -3537,6 +3656,31 @@ RollbackStmt:
 	{
 		$$ = &ast.RollbackStmt{}
 	}
+|	"ROLLBACK" "TO" Identifier
+	{
+		$$ = &ast.RollbackStmt{SavepointName: $3}
+	}
+|	"ROLLBACK" "TO" "SAVEPOINT" Identifier
+	{
+		$$ = &ast.RollbackStmt{SavepointName: $4}
+	}
+
+/**************************************SavepointStmt******************************************
+ * SAVEPOINT identifier
+ * RELEASE SAVEPOINT identifier
+ * See https://dev.mysql.com/doc/refman/5.7/en/savepoint.html
+ *******************************************************************************************/
+SavepointStmt:
+	"SAVEPOINT" Identifier
+	{
+		$$ = &ast.SavepointStmt{Name: $2}
+	}
+
+ReleaseSavepointStmt:
+	"RELEASE" "SAVEPOINT" Identifier
+	{
+		$$ = &ast.ReleaseSavepointStmt{Name: $3}
+	}
 
 SelectStmt:
 	"SELECT" SelectStmtOpts SelectStmtFieldList SelectStmtLimit SelectLockOpt
@@ -3691,6 +3835,13 @@ TableFactor:
 		tn.IndexHints = $3.([]*ast.IndexHint)
 		$$ = &ast.TableSource{Source: tn, AsName: $2.(model.CIStr)}
 	}
+|	TableName AsOfClause TableAsNameOpt IndexHintListOpt
+	{
+		tn := $1.(*ast.TableName)
+		tn.AsOf = $2.(*ast.AsOfClause)
+		tn.IndexHints = $4.([]*ast.IndexHint)
+		$$ = &ast.TableSource{Source: tn, AsName: $3.(model.CIStr)}
+	}
 |	'(' SelectStmt ')' TableAsName
 	{
 		st := $2.(*ast.SelectStmt)
@@ -3707,6 +3858,12 @@ TableFactor:
 		$$ = $2
 	}
 
+AsOfClause:
+	"AS" "OF" "TIMESTAMP" Expression
+	{
+		$$ = &ast.AsOfClause{TsExpr: $4.(ast.ExprNode)}
+	}
+
 TableAsNameOpt:
 	{
 		$$ = model.CIStr{}
@@ -3956,6 +4113,10 @@ TableOptimizerHintOpt:
 	{
 		$$ = &ast.TableOptimizerHint{HintName: model.NewCIStr($1), Tables: $3.([]model.CIStr)}
 	}
+|	tidbIndexMerge '(' HintTableList ')'
+	{
+		$$ = &ast.TableOptimizerHint{HintName: model.NewCIStr($1), Tables: $3.([]model.CIStr)}
+	}
 
 SelectStmtCalcFoundRows:
 	{
@@ -4724,11 +4885,15 @@ Statement:
 |	InsertIntoStmt
 |	KillStmt
 |	LoadDataStmt
+|	LoadStatsStmt
 |	PreparedStmt
 |	RollbackStmt
+|	RecoverTableStmt
+|	ReleaseSavepointStmt
 |	RenameTableStmt
 |	ReplaceIntoStmt
 |	RevokeStmt
+|	SavepointStmt
 |	SelectStmt
 |	UnionStmt
 |	SetStmt
@@ -4794,11 +4959,6 @@ TableElement:
 	{
 		$$ = $1.(*ast.Constraint)
 	}
-|	"CHECK" '(' Expression ')'
-	{
-		/* Nothing to do now */
-		$$ = nil
-	}
 
 TableElementList:
 	TableElement
@@ -5763,6 +5923,15 @@ LoadDataStmt:
 		$$ = x
 	}
 
+/**************************************LoadStatsStmt*****************************************
+ * LOAD STATS 'file.json'
+ *******************************************************************************************/
+LoadStatsStmt:
+	"LOAD" "STATS" stringLit
+	{
+		$$ = &ast.LoadStatsStmt{Path: $3}
+	}
+
 LocalOpt:
 	{
 		$$ = nil
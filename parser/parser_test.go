@@ -87,13 +87,14 @@ func (s *testParserSuite) TestSimple(c *C) {
 		"value", "warnings", "year", "now", "substr", "substring", "mode", "any", "some", "user", "identified",
 		"collation", "comment", "avg_row_length", "checksum", "compression", "connection", "key_block_size",
 		"max_rows", "min_rows", "national", "row", "quarter", "escape", "grants", "status", "fields", "triggers",
-		"delay_key_write", "isolation", "partitions", "repeatable", "committed", "uncommitted", "only", "serializable", "level",
+		"delay_key_write", "isolation", "partitions", "pessimistic", "optimistic", "repeatable", "committed", "uncommitted", "only", "serializable", "level",
 		"curtime", "variables", "dayname", "version", "btree", "hash", "row_format", "dynamic", "fixed", "compressed",
 		"compact", "redundant", "sql_no_cache sql_no_cache", "sql_cache sql_cache", "action", "round",
 		"enable", "disable", "reverse", "space", "privileges", "get_lock", "release_lock", "sleep", "no", "greatest", "least",
 		"binlog", "hex", "unhex", "function", "indexes", "from_unixtime", "processlist", "events", "less", "than", "timediff",
 		"ln", "log", "log2", "log10", "timestampdiff", "pi", "quote", "none", "super", "default", "shared", "exclusive",
-		"always", "stats", "stats_meta", "stats_histogram", "stats_buckets", "tidb_version",
+		"always", "stats", "stats_meta", "stats_histogram", "stats_buckets", "tidb_version", "recover", "flashback",
+		"savepoint",
 	}
 	for _, kw := range unreservedKws {
 		src := fmt.Sprintf("SELECT %s FROM tbl;", kw)
@@ -268,7 +269,14 @@ func (s *testParserSuite) TestDMLStmt(c *C) {
 			WHERE stuff.value >= ALL (SELECT stuff.value
 			FROM stuff)`, true},
 		{"BEGIN", true},
+		{"BEGIN PESSIMISTIC", true},
+		{"BEGIN OPTIMISTIC", true},
+		{"BEGIN PESSIMISTIC OPTIMISTIC", false},
 		{"START TRANSACTION", true},
+		{"START TRANSACTION READ ONLY", true},
+		{"START TRANSACTION READ ONLY AS OF TIMESTAMP '2017-11-11 11:11:11'", true},
+		{"START TRANSACTION READ ONLY AS OF TIMESTAMP DATE_SUB(NOW(), INTERVAL 5 SECOND)", true},
+		{"START TRANSACTION AS OF TIMESTAMP '2017-11-11 11:11:11'", false},
 		// 45
 		{"COMMIT", true},
 		{"ROLLBACK", true},
@@ -281,6 +289,23 @@ func (s *testParserSuite) TestDMLStmt(c *C) {
 			SELECT * from tmp;
 		ROLLBACK;`, true},
 
+		// for savepoint
+		{"SAVEPOINT sp1", true},
+		{"SAVEPOINT", false},
+		{"ROLLBACK TO sp1", true},
+		{"ROLLBACK TO SAVEPOINT sp1", true},
+		{"ROLLBACK TO", false},
+		{"RELEASE SAVEPOINT sp1", true},
+		{"RELEASE sp1", false},
+
+		// for as of timestamp
+		{"SELECT * FROM t AS OF TIMESTAMP '2017-11-11 11:11:11'", true},
+		{"SELECT * FROM t AS OF TIMESTAMP DATE_SUB(NOW(), INTERVAL 5 SECOND) AS t1 WHERE t1.a = 1", true},
+		{"SELECT * FROM db.t AS OF TIMESTAMP TIMESTAMP '2017-11-11 11:11:11' t1 USE INDEX (a)", true},
+		{"SELECT * FROM t AS OF TIMESTAMP '2017-11-11 11:11:11', t2 AS OF TIMESTAMP '2017-11-11 11:11:11'", true},
+		{"SELECT * FROM t AS OF '2017-11-11 11:11:11'", false},
+		{"SELECT * FROM t AS OF TIMESTAMP", false},
+
 		// qualified select
 		{"SELECT a.b.c FROM t", true},
 		{"SELECT a.b.*.c FROM t", false},
@@ -1272,6 +1297,12 @@ func (s *testParserSuite) TestDDL(c *C) {
 		// for check clause
 		{"create table t (c1 bool, c2 bool, check (c1 in (0, 1)), check (c2 in (0, 1)))", true},
 		{"CREATE TABLE Customer (SD integer CHECK (SD > 0), First_Name varchar(30));", true},
+		{"create table t (c int, constraint chk_c check (c > 0), constraint check (c < 10))", true},
+		{"create table t (c int, constraint chk_c check ())", false},
+		{"alter table t add constraint chk_c check (c > 0)", true},
+		{"alter table t add check (c > 0)", true},
+		{"alter table t drop check chk_c", true},
+		{"alter table t drop check", false},
 
 		{"create database xxx", true},
 		{"create database if exists xxx", false},
@@ -1294,6 +1325,8 @@ func (s *testParserSuite) TestDDL(c *C) {
 		{"drop table if not exists xxx", false},
 		{"drop view if exists xxx", true},
 		{"drop stats t", true},
+		{"load stats '/tmp/t.json'", true},
+		{"load stats t", false},
 		// for issue 974
 		{`CREATE TABLE address (
 		id bigint(20) NOT NULL AUTO_INCREMENT,
@@ -1419,6 +1452,10 @@ func (s *testParserSuite) TestDDL(c *C) {
 		{"ALTER TABLE t ALTER COLUMN a SET DEFAULT 1+1", false},
 		{"ALTER TABLE t ALTER COLUMN a DROP DEFAULT", true},
 		{"ALTER TABLE t ALTER a DROP DEFAULT", true},
+		{"ALTER TABLE t ALTER INDEX idx INVISIBLE", true},
+		{"ALTER TABLE t ALTER INDEX idx VISIBLE", true},
+		{"ALTER TABLE t ALTER INDEX idx", false},
+		{"ALTER TABLE t ADD INDEX idx ((a * 2)) INVISIBLE", true},
 		{"ALTER TABLE t ADD COLUMN a SMALLINT UNSIGNED, lock=none", true},
 		{"ALTER TABLE t ADD COLUMN a SMALLINT UNSIGNED, lock=default", true},
 		{"ALTER TABLE t ADD COLUMN a SMALLINT UNSIGNED, lock=shared", true},
@@ -1443,6 +1480,11 @@ func (s *testParserSuite) TestDDL(c *C) {
 		{"CREATE INDEX idx ON t (a) USING HASH COMMENT 'foo'", true},
 		{"CREATE INDEX idx USING BTREE ON t (a) USING HASH COMMENT 'foo'", true},
 		{"CREATE INDEX idx USING BTREE ON t (a)", true},
+		{"CREATE INDEX idx ON t ((lower(a)))", true},
+		{"CREATE INDEX idx ON t (a, (a + b) DESC)", true},
+		{"CREATE INDEX idx ON t (lower(a))", false},
+		{"CREATE INDEX idx ON t (a) INVISIBLE", true},
+		{"CREATE INDEX idx ON t (a) COMMENT 'foo' VISIBLE", true},
 
 		// for rename table statement
 		{"RENAME TABLE t TO t1", true},
@@ -1454,6 +1496,15 @@ func (s *testParserSuite) TestDDL(c *C) {
 		{"TRUNCATE TABLE t1", true},
 		{"TRUNCATE t1", true},
 
+		// for recover table statement
+		{"RECOVER TABLE t", true},
+		{"RECOVER TABLE d.t", true},
+		{"RECOVER t", false},
+		{"FLASHBACK TABLE t TO t1", true},
+		{"FLASHBACK TABLE d.t TO t1", true},
+		{"FLASHBACK TABLE t TO d.t1", false},
+		{"FLASHBACK TABLE t", false},
+
 		// for empty alert table index
 		{"ALTER TABLE t ADD INDEX () ", false},
 		{"ALTER TABLE t ADD UNIQUE ()", false},
@@ -1499,6 +1550,16 @@ func (s *testParserSuite) TestOptimizerHints(c *C) {
 	c.Assert(hints[1].HintName.L, Equals, "tidb_inlj")
 	c.Assert(hints[1].Tables[0].L, Equals, "t3")
 	c.Assert(hints[1].Tables[1].L, Equals, "t4")
+
+	stmt, err = parser.Parse("select /*+ TIDB_INDEX_MERGE(T1) */ c1, c2 from t1 where c1 = 1 or c2 = 2", "", "")
+	c.Assert(err, IsNil)
+	selectStmt = stmt[0].(*ast.SelectStmt)
+
+	hints = selectStmt.TableHints
+	c.Assert(len(hints), Equals, 1)
+	c.Assert(hints[0].HintName.L, Equals, "tidb_index_merge")
+	c.Assert(len(hints[0].Tables), Equals, 1)
+	c.Assert(hints[0].Tables[0].L, Equals, "t1")
 }
 
 func (s *testParserSuite) TestType(c *C) {
@@ -1893,6 +1954,10 @@ func (s *testParserSuite) TestAnalyze(c *C) {
 		{"analyze table t,t1", true},
 		{"analyze table t1 index a", true},
 		{"analyze table t1 index a,b", true},
+		{"analyze table t1 columns (a, b)", true},
+		{"analyze table t1 columns (a, b), (b, c, d)", true},
+		{"analyze table t1 columns ()", false},
+		{"analyze table t1, t2 columns (a, b)", false},
 	}
 	s.RunTest(c, table)
 }
@@ -1928,6 +1993,28 @@ func (s *testParserSuite) TestGeneratedColumn(c *C) {
 
 }
 
+func (s *testParserSuite) TestCheckConstraint(c *C) {
+	defer testleak.AfterTest(c)()
+	parser := New()
+	stmt, err := parser.ParseOneStmt("create table t (a int check (  a > 0 ), b int, constraint chk_b check (b < a + 1))", "", "")
+	c.Assert(err, IsNil)
+	createStmt := stmt.(*ast.CreateTableStmt)
+	opt := createStmt.Cols[0].Options[0]
+	c.Assert(opt.Tp, Equals, ast.ColumnOptionCheck)
+	c.Assert(opt.Expr.Text(), Equals, "a > 0")
+	c.Assert(createStmt.Constraints, HasLen, 1)
+	constr := createStmt.Constraints[0]
+	c.Assert(constr.Tp, Equals, ast.ConstraintCheck)
+	c.Assert(constr.Name, Equals, "chk_b")
+	c.Assert(constr.Expr.Text(), Equals, "b < a + 1")
+
+	stmt, err = parser.ParseOneStmt("alter table t drop check chk_b", "", "")
+	c.Assert(err, IsNil)
+	spec := stmt.(*ast.AlterTableStmt).Specs[0]
+	c.Assert(spec.Tp, Equals, ast.AlterTableDropCheck)
+	c.Assert(spec.Name, Equals, "chk_b")
+}
+
 func (s *testParserSuite) TestSetTransaction(c *C) {
 	defer testleak.AfterTest(c)()
 	// Set transaction is equivalent to setting the global or session value of tx_isolation.
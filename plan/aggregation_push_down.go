@@ -188,6 +188,17 @@ func (a *aggregationOptimizer) decompose(aggFunc aggregation.Aggregation, schema
 	return result, schema
 }
 
+// allDecomposable checks if all the aggregate functions are decomposable, the partial aggregations pushed down
+// across the union all can only compute these functions.
+func (a *aggregationOptimizer) allDecomposable(aggFuncs []aggregation.Aggregation) bool {
+	for _, fun := range aggFuncs {
+		if !a.isDecomposable(fun) {
+			return false
+		}
+	}
+	return true
+}
+
 func (a *aggregationOptimizer) allFirstRow(aggFuncs []aggregation.Aggregation) bool {
 	for _, fun := range aggFuncs {
 		if fun.GetName() != ast.AggFuncFirstRow {
@@ -216,6 +227,7 @@ func (a *aggregationOptimizer) tryToPushDownAgg(aggFuncs []aggregation.Aggregati
 		}
 	}
 	agg := a.makeNewAgg(aggFuncs, gbyCols)
+	agg.eager = true
 	child.SetParents(agg)
 	agg.SetChildren(child)
 	// If agg has no group-by item, it will return a default value, which may cause some bugs.
@@ -311,6 +323,7 @@ func (a *aggregationOptimizer) pushAggCrossUnion(agg *LogicalAggregation, unionS
 			return proj
 		}
 	}
+	newAgg.eager = true
 	newAgg.SetChildren(unionChild)
 	unionChild.SetParents(newAgg)
 	return newAgg
@@ -358,6 +371,13 @@ func (a *aggregationOptimizer) aggPushDown(p LogicalPlan) LogicalPlan {
 					join.buildKeyInfo()
 					proj := a.tryToEliminateAggregation(agg)
 					if proj != nil {
+						// The aggregation is eliminated by the unique keys of the pushed down aggregations, so
+						// they can't be removed by the physical optimizer.
+						for _, child := range join.children {
+							if pushedAgg, ok2 := child.(*LogicalAggregation); ok2 {
+								pushedAgg.eager = false
+							}
+						}
 						p = proj
 					}
 				}
@@ -378,7 +398,7 @@ func (a *aggregationOptimizer) aggPushDown(p LogicalPlan) LogicalPlan {
 				projChild := proj.children[0]
 				agg.SetChildren(projChild)
 				projChild.SetParents(agg)
-			} else if union, ok1 := child.(*Union); ok1 {
+			} else if union, ok1 := child.(*Union); ok1 && a.allDecomposable(agg.AggFuncs) {
 				var gbyCols []*expression.Column
 				for _, gbyExpr := range agg.GroupByItems {
 					gbyCols = append(gbyCols, expression.ExtractColumns(gbyExpr)...)
@@ -405,6 +425,34 @@ func (a *aggregationOptimizer) aggPushDown(p LogicalPlan) LogicalPlan {
 	return p
 }
 
+// removeEagerAggs replaces the aggregations pushed down by the optimizer with the projections computing their
+// aggregate functions on every row, the final aggregations above them still get the same results. So the physical
+// optimizer can compare the plans with and without the eager aggregations by the cost. It returns whether any
+// aggregation in the plan is replaced, and clears the tasks of the ancestors of the replaced aggregations.
+func (a *aggregationOptimizer) removeEagerAggs(p LogicalPlan) bool {
+	removed := false
+	newChildren := make([]Plan, 0, len(p.Children()))
+	for _, child := range p.Children() {
+		if agg, ok := child.(*LogicalAggregation); ok && agg.eager {
+			proj := a.convertAggToProj(agg, a.ctx, a.allocator)
+			proj.SetChildren(agg.children[0])
+			agg.children[0].SetParents(proj)
+			child = proj
+			removed = true
+		}
+		if a.removeEagerAggs(child.(LogicalPlan)) {
+			removed = true
+		}
+		child.SetParents(p)
+		newChildren = append(newChildren, child)
+	}
+	if removed {
+		p.SetChildren(newChildren...)
+		p.clearTasks()
+	}
+	return removed
+}
+
 // tryToEliminateAggregation will eliminate aggregation grouped by unique key.
 // e.g. select min(b) from t group by a. If a is a unique key, then this sql is equal to `select b from t group by a`.
 // For count(expr), sum(expr), avg(expr), count(distinct expr, [expr...]) we may need to rewrite the expr. Details are shown below.
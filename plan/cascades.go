@@ -0,0 +1,424 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plan
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/plan/cascades"
+)
+
+// operandOther is the operand of the operators not matched by the patterns of any rule.
+const operandOther cascades.Operand = "Other"
+
+// cascadesOptimize optimizes the logical plan by the cascades planner. The logical rewrites not ported to the
+// transformation rules yet are applied before the plan is put into the memo, then the join orders are explored
+// in the memo, and the physical plan is implemented by the operators' own physical plans.
+func cascadesOptimize(flag uint64, logic LogicalPlan, ctx context.Context, allocator *idAllocator) (PhysicalPlan, error) {
+	logic, err := logicalOptimize(flag, logic, ctx, allocator)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if !AllowCartesianProduct && existsCartesianProduct(logic) {
+		return nil, errors.Trace(ErrCartesianProductUnsupported)
+	}
+	logic.preparePossibleProperties()
+	logic.prepareStatsProfile()
+	memo := cascades.NewMemo()
+	root := buildMemoGroup(memo, logic)
+	optimizer := cascades.NewOptimizer(
+		[]cascades.TransformationRule{
+			&joinCommuteRule{ctx: ctx, allocator: allocator},
+			&joinAssociateRule{ctx: ctx, allocator: allocator},
+		},
+		[]cascades.ImplementationRule{
+			&dataSourceImplRule{},
+			&sortImplRule{},
+			&baseImplRule{},
+		},
+		[]cascades.Enforcer{
+			&sortEnforcer{ctx: ctx, allocator: allocator},
+		},
+	)
+	impl, err := optimizer.FindBestImplementation(memo, root, memoProp{&requiredProp{taskTp: rootTaskType, expectedCnt: math.MaxFloat64}})
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if impl == nil || impl.(memoTask).plan() == nil {
+		return nil, errors.New("the cascades planner can't find a physical plan")
+	}
+	p := impl.(memoTask).plan()
+	rebuildSchema(p)
+	p.ResolveIndices()
+	return eliminatePhysicalProjection(p), nil
+}
+
+// buildMemoGroup puts the logical plan into the memo, every operator is in its own group.
+func buildMemoGroup(memo *cascades.Memo, p LogicalPlan) *cascades.Group {
+	children := make([]*cascades.Group, 0, len(p.Children()))
+	for _, child := range p.Children() {
+		children = append(children, buildMemoGroup(memo, child.(LogicalPlan)))
+	}
+	return memo.GroupOf(newGroupExpr(p, children...))
+}
+
+func newGroupExpr(p LogicalPlan, children ...*cascades.Group) *cascades.GroupExpr {
+	var operand cascades.Operand
+	switch p.(type) {
+	case *LogicalJoin:
+		operand = TypeJoin
+	case *Projection:
+		operand = TypeProj
+	case *Sort:
+		operand = TypeSort
+	case *DataSource:
+		operand = TypeTableScan
+	default:
+		operand = operandOther
+	}
+	return cascades.NewGroupExpr(p, operand, memoDigest(p), children...)
+}
+
+// memoDigest returns the digest of the operators in the memo. The operators generated by the rules are identified
+// by their contents, so a rule won't generate the same expression again. The others are identified by their ids.
+func memoDigest(p LogicalPlan) string {
+	switch x := p.(type) {
+	case *LogicalJoin:
+		conds := concatOnAndWhereConds(x, nil)
+		codes := make([]string, 0, len(conds))
+		for _, cond := range conds {
+			codes = append(codes, string(cond.HashCode()))
+		}
+		sort.Strings(codes)
+		return fmt.Sprintf("%d,%s", x.JoinType, strings.Join(codes, ","))
+	case *Projection:
+		codes := make([]string, 0, len(x.Exprs))
+		for _, expr := range x.Exprs {
+			codes = append(codes, string(expr.HashCode()))
+		}
+		return strings.Join(codes, ",")
+	}
+	return strconv.Itoa(p.ID())
+}
+
+// groupPlan returns a logical plan of the group. The expressions of a group share the schema and the stats
+// profile, so any of them can be the child of a new operator.
+func groupPlan(g *cascades.Group) LogicalPlan {
+	return g.Exprs[0].Operator.(LogicalPlan)
+}
+
+// maxExploredJoinTables is the max number of the tables joined by a join transformed by the join rules. The
+// number of the join orders grows exponentially, the larger joins keep the order of the logical optimization.
+const maxExploredJoinTables = 6
+
+// canBeExplored checks if the join can be transformed by the join rules, it's the inner join whose conditions
+// are all between the children.
+func canBeExplored(j *LogicalJoin) bool {
+	if j.JoinType != InnerJoin || j.preferMergeJoin || j.preferINLJ > 0 {
+		return false
+	}
+	if len(j.LeftConditions) > 0 || len(j.RightConditions) > 0 {
+		return false
+	}
+	if j.schema.Len() != j.children[0].Schema().Len()+j.children[1].Schema().Len() {
+		return false
+	}
+	return joinTableCount(j) <= maxExploredJoinTables
+}
+
+// joinTableCount returns the number of the tables joined by the joins and the projections generated by the
+// join commute rule.
+func joinTableCount(p Plan) int {
+	switch x := p.(type) {
+	case *LogicalJoin:
+		return joinTableCount(x.children[0]) + joinTableCount(x.children[1])
+	case *Projection:
+		if join, ok := x.children[0].(*LogicalJoin); ok {
+			return joinTableCount(join)
+		}
+	}
+	return 1
+}
+
+// newMemoJoin creates an inner join of the children with the conditions, and prepares its properties and stats
+// profile as the operators put into the memo.
+func newMemoJoin(lChild, rChild LogicalPlan, conds []expression.Expression, ctx context.Context, allocator *idAllocator) *LogicalJoin {
+	join := LogicalJoin{
+		JoinType:  InnerJoin,
+		reordered: true,
+	}.init(allocator, ctx)
+	join.SetChildren(lChild, rChild)
+	join.SetSchema(expression.MergeSchema(lChild.Schema(), rChild.Schema()))
+	join.attachOnConds(conds)
+	for _, eqCond := range join.EqualConditions {
+		join.LeftJoinKeys = append(join.LeftJoinKeys, eqCond.GetArgs()[0].(*expression.Column))
+		join.RightJoinKeys = append(join.RightJoinKeys, eqCond.GetArgs()[1].(*expression.Column))
+	}
+	join.preparePossibleProperties()
+	join.prepareStatsProfile()
+	return join
+}
+
+// joinCommuteRule swaps the children of an inner join. A projection on the new join keeps the order of the
+// columns, so the expressions of a group have the same schema.
+type joinCommuteRule struct {
+	ctx       context.Context
+	allocator *idAllocator
+}
+
+// Pattern implements cascades.TransformationRule interface.
+func (r *joinCommuteRule) Pattern() *cascades.Pattern {
+	return cascades.NewPattern(TypeJoin)
+}
+
+// OnTransform implements cascades.TransformationRule interface.
+func (r *joinCommuteRule) OnTransform(memo *cascades.Memo, b *cascades.Binding) ([]*cascades.GroupExpr, error) {
+	join := b.Expr.Operator.(*LogicalJoin)
+	if !canBeExplored(join) {
+		return nil, nil
+	}
+	left, right := b.Expr.Children[0], b.Expr.Children[1]
+	newJoin := newMemoJoin(groupPlan(right), groupPlan(left), concatOnAndWhereConds(join, nil), r.ctx, r.allocator)
+	joinGroup := memo.GroupOf(newGroupExpr(newJoin, right, left))
+	proj := Projection{Exprs: expression.Column2Exprs(join.schema.Columns)}.init(r.allocator, r.ctx)
+	proj.SetSchema(join.schema.Clone())
+	proj.SetChildren(groupPlan(joinGroup))
+	proj.prepareStatsProfile()
+	return []*cascades.GroupExpr{newGroupExpr(proj, joinGroup)}, nil
+}
+
+// joinAssociateRule transforms (A join B) join C to A join (B join C) for inner joins. The conditions are
+// distributed to the joins again, and the rule isn't applied if B join C is a cartesian product.
+type joinAssociateRule struct {
+	ctx       context.Context
+	allocator *idAllocator
+}
+
+// Pattern implements cascades.TransformationRule interface.
+func (r *joinAssociateRule) Pattern() *cascades.Pattern {
+	return cascades.NewPattern(TypeJoin, cascades.NewPattern(TypeJoin), cascades.NewPattern(cascades.OperandAny))
+}
+
+// OnTransform implements cascades.TransformationRule interface.
+func (r *joinAssociateRule) OnTransform(memo *cascades.Memo, b *cascades.Binding) ([]*cascades.GroupExpr, error) {
+	top := b.Expr.Operator.(*LogicalJoin)
+	bottom := b.Children[0].Expr.Operator.(*LogicalJoin)
+	if !canBeExplored(top) || !canBeExplored(bottom) {
+		return nil, nil
+	}
+	a, bGroup, c := b.Children[0].Expr.Children[0], b.Children[0].Expr.Children[1], b.Expr.Children[1]
+	bPlan, cPlan := groupPlan(bGroup), groupPlan(c)
+	bcSchema := expression.MergeSchema(bPlan.Schema(), cPlan.Schema())
+	var bcConds, topConds []expression.Expression
+	for _, cond := range concatOnAndWhereConds(bottom, concatOnAndWhereConds(top, nil)) {
+		if len(bcSchema.ColumnsIndices(expression.ExtractColumns(cond))) > 0 {
+			bcConds = append(bcConds, cond)
+		} else {
+			topConds = append(topConds, cond)
+		}
+	}
+	bcJoin := newMemoJoin(bPlan, cPlan, bcConds, r.ctx, r.allocator)
+	if len(bcJoin.EqualConditions) == 0 {
+		return nil, nil
+	}
+	bcGroup := memo.GroupOf(newGroupExpr(bcJoin, bGroup, c))
+	newTop := newMemoJoin(groupPlan(a), groupPlan(bcGroup), topConds, r.ctx, r.allocator)
+	return []*cascades.GroupExpr{newGroupExpr(newTop, a, bcGroup)}, nil
+}
+
+// memoProp adapts requiredProp to cascades.Property.
+type memoProp struct {
+	*requiredProp
+}
+
+// Key implements cascades.Property interface.
+func (p memoProp) Key() string {
+	return string(p.hashCode())
+}
+
+// memoTask adapts task to cascades.Implementation.
+type memoTask struct {
+	task
+}
+
+// Cost implements cascades.Implementation interface.
+func (t memoTask) Cost() float64 {
+	return t.cost()
+}
+
+// memoCandidate implements cascades.Candidate interface.
+type memoCandidate struct {
+	childrenProps [][]*requiredProp
+	attach        func(tasks []task) (task, error)
+}
+
+// ChildrenProps implements cascades.Candidate interface.
+func (c *memoCandidate) ChildrenProps() [][]cascades.Property {
+	result := make([][]cascades.Property, 0, len(c.childrenProps))
+	for _, props := range c.childrenProps {
+		memoProps := make([]cascades.Property, len(props))
+		for i, prop := range props {
+			if prop != nil {
+				memoProps[i] = memoProp{prop}
+			}
+		}
+		result = append(result, memoProps)
+	}
+	return result
+}
+
+// Attach implements cascades.Candidate interface.
+func (c *memoCandidate) Attach(children []cascades.Implementation) (cascades.Implementation, error) {
+	tasks := make([]task, len(children))
+	for i, child := range children {
+		if child != nil {
+			tasks[i] = child.(memoTask).task
+		}
+	}
+	t, err := c.attach(tasks)
+	if err != nil || t == nil {
+		return nil, errors.Trace(err)
+	}
+	return memoTask{t}, nil
+}
+
+// dataSourceImplRule implements the DataSource by its access paths.
+type dataSourceImplRule struct{}
+
+// Operand implements cascades.ImplementationRule interface.
+func (r *dataSourceImplRule) Operand() cascades.Operand {
+	return TypeTableScan
+}
+
+// OnImplement implements cascades.ImplementationRule interface.
+func (r *dataSourceImplRule) OnImplement(expr *cascades.GroupExpr, prop cascades.Property) ([]cascades.Candidate, error) {
+	ds := expr.Operator.(*DataSource)
+	reqProp := prop.(memoProp).requiredProp
+	return []cascades.Candidate{&memoCandidate{
+		childrenProps: [][]*requiredProp{{}},
+		attach: func([]task) (task, error) {
+			t, err := ds.convert2NewPhysicalPlan(reqProp)
+			return t, errors.Trace(err)
+		},
+	}}, nil
+}
+
+// sortImplRule implements the Sort by the sort operator, or by requiring the order from the child.
+type sortImplRule struct{}
+
+// Operand implements cascades.ImplementationRule interface.
+func (r *sortImplRule) Operand() cascades.Operand {
+	return TypeSort
+}
+
+// OnImplement implements cascades.ImplementationRule interface.
+func (r *sortImplRule) OnImplement(expr *cascades.GroupExpr, prop cascades.Property) ([]cascades.Candidate, error) {
+	reqProp := prop.(memoProp).requiredProp
+	// The other orders are provided by the sort enforcer.
+	if !reqProp.isEmpty() || reqProp.taskTp != rootTaskType {
+		return nil, nil
+	}
+	s := expr.Operator.(*Sort)
+	candidates := []cascades.Candidate{&memoCandidate{
+		childrenProps: [][]*requiredProp{{{taskTp: rootTaskType, expectedCnt: math.MaxFloat64}}},
+		attach: func(tasks []task) (task, error) {
+			return s.attach2Task(tasks...), nil
+		},
+	}}
+	if newProp, canPassProp := getPropByOrderByItems(s.ByItems); canPassProp {
+		newProp.expectedCnt = reqProp.expectedCnt
+		candidates = append(candidates, &memoCandidate{
+			childrenProps: [][]*requiredProp{{newProp}},
+			attach: func(tasks []task) (task, error) {
+				return tasks[0], nil
+			},
+		})
+	}
+	return candidates, nil
+}
+
+// baseImplRule implements the other operators by the physical plans they generate.
+type baseImplRule struct{}
+
+// Operand implements cascades.ImplementationRule interface.
+func (r *baseImplRule) Operand() cascades.Operand {
+	return cascades.OperandAny
+}
+
+// OnImplement implements cascades.ImplementationRule interface.
+func (r *baseImplRule) OnImplement(expr *cascades.GroupExpr, prop cascades.Property) ([]cascades.Candidate, error) {
+	if expr.Operand == TypeTableScan || expr.Operand == TypeSort {
+		return nil, nil
+	}
+	reqProp := prop.(memoProp).requiredProp
+	// Currently all these operators can't be pushed down.
+	if reqProp.taskTp != rootTaskType {
+		return nil, nil
+	}
+	p := expr.Operator.(LogicalPlan)
+	if len(expr.Children) == 0 {
+		if !reqProp.isEmpty() {
+			return nil, nil
+		}
+		return []cascades.Candidate{&memoCandidate{
+			childrenProps: [][]*requiredProp{{}},
+			attach: func([]task) (task, error) {
+				return &rootTask{p: p.(PhysicalPlan)}, nil
+			},
+		}}, nil
+	}
+	physicalPlans := p.generatePhysicalPlans()
+	candidates := make([]cascades.Candidate, 0, len(physicalPlans))
+	for _, pp := range physicalPlans {
+		pp := pp
+		candidates = append(candidates, &memoCandidate{
+			childrenProps: pp.getChildrenPossibleProps(reqProp),
+			attach: func(tasks []task) (task, error) {
+				return pp.attach2Task(tasks...), nil
+			},
+		})
+	}
+	return candidates, nil
+}
+
+// sortEnforcer provides the order by a sort operator on the plan of any order.
+type sortEnforcer struct {
+	ctx       context.Context
+	allocator *idAllocator
+}
+
+// NewProp implements cascades.Enforcer interface.
+func (e *sortEnforcer) NewProp(prop cascades.Property) (cascades.Property, bool) {
+	reqProp := prop.(memoProp).requiredProp
+	if reqProp.isEmpty() || reqProp.taskTp != rootTaskType {
+		return nil, false
+	}
+	return memoProp{&requiredProp{taskTp: rootTaskType, expectedCnt: math.MaxFloat64}}, true
+}
+
+// OnEnforce implements cascades.Enforcer interface.
+func (e *sortEnforcer) OnEnforce(prop cascades.Property, impl cascades.Implementation) (cascades.Implementation, error) {
+	t := impl.(memoTask).task
+	if t.plan() == nil {
+		return impl, nil
+	}
+	return memoTask{prop.(memoProp).enforceProperty(t.copy(), e.ctx, e.allocator)}, nil
+}
@@ -0,0 +1,318 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cascades
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/util/testleak"
+)
+
+func TestT(t *testing.T) {
+	CustomVerboseFlag = true
+	TestingT(t)
+}
+
+var _ = Suite(&testCascadesSuite{})
+
+type testCascadesSuite struct{}
+
+const (
+	operandJoin Operand = "Join"
+	operandScan Operand = "Scan"
+	operandProj Operand = "Proj"
+)
+
+// mockOperator is an operator of the tests, the scans are named by their tables.
+type mockOperator struct {
+	name  string
+	count float64
+}
+
+func newMockExpr(operand Operand, name string, children ...*Group) *GroupExpr {
+	return NewGroupExpr(&mockOperator{name: name}, operand, name, children...)
+}
+
+// mockCommuteRule swaps the children of the joins.
+type mockCommuteRule struct{}
+
+func (r *mockCommuteRule) Pattern() *Pattern {
+	return NewPattern(operandJoin)
+}
+
+func (r *mockCommuteRule) OnTransform(m *Memo, b *Binding) ([]*GroupExpr, error) {
+	return []*GroupExpr{newMockExpr(operandJoin, "join", b.Expr.Children[1], b.Expr.Children[0])}, nil
+}
+
+// mockAssociateRule transforms (A join B) join C to A join (B join C).
+type mockAssociateRule struct{}
+
+func (r *mockAssociateRule) Pattern() *Pattern {
+	return NewPattern(operandJoin, NewPattern(operandJoin), NewPattern(OperandAny))
+}
+
+func (r *mockAssociateRule) OnTransform(m *Memo, b *Binding) ([]*GroupExpr, error) {
+	bottom := b.Children[0].Expr
+	bc := m.GroupOf(newMockExpr(operandJoin, "join", bottom.Children[1], b.Expr.Children[1]))
+	return []*GroupExpr{newMockExpr(operandJoin, "join", bottom.Children[0], bc)}, nil
+}
+
+// mockProp is the required order, the empty string means any order.
+type mockProp string
+
+func (p mockProp) Key() string {
+	return string(p)
+}
+
+// mockImpl is an implementation of the tests, the plan is described by a string.
+type mockImpl struct {
+	plan string
+	cost float64
+}
+
+func (i *mockImpl) Cost() float64 {
+	return i.cost
+}
+
+type mockCandidate struct {
+	childrenProps [][]Property
+	attach        func(children []Implementation) *mockImpl
+}
+
+func (c *mockCandidate) ChildrenProps() [][]Property {
+	return c.childrenProps
+}
+
+func (c *mockCandidate) Attach(children []Implementation) (Implementation, error) {
+	return c.attach(children), nil
+}
+
+// mockScanImplRule implements a scan by the table scan, or by the index scan which keeps the order of the
+// table name but costs more.
+type mockScanImplRule struct{}
+
+func (r *mockScanImplRule) Operand() Operand {
+	return operandScan
+}
+
+func (r *mockScanImplRule) OnImplement(expr *GroupExpr, prop Property) ([]Candidate, error) {
+	op := expr.Operator.(*mockOperator)
+	var candidates []Candidate
+	if prop.(mockProp) == "" {
+		candidates = append(candidates, &mockCandidate{
+			childrenProps: [][]Property{{}},
+			attach: func([]Implementation) *mockImpl {
+				return &mockImpl{plan: "Table(" + op.name + ")", cost: op.count}
+			},
+		})
+	}
+	if prop.(mockProp) == "" || string(prop.(mockProp)) == op.name {
+		candidates = append(candidates, &mockCandidate{
+			childrenProps: [][]Property{{}},
+			attach: func([]Implementation) *mockImpl {
+				return &mockImpl{plan: "Index(" + op.name + ")", cost: op.count * 3}
+			},
+		})
+	}
+	return candidates, nil
+}
+
+// mockJoinImplRule implements a join by the hash join, whose cost is the sum of the children.
+type mockJoinImplRule struct{}
+
+func (r *mockJoinImplRule) Operand() Operand {
+	return OperandAny
+}
+
+func (r *mockJoinImplRule) OnImplement(expr *GroupExpr, prop Property) ([]Candidate, error) {
+	if expr.Operand == operandScan || prop.(mockProp) != "" {
+		return nil, nil
+	}
+	childrenProps := make([]Property, len(expr.Children))
+	for i := range childrenProps {
+		childrenProps[i] = mockProp("")
+	}
+	return []Candidate{&mockCandidate{
+		childrenProps: [][]Property{childrenProps},
+		attach: func(children []Implementation) *mockImpl {
+			plans := make([]string, 0, len(children))
+			impl := &mockImpl{}
+			for _, child := range children {
+				plans = append(plans, child.(*mockImpl).plan)
+				impl.cost += child.Cost()
+			}
+			impl.plan = fmt.Sprintf("%s{%s}", expr.Operand, strings.Join(plans, ","))
+			return impl
+		},
+	}}, nil
+}
+
+// mockSortEnforcer sorts the rows of any order, its cost is fixed.
+type mockSortEnforcer struct{}
+
+func (e *mockSortEnforcer) NewProp(prop Property) (Property, bool) {
+	if prop.(mockProp) == "" {
+		return nil, false
+	}
+	return mockProp(""), true
+}
+
+func (e *mockSortEnforcer) OnEnforce(prop Property, impl Implementation) (Implementation, error) {
+	return &mockImpl{plan: impl.(*mockImpl).plan + "->Sort", cost: impl.Cost() + 15}, nil
+}
+
+func (s *testCascadesSuite) TestMemo(c *C) {
+	defer testleak.AfterTest(c)()
+	m := NewMemo()
+	a := m.GroupOf(newMockExpr(operandScan, "a"))
+	b := m.GroupOf(newMockExpr(operandScan, "b"))
+	c.Assert(a.ID, Equals, 0)
+	c.Assert(b.ID, Equals, 1)
+	c.Assert(m.GroupOf(newMockExpr(operandScan, "a")), Equals, a)
+
+	ab := m.GroupOf(newMockExpr(operandJoin, "join", a, b))
+	c.Assert(m.Insert(newMockExpr(operandJoin, "join", b, a), ab), IsTrue)
+	c.Assert(m.Insert(newMockExpr(operandJoin, "join", a, b), ab), IsFalse)
+	c.Assert(m.Insert(newMockExpr(operandJoin, "join", b, a), a), IsFalse)
+	c.Assert(ab.Exprs, HasLen, 2)
+	c.Assert(ab.Exprs[1].Group, Equals, ab)
+	c.Assert(m.Groups(), HasLen, 3)
+}
+
+func (s *testCascadesSuite) TestBind(c *C) {
+	defer testleak.AfterTest(c)()
+	m := NewMemo()
+	a := m.GroupOf(newMockExpr(operandScan, "a"))
+	b := m.GroupOf(newMockExpr(operandScan, "b"))
+	cGroup := m.GroupOf(newMockExpr(operandScan, "c"))
+	ab := m.GroupOf(newMockExpr(operandJoin, "join", a, b))
+	m.Insert(newMockExpr(operandJoin, "join", b, a), ab)
+	m.Insert(newMockExpr(operandProj, "proj", a), ab)
+	abc := m.GroupOf(newMockExpr(operandJoin, "join", ab, cGroup))
+
+	bindings := Bind(abc.Exprs[0], NewPattern(operandJoin))
+	c.Assert(bindings, HasLen, 1)
+	c.Assert(bindings[0].Children, HasLen, 0)
+	// The projection in the left child group doesn't match the pattern.
+	bindings = Bind(abc.Exprs[0], NewPattern(operandJoin, NewPattern(operandJoin), NewPattern(OperandAny)))
+	c.Assert(bindings, HasLen, 2)
+	for i, binding := range bindings {
+		c.Assert(binding.Expr, Equals, abc.Exprs[0])
+		c.Assert(binding.Children, HasLen, 2)
+		c.Assert(binding.Children[0].Expr, Equals, ab.Exprs[i])
+		c.Assert(binding.Children[1].Expr, Equals, cGroup.Exprs[0])
+	}
+	c.Assert(Bind(abc.Exprs[0], NewPattern(operandProj)), HasLen, 0)
+	c.Assert(Bind(abc.Exprs[0], NewPattern(operandJoin, NewPattern(OperandAny))), HasLen, 0)
+}
+
+func (s *testCascadesSuite) TestExplore(c *C) {
+	defer testleak.AfterTest(c)()
+	m := NewMemo()
+	a := m.GroupOf(newMockExpr(operandScan, "a"))
+	b := m.GroupOf(newMockExpr(operandScan, "b"))
+	cGroup := m.GroupOf(newMockExpr(operandScan, "c"))
+	ab := m.GroupOf(newMockExpr(operandJoin, "join", a, b))
+	abc := m.GroupOf(newMockExpr(operandJoin, "join", ab, cGroup))
+	o := NewOptimizer([]TransformationRule{&mockCommuteRule{}, &mockAssociateRule{}}, nil, nil)
+	impl, err := o.FindBestImplementation(m, abc, mockProp(""))
+	c.Assert(err, IsNil)
+	c.Assert(impl, IsNil)
+
+	bc := m.GroupOf(newMockExpr(operandJoin, "join", b, cGroup))
+	c.Assert(bc.ID, Less, len(m.Groups()))
+	exprs := make(map[string]bool)
+	for _, expr := range abc.Exprs {
+		exprs[expr.Fingerprint()] = true
+	}
+	c.Assert(exprs[newMockExpr(operandJoin, "join", cGroup, ab).Fingerprint()], IsTrue)
+	c.Assert(exprs[newMockExpr(operandJoin, "join", a, bc).Fingerprint()], IsTrue)
+	c.Assert(exprs[newMockExpr(operandJoin, "join", bc, a).Fingerprint()], IsTrue)
+	c.Assert(ab.Exprs, HasLen, 2)
+
+	// The memo is already explored completely.
+	groupCount, exprCount := len(m.Groups()), len(abc.Exprs)
+	_, err = o.FindBestImplementation(m, abc, mockProp(""))
+	c.Assert(err, IsNil)
+	c.Assert(m.Groups(), HasLen, groupCount)
+	c.Assert(abc.Exprs, HasLen, exprCount)
+}
+
+func (s *testCascadesSuite) TestImplement(c *C) {
+	defer testleak.AfterTest(c)()
+	tests := []struct {
+		count float64
+		prop  mockProp
+		best  string
+		cost  float64
+	}{
+		{
+			count: 10,
+			prop:  "",
+			best:  "Join{Table(a),Table(b)}",
+			cost:  20,
+		},
+		// Sorting is cheaper than the index scan.
+		{
+			count: 10,
+			prop:  "a",
+			best:  "Table(a)->Sort",
+			cost:  25,
+		},
+		{
+			count: 1,
+			prop:  "a",
+			best:  "Index(a)",
+			cost:  3,
+		},
+		// The order of the join is provided by the enforcer.
+		{
+			count: 10,
+			prop:  "b",
+			best:  "Join{Table(a),Table(b)}->Sort",
+			cost:  35,
+		},
+	}
+	for _, tt := range tests {
+		m := NewMemo()
+		a := m.GroupOf(NewGroupExpr(&mockOperator{name: "a", count: tt.count}, operandScan, "a"))
+		b := m.GroupOf(NewGroupExpr(&mockOperator{name: "b", count: 10}, operandScan, "b"))
+		root := a
+		if tt.prop != "a" {
+			root = m.GroupOf(newMockExpr(operandJoin, "join", a, b))
+		}
+		o := NewOptimizer(nil, []ImplementationRule{&mockScanImplRule{}, &mockJoinImplRule{}}, []Enforcer{&mockSortEnforcer{}})
+		impl, err := o.FindBestImplementation(m, root, tt.prop)
+		c.Assert(err, IsNil)
+		c.Assert(impl.(*mockImpl).plan, Equals, tt.best, Commentf("for %v", tt))
+		c.Assert(impl.Cost(), Equals, tt.cost, Commentf("for %v", tt))
+	}
+
+	// The groups in a cycle are implemented without the cycle.
+	m := NewMemo()
+	a := m.GroupOf(NewGroupExpr(&mockOperator{name: "a", count: 10}, operandScan, "a"))
+	p1 := m.GroupOf(newMockExpr(operandProj, "p1", a))
+	p2 := m.GroupOf(newMockExpr(operandProj, "p2", p1))
+	m.Insert(newMockExpr(operandProj, "p3", p2), p1)
+	o := NewOptimizer(nil, []ImplementationRule{&mockScanImplRule{}, &mockJoinImplRule{}}, nil)
+	impl, err := o.FindBestImplementation(m, p2, mockProp(""))
+	c.Assert(err, IsNil)
+	c.Assert(impl.(*mockImpl).plan, Equals, "Proj{Proj{Table(a)}}")
+	impl, err = o.FindBestImplementation(m, p1, mockProp("a"))
+	c.Assert(err, IsNil)
+	c.Assert(impl, IsNil)
+}
@@ -0,0 +1,128 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cascades implements a memo-based optimizer framework in the style of Cascades. The logical
+// operators are kept in a memo of groups, the transformation rules add equivalent expressions to the groups,
+// and the implementation rules and the enforcers find the cheapest implementation of a group for a required
+// physical property. The framework doesn't look into the operators, they are supplied by the rules.
+package cascades
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Operand is the kind of an operator, it's used to match the group expressions with the patterns of the rules.
+type Operand string
+
+// OperandAny matches the group expressions of any kind.
+const OperandAny Operand = "*"
+
+// Match checks if the operand of a pattern or a rule matches the operand of a group expression.
+func (o Operand) Match(other Operand) bool {
+	return o == OperandAny || o == other
+}
+
+// GroupExpr is a logical operator whose children are groups. All the expressions of a group are
+// logically equivalent.
+type GroupExpr struct {
+	// Operator is the logical operator, its own children are ignored by the framework.
+	Operator interface{}
+	Operand  Operand
+	Children []*Group
+	Group    *Group
+
+	digest      string
+	fingerprint string
+}
+
+// NewGroupExpr creates a group expression. The digest identifies the content of the operator, the group
+// expressions with the same operand, digest and children are duplicated, only one of them is kept in the memo.
+func NewGroupExpr(operator interface{}, operand Operand, digest string, children ...*Group) *GroupExpr {
+	return &GroupExpr{
+		Operator: operator,
+		Operand:  operand,
+		Children: children,
+		digest:   digest,
+	}
+}
+
+// Fingerprint returns the string identifying the group expression in the memo.
+func (e *GroupExpr) Fingerprint() string {
+	if e.fingerprint == "" {
+		ids := make([]string, 0, len(e.Children))
+		for _, child := range e.Children {
+			ids = append(ids, fmt.Sprintf("%d", child.ID))
+		}
+		e.fingerprint = fmt.Sprintf("%s{%s}(%s)", e.Operand, e.digest, strings.Join(ids, ","))
+	}
+	return e.fingerprint
+}
+
+// Group is a set of logically equivalent group expressions.
+type Group struct {
+	ID    int
+	Exprs []*GroupExpr
+
+	explored  bool
+	exploring bool
+	// impls are the cheapest implementations of the group by the keys of the required properties. A nil
+	// implementation means the group can't be implemented for the property.
+	impls map[string]Implementation
+	// implementing records the properties being implemented, it breaks the cycles among the groups.
+	implementing map[string]bool
+}
+
+// Memo stores the groups and deduplicates the group expressions.
+type Memo struct {
+	groups []*Group
+	exprs  map[string]*GroupExpr
+}
+
+// NewMemo creates an empty memo.
+func NewMemo() *Memo {
+	return &Memo{exprs: make(map[string]*GroupExpr)}
+}
+
+// Groups returns all the groups in the memo.
+func (m *Memo) Groups() []*Group {
+	return m.groups
+}
+
+// GroupOf returns the group of the expression. If the expression isn't in the memo, it's inserted into a
+// new group.
+func (m *Memo) GroupOf(expr *GroupExpr) *Group {
+	if old, ok := m.exprs[expr.Fingerprint()]; ok {
+		return old.Group
+	}
+	g := &Group{
+		ID:           len(m.groups),
+		impls:        make(map[string]Implementation),
+		implementing: make(map[string]bool),
+	}
+	m.groups = append(m.groups, g)
+	m.Insert(expr, g)
+	return g
+}
+
+// Insert inserts the expression into the group, it returns false if the expression is already in the memo.
+func (m *Memo) Insert(expr *GroupExpr, g *Group) bool {
+	if _, ok := m.exprs[expr.Fingerprint()]; ok {
+		return false
+	}
+	m.exprs[expr.Fingerprint()] = expr
+	expr.Group = g
+	g.Exprs = append(g.Exprs, expr)
+	g.explored = false
+	return true
+}
@@ -0,0 +1,232 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cascades
+
+import (
+	"github.com/juju/errors"
+)
+
+// Property is a physical property required by the parent, such as the order of the rows.
+type Property interface {
+	// Key returns the string identifying the property.
+	Key() string
+}
+
+// Implementation is a physical plan of a group with its cost.
+type Implementation interface {
+	Cost() float64
+}
+
+// Candidate is a physical operator implementing a group expression, its children are not chosen yet.
+type Candidate interface {
+	// ChildrenProps returns the alternatives of the properties required from the children groups. A nil
+	// property means the child group isn't read by the operator, its implementation is nil.
+	ChildrenProps() [][]Property
+	// Attach builds the implementation on the implementations of the children.
+	Attach(children []Implementation) (Implementation, error)
+}
+
+// TransformationRule generates the logically equivalent expressions of the expressions matching its pattern.
+type TransformationRule interface {
+	// Pattern returns the pattern of the expressions the rule is applied to.
+	Pattern() *Pattern
+	// OnTransform returns the new expressions equivalent to the root expression of the binding. The children
+	// of the new expressions are put into the memo by GroupOf.
+	OnTransform(m *Memo, b *Binding) ([]*GroupExpr, error)
+}
+
+// ImplementationRule generates the physical candidates of the expressions of an operand.
+type ImplementationRule interface {
+	// Operand returns the operand of the expressions the rule is applied to.
+	Operand() Operand
+	// OnImplement returns the physical candidates of the expression for the required property.
+	OnImplement(expr *GroupExpr, prop Property) ([]Candidate, error)
+}
+
+// Enforcer provides a physical property by adding an operator on the implementation of a weaker property,
+// such as the sort operator.
+type Enforcer interface {
+	// NewProp returns the property required from the group before enforcing, ok is false if the enforcer
+	// can't provide the property.
+	NewProp(prop Property) (newProp Property, ok bool)
+	// OnEnforce adds the enforcing operator on the implementation.
+	OnEnforce(prop Property, impl Implementation) (Implementation, error)
+}
+
+// Optimizer finds the cheapest implementation of a group by exploring the memo with the transformation
+// rules, and implementing the groups with the implementation rules and the enforcers.
+type Optimizer struct {
+	transformationRules []TransformationRule
+	implementationRules []ImplementationRule
+	enforcers           []Enforcer
+}
+
+// NewOptimizer creates an optimizer with the rules.
+func NewOptimizer(transformationRules []TransformationRule, implementationRules []ImplementationRule, enforcers []Enforcer) *Optimizer {
+	return &Optimizer{
+		transformationRules: transformationRules,
+		implementationRules: implementationRules,
+		enforcers:           enforcers,
+	}
+}
+
+// FindBestImplementation explores the memo from the group and returns the cheapest implementation of the
+// group for the property, it returns nil if the group can't be implemented.
+func (o *Optimizer) FindBestImplementation(m *Memo, g *Group, prop Property) (Implementation, error) {
+	// A group in a cycle may be explored before its child groups are done, so the exploration is repeated
+	// until nothing is added to the memo.
+	for {
+		exprCount := len(m.exprs)
+		for _, group := range m.groups {
+			group.explored = false
+		}
+		if err := o.explore(m, g); err != nil {
+			return nil, errors.Trace(err)
+		}
+		if len(m.exprs) == exprCount {
+			break
+		}
+	}
+	impl, err := o.implement(g, prop)
+	return impl, errors.Trace(err)
+}
+
+// explore applies the transformation rules to the expressions of the group and its descendants, until no new
+// expression is generated.
+func (o *Optimizer) explore(m *Memo, g *Group) error {
+	if g.exploring {
+		return nil
+	}
+	g.exploring = true
+	defer func() { g.exploring = false }()
+	for !g.explored {
+		g.explored = true
+		// The new expressions are appended to the group during the loop, they are explored too.
+		for i := 0; i < len(g.Exprs); i++ {
+			expr := g.Exprs[i]
+			for _, child := range expr.Children {
+				if err := o.explore(m, child); err != nil {
+					return errors.Trace(err)
+				}
+			}
+			for _, rule := range o.transformationRules {
+				for _, binding := range Bind(expr, rule.Pattern()) {
+					newExprs, err := rule.OnTransform(m, binding)
+					if err != nil {
+						return errors.Trace(err)
+					}
+					for _, newExpr := range newExprs {
+						m.Insert(newExpr, g)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// implement returns the cheapest implementation of the group for the property. Besides the candidates of the
+// expressions, the property can also be provided by the enforcers.
+func (o *Optimizer) implement(g *Group, prop Property) (Implementation, error) {
+	key := prop.Key()
+	if impl, ok := g.impls[key]; ok {
+		return impl, nil
+	}
+	// The group is required for the same property by itself through a cycle, the cycle is never cheaper.
+	if g.implementing[key] {
+		return nil, nil
+	}
+	g.implementing[key] = true
+	defer delete(g.implementing, key)
+
+	var best Implementation
+	for _, expr := range g.Exprs {
+		for _, rule := range o.implementationRules {
+			if !rule.Operand().Match(expr.Operand) {
+				continue
+			}
+			candidates, err := rule.OnImplement(expr, prop)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			for _, candidate := range candidates {
+				impl, err := o.implementCandidate(expr, candidate)
+				if err != nil {
+					return nil, errors.Trace(err)
+				}
+				best = cheaper(best, impl)
+			}
+		}
+	}
+	for _, enforcer := range o.enforcers {
+		newProp, ok := enforcer.NewProp(prop)
+		if !ok {
+			continue
+		}
+		impl, err := o.implement(g, newProp)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if impl == nil {
+			continue
+		}
+		impl, err = enforcer.OnEnforce(prop, impl)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		best = cheaper(best, impl)
+	}
+	g.impls[key] = best
+	return best, nil
+}
+
+// implementCandidate returns the cheapest implementation of the candidate among the alternatives of the
+// properties required from the children.
+func (o *Optimizer) implementCandidate(expr *GroupExpr, candidate Candidate) (Implementation, error) {
+	var best Implementation
+	for _, childProps := range candidate.ChildrenProps() {
+		children := make([]Implementation, len(expr.Children))
+		valid := true
+		for i, child := range expr.Children {
+			if childProps[i] == nil {
+				continue
+			}
+			impl, err := o.implement(child, childProps[i])
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			if impl == nil {
+				valid = false
+				break
+			}
+			children[i] = impl
+		}
+		if !valid {
+			continue
+		}
+		impl, err := candidate.Attach(children)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		best = cheaper(best, impl)
+	}
+	return best, nil
+}
+
+func cheaper(best, impl Implementation) Implementation {
+	if impl != nil && (best == nil || impl.Cost() < best.Cost()) {
+		return impl
+	}
+	return best
+}
@@ -0,0 +1,70 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cascades
+
+// Pattern is a tree of operands. A pattern without children matches the expressions with any children,
+// otherwise every child pattern matches an expression in the corresponding child group.
+type Pattern struct {
+	Operand  Operand
+	Children []*Pattern
+}
+
+// NewPattern creates a pattern.
+func NewPattern(operand Operand, children ...*Pattern) *Pattern {
+	return &Pattern{Operand: operand, Children: children}
+}
+
+// Binding is a tree of group expressions matching a pattern. The children of the binding are the bindings of
+// the child patterns, they are empty if the pattern has no children.
+type Binding struct {
+	Expr     *GroupExpr
+	Children []*Binding
+}
+
+// Bind returns all the bindings of the pattern rooted at the expression.
+func Bind(expr *GroupExpr, pattern *Pattern) []*Binding {
+	if !pattern.Operand.Match(expr.Operand) {
+		return nil
+	}
+	if len(pattern.Children) == 0 {
+		return []*Binding{{Expr: expr}}
+	}
+	if len(pattern.Children) != len(expr.Children) {
+		return nil
+	}
+	// childBindings[i] are the bindings of the i-th child pattern in the i-th child group.
+	childBindings := make([][]*Binding, len(expr.Children))
+	for i, child := range expr.Children {
+		for _, childExpr := range child.Exprs {
+			childBindings[i] = append(childBindings[i], Bind(childExpr, pattern.Children[i])...)
+		}
+		if len(childBindings[i]) == 0 {
+			return nil
+		}
+	}
+	// Enumerate the cartesian product of the bindings of the children.
+	results := []*Binding{{Expr: expr}}
+	for _, bindings := range childBindings {
+		newResults := make([]*Binding, 0, len(results)*len(bindings))
+		for _, result := range results {
+			for _, b := range bindings {
+				children := make([]*Binding, len(result.Children), len(result.Children)+1)
+				copy(children, result.Children)
+				newResults = append(newResults, &Binding{Expr: expr, Children: append(children, b)})
+			}
+		}
+		results = newResults
+	}
+	return results
+}
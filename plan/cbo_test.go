@@ -15,6 +15,8 @@ package plan_test
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/juju/errors"
 	. "github.com/pingcap/check"
@@ -252,6 +254,10 @@ func (s *testAnalyzeSuite) TestAnalyze(c *C) {
 			sql:  "analyze table t3",
 			best: "Analyze{Index(true, t3.a),Table(true, t3.b)}",
 		},
+		{
+			sql:  "analyze table t3 columns (b, a)",
+			best: "Analyze{Index(true, t3.a),Table(true, t3.b),Extended((t3.b, t3.a))}",
+		},
 		// Test analyze full table.
 		{
 			sql:  "select * from t where t.a <= 2",
@@ -301,6 +307,347 @@ func (s *testAnalyzeSuite) TestAnalyze(c *C) {
 	}
 }
 
+func (s *testAnalyzeSuite) TestJoinReorderByDP(c *C) {
+	defer testleak.AfterTest(c)()
+	store, dom, err := newStoreWithBootstrap()
+	c.Assert(err, IsNil)
+	testKit := testkit.NewTestKit(c, store)
+	defer func() {
+		dom.Close()
+		store.Close()
+	}()
+	testKit.MustExec("use test")
+	testKit.MustExec("drop table if exists f, d1, d2, d3")
+	testKit.MustExec("create table f (a int, b int, c int)")
+	testKit.MustExec("create table d1 (a int, b int)")
+	testKit.MustExec("create table d2 (a int, b int)")
+	testKit.MustExec("create table d3 (a int, b int)")
+	for i := 0; i < 100; i++ {
+		testKit.MustExec(fmt.Sprintf("insert into f values (%d, %d, %d)", i%20, i%10, i%5))
+	}
+	for i := 0; i < 20; i++ {
+		testKit.MustExec(fmt.Sprintf("insert into d1 values (%d, 1)", i))
+		testKit.MustExec(fmt.Sprintf("insert into d2 values (%d, %d)", i%10, i))
+		testKit.MustExec(fmt.Sprintf("insert into d3 values (%d, %d)", i%5, i))
+	}
+	testKit.MustExec("analyze table f, d1, d2, d3")
+	tests := []struct {
+		sql       string
+		threshold int
+		best      string
+	}{
+		// The greedy algorithm prefers the equal condition on d1, which doesn't filter any row actually.
+		{
+			sql:       "select * from f, d1, d2, d3 where f.a = d1.a and f.b = d2.a and f.c = d3.a and d1.b = 1 and d2.b < 1",
+			threshold: 0,
+			best:      "RightHashJoin{LeftHashJoin{RightHashJoin{TableReader(Table(d1)->Sel([eq(test.d1.b, 1)]))->TableReader(Table(f))}(test.d1.a,test.f.a)->TableReader(Table(d2)->Sel([lt(test.d2.b, 1)]))}(test.f.b,test.d2.a)->TableReader(Table(d3))}(test.f.c,test.d3.a)->Projection",
+		},
+		// The filtered d2 is joined first by the row counts estimated by the statistics.
+		{
+			sql:       "select * from f, d1, d2, d3 where f.a = d1.a and f.b = d2.a and f.c = d3.a and d1.b = 1 and d2.b < 1",
+			threshold: 4,
+			best:      "RightHashJoin{RightHashJoin{LeftHashJoin{TableReader(Table(f))->TableReader(Table(d2)->Sel([lt(test.d2.b, 1)]))}(test.f.b,test.d2.a)->TableReader(Table(d1)->Sel([eq(test.d1.b, 1)]))}(test.f.a,test.d1.a)->TableReader(Table(d3))}(test.f.c,test.d3.a)->Projection",
+		},
+		// The join group larger than the threshold is reordered by the greedy algorithm.
+		{
+			sql:       "select * from f, d1, d2, d3 where f.a = d1.a and f.b = d2.a and f.c = d3.a and d1.b = 1 and d2.b < 1",
+			threshold: 3,
+			best:      "RightHashJoin{LeftHashJoin{RightHashJoin{TableReader(Table(d1)->Sel([eq(test.d1.b, 1)]))->TableReader(Table(f))}(test.d1.a,test.f.a)->TableReader(Table(d2)->Sel([lt(test.d2.b, 1)]))}(test.f.b,test.d2.a)->TableReader(Table(d3))}(test.f.c,test.d3.a)->Projection",
+		},
+	}
+	for _, tt := range tests {
+		ctx := testKit.Se.(context.Context)
+		ctx.GetSessionVars().JoinReorderThreshold = tt.threshold
+		stmts, err := tidb.Parse(ctx, tt.sql)
+		c.Assert(err, IsNil)
+		c.Assert(stmts, HasLen, 1)
+		stmt := stmts[0]
+		is := sessionctx.GetDomain(ctx).InfoSchema()
+		err = plan.ResolveName(stmt, is, ctx)
+		c.Assert(err, IsNil)
+		err = expression.InferType(ctx.GetSessionVars().StmtCtx, stmt)
+		c.Assert(err, IsNil)
+		p, err := plan.Optimize(ctx, stmt, is)
+		c.Assert(err, IsNil)
+		c.Assert(plan.ToString(p), Equals, tt.best, Commentf("for %s", tt.sql))
+	}
+}
+
+func (s *testAnalyzeSuite) TestAggPushDownByCost(c *C) {
+	defer testleak.AfterTest(c)()
+	store, dom, err := newStoreWithBootstrap()
+	c.Assert(err, IsNil)
+	testKit := testkit.NewTestKit(c, store)
+	defer func() {
+		dom.Close()
+		store.Close()
+	}()
+	testKit.MustExec("use test")
+	testKit.MustExec("drop table if exists s, t1, t2")
+	testKit.MustExec("create table s (a int, b int)")
+	testKit.MustExec("create table t1 (a int, b int)")
+	testKit.MustExec("create table t2 (a int, b int)")
+	for i := 0; i < 10; i++ {
+		testKit.MustExec(fmt.Sprintf("insert into s values (%d, %d)", i, i%2))
+	}
+	values := make([]string, 0, 500)
+	for i := 0; i < 500; i++ {
+		values = append(values, fmt.Sprintf("(%d, %d)", i%10, i))
+	}
+	testKit.MustExec("insert into t1 values " + strings.Join(values, ","))
+	values = values[:0]
+	for i := 0; i < 500; i++ {
+		values = append(values, fmt.Sprintf("(%d, %d)", i, i))
+	}
+	testKit.MustExec("insert into t2 values " + strings.Join(values, ","))
+	testKit.MustExec("analyze table s, t1, t2")
+	tests := []struct {
+		sql  string
+		best string
+	}{
+		// The join key of t1 has only 10 distinct values, the aggregation pushed down reduces the rows of the join.
+		{
+			sql:  "select count(t1.b) from s, t1 where s.a = t1.a group by s.b",
+			best: "LeftHashJoin{TableReader(Table(s))->TableReader(Table(t1)->HashAgg)->HashAgg}(test.s.a,test.t1.a)->HashAgg",
+		},
+		// The join key of t2 is almost unique, the aggregation pushed down is removed.
+		{
+			sql:  "select count(t2.b) from s, t2 where s.a = t2.a group by s.b",
+			best: "RightHashJoin{TableReader(Table(s))->TableReader(Table(t2))->Projection}(test.s.a,test.t2.a)->HashAgg",
+		},
+		// The partial aggregations are pushed down across the union all.
+		{
+			sql:  "select a, sum(b) from (select * from t1 union all select * from t1) k group by a",
+			best: "UnionAll{TableReader(Table(t1)->HashAgg)->HashAgg->TableReader(Table(t1)->HashAgg)->HashAgg}->HashAgg->Projection",
+		},
+	}
+	ctx := testKit.Se.(context.Context)
+	for _, tt := range tests {
+		stmts, err := tidb.Parse(ctx, tt.sql)
+		c.Assert(err, IsNil)
+		c.Assert(stmts, HasLen, 1)
+		stmt := stmts[0]
+		is := sessionctx.GetDomain(ctx).InfoSchema()
+		err = plan.ResolveName(stmt, is, ctx)
+		c.Assert(err, IsNil)
+		err = expression.InferType(ctx.GetSessionVars().StmtCtx, stmt)
+		c.Assert(err, IsNil)
+		p, err := plan.Optimize(ctx, stmt, is)
+		c.Assert(err, IsNil)
+		c.Assert(plan.ToString(p), Equals, tt.best, Commentf("for %s", tt.sql))
+	}
+}
+
+func (s *testAnalyzeSuite) TestCascadesPlanner(c *C) {
+	defer testleak.AfterTest(c)()
+	store, dom, err := newStoreWithBootstrap()
+	c.Assert(err, IsNil)
+	testKit := testkit.NewTestKit(c, store)
+	defer func() {
+		dom.Close()
+		store.Close()
+	}()
+	testKit.MustExec("use test")
+	testKit.MustExec("drop table if exists f, d1, d2")
+	testKit.MustExec("create table f (a int, b int, c int, index c(c))")
+	testKit.MustExec("create table d1 (a int, b int)")
+	testKit.MustExec("create table d2 (a int, b int)")
+	for i := 0; i < 100; i++ {
+		testKit.MustExec(fmt.Sprintf("insert into f values (%d, %d, %d)", i%20, i%10, i))
+	}
+	for i := 0; i < 20; i++ {
+		testKit.MustExec(fmt.Sprintf("insert into d1 values (%d, 1)", i))
+		testKit.MustExec(fmt.Sprintf("insert into d2 values (%d, %d)", i%10, i))
+	}
+	testKit.MustExec("analyze table f, d1, d2")
+	tests := []struct {
+		sql  string
+		best string
+	}{
+		// The filtered d2 is joined first, the join order is found by the join rules in the memo.
+		{
+			sql:  "select * from f, d1, d2 where f.a = d1.a and f.b = d2.a and d1.b = 1 and d2.b < 1",
+			best: "LeftHashJoin{TableReader(Table(d1)->Sel([eq(test.d1.b, 1)]))->LeftHashJoin{TableReader(Table(f))->TableReader(Table(d2)->Sel([lt(test.d2.b, 1)]))}(test.f.b,test.d2.a)}(test.d1.a,test.f.a)->Projection",
+		},
+		// The order is provided by the index.
+		{
+			sql:  "select * from f where c > 10 order by c limit 1",
+			best: "IndexLookUp(Index(f.c)[(10,+inf]]->Limit, Table(f))->Limit",
+		},
+		// The order is provided by the sort enforcer.
+		{
+			sql:  "select f.a, d1.b from f, d1 where f.a = d1.a order by f.b",
+			best: "LeftHashJoin{TableReader(Table(f))->TableReader(Table(d1))}(test.f.a,test.d1.a)->Sort->Projection",
+		},
+	}
+	ctx := testKit.Se.(context.Context)
+	for _, tt := range tests {
+		ctx.GetSessionVars().EnableCascadesPlanner = true
+		stmts, err := tidb.Parse(ctx, tt.sql)
+		c.Assert(err, IsNil)
+		c.Assert(stmts, HasLen, 1)
+		stmt := stmts[0]
+		is := sessionctx.GetDomain(ctx).InfoSchema()
+		err = plan.ResolveName(stmt, is, ctx)
+		c.Assert(err, IsNil)
+		err = expression.InferType(ctx.GetSessionVars().StmtCtx, stmt)
+		c.Assert(err, IsNil)
+		p, err := plan.Optimize(ctx, stmt, is)
+		c.Assert(err, IsNil)
+		c.Assert(plan.ToString(p), Equals, tt.best, Commentf("for %s", tt.sql))
+
+		// The results are the same as the default planner.
+		testKit.MustExec("set @@tidb_enable_cascades_planner = 0")
+		expected := sortedRows(testKit.MustQuery(tt.sql).Rows())
+		testKit.MustExec("set @@tidb_enable_cascades_planner = 1")
+		c.Assert(sortedRows(testKit.MustQuery(tt.sql).Rows()), DeepEquals, expected, Commentf("for %s", tt.sql))
+	}
+}
+
+func sortedRows(rows [][]interface{}) []string {
+	result := make([]string, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, fmt.Sprintf("%v", row))
+	}
+	sort.Strings(result)
+	return result
+}
+
+func (s *testAnalyzeSuite) TestOuterJoinReorder(c *C) {
+	defer testleak.AfterTest(c)()
+	store, dom, err := newStoreWithBootstrap()
+	c.Assert(err, IsNil)
+	testKit := testkit.NewTestKit(c, store)
+	defer func() {
+		dom.Close()
+		store.Close()
+	}()
+	testKit.MustExec("use test")
+	testKit.MustExec("drop table if exists t1, t2, t3")
+	testKit.MustExec("create table t1 (a int, b int)")
+	testKit.MustExec("create table t2 (a int, b int)")
+	testKit.MustExec("create table t3 (a int, b int)")
+	for i := 0; i < 20; i++ {
+		testKit.MustExec(fmt.Sprintf("insert into t1 values (%d, %d)", i, i))
+		testKit.MustExec(fmt.Sprintf("insert into t3 values (%d, %d)", i, i))
+	}
+	for i := 0; i < 100; i++ {
+		testKit.MustExec(fmt.Sprintf("insert into t2 values (%d, %d)", i%5, i))
+	}
+	testKit.MustExec("analyze table t1, t2, t3")
+	tests := []struct {
+		sql  string
+		best string
+	}{
+		// Every row of t1 matches 20 rows of t2 but only one row of t3.
+		{
+			sql:  "select * from t1 left join t2 on t1.a = t2.a left join t3 on t1.b = t3.a",
+			best: "LeftHashJoin{LeftHashJoin{TableReader(Table(t1))->TableReader(Table(t3))}(test.t1.b,test.t3.a)->TableReader(Table(t2))}(test.t1.a,test.t2.a)->Projection",
+		},
+		// The join with t3 refers to t2, so it's not moved below the join with t2.
+		{
+			sql:  "select * from t1 left join t2 on t1.a = t2.a left join t3 on t2.b = t3.a",
+			best: "LeftHashJoin{LeftHashJoin{TableReader(Table(t1))->TableReader(Table(t2))}(test.t1.a,test.t2.a)->TableReader(Table(t3))}(test.t2.b,test.t3.a)",
+		},
+		// The outer join with t2 is simplified to inner join by the null-rejecting condition, and the filtered t2
+		// is joined first.
+		{
+			sql:  "select * from t1 left join t3 on t1.a = t3.a left join t2 on t1.b = t2.a where t2.b = 1",
+			best: "RightHashJoin{TableReader(Table(t2)->Sel([eq(test.t2.b, 1)]))->LeftHashJoin{TableReader(Table(t1))->TableReader(Table(t3))}(test.t1.a,test.t3.a)}(test.t2.a,test.t1.b)->Projection",
+		},
+	}
+	for _, tt := range tests {
+		ctx := testKit.Se.(context.Context)
+		stmts, err := tidb.Parse(ctx, tt.sql)
+		c.Assert(err, IsNil)
+		c.Assert(stmts, HasLen, 1)
+		stmt := stmts[0]
+		is := sessionctx.GetDomain(ctx).InfoSchema()
+		err = plan.ResolveName(stmt, is, ctx)
+		c.Assert(err, IsNil)
+		err = expression.InferType(ctx.GetSessionVars().StmtCtx, stmt)
+		c.Assert(err, IsNil)
+		p, err := plan.Optimize(ctx, stmt, is)
+		c.Assert(err, IsNil)
+		c.Assert(plan.ToString(p), Equals, tt.best, Commentf("for %s", tt.sql))
+	}
+}
+
+func (s *testAnalyzeSuite) TestIndexMerge(c *C) {
+	defer testleak.AfterTest(c)()
+	store, dom, err := newStoreWithBootstrap()
+	c.Assert(err, IsNil)
+	testKit := testkit.NewTestKit(c, store)
+	defer func() {
+		dom.Close()
+		store.Close()
+	}()
+	testKit.MustExec("use test")
+	testKit.MustExec("drop table if exists t")
+	testKit.MustExec("create table t (a int, b int, c int, index ia(a), index ib(b))")
+	for i := 0; i < 1000; i++ {
+		testKit.MustExec(fmt.Sprintf("insert into t values (%d, %d, %d)", i%20, i/50, i))
+	}
+	testKit.MustExec("analyze table t")
+	tests := []struct {
+		sql  string
+		best string
+	}{
+		// Every value of a and b matches 50 rows, reading one index and filtering the other condition is
+		// cheaper than intersecting the two index scans.
+		{
+			sql:  "select * from t where a = 1 and b = 1",
+			best: "IndexLookUp(Index(t.ia)[[1,1]], Table(t)->Sel([eq(test.t.b, 1)]))",
+		},
+		{
+			sql:  "select /*+ TIDB_INDEX_MERGE(t) */ * from t where a = 1 and b = 1",
+			best: "IndexMerge(intersection, [Index(t.ia)[[1,1]], Index(t.ib)[[1,1]]], Table(t))",
+		},
+		{
+			sql:  "select /*+ TIDB_INDEX_MERGE(t) */ * from t where a = 1 and b = 1 and c > 10",
+			best: "IndexMerge(intersection, [Index(t.ia)[[1,1]], Index(t.ib)[[1,1]]], Table(t)->Sel([gt(test.t.c, 10)]))",
+		},
+		// The hint is ignored if no index merge is possible.
+		{
+			sql:  "select /*+ TIDB_INDEX_MERGE(t) */ * from t where a = 1 and c = 1",
+			best: "IndexLookUp(Index(t.ia)[[1,1]], Table(t)->Sel([eq(test.t.c, 1)]))",
+		},
+		{
+			sql:  "select * from t where a = 1 or b = 1",
+			best: "IndexMerge(union, [Index(t.ia)[[1,1]], Index(t.ib)[[1,1]]], Table(t))",
+		},
+		// A single index is cheaper if the other condition isn't selective.
+		{
+			sql:  "select * from t where a = 1 and b < 18",
+			best: "IndexLookUp(Index(t.ia)[[1,1]], Table(t)->Sel([lt(test.t.b, 18)]))",
+		},
+		// The table scan is cheaper if the union returns most of the rows.
+		{
+			sql:  "select * from t where a < 18 or b = 1",
+			best: "TableReader(Table(t)->Sel([or(lt(test.t.a, 18), eq(test.t.b, 1))]))",
+		},
+		{
+			sql:  "select /*+ TIDB_INDEX_MERGE(t1) */ * from t t1 where a < 18 or b = 1",
+			best: "IndexMerge(union, [Index(t.ia)[[-inf,18)], Index(t.ib)[[1,1]]], Table(t))",
+		},
+	}
+	for _, tt := range tests {
+		ctx := testKit.Se.(context.Context)
+		stmts, err := tidb.Parse(ctx, tt.sql)
+		c.Assert(err, IsNil)
+		c.Assert(stmts, HasLen, 1)
+		stmt := stmts[0]
+		is := sessionctx.GetDomain(ctx).InfoSchema()
+		err = plan.ResolveName(stmt, is, ctx)
+		c.Assert(err, IsNil)
+		err = expression.InferType(ctx.GetSessionVars().StmtCtx, stmt)
+		c.Assert(err, IsNil)
+		p, err := plan.Optimize(ctx, stmt, is)
+		c.Assert(err, IsNil)
+		c.Assert(plan.ToString(p), Equals, tt.best, Commentf("for %s", tt.sql))
+	}
+}
+
 func newStoreWithBootstrap() (kv.Storage, *domain.Domain, error) {
 	store, err := tikv.NewMockTikvStore()
 	if err != nil {
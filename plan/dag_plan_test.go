@@ -168,6 +168,35 @@ func (s *testPlanSuite) TestDAGPlanBuilderSimpleCase(c *C) {
 			sql:  "select * from t use index(e_d_c_str_prefix) where t.c_str = 'abcdefghijk' and t.d_str = 'd' and t.e_str = 'e'",
 			best: "IndexLookUp(Index(t.e_d_c_str_prefix)[[e d [97 98 99 100 101 102 103 104 105 106],e d [97 98 99 100 101 102 103 104 105 106]]], Table(t)->Sel([eq(test.t.c_str, abcdefghijk)]))",
 		},
+		// Test index merge.
+		{
+			sql:  "select * from t where t.c = 1 or t.f = 2",
+			best: "IndexMerge(union, [Index(t.c_d_e)[[1,1]], Index(t.f)[[2,2]]], Table(t))",
+		},
+		// Test the conditions not used by index merge are filtered after the table is read.
+		{
+			sql:  "select * from t where (t.c = 1 or t.f > 2 and t.f < 4) and t.b = 3",
+			best: "IndexMerge(union, [Index(t.c_d_e)[[1,1]], Index(t.f)[(2,4)]], Table(t)->Sel([eq(test.t.b, 3)]))",
+		},
+		// Test index merge isn't used if an item of the disjunction can't be converted to index ranges.
+		{
+			sql:  "select * from t where t.c = 1 or t.b = 2",
+			best: "TableReader(Table(t)->Sel([or(eq(test.t.c, 1), eq(test.t.b, 2))]))",
+		},
+		// Test index merge only uses the indices in the index hint.
+		{
+			sql:  "select * from t use index(c_d_e) where t.c = 1 or t.f = 2",
+			best: "IndexLookUp(Index(t.c_d_e)[[<nil>,+inf]], Table(t)->Sel([or(eq(test.t.c, 1), eq(test.t.f, 2))]))",
+		},
+		// Test a single index is cheaper than the intersection of the indices with the pseudo statistics.
+		{
+			sql:  "select * from t where t.c_str = 'a' and t.g = 2",
+			best: "IndexLookUp(Index(t.g)[[2,2]], Table(t)->Sel([eq(test.t.c_str, a)]))",
+		},
+		{
+			sql:  "select * from t where t.c > 1 and t.g > 2",
+			best: "IndexLookUp(Index(t.c_d_e)[(1 +inf,+inf +inf]], Table(t)->Sel([gt(test.t.g, 2)]))",
+		},
 	}
 	for _, tt := range tests {
 		comment := Commentf("for %s", tt.sql)
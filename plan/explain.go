@@ -16,6 +16,7 @@ package plan
 import (
 	"bytes"
 	"fmt"
+	"strings"
 
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/expression/aggregation"
@@ -35,6 +36,11 @@ func setParents4FinalPlan(plan PhysicalPlan) {
 		case *PhysicalIndexLookUpReader:
 			setParents4FinalPlan(copPlan.indexPlan)
 			setParents4FinalPlan(copPlan.tablePlan)
+		case *PhysicalIndexMergeReader:
+			for _, partialPlan := range copPlan.partialPlans {
+				setParents4FinalPlan(partialPlan)
+			}
+			setParents4FinalPlan(copPlan.tablePlan)
 		}
 		for _, p := range allPlans[pID].Children() {
 			if !planMark[p.ID()] {
@@ -73,7 +79,12 @@ func (p *PhysicalIndexScan) ExplainInfo() string {
 	if len(p.Index.Columns) > 0 {
 		buffer.WriteString(", index:")
 		for i, idxCol := range p.Index.Columns {
-			buffer.WriteString(idxCol.Name.O)
+			// The expression parts are shown by their expressions instead of the hidden columns.
+			if col := p.Table.Columns[idxCol.Offset]; col.Hidden {
+				buffer.WriteString(fmt.Sprintf("(%s)", col.GeneratedExprString))
+			} else {
+				buffer.WriteString(idxCol.Name.O)
+			}
 			if i+1 < len(p.Index.Columns) {
 				buffer.WriteString(", ")
 			}
@@ -137,6 +148,19 @@ func (p *PhysicalIndexLookUpReader) ExplainInfo() string {
 	return fmt.Sprintf("index:%s, table:%s", p.indexPlan.ExplainID(), p.tablePlan.ExplainID())
 }
 
+// ExplainInfo implements PhysicalPlan interface.
+func (p *PhysicalIndexMergeReader) ExplainInfo() string {
+	mergeType := "union"
+	if p.IsIntersection {
+		mergeType = "intersection"
+	}
+	partials := make([]string, 0, len(p.partialPlans))
+	for _, partialPlan := range p.partialPlans {
+		partials = append(partials, partialPlan.ExplainID())
+	}
+	return fmt.Sprintf("%s, partial:%s, table:%s", mergeType, strings.Join(partials, ","), p.tablePlan.ExplainID())
+}
+
 // ExplainInfo implements PhysicalPlan interface.
 func (p *PhysicalUnionScan) ExplainInfo() string {
 	return string(expression.ExplainExpressionList(p.Conditions))
@@ -339,3 +339,40 @@ func (s *testExplainSuite) TestExplain(c *C) {
 		result.Check(testkit.Rows(dotFormatTests[i].expect))
 	}
 }
+
+func (s *testExplainSuite) TestExplainIndexVisibility(c *C) {
+	defer testleak.AfterTest(c)()
+	store, dom, err := newStoreWithBootstrap()
+	c.Assert(err, IsNil)
+	defer func() {
+		dom.Close()
+		store.Close()
+	}()
+	tk := testkit.NewTestKit(c, store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (a int, b varchar(20), key idx_a(a) invisible)")
+	tk.MustExec("create index idx_b on t ((lower(b)))")
+	tk.MustExec("insert into t values (1, 'Bob'), (2, 'bob'), (3, 'alice')")
+
+	tk.MustQuery("explain select a from t where lower(b) = 'bob'").Check(testkit.Rows(
+		"IndexScan_7   cop table:t, index:(lower(b)), range:[bob,bob], out of order:true 10",
+		"TableScan_8   cop table:t, keep order:false 10",
+		"IndexLookUp_9 Selection_3  root index:IndexScan_7, table:TableScan_8 10",
+		"Selection_3 Projection_4 IndexLookUp_9 root eq(lower(test.t.b), bob) 6400",
+		"Projection_4  Selection_3 root test.t.a 6400",
+	))
+	tk.MustQuery("select a from t where lower(b) = 'bob' order by a").Check(testkit.Rows("1", "2"))
+	tk.MustQuery("explain select * from t where a = 1").Check(testkit.Rows(
+		"TableScan_5 Selection_6  cop table:t, range:(-inf,+inf), keep order:false 10",
+		"Selection_6  TableScan_5 cop eq(test.t.a, 1) 10",
+		"TableReader_7   root data:Selection_6 10",
+	))
+	tk.MustExec("alter table t alter index idx_a visible")
+	tk.MustQuery("explain select * from t where a = 1").Check(testkit.Rows(
+		"IndexScan_8   cop table:t, index:a, range:[1,1], out of order:true 10",
+		"TableScan_9   cop table:t, keep order:false 10",
+		"IndexLookUp_10   root index:IndexScan_8, table:TableScan_9 10",
+	))
+	tk.MustQuery("select * from t where a = 1").Check(testkit.Rows("1 Bob"))
+}
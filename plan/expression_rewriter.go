@@ -58,6 +58,24 @@ func evalAstExpr(expr ast.ExprNode, ctx context.Context) (types.Datum, error) {
 	return newExpr.Eval(nil)
 }
 
+// rewriteAstExpr rewrites ast expression to expression.Expression, the column names in expr are resolved by schema.
+// The returned expression can be evaluated on the rows of schema.
+func rewriteAstExpr(expr ast.ExprNode, schema *expression.Schema, ctx context.Context) (expression.Expression, error) {
+	b := &planBuilder{
+		ctx:       ctx,
+		allocator: new(idAllocator),
+		colMapper: make(map[*ast.ColumnNameExpr]int),
+	}
+	mockPlan := TableDual{}.init(b.allocator, ctx)
+	mockPlan.SetSchema(schema)
+	newExpr, _, err := b.rewrite(expr, mockPlan, nil, true)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	newExpr.ResolveIndices(schema)
+	return newExpr, nil
+}
+
 // rewrite function rewrites ast expr to expression.Expression.
 // aggMapper maps ast.AggregateFuncExpr to the columns offset in p's output schema.
 // asScalar means whether this expression must be treated as a scalar expression.
@@ -0,0 +1,245 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plan
+
+import (
+	"math"
+	"sort"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/util/ranger"
+)
+
+// indexMergePartialPath is an index scan whose ranges are built by all of its conditions, so the handles it
+// returns are exactly the ones of the rows satisfying the conditions.
+type indexMergePartialPath struct {
+	is       *PhysicalIndexScan
+	conds    []expression.Expression
+	rowCount float64
+}
+
+// convertToIndexMerge tries to read the table by the handles of several index scans. A disjunction whose items
+// can all be converted to index ranges is read by the union of the index scans, and the conditions that can be
+// converted to the ranges of different indices are read by the intersection of the index scans. The rest of the
+// conditions are filtered after the table is read. The index merge never keeps order.
+func (p *DataSource) convertToIndexMerge(prop *requiredProp, indices []*model.IndexInfo) (task, error) {
+	if len(indices) == 0 || !prop.isEmpty() || prop.taskTp != rootTaskType || p.unionScanSchema != nil {
+		return invalidTask, nil
+	}
+	var t task = invalidTask
+	for i, cond := range p.pushedDownConds {
+		sf, ok := cond.(*expression.ScalarFunction)
+		if !ok || sf.FuncName.L != ast.LogicOr {
+			continue
+		}
+		paths, err := p.getUnionPartialPaths(sf, indices)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if len(paths) == 0 {
+			continue
+		}
+		tableConds := make([]expression.Expression, 0, len(p.pushedDownConds)-1)
+		tableConds = append(tableConds, p.pushedDownConds[:i]...)
+		tableConds = append(tableConds, p.pushedDownConds[i+1:]...)
+		if mergeTask := p.buildIndexMergeTask(paths, tableConds, false); mergeTask.cost() < t.cost() {
+			t = mergeTask
+		}
+	}
+	paths, err := p.getIntersectionPartialPaths(indices)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	// The most selective scans are intersected first, every prefix of them is tried.
+	for i := 2; i <= len(paths); i++ {
+		tableConds := make([]expression.Expression, 0, len(p.pushedDownConds))
+		for _, cond := range p.pushedDownConds {
+			if !isPathCondition(paths[:i], cond) {
+				tableConds = append(tableConds, cond)
+			}
+		}
+		if mergeTask := p.buildIndexMergeTask(paths[:i], tableConds, true); mergeTask.cost() < t.cost() {
+			t = mergeTask
+		}
+	}
+	return t, nil
+}
+
+// getUnionPartialPaths returns a partial path for every item of the disjunction, it returns nil if any of the
+// items can't be converted to index ranges.
+func (p *DataSource) getUnionPartialPaths(dnf *expression.ScalarFunction, indices []*model.IndexInfo) ([]*indexMergePartialPath, error) {
+	items := expression.SplitDNFItems(dnf)
+	paths := make([]*indexMergePartialPath, 0, len(items))
+	for _, item := range items {
+		path, err := p.getBestPartialPath(expression.SplitCNFItems(item), indices)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if path == nil {
+			return nil, nil
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// getIntersectionPartialPaths returns the partial paths of the pushed down conditions that can be converted to
+// index ranges, in the ascending order of their row counts. Every index is used once.
+func (p *DataSource) getIntersectionPartialPaths(indices []*model.IndexInfo) ([]*indexMergePartialPath, error) {
+	var paths []*indexMergePartialPath
+	for _, cond := range p.pushedDownConds {
+		path, err := p.getBestPartialPath([]expression.Expression{cond}, indices)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if path != nil {
+			paths = append(paths, path)
+		}
+	}
+	sort.SliceStable(paths, func(i, j int) bool { return paths[i].rowCount < paths[j].rowCount })
+	usedIndices := make(map[int64]bool, len(paths))
+	result := paths[:0]
+	for _, path := range paths {
+		if !usedIndices[path.is.Index.ID] {
+			usedIndices[path.is.Index.ID] = true
+			result = append(result, path)
+		}
+	}
+	return result, nil
+}
+
+// getBestPartialPath returns the index scan with the least rows among the ones whose ranges are built by all
+// the conditions, it returns nil if there isn't any.
+func (p *DataSource) getBestPartialPath(conds []expression.Expression, indices []*model.IndexInfo) (*indexMergePartialPath, error) {
+	sc := p.ctx.GetSessionVars().StmtCtx
+	var best *indexMergePartialPath
+	for _, idx := range indices {
+		idxCols, colLengths := expression.IndexInfo2Cols(p.Schema().Columns, idx)
+		if len(idxCols) == 0 {
+			continue
+		}
+		clonedConds := make([]expression.Expression, 0, len(conds))
+		for _, cond := range conds {
+			clonedConds = append(clonedConds, cond.Clone())
+		}
+		ranges, accessConds, filterConds, err := ranger.BuildRange(sc, clonedConds, ranger.IndexRangeType, idxCols, colLengths)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if len(accessConds) == 0 || len(filterConds) > 0 {
+			continue
+		}
+		is := PhysicalIndexScan{
+			Table:            p.tableInfo,
+			TableAsName:      p.TableAsName,
+			DBName:           p.DBName,
+			Columns:          p.Columns,
+			Index:            idx,
+			dataSourceSchema: p.schema,
+			Ranges:           ranger.Ranges2IndexRanges(ranges),
+			OutOfOrder:       true,
+		}.init(p.allocator, p.ctx)
+		is.AccessCondition = accessConds
+		rowCount, err := p.statisticTable.GetRowCountByIndexRanges(sc, idx.ID, is.Ranges)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if best == nil || rowCount < best.rowCount {
+			best = &indexMergePartialPath{is: is, conds: conds, rowCount: rowCount}
+		}
+	}
+	if best == nil {
+		return nil, nil
+	}
+	is := best.is
+	var indexCols []*expression.Column
+	for _, col := range is.Index.Columns {
+		indexCols = append(indexCols, &expression.Column{FromID: p.id, Position: col.Offset})
+	}
+	if is.Table.PKIsHandle {
+		for _, col := range is.Columns {
+			if mysql.HasPriKeyFlag(col.Flag) {
+				indexCols = append(indexCols, &expression.Column{FromID: p.id, Position: col.Offset})
+				break
+			}
+		}
+	}
+	is.SetSchema(expression.NewSchema(indexCols...))
+	is.profile = p.getStatsProfileByFilter(best.conds)
+	is.expectedCnt = best.rowCount
+	return best, nil
+}
+
+// isPathCondition checks if the condition is one of the conditions of the partial paths.
+func isPathCondition(paths []*indexMergePartialPath, cond expression.Expression) bool {
+	for _, path := range paths {
+		for _, pathCond := range path.conds {
+			if pathCond == cond {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// buildIndexMergeTask builds the root task of the index merge reader. The row count of the union is the sum of
+// the row counts of the partial paths, and the intersection is estimated by assuming the paths are independent.
+func (p *DataSource) buildIndexMergeTask(paths []*indexMergePartialPath, tableConds []expression.Expression, isIntersection bool) task {
+	total := float64(p.statisticTable.Count)
+	var cost, count float64
+	if isIntersection {
+		count = total
+	}
+	partialPlans := make([]PhysicalPlan, 0, len(paths))
+	for _, path := range paths {
+		cost += path.rowCount * (scanFactor + netWorkFactor)
+		if isIntersection {
+			count *= path.rowCount / math.Max(total, 1)
+		} else {
+			count += path.rowCount
+		}
+		partialPlans = append(partialPlans, path.is)
+	}
+	count = math.Min(count, total)
+	cost += count * (scanFactor + netWorkFactor)
+
+	ts := PhysicalTableScan{Columns: p.Columns, Table: p.tableInfo}.init(p.allocator, p.ctx)
+	ts.SetSchema(p.schema)
+	profile := p.getStatsProfileByFilter(nil)
+	if profile.count > 0 {
+		profile = profile.collapse(count / profile.count)
+	}
+	ts.profile = profile
+	var tablePlan PhysicalPlan = ts
+	if len(tableConds) > 0 {
+		sel := Selection{Conditions: tableConds}.init(p.allocator, p.ctx)
+		sel.SetSchema(ts.schema)
+		sel.SetChildren(ts)
+		sel.profile = p.profile
+		tablePlan = sel
+		cost += count * cpuFactor
+	}
+	reader := PhysicalIndexMergeReader{
+		partialPlans:   partialPlans,
+		tablePlan:      tablePlan,
+		IsIntersection: isIntersection,
+		NeedColHandle:  p.NeedColHandle,
+	}.init(p.allocator, p.ctx)
+	reader.profile = tablePlan.statsProfile()
+	return &rootTask{p: reader, cst: cost}
+}
@@ -85,6 +85,8 @@ const (
 	TypeTableReader = "TableReader"
 	// TypeIndexReader is the type of IndexReader.
 	TypeIndexReader = "IndexReader"
+	// TypeIndexMerge is the type of IndexMerge.
+	TypeIndexMerge = "IndexMerge"
 )
 
 func (p LogicalAggregation) init(allocator *idAllocator, ctx context.Context) *LogicalAggregation {
@@ -287,6 +289,18 @@ func (p PhysicalIndexLookUpReader) init(allocator *idAllocator, ctx context.Cont
 	return &p
 }
 
+func (p PhysicalIndexMergeReader) init(allocator *idAllocator, ctx context.Context) *PhysicalIndexMergeReader {
+	p.basePlan = newBasePlan(TypeIndexMerge, allocator, ctx, &p)
+	p.basePhysicalPlan = newBasePhysicalPlan(p.basePlan)
+	p.TablePlans = flattenPushDownPlan(p.tablePlan)
+	p.PartialPlans = make([][]PhysicalPlan, 0, len(p.partialPlans))
+	for _, partialPlan := range p.partialPlans {
+		p.PartialPlans = append(p.PartialPlans, flattenPushDownPlan(partialPlan))
+	}
+	p.schema = p.tablePlan.Schema()
+	return &p
+}
+
 func (p PhysicalTableReader) init(allocator *idAllocator, ctx context.Context) *PhysicalTableReader {
 	p.basePlan = newBasePlan(TypeTableReader, allocator, ctx, &p)
 	p.basePhysicalPlan = newBasePhysicalPlan(p.basePlan)
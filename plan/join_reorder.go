@@ -22,23 +22,39 @@ import (
 	"github.com/pingcap/tidb/expression"
 )
 
-// tryToGetJoinGroup tries to fetch a whole join group, which all joins is cartesian join.
-func tryToGetJoinGroup(j *LogicalJoin) ([]LogicalPlan, bool) {
-	// Ignore reorder if:
-	// 1. already reordered
-	// 2. not inner join
-	// 3. forced merge join
-	// 4. forced index nested loop join
-	if j.reordered || !j.cartesianJoin || j.preferMergeJoin || j.preferINLJ > 0 {
-		return nil, false
+// tryToGetJoinGroup tries to fetch a whole join group of cartesian inner joins and simplified outer joins, the other
+// joins are the leaves of the group. The conditions of the joins in the group are returned too, they are pushed down
+// again after the group is reordered.
+func tryToGetJoinGroup(j *LogicalJoin) ([]LogicalPlan, []expression.Expression, bool) {
+	if !(j.cartesianJoin || j.simplified) || !canBeReordered(j) {
+		return nil, nil, false
 	}
 	lChild := j.children[0].(LogicalPlan)
 	rChild := j.children[1].(LogicalPlan)
+	conds := concatOnAndWhereConds(j, nil)
 	if nj, ok := lChild.(*LogicalJoin); ok {
-		plans, valid := tryToGetJoinGroup(nj)
-		return append(plans, rChild), valid
+		if plans, subConds, valid := tryToGetJoinGroup(nj); valid {
+			return append(plans, rChild), append(subConds, conds...), true
+		}
+	}
+	return []LogicalPlan{lChild, rChild}, conds, true
+}
+
+// canBeReordered checks if the join can be moved in the join tree. Ignore reorder if:
+// 1. already reordered
+// 2. forced merge join
+// 3. forced index nested loop join
+// 4. the redundant columns of the join are eliminated, such as natural join
+// 5. the join of an apply
+func canBeReordered(j *LogicalJoin) bool {
+	if j.reordered || j.preferMergeJoin || j.preferINLJ > 0 {
+		return false
+	}
+	if j.schema.Len() != j.children[0].Schema().Len()+j.children[1].Schema().Len() {
+		return false
 	}
-	return []LogicalPlan{lChild, rChild}, true
+	_, isApply := j.self.(*LogicalApply)
+	return !isApply
 }
 
 func findColumnIndexByGroup(groups []LogicalPlan, col *expression.Column) int {
@@ -95,7 +111,12 @@ func (e *joinReOrderSolver) Len() int {
 // reorderJoin implements a simple join reorder algorithm. It will extract all the equal conditions and compose them to a graph.
 // Then walk through the graph and pick the nodes connected by some edges to compose a join tree.
 // We will pick the node with least result set as early as possible.
+// The join groups not larger than tidb_opt_join_reorder_threshold are reordered by dynamic programming instead.
 func (e *joinReOrderSolver) reorderJoin(group []LogicalPlan, conds []expression.Expression) {
+	if len(group) <= e.ctx.GetSessionVars().JoinReorderThreshold {
+		e.reorderJoinByDP(group, conds)
+		return
+	}
 	e.graph = make([]edgeList, len(group))
 	e.group = group
 	e.visited = make([]bool, len(group))
@@ -0,0 +1,213 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plan
+
+import (
+	"math"
+	"sort"
+
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/expression"
+)
+
+// joinGroupEdge is an equal condition between the columns of two plans in a join group.
+type joinGroupEdge struct {
+	lNode, rNode int
+	lCol, rCol   *expression.Column
+}
+
+// dpJoinTree is the cheapest join tree found for a subset of a join group. The subsets are bitmaps of
+// the plans in the group.
+type dpJoinTree struct {
+	// left and right are the subsets joined by the tree, they are zero if the subset is a single plan.
+	left, right uint64
+	count       float64
+	// cost is the sum of the estimated row counts of all the joins in the tree.
+	cost float64
+}
+
+// reorderJoinByDP finds the join tree with the least cost for every connected part of the join graph by
+// dynamic programming on the connected subsets, the row counts are estimated by the stats profiles of the
+// plans filtered by their own conditions. The parts not connected by any equal condition are joined as a
+// bushy tree.
+func (e *joinReOrderSolver) reorderJoinByDP(group []LogicalPlan, conds []expression.Expression) {
+	e.group = group
+	adjacents := make([]uint64, len(group))
+	localConds := make([][]expression.Expression, len(group))
+	var edges []joinGroupEdge
+	for _, cond := range conds {
+		if f, ok := cond.(*expression.ScalarFunction); ok && f.FuncName.L == ast.EQ {
+			lCol, lok := f.GetArgs()[0].(*expression.Column)
+			rCol, rok := f.GetArgs()[1].(*expression.Column)
+			if lok && rok {
+				lID := findColumnIndexByGroup(group, lCol)
+				rID := findColumnIndexByGroup(group, rCol)
+				if lID >= 0 && rID >= 0 && lID != rID {
+					edges = append(edges, joinGroupEdge{lNode: lID, rNode: rID, lCol: lCol, rCol: rCol})
+					adjacents[lID] |= 1 << uint(rID)
+					adjacents[rID] |= 1 << uint(lID)
+					continue
+				}
+			}
+		}
+		id := -1
+		for _, col := range expression.ExtractColumns(cond) {
+			idx := findColumnIndexByGroup(group, col)
+			if id != -1 && idx != id {
+				id = -1
+				break
+			}
+			id = idx
+		}
+		if id >= 0 {
+			localConds[id] = append(localConds[id], cond)
+		}
+	}
+	profiles := make([]*statsProfile, len(group))
+	for i, p := range group {
+		profiles[i] = getFilteredStatsProfile(p, localConds[i])
+	}
+
+	dp := make(map[uint64]*dpJoinTree)
+	for i, profile := range profiles {
+		dp[1<<uint(i)] = &dpJoinTree{count: profile.count}
+	}
+	var components []uint64
+	var visited uint64
+	for i := range group {
+		if visited&(1<<uint(i)) != 0 {
+			continue
+		}
+		component := connectedComponent(adjacents, i)
+		visited |= component
+		components = append(components, component)
+		// The subsets of the component are enumerated in ascending order, so the subsets of a subset are
+		// always solved before it.
+		for subset := component & -component; subset != 0; subset = (subset - component) & component {
+			if subset&(subset-1) != 0 {
+				e.solveSubset(dp, subset, adjacents, edges, profiles)
+			}
+		}
+	}
+	sort.SliceStable(components, func(i, j int) bool { return dp[components[i]].count < dp[components[j]].count })
+	cartesianJoinGroup := make([]LogicalPlan, 0, len(components))
+	for _, component := range components {
+		cartesianJoinGroup = append(cartesianJoinGroup, e.buildDPJoinTree(dp, component))
+	}
+	e.makeBushyJoin(cartesianJoinGroup)
+}
+
+// solveSubset finds the cheapest join of two connected subsets whose union is the subset.
+func (e *joinReOrderSolver) solveSubset(dp map[uint64]*dpJoinTree, subset uint64, adjacents []uint64, edges []joinGroupEdge, profiles []*statsProfile) {
+	lowest := subset & -subset
+	var best *dpJoinTree
+	// Every pair of subsets is considered once by keeping the lowest plan on the left.
+	for left := (subset - 1) & subset; left != 0; left = (left - 1) & subset {
+		right := subset ^ left
+		if left&lowest == 0 {
+			continue
+		}
+		lTree, lok := dp[left]
+		rTree, rok := dp[right]
+		if !lok || !rok || !isConnected(adjacents, left, right) {
+			continue
+		}
+		leftKeyCardinality, rightKeyCardinality := 1.0, 1.0
+		for _, edge := range edges {
+			lCol, rCol := edge.lCol, edge.rCol
+			lNode, rNode := edge.lNode, edge.rNode
+			if left&(1<<uint(rNode)) != 0 && right&(1<<uint(lNode)) != 0 {
+				lCol, rCol = rCol, lCol
+				lNode, rNode = rNode, lNode
+			} else if left&(1<<uint(lNode)) == 0 || right&(1<<uint(rNode)) == 0 {
+				continue
+			}
+			leftKeyCardinality = math.Max(leftKeyCardinality, math.Min(columnCardinality(e.group[lNode], profiles[lNode], lCol), lTree.count))
+			rightKeyCardinality = math.Max(rightKeyCardinality, math.Min(columnCardinality(e.group[rNode], profiles[rNode], rCol), rTree.count))
+		}
+		count := getJoinRowCount(lTree.count, rTree.count, leftKeyCardinality, rightKeyCardinality)
+		cost := lTree.cost + rTree.cost + count
+		if best == nil || cost < best.cost {
+			best = &dpJoinTree{left: left, right: right, count: count, cost: cost}
+		}
+	}
+	if best != nil {
+		dp[subset] = best
+	}
+}
+
+// buildDPJoinTree builds the join tree of the subset found by dynamic programming.
+func (e *joinReOrderSolver) buildDPJoinTree(dp map[uint64]*dpJoinTree, subset uint64) LogicalPlan {
+	tree := dp[subset]
+	if tree.left == 0 {
+		for i := range e.group {
+			if subset == 1<<uint(i) {
+				return e.group[i]
+			}
+		}
+	}
+	return e.newJoin(e.buildDPJoinTree(dp, tree.left), e.buildDPJoinTree(dp, tree.right))
+}
+
+// getFilteredStatsProfile estimates the stats profile of the plan filtered by the conditions. The selectivity
+// of the conditions on a data source is estimated by the statistics of the table.
+func getFilteredStatsProfile(p LogicalPlan, conds []expression.Expression) *statsProfile {
+	if ds, ok := p.(*DataSource); ok {
+		filters := make([]expression.Expression, 0, len(ds.pushedDownConds)+len(conds))
+		filters = append(filters, ds.pushedDownConds...)
+		filters = append(filters, conds...)
+		return ds.getStatsProfileByFilter(filters)
+	}
+	profile := p.prepareStatsProfile()
+	if len(conds) > 0 {
+		profile = profile.collapse(selectionFactor)
+	}
+	return profile
+}
+
+// columnCardinality returns the cardinality of the column in the output of the plan.
+func columnCardinality(p LogicalPlan, profile *statsProfile, col *expression.Column) float64 {
+	idx := p.Schema().ColumnIndex(col)
+	if idx < 0 || idx >= len(profile.cardinality) {
+		return profile.count
+	}
+	return profile.cardinality[idx]
+}
+
+// connectedComponent returns the plans connected with the i-th plan by the equal conditions.
+func connectedComponent(adjacents []uint64, i int) uint64 {
+	component := uint64(1) << uint(i)
+	for {
+		next := component
+		for j := range adjacents {
+			if component&(1<<uint(j)) != 0 {
+				next |= adjacents[j]
+			}
+		}
+		if next == component {
+			return component
+		}
+		component = next
+	}
+}
+
+// isConnected checks if there is an equal condition between the two subsets.
+func isConnected(adjacents []uint64, left, right uint64) bool {
+	for i := range adjacents {
+		if left&(1<<uint(i)) != 0 && adjacents[i]&right != 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -39,6 +39,8 @@ const (
 	TiDBMergeJoin = "tidb_smj"
 	// TiDBIndexNestedLoopJoin is hint enforce index nested loop join.
 	TiDBIndexNestedLoopJoin = "tidb_inlj"
+	// TiDBIndexMerge is hint enforce index merge.
+	TiDBIndexMerge = "tidb_index_merge"
 )
 
 type idAllocator struct {
@@ -133,6 +135,9 @@ func (b *planBuilder) buildResultSetNode(node ast.ResultSetNode) LogicalPlan {
 		}
 		if v, ok := p.(*DataSource); ok {
 			v.TableAsName = &x.AsName
+			if hintInfo := b.TableHints(); hintInfo != nil {
+				v.preferIndexMerge = hintInfo.ifPreferIndexMerge(extractTableAlias(v))
+			}
 		}
 		if x.AsName.L != "" {
 			for _, col := range p.Schema().Columns {
@@ -1010,7 +1015,7 @@ func (b *planBuilder) unfoldWildStar(p LogicalPlan, selectFields []*ast.SelectFi
 		for _, col := range p.Schema().Columns {
 			if (dbName.L == "" || dbName.L == col.DBName.L) &&
 				(tblName.L == "" || tblName.L == col.TblName.L) &&
-				col.ID != model.ExtraHandleID {
+				col.ID != model.ExtraHandleID && !col.IsHidden {
 				colName := &ast.ColumnNameExpr{
 					Name: &ast.ColumnName{
 						Schema: col.DBName,
@@ -1028,21 +1033,24 @@ func (b *planBuilder) unfoldWildStar(p LogicalPlan, selectFields []*ast.SelectFi
 }
 
 func (b *planBuilder) pushTableHints(hints []*ast.TableOptimizerHint) bool {
-	var sortMergeTables, INLJTables []model.CIStr
+	var sortMergeTables, INLJTables, indexMergeTables []model.CIStr
 	for _, hint := range hints {
 		switch hint.HintName.L {
 		case TiDBMergeJoin:
 			sortMergeTables = append(sortMergeTables, hint.Tables...)
 		case TiDBIndexNestedLoopJoin:
 			INLJTables = append(INLJTables, hint.Tables...)
+		case TiDBIndexMerge:
+			indexMergeTables = append(indexMergeTables, hint.Tables...)
 		default:
 			// ignore hints that not implemented
 		}
 	}
-	if len(sortMergeTables) != 0 || len(INLJTables) != 0 {
+	if len(sortMergeTables) != 0 || len(INLJTables) != 0 || len(indexMergeTables) != 0 {
 		b.tableHintInfo = append(b.tableHintInfo, tableHintInfo{
 			sortMergeJoinTables:       sortMergeTables,
 			indexNestedLoopJoinTables: INLJTables,
+			indexMergeTables:          indexMergeTables,
 		})
 		return true
 	}
@@ -1228,7 +1236,8 @@ func (b *planBuilder) buildDataSource(tn *ast.TableName) LogicalPlan {
 			DBName:   schemaName,
 			RetType:  &col.FieldType,
 			Position: i,
-			ID:       col.ID})
+			ID:       col.ID,
+			IsHidden: col.Hidden})
 		if tableInfo.PKIsHandle && mysql.HasPriKeyFlag(col.Flag) {
 			pkCol = schema.Columns[schema.Len()-1]
 		}
@@ -1307,6 +1316,10 @@ func (b *planBuilder) projectVirtualColumns(ds *DataSource, columns []*table.Col
 				}
 				// Because the expression maybe return different type from
 				// the generated column, we should wrap a CAST on the result.
+				if column.Hidden {
+					ds.hiddenCols = append(ds.hiddenCols, colExpr.Clone().(*expression.Column))
+					ds.hiddenColExprs = append(ds.hiddenColExprs, expr.Clone())
+				}
 				expr = expression.BuildCastFunction(expr, colExpr.GetType(), b.ctx)
 				exprIsGen = true
 			}
@@ -462,7 +462,7 @@ func (s *testPlanSuite) TestPredicatePushDown(c *C) {
 		},
 		{
 			sql:  "select * from t ta left outer join t tb on ta.d = tb.d and ta.d > 1 where tb.a = 0",
-			best: "Join{DataScan(ta)->Selection->DataScan(tb)->Selection}(ta.d,tb.d)->Projection",
+			best: "Join{DataScan(tb)->Selection->DataScan(ta)->Selection}(tb.d,ta.d)->Projection",
 		},
 		{
 			sql:  "select * from t ta right outer join t tb on ta.d = tb.d and ta.a > 1 where tb.a = 0",
@@ -474,27 +474,27 @@ func (s *testPlanSuite) TestPredicatePushDown(c *C) {
 		},
 		{
 			sql:  "select * from t ta left outer join t tb on ta.d = tb.d and ta.a > 1 where tb.d = 0",
-			best: "Join{DataScan(ta)->Selection->DataScan(tb)->Selection}->Projection",
+			best: "Join{DataScan(tb)->Selection->DataScan(ta)->Selection}->Projection",
 		},
 		{
 			sql:  "select * from t ta left outer join t tb on ta.d = tb.d and ta.a > 1 where tb.c is not null and tb.c = 0 and ifnull(tb.d, 1)",
-			best: "Join{DataScan(ta)->Selection->DataScan(tb)->Selection}(ta.d,tb.d)->Projection",
+			best: "Join{DataScan(tb)->Selection->DataScan(ta)->Selection}(tb.d,ta.d)->Projection",
 		},
 		{
 			sql:  "select * from t ta left outer join t tb on ta.a = tb.a left outer join t tc on tb.b = tc.b where tc.c > 0",
-			best: "Join{Join{DataScan(ta)->DataScan(tb)}(ta.a,tb.a)->DataScan(tc)->Selection}(tb.b,tc.b)->Projection",
+			best: "Join{DataScan(tc)->Selection->Join{DataScan(ta)->DataScan(tb)}(ta.a,tb.a)}(tc.b,tb.b)->Projection",
 		},
 		{
 			sql:  "select * from t ta left outer join t tb on ta.a = tb.a left outer join t tc on tc.b = ta.b where tb.c > 0",
-			best: "Join{Join{DataScan(ta)->DataScan(tb)->Selection}(ta.a,tb.a)->DataScan(tc)}(ta.b,tc.b)->Projection",
+			best: "Join{Join{DataScan(tb)->Selection->DataScan(ta)}(tb.a,ta.a)->DataScan(tc)}(ta.b,tc.b)->Projection",
 		},
 		{
 			sql:  "select * from t as ta left outer join (t as tb left join t as tc on tc.b = tb.b) on tb.a = ta.a where tc.c > 0",
-			best: "Join{DataScan(ta)->Join{DataScan(tb)->DataScan(tc)->Selection}(tb.b,tc.b)}(ta.a,tb.a)->Projection",
+			best: "Join{Join{DataScan(tc)->Selection->DataScan(tb)}(tc.b,tb.b)->DataScan(ta)}(tb.a,ta.a)->Projection",
 		},
 		{
 			sql:  "select * from ( t as ta left outer join t as tb on ta.a = tb.a) join ( t as tc left join t as td on tc.b = td.b) on ta.c = td.c where tb.c = 2 and td.a = 1",
-			best: "Join{Join{DataScan(ta)->DataScan(tb)->Selection}(ta.a,tb.a)->Join{DataScan(tc)->DataScan(td)->Selection}(tc.b,td.b)}(ta.c,td.c)->Projection",
+			best: "Join{Join{DataScan(tb)->Selection->DataScan(ta)}(tb.a,ta.a)->Join{DataScan(td)->Selection->DataScan(tc)}(td.b,tc.b)}(ta.c,td.c)->Projection",
 		},
 		{
 			sql:  "select * from t ta left outer join (t tb left outer join t tc on tc.b = tb.b) on tb.a = ta.a and tc.c = ta.c where tc.d > 0 or ta.d > 0",
@@ -703,6 +703,68 @@ func (s *testPlanSuite) TestPlanBuilder(c *C) {
 	}
 }
 
+func (s *testPlanSuite) TestOuterJoinSimplify(c *C) {
+	defer testleak.AfterTest(c)()
+	tests := []struct {
+		sql       string
+		joinTypes []JoinType
+	}{
+		{
+			sql:       "select * from t t1 left join t t2 on t1.a = t2.a where t2.b > 0",
+			joinTypes: []JoinType{InnerJoin},
+		},
+		{
+			sql:       "select * from t t1 left join t t2 on t1.a = t2.a where t1.b > 0 or t2.b > 0",
+			joinTypes: []JoinType{LeftOuterJoin},
+		},
+		// The join condition of the inner join rejects the null values of the embedded outer join on its right side.
+		{
+			sql:       "select * from t t3 join (t t1 left join t t2 on t1.a = t2.a) on t1.b = t3.b and t2.c > 0",
+			joinTypes: []JoinType{InnerJoin, InnerJoin},
+		},
+		// The join condition of the outer join is not applied on its outer table.
+		{
+			sql:       "select * from (t t1 left join t t2 on t1.a = t2.a) left join t t3 on t2.b = t3.b and t2.c > 0",
+			joinTypes: []JoinType{LeftOuterJoin, LeftOuterJoin},
+		},
+		{
+			sql:       "select * from (t t1 left join t t2 on t1.a = t2.a) left join t t3 on t2.b = t3.b and t2.c > 0 where t3.c > 0",
+			joinTypes: []JoinType{InnerJoin, InnerJoin},
+		},
+	}
+	for _, tt := range tests {
+		comment := Commentf("for %s", tt.sql)
+		stmt, err := s.ParseOneStmt(tt.sql, "", "")
+		c.Assert(err, IsNil, comment)
+
+		is, err := MockResolve(stmt)
+		c.Assert(err, IsNil)
+
+		builder := &planBuilder{
+			allocator: new(idAllocator),
+			ctx:       mockContext(),
+			colMapper: make(map[*ast.ColumnNameExpr]int),
+			is:        is,
+		}
+		p := builder.build(stmt)
+		c.Assert(builder.err, IsNil)
+		p, err = logicalOptimize(flagPredicatePushDown, p.(LogicalPlan), builder.ctx, builder.allocator)
+		c.Assert(err, IsNil)
+		c.Assert(collectJoinTypes(p), DeepEquals, tt.joinTypes, comment)
+	}
+}
+
+func collectJoinTypes(p Plan) []JoinType {
+	var joinTypes []JoinType
+	if join, ok := p.(*LogicalJoin); ok {
+		joinTypes = append(joinTypes, join.JoinType)
+	}
+	for _, child := range p.Children() {
+		joinTypes = append(joinTypes, collectJoinTypes(child)...)
+	}
+	return joinTypes
+}
+
 func (s *testPlanSuite) TestJoinReOrder(c *C) {
 	defer testleak.AfterTest(c)()
 	tests := []struct {
@@ -729,6 +791,26 @@ func (s *testPlanSuite) TestJoinReOrder(c *C) {
 			sql:  "select * from t o where o.b in (select t3.c from t t1, t t2, t t3 where t1.a = t3.a and t2.a = t3.a and t2.a = o.a)",
 			best: "Apply{DataScan(o)->Join{Join{DataScan(t2)->Selection->DataScan(t3)}(t2.a,t3.a)->DataScan(t1)}(t3.a,t1.a)->Projection}->Projection",
 		},
+		{
+			sql:  "select * from t t1 left join t t2 on t1.a = t2.a, t t3, t t4 where t1.b = t4.b and t3.c = t4.c and t3.d = 1",
+			best: "Join{Join{DataScan(t3)->Selection->DataScan(t4)}(t3.c,t4.c)->Join{DataScan(t1)->DataScan(t2)}(t1.a,t2.a)}(t4.b,t1.b)->Projection",
+		},
+		{
+			sql:  "select * from t t1 left join t t2 on t1.a = t2.a left join t t3 on t1.b = t3.b",
+			best: "Join{Join{DataScan(t1)->DataScan(t2)}(t1.a,t2.a)->DataScan(t3)}(t1.b,t3.b)->Projection",
+		},
+		{
+			sql:  "select * from t t1 left join t t2 on t1.a = t2.a left join t t3 on t2.b = t3.b",
+			best: "Join{Join{DataScan(t1)->DataScan(t2)}(t1.a,t2.a)->DataScan(t3)}(t2.b,t3.b)->Projection",
+		},
+		{
+			sql:  "select * from t t1 left join t t2 on t1.a = t2.a left join t t3 on t2.b = t3.b left join t t4 on t1.c = t4.c and t4.d = 1",
+			best: "Join{Join{Join{DataScan(t1)->DataScan(t4)->Selection}(t1.c,t4.c)->DataScan(t2)}(t1.a,t2.a)->DataScan(t3)}(t2.b,t3.b)->Projection",
+		},
+		{
+			sql:  "select * from t t3 join (t t1 left join t t2 on t1.a = t2.a) on t2.b = t3.b",
+			best: "Join{DataScan(t3)->Join{DataScan(t1)->DataScan(t2)}(t1.a,t2.a)}(t3.b,t2.b)->Projection",
+		},
 		{
 			sql:  "select * from t o where o.b in (select t3.c from t t1, t t2, t t3 where t1.a = t3.a and t2.a = t3.a and t2.a = o.a and t1.a = 1)",
 			best: "Apply{DataScan(o)->Join{Join{DataScan(t1)->Selection->DataScan(t3)->Selection}->DataScan(t2)->Selection}->Projection}->Projection",
@@ -757,6 +839,53 @@ func (s *testPlanSuite) TestJoinReOrder(c *C) {
 	}
 }
 
+func (s *testPlanSuite) TestJoinReOrderByDP(c *C) {
+	defer testleak.AfterTest(c)()
+	tests := []struct {
+		sql  string
+		best string
+	}{
+		{
+			sql:  "select * from t t1, t t2, t t3, t t4, t t5, t t6 where t1.a = t2.b and t2.a = t3.b and t3.c = t4.a and t4.d = t2.c and t5.d = t6.d",
+			best: "Join{Join{DataScan(t5)->DataScan(t6)}(t5.d,t6.d)->Join{Join{DataScan(t1)->DataScan(t2)}(t1.a,t2.b)->Join{DataScan(t3)->DataScan(t4)}(t3.c,t4.a)}(t2.a,t3.b)(t2.c,t4.d)}->Projection",
+		},
+		{
+			sql:  "select * from t t1, t t2, t t3, t t4, t t5, t t6, t t7, t t8 where t1.a = t8.a",
+			best: "Join{Join{Join{DataScan(t2)->DataScan(t3)}->Join{DataScan(t4)->DataScan(t5)}}->Join{Join{DataScan(t6)->DataScan(t7)}->Join{DataScan(t1)->DataScan(t8)}(t1.a,t8.a)}}->Projection",
+		},
+		{
+			sql:  "select * from t t1, t t2, t t3, t t4 where t1.a = t2.a and t1.b = t3.b and t1.c = t4.c and t4.a = 1 and t4.b = 1",
+			best: "Join{Join{Join{DataScan(t1)->DataScan(t4)->Selection}(t1.c,t4.c)->DataScan(t3)}(t1.b,t3.b)->DataScan(t2)}(t1.a,t2.a)->Projection",
+		},
+		{
+			sql:  "select * from t o where o.b in (select t3.c from t t1, t t2, t t3 where t1.a = t3.a and t2.a = t3.a and t2.a = o.a)",
+			best: "Apply{DataScan(o)->Join{Join{DataScan(t1)->DataScan(t3)}(t1.a,t3.a)->DataScan(t2)->Selection}(t3.a,t2.a)->Projection}->Projection",
+		},
+	}
+	for _, tt := range tests {
+		comment := Commentf("for %s", tt.sql)
+		stmt, err := s.ParseOneStmt(tt.sql, "", "")
+		c.Assert(err, IsNil, comment)
+
+		is, err := MockResolve(stmt)
+		c.Assert(err, IsNil)
+
+		builder := &planBuilder{
+			allocator: new(idAllocator),
+			ctx:       mockContext(),
+			colMapper: make(map[*ast.ColumnNameExpr]int),
+			is:        is,
+		}
+		builder.ctx.GetSessionVars().JoinReorderThreshold = 8
+		p := builder.build(stmt)
+		c.Assert(builder.err, IsNil)
+		lp := p.(LogicalPlan)
+		p, err = logicalOptimize(flagPredicatePushDown, lp.(LogicalPlan), builder.ctx, builder.allocator)
+		c.Assert(err, IsNil)
+		c.Assert(ToString(lp), Equals, tt.best, Commentf("for %s", tt.sql))
+	}
+}
+
 func (s *testPlanSuite) TestAggPushDown(c *C) {
 	defer testleak.AfterTest(c)()
 	tests := []struct {
@@ -1632,3 +1761,71 @@ func (s *testPlanSuite) TestTopNPushDown(c *C) {
 		c.Assert(ToString(p), Equals, tt.best, comment)
 	}
 }
+
+func (s *testPlanSuite) TestGroupCardinality(c *C) {
+	defer testleak.AfterTest(c)()
+	tests := []struct {
+		sql   string
+		count float64
+	}{
+		{
+			sql:   "select count(*) from t group by a",
+			count: 10,
+		},
+		{
+			sql:   "select count(*) from t group by b, a",
+			count: 50,
+		},
+		{
+			sql:   "select count(*) from t group by a, b, c",
+			count: 50,
+		},
+		{
+			sql:   "select count(*) from (select a, b from t limit 20) t group by a, b",
+			count: 20,
+		},
+		{
+			sql:   "select count(*) from (select a, c from t) t group by a, c",
+			count: 10,
+		},
+	}
+	for _, tt := range tests {
+		comment := Commentf("for %s", tt.sql)
+		stmt, err := s.ParseOneStmt(tt.sql, "", "")
+		c.Assert(err, IsNil, comment)
+
+		is, err := MockResolve(stmt)
+		c.Assert(err, IsNil)
+
+		builder := &planBuilder{
+			allocator: new(idAllocator),
+			ctx:       mockContext(),
+			is:        is,
+			colMapper: make(map[*ast.ColumnNameExpr]int),
+		}
+		p := builder.build(stmt).(LogicalPlan)
+		c.Assert(builder.err, IsNil)
+		// Mock the stats of t, the columns a and b are correlated.
+		cur := Plan(p)
+		for len(cur.Children()) > 0 {
+			cur = cur.Children()[0]
+		}
+		ds := cur.(*DataSource)
+		statsTbl := &statistics.Table{Count: 1000, Columns: make(map[int64]*statistics.Column)}
+		var groupIDs []int64
+		for _, col := range ds.Columns {
+			statsTbl.Columns[col.ID] = &statistics.Column{Histogram: statistics.Histogram{ID: col.ID, NDV: 10}}
+			if col.Name.L == "a" || col.Name.L == "b" {
+				groupIDs = append(groupIDs, col.ID)
+			}
+		}
+		statsTbl.ExtendedStats = []*statistics.ExtendedStats{{ColIDs: groupIDs, NDV: 50, Dependency: 0}}
+		ds.statisticTable = statsTbl
+		cur = p
+		for _, ok := cur.(*LogicalAggregation); !ok; _, ok = cur.(*LogicalAggregation) {
+			cur = cur.Children()[0]
+		}
+		agg := cur.(*LogicalAggregation)
+		c.Assert(agg.prepareStatsProfile().count, Equals, tt.count, comment)
+	}
+}
@@ -92,6 +92,8 @@ type LogicalJoin struct {
 	cartesianJoin   bool
 	preferINLJ      int
 	preferMergeJoin bool
+	// simplified is true if the join is an outer join simplified to inner join.
+	simplified bool
 
 	EqualConditions []*expression.ScalarFunction
 	LeftConditions  expression.CNFExprs
@@ -185,6 +187,9 @@ type LogicalAggregation struct {
 
 	possibleProperties [][]*expression.Column
 	inputCount         float64 // inputCount is the input count of this plan.
+	// eager means the aggregation is pushed down across a join or a union all as a partial aggregation, it's kept
+	// only if the plan with it is cheaper.
+	eager bool
 }
 
 func (p *LogicalAggregation) extractCorrelatedCols() []*expression.CorrelatedColumn {
@@ -295,6 +300,17 @@ type DataSource struct {
 
 	// This is schema the PhysicalUnionScan should be.
 	unionScanSchema *expression.Schema
+
+	// hiddenCols are the hidden columns of the expression indices, and hiddenColExprs are
+	// their generation expressions. They are used to match the conditions with the indices.
+	hiddenCols     []*expression.Column
+	hiddenColExprs []expression.Expression
+	// hiddenColConds are the conditions on hidden columns which can't be pushed down as filters,
+	// they are only used to build the ranges of the expression indices.
+	hiddenColConds []expression.Expression
+
+	// preferIndexMerge is set by the TIDB_INDEX_MERGE hint, the index merge is chosen whenever it's possible.
+	preferIndexMerge bool
 }
 
 func (p *DataSource) getPKIsHandleCol() *expression.Column {
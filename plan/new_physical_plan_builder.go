@@ -652,7 +652,7 @@ func (p *DataSource) convert2NewPhysicalPlan(prop *requiredProp) (task, error) {
 			return nil, errors.Trace(err)
 		}
 	}
-	if !includeTableScan || len(p.pushedDownConds) > 0 || len(prop.cols) > 0 {
+	if !includeTableScan || len(p.pushedDownConds) > 0 || len(p.hiddenColConds) > 0 || len(prop.cols) > 0 {
 		for _, idx := range indices {
 			idxTask, err := p.convertToIndexScan(prop, idx)
 			if err != nil {
@@ -662,6 +662,13 @@ func (p *DataSource) convert2NewPhysicalPlan(prop *requiredProp) (task, error) {
 				t = idxTask
 			}
 		}
+		mergeTask, err := p.convertToIndexMerge(prop, indices)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if mergeTask.cost() < t.cost() || (p.preferIndexMerge && mergeTask != invalidTask) {
+			t = mergeTask
+		}
 	}
 	p.storeTask(prop, t)
 	return t, nil
@@ -720,11 +727,18 @@ func (p *DataSource) convertToIndexScan(prop *requiredProp, idx *model.IndexInfo
 	statsTbl := p.statisticTable
 	rowCount := float64(statsTbl.Count)
 	sc := p.ctx.GetSessionVars().StmtCtx
-	idxCols, colLengths := expression.IndexInfo2Cols(p.Schema().Columns, idx)
+	schemaCols := p.Schema().Columns
+	if len(p.hiddenCols) > 0 {
+		schemaCols = append(append(make([]*expression.Column, 0, len(schemaCols)+len(p.hiddenCols)), schemaCols...), p.hiddenCols...)
+	}
+	idxCols, colLengths := expression.IndexInfo2Cols(schemaCols, idx)
 	is.Ranges = ranger.FullIndexRange()
-	if len(p.pushedDownConds) > 0 {
-		conds := make([]expression.Expression, 0, len(p.pushedDownConds))
+	if len(p.pushedDownConds) > 0 || len(p.hiddenColConds) > 0 {
+		conds := make([]expression.Expression, 0, len(p.pushedDownConds)+len(p.hiddenColConds))
 		for _, cond := range p.pushedDownConds {
+			conds = append(conds, p.substituteHiddenColumns(cond.Clone()))
+		}
+		for _, cond := range p.hiddenColConds {
 			conds = append(conds, cond.Clone())
 		}
 		if len(idxCols) > 0 {
@@ -733,16 +747,17 @@ func (p *DataSource) convertToIndexScan(prop *requiredProp, idx *model.IndexInfo
 			if err != nil {
 				return nil, errors.Trace(err)
 			}
+			is.filterCondition = p.restoreHiddenColumns(is.filterCondition)
 			is.Ranges = ranger.Ranges2IndexRanges(ranges)
 			rowCount, err = statsTbl.GetRowCountByIndexRanges(sc, is.Index.ID, is.Ranges)
 			if err != nil {
 				return nil, errors.Trace(err)
 			}
 		} else {
-			is.filterCondition = conds
+			is.filterCondition = p.restoreHiddenColumns(conds)
 		}
 	}
-	is.profile = p.getStatsProfileByFilter(p.pushedDownConds)
+	is.profile = p.getStatsProfileByFilter(is.AccessCondition)
 	cop := &copTask{
 		indexPlan: is,
 	}
@@ -827,6 +842,39 @@ func (p *DataSource) convertToIndexScan(prop *requiredProp, idx *model.IndexInfo
 	return task, nil
 }
 
+// substituteHiddenColumns replaces the generation expressions of the hidden columns in the condition
+// by the hidden columns, so the condition can be used to build the ranges of the expression indices.
+func (p *DataSource) substituteHiddenColumns(cond expression.Expression) expression.Expression {
+	if len(p.hiddenCols) == 0 {
+		return cond
+	}
+	hiddenCols := make([]expression.Expression, 0, len(p.hiddenCols))
+	for _, col := range p.hiddenCols {
+		hiddenCols = append(hiddenCols, col)
+	}
+	newCond, err := expression.ExpressionSubstitute(cond, p.hiddenColExprs, hiddenCols, p.ctx)
+	if err != nil {
+		// The condition is still correct without the substitution, it just can't use the expression indices.
+		return cond
+	}
+	return newCond
+}
+
+// restoreHiddenColumns replaces the hidden columns in the conditions by their generation expressions,
+// because the hidden columns can't be read from the table. The restored conditions that can't be
+// pushed down are dropped, because they are still checked by the Selection above the DataSource.
+func (p *DataSource) restoreHiddenColumns(conds []expression.Expression) []expression.Expression {
+	if len(p.hiddenCols) == 0 {
+		return conds
+	}
+	schema := expression.NewSchema(p.hiddenCols...)
+	for i, cond := range conds {
+		conds[i] = expression.ColumnSubstitute(cond, schema, p.hiddenColExprs)
+	}
+	_, conds, _ = expression.ExpressionsToPB(p.ctx.GetSessionVars().StmtCtx, conds, p.ctx.GetClient())
+	return conds
+}
+
 func (is *PhysicalIndexScan) addPushedDownSelection(copTask *copTask, p *DataSource, expectedCnt float64) {
 	// Add filter condition to table plan now.
 	if len(is.filterCondition) > 0 {
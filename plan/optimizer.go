@@ -116,6 +116,9 @@ func checkPrivilege(pm privilege.Manager, vs []visitInfo) bool {
 }
 
 func doOptimize(flag uint64, logic LogicalPlan, ctx context.Context, allocator *idAllocator) (PhysicalPlan, error) {
+	if ctx.GetSessionVars().EnableCascadesPlanner && UseDAGPlanBuilder(ctx) {
+		return cascadesOptimize(flag, logic, ctx, allocator)
+	}
 	logic, err := logicalOptimize(flag, logic, ctx, allocator)
 	if err != nil {
 		return nil, errors.Trace(err)
@@ -125,7 +128,7 @@ func doOptimize(flag uint64, logic LogicalPlan, ctx context.Context, allocator *
 	}
 	var physical PhysicalPlan
 	if UseDAGPlanBuilder(ctx) {
-		physical, err = dagPhysicalOptimize(logic)
+		physical, err = dagPhysicalOptimize(logic, ctx, allocator)
 	} else {
 		physical, err = physicalOptimize(flag, logic, allocator)
 	}
@@ -153,19 +156,36 @@ func logicalOptimize(flag uint64, logic LogicalPlan, ctx context.Context, alloc
 	return logic, errors.Trace(err)
 }
 
-func dagPhysicalOptimize(logic LogicalPlan) (PhysicalPlan, error) {
-	logic.preparePossibleProperties()
-	logic.prepareStatsProfile()
-	t, err := logic.convert2NewPhysicalPlan(&requiredProp{taskTp: rootTaskType, expectedCnt: math.MaxFloat64})
+func dagPhysicalOptimize(logic LogicalPlan, ctx context.Context, allocator *idAllocator) (PhysicalPlan, error) {
+	t, err := findBestTask(logic)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
+	// The eager aggregations pushed down by the aggregation optimizer don't always reduce the rows much, so the
+	// plan without them is converted too, and the cheaper one is chosen.
+	solver := &aggregationOptimizer{allocator: allocator, ctx: ctx}
+	if solver.removeEagerAggs(logic) {
+		lazyTask, err := findBestTask(logic)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if lazyTask.cost() < t.cost() {
+			t = lazyTask
+		}
+	}
 	p := t.plan()
 	rebuildSchema(p)
 	p.ResolveIndices()
 	return p, nil
 }
 
+func findBestTask(logic LogicalPlan) (task, error) {
+	logic.preparePossibleProperties()
+	logic.prepareStatsProfile()
+	t, err := logic.convert2NewPhysicalPlan(&requiredProp{taskTp: rootTaskType, expectedCnt: math.MaxFloat64})
+	return t, errors.Trace(err)
+}
+
 func physicalOptimize(flag uint64, logic LogicalPlan, allocator *idAllocator) (PhysicalPlan, error) {
 	logic.ResolveIndices()
 	info, err := logic.convert2PhysicalPlan(&requiredProperty{})
@@ -239,4 +259,5 @@ func init() {
 	}
 	terror.ErrClassToMySQLCodes[terror.ClassOptimizer] = mySQLErrCodes
 	expression.EvalAstExpr = evalAstExpr
+	expression.RewriteAstExpr = rewriteAstExpr
 }
@@ -0,0 +1,105 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plan
+
+import (
+	"math"
+
+	"github.com/pingcap/tidb/expression"
+)
+
+// tryToGetOuterJoinChain tries to fetch a chain of left outer joins, every join in the chain joins an inner table
+// with the result of the lower joins. The joins are returned from the bottom to the top of the chain.
+// Two adjacent joins in the chain commute if neither of them refers to the inner table of the other one:
+// (A left join B on P(A, B)) left join C on P(A, C) equals to (A left join C on P(A, C)) left join B on P(A, B).
+func tryToGetOuterJoinChain(j *LogicalJoin) (LogicalPlan, []*LogicalJoin, bool) {
+	var joins []*LogicalJoin
+	var outer LogicalPlan = j
+	for {
+		join, ok := outer.(*LogicalJoin)
+		if !ok || join.JoinType != LeftOuterJoin || !canBeReordered(join) {
+			break
+		}
+		joins = append(joins, join)
+		outer = join.children[0].(LogicalPlan)
+	}
+	// Reverse the joins to make them from the bottom to the top.
+	for i, j := 0, len(joins)-1; i < j; i, j = i+1, j-1 {
+		joins[i], joins[j] = joins[j], joins[i]
+	}
+	return outer, joins, len(joins) > 1
+}
+
+// reorderOuterJoinChain joins the inner tables of the chain in the ascending order of the number of the rows an
+// outer row matches, so the rows joined are kept as few as possible until the last join. A join is never moved
+// below the joins whose inner tables it refers to. The top join of the new chain is returned.
+func reorderOuterJoinChain(outer LogicalPlan, joins []*LogicalJoin) *LogicalJoin {
+	plans := make([]LogicalPlan, 0, len(joins)+1)
+	plans = append(plans, outer)
+	for _, join := range joins {
+		plans = append(plans, join.children[1].(LogicalPlan))
+	}
+	profiles := make([]*statsProfile, 0, len(plans))
+	profiles = append(profiles, getFilteredStatsProfile(outer, nil))
+	for _, join := range joins {
+		profiles = append(profiles, getFilteredStatsProfile(join.children[1].(LogicalPlan), join.RightConditions))
+	}
+	// The i-th join refers to the inner tables of the dependencies[i] joins.
+	dependencies := make([][]int, len(joins))
+	fanouts := make([]float64, len(joins))
+	for i, join := range joins {
+		for _, cond := range concatOnAndWhereConds(join, nil) {
+			for _, col := range expression.ExtractColumns(cond) {
+				if id := findColumnIndexByGroup(plans, col); id > 0 && id-1 != i {
+					dependencies[i] = append(dependencies[i], id-1)
+				}
+			}
+		}
+		innerProfile := profiles[i+1]
+		outerKeyCardinality, innerKeyCardinality := 1.0, 1.0
+		for _, eqCond := range join.EqualConditions {
+			outerCol := eqCond.GetArgs()[0].(*expression.Column)
+			innerCol := eqCond.GetArgs()[1].(*expression.Column)
+			if id := findColumnIndexByGroup(plans, outerCol); id >= 0 {
+				outerKeyCardinality = math.Max(outerKeyCardinality, columnCardinality(plans[id], profiles[id], outerCol))
+			}
+			innerKeyCardinality = math.Max(innerKeyCardinality, columnCardinality(plans[i+1], innerProfile, innerCol))
+		}
+		fanouts[i] = math.Max(innerProfile.count/math.Max(outerKeyCardinality, innerKeyCardinality), 1)
+	}
+	placed := make([]bool, len(joins))
+	for range joins {
+		next := -1
+		for i := range joins {
+			if placed[i] || (next >= 0 && fanouts[i] >= fanouts[next]) {
+				continue
+			}
+			ready := true
+			for _, dep := range dependencies[i] {
+				ready = ready && placed[dep]
+			}
+			if ready {
+				next = i
+			}
+		}
+		placed[next] = true
+		join := joins[next]
+		join.SetChildren(outer, join.children[1])
+		outer.SetParents(join)
+		join.mergeSchema()
+		join.reordered = true
+		outer = join
+	}
+	return outer.(*LogicalJoin)
+}
@@ -62,6 +62,7 @@ var (
 	_ PhysicalPlan = &PhysicalTableReader{}
 	_ PhysicalPlan = &PhysicalIndexReader{}
 	_ PhysicalPlan = &PhysicalIndexLookUpReader{}
+	_ PhysicalPlan = &PhysicalIndexMergeReader{}
 	_ PhysicalPlan = &PhysicalAggregation{}
 	_ PhysicalPlan = &PhysicalApply{}
 	_ PhysicalPlan = &PhysicalIndexJoin{}
@@ -141,6 +142,33 @@ func (p *PhysicalIndexLookUpReader) Copy() PhysicalPlan {
 	return &np
 }
 
+// PhysicalIndexMergeReader is the reader which reads the table by the union or the intersection of the handles
+// returned by several index scans.
+type PhysicalIndexMergeReader struct {
+	*basePlan
+	basePhysicalPlan
+
+	// PartialPlans flats the partialPlans to construct executor pb.
+	PartialPlans [][]PhysicalPlan
+	// TablePlans flats the tablePlan to construct executor pb.
+	TablePlans   []PhysicalPlan
+	partialPlans []PhysicalPlan
+	tablePlan    PhysicalPlan
+	// IsIntersection means the handles of the partial plans are intersected, otherwise they are unioned.
+	IsIntersection bool
+
+	// NeedColHandle is used in execution phase.
+	NeedColHandle bool
+}
+
+// Copy implements the PhysicalPlan Copy interface.
+func (p *PhysicalIndexMergeReader) Copy() PhysicalPlan {
+	np := *p
+	np.basePlan = p.basePlan.copy()
+	np.basePhysicalPlan = newBasePhysicalPlan(np.basePlan)
+	return &np
+}
+
 // PhysicalIndexScan represents an index scan plan.
 type PhysicalIndexScan struct {
 	physicalTableSource
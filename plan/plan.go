@@ -250,6 +250,9 @@ type LogicalPlan interface {
 
 	// generatePhysicalPlans generates all possible plans.
 	generatePhysicalPlans() []PhysicalPlan
+
+	// clearTasks clears the tasks converted from the plan, they are converted again after the children are changed.
+	clearTasks()
 }
 
 // PhysicalPlan is a tree of the physical operators.
@@ -338,6 +341,10 @@ func (p *baseLogicalPlan) convert2PhysicalPlan(prop *requiredProperty) (*physica
 	return info, p.storePlanInfo(prop, info)
 }
 
+func (p *baseLogicalPlan) clearTasks() {
+	p.taskMap = make(map[string]task)
+}
+
 func (p *baseLogicalPlan) storeTask(prop *requiredProp, task task) {
 	key := prop.hashCode()
 	p.taskMap[string(key)] = task
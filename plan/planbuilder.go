@@ -26,6 +26,7 @@ import (
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/parser/opcode"
+	"github.com/pingcap/tidb/sessionctx"
 	"github.com/pingcap/tidb/table"
 	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/types"
@@ -40,6 +41,7 @@ var (
 	ErrWrongArguments       = terror.ClassOptimizerPlan.New(CodeWrongArguments, "Incorrect arguments to EXECUTE")
 	ErrAmbiguous            = terror.ClassOptimizerPlan.New(CodeAmbiguous, "Column '%s' in field list is ambiguous")
 	ErrAnalyzeMissIndex     = terror.ClassOptimizerPlan.New(CodeAnalyzeMissIndex, "Index '%s' in field list does not exist in table '%s'")
+	ErrAnalyzeColumnGroup   = terror.ClassOptimizerPlan.New(CodeAnalyzeColumnGroup, "Column group (%s) should have at least two different columns")
 	ErrAlterAutoID          = terror.ClassAutoid.New(CodeAlterAutoID, "No support for setting auto_increment using alter_table")
 	ErrBadGeneratedColumn   = terror.ClassOptimizerPlan.New(CodeBadGeneratedColumn, mysql.MySQLErrName[mysql.ErrBadGeneratedColumn])
 )
@@ -50,6 +52,7 @@ const (
 	SystemInternalError                   = 2
 	CodeAlterAutoID                       = 3
 	CodeAnalyzeMissIndex                  = 4
+	CodeAnalyzeColumnGroup                = 5
 	CodeAmbiguous                         = 1052
 	CodeUnknownColumn                     = mysql.ErrBadField
 	CodeUnknownTable                      = mysql.ErrBadTable
@@ -78,6 +81,7 @@ type visitInfo struct {
 type tableHintInfo struct {
 	indexNestedLoopJoinTables []model.CIStr
 	sortMergeJoinTables       []model.CIStr
+	indexMergeTables          []model.CIStr
 }
 
 func (info *tableHintInfo) ifPreferMergeJoin(tableNames ...*model.CIStr) bool {
@@ -115,6 +119,20 @@ func (info *tableHintInfo) ifPreferINLJ(tableNames ...*model.CIStr) bool {
 	return false
 }
 
+func (info *tableHintInfo) ifPreferIndexMerge(tableNames ...*model.CIStr) bool {
+	for _, tableName := range tableNames {
+		if tableName == nil {
+			continue
+		}
+		for _, curEntry := range info.indexMergeTables {
+			if curEntry.L == tableName.L {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // planBuilder builds Plan from an ast.Node.
 // It just builds the ast node straightforwardly.
 type planBuilder struct {
@@ -169,8 +187,8 @@ func (b *planBuilder) build(node ast.Node) Plan {
 	case *ast.AnalyzeTableStmt:
 		return b.buildAnalyze(x)
 	case *ast.BinlogStmt, *ast.FlushStmt, *ast.UseStmt,
-		*ast.BeginStmt, *ast.CommitStmt, *ast.RollbackStmt, *ast.CreateUserStmt, *ast.SetPwdStmt,
-		*ast.GrantStmt, *ast.DropUserStmt, *ast.AlterUserStmt, *ast.RevokeStmt, *ast.KillStmt, *ast.DropStatsStmt:
+		*ast.BeginStmt, *ast.CommitStmt, *ast.RollbackStmt, *ast.SavepointStmt, *ast.ReleaseSavepointStmt,
+		*ast.CreateUserStmt, *ast.SetPwdStmt, *ast.GrantStmt, *ast.DropUserStmt, *ast.AlterUserStmt, *ast.RevokeStmt, *ast.KillStmt, *ast.DropStatsStmt, *ast.LoadStatsStmt:
 		return b.buildSimple(node.(ast.StmtNode))
 	case ast.DDLNode:
 		return b.buildDDL(x)
@@ -276,7 +294,8 @@ func availableIndices(hints []*ast.IndexHint, tableInfo *model.TableInfo) (indic
 	}
 	publicIndices := make([]*model.IndexInfo, 0, len(tableInfo.Indices))
 	for _, index := range tableInfo.Indices {
-		if index.State == model.StatePublic {
+		// Invisible indices are still maintained, but they are ignored by the optimizer.
+		if index.State == model.StatePublic && !index.Invisible {
 			publicIndices = append(publicIndices, index)
 		}
 	}
@@ -340,6 +359,15 @@ func findIndexByName(indices []*model.IndexInfo, name model.CIStr) *model.IndexI
 	return nil
 }
 
+func findColumnByName(cols []*model.ColumnInfo, name model.CIStr) *model.ColumnInfo {
+	for _, col := range cols {
+		if col.Name.L == name.L && col.State == model.StatePublic {
+			return col
+		}
+	}
+	return nil
+}
+
 func (b *planBuilder) buildSelectLock(src Plan, lock ast.SelectLockType) *SelectLock {
 	selectLock := SelectLock{Lock: lock}.init(b.allocator, b.ctx)
 	addChild(selectLock, src)
@@ -432,11 +460,75 @@ func (b *planBuilder) buildAnalyzeTable(as *ast.AnalyzeTableStmt) Plan {
 		if len(colInfo) > 0 || pkInfo != nil {
 			p.ColTasks = append(p.ColTasks, AnalyzeColumnsTask{TableInfo: tbl.TableInfo, PKInfo: pkInfo, ColsInfo: colInfo, PushDown: pushdownCol})
 		}
+		groups := b.getColumnGroups(tbl.TableInfo, as.ColumnGroups)
+		if b.err != nil {
+			return nil
+		}
+		if len(groups) > 0 {
+			p.ExtendedTasks = append(p.ExtendedTasks, AnalyzeExtendedTask{TableInfo: tbl.TableInfo, ColumnGroups: groups})
+		}
 	}
 	p.SetSchema(&expression.Schema{})
 	return p
 }
 
+// getColumnGroups returns the column groups to collect the extended statistics on, which are
+// the groups declared before and the new declared ones.
+func (b *planBuilder) getColumnGroups(tblInfo *model.TableInfo, declared [][]*ast.ColumnName) [][]*model.ColumnInfo {
+	var groups [][]*model.ColumnInfo
+	keys := make(map[string]struct{})
+	appendGroup := func(group []*model.ColumnInfo) {
+		names := make([]string, 0, len(group))
+		for _, col := range group {
+			names = append(names, col.Name.L)
+		}
+		key := strings.Join(names, ",")
+		if _, ok := keys[key]; !ok {
+			keys[key] = struct{}{}
+			groups = append(groups, group)
+		}
+	}
+	if handle := sessionctx.GetDomain(b.ctx).StatsHandle(); handle != nil {
+		for _, stats := range handle.GetTableStats(tblInfo.ID).ExtendedStats {
+			group := make([]*model.ColumnInfo, 0, len(stats.ColIDs))
+			for _, id := range stats.ColIDs {
+				for _, col := range tblInfo.Columns {
+					if col.ID == id && col.State == model.StatePublic {
+						group = append(group, col)
+						break
+					}
+				}
+			}
+			if len(group) == len(stats.ColIDs) {
+				appendGroup(group)
+			}
+		}
+	}
+	for _, names := range declared {
+		group := make([]*model.ColumnInfo, 0, len(names))
+		colNames := make([]string, 0, len(names))
+		seen := make(map[string]struct{}, len(names))
+		for _, name := range names {
+			col := findColumnByName(tblInfo.Columns, name.Name)
+			if col == nil {
+				b.err = ErrUnknownColumn.GenByArgs(name.Name.O, "column group")
+				return nil
+			}
+			colNames = append(colNames, name.Name.O)
+			if _, ok := seen[col.Name.L]; !ok {
+				seen[col.Name.L] = struct{}{}
+				group = append(group, col)
+			}
+		}
+		if len(group) < 2 {
+			b.err = ErrAnalyzeColumnGroup.GenByArgs(strings.Join(colNames, ", "))
+			return nil
+		}
+		appendGroup(group)
+	}
+	return groups
+}
+
 func (b *planBuilder) buildAnalyzeIndex(as *ast.AnalyzeTableStmt) Plan {
 	p := &Analyze{}
 	tblInfo := as.TableNames[0].TableInfo
@@ -681,6 +773,20 @@ func (b *planBuilder) resolveGeneratedColumns(columns []*table.Column, onDups ma
 	return
 }
 
+// visibleColumnNames returns the names of the visible columns if there are hidden columns in the table.
+func visibleColumnNames(columns []*table.Column) []*ast.ColumnName {
+	names := make([]*ast.ColumnName, 0, len(columns))
+	for _, col := range columns {
+		if !col.Hidden {
+			names = append(names, &ast.ColumnName{Name: col.Name})
+		}
+	}
+	if len(names) == len(columns) {
+		return nil
+	}
+	return names
+}
+
 func (b *planBuilder) buildInsert(insert *ast.InsertStmt) Plan {
 	ts, ok := insert.Table.TableRefs.Left.(*ast.TableSource)
 	if !ok {
@@ -700,9 +806,15 @@ func (b *planBuilder) buildInsert(insert *ast.InsertStmt) Plan {
 		return nil
 	}
 
+	columns := insert.Columns
+	if len(columns) == 0 && len(insert.Setlist) == 0 {
+		// The values of hidden columns are always calculated from their generation expressions.
+		columns = visibleColumnNames(tableInPlan.Cols())
+	}
+
 	insertPlan := Insert{
 		Table:       tableInPlan,
-		Columns:     insert.Columns,
+		Columns:     columns,
 		tableSchema: schema,
 		IsReplace:   insert.IsReplace,
 		Priority:    insert.Priority,
@@ -722,8 +834,8 @@ func (b *planBuilder) buildInsert(insert *ast.InsertStmt) Plan {
 
 	// Check insert.Columns contains generated columns or not.
 	// It's for INSERT INTO t (...) VALUES (...)
-	if len(insert.Columns) > 0 {
-		for _, col := range insert.Columns {
+	if len(columns) > 0 {
+		for _, col := range columns {
 			if column, ok := columnByName[col.Name.L]; ok {
 				if column.IsGenerated() {
 					b.err = ErrBadGeneratedColumn.GenByArgs(col.Name.O, tableInfo.Name.O)
@@ -766,7 +878,7 @@ func (b *planBuilder) buildInsert(insert *ast.InsertStmt) Plan {
 	}
 
 	// It's for INSERT INTO t VALUES (...)
-	if len(insert.Columns) == 0 {
+	if len(columns) == 0 {
 		// The length of VALUES list maybe exceed table width,
 		// we ignore this here but do checking in executor.
 		var effectiveValuesLen int
@@ -947,6 +1059,17 @@ func (b *planBuilder) buildDDL(node ast.DDLNode) Plan {
 				table:     table.Name.L,
 			})
 		}
+	case *ast.RecoverTableStmt:
+		b.visitInfo = append(b.visitInfo, visitInfo{
+			privilege: mysql.CreatePriv,
+			db:        v.Table.Schema.L,
+			table:     v.Table.Name.L,
+		})
+		b.visitInfo = append(b.visitInfo, visitInfo{
+			privilege: mysql.DropPriv,
+			db:        v.Table.Schema.L,
+			table:     v.Table.Name.L,
+		})
 	case *ast.TruncateTableStmt:
 		b.visitInfo = append(b.visitInfo, visitInfo{
 			privilege: mysql.DeletePriv,
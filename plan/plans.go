@@ -154,12 +154,19 @@ type AnalyzeIndexTask struct {
 	PushDown  bool
 }
 
+// AnalyzeExtendedTask is used for analyze the extended statistics of the column groups.
+type AnalyzeExtendedTask struct {
+	TableInfo    *model.TableInfo
+	ColumnGroups [][]*model.ColumnInfo
+}
+
 // Analyze represents an analyze plan
 type Analyze struct {
 	basePlan
 
-	ColTasks []AnalyzeColumnsTask
-	IdxTasks []AnalyzeIndexTask
+	ColTasks      []AnalyzeColumnsTask
+	IdxTasks      []AnalyzeIndexTask
+	ExtendedTasks []AnalyzeExtendedTask
 }
 
 // LoadData represents a loaddata plan.
@@ -233,7 +240,7 @@ func (e *Explain) prepareExplainInfo4DAGTask(p PhysicalPlan, taskType string) {
 }
 
 // prepareCopTaskInfo generates explain information for cop-tasks.
-// Only PhysicalTableReader, PhysicalIndexReader and PhysicalIndexLookUpReader have cop-tasks currently.
+// Only PhysicalTableReader, PhysicalIndexReader, PhysicalIndexLookUpReader and PhysicalIndexMergeReader have cop-tasks currently.
 func (e *Explain) prepareCopTaskInfo(plans []PhysicalPlan) {
 	for _, p := range plans {
 		e.prepareExplainInfo4DAGTask(p, "cop")
@@ -257,6 +264,11 @@ func (e *Explain) prepareRootTaskInfo(p PhysicalPlan) {
 	case *PhysicalIndexLookUpReader:
 		e.prepareCopTaskInfo(copPlan.IndexPlans)
 		e.prepareCopTaskInfo(copPlan.TablePlans)
+	case *PhysicalIndexMergeReader:
+		for _, partialPlans := range copPlan.PartialPlans {
+			e.prepareCopTaskInfo(partialPlans)
+		}
+		e.prepareCopTaskInfo(copPlan.TablePlans)
 	}
 	e.prepareExplainInfo4DAGTask(p, "root")
 }
@@ -299,6 +311,13 @@ func (e *Explain) prepareTaskDot(p PhysicalPlan, taskTp string, buffer *bytes.Bu
 			pipelines = append(pipelines, fmt.Sprintf("\"%s\" -> \"%s\"\n", copPlan.ExplainID(), copPlan.indexPlan.ExplainID()))
 			copTasks = append(copTasks, copPlan.tablePlan)
 			copTasks = append(copTasks, copPlan.indexPlan)
+		case *PhysicalIndexMergeReader:
+			pipelines = append(pipelines, fmt.Sprintf("\"%s\" -> \"%s\"\n", copPlan.ExplainID(), copPlan.tablePlan.ExplainID()))
+			copTasks = append(copTasks, copPlan.tablePlan)
+			for _, partialPlan := range copPlan.partialPlans {
+				pipelines = append(pipelines, fmt.Sprintf("\"%s\" -> \"%s\"\n", copPlan.ExplainID(), partialPlan.ExplainID()))
+				copTasks = append(copTasks, partialPlan)
+			}
 		}
 		for _, child := range curPlan.Children() {
 			buffer.WriteString(fmt.Sprintf("\"%s\" -> \"%s\"\n", curPlan.ExplainID(), child.ExplainID()))
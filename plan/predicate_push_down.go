@@ -57,10 +57,32 @@ func (p *Selection) PredicatePushDown(predicates []expression.Expression) ([]exp
 func (p *DataSource) PredicatePushDown(predicates []expression.Expression) ([]expression.Expression, LogicalPlan, error) {
 	if UseDAGPlanBuilder(p.ctx) {
 		_, p.pushedDownConds, predicates = expression.ExpressionsToPB(p.ctx.GetSessionVars().StmtCtx, predicates, p.ctx.GetClient())
+		p.hiddenColConds = p.buildHiddenColumnConds(predicates)
 	}
 	return predicates, p, nil
 }
 
+// buildHiddenColumnConds substitutes the hidden columns into the conditions that can't be pushed down,
+// if the substituted conditions can be pushed down, they can be used to build the ranges of expression indices.
+func (p *DataSource) buildHiddenColumnConds(predicates []expression.Expression) []expression.Expression {
+	if len(p.hiddenCols) == 0 {
+		return nil
+	}
+	hiddenSchema := expression.NewSchema(p.hiddenCols...)
+	conds := make([]expression.Expression, 0, len(predicates))
+	for _, pred := range predicates {
+		cond := p.substituteHiddenColumns(pred.Clone())
+		for _, col := range expression.ExtractColumns(cond) {
+			if hiddenSchema.Contains(col) {
+				conds = append(conds, cond)
+				break
+			}
+		}
+	}
+	_, conds, _ = expression.ExpressionsToPB(p.ctx.GetSessionVars().StmtCtx, conds, p.ctx.GetClient())
+	return conds
+}
+
 // PredicatePushDown implements LogicalPlan PredicatePushDown interface.
 func (p *TableDual) PredicatePushDown(predicates []expression.Expression) ([]expression.Expression, LogicalPlan, error) {
 	return predicates, p, nil
@@ -72,8 +94,9 @@ func (p *LogicalJoin) PredicatePushDown(predicates []expression.Expression) (ret
 	if err != nil {
 		return nil, nil, errors.Trace(err)
 	}
-	groups, valid := tryToGetJoinGroup(p)
+	groups, conds, valid := tryToGetJoinGroup(p)
 	if valid {
+		predicates = append(conds, predicates...)
 		e := joinReOrderSolver{allocator: p.allocator, ctx: p.ctx}
 		e.reorderJoin(groups, predicates)
 		newJoin := e.resultJoin
@@ -84,6 +107,16 @@ func (p *LogicalJoin) PredicatePushDown(predicates []expression.Expression) (ret
 		}
 		return newJoin.PredicatePushDown(predicates)
 	}
+	if outer, joins, valid := tryToGetOuterJoinChain(p); valid {
+		parents := p.parents
+		newJoin := reorderOuterJoinChain(outer, joins)
+		if len(parents) > 0 {
+			parent := parents[0]
+			newJoin.SetParents(parent)
+			parent.ReplaceChild(p, newJoin)
+		}
+		return newJoin.PredicatePushDown(predicates)
+	}
 	var leftCond, rightCond []expression.Expression
 	retPlan = p
 	leftPlan := p.children[0].(LogicalPlan)
@@ -229,11 +262,14 @@ func (p *LogicalJoin) getProj(idx int) *Projection {
 }
 
 // outerJoinSimplify simplifies outer join.
+// The outer join is simplified to inner join if a condition above it rejects the null values of its inner table.
+// The embedded joins are simplified after the embedding join: the conditions above the embedding join and its join
+// conditions are both applied on its inner table, but only the conditions above it are applied on the outer table
+// of an outer join.
 func outerJoinSimplify(p *LogicalJoin, predicates []expression.Expression) error {
 	var innerTable, outerTable LogicalPlan
 	child1 := p.children[0].(LogicalPlan)
 	child2 := p.children[1].(LogicalPlan)
-	var fullConditions []expression.Expression
 	if p.JoinType == LeftOuterJoin {
 		innerTable = child2
 		outerTable = child1
@@ -243,43 +279,36 @@ func outerJoinSimplify(p *LogicalJoin, predicates []expression.Expression) error
 	} else {
 		return nil
 	}
-	// first simplify embedded outer join.
-	// When trying to simplify an embedded outer join operation in a query,
-	// we must take into account the join condition for the embedding outer join together with the WHERE condition.
+	if p.JoinType != InnerJoin {
+		for _, expr := range predicates {
+			isOk, err := isNullRejected(p.ctx, innerTable.Schema(), expr)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			if isOk {
+				p.JoinType = InnerJoin
+				p.simplified = true
+				break
+			}
+		}
+	}
+	fullConditions := concatOnAndWhereConds(p, predicates)
 	if innerPlan, ok := innerTable.(*LogicalJoin); ok {
-		fullConditions = concatOnAndWhereConds(p, predicates)
 		err := outerJoinSimplify(innerPlan, fullConditions)
 		if err != nil {
 			return errors.Trace(err)
 		}
 	}
 	if outerPlan, ok := outerTable.(*LogicalJoin); ok {
-		if fullConditions != nil {
-			fullConditions = concatOnAndWhereConds(p, predicates)
+		outerConditions := predicates
+		if p.JoinType == InnerJoin {
+			outerConditions = fullConditions
 		}
-		err := outerJoinSimplify(outerPlan, fullConditions)
+		err := outerJoinSimplify(outerPlan, outerConditions)
 		if err != nil {
 			return errors.Trace(err)
 		}
 	}
-	if p.JoinType == InnerJoin {
-		return nil
-	}
-	// then simplify embedding outer join.
-	canBeSimplified := false
-	for _, expr := range predicates {
-		isOk, err := isNullRejected(p.ctx, innerTable.Schema(), expr)
-		if err != nil {
-			return errors.Trace(err)
-		}
-		if isOk {
-			canBeSimplified = true
-			break
-		}
-	}
-	if canBeSimplified {
-		p.JoinType = InnerJoin
-	}
 	return nil
 }
 
@@ -159,6 +159,14 @@ func (p *PhysicalIndexLookUpReader) ResolveIndices() {
 	p.indexPlan.ResolveIndices()
 }
 
+// ResolveIndices implements Plan interface.
+func (p *PhysicalIndexMergeReader) ResolveIndices() {
+	p.tablePlan.ResolveIndices()
+	for _, partialPlan := range p.partialPlans {
+		partialPlan.ResolveIndices()
+	}
+}
+
 // ResolveIndices implements Plan interface.
 func (p *Selection) ResolveIndices() {
 	p.basePlan.ResolveIndices()
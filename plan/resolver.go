@@ -119,6 +119,10 @@ type resolverContext struct {
 	inShow bool
 	// When visiting create/alter table statement.
 	inColumnOption bool
+	// When visiting the expression parts of an index.
+	inIndexExpr bool
+	// When visiting the expression of a check constraint.
+	inCheckExpr bool
 }
 
 // currentContext gets the current resolverContext.
@@ -197,13 +201,21 @@ func (nr *nameResolver) Enter(inNode ast.Node) (outNode ast.Node, skipChildren b
 		nr.currentContext().inCreateOrDropTable = true
 	case *ast.ColumnOption:
 		nr.currentContext().inColumnOption = true
+	case *ast.IndexColName:
+		if v.Expr != nil {
+			nr.currentContext().inIndexExpr = true
+		}
+	case *ast.Constraint:
+		if v.Tp == ast.ConstraintCheck {
+			nr.currentContext().inCheckExpr = true
+		}
 	case *ast.DeleteStmt:
 		nr.pushContext()
 	case *ast.DeleteTableList:
 		nr.currentContext().inDeleteTableList = true
 	case *ast.DoStmt:
 		nr.pushContext()
-	case *ast.DropTableStmt:
+	case *ast.DropTableStmt, *ast.RecoverTableStmt:
 		nr.pushContext()
 		nr.currentContext().inCreateOrDropTable = true
 	case *ast.DropIndexStmt:
@@ -279,13 +291,17 @@ func (nr *nameResolver) Leave(inNode ast.Node) (node ast.Node, ok bool) {
 		nr.popContext()
 	case *ast.ColumnOption:
 		nr.currentContext().inColumnOption = false
+	case *ast.IndexColName:
+		nr.currentContext().inIndexExpr = false
+	case *ast.Constraint:
+		nr.currentContext().inCheckExpr = false
 	case *ast.DeleteTableList:
 		nr.currentContext().inDeleteTableList = false
 	case *ast.DoStmt:
 		nr.popContext()
 	case *ast.DropIndexStmt:
 		nr.popContext()
-	case *ast.DropTableStmt:
+	case *ast.DropTableStmt, *ast.RecoverTableStmt:
 		nr.popContext()
 	case *ast.TableSource:
 		nr.handleTableSource(v)
@@ -506,9 +522,10 @@ func (nr *nameResolver) handleColumnName(cn *ast.ColumnNameExpr) {
 		return
 	}
 
-	if ctx.inColumnOption {
+	if ctx.inColumnOption || ctx.inIndexExpr || ctx.inCheckExpr {
 		// In column option, only columns in current create table statement
 		// is available. But we check it in ddl/ddl_api.go.
+		// It's the same for the expression parts of an index and check constraints.
 		return
 	}
 
@@ -18,12 +18,21 @@ import (
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/statistics"
 )
 
 // statsProfile stores the basic information of statistics for the a plan's output. It is used for cost estimation.
 type statsProfile struct {
 	count       float64
 	cardinality []float64
+	// groups are the cardinalities of the column groups with the extended statistics.
+	groups []groupCardinality
+}
+
+// groupCardinality is the cardinality of a group of output columns as a whole.
+type groupCardinality struct {
+	offsets     []int
+	cardinality float64
 }
 
 // collapse receives a selectivity and multiple it with count and cardinality.
@@ -31,13 +40,39 @@ func (s *statsProfile) collapse(factor float64) *statsProfile {
 	profile := &statsProfile{
 		count:       s.count * factor,
 		cardinality: make([]float64, len(s.cardinality)),
+		groups:      make([]groupCardinality, 0, len(s.groups)),
 	}
 	for i := range profile.cardinality {
 		profile.cardinality[i] = s.cardinality[i] * factor
 	}
+	for _, g := range s.groups {
+		profile.groups = append(profile.groups, groupCardinality{offsets: g.offsets, cardinality: g.cardinality * factor})
+	}
 	return profile
 }
 
+// limitGroups returns the cardinalities of the groups which are not larger than the count.
+func limitGroups(groups []groupCardinality, count float64) []groupCardinality {
+	limited := make([]groupCardinality, 0, len(groups))
+	for _, g := range groups {
+		limited = append(limited, groupCardinality{offsets: g.offsets, cardinality: math.Min(g.cardinality, count)})
+	}
+	return limited
+}
+
+// shiftGroups returns the groups with the offsets of the columns increased by delta.
+func shiftGroups(groups []groupCardinality, delta int) []groupCardinality {
+	shifted := make([]groupCardinality, 0, len(groups))
+	for _, g := range groups {
+		offsets := make([]int, 0, len(g.offsets))
+		for _, offset := range g.offsets {
+			offsets = append(offsets, offset+delta)
+		}
+		shifted = append(shifted, groupCardinality{offsets: offsets, cardinality: g.cardinality})
+	}
+	return shifted
+}
+
 func (p *basePhysicalPlan) statsProfile() *statsProfile {
 	profile := p.basePlan.profile
 	if p.expectedCnt > 0 && p.expectedCnt < profile.count {
@@ -46,6 +81,9 @@ func (p *basePhysicalPlan) statsProfile() *statsProfile {
 		for i := range profile.cardinality {
 			profile.cardinality[i] = profile.cardinality[i] * factor
 		}
+		for i := range profile.groups {
+			profile.groups[i].cardinality = profile.groups[i].cardinality * factor
+		}
 	}
 	return profile
 }
@@ -79,6 +117,11 @@ func (p *DataSource) getStatsProfileByFilter(conds expression.CNFExprs) *statsPr
 			profile.cardinality[i] = profile.count * distinctFactor
 		}
 	}
+	for _, stats := range p.statisticTable.ExtendedStats {
+		if g, ok := p.getGroupCardinality(stats); ok {
+			profile.groups = append(profile.groups, g)
+		}
+	}
 	selectivity, err := p.statisticTable.Selectivity(p.ctx, conds)
 	if err != nil {
 		log.Warnf("An error happened: %v, we have to use the default selectivity", err.Error())
@@ -87,6 +130,25 @@ func (p *DataSource) getStatsProfileByFilter(conds expression.CNFExprs) *statsPr
 	return profile.collapse(selectivity)
 }
 
+// getGroupCardinality returns the cardinality of the column group if all its columns are output.
+func (p *DataSource) getGroupCardinality(stats *statistics.ExtendedStats) (groupCardinality, bool) {
+	g := groupCardinality{offsets: make([]int, 0, len(stats.ColIDs)), cardinality: float64(stats.NDV)}
+	for _, id := range stats.ColIDs {
+		offset := -1
+		for i, col := range p.Columns {
+			if col.ID == id {
+				offset = i
+				break
+			}
+		}
+		if offset == -1 {
+			return g, false
+		}
+		g.offsets = append(g.offsets, offset)
+	}
+	return g, stats.NDV > 0
+}
+
 func (p *DataSource) prepareStatsProfile() *statsProfile {
 	p.profile = p.getStatsProfileByFilter(p.pushedDownConds)
 	return p.profile
@@ -127,6 +189,7 @@ func (p *Limit) prepareStatsProfile() *statsProfile {
 			p.profile.cardinality[i] = p.profile.count
 		}
 	}
+	p.profile.groups = limitGroups(childProfile.groups, p.profile.count)
 	return p.profile
 }
 
@@ -145,11 +208,13 @@ func (p *TopN) prepareStatsProfile() *statsProfile {
 			p.profile.cardinality[i] = p.profile.count
 		}
 	}
+	p.profile.groups = limitGroups(childProfile.groups, p.profile.count)
 	return p.profile
 }
 
 // getCardinality will return the cardinality of a couple of columns. We simply return the max one, because we cannot know
 // the cardinality for multi-dimension attributes properly. This is a simple and naive scheme of cardinality estimation.
+// If some of the columns are a column group with the extended statistics, the cardinality is at least that of the group.
 func getCardinality(cols []*expression.Column, schema *expression.Schema, profile *statsProfile) float64 {
 	indices := schema.ColumnsIndices(cols)
 	if indices == nil {
@@ -163,9 +228,30 @@ func getCardinality(cols []*expression.Column, schema *expression.Schema, profil
 			cardinality = profile.cardinality[idx]
 		}
 	}
+	for _, g := range profile.groups {
+		if cardinality < g.cardinality && containsAllOffsets(indices, g.offsets) {
+			cardinality = g.cardinality
+		}
+	}
 	return cardinality
 }
 
+func containsAllOffsets(indices []int, offsets []int) bool {
+	for _, offset := range offsets {
+		found := false
+		for _, idx := range indices {
+			if idx == offset {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
 func (p *Projection) prepareStatsProfile() *statsProfile {
 	childProfile := p.children[0].(LogicalPlan).prepareStatsProfile()
 	p.profile = &statsProfile{
@@ -176,9 +262,32 @@ func (p *Projection) prepareStatsProfile() *statsProfile {
 		cols := expression.ExtractColumns(expr)
 		p.profile.cardinality[i] = getCardinality(cols, p.children[0].Schema(), childProfile)
 	}
+	p.profile.groups = p.projectGroups(childProfile.groups)
 	return p.profile
 }
 
+// projectGroups maps the column groups of the child to the output, the groups with a column not
+// projected directly are discarded.
+func (p *Projection) projectGroups(groups []groupCardinality) []groupCardinality {
+	childSchema := p.children[0].Schema()
+	projected := make([]groupCardinality, 0, len(groups))
+	for _, g := range groups {
+		offsets := make([]int, 0, len(g.offsets))
+		for _, offset := range g.offsets {
+			for i, expr := range p.Exprs {
+				if col, ok := expr.(*expression.Column); ok && col.Equal(childSchema.Columns[offset], p.ctx) {
+					offsets = append(offsets, i)
+					break
+				}
+			}
+		}
+		if len(offsets) == len(g.offsets) {
+			projected = append(projected, groupCardinality{offsets: offsets, cardinality: g.cardinality})
+		}
+	}
+	return projected
+}
+
 func (p *LogicalAggregation) prepareStatsProfile() *statsProfile {
 	childProfile := p.children[0].(LogicalPlan).prepareStatsProfile()
 	var gbyCols []*expression.Column
@@ -227,10 +336,13 @@ func (p *LogicalJoin) prepareStatsProfile() *statsProfile {
 		p.profile.cardinality[len(p.profile.cardinality)-1] = 2.0
 		return p.profile
 	}
+	// The offsets of the columns of the right child are after the left child's.
+	groups := append(shiftGroups(leftProfile.groups, 0), shiftGroups(rightProfile.groups, len(leftProfile.cardinality))...)
 	if 0 == len(p.EqualConditions) {
 		p.profile = &statsProfile{
 			count:       leftProfile.count * rightProfile.count,
 			cardinality: append(leftProfile.cardinality, rightProfile.cardinality...),
+			groups:      groups,
 		}
 		return p.profile
 	}
@@ -241,7 +353,7 @@ func (p *LogicalJoin) prepareStatsProfile() *statsProfile {
 	}
 	leftKeyCardinality := getCardinality(leftKeys, p.children[0].Schema(), leftProfile)
 	rightKeyCardinality := getCardinality(rightKeys, p.children[1].Schema(), rightProfile)
-	count := (leftProfile.count * rightProfile.count / leftKeyCardinality / rightKeyCardinality) * math.Min(leftKeyCardinality, rightKeyCardinality)
+	count := getJoinRowCount(leftProfile.count, rightProfile.count, leftKeyCardinality, rightKeyCardinality)
 	if p.JoinType == LeftOuterJoin {
 		count = math.Max(count, leftProfile.count)
 	} else if p.JoinType == RightOuterJoin {
@@ -256,10 +368,17 @@ func (p *LogicalJoin) prepareStatsProfile() *statsProfile {
 	p.profile = &statsProfile{
 		count:       count,
 		cardinality: cardinality,
+		groups:      limitGroups(groups, count),
 	}
 	return p.profile
 }
 
+// getJoinRowCount estimates the row count of an inner join by the row counts of the children and the
+// cardinalities of their join keys.
+func getJoinRowCount(leftCount, rightCount, leftKeyCardinality, rightKeyCardinality float64) float64 {
+	return (leftCount * rightCount / leftKeyCardinality / rightKeyCardinality) * math.Min(leftKeyCardinality, rightKeyCardinality)
+}
+
 func (p *LogicalApply) prepareStatsProfile() *statsProfile {
 	leftProfile := p.children[0].(LogicalPlan).prepareStatsProfile()
 	_ = p.children[1].(LogicalPlan).prepareStatsProfile()
@@ -170,6 +170,16 @@ func toString(in Plan, strs []string, idxs []int) ([]string, []int) {
 		str = fmt.Sprintf("IndexReader(%s)", ToString(x.indexPlan))
 	case *PhysicalIndexLookUpReader:
 		str = fmt.Sprintf("IndexLookUp(%s, %s)", ToString(x.indexPlan), ToString(x.tablePlan))
+	case *PhysicalIndexMergeReader:
+		mergeType := "union"
+		if x.IsIntersection {
+			mergeType = "intersection"
+		}
+		partials := make([]string, 0, len(x.partialPlans))
+		for _, partialPlan := range x.partialPlans {
+			partials = append(partials, ToString(partialPlan))
+		}
+		str = fmt.Sprintf("IndexMerge(%s, [%s], %s)", mergeType, strings.Join(partials, ", "), ToString(x.tablePlan))
 	case *PhysicalUnionScan:
 		str = fmt.Sprintf("UnionScan(%s)", x.Conditions)
 	case *PhysicalIndexJoin:
@@ -200,6 +210,17 @@ func toString(in Plan, strs []string, idxs []int) ([]string, []int) {
 			}
 			children = append(children, fmt.Sprintf("Table(%t, %s)", col.PushDown, strings.Join(colNames, ", ")))
 		}
+		for _, task := range x.ExtendedTasks {
+			var groups []string
+			for _, group := range task.ColumnGroups {
+				var colNames []string
+				for _, c := range group {
+					colNames = append(colNames, fmt.Sprintf("%s.%s", task.TableInfo.Name.O, c.Name.O))
+				}
+				groups = append(groups, "("+strings.Join(colNames, ", ")+")")
+			}
+			children = append(children, fmt.Sprintf("Extended(%s)", strings.Join(groups, ", ")))
+		}
 		str = str + strings.Join(children, ",") + "}"
 	default:
 		str = fmt.Sprintf("%T", in)
@@ -86,7 +86,9 @@ func (v *validator) Leave(in ast.Node) (out ast.Node, ok bool) {
 	case *ast.AggregateFuncExpr:
 		v.inAggregate = false
 	case *ast.CreateTableStmt:
-		v.checkAutoIncrement(x)
+		if v.err == nil {
+			v.checkAutoIncrement(x)
+		}
 	case *ast.ParamMarkerExpr:
 		if !v.inPrepare {
 			v.err = parser.ErrSyntax.Gen("syntax error, unexpected '?'")
@@ -159,11 +161,14 @@ func checkAutoIncrementOp(colDef *ast.ColumnDef, num int) (bool, error) {
 
 func isConstraintKeyTp(constraints []*ast.Constraint, colDef *ast.ColumnDef) bool {
 	for _, c := range constraints {
-		if len(c.Keys) < 1 {
+		// The CHECK constraints have no keys.
+		if c.Tp == ast.ConstraintCheck || len(c.Keys) < 1 {
+			continue
 		}
 		// If the constraint as follows: primary key(c1, c2)
 		// we only support c1 column can be auto_increment.
-		if colDef.Name.Name.L != c.Keys[0].Column.Name.L {
+		// An expression key part has no column.
+		if c.Keys[0].Column == nil || colDef.Name.Name.L != c.Keys[0].Column.Name.L {
 			continue
 		}
 		switch c.Tp {
@@ -268,6 +273,14 @@ func (v *validator) checkCreateTableGrammar(stmt *ast.CreateTableStmt) {
 		}
 	}
 	for _, constraint := range stmt.Constraints {
+		if err := checkExpressionIndex(constraint); err != nil {
+			v.err = err
+			return
+		}
+		if hasExpressionKey(constraint.Keys) {
+			v.err = ddl.ErrUnsupportedExpressionIndex.GenByArgs("CREATE TABLE")
+			return
+		}
 		switch tp := constraint.Tp; tp {
 		case ast.ConstraintKey, ast.ConstraintIndex, ast.ConstraintUniq, ast.ConstraintUniqKey, ast.ConstraintUniqIndex:
 			err := checkIndexInfo(constraint.Name, constraint.Keys)
@@ -351,6 +364,9 @@ func (v *validator) checkAlterTableGrammar(stmt *ast.AlterTableStmt) {
 		}
 		switch spec.Tp {
 		case ast.AlterTableAddConstraint:
+			if v.err = checkExpressionIndex(spec.Constraint); v.err != nil {
+				return
+			}
 			switch spec.Constraint.Tp {
 			case ast.ConstraintKey, ast.ConstraintIndex, ast.ConstraintUniq, ast.ConstraintUniqIndex,
 				ast.ConstraintUniqKey:
@@ -374,11 +390,41 @@ func (v *validator) checkAlterTableGrammar(stmt *ast.AlterTableStmt) {
 	}
 }
 
+// hasExpressionKey checks if any key part of the index is an expression.
+func hasExpressionKey(indexColNames []*ast.IndexColName) bool {
+	for _, colName := range indexColNames {
+		if colName.Expr != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// checkExpressionIndex checks if the constraint can be built with expression key parts.
+func checkExpressionIndex(constraint *ast.Constraint) error {
+	if !hasExpressionKey(constraint.Keys) {
+		return nil
+	}
+	switch constraint.Tp {
+	case ast.ConstraintPrimaryKey:
+		return ddl.ErrFunctionalIndexPrimaryKey
+	case ast.ConstraintForeignKey:
+		return ddl.ErrUnsupportedExpressionIndex.GenByArgs("FOREIGN KEY")
+	}
+	return nil
+}
+
 // checkDuplicateColumnName checks if index exists duplicated columns.
 func checkDuplicateColumnName(indexColNames []*ast.IndexColName) error {
 	for i := 0; i < len(indexColNames); i++ {
+		if indexColNames[i].Expr != nil {
+			continue
+		}
 		name1 := indexColNames[i].Column.Name
 		for j := i + 1; j < len(indexColNames); j++ {
+			if indexColNames[j].Expr != nil {
+				continue
+			}
 			name2 := indexColNames[j].Column.Name
 			if name1.L == name2.L {
 				return infoschema.ErrColumnExists.GenByArgs(name2)
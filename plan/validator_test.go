@@ -18,6 +18,7 @@ import (
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb"
 	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/ddl"
 	"github.com/pingcap/tidb/parser"
 	"github.com/pingcap/tidb/plan"
 	"github.com/pingcap/tidb/terror"
@@ -57,6 +58,8 @@ func (s *testValidatorSuite) TestValidator(c *C) {
 			errors.New("Incorrect column specifier for column 'id'")},
 		{"create table t(id float auto_increment, key (id))", true, nil},
 		{"create table t(id int auto_increment) ENGINE=MYISAM", true, nil},
+		{"create table t(a int auto_increment, key ((a + 1)))", true, ddl.ErrUnsupportedExpressionIndex},
+		{"create table t(a int auto_increment, b int, check (b > 0), key(a))", true, nil},
 		{"create table t(a int primary key, b int, c varchar(10), d char(256));", true,
 			errors.New("[types:1074]Column length too big for column 'd' (max = 255); use BLOB or TEXT instead")},
 		{"create index ib on t(b,a,b);", true, errors.New("[schema:1060]Duplicate column name 'b'")},
@@ -41,6 +41,10 @@ func (s *Server) startHTTPServer() {
 	router.HandleFunc("/status", s.handleStatus)
 	// HTTP path for prometheus.
 	router.Handle("/metrics", prometheus.Handler())
+	if driver, ok := s.driver.(*TiDBDriver); ok {
+		// HTTP path for dumping the statistics.
+		router.Handle("/stats/dump/{db}/{table}", statsHandler{driver.store})
+	}
 
 	if s.cfg.Store == "tikv" {
 		tikvHandler := s.newRegionHandler()
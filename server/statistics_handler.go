@@ -0,0 +1,66 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/sessionctx"
+)
+
+// statsHandler is the handler for dumping the statistics of a table, the output can be loaded
+// by the LOAD STATS statement.
+type statsHandler struct {
+	store kv.Storage
+}
+
+// ServeHTTP handles request of dump the statistics of a table.
+func (sh statsHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	params := mux.Vars(req)
+	js, err := sh.dumpStats(params[pDBName], params[pTableName])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	w.Header().Set(headerContentType, contentTypeJSON)
+	w.WriteHeader(http.StatusOK)
+	w.Write(js)
+}
+
+func (sh statsHandler) dumpStats(dbName, tableName string) ([]byte, error) {
+	session, err := tidb.CreateSession(sh.store)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer session.Close()
+	dom := sessionctx.GetDomain(session.(context.Context))
+	tbl, err := dom.InfoSchema().TableByName(model.NewCIStr(dbName), model.NewCIStr(tableName))
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	jsonTbl, err := dom.StatsHandle().DumpStatsToJSON(dbName, tbl.Meta())
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	js, err := json.Marshal(jsonTbl)
+	return js, errors.Trace(err)
+}
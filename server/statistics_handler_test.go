@@ -0,0 +1,73 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gorilla/mux"
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb"
+	"github.com/pingcap/tidb/statistics"
+	"github.com/pingcap/tidb/store/tikv"
+	"github.com/pingcap/tidb/util/testkit"
+)
+
+type testDumpStatsSuite struct{}
+
+var _ = Suite(new(testDumpStatsSuite))
+
+// TestDumpStats serves the handler by a test server, because the status server is started only
+// once by the servers in a process.
+func (ds *testDumpStatsSuite) TestDumpStats(c *C) {
+	store, err := tikv.NewMockTikvStore()
+	c.Assert(err, IsNil)
+	defer store.Close()
+	do, err := tidb.BootstrapSession(store)
+	c.Assert(err, IsNil)
+	defer do.Close()
+	tk := testkit.NewTestKit(c, store)
+	tk.MustExec("create database tidb")
+	tk.MustExec("use tidb")
+	tk.MustExec("create table test (a int, b varchar(20))")
+	tk.MustExec("insert test values (1, 's')")
+	tk.MustExec("insert test values (2, 's')")
+	tk.MustExec("create index c on test (a, b)")
+	tk.MustExec("analyze table test")
+
+	router := mux.NewRouter()
+	router.Handle("/stats/dump/{db}/{table}", statsHandler{store})
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/stats/dump/tidb/test")
+	c.Assert(err, IsNil)
+	defer resp.Body.Close()
+	c.Assert(resp.StatusCode, Equals, http.StatusOK)
+	jsonTbl := &statistics.JSONTable{}
+	c.Assert(json.NewDecoder(resp.Body).Decode(jsonTbl), IsNil)
+	c.Assert(jsonTbl.DatabaseName, Equals, "tidb")
+	c.Assert(jsonTbl.TableName, Equals, "test")
+	c.Assert(jsonTbl.Count, Equals, int64(2))
+	c.Assert(jsonTbl.Columns, HasLen, 2)
+	c.Assert(jsonTbl.Indices, HasLen, 1)
+	c.Assert(jsonTbl.Columns["a"].NDV, Equals, int64(2))
+
+	resp, err = http.Get(server.URL + "/stats/dump/tidb/non_existent")
+	c.Assert(err, IsNil)
+	defer resp.Body.Close()
+	c.Assert(resp.StatusCode, Equals, http.StatusBadRequest)
+}
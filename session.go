@@ -299,7 +299,7 @@ func (s *session) doCommitWithRetry() error {
 			log.Warnf("[%d] retryable error: %v, txn: %v", s.sessionVars.ConnectionID, err, s.txn)
 			// Transactions will retry 2 ~ commitRetryLimit times.
 			// We make larger transactions retry less times to prevent cluster resource outage.
-			txnSizeRate := float64(txnSize) / float64(kv.TxnTotalSizeLimit)
+			txnSizeRate := float64(txnSize) / float64(atomic.LoadUint64(&kv.TxnTotalSizeLimit))
 			maxRetryCount := commitRetryLimit - int(float64(commitRetryLimit-1)*txnSizeRate)
 			err = s.retry(maxRetryCount, domain.ErrInfoSchemaChanged.Equal(err))
 		}
@@ -382,6 +382,9 @@ func (s *session) retry(maxCnt int, infoSchemaChanged bool) error {
 	if s.sessionVars.TxnCtx.ForUpdate {
 		return errors.Errorf("[%d] can not retry select for update statement", connID)
 	}
+	if s.sessionVars.TxnCtx.IsPessimistic {
+		return errors.Errorf("[%d] can not retry pessimistic transaction", connID)
+	}
 	s.sessionVars.RetryInfo.Retrying = true
 	retryCnt := 0
 	defer func() {
@@ -784,10 +787,25 @@ func (s *session) NewTxn() error {
 	if err != nil {
 		return errors.Trace(err)
 	}
+	s.setTxnOptions(txn)
 	s.txn = txn
 	return nil
 }
 
+// setTxnOptions sets the commit protocols and the replica read of the transaction
+// according to the session variables.
+func (s *session) setTxnOptions(txn kv.Transaction) {
+	if s.sessionVars.EnableAsyncCommit {
+		txn.SetOption(kv.EnableAsyncCommit, true)
+	}
+	if s.sessionVars.Enable1PC {
+		txn.SetOption(kv.Enable1PC, true)
+	}
+	if replicaRead := varsutil.GetReplicaRead(s.sessionVars); replicaRead.IsFollowerRead() {
+		txn.SetOption(kv.ReplicaRead, replicaRead)
+	}
+}
+
 func (s *session) SetValue(key fmt.Stringer, value interface{}) {
 	s.mu.Lock()
 	s.mu.values[key] = value
@@ -1007,7 +1025,7 @@ func createSessionWithDomain(store kv.Storage, dom *domain.Domain) (*session, er
 
 const (
 	notBootstrapped         = 0
-	currentBootstrapVersion = 15
+	currentBootstrapVersion = 18
 )
 
 func getStoreBootstrapVersion(store kv.Storage) int64 {
@@ -1056,6 +1074,8 @@ const loadCommonGlobalVarsSQL = "select HIGH_PRIORITY * from mysql.global_variab
 	variable.AutocommitVar + quoteCommaQuote +
 	variable.SQLModeVar + quoteCommaQuote +
 	variable.MaxAllowedPacket + quoteCommaQuote +
+	variable.ForeignKeyChecks + quoteCommaQuote +
+	variable.InnodbLockWaitTimeout + quoteCommaQuote +
 	/* TiDB specific global variables: */
 	variable.TiDBSkipUTF8Check + quoteCommaQuote +
 	variable.TiDBIndexJoinBatchSize + quoteCommaQuote +
@@ -1064,6 +1084,12 @@ const loadCommonGlobalVarsSQL = "select HIGH_PRIORITY * from mysql.global_variab
 	variable.TiDBIndexSerialScanConcurrency + quoteCommaQuote +
 	variable.TiDBMaxRowCountForINLJ + quoteCommaQuote +
 	variable.TiDBCBO + quoteCommaQuote +
+	variable.TiDBOptJoinReorderThreshold + quoteCommaQuote +
+	variable.TiDBTxnMode + quoteCommaQuote +
+	variable.TiDBEnableAsyncCommit + quoteCommaQuote +
+	variable.TiDBEnable1PC + quoteCommaQuote +
+	variable.TiDBReplicaRead + quoteCommaQuote +
+	variable.TiDBFeedbackProbability + quoteCommaQuote +
 	variable.TiDBDistSQLScanConcurrency + "')"
 
 // loadCommonGlobalVariablesIfNeeded loads and applies commonly used global variables for the session.
@@ -1135,6 +1161,7 @@ func (s *session) PrepareTxnCtx() {
 	}
 	if !s.sessionVars.IsAutocommit() {
 		s.sessionVars.SetStatusFlag(mysql.ServerStatusInTrans, true)
+		s.sessionVars.TxnCtx.IsPessimistic = s.sessionVars.TxnMode == variable.PessimisticTxnMode
 	}
 }
 
@@ -1170,6 +1197,10 @@ func (s *session) ActivePendingTxn() error {
 	if s.sessionVars.Systems[variable.TxnIsolation] == ast.ReadCommitted {
 		txn.SetOption(kv.IsolationLevel, kv.RC)
 	}
+	if s.sessionVars.TxnCtx.IsPessimistic {
+		txn.SetOption(kv.Pessimistic, true)
+	}
+	s.setTxnOptions(txn)
 	return nil
 }
 
@@ -1192,6 +1223,7 @@ func (s *session) InitTxnWithStartTS(startTS uint64) error {
 	if err != nil {
 		return errors.Trace(err)
 	}
+	s.setTxnOptions(s.txn)
 	return nil
 }
 
@@ -1214,7 +1246,8 @@ func logCrucialStmt(node ast.StmtNode) {
 	switch stmt := node.(type) {
 	case *ast.CreateUserStmt, *ast.DropUserStmt, *ast.AlterUserStmt, *ast.SetPwdStmt, *ast.GrantStmt,
 		*ast.RevokeStmt, *ast.AlterTableStmt, *ast.CreateDatabaseStmt, *ast.CreateIndexStmt, *ast.CreateTableStmt,
-		*ast.DropDatabaseStmt, *ast.DropIndexStmt, *ast.DropTableStmt, *ast.RenameTableStmt, *ast.TruncateTableStmt:
+		*ast.DropDatabaseStmt, *ast.DropIndexStmt, *ast.DropTableStmt, *ast.RenameTableStmt, *ast.TruncateTableStmt,
+		*ast.RecoverTableStmt:
 		if ss, ok := node.(ast.SensitiveStmtNode); ok {
 			log.Infof("[CRUCIAL OPERATION] %s.", ss.SecureText())
 		} else {
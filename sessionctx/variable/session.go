@@ -16,6 +16,7 @@ package variable
 import (
 	"crypto/tls"
 	"math"
+	"strings"
 	"sync"
 	"time"
 
@@ -87,6 +88,37 @@ type TransactionContext struct {
 	SchemaVersion int64
 	StartTS       uint64
 	TableDeltaMap map[int64]TableDelta
+	// IsPessimistic is true if the transaction acquires locks during the execution of statements.
+	IsPessimistic bool
+	// ForUpdateTS is the ts the current statement of a pessimistic transaction reads data at,
+	// it's 0 when no DML or SELECT FOR UPDATE statement is running.
+	ForUpdateTS uint64
+	// Savepoints are the savepoints set in the transaction, in the order they are set.
+	Savepoints []Savepoint
+	// ReadOnly is set by START TRANSACTION READ ONLY.
+	ReadOnly bool
+	// StaleReadTS is the ts a READ ONLY transaction with the AS OF clause reads data at.
+	StaleReadTS uint64
+}
+
+// Savepoint is a named point of a transaction that the transaction can be rolled back to.
+type Savepoint struct {
+	Name string
+	// Handle is the kv.StagingHandle of the transaction buffer created for the savepoint.
+	Handle int
+	// State is the transaction scope state saved by the executor, such as DirtyDB.
+	State interface{}
+}
+
+// FindSavepoint returns the index of the savepoint, or -1 if it's not found.
+// The savepoint names are case-insensitive.
+func (tc *TransactionContext) FindSavepoint(name string) int {
+	for i, sp := range tc.Savepoints {
+		if strings.EqualFold(sp.Name, name) {
+			return i
+		}
+	}
+	return -1
 }
 
 // UpdateDeltaForTable updates the delta info for some table.
@@ -175,6 +207,12 @@ type SessionVars struct {
 	// AllowInSubqueryUnFolding can be set to true to fold in subquery
 	AllowInSubqueryUnFolding bool
 
+	// JoinReorderThreshold is the max number of the tables in a join group reordered by dynamic programming.
+	JoinReorderThreshold int
+
+	// EnableCascadesPlanner can be set to true to optimize the queries by the cascades planner.
+	EnableCascadesPlanner bool
+
 	// CurrInsertValues is used to record current ValuesExpr's values.
 	// See http://dev.mysql.com/doc/refman/5.7/en/miscellaneous-functions.html#function_values
 	CurrInsertValues interface{}
@@ -222,6 +260,27 @@ type SessionVars struct {
 
 	// CBO indicates if we use new planner with cbo.
 	CBO bool
+
+	// ForeignKeyChecks indicates if the foreign key constraints are checked on write.
+	ForeignKeyChecks bool
+
+	// TxnMode is the mode of the explicit transactions, see tidb_txn_mode.
+	TxnMode string
+
+	// LockWaitTimeout is the max time a pessimistic transaction waits for a row lock.
+	LockWaitTimeout time.Duration
+
+	// EnableAsyncCommit indicates if the small transactions are committed by async commit.
+	EnableAsyncCommit bool
+
+	// Enable1PC indicates if the transactions in one region are committed by one-phase commit.
+	Enable1PC bool
+
+	// ReplicaRead is the type of the replicas the reads are sent to, see tidb_replica_read.
+	ReplicaRead string
+
+	// FeedbackProbability is the probability that a scan collects the query feedback.
+	FeedbackProbability float64
 }
 
 // NewSessionVars creates a session vars object.
@@ -237,6 +296,7 @@ func NewSessionVars() *SessionVars {
 		Status:                     mysql.ServerStatusAutocommit,
 		StmtCtx:                    new(StatementContext),
 		AllowAggPushDown:           true,
+		JoinReorderThreshold:       DefOptJoinReorderThreshold,
 		BuildStatsConcurrencyVar:   DefBuildStatsConcurrency,
 		IndexJoinBatchSize:         DefIndexJoinBatchSize,
 		IndexLookupSize:            DefIndexLookupSize,
@@ -245,6 +305,8 @@ func NewSessionVars() *SessionVars {
 		DistSQLScanConcurrency:     DefDistSQLScanConcurrency,
 		MaxRowCountForINLJ:         DefMaxRowCountForINLJ,
 		CBO:                        true,
+		ForeignKeyChecks:           true,
+		LockWaitTimeout:            DefInnodbLockWaitTimeout * time.Second,
 	}
 }
 
@@ -318,6 +380,9 @@ const (
 	MaxAllowedPacket    = "max_allowed_packet"
 	TimeZone            = "time_zone"
 	TxnIsolation        = "tx_isolation"
+	ForeignKeyChecks    = "foreign_key_checks"
+	// InnodbLockWaitTimeout is the seconds a pessimistic transaction waits for a row lock.
+	InnodbLockWaitTimeout = "innodb_lock_wait_timeout"
 )
 
 // TableDelta stands for the changed count for one table.
@@ -339,6 +404,8 @@ type StatementContext struct {
 	TruncateAsWarning      bool
 	OverflowAsWarning      bool
 	InShowWarning          bool
+	// StaleReadTS is the ts the statement reads data at, it's set by the AS OF clause.
+	StaleReadTS uint64
 
 	// mu struct holds variables that change during execution.
 	mu struct {
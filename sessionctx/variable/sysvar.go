@@ -61,15 +61,17 @@ const (
 	CodeIncorrectScope   terror.ErrCode = 1238
 	CodeUnknownTimeZone  terror.ErrCode = 1298
 	CodeReadOnly         terror.ErrCode = 1621
+	CodeWrongValueForVar terror.ErrCode = 1231
 )
 
 // Variable errors
 var (
-	UnknownStatusVar   = terror.ClassVariable.New(CodeUnknownStatusVar, "unknown status variable")
-	UnknownSystemVar   = terror.ClassVariable.New(CodeUnknownSystemVar, "unknown system variable '%s'")
-	ErrIncorrectScope  = terror.ClassVariable.New(CodeIncorrectScope, "Incorrect variable scope")
-	ErrUnknownTimeZone = terror.ClassVariable.New(CodeUnknownTimeZone, "unknown or incorrect time zone: %s")
-	ErrReadOnly        = terror.ClassVariable.New(CodeReadOnly, "variable is read only")
+	UnknownStatusVar    = terror.ClassVariable.New(CodeUnknownStatusVar, "unknown status variable")
+	UnknownSystemVar    = terror.ClassVariable.New(CodeUnknownSystemVar, "unknown system variable '%s'")
+	ErrIncorrectScope   = terror.ClassVariable.New(CodeIncorrectScope, "Incorrect variable scope")
+	ErrUnknownTimeZone  = terror.ClassVariable.New(CodeUnknownTimeZone, "unknown or incorrect time zone: %s")
+	ErrReadOnly         = terror.ClassVariable.New(CodeReadOnly, "variable is read only")
+	ErrWrongValueForVar = terror.ClassVariable.New(CodeWrongValueForVar, "Variable '%s' can't be set to the value of '%s'")
 )
 
 func init() {
@@ -84,6 +86,7 @@ func init() {
 		CodeIncorrectScope:   mysql.ErrIncorrectGlobalLocalVar,
 		CodeUnknownTimeZone:  mysql.ErrUnknownTimeZone,
 		CodeReadOnly:         mysql.ErrVariableIsReadonly,
+		CodeWrongValueForVar: mysql.ErrWrongValueForVar,
 	}
 	terror.ErrClassToMySQLCodes[terror.ClassVariable] = mySQLErrCodes
 }
@@ -208,7 +211,7 @@ var defaultSysVars = []*SysVar{
 	{ScopeNone, "innodb_autoinc_lock_mode", "1"},
 	{ScopeGlobal, "slave_net_timeout", "3600"},
 	{ScopeGlobal, "key_buffer_size", "8388608"},
-	{ScopeGlobal | ScopeSession, "foreign_key_checks", "ON"},
+	{ScopeGlobal | ScopeSession, ForeignKeyChecks, "ON"},
 	{ScopeGlobal, "host_cache_size", "279"},
 	{ScopeGlobal, "delay_key_write", "ON"},
 	{ScopeNone, "metadata_locks_cache_size", "1024"},
@@ -535,7 +538,7 @@ var defaultSysVars = []*SysVar{
 	{ScopeNone, "basedir", "/usr/local/mysql"},
 	{ScopeGlobal, "innodb_old_blocks_time", "1000"},
 	{ScopeGlobal, "innodb_stats_method", "nulls_equal"},
-	{ScopeGlobal | ScopeSession, "innodb_lock_wait_timeout", "50"},
+	{ScopeGlobal | ScopeSession, InnodbLockWaitTimeout, strconv.Itoa(DefInnodbLockWaitTimeout)},
 	{ScopeGlobal, "local_infile", "ON"},
 	{ScopeGlobal | ScopeSession, "myisam_stats_method", "nulls_unequal"},
 	{ScopeNone, "version_compile_os", "osx10.8"},
@@ -606,6 +609,8 @@ var defaultSysVars = []*SysVar{
 	{ScopeSession, TiDBSkipConstraintCheck, "0"},
 	{ScopeSession, TiDBOptAggPushDown, boolToIntStr(DefOptAggPushDown)},
 	{ScopeSession, TiDBOptInSubqUnFolding, boolToIntStr(DefOptInSubqUnfolding)},
+	{ScopeGlobal | ScopeSession, TiDBOptJoinReorderThreshold, strconv.Itoa(DefOptJoinReorderThreshold)},
+	{ScopeSession, TiDBEnableCascadesPlanner, boolToIntStr(DefEnableCascadesPlanner)},
 	{ScopeSession, TiDBBuildStatsConcurrency, strconv.Itoa(DefBuildStatsConcurrency)},
 	{ScopeGlobal | ScopeSession, TiDBDistSQLScanConcurrency, strconv.Itoa(DefDistSQLScanConcurrency)},
 	{ScopeGlobal | ScopeSession, TiDBIndexJoinBatchSize, strconv.Itoa(DefIndexJoinBatchSize)},
@@ -618,6 +623,15 @@ var defaultSysVars = []*SysVar{
 	{ScopeSession, TiDBBatchInsert, boolToIntStr(DefBatchInsert)},
 	{ScopeSession, TiDBBatchDelete, boolToIntStr(DefBatchDelete)},
 	{ScopeSession, TiDBCurrentTS, strconv.Itoa(DefCurretTS)},
+	{ScopeGlobal | ScopeSession, TiDBTxnMode, ""},
+	{ScopeGlobal | ScopeSession, TiDBEnableAsyncCommit, boolToIntStr(DefEnableAsyncCommit)},
+	{ScopeGlobal | ScopeSession, TiDBEnable1PC, boolToIntStr(DefEnable1PC)},
+	{ScopeGlobal | ScopeSession, TiDBReplicaRead, ReplicaReadLeader},
+	{ScopeGlobal | ScopeSession, TiDBFeedbackProbability, strconv.FormatFloat(DefFeedbackProbability, 'f', -1, 64)},
+	{ScopeGlobal, TiDBAutoAnalyzeRatio, strconv.FormatFloat(DefAutoAnalyzeRatio, 'f', -1, 64)},
+	{ScopeGlobal, TiDBAutoAnalyzeStartTime, DefAutoAnalyzeStartTime},
+	{ScopeGlobal, TiDBAutoAnalyzeEndTime, DefAutoAnalyzeEndTime},
+	{ScopeGlobal, TiDBAutoAnalyzeConcurrency, strconv.Itoa(DefAutoAnalyzeConcurrency)},
 }
 
 // SetNamesVariables is the system variable names related to set names statements.
@@ -44,6 +44,13 @@ const (
 	// tidb_opt_insubquery_unfold is used to enable/disable the optimizer rule of in subquery unfold.
 	TiDBOptInSubqUnFolding = "tidb_opt_insubquery_unfold"
 
+	// tidb_opt_join_reorder_threshold is the max number of the tables in a join group reordered by dynamic
+	// programming, the larger join groups are reordered by the greedy algorithm.
+	TiDBOptJoinReorderThreshold = "tidb_opt_join_reorder_threshold"
+
+	// tidb_enable_cascades_planner is used to enable/disable the memo-based cascades planner, it's experimental.
+	TiDBEnableCascadesPlanner = "tidb_enable_cascades_planner"
+
 	// tidb_build_stats_concurrency is used to speed up the ANALYZE statement, when a table has multiple indices,
 	// those indices can be scanned concurrently, with the cost of higher system performance impact.
 	TiDBBuildStatsConcurrency = "tidb_build_stats_concurrency"
@@ -103,6 +110,41 @@ const (
 
 	// tidb_cbo uses new planner with cost based optimizer.
 	TiDBCBO = "tidb_cbo"
+
+	// tidb_txn_mode is the mode of the transactions started by BEGIN or with autocommit off,
+	// the value is "optimistic" or "pessimistic". The default empty value means optimistic.
+	// A pessimistic transaction acquires locks when executing DML and SELECT FOR UPDATE statements.
+	TiDBTxnMode = "tidb_txn_mode"
+
+	// tidb_enable_async_commit makes the small transactions committed once all their keys are prewritten,
+	// which saves the latency of committing the primary key. It needs the storage to support async commit.
+	TiDBEnableAsyncCommit = "tidb_enable_async_commit"
+
+	// tidb_enable_1pc makes the transactions whose keys are all in one region committed by the prewrite.
+	// It needs the storage to support one-phase commit.
+	TiDBEnable1PC = "tidb_enable_1pc"
+
+	// tidb_replica_read sets the replicas the reads are sent to, the value is "leader", "follower",
+	// "leader-and-follower" or "closest". The follower reads can only be set if the storage supports
+	// replica read.
+	TiDBReplicaRead = "tidb_replica_read"
+
+	// tidb_feedback_probability is the probability that a scan collects the actual row counts of its
+	// ranges to refine the histograms, 0 disables it.
+	TiDBFeedbackProbability = "tidb_feedback_probability"
+
+	// tidb_auto_analyze_ratio is the ratio of the modified rows to the rows of a table above which the
+	// table is analyzed automatically, 0 disables it. The tables never analyzed are always analyzed.
+	TiDBAutoAnalyzeRatio = "tidb_auto_analyze_ratio"
+
+	// tidb_auto_analyze_start_time and tidb_auto_analyze_end_time are the daily time window in which the
+	// tables are analyzed for the modified rows, the format is "15:04 -0700". The window crosses the
+	// midnight if the end time is earlier than the start time.
+	TiDBAutoAnalyzeStartTime = "tidb_auto_analyze_start_time"
+	TiDBAutoAnalyzeEndTime   = "tidb_auto_analyze_end_time"
+
+	// tidb_auto_analyze_concurrency is the max number of the tables analyzed automatically at the same time.
+	TiDBAutoAnalyzeConcurrency = "tidb_auto_analyze_concurrency"
 )
 
 // Default TiDB system variable values.
@@ -117,7 +159,31 @@ const (
 	DefSkipUTF8Check              = false
 	DefOptAggPushDown             = true
 	DefOptInSubqUnfolding         = false
+	DefOptJoinReorderThreshold    = 0
+	DefEnableCascadesPlanner      = false
 	DefBatchInsert                = false
 	DefBatchDelete                = false
 	DefCurretTS                   = 0
+	DefInnodbLockWaitTimeout      = 50
+	DefEnableAsyncCommit          = false
+	DefEnable1PC                  = false
+	DefAutoAnalyzeRatio           = 0.5
+	DefAutoAnalyzeStartTime       = "00:00 +0000"
+	DefAutoAnalyzeEndTime         = "23:59 +0000"
+	DefAutoAnalyzeConcurrency     = 1
+	DefFeedbackProbability        = 0
+)
+
+// Transaction modes of tidb_txn_mode.
+const (
+	OptimisticTxnMode  = "optimistic"
+	PessimisticTxnMode = "pessimistic"
+)
+
+// Replica read types of tidb_replica_read.
+const (
+	ReplicaReadLeader            = "leader"
+	ReplicaReadFollower          = "follower"
+	ReplicaReadLeaderAndFollower = "leader-and-follower"
+	ReplicaReadClosest           = "closest"
 )
@@ -20,6 +20,7 @@ import (
 	"time"
 
 	"github.com/juju/errors"
+	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/util/types"
@@ -126,6 +127,8 @@ func SetSessionSystemVar(vars *variable.SessionVars, name string, value types.Da
 		if isAutocommit {
 			vars.SetStatusFlag(mysql.ServerStatusInTrans, false)
 		}
+	case variable.ForeignKeyChecks:
+		vars.ForeignKeyChecks = tidbOptOn(sVal)
 	case variable.TiDBSkipConstraintCheck:
 		vars.SkipConstraintCheck = tidbOptOn(sVal)
 	case variable.TiDBSkipUTF8Check:
@@ -134,6 +137,14 @@ func SetSessionSystemVar(vars *variable.SessionVars, name string, value types.Da
 		vars.AllowAggPushDown = tidbOptOn(sVal)
 	case variable.TiDBOptInSubqUnFolding:
 		vars.AllowInSubqueryUnFolding = tidbOptOn(sVal)
+	case variable.TiDBOptJoinReorderThreshold:
+		threshold, err1 := strconv.Atoi(sVal)
+		if err1 != nil || threshold < 0 || threshold > maxJoinReorderThreshold {
+			return variable.ErrWrongValueForVar.GenByArgs(name, sVal)
+		}
+		vars.JoinReorderThreshold = threshold
+	case variable.TiDBEnableCascadesPlanner:
+		vars.EnableCascadesPlanner = tidbOptOn(sVal)
 	case variable.TiDBIndexLookupConcurrency:
 		vars.IndexLookupConcurrency = tidbOptPositiveInt(sVal, variable.DefIndexLookupConcurrency)
 	case variable.TiDBIndexJoinBatchSize:
@@ -154,11 +165,52 @@ func SetSessionSystemVar(vars *variable.SessionVars, name string, value types.Da
 		vars.CBO = tidbOptOn(sVal)
 	case variable.TiDBCurrentTS:
 		return variable.ErrReadOnly
+	case variable.TiDBTxnMode:
+		sVal = strings.ToLower(sVal)
+		if sVal != "" && sVal != variable.OptimisticTxnMode && sVal != variable.PessimisticTxnMode {
+			return variable.ErrWrongValueForVar.GenByArgs(name, sVal)
+		}
+		vars.TxnMode = sVal
+	case variable.TiDBEnableAsyncCommit:
+		vars.EnableAsyncCommit = tidbOptOn(sVal)
+	case variable.TiDBEnable1PC:
+		vars.Enable1PC = tidbOptOn(sVal)
+	case variable.TiDBReplicaRead:
+		sVal = strings.ToLower(sVal)
+		if _, ok := replicaReadTypes[sVal]; !ok {
+			return variable.ErrWrongValueForVar.GenByArgs(name, sVal)
+		}
+		vars.ReplicaRead = sVal
+	case variable.TiDBFeedbackProbability:
+		prob, err1 := strconv.ParseFloat(sVal, 64)
+		if err1 != nil || prob < 0 || prob > 1 {
+			return variable.ErrWrongValueForVar.GenByArgs(name, sVal)
+		}
+		vars.FeedbackProbability = prob
+	case variable.InnodbLockWaitTimeout:
+		vars.LockWaitTimeout = time.Duration(tidbOptPositiveInt(sVal, variable.DefInnodbLockWaitTimeout)) * time.Second
 	}
 	vars.Systems[name] = sVal
 	return nil
 }
 
+// maxJoinReorderThreshold is the max value of tidb_opt_join_reorder_threshold, because the subsets of a
+// join group are represented by the bits of an uint64 in dynamic programming.
+const maxJoinReorderThreshold = 63
+
+var replicaReadTypes = map[string]kv.ReplicaReadType{
+	variable.ReplicaReadLeader:            kv.ReplicaReadLeader,
+	variable.ReplicaReadFollower:          kv.ReplicaReadFollower,
+	variable.ReplicaReadLeaderAndFollower: kv.ReplicaReadMixed,
+	variable.ReplicaReadClosest:           kv.ReplicaReadClosest,
+}
+
+// GetReplicaRead returns the replica read type of the session, the reads are sent to the leader
+// if tidb_replica_read isn't set.
+func GetReplicaRead(vars *variable.SessionVars) kv.ReplicaReadType {
+	return replicaReadTypes[vars.ReplicaRead]
+}
+
 // tidbOptOn could be used for all tidb session variable options, we use "ON"/1 to turn on those options.
 func tidbOptOn(opt string) bool {
 	return strings.EqualFold(opt, "ON") || opt == "1"
@@ -156,6 +156,31 @@ func (s *testVarsutilSuite) TestVarsutil(c *C) {
 	c.Assert(v.MaxRowCountForINLJ, Equals, 128)
 	SetSessionSystemVar(v, variable.TiDBMaxRowCountForINLJ, types.NewStringDatum("127"))
 	c.Assert(v.MaxRowCountForINLJ, Equals, 127)
+
+	// Test case for foreign_key_checks.
+	c.Assert(v.ForeignKeyChecks, IsTrue)
+	SetSessionSystemVar(v, variable.ForeignKeyChecks, types.NewStringDatum("0"))
+	c.Assert(v.ForeignKeyChecks, IsFalse)
+
+	// Test case for tidb_feedback_probability.
+	c.Assert(v.FeedbackProbability, Equals, 0.0)
+	SetSessionSystemVar(v, variable.TiDBFeedbackProbability, types.NewStringDatum("0.5"))
+	c.Assert(v.FeedbackProbability, Equals, 0.5)
+	c.Assert(SetSessionSystemVar(v, variable.TiDBFeedbackProbability, types.NewStringDatum("2")), NotNil)
+	c.Assert(v.FeedbackProbability, Equals, 0.5)
+
+	// Test case for tidb_opt_join_reorder_threshold.
+	c.Assert(v.JoinReorderThreshold, Equals, variable.DefOptJoinReorderThreshold)
+	SetSessionSystemVar(v, variable.TiDBOptJoinReorderThreshold, types.NewStringDatum("10"))
+	c.Assert(v.JoinReorderThreshold, Equals, 10)
+	c.Assert(SetSessionSystemVar(v, variable.TiDBOptJoinReorderThreshold, types.NewStringDatum("64")), NotNil)
+	c.Assert(SetSessionSystemVar(v, variable.TiDBOptJoinReorderThreshold, types.NewStringDatum("-1")), NotNil)
+	c.Assert(v.JoinReorderThreshold, Equals, 10)
+
+	// Test case for tidb_enable_cascades_planner.
+	c.Assert(v.EnableCascadesPlanner, IsFalse)
+	SetSessionSystemVar(v, variable.TiDBEnableCascadesPlanner, types.NewStringDatum("1"))
+	c.Assert(v.EnableCascadesPlanner, IsTrue)
 }
 
 type mockGlobalAccessor struct {
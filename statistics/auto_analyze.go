@@ -0,0 +1,344 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statistics
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/infoschema"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/store/tikv/oracle"
+	"github.com/pingcap/tidb/util/sqlexec"
+)
+
+const (
+	// autoAnalyzeTimeLayout is the layout of tidb_auto_analyze_start_time and tidb_auto_analyze_end_time.
+	autoAnalyzeTimeLayout = "15:04 -0700"
+	// autoAnalyzeHistoryTTL is how long the auto analyze history is kept.
+	autoAnalyzeHistoryTTL = 7 * 24 * time.Hour
+	// unanalyzedPriority is the priority of the tables and indices never analyzed, they
+	// are analyzed before the tables with modified rows.
+	unanalyzedPriority = math.MaxFloat64
+
+	analyzeStateFinished = "finished"
+	analyzeStateFailed   = "failed"
+)
+
+// autoAnalyzeParams are the parameters of the auto analyze set by the global variables.
+type autoAnalyzeParams struct {
+	ratio       float64
+	start       time.Time
+	end         time.Time
+	concurrency int
+}
+
+// loadAutoAnalyzeParams loads the parameters of the auto analyze from the global variables,
+// the defaults are used for the variables not set or invalid.
+func (h *Handle) loadAutoAnalyzeParams() (*autoAnalyzeParams, error) {
+	sql := fmt.Sprintf("select variable_name, variable_value from mysql.global_variables where variable_name in ('%s', '%s', '%s', '%s')",
+		variable.TiDBAutoAnalyzeRatio, variable.TiDBAutoAnalyzeStartTime, variable.TiDBAutoAnalyzeEndTime, variable.TiDBAutoAnalyzeConcurrency)
+	rows, _, err := h.ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(h.ctx, sql)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	values := make(map[string]string, len(rows))
+	for _, row := range rows {
+		values[row.Data[0].GetString()] = row.Data[1].GetString()
+	}
+	return parseAutoAnalyzeParams(values), nil
+}
+
+func parseAutoAnalyzeParams(values map[string]string) *autoAnalyzeParams {
+	params := &autoAnalyzeParams{
+		ratio:       variable.DefAutoAnalyzeRatio,
+		concurrency: variable.DefAutoAnalyzeConcurrency,
+	}
+	if v, ok := values[variable.TiDBAutoAnalyzeRatio]; ok {
+		ratio, err := strconv.ParseFloat(v, 64)
+		if err == nil && ratio >= 0 {
+			params.ratio = ratio
+		} else {
+			log.Warnf("[stats] invalid %s %s, use the default %v", variable.TiDBAutoAnalyzeRatio, v, params.ratio)
+		}
+	}
+	if v, ok := values[variable.TiDBAutoAnalyzeConcurrency]; ok {
+		concurrency, err := strconv.Atoi(v)
+		if err == nil && concurrency > 0 {
+			params.concurrency = concurrency
+		} else {
+			log.Warnf("[stats] invalid %s %s, use the default %v", variable.TiDBAutoAnalyzeConcurrency, v, params.concurrency)
+		}
+	}
+	params.start = parseAnalyzeTime(values, variable.TiDBAutoAnalyzeStartTime, variable.DefAutoAnalyzeStartTime)
+	params.end = parseAnalyzeTime(values, variable.TiDBAutoAnalyzeEndTime, variable.DefAutoAnalyzeEndTime)
+	return params
+}
+
+func parseAnalyzeTime(values map[string]string, name, def string) time.Time {
+	if v, ok := values[name]; ok {
+		t, err := time.Parse(autoAnalyzeTimeLayout, v)
+		if err == nil {
+			return t
+		}
+		log.Warnf("[stats] invalid %s %s, use the default %s", name, v, def)
+	}
+	// The defaults are always valid.
+	t, _ := time.Parse(autoAnalyzeTimeLayout, def)
+	return t
+}
+
+// withinDayTimePeriod checks whether the time of day of now is in [start, end], only the
+// hours and minutes are compared. The period crosses the midnight if end is before start.
+func withinDayTimePeriod(start, end, now time.Time) bool {
+	minutes := func(t time.Time) int {
+		t = t.UTC()
+		return t.Hour()*60 + t.Minute()
+	}
+	s, e, n := minutes(start), minutes(end), minutes(now)
+	if s <= e {
+		return s <= n && n <= e
+	}
+	return n >= s || n <= e
+}
+
+// autoAnalyzeJob is an ANALYZE statement run by the auto analyze.
+type autoAnalyzeJob struct {
+	tableID   int64
+	dbName    string
+	tableName string
+	// indexName is the index to analyze, the whole table is analyzed if it's empty.
+	indexName string
+	reason    string
+	// priority is the modify ratio of the table, the jobs with higher priority run first.
+	priority float64
+	// inPeriod is set if the job only runs in the time period of the auto analyze.
+	inPeriod bool
+}
+
+func (j *autoAnalyzeJob) info() string {
+	if j.indexName != "" {
+		return fmt.Sprintf("analyze index %s", j.indexName)
+	}
+	return "analyze table"
+}
+
+func (j *autoAnalyzeJob) sql() string {
+	sql := fmt.Sprintf("analyze table `%s`.`%s`", j.dbName, j.tableName)
+	if j.indexName != "" {
+		sql += fmt.Sprintf(" index `%s`", j.indexName)
+	}
+	return sql
+}
+
+// autoAnalyzeQueue is a priority queue of the jobs, the most stale table is at the top.
+type autoAnalyzeQueue []*autoAnalyzeJob
+
+func (q autoAnalyzeQueue) Len() int { return len(q) }
+
+func (q autoAnalyzeQueue) Less(i, j int) bool {
+	if q[i].priority != q[j].priority {
+		return q[i].priority > q[j].priority
+	}
+	return q[i].tableID < q[j].tableID
+}
+
+func (q autoAnalyzeQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *autoAnalyzeQueue) Push(x interface{}) {
+	*q = append(*q, x.(*autoAnalyzeJob))
+}
+
+func (q *autoAnalyzeQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	job := old[n-1]
+	*q = old[:n-1]
+	return job
+}
+
+// tableAnalyzed checks whether any column or index of the table has been analyzed.
+func tableAnalyzed(tbl *Table) bool {
+	for _, col := range tbl.Columns {
+		if len(col.Buckets) > 0 {
+			return true
+		}
+	}
+	for _, idx := range tbl.Indices {
+		if len(idx.Buckets) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// needAnalyzeTable checks whether the table needs to be analyzed. A table never analyzed is
+// analyzed once its stats are not updated for the limit, an analyzed table is analyzed if the
+// ratio of its modified rows is larger than ratio. It returns the reason and the priority.
+func needAnalyzeTable(tbl *Table, limit time.Duration, ratio float64) (bool, string, float64) {
+	if tbl.ModifyCount == 0 {
+		return false, "", 0
+	}
+	if !tableAnalyzed(tbl) {
+		t := time.Unix(0, oracle.ExtractPhysical(tbl.Version)*int64(time.Millisecond))
+		if time.Since(t) < limit {
+			return false, "", 0
+		}
+		return true, "table unanalyzed", unanalyzedPriority
+	}
+	if ratio == 0 {
+		return false, "", 0
+	}
+	modifyRatio := float64(tbl.ModifyCount) / math.Max(float64(tbl.Count), 1)
+	if modifyRatio <= ratio {
+		return false, "", 0
+	}
+	return true, fmt.Sprintf("too many modifications (%d/%d > %v)", tbl.ModifyCount, tbl.Count, ratio), modifyRatio
+}
+
+// buildAutoAnalyzeQueue collects the tables and indices to analyze.
+func (h *Handle) buildAutoAnalyzeQueue(is infoschema.InfoSchema, params *autoAnalyzeParams) *autoAnalyzeQueue {
+	queue := &autoAnalyzeQueue{}
+	for _, db := range is.AllSchemaNames() {
+		for _, tbl := range is.SchemaTables(model.NewCIStr(db)) {
+			tblInfo := tbl.Meta()
+			statsTbl := h.GetTableStats(tblInfo.ID)
+			if statsTbl.Pseudo || statsTbl.Count == 0 {
+				continue
+			}
+			if ok, reason, priority := needAnalyzeTable(statsTbl, 20*h.Lease, params.ratio); ok {
+				heap.Push(queue, &autoAnalyzeJob{
+					tableID:   tblInfo.ID,
+					dbName:    db,
+					tableName: tblInfo.Name.O,
+					reason:    reason,
+					priority:  priority,
+					inPeriod:  priority != unanalyzedPriority,
+				})
+				continue
+			}
+			for _, idx := range tblInfo.Indices {
+				if _, ok := statsTbl.Indices[idx.ID]; !ok {
+					heap.Push(queue, &autoAnalyzeJob{
+						tableID:   tblInfo.ID,
+						dbName:    db,
+						tableName: tblInfo.Name.O,
+						indexName: idx.Name.O,
+						reason:    "index unanalyzed",
+						priority:  unanalyzedPriority,
+					})
+				}
+			}
+		}
+	}
+	return queue
+}
+
+// HandleAutoAnalyze analyzes the tables never analyzed, the new indices, and the tables whose
+// ratio of the modified rows exceeds tidb_auto_analyze_ratio in the time period. The most
+// stale tables are analyzed first, at most tidb_auto_analyze_concurrency at the same time.
+// Each job is recorded in mysql.analyze_history, the first error is returned.
+func (h *Handle) HandleAutoAnalyze(is infoschema.InfoSchema) error {
+	params, err := h.loadAutoAnalyzeParams()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	queue := h.buildAutoAnalyzeQueue(is, params)
+	if queue.Len() == 0 {
+		return nil
+	}
+
+	jobCh := make(chan *autoAnalyzeJob)
+	errCh := make(chan error, queue.Len())
+	workers := params.concurrency
+	if workers > queue.Len() {
+		workers = queue.Len()
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobCh {
+				errCh <- h.runAutoAnalyzeJob(job, params)
+			}
+		}()
+	}
+	for queue.Len() > 0 {
+		jobCh <- heap.Pop(queue).(*autoAnalyzeJob)
+	}
+	close(jobCh)
+	wg.Wait()
+	close(errCh)
+
+	var firstErr error
+	for err := range errCh {
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	h.cleanAutoAnalyzeHistory()
+	return errors.Trace(firstErr)
+}
+
+// runAutoAnalyzeJob runs the job and records it in the history, the jobs that only run in the
+// time period are skipped out of it.
+func (h *Handle) runAutoAnalyzeJob(job *autoAnalyzeJob, params *autoAnalyzeParams) error {
+	if job.inPeriod && !withinDayTimePeriod(params.start, params.end, time.Now()) {
+		return nil
+	}
+	exec := h.ctx.(sqlexec.RestrictedSQLExecutor)
+	log.Infof("[stats] auto %s for table `%s`.`%s` now, reason: %s", job.info(), job.dbName, job.tableName, job.reason)
+	start := time.Now()
+	_, _, err := exec.ExecRestrictedSQL(h.ctx, job.sql())
+	end := time.Now()
+
+	state, failReason := analyzeStateFinished, ""
+	if err != nil {
+		state, failReason = analyzeStateFailed, err.Error()
+		log.Errorf("[stats] auto %s for table `%s`.`%s` failed: %v", job.info(), job.dbName, job.tableName, errors.ErrorStack(err))
+	}
+	sql := fmt.Sprintf("insert into mysql.analyze_history (table_id, table_schema, table_name, job_info, reason, start_time, end_time, state, fail_reason) values (%d, '%s', '%s', '%s', '%s', '%s', '%s', '%s', '%s')",
+		job.tableID, escapeSQLString(job.dbName), escapeSQLString(job.tableName), escapeSQLString(job.info()), escapeSQLString(job.reason),
+		start.Format(analyzeHistoryTimeFormat), end.Format(analyzeHistoryTimeFormat), state, escapeSQLString(failReason))
+	if _, _, err1 := exec.ExecRestrictedSQL(h.ctx, sql); err1 != nil {
+		log.Warnf("[stats] record auto analyze history failed: %v", errors.ErrorStack(err1))
+	}
+	return errors.Trace(err)
+}
+
+const analyzeHistoryTimeFormat = "2006-01-02 15:04:05"
+
+// cleanAutoAnalyzeHistory removes the history older than autoAnalyzeHistoryTTL.
+func (h *Handle) cleanAutoAnalyzeHistory() {
+	sql := fmt.Sprintf("delete from mysql.analyze_history where end_time < '%s'", time.Now().Add(-autoAnalyzeHistoryTTL).Format(analyzeHistoryTimeFormat))
+	if _, _, err := h.ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(h.ctx, sql); err != nil {
+		log.Warnf("[stats] clean auto analyze history failed: %v", errors.ErrorStack(err))
+	}
+}
+
+var sqlStringReplacer = strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+
+// escapeSQLString escapes the string to be quoted by single quotes in a SQL.
+func escapeSQLString(s string) string {
+	return sqlStringReplacer.Replace(s)
+}
@@ -0,0 +1,89 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statistics
+
+import (
+	"container/heap"
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/sessionctx/variable"
+)
+
+var _ = Suite(&testAutoAnalyzeSuite{})
+
+type testAutoAnalyzeSuite struct {
+}
+
+func (s *testAutoAnalyzeSuite) TestWithinDayTimePeriod(c *C) {
+	parse := func(s string) time.Time {
+		t, err := time.Parse(autoAnalyzeTimeLayout, s)
+		c.Assert(err, IsNil)
+		return t
+	}
+	tests := []struct {
+		start, end, now string
+		within          bool
+	}{
+		{"00:00 +0000", "23:59 +0000", "12:00 +0000", true},
+		{"01:00 +0000", "05:00 +0000", "01:00 +0000", true},
+		{"01:00 +0000", "05:00 +0000", "05:00 +0000", true},
+		{"01:00 +0000", "05:00 +0000", "05:01 +0000", false},
+		{"01:00 +0000", "05:00 +0000", "00:59 +0000", false},
+		// The period crosses the midnight.
+		{"22:00 +0000", "02:00 +0000", "23:00 +0000", true},
+		{"22:00 +0000", "02:00 +0000", "01:00 +0000", true},
+		{"22:00 +0000", "02:00 +0000", "12:00 +0000", false},
+		// The time zones are respected.
+		{"01:00 +0800", "05:00 +0800", "18:00 +0000", true},
+		{"01:00 +0800", "05:00 +0800", "01:00 +0000", false},
+	}
+	for _, t := range tests {
+		c.Assert(withinDayTimePeriod(parse(t.start), parse(t.end), parse(t.now)), Equals, t.within, Commentf("%v", t))
+	}
+}
+
+func (s *testAutoAnalyzeSuite) TestParseParams(c *C) {
+	params := parseAutoAnalyzeParams(map[string]string{
+		variable.TiDBAutoAnalyzeRatio:       "0.3",
+		variable.TiDBAutoAnalyzeConcurrency: "4",
+		variable.TiDBAutoAnalyzeStartTime:   "01:00 +0800",
+		variable.TiDBAutoAnalyzeEndTime:     "invalid",
+	})
+	c.Assert(params.ratio, Equals, 0.3)
+	c.Assert(params.concurrency, Equals, 4)
+	c.Assert(params.start.UTC().Hour(), Equals, 17)
+	c.Assert(params.end.Format(autoAnalyzeTimeLayout), Equals, variable.DefAutoAnalyzeEndTime)
+
+	params = parseAutoAnalyzeParams(map[string]string{
+		variable.TiDBAutoAnalyzeRatio:       "-1",
+		variable.TiDBAutoAnalyzeConcurrency: "0",
+	})
+	c.Assert(params.ratio, Equals, variable.DefAutoAnalyzeRatio)
+	c.Assert(params.concurrency, Equals, variable.DefAutoAnalyzeConcurrency)
+}
+
+func (s *testAutoAnalyzeSuite) TestQueueOrder(c *C) {
+	queue := &autoAnalyzeQueue{}
+	heap.Push(queue, &autoAnalyzeJob{tableID: 1, priority: 0.6})
+	heap.Push(queue, &autoAnalyzeJob{tableID: 2, priority: 2})
+	heap.Push(queue, &autoAnalyzeJob{tableID: 4, priority: unanalyzedPriority})
+	heap.Push(queue, &autoAnalyzeJob{tableID: 3, priority: unanalyzedPriority})
+	heap.Push(queue, &autoAnalyzeJob{tableID: 5, priority: 0.8})
+	var ids []int64
+	for queue.Len() > 0 {
+		ids = append(ids, heap.Pop(queue).(*autoAnalyzeJob).tableID)
+	}
+	c.Assert(ids, DeepEquals, []int64{3, 4, 2, 5, 1})
+}
@@ -14,10 +14,13 @@
 package statistics
 
 import (
+	"time"
+
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/tablecodec"
 	"github.com/pingcap/tidb/util/codec"
 	"github.com/pingcap/tidb/util/types"
 )
@@ -31,6 +34,9 @@ type SortedBuilder struct {
 	bucketIdx       int64
 	Count           int64
 	hist            *Histogram
+	cms             *CMSketch
+	topN            *sortedTopN
+	numTopN         int
 }
 
 // NewSortedBuilder creates a new SortedBuilder.
@@ -54,8 +60,29 @@ func (b *SortedBuilder) Hist() *Histogram {
 	return b.hist
 }
 
+// EnableCMSketch makes the builder build a CM sketch with the TopN of the values, the values
+// should be bytes.
+func (b *SortedBuilder) EnableCMSketch(depth, width int32, numTopN int) {
+	b.cms = NewCMSketch(depth, width)
+	b.topN = newSortedTopN(numTopN)
+	b.numTopN = numTopN
+}
+
+// CMSketch returns the CM sketch built by SortedBuilder, it's nil if the CM sketch isn't enabled.
+func (b *SortedBuilder) CMSketch() *CMSketch {
+	if b.cms == nil {
+		return nil
+	}
+	b.cms.extractTopN(b.topN.candidates(), b.numTopN)
+	return b.cms
+}
+
 // Iterate updates the histogram incrementally.
 func (b *SortedBuilder) Iterate(data types.Datum) error {
+	if b.cms != nil {
+		b.cms.InsertBytes(data.GetBytes())
+		b.topN.collect(data.GetBytes())
+	}
 	cmp, err := b.hist.Buckets[b.bucketIdx].UpperBound.CompareDatum(b.sc, data)
 	if err != nil {
 		return errors.Trace(err)
@@ -108,31 +135,33 @@ func (b *SortedBuilder) Iterate(data types.Datum) error {
 	return nil
 }
 
-// BuildIndex builds histogram for index.
-func BuildIndex(ctx context.Context, numBuckets, id int64, records ast.RecordSet) (int64, *Histogram, error) {
+// BuildIndex builds histogram and CM sketch for index.
+func BuildIndex(ctx context.Context, numBuckets, id int64, records ast.RecordSet) (int64, *Histogram, *CMSketch, error) {
 	b := NewSortedBuilder(ctx.GetSessionVars().StmtCtx, numBuckets, id)
+	b.EnableCMSketch(DefaultCMSketchDepth, DefaultCMSketchWidth, DefaultNumTopN)
 	for {
 		row, err := records.Next()
 		if err != nil {
-			return 0, nil, errors.Trace(err)
+			return 0, nil, nil, errors.Trace(err)
 		}
 		if row == nil {
 			break
 		}
 		bytes, err := codec.EncodeKey(nil, row.Data...)
 		if err != nil {
-			return 0, nil, errors.Trace(err)
+			return 0, nil, nil, errors.Trace(err)
 		}
 		data := types.NewBytesDatum(bytes)
 		err = b.Iterate(data)
 		if err != nil {
-			return 0, nil, errors.Trace(err)
+			return 0, nil, nil, errors.Trace(err)
 		}
 	}
-	return b.Count, b.Hist(), nil
+	return b.Count, b.Hist(), b.CMSketch(), nil
 }
 
-// BuildColumn builds histogram from samples for column.
+// BuildColumn builds histogram from samples for column. If the collector has a CM sketch, the
+// most frequent values of the samples are moved to the TopN of the sketch.
 func BuildColumn(ctx context.Context, numBuckets, id int64, collector *SampleCollector) (*Histogram, error) {
 	count := collector.Count
 	if count == 0 {
@@ -194,14 +223,43 @@ func BuildColumn(ctx context.Context, numBuckets, id int64, collector *SampleCol
 			})
 		}
 	}
+	if collector.CMSketch != nil {
+		err = extractColumnTopN(collector.CMSketch, samples, ctx.GetSessionVars().GetTimeZone())
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
 	return hg, nil
 }
 
+// extractColumnTopN moves the values repeated most in the sorted samples to the TopN of the
+// CM sketch, they are encoded in the row format, the same as the values inserted.
+func extractColumnTopN(cms *CMSketch, samples []types.Datum, loc *time.Location) error {
+	topN := newSortedTopN(DefaultNumTopN)
+	for _, sample := range samples {
+		bytes, err := tablecodec.EncodeValue(sample, loc)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		topN.collect(bytes)
+	}
+	// The lengths of the runs in the samples are not the counts of the values.
+	candidates := topN.candidates()
+	for i := range candidates {
+		candidates[i].count = 0
+	}
+	cms.extractTopN(candidates, DefaultNumTopN)
+	return nil
+}
+
 // AnalyzeResult is used to represent analyze result.
 type AnalyzeResult struct {
 	TableID int64
 	Hist    []*Histogram
+	Cms     []*CMSketch
 	Count   int64
 	IsIndex int
-	Err     error
+	// Extended is the extended statistics of the column groups, the result of them has no histogram.
+	Extended []*ExtendedStats
+	Err      error
 }
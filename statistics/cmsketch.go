@@ -0,0 +1,452 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statistics
+
+import (
+	"container/heap"
+	"sort"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/tablecodec"
+	"github.com/pingcap/tidb/util/types"
+	"github.com/pingcap/tipb/go-tipb"
+	"github.com/spaolacci/murmur3"
+)
+
+const (
+	// DefaultCMSketchDepth is the default depth of the CM sketch.
+	DefaultCMSketchDepth = 5
+	// DefaultCMSketchWidth is the default width of the CM sketch.
+	DefaultCMSketchWidth = 2048
+	// DefaultNumTopN is the default number of the most frequent values kept by the CM sketch.
+	DefaultNumTopN = 20
+)
+
+// CMSketch is used to estimate the count of a value. The most frequent values are kept in the
+// TopN with their counts, the other values are counted by a Count-Min sketch.
+// See https://en.wikipedia.org/wiki/Count%E2%80%93min_sketch
+type CMSketch struct {
+	depth int32
+	width int32
+	count uint64
+	table [][]uint32
+	// topN is the counts of the most frequent values, they are not counted in the table.
+	topN map[string]uint64
+}
+
+// NewCMSketch returns a new CM sketch.
+func NewCMSketch(d, w int32) *CMSketch {
+	tbl := make([][]uint32, d)
+	for i := range tbl {
+		tbl[i] = make([]uint32, w)
+	}
+	return &CMSketch{depth: d, width: w, table: tbl}
+}
+
+func (c *CMSketch) hash(bytes []byte) (uint64, uint64) {
+	return murmur3.Sum128(bytes)
+}
+
+// InsertBytes inserts the bytes into the CM sketch.
+func (c *CMSketch) InsertBytes(bytes []byte) {
+	c.insertBytesN(bytes, 1)
+}
+
+func (c *CMSketch) insertBytesN(bytes []byte, n uint64) {
+	c.count += n
+	if _, ok := c.topN[string(bytes)]; ok {
+		c.topN[string(bytes)] += n
+		return
+	}
+	h1, h2 := c.hash(bytes)
+	for i := range c.table {
+		j := (h1 + h2*uint64(i)) % uint64(c.width)
+		c.table[i][j] += uint32(n)
+	}
+}
+
+// queryBytes returns the estimated count of the bytes.
+func (c *CMSketch) queryBytes(bytes []byte) uint64 {
+	if cnt, ok := c.topN[string(bytes)]; ok {
+		return cnt
+	}
+	return c.queryTable(bytes)
+}
+
+func (c *CMSketch) queryTable(bytes []byte) uint64 {
+	h1, h2 := c.hash(bytes)
+	min := uint32(0)
+	for i := range c.table {
+		j := (h1 + h2*uint64(i)) % uint64(c.width)
+		if i == 0 || c.table[i][j] < min {
+			min = c.table[i][j]
+		}
+	}
+	return uint64(min)
+}
+
+// queryColumnValue returns the estimated count of the column value, the value is encoded in
+// the row format, the same as the values inserted into the sketch of a column.
+func (c *CMSketch) queryColumnValue(sc *variable.StatementContext, value types.Datum) (uint64, error) {
+	loc := sc.TimeZone
+	if loc == nil {
+		loc = time.Local
+	}
+	bytes, err := tablecodec.EncodeValue(value, loc)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	return c.queryBytes(bytes), nil
+}
+
+// TotalCount returns the count of all the values inserted.
+func (c *CMSketch) TotalCount() uint64 {
+	return c.count
+}
+
+// MergeCMSketch merges two CM sketches, the TopN of the result is chosen from the TopN of both.
+func (c *CMSketch) MergeCMSketch(rc *CMSketch) error {
+	if c.depth != rc.depth || c.width != rc.width {
+		return errors.New("Dimensions of Count-Min Sketch should be the same")
+	}
+	candidates := make([]valueRun, 0, len(c.topN)+len(rc.topN))
+	// The values of TopN are put back into the tables to be merged.
+	for _, s := range []*CMSketch{c, rc} {
+		for key, cnt := range s.topN {
+			candidates = append(candidates, valueRun{value: []byte(key)})
+			h1, h2 := c.hash([]byte(key))
+			for i := range s.table {
+				j := (h1 + h2*uint64(i)) % uint64(c.width)
+				c.table[i][j] += uint32(cnt)
+			}
+		}
+	}
+	c.topN = nil
+	c.count += rc.count
+	for i := range c.table {
+		for j := range c.table[i] {
+			c.table[i][j] += rc.table[i][j]
+		}
+	}
+	c.extractTopN(candidates, DefaultNumTopN)
+	return nil
+}
+
+// extractTopN moves at most n values of the candidates with the largest counts from the table
+// to the TopN. The count of a candidate is estimated by the table if it's unknown. The values
+// counted only once are not worth keeping.
+func (c *CMSketch) extractTopN(candidates []valueRun, n int) {
+	seen := make(map[string]struct{}, len(candidates))
+	counts := make([]valueRun, 0, len(candidates))
+	for _, cand := range candidates {
+		if _, ok := seen[string(cand.value)]; ok {
+			continue
+		}
+		seen[string(cand.value)] = struct{}{}
+		if _, ok := c.topN[string(cand.value)]; ok {
+			continue
+		}
+		cnt := c.queryTable(cand.value)
+		if cand.count > 0 && cand.count < cnt {
+			cnt = cand.count
+		}
+		if cnt > 1 {
+			counts = append(counts, valueRun{value: cand.value, count: cnt})
+		}
+	}
+	sort.Slice(counts, func(i, j int) bool { return counts[i].count > counts[j].count })
+	if c.topN == nil {
+		c.topN = make(map[string]uint64, n)
+	}
+	for _, vc := range counts {
+		if len(c.topN) >= n {
+			break
+		}
+		h1, h2 := c.hash(vc.value)
+		for i := range c.table {
+			j := (h1 + h2*uint64(i)) % uint64(c.width)
+			c.table[i][j] -= uint32(vc.count)
+		}
+		c.topN[string(vc.value)] = vc.count
+	}
+}
+
+// sortedTopN collects the candidates of the TopN from the sorted values, which are the values
+// of the longest runs.
+type sortedTopN struct {
+	n     int
+	last  []byte
+	run   uint64
+	runs  runHeap
+	empty bool
+}
+
+// valueRun is a value with its count, the count is 0 if it's unknown.
+type valueRun struct {
+	value []byte
+	count uint64
+}
+
+// runHeap is a min-heap of the runs, the shortest run is at the top.
+type runHeap []valueRun
+
+func (h runHeap) Len() int            { return len(h) }
+func (h runHeap) Less(i, j int) bool  { return h[i].count < h[j].count }
+func (h runHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *runHeap) Push(x interface{}) { *h = append(*h, x.(valueRun)) }
+
+func (h *runHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	run := old[n-1]
+	*h = old[:n-1]
+	return run
+}
+
+func newSortedTopN(n int) *sortedTopN {
+	return &sortedTopN{n: n, empty: true}
+}
+
+func (t *sortedTopN) collect(value []byte) {
+	if !t.empty && string(value) == string(t.last) {
+		t.run++
+		return
+	}
+	t.finishRun()
+	t.last = append(t.last[:0], value...)
+	t.run = 1
+	t.empty = false
+}
+
+func (t *sortedTopN) finishRun() {
+	if t.empty || t.run <= 1 {
+		return
+	}
+	if len(t.runs) < t.n {
+		heap.Push(&t.runs, valueRun{value: append([]byte(nil), t.last...), count: t.run})
+	} else if t.n > 0 && t.runs[0].count < t.run {
+		t.runs[0] = valueRun{value: append([]byte(nil), t.last...), count: t.run}
+		heap.Fix(&t.runs, 0)
+	}
+}
+
+// candidates returns the values of the longest runs with the lengths of the runs.
+func (t *sortedTopN) candidates() []valueRun {
+	t.finishRun()
+	t.empty = true
+	return t.runs
+}
+
+// TopN returns the most frequent values and their counts in the descending order of the counts.
+func (c *CMSketch) TopN() ([][]byte, []uint64) {
+	values := make([][]byte, 0, len(c.topN))
+	for key := range c.topN {
+		values = append(values, []byte(key))
+	}
+	sort.Slice(values, func(i, j int) bool {
+		ci, cj := c.topN[string(values[i])], c.topN[string(values[j])]
+		if ci != cj {
+			return ci > cj
+		}
+		return string(values[i]) < string(values[j])
+	})
+	counts := make([]uint64, 0, len(values))
+	for _, value := range values {
+		counts = append(counts, c.topN[string(value)])
+	}
+	return values, counts
+}
+
+// Equal tests if two CM sketches are equal.
+func (c *CMSketch) Equal(rc *CMSketch) bool {
+	if c == nil || rc == nil {
+		return c == nil && rc == nil
+	}
+	if c.width != rc.width || c.depth != rc.depth || c.count != rc.count || len(c.topN) != len(rc.topN) {
+		return false
+	}
+	for i := range c.table {
+		for j := range c.table[i] {
+			if c.table[i][j] != rc.table[i][j] {
+				return false
+			}
+		}
+	}
+	for key, cnt := range c.topN {
+		if rcnt, ok := rc.topN[key]; !ok || rcnt != cnt {
+			return false
+		}
+	}
+	return true
+}
+
+// The tipb in use has no CM sketch. The messages below are the same as the CMSketch of the later
+// tipb, and the sketches in the analyze responses are put in the unrecognized fields with the
+// field numbers of the later tipb, so they are compatible with the stores that build them.
+const (
+	cmSketchFieldOfIndexResp = 2
+	cmSketchFieldOfCollector = 5
+)
+
+type cmSketchRowPB struct {
+	Counters []uint32 `protobuf:"varint,1,rep,name=counters"`
+}
+
+func (m *cmSketchRowPB) Reset()         { *m = cmSketchRowPB{} }
+func (m *cmSketchRowPB) String() string { return proto.CompactTextString(m) }
+func (*cmSketchRowPB) ProtoMessage()    {}
+
+type cmSketchTopNPB struct {
+	Data  []byte `protobuf:"bytes,1,opt,name=data"`
+	Count uint64 `protobuf:"varint,2,opt,name=count"`
+}
+
+func (m *cmSketchTopNPB) Reset()         { *m = cmSketchTopNPB{} }
+func (m *cmSketchTopNPB) String() string { return proto.CompactTextString(m) }
+func (*cmSketchTopNPB) ProtoMessage()    {}
+
+type cmSketchPB struct {
+	Rows []*cmSketchRowPB  `protobuf:"bytes,1,rep,name=rows"`
+	TopN []*cmSketchTopNPB `protobuf:"bytes,2,rep,name=top_n"`
+}
+
+func (m *cmSketchPB) Reset()         { *m = cmSketchPB{} }
+func (m *cmSketchPB) String() string { return proto.CompactTextString(m) }
+func (*cmSketchPB) ProtoMessage()    {}
+
+// encodeCMSketch encodes the CM sketch to bytes, it's nil if the sketch is nil.
+func encodeCMSketch(c *CMSketch) ([]byte, error) {
+	if c == nil {
+		return nil, nil
+	}
+	p := &cmSketchPB{Rows: make([]*cmSketchRowPB, c.depth)}
+	for i := range c.table {
+		p.Rows[i] = &cmSketchRowPB{Counters: c.table[i]}
+	}
+	values, counts := c.TopN()
+	for i, value := range values {
+		p.TopN = append(p.TopN, &cmSketchTopNPB{Data: value, Count: counts[i]})
+	}
+	data, err := proto.Marshal(p)
+	return data, errors.Trace(err)
+}
+
+// decodeCMSketch decodes the CM sketch from bytes, it returns nil if the data is empty.
+func decodeCMSketch(data []byte) (*CMSketch, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	p := &cmSketchPB{}
+	if err := proto.Unmarshal(data, p); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if len(p.Rows) == 0 {
+		return nil, nil
+	}
+	c := NewCMSketch(int32(len(p.Rows)), int32(len(p.Rows[0].Counters)))
+	for i, row := range p.Rows {
+		if len(row.Counters) != int(c.width) {
+			return nil, errors.New("Rows of Count-Min Sketch should have the same width")
+		}
+		copy(c.table[i], row.Counters)
+	}
+	// The rows of a sketch have the same sum, which is the count of the values not in TopN.
+	for _, cnt := range c.table[0] {
+		c.count += uint64(cnt)
+	}
+	if len(p.TopN) > 0 {
+		c.topN = make(map[string]uint64, len(p.TopN))
+		for _, t := range p.TopN {
+			c.topN[string(t.Data)] = t.Count
+			c.count += t.Count
+		}
+	}
+	return c, nil
+}
+
+// appendCMSketchField appends the encoded CM sketch as the field of a message to the unrecognized fields.
+func appendCMSketchField(unrecognized []byte, field int, c *CMSketch) ([]byte, error) {
+	data, err := encodeCMSketch(c)
+	if err != nil || data == nil {
+		return unrecognized, errors.Trace(err)
+	}
+	b := proto.NewBuffer(unrecognized)
+	if err = b.EncodeVarint(uint64(field)<<3 | proto.WireBytes); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if err = b.EncodeRawBytes(data); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return b.Bytes(), nil
+}
+
+// cmSketchFromField decodes the CM sketch in the field of a message from the unrecognized fields,
+// it returns nil if the field doesn't exist.
+func cmSketchFromField(unrecognized []byte, field int) (*CMSketch, error) {
+	errCorrupted := errors.New("corrupted unrecognized fields")
+	buf := unrecognized
+	for len(buf) > 0 {
+		key, n := proto.DecodeVarint(buf)
+		if n == 0 {
+			return nil, errCorrupted
+		}
+		buf = buf[n:]
+		switch key & 7 {
+		case proto.WireVarint:
+			if _, n = proto.DecodeVarint(buf); n == 0 {
+				return nil, errCorrupted
+			}
+			buf = buf[n:]
+		case proto.WireFixed64, proto.WireFixed32:
+			size := 8
+			if key&7 == proto.WireFixed32 {
+				size = 4
+			}
+			if len(buf) < size {
+				return nil, errCorrupted
+			}
+			buf = buf[size:]
+		case proto.WireBytes:
+			l, n := proto.DecodeVarint(buf)
+			if n == 0 || uint64(len(buf)-n) < l {
+				return nil, errCorrupted
+			}
+			data := buf[n : n+int(l)]
+			buf = buf[n+int(l):]
+			if int(key>>3) == field {
+				return decodeCMSketch(data)
+			}
+		default:
+			return nil, errors.Errorf("unexpected wire type %d", key&7)
+		}
+	}
+	return nil, nil
+}
+
+// SetIndexRespCMSketch sets the CM sketch of the analyze index response.
+func SetIndexRespCMSketch(resp *tipb.AnalyzeIndexResp, c *CMSketch) error {
+	var err error
+	resp.XXX_unrecognized, err = appendCMSketchField(resp.XXX_unrecognized, cmSketchFieldOfIndexResp, c)
+	return errors.Trace(err)
+}
+
+// IndexRespCMSketch returns the CM sketch of the analyze index response, it's nil if the store
+// doesn't build it.
+func IndexRespCMSketch(resp *tipb.AnalyzeIndexResp) (*CMSketch, error) {
+	c, err := cmSketchFromField(resp.XXX_unrecognized, cmSketchFieldOfIndexResp)
+	return c, errors.Trace(err)
+}
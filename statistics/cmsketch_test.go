@@ -0,0 +1,180 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statistics
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/util/codec"
+	"github.com/pingcap/tidb/util/types"
+	"github.com/pingcap/tipb/go-tipb"
+)
+
+var _ = Suite(&testCMSketchSuite{})
+
+type testCMSketchSuite struct {
+}
+
+// buildCMSketchAndMap builds a CM sketch of the zipfian distributed values, the values are
+// inserted in sorted order and the TopN is extracted like the index analyze does.
+func buildCMSketchAndMap(d, w int32, total, imax uint64, s float64) (*CMSketch, map[int64]uint64, error) {
+	zipf := rand.NewZipf(rand.New(rand.NewSource(1)), s, 1, imax)
+	values := make([]int64, 0, total)
+	for i := uint64(0); i < total; i++ {
+		values = append(values, int64(zipf.Uint64()))
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+	b := NewSortedBuilder(nil, 256, 0)
+	b.EnableCMSketch(d, w, DefaultNumTopN)
+	mp := make(map[int64]uint64)
+	for _, v := range values {
+		bytes, err := codec.EncodeKey(nil, types.NewIntDatum(v))
+		if err != nil {
+			return nil, nil, err
+		}
+		if err = b.Iterate(types.NewBytesDatum(bytes)); err != nil {
+			return nil, nil, err
+		}
+		mp[v]++
+	}
+	return b.CMSketch(), mp, nil
+}
+
+func averageAbsoluteError(cms *CMSketch, mp map[int64]uint64) (uint64, error) {
+	var total uint64
+	for num, count := range mp {
+		bytes, err := codec.EncodeKey(nil, types.NewIntDatum(num))
+		if err != nil {
+			return 0, err
+		}
+		estimate := cms.queryBytes(bytes)
+		if estimate > count {
+			total += estimate - count
+		} else {
+			total += count - estimate
+		}
+	}
+	return total / uint64(len(mp)), nil
+}
+
+func (s *testCMSketchSuite) TestCMSketch(c *C) {
+	tests := []struct {
+		zipfFactor float64
+		avgError   uint64
+	}{
+		{zipfFactor: 1.1, avgError: 10},
+		{zipfFactor: 2, avgError: 1},
+		{zipfFactor: 3, avgError: 1},
+	}
+	d, w := int32(5), int32(2048)
+	total, imax := uint64(100000), uint64(1000000)
+	for _, t := range tests {
+		cms, mp, err := buildCMSketchAndMap(d, w, total, imax, t.zipfFactor)
+		c.Assert(err, IsNil)
+		c.Assert(cms.TotalCount(), Equals, total)
+		avg, err := averageAbsoluteError(cms, mp)
+		c.Assert(err, IsNil)
+		c.Check(avg, LessEqual, t.avgError, Commentf("zipf factor %v", t.zipfFactor))
+	}
+}
+
+func (s *testCMSketchSuite) TestTopN(c *C) {
+	cms, mp, err := buildCMSketchAndMap(5, 2048, 100000, 1000000, 1.1)
+	c.Assert(err, IsNil)
+	values, counts := cms.TopN()
+	c.Assert(len(values), Equals, DefaultNumTopN)
+	// The counts of the TopN are exact, and they are the most frequent values.
+	minTopN := uint64(math.MaxUint64)
+	inTopN := make(map[int64]struct{}, len(values))
+	for i, value := range values {
+		_, d, err := codec.DecodeOne(value)
+		c.Assert(err, IsNil)
+		c.Assert(counts[i], Equals, mp[d.GetInt64()])
+		c.Assert(cms.queryBytes(value), Equals, counts[i])
+		if counts[i] < minTopN {
+			minTopN = counts[i]
+		}
+		inTopN[d.GetInt64()] = struct{}{}
+		if i > 0 {
+			c.Assert(counts[i-1] >= counts[i], IsTrue)
+		}
+	}
+	for num, count := range mp {
+		if _, ok := inTopN[num]; !ok {
+			c.Assert(count <= minTopN, IsTrue, Commentf("%d appears %d times", num, count))
+		}
+	}
+}
+
+func (s *testCMSketchSuite) TestCMSketchMerge(c *C) {
+	lcms, lmp, err := buildCMSketchAndMap(5, 2048, 50000, 1000000, 1.1)
+	c.Assert(err, IsNil)
+	rcms, rmp, err := buildCMSketchAndMap(5, 2048, 50000, 1000, 2)
+	c.Assert(err, IsNil)
+	err = lcms.MergeCMSketch(rcms)
+	c.Assert(err, IsNil)
+	c.Assert(lcms.TotalCount(), Equals, uint64(100000))
+	for num, count := range rmp {
+		lmp[num] += count
+	}
+	avg, err := averageAbsoluteError(lcms, lmp)
+	c.Assert(err, IsNil)
+	c.Check(avg, LessEqual, uint64(4))
+	_, counts := lcms.TopN()
+	c.Assert(len(counts), Equals, DefaultNumTopN)
+
+	err = lcms.MergeCMSketch(NewCMSketch(4, 2048))
+	c.Assert(err, NotNil)
+}
+
+func (s *testCMSketchSuite) TestCMSketchCoding(c *C) {
+	cms, _, err := buildCMSketchAndMap(5, 2048, 10000, 1000, 1.1)
+	c.Assert(err, IsNil)
+	data, err := encodeCMSketch(cms)
+	c.Assert(err, IsNil)
+	rcms, err := decodeCMSketch(data)
+	c.Assert(err, IsNil)
+	c.Assert(cms.Equal(rcms), IsTrue)
+
+	data, err = encodeCMSketch(nil)
+	c.Assert(err, IsNil)
+	rcms, err = decodeCMSketch(data)
+	c.Assert(err, IsNil)
+	c.Assert(rcms, IsNil)
+}
+
+func (s *testCMSketchSuite) TestIndexRespCMSketch(c *C) {
+	cms, _, err := buildCMSketchAndMap(5, 2048, 10000, 1000, 1.1)
+	c.Assert(err, IsNil)
+	resp := &tipb.AnalyzeIndexResp{Hist: &tipb.Histogram{Ndv: 1}}
+	err = SetIndexRespCMSketch(resp, cms)
+	c.Assert(err, IsNil)
+	data, err := resp.Marshal()
+	c.Assert(err, IsNil)
+
+	rresp := &tipb.AnalyzeIndexResp{}
+	c.Assert(rresp.Unmarshal(data), IsNil)
+	c.Assert(rresp.Hist.Ndv, Equals, int64(1))
+	rcms, err := IndexRespCMSketch(rresp)
+	c.Assert(err, IsNil)
+	c.Assert(cms.Equal(rcms), IsTrue)
+
+	// The stores which don't build the CM sketch.
+	rcms, err = IndexRespCMSketch(&tipb.AnalyzeIndexResp{})
+	c.Assert(err, IsNil)
+	c.Assert(rcms, IsNil)
+}
@@ -0,0 +1,300 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statistics
+
+import (
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/infoschema"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// JSONTable is the JSON representation of the statistics of a table. The columns and the indices
+// are referred by names, so the statistics can be loaded by a table with the same definition in
+// another cluster.
+type JSONTable struct {
+	DatabaseName  string                 `json:"database_name"`
+	TableName     string                 `json:"table_name"`
+	Columns       map[string]*JSONColumn `json:"columns"`
+	Indices       map[string]*JSONColumn `json:"indices"`
+	ExtendedStats []*JSONExtendedStats   `json:"extended_stats"`
+	Count         int64                  `json:"count"`
+	ModifyCount   int64                  `json:"modify_count"`
+	Version       uint64                 `json:"version"`
+}
+
+// JSONColumn is the JSON representation of the histogram and the CM sketch of a column or an index.
+type JSONColumn struct {
+	NDV               int64        `json:"ndv"`
+	NullCount         int64        `json:"null_count"`
+	LastUpdateVersion uint64       `json:"last_update_version"`
+	Buckets           []JSONBucket `json:"buckets"`
+	// CMSketch is the encoded CM sketch, it's empty if the column has no sketch.
+	CMSketch []byte `json:"cm_sketch"`
+}
+
+// JSONBucket is the JSON representation of a histogram bucket. The bounds of a column bucket are
+// the values converted to blob, and the bounds of an index bucket are the encoded index values.
+type JSONBucket struct {
+	Count      int64  `json:"count"`
+	Repeats    int64  `json:"repeats"`
+	LowerBound []byte `json:"lower_bound"`
+	UpperBound []byte `json:"upper_bound"`
+}
+
+// JSONExtendedStats is the JSON representation of the extended statistics of a column group.
+type JSONExtendedStats struct {
+	Columns    []string `json:"columns"`
+	NDV        int64    `json:"ndv"`
+	Dependency float64  `json:"dependency"`
+}
+
+func dumpJSONColumn(sc *variable.StatementContext, hg *Histogram, cms *CMSketch, isIndex bool) (*JSONColumn, error) {
+	data, err := encodeCMSketch(cms)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	jsonCol := &JSONColumn{
+		NDV:               hg.NDV,
+		NullCount:         hg.NullCount,
+		LastUpdateVersion: hg.LastUpdateVersion,
+		Buckets:           make([]JSONBucket, 0, len(hg.Buckets)),
+		CMSketch:          data,
+	}
+	for _, bucket := range hg.Buckets {
+		lowerBound, upperBound := bucket.LowerBound, bucket.UpperBound
+		if !isIndex {
+			lowerBound, err = lowerBound.ConvertTo(sc, types.NewFieldType(mysql.TypeBlob))
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			upperBound, err = upperBound.ConvertTo(sc, types.NewFieldType(mysql.TypeBlob))
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+		jsonCol.Buckets = append(jsonCol.Buckets, JSONBucket{
+			Count:      bucket.Count,
+			Repeats:    bucket.Repeats,
+			LowerBound: lowerBound.GetBytes(),
+			UpperBound: upperBound.GetBytes(),
+		})
+	}
+	return jsonCol, nil
+}
+
+func histogramFromJSON(sc *variable.StatementContext, id int64, jsonCol *JSONColumn, tp *types.FieldType) (*Histogram, *CMSketch, error) {
+	hg := &Histogram{
+		ID:                id,
+		NDV:               jsonCol.NDV,
+		NullCount:         jsonCol.NullCount,
+		LastUpdateVersion: jsonCol.LastUpdateVersion,
+		Buckets:           make([]Bucket, 0, len(jsonCol.Buckets)),
+	}
+	for _, bucket := range jsonCol.Buckets {
+		lowerBound, upperBound := types.NewBytesDatum(bucket.LowerBound), types.NewBytesDatum(bucket.UpperBound)
+		if tp != nil {
+			var err error
+			lowerBound, err = lowerBound.ConvertTo(sc, tp)
+			if err != nil {
+				return nil, nil, errors.Trace(err)
+			}
+			upperBound, err = upperBound.ConvertTo(sc, tp)
+			if err != nil {
+				return nil, nil, errors.Trace(err)
+			}
+		}
+		hg.Buckets = append(hg.Buckets, Bucket{
+			Count:      bucket.Count,
+			Repeats:    bucket.Repeats,
+			LowerBound: lowerBound,
+			UpperBound: upperBound,
+		})
+	}
+	cms, err := decodeCMSketch(jsonCol.CMSketch)
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	return hg, cms, nil
+}
+
+// DumpStatsToJSON dumps the statistics of the table in the cache to JSON.
+func (h *Handle) DumpStatsToJSON(dbName string, tableInfo *model.TableInfo) (*JSONTable, error) {
+	tbl := h.GetTableStats(tableInfo.ID)
+	jsonTbl := &JSONTable{
+		DatabaseName: dbName,
+		TableName:    tableInfo.Name.L,
+		Columns:      make(map[string]*JSONColumn, len(tbl.Columns)),
+		Indices:      make(map[string]*JSONColumn, len(tbl.Indices)),
+		Count:        tbl.Count,
+		ModifyCount:  tbl.ModifyCount,
+		Version:      tbl.Version,
+	}
+	sc := h.ctx.GetSessionVars().StmtCtx
+	for _, colInfo := range tableInfo.Columns {
+		col, ok := tbl.Columns[colInfo.ID]
+		if !ok {
+			continue
+		}
+		jsonCol, err := dumpJSONColumn(sc, &col.Histogram, col.CMSketch, false)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		jsonTbl.Columns[colInfo.Name.L] = jsonCol
+	}
+	for _, idxInfo := range tableInfo.Indices {
+		idx, ok := tbl.Indices[idxInfo.ID]
+		if !ok {
+			continue
+		}
+		jsonIdx, err := dumpJSONColumn(sc, &idx.Histogram, idx.CMSketch, true)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		jsonTbl.Indices[idxInfo.Name.L] = jsonIdx
+	}
+	for _, stats := range tbl.ExtendedStats {
+		names := make([]string, 0, len(stats.ColIDs))
+		for _, id := range stats.ColIDs {
+			colInfo := findColumnInfoByID(tableInfo, id)
+			if colInfo == nil {
+				return nil, errors.Errorf("column %d of table %s is not found", id, tableInfo.Name)
+			}
+			names = append(names, colInfo.Name.L)
+		}
+		jsonTbl.ExtendedStats = append(jsonTbl.ExtendedStats, &JSONExtendedStats{
+			Columns:    names,
+			NDV:        stats.NDV,
+			Dependency: stats.Dependency,
+		})
+	}
+	return jsonTbl, nil
+}
+
+// TableStatsFromJSON builds the statistics of the table from JSON, the column and index IDs are
+// the ones of the tableInfo.
+func TableStatsFromJSON(sc *variable.StatementContext, tableInfo *model.TableInfo, jsonTbl *JSONTable) (*Table, error) {
+	tbl := &Table{
+		TableID:     tableInfo.ID,
+		Columns:     make(map[int64]*Column, len(jsonTbl.Columns)),
+		Indices:     make(map[int64]*Index, len(jsonTbl.Indices)),
+		Count:       jsonTbl.Count,
+		ModifyCount: jsonTbl.ModifyCount,
+		Version:     jsonTbl.Version,
+	}
+	for name, jsonCol := range jsonTbl.Columns {
+		colInfo := findColumnInfoByName(tableInfo, name)
+		if colInfo == nil {
+			return nil, errors.Errorf("column %s of table %s is not found", name, tableInfo.Name)
+		}
+		hg, cms, err := histogramFromJSON(sc, colInfo.ID, jsonCol, &colInfo.FieldType)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		tbl.Columns[colInfo.ID] = &Column{Histogram: *hg, CMSketch: cms, Info: colInfo}
+	}
+	for name, jsonIdx := range jsonTbl.Indices {
+		var idxInfo *model.IndexInfo
+		for _, idx := range tableInfo.Indices {
+			if idx.Name.L == strings.ToLower(name) && idx.State == model.StatePublic {
+				idxInfo = idx
+				break
+			}
+		}
+		if idxInfo == nil {
+			return nil, errors.Errorf("index %s of table %s is not found", name, tableInfo.Name)
+		}
+		hg, cms, err := histogramFromJSON(sc, idxInfo.ID, jsonIdx, nil)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		tbl.Indices[idxInfo.ID] = &Index{Histogram: *hg, CMSketch: cms, Info: idxInfo}
+	}
+	for _, jsonStats := range jsonTbl.ExtendedStats {
+		colIDs := make([]int64, 0, len(jsonStats.Columns))
+		for _, name := range jsonStats.Columns {
+			colInfo := findColumnInfoByName(tableInfo, name)
+			if colInfo == nil {
+				return nil, errors.Errorf("column %s of table %s is not found", name, tableInfo.Name)
+			}
+			colIDs = append(colIDs, colInfo.ID)
+		}
+		tbl.ExtendedStats = append(tbl.ExtendedStats, &ExtendedStats{
+			ColIDs:     colIDs,
+			NDV:        jsonStats.NDV,
+			Dependency: jsonStats.Dependency,
+		})
+	}
+	return tbl, nil
+}
+
+// LoadStatsFromJSON saves the statistics in JSON to storage as the statistics of the table named
+// in it, the versions are assigned again. The statistics are loaded at once if the handle has no
+// lease, otherwise they are loaded by the next update of the handle.
+func (h *Handle) LoadStatsFromJSON(ctx context.Context, is infoschema.InfoSchema, jsonTbl *JSONTable) error {
+	table, err := is.TableByName(model.NewCIStr(jsonTbl.DatabaseName), model.NewCIStr(jsonTbl.TableName))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	tableInfo := table.Meta()
+	tbl, err := TableStatsFromJSON(ctx.GetSessionVars().StmtCtx, tableInfo, jsonTbl)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for _, col := range tbl.Columns {
+		err = SaveStatsToStorage(ctx, tbl.TableID, tbl.Count, 0, &col.Histogram, col.CMSketch)
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+	for _, idx := range tbl.Indices {
+		err = SaveStatsToStorage(ctx, tbl.TableID, tbl.Count, 1, &idx.Histogram, idx.CMSketch)
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+	if len(tbl.ExtendedStats) > 0 {
+		err = SaveExtendedStatsToStorage(ctx, tbl.TableID, tbl.ExtendedStats)
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+	if h.Lease > 0 {
+		return nil
+	}
+	return errors.Trace(h.Update(is))
+}
+
+func findColumnInfoByID(tableInfo *model.TableInfo, id int64) *model.ColumnInfo {
+	for _, col := range tableInfo.Columns {
+		if col.ID == id && col.State == model.StatePublic {
+			return col
+		}
+	}
+	return nil
+}
+
+func findColumnInfoByName(tableInfo *model.TableInfo, name string) *model.ColumnInfo {
+	for _, col := range tableInfo.Columns {
+		if col.Name.L == strings.ToLower(name) && col.State == model.StatePublic {
+			return col
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statistics_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/statistics"
+	"github.com/pingcap/tidb/util/testkit"
+)
+
+func (s *testStatsCacheSuite) TestDumpAndLoadStats(c *C) {
+	defer cleanEnv(c, s.store, s.do)
+	testKit := testkit.NewTestKit(c, s.store)
+	testKit.MustExec("use test")
+	testKit.MustExec("create table t (a int, b varchar(10), c datetime)")
+	for i := 0; i < 100; i++ {
+		testKit.MustExec(fmt.Sprintf("insert into t values (%d, 'b%d', '2017-11-%02d 10:00:00')", i, i%10, i%30+1))
+	}
+	testKit.MustExec("create index idx on t(b, a)")
+	testKit.MustExec("analyze table t columns (a, b)")
+	is := s.do.InfoSchema()
+	tbl, err := is.TableByName(model.NewCIStr("test"), model.NewCIStr("t"))
+	c.Assert(err, IsNil)
+	tableInfo := tbl.Meta()
+	h := s.do.StatsHandle()
+	statsTbl := h.GetTableStats(tableInfo.ID)
+
+	jsonTbl, err := h.DumpStatsToJSON("test", tableInfo)
+	c.Assert(err, IsNil)
+	c.Assert(jsonTbl.Count, Equals, int64(100))
+	c.Assert(jsonTbl.Columns, HasLen, 3)
+	c.Assert(jsonTbl.Indices, HasLen, 1)
+	c.Assert(jsonTbl.ExtendedStats, HasLen, 1)
+	c.Assert(jsonTbl.ExtendedStats[0].Columns, DeepEquals, []string{"a", "b"})
+	data, err := json.Marshal(jsonTbl)
+	c.Assert(err, IsNil)
+	loadTbl := &statistics.JSONTable{}
+	c.Assert(json.Unmarshal(data, loadTbl), IsNil)
+	sc := testKit.Se.GetSessionVars().StmtCtx
+	jsonStatsTbl, err := statistics.TableStatsFromJSON(sc, tableInfo, loadTbl)
+	c.Assert(err, IsNil)
+	assertTableEqual(c, statsTbl, jsonStatsTbl)
+	for id, col := range statsTbl.Columns {
+		c.Assert(col.CMSketch.Equal(jsonStatsTbl.Columns[id].CMSketch), IsTrue)
+	}
+	for id, idx := range statsTbl.Indices {
+		c.Assert(idx.CMSketch.Equal(jsonStatsTbl.Indices[id].CMSketch), IsTrue)
+	}
+	c.Assert(jsonStatsTbl.ExtendedStats, DeepEquals, statsTbl.ExtendedStats)
+
+	// Load the dumped statistics by the statement.
+	file, err := ioutil.TempFile("", "stats")
+	c.Assert(err, IsNil)
+	defer os.Remove(file.Name())
+	_, err = file.Write(data)
+	c.Assert(err, IsNil)
+	c.Assert(file.Close(), IsNil)
+	testKit.MustExec("drop stats t")
+	c.Assert(h.GetTableStats(tableInfo.ID).Pseudo, IsTrue)
+	testKit.MustExec(fmt.Sprintf("load stats '%s'", file.Name()))
+	loadedTbl := h.GetTableStats(tableInfo.ID)
+	c.Assert(loadedTbl.Pseudo, IsFalse)
+	c.Assert(loadedTbl.Count, Equals, int64(100))
+	assertTableEqual(c, statsTbl, loadedTbl)
+	c.Assert(loadedTbl.ExtendedStats, DeepEquals, statsTbl.ExtendedStats)
+
+	// The statistics can't be loaded by a table with another definition.
+	testKit.MustExec("alter table t drop column c")
+	_, err = testKit.Exec(fmt.Sprintf("load stats '%s'", file.Name()))
+	c.Assert(err, NotNil)
+	_, err = testKit.Exec("load stats '/non/existent/file.json'")
+	c.Assert(err, NotNil)
+}
@@ -0,0 +1,232 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statistics
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/util/codec"
+	"github.com/pingcap/tidb/util/sqlexec"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// ExtendedStats is the statistics of a group of correlated columns, it's collected by analyze
+// only if the group is declared.
+type ExtendedStats struct {
+	// ColIDs are the IDs of the columns in the declared order.
+	ColIDs []int64
+	// NDV is the number of distinct values of the columns as a whole.
+	NDV int64
+	// Dependency is the degree of the functional dependency of the last column on the other
+	// columns. It's 1 if the other columns determine the last one, and 0 if they are independent.
+	Dependency float64
+}
+
+// extendedStatsKey is the key of a column group in mysql.stats_extended.
+func extendedStatsKey(colIDs []int64) string {
+	ids := make([]string, 0, len(colIDs))
+	for _, id := range colIDs {
+		ids = append(ids, strconv.FormatInt(id, 10))
+	}
+	return strings.Join(ids, ",")
+}
+
+func parseExtendedStatsKey(key string) ([]int64, error) {
+	strs := strings.Split(key, ",")
+	colIDs := make([]int64, 0, len(strs))
+	for _, s := range strs {
+		id, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		colIDs = append(colIDs, id)
+	}
+	return colIDs, nil
+}
+
+// dependencyDegree estimates the degree of the functional dependency by the NDVs. If the prefix
+// columns determine the last column, the NDV of all the columns equals the NDV of the prefix, and
+// if they are independent, it's the product of the NDVs of the prefix and the last column.
+func dependencyDegree(prefixNDV, lastNDV, ndv int64) float64 {
+	if ndv <= 0 || lastNDV <= 1 {
+		return 1
+	}
+	ratio := float64(prefixNDV) / float64(ndv)
+	independent := 1 / float64(lastNDV)
+	degree := (ratio - independent) / (1 - independent)
+	if degree < 0 {
+		return 0
+	}
+	if degree > 1 {
+		return 1
+	}
+	return degree
+}
+
+// extendedStatsCollector estimates the NDVs needed by the extended statistics of a column group.
+type extendedStatsCollector struct {
+	colIDs  []int64
+	offsets []int
+	all     *FMSketch
+	prefix  *FMSketch
+	last    *FMSketch
+}
+
+func (c *extendedStatsCollector) collect(row []types.Datum) error {
+	values := make([]types.Datum, 0, len(c.offsets))
+	for _, offset := range c.offsets {
+		values = append(values, row[offset])
+	}
+	prefix, err := codec.EncodeValue(nil, values[:len(values)-1]...)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	err = c.prefix.InsertValue(types.NewBytesDatum(prefix))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	all, err := codec.EncodeValue(prefix, values[len(values)-1])
+	if err != nil {
+		return errors.Trace(err)
+	}
+	err = c.all.InsertValue(types.NewBytesDatum(all))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(c.last.InsertValue(values[len(values)-1]))
+}
+
+// BuildExtendedStats builds the extended statistics of the column groups from the records, cols
+// are the IDs of the columns of the records.
+func BuildExtendedStats(records ast.RecordSet, cols []int64, groups [][]int64, maxSketchSize int) ([]*ExtendedStats, error) {
+	collectors := make([]*extendedStatsCollector, 0, len(groups))
+	for _, group := range groups {
+		if len(group) < 2 {
+			return nil, errors.Errorf("column group %v should have at least two columns", group)
+		}
+		c := &extendedStatsCollector{
+			colIDs: group,
+			all:    NewFMSketch(maxSketchSize),
+			prefix: NewFMSketch(maxSketchSize),
+			last:   NewFMSketch(maxSketchSize),
+		}
+		for _, id := range group {
+			offset := -1
+			for i, col := range cols {
+				if col == id {
+					offset = i
+					break
+				}
+			}
+			if offset == -1 {
+				return nil, errors.Errorf("column %d of group %v is not in the records", id, group)
+			}
+			c.offsets = append(c.offsets, offset)
+		}
+		collectors = append(collectors, c)
+	}
+	for {
+		row, err := records.Next()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if row == nil {
+			break
+		}
+		for _, c := range collectors {
+			if err = c.collect(row.Data); err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+	}
+	stats := make([]*ExtendedStats, 0, len(collectors))
+	for _, c := range collectors {
+		ndv := c.all.NDV()
+		stats = append(stats, &ExtendedStats{
+			ColIDs:     c.colIDs,
+			NDV:        ndv,
+			Dependency: dependencyDegree(c.prefix.NDV(), c.last.NDV(), ndv),
+		})
+	}
+	return stats, nil
+}
+
+// SaveExtendedStatsToStorage saves the extended statistics to storage, the column groups not
+// saved before are declared by the way.
+func SaveExtendedStatsToStorage(ctx context.Context, tableID int64, stats []*ExtendedStats) error {
+	exec := ctx.(sqlexec.SQLExecutor)
+	_, err := exec.Execute("begin")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	version := ctx.Txn().StartTS()
+	// Update the version to make the stats reloaded.
+	_, err = exec.Execute(fmt.Sprintf("update mysql.stats_meta set version = %d where table_id = %d", version, tableID))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for _, s := range stats {
+		replaceSQL := fmt.Sprintf("replace into mysql.stats_extended (table_id, column_ids, ndv, dependency, version) values (%d, '%s', %d, %v, %d)",
+			tableID, extendedStatsKey(s.ColIDs), s.NDV, s.Dependency, version)
+		_, err = exec.Execute(replaceSQL)
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+	_, err = exec.Execute("commit")
+	return errors.Trace(err)
+}
+
+// extendedStatsFromStorage loads the extended statistics of the table, the column groups that
+// have a dropped column are skipped.
+func (h *Handle) extendedStatsFromStorage(tableInfo *model.TableInfo) ([]*ExtendedStats, error) {
+	selSQL := fmt.Sprintf("select column_ids, ndv, dependency from mysql.stats_extended where table_id = %d", tableInfo.ID)
+	rows, _, err := h.ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(h.ctx, selSQL)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	var stats []*ExtendedStats
+	for _, row := range rows {
+		colIDs, err := parseExtendedStatsKey(row.Data[0].GetString())
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if !allColumnsExist(tableInfo, colIDs) {
+			log.Warnf("[stats] column group %s of table %s has a dropped column.", row.Data[0].GetString(), tableInfo.Name)
+			continue
+		}
+		stats = append(stats, &ExtendedStats{
+			ColIDs:     colIDs,
+			NDV:        row.Data[1].GetInt64(),
+			Dependency: row.Data[2].GetFloat64(),
+		})
+	}
+	return stats, nil
+}
+
+func allColumnsExist(tableInfo *model.TableInfo, colIDs []int64) bool {
+	for _, id := range colIDs {
+		if findColumnInfoByID(tableInfo, id) == nil {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,79 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statistics_test
+
+import (
+	"fmt"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/util/testkit"
+)
+
+func (s *testStatsCacheSuite) TestExtendedStats(c *C) {
+	defer cleanEnv(c, s.store, s.do)
+	testKit := testkit.NewTestKit(c, s.store)
+	testKit.MustExec("use test")
+	testKit.MustExec("create table t (city int, state int, x int)")
+	// The state is determined by the city, and x is independent of them.
+	for i := 0; i < 100; i++ {
+		testKit.MustExec(fmt.Sprintf("insert into t values (%d, %d, %d)", i%20, i%20/5, i%7))
+	}
+	_, err := testKit.Exec("analyze table t columns (city, city)")
+	c.Assert(err, NotNil)
+	_, err = testKit.Exec("analyze table t columns (city, y)")
+	c.Assert(err, NotNil)
+
+	testKit.MustExec("analyze table t columns (city, state), (city, x)")
+	is := s.do.InfoSchema()
+	tbl, err := is.TableByName(model.NewCIStr("test"), model.NewCIStr("t"))
+	c.Assert(err, IsNil)
+	tableInfo := tbl.Meta()
+	statsTbl := s.do.StatsHandle().GetTableStats(tableInfo.ID)
+	c.Assert(statsTbl.ExtendedStats, HasLen, 2)
+	for _, stats := range statsTbl.ExtendedStats {
+		switch stats.ColIDs[1] {
+		case tableInfo.Columns[1].ID:
+			c.Assert(stats.NDV, Equals, int64(20))
+			c.Assert(stats.Dependency, Equals, 1.0)
+		case tableInfo.Columns[2].ID:
+			c.Assert(stats.NDV, Equals, int64(100))
+			c.Assert(stats.Dependency < 0.1, IsTrue, Commentf("dependency %v", stats.Dependency))
+		default:
+			c.Fatalf("unexpected column group %v", stats.ColIDs)
+		}
+	}
+
+	// The declared groups are collected by the later analyze, and dropped with the stats.
+	testKit.MustExec("insert into t values (100, 100, 100)")
+	testKit.MustExec("analyze table t")
+	statsTbl = s.do.StatsHandle().GetTableStats(tableInfo.ID)
+	c.Assert(statsTbl.ExtendedStats, HasLen, 2)
+	for _, stats := range statsTbl.ExtendedStats {
+		if stats.ColIDs[1] == tableInfo.Columns[1].ID {
+			c.Assert(stats.NDV, Equals, int64(21))
+		}
+	}
+	testKit.MustExec("drop stats t")
+	testKit.MustQuery("select count(*) from mysql.stats_extended").Check(testkit.Rows("0"))
+
+	// The groups with a dropped column are not loaded.
+	testKit.MustExec("analyze table t columns (city, x)")
+	c.Assert(s.do.StatsHandle().GetTableStats(tableInfo.ID).ExtendedStats, HasLen, 1)
+	testKit.MustExec("alter table t drop column x")
+	is = s.do.InfoSchema()
+	s.do.StatsHandle().Clear()
+	c.Assert(s.do.StatsHandle().Update(is), IsNil)
+	c.Assert(s.do.StatsHandle().GetTableStats(tableInfo.ID).ExtendedStats, HasLen, 0)
+}
@@ -0,0 +1,294 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statistics
+
+import (
+	"math"
+	"sort"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// maxQueryFeedback is the max number of the feedback waiting to update the statistics, the
+// feedback is dropped if there are more.
+const maxQueryFeedback = 1024
+
+// feedback is the actual row count of a range, the range includes the lower bound and excludes
+// the upper bound.
+type feedback struct {
+	lower types.Datum
+	upper types.Datum
+	count int64
+}
+
+// QueryFeedback is the actual row counts of the ranges scanned by a query, it's used to refine
+// the histogram which estimates the ranges.
+type QueryFeedback struct {
+	tableID int64
+	histID  int64
+	isIndex bool
+	// version is the version of the histogram when the query is executed, the feedback is
+	// dropped if the histogram is updated since then.
+	version  uint64
+	feedback []feedback
+	valid    bool
+}
+
+// NewQueryFeedback returns a feedback of the scan estimated by the histogram, it's valid once
+// the ranges are set.
+func NewQueryFeedback(tableID int64, hist *Histogram, isIndex bool) *QueryFeedback {
+	return &QueryFeedback{
+		tableID: tableID,
+		histID:  hist.ID,
+		isIndex: isIndex,
+		version: hist.LastUpdateVersion,
+	}
+}
+
+// SetIntRanges sets the ranges of the integer handle scanned by the query.
+func (q *QueryFeedback) SetIntRanges(ranges []types.IntColumnRange) {
+	if q == nil {
+		return
+	}
+	q.feedback = make([]feedback, 0, len(ranges))
+	for _, ran := range ranges {
+		upper := types.MaxValueDatum()
+		if ran.HighVal != math.MaxInt64 {
+			upper = types.NewIntDatum(ran.HighVal + 1)
+		}
+		q.feedback = append(q.feedback, feedback{lower: types.NewIntDatum(ran.LowVal), upper: upper})
+	}
+	sort.Slice(q.feedback, func(i, j int) bool { return q.feedback[i].lower.GetInt64() < q.feedback[j].lower.GetInt64() })
+	q.valid = true
+}
+
+// SetIndexRange sets the range of the encoded index values scanned by the query.
+func (q *QueryFeedback) SetIndexRange(lower, upper []byte) {
+	if q == nil {
+		return
+	}
+	q.feedback = []feedback{{lower: types.NewBytesDatum(lower), upper: types.NewBytesDatum(upper)}}
+	q.valid = true
+}
+
+// UpdateByHandle counts the row of the handle in the range it belongs to.
+func (q *QueryFeedback) UpdateByHandle(handle int64) {
+	if q == nil || !q.valid {
+		return
+	}
+	i := sort.Search(len(q.feedback), func(i int) bool { return q.feedback[i].lower.GetInt64() > handle }) - 1
+	if i < 0 {
+		q.valid = false
+		return
+	}
+	q.feedback[i].count++
+}
+
+// Update counts the rows of the only range.
+func (q *QueryFeedback) Update(count int64) {
+	if q == nil || !q.valid {
+		return
+	}
+	if len(q.feedback) != 1 {
+		q.valid = false
+		return
+	}
+	q.feedback[0].count += count
+}
+
+// Invalidate drops the feedback, it's called if the rows scanned are not the ones in the ranges.
+func (q *QueryFeedback) Invalidate() {
+	if q != nil {
+		q.valid = false
+	}
+}
+
+// StoreQueryFeedback stores the feedback of a finished scan, the histograms are updated by it
+// asynchronously. The feedback is dropped if there is too much feedback waiting.
+func (h *Handle) StoreQueryFeedback(q *QueryFeedback) {
+	if q == nil || !q.valid {
+		return
+	}
+	select {
+	case h.feedbackCh <- q:
+	default:
+	}
+}
+
+// UpdateStatsByLocalFeedback updates the histograms in the cache by the stored feedback.
+func (h *Handle) UpdateStatsByLocalFeedback() {
+	tables := make(map[int64]*Table)
+	for {
+		var q *QueryFeedback
+		select {
+		case q = <-h.feedbackCh:
+		default:
+		}
+		if q == nil {
+			break
+		}
+		tbl, ok := tables[q.tableID]
+		if !ok {
+			tbl, ok = h.statsCache.Load().(statsCache)[q.tableID]
+			if !ok {
+				continue
+			}
+			tbl = tbl.copy()
+			tables[q.tableID] = tbl
+		}
+		err := tbl.updateByFeedback(h.ctx.GetSessionVars().StmtCtx, q)
+		if err != nil {
+			log.Warnf("[stats] update the stats of table %d by feedback fail: %v", q.tableID, errors.ErrorStack(err))
+		}
+	}
+	if len(tables) == 0 {
+		return
+	}
+	updated := make([]*Table, 0, len(tables))
+	for _, tbl := range tables {
+		updated = append(updated, tbl)
+	}
+	h.UpdateTableStats(updated, nil)
+}
+
+// updateByFeedback updates the histogram of the feedback, the table should be a copy.
+func (t *Table) updateByFeedback(sc *variable.StatementContext, q *QueryFeedback) error {
+	if q.isIndex {
+		idx, ok := t.Indices[q.histID]
+		if !ok || idx.LastUpdateVersion != q.version {
+			return nil
+		}
+		hg, err := idx.Histogram.updateByFeedback(sc, q.feedback)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		t.Indices[q.histID] = &Index{Histogram: *hg, CMSketch: idx.CMSketch, Info: idx.Info}
+		return nil
+	}
+	col, ok := t.Columns[q.histID]
+	if !ok || col.LastUpdateVersion != q.version {
+		return nil
+	}
+	hg, err := col.Histogram.updateByFeedback(sc, q.feedback)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	t.Columns[q.histID] = &Column{Histogram: *hg, CMSketch: col.CMSketch, Info: col.Info}
+	return nil
+}
+
+// updateByFeedback returns a new histogram whose bucket counts are adjusted by the actual row
+// counts of the ranges. The difference between the actual and the estimated count of a range is
+// shared by the buckets overlapping with the range in proportion to their estimated counts in it.
+func (hg *Histogram) updateByFeedback(sc *variable.StatementContext, fbs []feedback) (*Histogram, error) {
+	counts := make([]float64, len(hg.Buckets))
+	repeats := make([]float64, len(hg.Buckets))
+	for i, bkt := range hg.Buckets {
+		counts[i] = float64(bkt.Count)
+		if i > 0 {
+			counts[i] -= float64(hg.Buckets[i-1].Count)
+		}
+		repeats[i] = float64(bkt.Repeats)
+	}
+	fractions := make([]float64, len(hg.Buckets))
+	for _, fb := range fbs {
+		var expected, weight float64
+		for i := range hg.Buckets {
+			frac, err := hg.Buckets[i].overlapFraction(sc, fb)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			fractions[i] = frac
+			expected += frac * counts[i]
+			weight += frac
+		}
+		if weight == 0 {
+			continue
+		}
+		log.Debugf("[stats] histogram %d estimates %v rows in the range, and %d rows are scanned.", hg.ID, expected, fb.count)
+		for i, frac := range fractions {
+			if frac == 0 {
+				continue
+			}
+			var delta float64
+			if expected > 0 {
+				delta = frac * counts[i] * (float64(fb.count)/expected - 1)
+			} else {
+				delta = frac / weight * float64(fb.count)
+			}
+			if frac == 1 && counts[i] > 0 {
+				repeats[i] *= (counts[i] + delta) / counts[i]
+			}
+			counts[i] = math.Max(counts[i]+delta, 0)
+		}
+	}
+	newHg := &Histogram{
+		ID:                hg.ID,
+		NDV:               hg.NDV,
+		NullCount:         hg.NullCount,
+		LastUpdateVersion: hg.LastUpdateVersion,
+		Buckets:           make([]Bucket, len(hg.Buckets)),
+	}
+	var total int64
+	for i, bkt := range hg.Buckets {
+		count := int64(counts[i] + 0.5)
+		total += count
+		newHg.Buckets[i] = Bucket{
+			Count:      total,
+			Repeats:    int64(math.Min(repeats[i], float64(count)) + 0.5),
+			LowerBound: bkt.LowerBound,
+			UpperBound: bkt.UpperBound,
+		}
+	}
+	return newHg, nil
+}
+
+// overlapFraction estimates the fraction of the rows of the bucket in the range of the feedback.
+// The integer buckets are assumed to be uniform, and half of the rows of the other buckets are
+// assumed to be in the range if the range covers a part of the bucket.
+func (b *Bucket) overlapFraction(sc *variable.StatementContext, fb feedback) (float64, error) {
+	cmp, err := b.UpperBound.CompareDatum(sc, fb.lower)
+	if err != nil || cmp < 0 {
+		return 0, errors.Trace(err)
+	}
+	cmp, err = b.LowerBound.CompareDatum(sc, fb.upper)
+	if err != nil || cmp >= 0 {
+		return 0, errors.Trace(err)
+	}
+	lowerIn, err := b.LowerBound.CompareDatum(sc, fb.lower)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	upperIn, err := b.UpperBound.CompareDatum(sc, fb.upper)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	if lowerIn >= 0 && upperIn < 0 {
+		return 1, nil
+	}
+	if b.LowerBound.Kind() != types.KindInt64 || b.UpperBound.Kind() != types.KindInt64 {
+		return 0.5, nil
+	}
+	low, high := b.LowerBound.GetInt64(), b.UpperBound.GetInt64()
+	if lowerIn < 0 {
+		low = fb.lower.GetInt64()
+	}
+	if upperIn >= 0 {
+		high = fb.upper.GetInt64() - 1
+	}
+	return (float64(high) - float64(low) + 1) / (float64(b.UpperBound.GetInt64()) - float64(b.LowerBound.GetInt64()) + 1), nil
+}
@@ -0,0 +1,62 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statistics_test
+
+import (
+	"fmt"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/util/testkit"
+)
+
+func (s *testStatsCacheSuite) TestQueryFeedback(c *C) {
+	defer cleanEnv(c, s.store, s.do)
+	testKit := testkit.NewTestKit(c, s.store)
+	testKit.MustExec("use test")
+	testKit.MustExec("create table t (a int primary key, b int, index idx(b))")
+	for i := 0; i < 20; i++ {
+		testKit.MustExec(fmt.Sprintf("insert into t values (%d, %d)", i, i))
+	}
+	testKit.MustExec("analyze table t")
+	testKit.MustExec("delete from t where a < 10")
+	is := s.do.InfoSchema()
+	tbl, err := is.TableByName(model.NewCIStr("test"), model.NewCIStr("t"))
+	c.Assert(err, IsNil)
+	tableInfo := tbl.Meta()
+	pkID, idxID := tableInfo.GetPkColInfo().ID, tableInfo.Indices[0].ID
+	h := s.do.StatsHandle()
+
+	// The feedback is not collected by default.
+	testKit.MustQuery("select * from t where a < 10").Check(testkit.Rows())
+	h.UpdateStatsByLocalFeedback()
+	hg := h.GetTableStats(tableInfo.ID).Columns[pkID].Histogram
+	c.Assert(hg.Buckets[len(hg.Buckets)-1].Count, Equals, int64(20))
+
+	testKit.MustExec("set @@session.tidb_feedback_probability = 1")
+	testKit.MustQuery("select * from t where a < 10").Check(testkit.Rows())
+	testKit.MustQuery("select b from t where b < 10").Check(testkit.Rows())
+	h.UpdateStatsByLocalFeedback()
+	statsTbl := h.GetTableStats(tableInfo.ID)
+	hg = statsTbl.Columns[pkID].Histogram
+	c.Assert(hg.Buckets[len(hg.Buckets)-1].Count, Equals, int64(10))
+	hg = statsTbl.Indices[idxID].Histogram
+	c.Assert(hg.Buckets[len(hg.Buckets)-1].Count, Equals, int64(10))
+
+	// The feedback of the scan whose rows are filtered in the coprocessor is not collected.
+	testKit.MustQuery("select * from t where a >= 10 and b > 15").Check(testkit.Rows("16 16", "17 17", "18 18", "19 19"))
+	h.UpdateStatsByLocalFeedback()
+	hg = h.GetTableStats(tableInfo.ID).Columns[pkID].Histogram
+	c.Assert(hg.Buckets[len(hg.Buckets)-1].Count, Equals, int64(10))
+}
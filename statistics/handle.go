@@ -45,6 +45,8 @@ type Handle struct {
 	// analyzeResultCh is a channel to notify an analyze index or column operation has ended.
 	// We need this to avoid updating the stats simultaneously.
 	analyzeResultCh chan *AnalyzeResult
+	// feedbackCh holds the query feedback waiting to update the histograms.
+	feedbackCh chan *QueryFeedback
 	// All the stats collector required by session are maintained in this list.
 	listHead *SessionStatsCollector
 	// We collect the delta map and merge them with globalMap.
@@ -64,6 +66,9 @@ func (h *Handle) Clear() {
 	for len(h.analyzeResultCh) > 0 {
 		<-h.analyzeResultCh
 	}
+	for len(h.feedbackCh) > 0 {
+		<-h.feedbackCh
+	}
 	h.listHead = &SessionStatsCollector{mapper: make(tableDeltaMap)}
 	h.globalMap = make(tableDeltaMap)
 }
@@ -74,6 +79,7 @@ func NewHandle(ctx context.Context, lease time.Duration) *Handle {
 		ctx:             ctx,
 		ddlEventCh:      make(chan *ddl.Event, 100),
 		analyzeResultCh: make(chan *AnalyzeResult, 100),
+		feedbackCh:      make(chan *QueryFeedback, maxQueryFeedback),
 		listHead:        &SessionStatsCollector{mapper: make(tableDeltaMap)},
 		globalMap:       make(tableDeltaMap),
 		Lease:           lease,
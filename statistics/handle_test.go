@@ -62,6 +62,8 @@ func cleanEnv(c *C, store kv.Storage, do *domain.Domain) {
 	tk.MustExec("truncate table mysql.stats_meta")
 	tk.MustExec("truncate table mysql.stats_histograms")
 	tk.MustExec("truncate table mysql.stats_buckets")
+	tk.MustExec("truncate table mysql.analyze_history")
+	tk.MustExec("truncate table mysql.stats_extended")
 }
 
 func (s *testStatsCacheSuite) TestStatsCache(c *C) {
@@ -347,3 +349,54 @@ func newStoreWithBootstrap() (kv.Storage, *domain.Domain, error) {
 	do, err := tidb.BootstrapSession(store)
 	return store, do, errors.Trace(err)
 }
+
+func (s *testStatsCacheSuite) TestSkewedEqualRowCount(c *C) {
+	defer cleanEnv(c, s.store, s.do)
+	testKit := testkit.NewTestKit(c, s.store)
+	testKit.MustExec("use test")
+	testKit.MustExec("create table t (a int, b int)")
+	// 40% of the rows have the same value, the others are distinct.
+	for i := 0; i < 1000; i++ {
+		v := i
+		if i < 400 {
+			v = 0
+		}
+		testKit.MustExec("insert into t values (?, ?)", v, v)
+	}
+	testKit.MustExec("create index idx on t(b)")
+	testKit.MustExec("analyze table t")
+	do := s.do
+	is := do.InfoSchema()
+	tbl, err := is.TableByName(model.NewCIStr("test"), model.NewCIStr("t"))
+	c.Assert(err, IsNil)
+	tableInfo := tbl.Meta()
+
+	// The CM sketches are loaded from the storage.
+	do.StatsHandle().Clear()
+	do.StatsHandle().Update(is)
+	statsTbl := do.StatsHandle().GetTableStats(tableInfo.ID)
+	c.Assert(statsTbl.Columns[tableInfo.Columns[0].ID].CMSketch, NotNil)
+	c.Assert(statsTbl.Indices[tableInfo.Indices[0].ID].CMSketch, NotNil)
+
+	sc := new(variable.StatementContext)
+	count, err := statsTbl.ColumnEqualRowCount(sc, types.NewIntDatum(0), tableInfo.Columns[0])
+	c.Assert(err, IsNil)
+	c.Assert(count, Equals, 400.0)
+	count, err = statsTbl.ColumnEqualRowCount(sc, types.NewIntDatum(500), tableInfo.Columns[0])
+	c.Assert(err, IsNil)
+	c.Assert(count, Equals, 1.0)
+
+	idxID := tableInfo.Indices[0].ID
+	for _, t := range []struct {
+		value int64
+		count float64
+	}{{0, 400}, {500, 1}, {2000, 0}} {
+		ran := &types.IndexRange{
+			LowVal:  []types.Datum{types.NewIntDatum(t.value)},
+			HighVal: []types.Datum{types.NewIntDatum(t.value)},
+		}
+		count, err = statsTbl.GetRowCountByIndexRanges(sc, idxID, []*types.IndexRange{ran})
+		c.Assert(err, IsNil)
+		c.Assert(count, Equals, t.count, Commentf("value %d", t.value))
+	}
+}
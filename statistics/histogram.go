@@ -14,6 +14,7 @@
 package statistics
 
 import (
+	"bytes"
 	"fmt"
 	"math"
 	"sort"
@@ -57,8 +58,8 @@ type Bucket struct {
 	Repeats    int64
 }
 
-// SaveToStorage saves the histogram to storage.
-func (hg *Histogram) SaveToStorage(ctx context.Context, tableID int64, count int64, isIndex int) error {
+// SaveStatsToStorage saves the histogram and the CM sketch to storage, cms may be nil.
+func SaveStatsToStorage(ctx context.Context, tableID int64, count int64, isIndex int, hg *Histogram, cms *CMSketch) error {
 	exec := ctx.(sqlexec.SQLExecutor)
 	_, err := exec.Execute("begin")
 	if err != nil {
@@ -71,7 +72,11 @@ func (hg *Histogram) SaveToStorage(ctx context.Context, tableID int64, count int
 	if err != nil {
 		return errors.Trace(err)
 	}
-	replaceSQL = fmt.Sprintf("replace into mysql.stats_histograms (table_id, is_index, hist_id, distinct_count, version, null_count) values (%d, %d, %d, %d, %d, %d)", tableID, isIndex, hg.ID, hg.NDV, version, hg.NullCount)
+	data, err := encodeCMSketch(cms)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	replaceSQL = fmt.Sprintf("replace into mysql.stats_histograms (table_id, is_index, hist_id, distinct_count, version, null_count, cm_sketch) values (%d, %d, %d, %d, %d, %d, X'%X')", tableID, isIndex, hg.ID, hg.NDV, version, hg.NullCount, data)
 	_, err = exec.Execute(replaceSQL)
 	if err != nil {
 		return errors.Trace(err)
@@ -431,6 +436,7 @@ func MergeHistograms(sc *variable.StatementContext, lh *Histogram, rh *Histogram
 // Column represents a column histogram.
 type Column struct {
 	Histogram
+	*CMSketch
 	Info *model.ColumnInfo
 }
 
@@ -438,6 +444,16 @@ func (c *Column) String() string {
 	return c.Histogram.toString(false)
 }
 
+// equalRowCount estimates the row count where the column equals to value, the CM sketch is
+// used if the column has one.
+func (c *Column) equalRowCount(sc *variable.StatementContext, value types.Datum) (float64, error) {
+	if c.CMSketch == nil || value.IsNull() {
+		return c.Histogram.equalRowCount(sc, value)
+	}
+	count, err := c.CMSketch.queryColumnValue(sc, value)
+	return float64(count), errors.Trace(err)
+}
+
 // getIntColumnRowCount estimates the row count by a slice of IntColumnRange.
 func (c *Column) getIntColumnRowCount(sc *variable.StatementContext, intRanges []types.IntColumnRange,
 	totalRowCount float64) (float64, error) {
@@ -524,6 +540,7 @@ func (c *Column) getColumnRowCount(sc *variable.StatementContext, ranges []*type
 // Index represents an index histogram.
 type Index struct {
 	Histogram
+	*CMSketch
 	Info *model.IndexInfo
 }
 
@@ -546,6 +563,11 @@ func (idx *Index) getRowCount(sc *variable.StatementContext, indexRanges []*type
 		if err != nil {
 			return 0, errors.Trace(err)
 		}
+		// The point on all the columns of the index is estimated by the CM sketch.
+		if idx.CMSketch != nil && !indexRange.LowExclude && !indexRange.HighExclude && bytes.Equal(lb, rb) {
+			totalCount += float64(idx.CMSketch.queryBytes(lb))
+			continue
+		}
 		if !indexRange.HighExclude {
 			rb = append(rb, 0)
 		}
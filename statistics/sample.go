@@ -30,19 +30,34 @@ type SampleCollector struct {
 	Count         int64
 	MaxSampleSize int64
 	Sketch        *FMSketch
+	// CMSketch counts the values encoded in the row format, it's only built by the coprocessor.
+	CMSketch *CMSketch
 }
 
-// MergeSampleCollector merges two sample collectors.
-func (c *SampleCollector) MergeSampleCollector(rc *SampleCollector) {
+// MergeSampleCollector merges two sample collectors. The merged collector has no CM sketch if
+// any of them doesn't have one, the counts of the values would be incomplete.
+func (c *SampleCollector) MergeSampleCollector(rc *SampleCollector) error {
 	c.NullCount += rc.NullCount
 	c.Sketch.mergeFMSketch(rc.Sketch)
+	if c.CMSketch != nil && rc.CMSketch != nil {
+		err := c.CMSketch.MergeCMSketch(rc.CMSketch)
+		if err != nil {
+			return errors.Trace(err)
+		}
+	} else {
+		c.CMSketch = nil
+	}
 	for _, val := range rc.Samples {
-		c.collect(val, false)
+		err := c.collect(val, false)
+		if err != nil {
+			return errors.Trace(err)
+		}
 	}
+	return nil
 }
 
 // SampleCollectorToProto converts SampleCollector to its protobuf representation.
-func SampleCollectorToProto(c *SampleCollector) *tipb.SampleCollector {
+func SampleCollectorToProto(c *SampleCollector) (*tipb.SampleCollector, error) {
 	collector := &tipb.SampleCollector{
 		NullCount: c.NullCount,
 		Count:     c.Count,
@@ -51,11 +66,13 @@ func SampleCollectorToProto(c *SampleCollector) *tipb.SampleCollector {
 	for _, sample := range c.Samples {
 		collector.Samples = append(collector.Samples, sample.GetBytes())
 	}
-	return collector
+	var err error
+	collector.XXX_unrecognized, err = appendCMSketchField(collector.XXX_unrecognized, cmSketchFieldOfCollector, c.CMSketch)
+	return collector, errors.Trace(err)
 }
 
 // SampleCollectorFromProto converts SampleCollector from its protobuf representation.
-func SampleCollectorFromProto(collector *tipb.SampleCollector) *SampleCollector {
+func SampleCollectorFromProto(collector *tipb.SampleCollector) (*SampleCollector, error) {
 	s := &SampleCollector{
 		NullCount: collector.NullCount,
 		Count:     collector.Count,
@@ -64,7 +81,9 @@ func SampleCollectorFromProto(collector *tipb.SampleCollector) *SampleCollector
 	for _, val := range collector.Samples {
 		s.Samples = append(s.Samples, types.NewBytesDatum(val))
 	}
-	return s
+	var err error
+	s.CMSketch, err = cmSketchFromField(collector.XXX_unrecognized, cmSketchFieldOfCollector)
+	return s, errors.Trace(err)
 }
 
 func (c *SampleCollector) collect(d types.Datum, insertSketch bool) error {
@@ -86,6 +105,9 @@ func (c *SampleCollector) collect(d types.Datum, insertSketch bool) error {
 		}
 	}
 	if insertSketch {
+		if c.CMSketch != nil {
+			c.CMSketch.InsertBytes(d.GetBytes())
+		}
 		return errors.Trace(c.Sketch.InsertValue(d))
 	}
 	return nil
@@ -101,6 +123,10 @@ type SampleBuilder struct {
 	MaxBucketSize int64
 	MaxSampleSize int64
 	MaxSketchSize int64
+	// CMSketchDepth and CMSketchWidth are the size of the CM sketches of the columns, the CM
+	// sketches are built only if they are set, and the values should be the encoded bytes.
+	CMSketchDepth int32
+	CMSketchWidth int32
 }
 
 // CollectSamplesAndEstimateNDVs collects sample from the result set using Reservoir Sampling algorithm,
@@ -119,6 +145,9 @@ func (s SampleBuilder) CollectSamplesAndEstimateNDVs() ([]*SampleCollector, *Sor
 			MaxSampleSize: s.MaxSampleSize,
 			Sketch:        NewFMSketch(int(s.MaxSketchSize)),
 		}
+		if s.CMSketchDepth > 0 && s.CMSketchWidth > 0 {
+			collectors[i].CMSketch = NewCMSketch(s.CMSketchDepth, s.CMSketchWidth)
+		}
 	}
 	for {
 		row, err := s.RecordSet.Next()
@@ -105,7 +105,8 @@ func (s *testSampleSuite) TestMergeSampleCollector(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(pkBuilder, IsNil)
 	c.Assert(len(collectors), Equals, 2)
-	collectors[0].MergeSampleCollector(collectors[1])
+	err = collectors[0].MergeSampleCollector(collectors[1])
+	c.Assert(err, IsNil)
 	c.Assert(collectors[0].Sketch.NDV(), Equals, int64(9280))
 	c.Assert(len(collectors[0].Samples), Equals, 10000)
 	c.Assert(collectors[0].NullCount, Equals, int64(1000))
@@ -127,8 +128,10 @@ func (s *testSampleSuite) TestCollectorProtoConversion(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(pkBuilder, IsNil)
 	for _, collector := range collectors {
-		p := statistics.SampleCollectorToProto(collector)
-		s := statistics.SampleCollectorFromProto(p)
+		p, err := statistics.SampleCollectorToProto(collector)
+		c.Assert(err, IsNil)
+		s, err := statistics.SampleCollectorFromProto(p)
+		c.Assert(err, IsNil)
 		c.Assert(collector.Count, Equals, s.Count)
 		c.Assert(collector.NullCount, Equals, s.NullCount)
 		c.Assert(collector.Sketch.NDV(), Equals, s.Sketch.NDV())
@@ -122,9 +122,9 @@ func (t *Table) Selectivity(ctx context.Context, exprs []expression.Expression)
 		}
 	}
 	sets = getUsableSetsByGreedy(sets)
-	ret := 1.0
 	// Initialize the mask with the full set.
 	mask := (int64(1) << uint(len(exprs))) - 1
+	selectivities := make([]float64, 0, len(sets))
 	for _, set := range sets {
 		mask ^= set.mask
 		var (
@@ -142,8 +142,9 @@ func (t *Table) Selectivity(ctx context.Context, exprs []expression.Expression)
 		if err != nil {
 			return 0, errors.Trace(err)
 		}
-		ret *= rowCount / float64(t.Count)
+		selectivities = append(selectivities, rowCount/float64(t.Count))
 	}
+	ret := t.combineSelectivities(exprs, sets, selectivities)
 	// If there's still conditions which cannot be calculated, we will multiply a selectionFactor.
 	if mask > 0 {
 		ret *= selectionFactor
@@ -151,6 +152,63 @@ func (t *Table) Selectivity(ctx context.Context, exprs []expression.Expression)
 	return ret, nil
 }
 
+// combineSelectivities multiplies the selectivities of the sets. The equal conditions on the
+// columns of a column group with the extended statistics are correlated, their selectivity is
+// sel(prefix) * (d + (1 - d) * sel(last)), where d is the dependency degree of the group.
+func (t *Table) combineSelectivities(exprs []expression.Expression, sets []*exprSet, selectivities []float64) float64 {
+	used := make([]bool, len(sets))
+	ret := 1.0
+	for _, stats := range t.ExtendedStats {
+		if stats.NDV <= 0 {
+			continue
+		}
+		offsets := make([]int, 0, len(stats.ColIDs))
+		for _, colID := range stats.ColIDs {
+			offset := findEqualColumnSet(exprs, sets, used, colID)
+			if offset == -1 {
+				break
+			}
+			offsets = append(offsets, offset)
+		}
+		if len(offsets) < len(stats.ColIDs) {
+			continue
+		}
+		sel := 1.0
+		for _, offset := range offsets[:len(offsets)-1] {
+			sel *= selectivities[offset]
+			used[offset] = true
+		}
+		last := offsets[len(offsets)-1]
+		used[last] = true
+		sel *= stats.Dependency + (1-stats.Dependency)*selectivities[last]
+		ret *= sel
+	}
+	for i, sel := range selectivities {
+		if !used[i] {
+			ret *= sel
+		}
+	}
+	return ret
+}
+
+// findEqualColumnSet finds the unused column set which only covers an equal condition on the column.
+func findEqualColumnSet(exprs []expression.Expression, sets []*exprSet, used []bool, colID int64) int {
+	for i, set := range sets {
+		if used[i] || set.tp == indexType || set.ID != colID || popCount(set.mask) != 1 {
+			continue
+		}
+		for j := range exprs {
+			if set.mask&(1<<uint(j)) == 0 {
+				continue
+			}
+			if fun, ok := exprs[j].(*expression.ScalarFunction); ok && fun.FuncName.L == ast.EQ && checkColumnConstant(fun.GetArgs()) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
 func getMaskAndRanges(ctx context.Context, exprs []expression.Expression, rangeType int,
 	lengths []int, cols ...*expression.Column) (int64, []types.Range, error) {
 	exprsClone := make([]expression.Expression, 0, len(exprs))
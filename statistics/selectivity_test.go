@@ -183,3 +183,87 @@ func (s *testSelectivitySuite) TestSelectivity(c *C) {
 		c.Assert(math.Abs(ratio-tt.selectivity) < eps, IsTrue, comment)
 	}
 }
+
+func (s *testSelectivitySuite) TestExtendedStatsSelectivity(c *C) {
+	store, dom, err := newStoreWithBootstrap()
+	defer func() {
+		dom.Close()
+		store.Close()
+	}()
+	c.Assert(err, IsNil)
+
+	testKit := testkit.NewTestKit(c, store)
+	testKit.MustExec("use test")
+	testKit.MustExec("drop table if exists t")
+	testKit.MustExec("create table t(a int, b int, c int)")
+
+	is := dom.InfoSchema()
+	tb, err := is.TableByName(model.NewCIStr("test"), model.NewCIStr("t"))
+	c.Assert(err, IsNil)
+	tbl := tb.Meta()
+
+	statsTbl := mockStatsTable(tbl, 540)
+	colValues, _ := s.generateIntDatum(1, 54)
+	for i := 1; i <= 3; i++ {
+		statsTbl.Columns[int64(i)] = &statistics.Column{Histogram: *mockStatsHistogram(int64(i), colValues, 10), Info: tbl.Columns[i-1]}
+	}
+
+	tests := []struct {
+		exprs       string
+		dependency  float64
+		selectivity float64
+	}{
+		{
+			exprs:       "a = 1 and b = 1",
+			dependency:  1,
+			selectivity: 0.01851851851,
+		},
+		{
+			exprs:       "b = 1 and a = 1",
+			dependency:  0.5,
+			selectivity: 0.00943072702,
+		},
+		{
+			exprs:       "a = 1 and b = 1",
+			dependency:  0,
+			selectivity: 0.00034293552,
+		},
+		{
+			exprs:       "a = 1 and b = 1 and c = 1",
+			dependency:  1,
+			selectivity: 0.00034293552,
+		},
+		{
+			// The range conditions are not correlated by the extended stats.
+			exprs:       "a = 1 and b < 1",
+			dependency:  1,
+			selectivity: 0.00034293552,
+		},
+	}
+	for _, tt := range tests {
+		statsTbl.ExtendedStats = []*statistics.ExtendedStats{{ColIDs: []int64{1, 2}, NDV: 54, Dependency: tt.dependency}}
+		sql := "select * from t where " + tt.exprs
+		comment := Commentf("for %s with dependency %v", tt.exprs, tt.dependency)
+		ctx := testKit.Se.(context.Context)
+		stmts, err := tidb.Parse(ctx, sql)
+		c.Assert(err, IsNil, comment)
+		c.Assert(stmts, HasLen, 1)
+		err = plan.ResolveName(stmts[0], is, ctx)
+		c.Assert(err, IsNil, comment)
+
+		p, err := plan.BuildLogicalPlan(ctx, stmts[0], is)
+		c.Assert(err, IsNil, comment)
+		var sel *plan.Selection
+		for _, child := range p.Children() {
+			p, ok := child.(*plan.Selection)
+			if ok {
+				sel = p
+				break
+			}
+		}
+		c.Assert(sel, NotNil, comment)
+		ratio, err := statsTbl.Selectivity(ctx, sel.Conditions)
+		c.Assert(err, IsNil, comment)
+		c.Assert(math.Abs(ratio-tt.selectivity) < eps, IsTrue, Commentf("for %s with dependency %v, got %v", tt.exprs, tt.dependency, ratio))
+	}
+}
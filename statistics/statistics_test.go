@@ -192,7 +192,7 @@ func (s *testStatisticsSuite) TestBuild(c *C) {
 	c.Check(err, IsNil)
 	c.Check(int(count), Equals, 9)
 
-	tblCount, col, err := BuildIndex(ctx, bucketCount, 1, ast.RecordSet(s.rc))
+	tblCount, col, _, err := BuildIndex(ctx, bucketCount, 1, ast.RecordSet(s.rc))
 	c.Check(err, IsNil)
 	c.Check(int(tblCount), Equals, 100000)
 	count, err = col.equalRowCount(sc, encodeKey(types.NewIntDatum(10000)))
@@ -241,7 +241,7 @@ func (s *testStatisticsSuite) TestBuild(c *C) {
 func (s *testStatisticsSuite) TestHistogramProtoConversion(c *C) {
 	ctx := mock.NewContext()
 	s.rc.Close()
-	tblCount, col, err := BuildIndex(ctx, 256, 1, ast.RecordSet(s.rc))
+	tblCount, col, _, err := BuildIndex(ctx, 256, 1, ast.RecordSet(s.rc))
 	c.Check(err, IsNil)
 	c.Check(int(tblCount), Equals, 100000)
 
@@ -477,3 +477,39 @@ func (s *testStatisticsSuite) TestIntColumnRanges(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(int(count), Equals, 1)
 }
+
+func (s *testStatisticsSuite) TestUpdateHistogramByFeedback(c *C) {
+	sc := mock.NewContext().GetSessionVars().StmtCtx
+	hg := &Histogram{NDV: 30}
+	for i := int64(0); i < 3; i++ {
+		hg.Buckets = append(hg.Buckets, Bucket{
+			LowerBound: types.NewIntDatum(i * 10),
+			UpperBound: types.NewIntDatum(i*10 + 9),
+			Count:      (i + 1) * 10,
+			Repeats:    1,
+		})
+	}
+
+	// The range [5, 15) covers half of the first two buckets, and the rows are twice the estimation.
+	q := NewQueryFeedback(0, hg, false)
+	q.SetIntRanges([]types.IntColumnRange{{LowVal: 5, HighVal: 14}})
+	for i := 0; i < 20; i++ {
+		q.UpdateByHandle(10)
+	}
+	newHg, err := hg.updateByFeedback(sc, q.feedback)
+	c.Assert(err, IsNil)
+	c.Assert(newHg.Buckets[0].Count, Equals, int64(15))
+	c.Assert(newHg.Buckets[1].Count, Equals, int64(30))
+	c.Assert(newHg.Buckets[2].Count, Equals, int64(40))
+
+	// The range [20, +inf) covers the last bucket which is empty actually.
+	q.SetIntRanges([]types.IntColumnRange{{LowVal: 20, HighVal: math.MaxInt64}})
+	newHg, err = newHg.updateByFeedback(sc, q.feedback)
+	c.Assert(err, IsNil)
+	c.Assert(newHg.Buckets[2].Count, Equals, int64(30))
+	c.Assert(newHg.Buckets[2].Repeats, Equals, int64(0))
+
+	// The handle out of the ranges invalidates the feedback.
+	q.UpdateByHandle(10)
+	c.Assert(q.valid, IsFalse)
+}
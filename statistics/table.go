@@ -48,15 +48,18 @@ type Table struct {
 	ModifyCount int64 // Total modify count in a table.
 	Version     uint64
 	Pseudo      bool
+	// ExtendedStats are the statistics of the declared column groups.
+	ExtendedStats []*ExtendedStats
 }
 
 func (t *Table) copy() *Table {
 	nt := &Table{
-		TableID: t.TableID,
-		Count:   t.Count,
-		Pseudo:  t.Pseudo,
-		Columns: make(map[int64]*Column),
-		Indices: make(map[int64]*Index),
+		TableID:       t.TableID,
+		Count:         t.Count,
+		Pseudo:        t.Pseudo,
+		Columns:       make(map[int64]*Column),
+		Indices:       make(map[int64]*Index),
+		ExtendedStats: t.ExtendedStats,
 	}
 	for id, col := range t.Columns {
 		nt.Columns[id] = col
@@ -80,7 +83,7 @@ func (h *Handle) tableStatsFromStorage(tableInfo *model.TableInfo) (*Table, erro
 		// We copy it before writing to avoid race.
 		table = table.copy()
 	}
-	selSQL := fmt.Sprintf("select table_id, is_index, hist_id, distinct_count, version, null_count from mysql.stats_histograms where table_id = %d", tableInfo.ID)
+	selSQL := fmt.Sprintf("select table_id, is_index, hist_id, distinct_count, version, null_count, cm_sketch from mysql.stats_histograms where table_id = %d", tableInfo.ID)
 	rows, _, err := h.ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(h.ctx, selSQL)
 	if err != nil {
 		return nil, errors.Trace(err)
@@ -104,7 +107,11 @@ func (h *Handle) tableStatsFromStorage(tableInfo *model.TableInfo) (*Table, erro
 						if err != nil {
 							return nil, errors.Trace(err)
 						}
-						idx = &Index{Histogram: *hg, Info: idxInfo}
+						cms, err := decodeCMSketch(row.Data[6].GetBytes())
+						if err != nil {
+							return nil, errors.Trace(err)
+						}
+						idx = &Index{Histogram: *hg, CMSketch: cms, Info: idxInfo}
 					}
 					break
 				}
@@ -124,7 +131,11 @@ func (h *Handle) tableStatsFromStorage(tableInfo *model.TableInfo) (*Table, erro
 						if err != nil {
 							return nil, errors.Trace(err)
 						}
-						col = &Column{Histogram: *hg, Info: colInfo}
+						cms, err := decodeCMSketch(row.Data[6].GetBytes())
+						if err != nil {
+							return nil, errors.Trace(err)
+						}
+						col = &Column{Histogram: *hg, CMSketch: cms, Info: colInfo}
 					}
 					break
 				}
@@ -139,6 +150,10 @@ func (h *Handle) tableStatsFromStorage(tableInfo *model.TableInfo) (*Table, erro
 			}
 		}
 	}
+	table.ExtendedStats, err = h.extendedStatsFromStorage(tableInfo)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
 	return table, nil
 }
 
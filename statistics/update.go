@@ -16,14 +16,10 @@ package statistics
 import (
 	"fmt"
 	"sync"
-	"time"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/juju/errors"
-	"github.com/pingcap/tidb/infoschema"
-	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/sessionctx/variable"
-	"github.com/pingcap/tidb/store/tikv/oracle"
 	"github.com/pingcap/tidb/util/sqlexec"
 )
 
@@ -144,55 +140,3 @@ const (
 	// StatsPrompt is the prompt for stats owner manager.
 	StatsPrompt = "stats"
 )
-
-func needAnalyzeTable(tbl *Table, limit time.Duration) bool {
-	if tbl.ModifyCount == 0 {
-		return false
-	}
-	t := time.Unix(0, oracle.ExtractPhysical(tbl.Version)*int64(time.Millisecond))
-	if time.Since(t) < limit {
-		return false
-	}
-	for _, col := range tbl.Columns {
-		if len(col.Buckets) > 0 {
-			return false
-		}
-	}
-	for _, idx := range tbl.Indices {
-		if len(idx.Buckets) > 0 {
-			return false
-		}
-	}
-	return true
-}
-
-// HandleAutoAnalyze analyzes the newly created table or index.
-func (h *Handle) HandleAutoAnalyze(is infoschema.InfoSchema) error {
-	dbs := is.AllSchemaNames()
-	for _, db := range dbs {
-		tbls := is.SchemaTables(model.NewCIStr(db))
-		for _, tbl := range tbls {
-			tblInfo := tbl.Meta()
-			statsTbl := h.GetTableStats(tblInfo.ID)
-			if statsTbl.Pseudo || statsTbl.Count == 0 {
-				continue
-			}
-			tblName := "`" + db + "`.`" + tblInfo.Name.O + "`"
-			if needAnalyzeTable(statsTbl, 20*h.Lease) {
-				sql := fmt.Sprintf("analyze table %s", tblName)
-				log.Infof("[stats] auto analyze table %s now", tblName)
-				_, _, err := h.ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(h.ctx, sql)
-				return errors.Trace(err)
-			}
-			for _, idx := range tblInfo.Indices {
-				if _, ok := statsTbl.Indices[idx.ID]; !ok {
-					sql := fmt.Sprintf("analyze table %s index `%s`", tblName, idx.Name.O)
-					log.Infof("[stats] auto analyze index `%s` for table %s now", idx.Name.O, tblName)
-					_, _, err := h.ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(h.ctx, sql)
-					return errors.Trace(err)
-				}
-			}
-		}
-	}
-	return nil
-}
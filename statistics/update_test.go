@@ -14,6 +14,9 @@
 package statistics_test
 
 import (
+	"fmt"
+	"time"
+
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb/domain"
 	"github.com/pingcap/tidb/kv"
@@ -282,3 +285,65 @@ func (s *testStatsUpdateSuite) TestAutoUpdate(c *C) {
 	c.Assert(hg.NDV, Equals, int64(1))
 	c.Assert(len(hg.Buckets), Equals, 1)
 }
+
+func (s *testStatsUpdateSuite) TestAutoAnalyzeRatio(c *C) {
+	defer cleanEnv(c, s.store, s.do)
+	testKit := testkit.NewTestKit(c, s.store)
+	testKit.MustExec("use test")
+	testKit.MustExec("create table t (a int)")
+
+	do := s.do
+	is := do.InfoSchema()
+	tbl, err := is.TableByName(model.NewCIStr("test"), model.NewCIStr("t"))
+	c.Assert(err, IsNil)
+	tableInfo := tbl.Meta()
+	h := do.StatsHandle()
+	h.HandleDDLEvent(<-h.DDLEventCh())
+	for i := 0; i < 10; i++ {
+		testKit.MustExec(fmt.Sprintf("insert into t values (%d)", i))
+	}
+	h.DumpStatsDeltaToKV()
+	testKit.MustExec("analyze table t")
+	h.Update(is)
+	c.Assert(h.GetTableStats(tableInfo.ID).ModifyCount, Equals, int64(0))
+
+	insert := func(count int) {
+		for i := 0; i < count; i++ {
+			testKit.MustExec("insert into t values (1)")
+		}
+		h.DumpStatsDeltaToKV()
+		h.Update(is)
+	}
+	// The ratio of the modified rows, 5/15, doesn't exceed tidb_auto_analyze_ratio.
+	insert(5)
+	c.Assert(h.HandleAutoAnalyze(is), IsNil)
+	h.Update(is)
+	c.Assert(h.GetTableStats(tableInfo.ID).ModifyCount, Equals, int64(5))
+
+	// The auto analyze is out of the time period.
+	insert(1)
+	now := time.Now().UTC()
+	testKit.MustExec(fmt.Sprintf("set global tidb_auto_analyze_start_time = '%s'", now.Add(2*time.Hour).Format("15:04 -0700")))
+	testKit.MustExec(fmt.Sprintf("set global tidb_auto_analyze_end_time = '%s'", now.Add(3*time.Hour).Format("15:04 -0700")))
+	c.Assert(h.HandleAutoAnalyze(is), IsNil)
+	h.Update(is)
+	c.Assert(h.GetTableStats(tableInfo.ID).ModifyCount, Equals, int64(6))
+
+	// A ratio of 0 disables the auto analyze of the analyzed tables.
+	testKit.MustExec("set global tidb_auto_analyze_start_time = '00:00 +0000'")
+	testKit.MustExec("set global tidb_auto_analyze_end_time = '23:59 +0000'")
+	testKit.MustExec("set global tidb_auto_analyze_ratio = 0")
+	c.Assert(h.HandleAutoAnalyze(is), IsNil)
+	h.Update(is)
+	c.Assert(h.GetTableStats(tableInfo.ID).ModifyCount, Equals, int64(6))
+
+	// The ratio of the modified rows is 6/16 now.
+	testKit.MustExec("set global tidb_auto_analyze_ratio = 0.3")
+	defer testKit.MustExec("set global tidb_auto_analyze_ratio = 0.5")
+	c.Assert(h.HandleAutoAnalyze(is), IsNil)
+	h.Update(is)
+	stats := h.GetTableStats(tableInfo.ID)
+	c.Assert(stats.Count, Equals, int64(16))
+	c.Assert(stats.ModifyCount, Equals, int64(0))
+	testKit.MustQuery("select table_name, job_info, state from mysql.analyze_history").Check(testkit.Rows("t analyze table finished"))
+}
@@ -34,6 +34,8 @@ type dbTxn struct {
 	version    kv.Version          // commit version
 	lockedKeys map[string]struct{} // origin version in snapshot
 	dirty      bool
+	// stagingLockedKeys are the keys newly locked in each staging layer.
+	stagingLockedKeys [][]string
 }
 
 func newTxn(s *dbStore, ver kv.Version) *dbTxn {
@@ -131,6 +133,7 @@ type schemaLeaseChecker interface {
 }
 
 func (txn *dbTxn) close() error {
+	txn.us.Release()
 	txn.lockedKeys = nil
 	txn.valid = false
 	return nil
@@ -146,11 +149,51 @@ func (txn *dbTxn) Rollback() error {
 
 func (txn *dbTxn) LockKeys(keys ...kv.Key) error {
 	for _, key := range keys {
+		if n := len(txn.stagingLockedKeys); n > 0 {
+			if _, ok := txn.lockedKeys[string(key)]; !ok {
+				txn.stagingLockedKeys[n-1] = append(txn.stagingLockedKeys[n-1], string(key))
+			}
+		}
 		txn.lockedKeys[string(key)] = struct{}{}
 	}
 	return nil
 }
 
+// Staging implements the kv.Stager interface.
+func (txn *dbTxn) Staging() kv.StagingHandle {
+	h := txn.us.Staging()
+	txn.stagingLockedKeys = append(txn.stagingLockedKeys[:h-1], nil)
+	return h
+}
+
+// ReleaseStaging implements the kv.Stager interface.
+func (txn *dbTxn) ReleaseStaging(h kv.StagingHandle) {
+	if int(h) <= 0 || int(h) > len(txn.stagingLockedKeys) {
+		return
+	}
+	if h > 1 {
+		for _, keys := range txn.stagingLockedKeys[h-1:] {
+			txn.stagingLockedKeys[h-2] = append(txn.stagingLockedKeys[h-2], keys...)
+		}
+	}
+	txn.stagingLockedKeys = txn.stagingLockedKeys[:h-1]
+	txn.us.ReleaseStaging(h)
+}
+
+// CleanupStaging implements the kv.Stager interface.
+func (txn *dbTxn) CleanupStaging(h kv.StagingHandle) error {
+	if int(h) <= 0 || int(h) > len(txn.stagingLockedKeys) {
+		return nil
+	}
+	for _, keys := range txn.stagingLockedKeys[h-1:] {
+		for _, k := range keys {
+			delete(txn.lockedKeys, k)
+		}
+	}
+	txn.stagingLockedKeys = txn.stagingLockedKeys[:h-1]
+	return errors.Trace(txn.us.CleanupStaging(h))
+}
+
 func (txn *dbTxn) IsReadOnly() bool {
 	return !txn.dirty
 }
@@ -16,6 +16,7 @@ package tikv
 import (
 	"bytes"
 	"math"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -60,22 +61,49 @@ func (ca twoPhaseCommitAction) MetricsTag() string {
 
 // twoPhaseCommitter executes a two-phase commit protocol.
 type twoPhaseCommitter struct {
-	store     *tikvStore
-	txn       *tikvTxn
-	startTS   uint64
+	store   *tikvStore
+	txn     *tikvTxn
+	startTS uint64
+	// keys and mutations are the mutations of the chunk being processed. For a
+	// transaction smaller than txnCommitChunkSize, there is only one chunk which
+	// holds all the mutations, see forEachChunk.
 	keys      [][]byte
 	mutations map[string]*pb.Mutation
-	lockTTL   uint64
-	commitTS  uint64
-	mu        struct {
+	chunked   bool
+	// prewriteChunks is the number of the chunks whose prewrite is started, they
+	// are cleaned up if the transaction fails.
+	prewriteChunks int
+	primaryKey     []byte
+	// lockOnlyKeys are the sorted keys locked but not written by the transaction.
+	lockOnlyKeys [][]byte
+	size         int
+	lockTTL      uint64
+	commitTS     uint64
+	mu           struct {
 		sync.RWMutex
-		writtenKeys  [][]byte
 		committed    bool
 		undetermined bool
+		// minCommitTS is the max min commit ts of the async commit locks, it's the
+		// commit ts of an async commit transaction.
+		minCommitTS uint64
+		// onePCCommitTS is the commit ts of the transaction committed by 1PC.
+		onePCCommitTS uint64
+		// useOnePC is set if the transaction tries 1PC, it's reset before the prewrite
+		// requests are sent if the keys turn out to be in multiple batches.
+		useOnePC bool
 	}
 	priority pb.CommandPri
+	// useAsyncCommit is set if the transaction is committed by async commit, 1PC is
+	// tried first. maxCommitTS bounds the commit ts calculated by the storage.
+	useAsyncCommit bool
+	maxCommitTS    uint64
 }
 
+// txnCommitChunkSize is the max size of the mutations a committer loads into memory
+// at a time. A larger transaction is prewritten, committed and cleaned up chunk by
+// chunk, the chunks are read from the transaction's buffer each time.
+var txnCommitChunkSize = 64 * 1024 * 1024
+
 // newTwoPhaseCommitter creates a twoPhaseCommitter.
 func newTwoPhaseCommitter(txn *tikvTxn) (*twoPhaseCommitter, error) {
 	var (
@@ -84,75 +112,190 @@ func newTwoPhaseCommitter(txn *tikvTxn) (*twoPhaseCommitter, error) {
 		putCnt  int
 		delCnt  int
 		lockCnt int
+		chunked bool
+		primary []byte
 	)
+	lockKeys := make(map[string]struct{}, len(txn.lockKeys)+len(txn.lockedKeys))
+	for _, k := range txn.lockKeys {
+		lockKeys[string(k)] = struct{}{}
+	}
+	for k := range txn.lockedKeys {
+		lockKeys[k] = struct{}{}
+	}
 	mutations := make(map[string]*pb.Mutation)
 	err := txn.us.WalkBuffer(func(k kv.Key, v []byte) error {
 		if len(v) > 0 {
-			mutations[string(k)] = &pb.Mutation{
-				Op:    pb.Op_Put,
-				Key:   k,
-				Value: v,
-			}
 			putCnt++
 		} else {
-			mutations[string(k)] = &pb.Mutation{
-				Op:  pb.Op_Del,
-				Key: k,
-			}
 			delCnt++
 		}
-		keys = append(keys, k)
+		delete(lockKeys, string(k))
 		entrySize := len(k) + len(v)
 		if entrySize > kv.TxnEntrySizeLimit {
 			return kv.ErrEntryTooLarge
 		}
 		size += entrySize
+		if primary == nil {
+			primary = kv.Key(k).Clone()
+		}
+		if chunked {
+			return nil
+		}
+		if size > txnCommitChunkSize {
+			// Too many mutations to hold in memory, they are loaded chunk by chunk later.
+			chunked = true
+			keys, mutations = nil, nil
+			return nil
+		}
+		keys = appendMutation(keys, mutations, k, v)
 		return nil
 	})
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
-	for _, lockKey := range txn.lockKeys {
-		if _, ok := mutations[string(lockKey)]; !ok {
-			mutations[string(lockKey)] = &pb.Mutation{
-				Op:  pb.Op_Lock,
-				Key: lockKey,
-			}
-			lockCnt++
-			keys = append(keys, lockKey)
-			size += len(lockKey)
-		}
+	lockOnlyKeys := make([][]byte, 0, len(lockKeys))
+	for k := range lockKeys {
+		lockOnlyKeys = append(lockOnlyKeys, []byte(k))
+		size += len(k)
 	}
-	if len(keys) == 0 {
+	sort.Sort(bytesSlice(lockOnlyKeys))
+	lockCnt = len(lockOnlyKeys)
+	cnt := putCnt + delCnt + lockCnt
+	if cnt == 0 {
 		return nil, nil
 	}
+	if primary == nil {
+		primary = lockOnlyKeys[0]
+	}
+	if txn.primaryKey != nil {
+		// The other pessimistic locks point to the primary key.
+		primary = txn.primaryKey
+	}
 	entrylimit := atomic.LoadUint64(&kv.TxnEntryCountLimit)
-	if len(keys) > int(entrylimit) || size > kv.TxnTotalSizeLimit {
+	if uint64(cnt) > entrylimit || uint64(size) > atomic.LoadUint64(&kv.TxnTotalSizeLimit) {
 		return nil, kv.ErrTxnTooLarge
 	}
 	const logEntryCount = 10000
 	const logSize = 4 * 1024 * 1024 // 4MB
-	if len(keys) > logEntryCount || size > logSize {
-		tableID := tablecodec.DecodeTableID(keys[0])
-		log.Infof("[BIG_TXN] table id:%d size:%d, keys:%d, puts:%d, dels:%d, locks:%d, startTS:%d",
-			tableID, size, len(keys), putCnt, delCnt, lockCnt, txn.startTS)
+	if cnt > logEntryCount || size > logSize {
+		tableID := tablecodec.DecodeTableID(primary)
+		log.Infof("[BIG_TXN] table id:%d size:%d, keys:%d, puts:%d, dels:%d, locks:%d, startTS:%d, chunked:%v",
+			tableID, size, cnt, putCnt, delCnt, lockCnt, txn.startTS, chunked)
 	}
 
-	txnWriteKVCountHistogram.Observe(float64(len(keys)))
+	txnWriteKVCountHistogram.Observe(float64(cnt))
 	txnWriteSizeHistogram.Observe(float64(size / 1024))
-	return &twoPhaseCommitter{
-		store:     txn.store,
-		txn:       txn,
-		startTS:   txn.StartTS(),
-		keys:      keys,
-		mutations: mutations,
-		lockTTL:   txnLockTTL(txn.startTime, size),
-		priority:  getTxnPriority(txn),
-	}, nil
+	c := &twoPhaseCommitter{
+		store:        txn.store,
+		txn:          txn,
+		startTS:      txn.StartTS(),
+		chunked:      chunked,
+		primaryKey:   primary,
+		lockOnlyKeys: lockOnlyKeys,
+		size:         size,
+		lockTTL:      txnLockTTL(txn.startTime, size),
+		priority:     getTxnPriority(txn),
+	}
+	if !chunked {
+		keys = appendLockMutations(keys, mutations, lockOnlyKeys)
+		primaryFirst(keys, primary)
+		c.keys, c.mutations = keys, mutations
+	}
+	return c, nil
+}
+
+// appendMutation appends the key to keys and adds its mutation, the key and value
+// are copied because the buffer may reuse them.
+func appendMutation(keys [][]byte, mutations map[string]*pb.Mutation, k, v []byte) [][]byte {
+	key := append([]byte(nil), k...)
+	if len(v) > 0 {
+		mutations[string(key)] = &pb.Mutation{
+			Op:    pb.Op_Put,
+			Key:   key,
+			Value: append([]byte(nil), v...),
+		}
+	} else {
+		mutations[string(key)] = &pb.Mutation{
+			Op:  pb.Op_Del,
+			Key: key,
+		}
+	}
+	return append(keys, key)
+}
+
+func appendLockMutations(keys [][]byte, mutations map[string]*pb.Mutation, lockKeys [][]byte) [][]byte {
+	for _, k := range lockKeys {
+		mutations[string(k)] = &pb.Mutation{
+			Op:  pb.Op_Lock,
+			Key: k,
+		}
+	}
+	return append(keys, lockKeys...)
+}
+
+type bytesSlice [][]byte
+
+func (s bytesSlice) Len() int           { return len(s) }
+func (s bytesSlice) Less(i, j int) bool { return bytes.Compare(s[i], s[j]) < 0 }
+func (s bytesSlice) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// errStopChunks stops forEachChunk early.
+var errStopChunks = errors.New("stop chunks")
+
+// forEachChunk loads at most limit chunks of the mutations into c.keys and c.mutations
+// one by one and calls f on each of them, a negative limit means all the chunks. The
+// primary key is always the first key of its chunk.
+func (c *twoPhaseCommitter) forEachChunk(limit int, f func() error) error {
+	if !c.chunked {
+		if limit == 0 {
+			return nil
+		}
+		return errors.Trace(f())
+	}
+	var (
+		chunks int
+		size   int
+	)
+	reset := func() {
+		c.keys, c.mutations, size = nil, make(map[string]*pb.Mutation), 0
+	}
+	flush := func() error {
+		if len(c.keys) == 0 {
+			return nil
+		}
+		primaryFirst(c.keys, c.primaryKey)
+		chunks++
+		err := f()
+		reset()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if limit >= 0 && chunks >= limit {
+			return errStopChunks
+		}
+		return nil
+	}
+	reset()
+	err := c.txn.us.WalkBuffer(func(k kv.Key, v []byte) error {
+		c.keys = appendMutation(c.keys, c.mutations, k, v)
+		size += len(k) + len(v)
+		if size < txnCommitChunkSize {
+			return nil
+		}
+		return flush()
+	})
+	if err == nil {
+		c.keys = appendLockMutations(c.keys, c.mutations, c.lockOnlyKeys)
+		err = flush()
+	}
+	if errors.Cause(err) == errStopChunks {
+		return nil
+	}
+	return errors.Trace(err)
 }
 
 func (c *twoPhaseCommitter) primary() []byte {
-	return c.keys[0]
+	return c.primaryKey
 }
 
 const bytesPerMiB = 1024 * 1024
@@ -188,23 +331,15 @@ func (c *twoPhaseCommitter) doActionOnKeys(bo *Backoffer, action twoPhaseCommitA
 	if len(keys) == 0 {
 		return nil
 	}
-	groups, firstRegion, err := c.store.regionCache.GroupKeysByRegion(bo, keys)
+	batches, err := c.groupBatches(bo, action, keys)
 	if err != nil {
 		return errors.Trace(err)
 	}
 
-	txnRegionsNumHistogram.WithLabelValues(action.MetricsTag()).Observe(float64(len(groups)))
-
-	var batches []batchKeys
-	var sizeFunc = c.keySize
-	if action == actionPrewrite {
-		sizeFunc = c.keyValueSize
-	}
-	// Make sure the group that contains primary key goes first.
-	batches = appendBatchBySize(batches, firstRegion, groups[firstRegion], sizeFunc, txnCommitBatchSize)
-	delete(groups, firstRegion)
-	for id, g := range groups {
-		batches = appendBatchBySize(batches, id, g, sizeFunc, txnCommitBatchSize)
+	if action == actionPrewrite && len(batches) > 1 && c.isOnePC() {
+		// The keys are not in a single batch, they can't be committed by 1PC. No
+		// prewrite request is sent yet, the keys are split by a region error otherwise.
+		c.setOnePC(false)
 	}
 
 	firstIsPrimary := bytes.Equal(keys[0], c.primary())
@@ -231,6 +366,46 @@ func (c *twoPhaseCommitter) doActionOnKeys(bo *Backoffer, action twoPhaseCommitA
 	return errors.Trace(err)
 }
 
+// doActionOnSecondaries does action on the keys except the primary key, the primary
+// key is the first one if it's in keys.
+func (c *twoPhaseCommitter) doActionOnSecondaries(bo *Backoffer, action twoPhaseCommitAction, keys [][]byte) error {
+	if len(keys) > 0 && bytes.Equal(keys[0], c.primary()) {
+		keys = keys[1:]
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	batches, err := c.groupBatches(bo, action, keys)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(c.doActionOnBatches(bo, action, batches))
+}
+
+// groupBatches groups keys into batches by region, the batches of the region of the
+// first key go first.
+func (c *twoPhaseCommitter) groupBatches(bo *Backoffer, action twoPhaseCommitAction, keys [][]byte) ([]batchKeys, error) {
+	groups, firstRegion, err := c.store.regionCache.GroupKeysByRegion(bo, keys)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	txnRegionsNumHistogram.WithLabelValues(action.MetricsTag()).Observe(float64(len(groups)))
+
+	var batches []batchKeys
+	var sizeFunc = c.keySize
+	if action == actionPrewrite {
+		sizeFunc = c.keyValueSize
+	}
+	// Make sure the group that contains primary key goes first.
+	batches = appendBatchBySize(batches, firstRegion, groups[firstRegion], sizeFunc, txnCommitBatchSize)
+	delete(groups, firstRegion)
+	for id, g := range groups {
+		batches = appendBatchBySize(batches, id, g, sizeFunc, txnCommitBatchSize)
+	}
+	return batches, nil
+}
+
 // reserveStack reserves 4KB memory on the stack to avoid runtime.morestack, call it after new a goroutine if necessary.
 func reserveStack(dummy bool) {
 	var buf [8 << 10]byte
@@ -338,6 +513,9 @@ func (c *twoPhaseCommitter) prewriteSingleBatch(bo *Backoffer, batch batchKeys)
 			LockTtl:      c.lockTTL,
 		},
 	}
+	if c.useAsyncCommit || c.isOnePC() {
+		req = c.buildAsyncPrewriteRequest(batch, mutations)
+	}
 	for {
 		resp, err := c.store.SendReq(bo, req, batch.region, readTimeoutShort)
 		if err != nil {
@@ -348,30 +526,35 @@ func (c *twoPhaseCommitter) prewriteSingleBatch(bo *Backoffer, batch batchKeys)
 			return errors.Trace(err)
 		}
 		if regionErr != nil {
-			err = bo.Backoff(boRegionMiss, errors.New(regionErr.String()))
+			err = bo.Backoff(BoRegionMiss, errors.New(regionErr.String()))
 			if err != nil {
 				return errors.Trace(err)
 			}
 			err = c.prewriteKeys(bo, batch.keys)
 			return errors.Trace(err)
 		}
-		prewriteResp := resp.Prewrite
-		if prewriteResp == nil {
-			return errors.Trace(errBodyMissing)
+		var keyErrs []*pb.KeyError
+		if req.Type == tikvrpc.CmdAsyncPrewrite {
+			prewriteResp := resp.AsyncPrewrite
+			if prewriteResp == nil {
+				return errors.Trace(ErrBodyMissing)
+			}
+			keyErrs = prewriteResp.Errors
+			if len(keyErrs) == 0 {
+				c.onAsyncPrewrite(prewriteResp)
+			}
+		} else {
+			prewriteResp := resp.Prewrite
+			if prewriteResp == nil {
+				return errors.Trace(ErrBodyMissing)
+			}
+			keyErrs = prewriteResp.GetErrors()
 		}
-		keyErrs := prewriteResp.GetErrors()
 		if len(keyErrs) == 0 {
-			// We need to cleanup all written keys if transaction aborts.
-			c.mu.Lock()
-			defer c.mu.Unlock()
-			// Primary key should always been in the front since in `cleanup` we
-			// would check whether the `writtenKeys`'s first key is primary key.
-			if bytes.Equal(batch.keys[0], c.primary()) {
-				tmpKeys := make([][]byte, 0, len(batch.keys)+len(c.mu.writtenKeys))
-				tmpKeys = append(tmpKeys, batch.keys...)
-				c.mu.writtenKeys = append(tmpKeys, c.mu.writtenKeys...)
-			} else {
-				c.mu.writtenKeys = append(c.mu.writtenKeys, batch.keys...)
+			if c.size >= ttlManagedTxnSize && bytes.Equal(batch.keys[0], c.primary()) {
+				// Keep the primary lock alive until all the keys are prewritten and
+				// the primary key is committed.
+				c.txn.ttlManager.run(c.txn, c.primary())
 			}
 			return nil
 		}
@@ -389,7 +572,7 @@ func (c *twoPhaseCommitter) prewriteSingleBatch(bo *Backoffer, batch batchKeys)
 			return errors.Trace(err)
 		}
 		if !ok {
-			err = bo.Backoff(boTxnLock, errors.Errorf("2PC prewrite lockedKeys: %d", len(locks)))
+			err = bo.Backoff(BoTxnLock, errors.Errorf("2PC prewrite lockedKeys: %d", len(locks)))
 			if err != nil {
 				return errors.Trace(err)
 			}
@@ -445,7 +628,7 @@ func (c *twoPhaseCommitter) commitSingleBatch(bo *Backoffer, batch batchKeys) er
 		return errors.Trace(err)
 	}
 	if regionErr != nil {
-		err = bo.Backoff(boRegionMiss, errors.New(regionErr.String()))
+		err = bo.Backoff(BoRegionMiss, errors.New(regionErr.String()))
 		if err != nil {
 			return errors.Trace(err)
 		}
@@ -455,7 +638,7 @@ func (c *twoPhaseCommitter) commitSingleBatch(bo *Backoffer, batch batchKeys) er
 	}
 	commitResp := resp.Commit
 	if commitResp == nil {
-		return errors.Trace(errBodyMissing)
+		return errors.Trace(ErrBodyMissing)
 	}
 	if keyErr := commitResp.GetError(); keyErr != nil {
 		c.mu.RLock()
@@ -497,7 +680,7 @@ func (c *twoPhaseCommitter) cleanupSingleBatch(bo *Backoffer, batch batchKeys) e
 		return errors.Trace(err)
 	}
 	if regionErr != nil {
-		err = bo.Backoff(boRegionMiss, errors.New(regionErr.String()))
+		err = bo.Backoff(BoRegionMiss, errors.New(regionErr.String()))
 		if err != nil {
 			return errors.Trace(err)
 		}
@@ -532,28 +715,46 @@ const maxTxnTimeUse = 590000
 // execute executes the two-phase commit protocol.
 func (c *twoPhaseCommitter) execute() error {
 	defer func() {
-		// Always clean up all written keys if the txn does not commit.
+		c.txn.ttlManager.close()
 		c.mu.RLock()
-		writtenKeys := c.mu.writtenKeys
 		committed := c.mu.committed
 		undetermined := c.mu.undetermined
 		c.mu.RUnlock()
-		if !committed && !undetermined {
-			go func() {
-				reserveStack(false)
-				err := c.cleanupKeys(NewBackoffer(cleanupMaxBackoff, goctx.Background()), writtenKeys)
-				if err != nil {
-					log.Infof("2PC cleanup err: %v, tid: %d", err, c.startTS)
-				} else {
-					log.Infof("2PC clean up done, tid: %d", c.startTS)
-				}
-			}()
+		if committed {
+			// The buffer is released after the secondary keys are committed.
+			return
+		}
+		if undetermined {
+			c.txn.us.Release()
+			return
 		}
+		// Always clean up all written keys if the txn does not commit.
+		go func() {
+			reserveStack(false)
+			err := c.cleanup(NewBackoffer(cleanupMaxBackoff, goctx.Background()))
+			if err != nil {
+				log.Infof("2PC cleanup err: %v, tid: %d", err, c.startTS)
+			} else {
+				log.Infof("2PC clean up done, tid: %d", c.startTS)
+			}
+			c.txn.us.Release()
+		}()
 	}()
 
 	ctx := goctx.Background()
+	c.useAsyncCommit = c.checkAsyncCommit()
+	c.setOnePC(c.checkOnePC())
+	if c.useAsyncCommit || c.isOnePC() {
+		if err := c.calculateMaxCommitTS(); err != nil {
+			return errors.Trace(err)
+		}
+	}
 	binlogChan := c.prewriteBinlog()
-	err := c.prewriteKeys(NewBackoffer(prewriteMaxBackoff, ctx), c.keys)
+	bo := NewBackoffer(prewriteMaxBackoff, ctx)
+	err := c.forEachChunk(-1, func() error {
+		c.prewriteChunks++
+		return errors.Trace(c.prewriteKeys(bo, c.keys))
+	})
 	if binlogChan != nil {
 		binlogErr := <-binlogChan
 		if binlogErr != nil {
@@ -564,6 +765,9 @@ func (c *twoPhaseCommitter) execute() error {
 		log.Debugf("2PC failed on prewrite: %v, tid: %d", err, c.startTS)
 		return errors.Trace(err)
 	}
+	if c.isOnePC() || c.useAsyncCommit {
+		return errors.Trace(c.onPrewriteCommitted())
+	}
 
 	commitTS, err := c.store.getTimestampWithRetry(NewBackoffer(tsoMaxBackoff, ctx))
 	if err != nil {
@@ -580,7 +784,7 @@ func (c *twoPhaseCommitter) execute() error {
 		return errors.Trace(err)
 	}
 	c.commitTS = commitTS
-	if err = c.checkSchemaValid(); err != nil {
+	if err = c.checkSchemaValid(c.commitTS); err != nil {
 		return errors.Trace(err)
 	}
 
@@ -589,7 +793,7 @@ func (c *twoPhaseCommitter) execute() error {
 		return errors.Annotate(err, txnRetryableMark)
 	}
 
-	err = c.commitKeys(NewBackoffer(commitMaxBackoff, ctx), c.keys)
+	err = c.commitKeys(NewBackoffer(commitMaxBackoff, ctx), [][]byte{c.primary()})
 	if err != nil {
 		if errors.Cause(err) == terror.ErrResultUndetermined {
 			c.mu.undetermined = true
@@ -600,17 +804,80 @@ func (c *twoPhaseCommitter) execute() error {
 		}
 		log.Debugf("2PC succeed with error: %v, tid: %d", err, c.startTS)
 	}
+	// Commit the secondary keys in background goroutine to reduce latency.
+	go func() {
+		reserveStack(false)
+		c.commitSecondaries()
+	}()
 	return nil
 }
 
+// onPrewriteCommitted finishes the transaction committed by 1PC or async commit after the
+// prewrite, the keys of an async commit transaction are committed in background.
+func (c *twoPhaseCommitter) onPrewriteCommitted() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.mu.useOnePC {
+		if c.mu.onePCCommitTS == 0 {
+			return errors.Errorf("invalid 1PC commit ts, tid: %d", c.startTS)
+		}
+		c.commitTS = c.mu.onePCCommitTS
+		c.mu.committed = true
+		c.txn.us.Release()
+		return nil
+	}
+	if c.mu.minCommitTS == 0 {
+		return errors.Errorf("invalid async commit ts, tid: %d", c.startTS)
+	}
+	c.commitTS = c.mu.minCommitTS
+	c.mu.committed = true
+	go func() {
+		reserveStack(false)
+		c.commitAsyncCommitTxn()
+	}()
+	return nil
+}
+
+// commitSecondaries commits the secondary keys chunk by chunk after the primary key
+// is committed, then it releases the buffer of the transaction.
+func (c *twoPhaseCommitter) commitSecondaries() {
+	err := c.forEachChunk(-1, func() error {
+		e := c.doActionOnSecondaries(NewBackoffer(commitMaxBackoff, goctx.Background()), actionCommit, c.keys)
+		if e != nil {
+			// The locks left are resolved by the readers.
+			log.Debugf("2PC async commit secondaries err: %v, tid: %d", e, c.startTS)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Debugf("2PC async commit secondaries err: %v, tid: %d", err, c.startTS)
+	}
+	c.txn.us.Release()
+}
+
+// cleanup rolls back the keys of the chunks whose prewrite is started.
+func (c *twoPhaseCommitter) cleanup(bo *Backoffer) error {
+	if c.prewriteChunks == 0 {
+		return nil
+	}
+	// Roll back the primary key first, so the other locks are resolved as rolled back.
+	if err := c.cleanupKeys(bo, [][]byte{c.primary()}); err != nil {
+		return errors.Trace(err)
+	}
+	err := c.forEachChunk(c.prewriteChunks, func() error {
+		return errors.Trace(c.doActionOnSecondaries(bo, actionCleanup, c.keys))
+	})
+	return errors.Trace(err)
+}
+
 type schemaLeaseChecker interface {
 	Check(txnTS uint64) error
 }
 
-func (c *twoPhaseCommitter) checkSchemaValid() error {
+func (c *twoPhaseCommitter) checkSchemaValid(ts uint64) error {
 	checker, ok := c.txn.us.GetOption(kv.SchemaLeaseChecker).(schemaLeaseChecker)
 	if ok {
-		err := checker.Check(c.commitTS)
+		err := checker.Check(ts)
 		if err != nil {
 			return errors.Trace(err)
 		}
@@ -628,7 +895,7 @@ func (c *twoPhaseCommitter) prewriteBinlog() chan error {
 		bin := binInfo.Data
 		bin.StartTs = int64(c.startTS)
 		if bin.Tp == binlog.BinlogType_Prewrite {
-			bin.PrewriteKey = c.keys[0]
+			bin.PrewriteKey = c.primary()
 		}
 		err := binInfo.WriteBinlog(c.store.clusterID)
 		ch <- errors.Trace(err)
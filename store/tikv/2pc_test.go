@@ -14,6 +14,7 @@
 package tikv
 
 import (
+	"fmt"
 	"math"
 	"math/rand"
 	"strings"
@@ -44,6 +45,7 @@ func (s *testCommitterSuite) SetUpTest(c *C) {
 	pdCli := &codecPDClient{mocktikv.NewPDClient(s.cluster)}
 	store, err := newTikvStore("mock-tikv-store", pdCli, client, false)
 	c.Assert(err, IsNil)
+	store.mock = true
 	s.store = store
 	commitMaxBackoff = 2000
 }
@@ -495,3 +497,92 @@ func (s *testCommitterSuite) TestCommitTimeout(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(len(value), Greater, 0)
 }
+
+func (s *testCommitterSuite) TestChunkedCommit(c *C) {
+	originChunkSize := txnCommitChunkSize
+	txnCommitChunkSize = 64
+	defer func() {
+		txnCommitChunkSize = originChunkSize
+	}()
+
+	m := make(map[string]string)
+	txn := s.begin(c)
+	for i := 0; i < 100; i++ {
+		k, v := fmt.Sprintf("key%03d", i), fmt.Sprintf("value%03d", i)
+		m[k] = v
+		c.Assert(txn.Set([]byte(k), []byte(v)), IsNil)
+	}
+	c.Assert(txn.LockKeys([]byte("lock1"), []byte("key000")), IsNil)
+	committer, err := newTwoPhaseCommitter(txn)
+	c.Assert(err, IsNil)
+	c.Assert(committer.chunked, IsTrue)
+	c.Assert(committer.primary(), BytesEquals, []byte("key000"))
+	var chunks, keys int
+	err = committer.forEachChunk(-1, func() error {
+		chunks++
+		keys += len(committer.keys)
+		return nil
+	})
+	c.Assert(err, IsNil)
+	c.Assert(chunks > 1, IsTrue)
+	c.Assert(keys, Equals, 101)
+
+	c.Assert(txn.Commit(), IsNil)
+	s.checkValues(c, m)
+
+	// A failed chunked txn is cleaned up.
+	txn1 := s.begin(c)
+	for i := 0; i < 100; i++ {
+		c.Assert(txn1.Set([]byte(fmt.Sprintf("key%03d", i)), []byte("v1")), IsNil)
+	}
+	s.mustCommit(c, map[string]string{"key099": "v2"})
+	c.Assert(txn1.Commit(), NotNil)
+	m["key099"] = "v2"
+	s.checkValues(c, m)
+}
+
+func (s *testCommitterSuite) TestTxnHeartBeat(c *C) {
+	originInterval := ttlManagerInterval
+	ttlManagerInterval = 10 * time.Millisecond
+	defer func() {
+		ttlManagerInterval = originInterval
+	}()
+
+	txn := s.begin(c)
+	c.Assert(txn.Set([]byte("a"), []byte("a1")), IsNil)
+	c.Assert(txn.Set([]byte("b"), []byte("b1")), IsNil)
+	committer, err := newTwoPhaseCommitter(txn)
+	c.Assert(err, IsNil)
+	bo := NewBackoffer(prewriteMaxBackoff, goctx.Background())
+	c.Assert(committer.prewriteKeys(bo, committer.keys), IsNil)
+
+	ttl, err := sendTxnHeartBeat(bo, s.store, []byte("a"), txn.startTS, 0)
+	c.Assert(err, IsNil)
+	c.Assert(ttl, Equals, committer.lockTTL)
+
+	txn.ttlManager.run(txn, []byte("a"))
+	for i := 0; i < 100; i++ {
+		ttl, err = sendTxnHeartBeat(bo, s.store, []byte("a"), txn.startTS, 0)
+		c.Assert(err, IsNil)
+		if ttl > managedLockTTL {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	c.Assert(ttl > managedLockTTL, IsTrue)
+	txn.ttlManager.close()
+
+	// The lock of "b" is expired by its own ttl, but the primary lock is alive.
+	lock := &Lock{Key: []byte("b"), Primary: []byte("a"), TxnID: txn.startTS, TTL: 0}
+	ok, err := s.store.lockResolver.ResolveLocks(bo, []*Lock{lock})
+	c.Assert(err, IsNil)
+	c.Assert(ok, IsFalse)
+
+	// The lock is resolved when the primary lock is confirmed gone.
+	c.Assert(committer.cleanupKeys(bo, [][]byte{[]byte("a")}), IsNil)
+	_, err = sendTxnHeartBeat(bo, s.store, []byte("a"), txn.startTS, 0)
+	c.Assert(errors.Cause(err), Equals, errPrimaryLockNotFound)
+	ok, err = s.store.lockResolver.ResolveLocks(bo, []*Lock{lock})
+	c.Assert(err, IsNil)
+	c.Assert(ok, IsTrue)
+}
@@ -0,0 +1,280 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tikv
+
+import (
+	"bytes"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/coreos/etcd/pkg/monotime"
+	"github.com/juju/errors"
+	pb "github.com/pingcap/kvproto/pkg/kvrpcpb"
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/store/tikv/oracle"
+	"github.com/pingcap/tidb/store/tikv/tikvrpc"
+	goctx "golang.org/x/net/context"
+)
+
+// Async commit and 1PC are only used by the small transactions, the primary lock of an
+// async commit transaction holds all its secondary keys.
+const (
+	asyncCommitKeysLimit     = 256
+	asyncCommitKeysSizeLimit = 4096
+)
+
+// asyncCommitSafeWindow bounds the commit ts of an async commit or 1PC transaction after
+// the ts its schema is checked at. It should be much less than the schema lease, so the
+// schema can't change before the commit ts.
+var asyncCommitSafeWindow = 2 * time.Second
+
+// supportAsyncCommit checks whether the store serves the async prewrite and the txn
+// status requests, the vendored kvproto has no such commands, only mock-tikv serves them.
+func supportAsyncCommit(store *tikvStore) bool {
+	return store.mock
+}
+
+// checkAsyncCommit checks whether the transaction can be committed by the async commit
+// protocol, in which the transaction is committed once all its keys are prewritten.
+func (c *twoPhaseCommitter) checkAsyncCommit() bool {
+	if enabled, _ := c.txn.us.GetOption(kv.EnableAsyncCommit).(bool); !enabled || !supportAsyncCommit(c.store) {
+		return false
+	}
+	if c.chunked || c.shouldWriteBinlog() || len(c.keys) > asyncCommitKeysLimit {
+		return false
+	}
+	size := 0
+	for _, k := range c.keys {
+		size += len(k)
+	}
+	return size <= asyncCommitKeysSizeLimit
+}
+
+// checkOnePC checks whether the transaction can try 1PC, it's committed by the prewrite
+// request if all its keys are in a single batch.
+func (c *twoPhaseCommitter) checkOnePC() bool {
+	if enabled, _ := c.txn.us.GetOption(kv.Enable1PC).(bool); !enabled || !supportAsyncCommit(c.store) {
+		return false
+	}
+	return !c.chunked && !c.shouldWriteBinlog()
+}
+
+func (c *twoPhaseCommitter) isOnePC() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.mu.useOnePC
+}
+
+func (c *twoPhaseCommitter) setOnePC(useOnePC bool) {
+	c.mu.Lock()
+	c.mu.useOnePC = useOnePC
+	c.mu.Unlock()
+}
+
+// calculateMaxCommitTS checks the schema at the current ts and bounds the commit ts by
+// asyncCommitSafeWindow after it. The commit ts of async commit and 1PC is calculated by
+// the storage, so the schema can't be checked at the commit ts before the commit.
+func (c *twoPhaseCommitter) calculateMaxCommitTS() error {
+	elapsed := time.Duration(monotime.Now()-c.txn.startTime) / time.Millisecond
+	currentTS := c.startTS + oracle.ComposeTS(int64(elapsed), 0)
+	if err := c.checkSchemaValid(currentTS); err != nil {
+		return errors.Trace(err)
+	}
+	c.maxCommitTS = currentTS + oracle.ComposeTS(int64(asyncCommitSafeWindow/time.Millisecond), 0)
+	return nil
+}
+
+// secondaries returns the keys except the primary key.
+func (c *twoPhaseCommitter) secondaries() [][]byte {
+	secondaries := make([][]byte, 0, len(c.keys))
+	for _, k := range c.keys {
+		if !bytes.Equal(k, c.primary()) {
+			secondaries = append(secondaries, k)
+		}
+	}
+	return secondaries
+}
+
+func (c *twoPhaseCommitter) buildAsyncPrewriteRequest(batch batchKeys, mutations []*pb.Mutation) *tikvrpc.Request {
+	req := &tikvrpc.AsyncPrewriteRequest{
+		Mutations:    mutations,
+		PrimaryLock:  c.primary(),
+		StartVersion: c.startTS,
+		LockTtl:      c.lockTTL,
+		MaxCommitTs:  c.maxCommitTS,
+	}
+	if c.isOnePC() {
+		req.TryOnePc = true
+	} else {
+		req.UseAsyncCommit = true
+		if bytes.Equal(batch.keys[0], c.primary()) {
+			req.Secondaries = c.secondaries()
+		}
+	}
+	return &tikvrpc.Request{
+		Type:          tikvrpc.CmdAsyncPrewrite,
+		Priority:      c.priority,
+		AsyncPrewrite: req,
+	}
+}
+
+// onAsyncPrewrite records the commit ts calculated by the storage.
+func (c *twoPhaseCommitter) onAsyncPrewrite(resp *tikvrpc.AsyncPrewriteResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if resp.OnePcCommitTs > 0 {
+		c.mu.onePCCommitTS = resp.OnePcCommitTs
+	}
+	if resp.MinCommitTs > c.mu.minCommitTS {
+		c.mu.minCommitTS = resp.MinCommitTs
+	}
+}
+
+// commitAsyncCommitTxn commits the keys of an async commit transaction which has been
+// committed logically, then it releases the buffer of the transaction.
+func (c *twoPhaseCommitter) commitAsyncCommitTxn() {
+	err := c.commitKeys(NewBackoffer(commitMaxBackoff, goctx.Background()), [][]byte{c.primary()})
+	if err != nil {
+		// The locks left are resolved by the readers.
+		log.Debugf("2PC async commit primary err: %v, tid: %d", err, c.startTS)
+	}
+	c.commitSecondaries()
+}
+
+// getAsyncCommitTxnStatus decides the status of an async commit transaction whose primary
+// key is still locked. The transaction is committed if all its keys are prewritten, and
+// the commit ts is the max min commit ts of the locks. Otherwise it's rolled back, the keys
+// not prewritten are rolled back by checking the secondary locks so they can't be prewritten
+// later.
+func (lr *LockResolver) getAsyncCommitTxnStatus(bo *Backoffer, txnID uint64, primary []byte) (TxnStatus, error) {
+	lockResolverCounter.WithLabelValues("query_async_commit_txn_status").Inc()
+
+	primaryStatus, err := lr.checkTxnStatus(bo, txnID, primary)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	if primaryStatus.LockTtl == 0 {
+		return TxnStatus(primaryStatus.CommitVersion), nil
+	}
+	if !primaryStatus.UseAsyncCommit {
+		return 0, errors.Errorf("unexpected lock on the primary key of txn %d", txnID)
+	}
+
+	groups, _, err := lr.store.regionCache.GroupKeysByRegion(bo, primaryStatus.Secondaries)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	commitTS := primaryStatus.MinCommitTs
+	for region, keys := range groups {
+		resp, err := lr.checkSecondaryLocks(bo, txnID, region, keys)
+		if err != nil {
+			return 0, errors.Trace(err)
+		}
+		if resp == nil {
+			// The region is changed, check the secondary locks again.
+			return lr.getAsyncCommitTxnStatus(bo, txnID, primary)
+		}
+		if resp.CommitTs != 0 {
+			commitTS = resp.CommitTs
+			break
+		}
+		if len(resp.Locks) < len(keys) {
+			commitTS = 0
+			break
+		}
+		if resp.MinCommitTs > commitTS {
+			commitTS = resp.MinCommitTs
+		}
+	}
+	status := TxnStatus(commitTS)
+	err = lr.resolveLock(bo, &Lock{Key: primary, Primary: primary, TxnID: txnID}, status, make(map[RegionVerID]struct{}))
+	return status, errors.Trace(err)
+}
+
+// checkTxnStatus queries the status of a transaction by its primary key without rolling
+// back the primary lock.
+func (lr *LockResolver) checkTxnStatus(bo *Backoffer, txnID uint64, primary []byte) (*tikvrpc.CheckTxnStatusResponse, error) {
+	req := &tikvrpc.Request{
+		Type: tikvrpc.CmdCheckTxnStatus,
+		CheckTxnStatus: &tikvrpc.CheckTxnStatusRequest{
+			PrimaryKey: primary,
+			LockTs:     txnID,
+		},
+	}
+	for {
+		loc, err := lr.store.regionCache.LocateKey(bo, primary)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		resp, err := lr.store.SendReq(bo, req, loc.Region, readTimeoutShort)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		regionErr, err := resp.GetRegionError()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if regionErr != nil {
+			err = bo.Backoff(BoRegionMiss, errors.New(regionErr.String()))
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			continue
+		}
+		cmdResp := resp.CheckTxnStatus
+		if cmdResp == nil {
+			return nil, errors.Trace(ErrBodyMissing)
+		}
+		if keyErr := cmdResp.Error; keyErr != nil {
+			err = errors.Errorf("unexpected check txn status err: %s, tid: %v", keyErr, txnID)
+			log.Error(err)
+			return nil, err
+		}
+		return cmdResp, nil
+	}
+}
+
+// checkSecondaryLocks checks the secondary locks in a region, it returns nil if the region
+// is changed.
+func (lr *LockResolver) checkSecondaryLocks(bo *Backoffer, txnID uint64, region RegionVerID, keys [][]byte) (*tikvrpc.CheckSecondaryLocksResponse, error) {
+	req := &tikvrpc.Request{
+		Type: tikvrpc.CmdCheckSecondaryLocks,
+		CheckSecondaryLocks: &tikvrpc.CheckSecondaryLocksRequest{
+			Keys:         keys,
+			StartVersion: txnID,
+		},
+	}
+	resp, err := lr.store.SendReq(bo, req, region, readTimeoutShort)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	regionErr, err := resp.GetRegionError()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if regionErr != nil {
+		err = bo.Backoff(BoRegionMiss, errors.New(regionErr.String()))
+		return nil, errors.Trace(err)
+	}
+	cmdResp := resp.CheckSecondaryLocks
+	if cmdResp == nil {
+		return nil, errors.Trace(ErrBodyMissing)
+	}
+	if keyErr := cmdResp.Error; keyErr != nil {
+		err = errors.Errorf("unexpected check secondary locks err: %s, tid: %v", keyErr, txnID)
+		log.Error(err)
+		return nil, err
+	}
+	return cmdResp, nil
+}
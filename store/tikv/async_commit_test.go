@@ -0,0 +1,146 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tikv
+
+import (
+	"fmt"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/kv"
+	goctx "golang.org/x/net/context"
+)
+
+func (s *testCommitterSuite) beginWithOption(c *C, opt kv.Option, m map[string]string) *tikvTxn {
+	txn := s.begin(c)
+	txn.SetOption(opt, true)
+	for k, v := range m {
+		c.Assert(txn.Set([]byte(k), []byte(v)), IsNil)
+	}
+	return txn
+}
+
+func (s *testCommitterSuite) TestOnePC(c *C) {
+	// The keys are in the same region.
+	m := map[string]string{"a1": "v1", "a2": "v2", "a3": "v3"}
+	txn := s.beginWithOption(c, kv.Enable1PC, m)
+	committer, err := newTwoPhaseCommitter(txn)
+	c.Assert(err, IsNil)
+	c.Assert(committer.execute(), IsNil)
+	c.Assert(committer.isOnePC(), IsTrue)
+	c.Assert(committer.commitTS, Greater, txn.startTS)
+	s.checkValues(c, m)
+
+	// The keys are in different regions, they are committed by 2PC.
+	m = map[string]string{"a1": "v4", "b1": "v5"}
+	txn = s.beginWithOption(c, kv.Enable1PC, m)
+	committer, err = newTwoPhaseCommitter(txn)
+	c.Assert(err, IsNil)
+	c.Assert(committer.execute(), IsNil)
+	c.Assert(committer.isOnePC(), IsFalse)
+	s.checkValues(c, m)
+
+	// The store doesn't support 1PC, the keys are committed by 2PC.
+	s.store.mock = false
+	defer func() {
+		s.store.mock = true
+	}()
+	m = map[string]string{"a1": "v6", "a2": "v7"}
+	txn = s.beginWithOption(c, kv.Enable1PC, m)
+	committer, err = newTwoPhaseCommitter(txn)
+	c.Assert(err, IsNil)
+	c.Assert(committer.execute(), IsNil)
+	c.Assert(committer.isOnePC(), IsFalse)
+	s.checkValues(c, m)
+}
+
+func (s *testCommitterSuite) TestAsyncCommit(c *C) {
+	s.mustCommit(c, map[string]string{"a": "a0", "b": "b0"})
+
+	reader := s.begin(c)
+	m := map[string]string{"a": "a1", "b": "b1", "c": "c1"}
+	txn := s.beginWithOption(c, kv.EnableAsyncCommit, m)
+	c.Assert(txn.Commit(), IsNil)
+	c.Assert(txn.commitTS, Greater, reader.startTS)
+	s.checkValues(c, m)
+
+	// The transaction is too large for async commit.
+	txn = s.beginWithOption(c, kv.EnableAsyncCommit, nil)
+	for i := 0; i <= asyncCommitKeysLimit; i++ {
+		c.Assert(txn.Set([]byte(fmt.Sprintf("a%d", i)), []byte("v")), IsNil)
+	}
+	committer, err := newTwoPhaseCommitter(txn)
+	c.Assert(err, IsNil)
+	c.Assert(committer.checkAsyncCommit(), IsFalse)
+}
+
+// prewriteAsyncCommit prewrites the keys of an async commit transaction, the primary
+// key is "a" and the secondaries are "b" and "c".
+func (s *testCommitterSuite) prewriteAsyncCommit(c *C, keys ...string) *twoPhaseCommitter {
+	txn := s.beginWithOption(c, kv.EnableAsyncCommit, map[string]string{"a": "a1", "b": "b1", "c": "c1"})
+	committer, err := newTwoPhaseCommitter(txn)
+	c.Assert(err, IsNil)
+	c.Assert(committer.checkAsyncCommit(), IsTrue)
+	committer.useAsyncCommit = true
+	c.Assert(committer.calculateMaxCommitTS(), IsNil)
+	bo := NewBackoffer(prewriteMaxBackoff, goctx.Background())
+	var prewriteKeys [][]byte
+	for _, k := range keys {
+		prewriteKeys = append(prewriteKeys, []byte(k))
+	}
+	c.Assert(committer.prewriteKeys(bo, prewriteKeys), IsNil)
+	return committer
+}
+
+func (s *testCommitterSuite) TestResolveAsyncCommitLocks(c *C) {
+	s.mustCommit(c, map[string]string{"a": "a0", "b": "b0", "c": "c0"})
+
+	// The reads before the prewrite don't wait for the locks.
+	reader := s.begin(c)
+	committer := s.prewriteAsyncCommit(c, "a", "b", "c")
+	c.Assert(committer.mu.minCommitTS, Greater, reader.startTS)
+	val, err := reader.Get([]byte("b"))
+	c.Assert(err, IsNil)
+	c.Assert(string(val), Equals, "b0")
+
+	// All the keys are prewritten, the transaction is committed.
+	bo := NewBackoffer(cleanupMaxBackoff, goctx.Background())
+	lr := s.store.lockResolver
+	status, err := lr.getTxnStatus(bo, committer.startTS, []byte("a"))
+	c.Assert(err, IsNil)
+	c.Assert(status.IsCommitted(), IsTrue)
+	c.Assert(status.CommitTS(), Equals, committer.mu.minCommitTS)
+	for _, k := range []string{"b", "c"} {
+		lock := &Lock{Key: []byte(k), Primary: []byte("a"), TxnID: committer.startTS}
+		c.Assert(lr.resolveLock(bo, lock, status, make(map[RegionVerID]struct{})), IsNil)
+	}
+	s.checkValues(c, map[string]string{"a": "a1", "b": "b1", "c": "c1"})
+}
+
+func (s *testCommitterSuite) TestRollbackAsyncCommitTxn(c *C) {
+	s.mustCommit(c, map[string]string{"a": "a0", "b": "b0", "c": "c0"})
+
+	// "c" isn't prewritten, the transaction is rolled back.
+	committer := s.prewriteAsyncCommit(c, "a", "b")
+	bo := NewBackoffer(cleanupMaxBackoff, goctx.Background())
+	lr := s.store.lockResolver
+	status, err := lr.getTxnStatus(bo, committer.startTS, []byte("a"))
+	c.Assert(err, IsNil)
+	c.Assert(status.IsCommitted(), IsFalse)
+	lock := &Lock{Key: []byte("b"), Primary: []byte("a"), TxnID: committer.startTS}
+	c.Assert(lr.resolveLock(bo, lock, status, make(map[RegionVerID]struct{})), IsNil)
+	s.checkValues(c, map[string]string{"a": "a0", "b": "b0", "c": "c0"})
+
+	// "c" can't be prewritten after the transaction is rolled back.
+	c.Assert(committer.prewriteKeys(bo, [][]byte{[]byte("c")}), NotNil)
+}
@@ -69,12 +69,13 @@ func expo(base, cap, n int) int {
 
 type backoffType int
 
+// Back off types, BoTxnLock and BoRegionMiss are exported for the GC worker.
 const (
 	boTiKVRPC backoffType = iota
-	boTxnLock
+	BoTxnLock
 	boTxnLockFast
 	boPDRPC
-	boRegionMiss
+	BoRegionMiss
 	boServerBusy
 )
 
@@ -82,13 +83,13 @@ func (t backoffType) createFn() func() int {
 	switch t {
 	case boTiKVRPC:
 		return NewBackoffFn(100, 2000, EqualJitter)
-	case boTxnLock:
+	case BoTxnLock:
 		return NewBackoffFn(200, 3000, EqualJitter)
 	case boTxnLockFast:
 		return NewBackoffFn(100, 3000, EqualJitter)
 	case boPDRPC:
 		return NewBackoffFn(500, 3000, EqualJitter)
-	case boRegionMiss:
+	case BoRegionMiss:
 		return NewBackoffFn(100, 500, NoJitter)
 	case boServerBusy:
 		return NewBackoffFn(2000, 10000, EqualJitter)
@@ -100,13 +101,13 @@ func (t backoffType) String() string {
 	switch t {
 	case boTiKVRPC:
 		return "tikvRPC"
-	case boTxnLock:
+	case BoTxnLock:
 		return "txnLock"
 	case boTxnLockFast:
 		return "txnLockFast"
 	case boPDRPC:
 		return "pdRPC"
-	case boRegionMiss:
+	case BoRegionMiss:
 		return "regionMiss"
 	case boServerBusy:
 		return "serverBusy"
@@ -116,18 +117,15 @@ func (t backoffType) String() string {
 
 // Maximum total sleep time(in ms) for kv/cop commands.
 const (
-	copBuildTaskMaxBackoff  = 5000
-	tsoMaxBackoff           = 5000
-	scannerNextMaxBackoff   = 20000
-	batchGetMaxBackoff      = 20000
-	copNextMaxBackoff       = 20000
-	getMaxBackoff           = 20000
-	prewriteMaxBackoff      = 20000
-	cleanupMaxBackoff       = 20000
-	gcMaxBackoff            = 100000
-	gcResolveLockMaxBackoff = 100000
-	gcDeleteRangeMaxBackoff = 100000
-	rawkvMaxBackoff         = 20000
+	copBuildTaskMaxBackoff = 5000
+	tsoMaxBackoff          = 5000
+	scannerNextMaxBackoff  = 20000
+	batchGetMaxBackoff     = 20000
+	copNextMaxBackoff      = 20000
+	getMaxBackoff          = 20000
+	prewriteMaxBackoff     = 20000
+	cleanupMaxBackoff      = 20000
+	rawkvMaxBackoff        = 20000
 )
 
 var commitMaxBackoff = 20000
@@ -30,10 +30,16 @@ import (
 const (
 	maxConnectionNumber = 16
 	dialTimeout         = 5 * time.Second
-	readTimeoutShort    = 20 * time.Second  // For requests that read/write several key-values.
-	readTimeoutMedium   = 60 * time.Second  // For requests that may need scan region.
-	readTimeoutLong     = 150 * time.Second // For requests that may need scan region multiple times.
+	readTimeoutShort    = 20 * time.Second // For requests that read/write several key-values.
+)
 
+// Timeouts of the requests that may scan regions, they are exported for the GC worker.
+const (
+	ReadTimeoutMedium = 60 * time.Second  // For requests that may need scan region.
+	ReadTimeoutLong   = 150 * time.Second // For requests that may need scan region multiple times.
+)
+
+const (
 	grpcInitialWindowSize     = 1 << 30
 	grpcInitialConnWindowSize = 1 << 30
 
@@ -313,6 +319,11 @@ func (c *rpcClient) callRPC(ctx goctx.Context, client tikvpb.TikvClient, req *ti
 		}
 		resp.MvccGetByStartTS = r
 		return resp, nil
+	case tikvrpc.CmdPessimisticLock, tikvrpc.CmdPessimisticRollback, tikvrpc.CmdTxnHeartBeat,
+		tikvrpc.CmdAsyncPrewrite, tikvrpc.CmdCheckTxnStatus, tikvrpc.CmdCheckSecondaryLocks,
+		tikvrpc.CmdRawBatchGet, tikvrpc.CmdRawBatchPut, tikvrpc.CmdRawBatchDelete, tikvrpc.CmdRawDeleteRange,
+		tikvrpc.CmdRawReverseScan, tikvrpc.CmdRawCompareAndSwap, tikvrpc.CmdRawGetKeyTTL:
+		return nil, errors.Annotatef(errUnsupportedCmd, "request type %v", req.Type)
 	default:
 		return nil, errors.Errorf("invalid request type: %v", req.Type)
 	}
@@ -0,0 +1,367 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tikv
+
+import (
+	"encoding/binary"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/juju/errors"
+	"github.com/pingcap/kvproto/pkg/kvrpcpb"
+	"github.com/pingcap/tidb/config"
+	"github.com/pingcap/tidb/store/tikv/tikvrpc"
+	goctx "golang.org/x/net/context"
+)
+
+const (
+	// batchQueueFactor times the max batch size is the number of the requests queued for
+	// a store, the senders are blocked when the queue is full.
+	batchQueueFactor = 4
+	// maxBatchInflight is the max number of the concurrent requests sent by the batches of
+	// a store, a batch isn't sent until one of them finishes.
+	maxBatchInflight = 64
+)
+
+var errBatchClientClosed = errors.New("batchClient is closed")
+
+// batchClient coalesces the point gets sent to the same store. The gets are queued by
+// store and collected into batches, and the gets of a batch reading the same region at
+// the same version are sent in a KvBatchGet. The other requests are sent by the wrapped
+// client directly. The errors are returned to each request the same as the wrapped
+// client, so the retries of RegionRequestSender work as before.
+type batchClient struct {
+	Client
+	maxBatchSize  int
+	maxWaitTime   time.Duration
+	batchWaitSize int
+
+	mu struct {
+		sync.RWMutex
+		closed bool
+		conns  map[string]*batchConn
+	}
+}
+
+// newBatchClient wraps the client with a batchClient by the config, it returns the client
+// itself if the batching is disabled.
+func newBatchClient(client Client, cfg config.TiKVClient) Client {
+	if cfg.MaxBatchSize == 0 {
+		return client
+	}
+	c := &batchClient{
+		Client:        client,
+		maxBatchSize:  int(cfg.MaxBatchSize),
+		batchWaitSize: int(cfg.BatchWaitSize),
+	}
+	if cfg.MaxBatchWaitTime != "" {
+		d, err := time.ParseDuration(cfg.MaxBatchWaitTime)
+		if err != nil {
+			log.Warnf("[kv] invalid max-batch-wait-time %s, the batches don't wait: %v", cfg.MaxBatchWaitTime, err)
+		}
+		c.maxWaitTime = d
+	}
+	c.mu.conns = make(map[string]*batchConn)
+	return c
+}
+
+// batchEntry is a queued point get.
+type batchEntry struct {
+	ctx  goctx.Context
+	req  *tikvrpc.Request
+	resp chan batchResult
+}
+
+type batchResult struct {
+	resp *kvrpcpb.GetResponse
+	err  error
+}
+
+func (e *batchEntry) finish(resp *kvrpcpb.GetResponse, err error) {
+	// resp is buffered, the sender may have given up waiting.
+	e.resp <- batchResult{resp: resp, err: err}
+}
+
+// SendReq implements the Client SendReq interface.
+func (c *batchClient) SendReq(ctx goctx.Context, addr string, req *tikvrpc.Request) (*tikvrpc.Response, error) {
+	if req.Type != tikvrpc.CmdGet {
+		return c.Client.SendReq(ctx, addr, req)
+	}
+	conn, err := c.getConn(addr)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	entry := &batchEntry{ctx: ctx, req: req, resp: make(chan batchResult, 1)}
+	select {
+	case conn.reqCh <- entry:
+	case <-ctx.Done():
+		return nil, errors.Trace(ctx.Err())
+	case <-conn.closed:
+		return nil, errors.Trace(errBatchClientClosed)
+	}
+	select {
+	case res := <-entry.resp:
+		if res.err != nil {
+			return nil, errors.Trace(res.err)
+		}
+		return &tikvrpc.Response{Type: tikvrpc.CmdGet, Get: res.resp}, nil
+	case <-ctx.Done():
+		return nil, errors.Trace(ctx.Err())
+	case <-conn.closed:
+		return nil, errors.Trace(errBatchClientClosed)
+	}
+}
+
+func (c *batchClient) getConn(addr string) (*batchConn, error) {
+	c.mu.RLock()
+	conn, ok := c.mu.conns[addr]
+	closed := c.mu.closed
+	c.mu.RUnlock()
+	if closed {
+		return nil, errBatchClientClosed
+	}
+	if ok {
+		return conn, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.mu.closed {
+		return nil, errBatchClientClosed
+	}
+	if conn, ok = c.mu.conns[addr]; !ok {
+		conn = &batchConn{
+			client:   c,
+			addr:     addr,
+			reqCh:    make(chan *batchEntry, c.maxBatchSize*batchQueueFactor),
+			inflight: make(chan struct{}, maxBatchInflight),
+			closed:   make(chan struct{}),
+		}
+		c.mu.conns[addr] = conn
+		go conn.run()
+	}
+	return conn, nil
+}
+
+// Close implements the Client Close interface.
+func (c *batchClient) Close() error {
+	c.mu.Lock()
+	if !c.mu.closed {
+		c.mu.closed = true
+		for _, conn := range c.mu.conns {
+			close(conn.closed)
+		}
+	}
+	c.mu.Unlock()
+	return errors.Trace(c.Client.Close())
+}
+
+// batchConn collects the queued point gets of a store into batches.
+type batchConn struct {
+	client   *batchClient
+	addr     string
+	reqCh    chan *batchEntry
+	inflight chan struct{}
+	closed   chan struct{}
+}
+
+func (b *batchConn) run() {
+	// avgBatchSize is the moving average of the batch sizes, a batch waits for more requests
+	// only if the recent batches are large, which means there are many concurrent requests.
+	var avgBatchSize float64
+	for {
+		var entries []*batchEntry
+		select {
+		case entry := <-b.reqCh:
+			entries = append(entries, entry)
+		case <-b.closed:
+			return
+		}
+		entries = b.collect(entries, avgBatchSize >= float64(b.client.batchWaitSize))
+		avgBatchSize = avgBatchSize*0.8 + float64(len(entries))*0.2
+		batchGetSizeHistogram.Observe(float64(len(entries)))
+		if !b.dispatch(entries) {
+			return
+		}
+	}
+}
+
+// collect fetches the queued requests until the batch is full. If wait is set, it waits for
+// the later requests until the max wait time passes, otherwise it returns when the queue
+// is empty.
+func (b *batchConn) collect(entries []*batchEntry, wait bool) []*batchEntry {
+	maxSize := b.client.maxBatchSize
+	for len(entries) < maxSize {
+		select {
+		case entry := <-b.reqCh:
+			entries = append(entries, entry)
+			continue
+		default:
+		}
+		break
+	}
+	if !wait || b.client.maxWaitTime <= 0 || len(entries) >= maxSize {
+		return entries
+	}
+	timer := time.NewTimer(b.client.maxWaitTime)
+	defer timer.Stop()
+	for len(entries) < maxSize {
+		select {
+		case entry := <-b.reqCh:
+			entries = append(entries, entry)
+		case <-timer.C:
+			return entries
+		case <-b.closed:
+			return entries
+		}
+	}
+	return entries
+}
+
+// dispatch groups the requests by region and version, and sends the groups concurrently.
+// It returns false if the client is closed.
+func (b *batchConn) dispatch(entries []*batchEntry) bool {
+	groups := make(map[string][]*batchEntry)
+	var keys []string
+	for _, entry := range entries {
+		// The requests already timed out or canceled are not sent.
+		if entry.ctx.Err() != nil {
+			entry.finish(nil, entry.ctx.Err())
+			continue
+		}
+		key, err := batchGroupKey(entry.req)
+		if err != nil {
+			entry.finish(nil, err)
+			continue
+		}
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], entry)
+	}
+	for _, key := range keys {
+		select {
+		case b.inflight <- struct{}{}:
+		case <-b.closed:
+			for _, k := range keys {
+				for _, entry := range groups[k] {
+					entry.finish(nil, errBatchClientClosed)
+				}
+			}
+			return false
+		}
+		go func(group []*batchEntry) {
+			defer func() { <-b.inflight }()
+			b.sendGroup(group)
+		}(groups[key])
+		delete(groups, key)
+	}
+	return true
+}
+
+// batchGroupKey returns the key of the gets that can be merged into a KvBatchGet, which
+// have the same request context and version.
+func batchGroupKey(req *tikvrpc.Request) (string, error) {
+	data, err := req.Get.GetContext().Marshal()
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	var buf [9]byte
+	binary.BigEndian.PutUint64(buf[:], req.Get.GetVersion())
+	if req.ReplicaRead {
+		buf[8] = 1
+	}
+	return string(data) + string(buf[:]), nil
+}
+
+// sendGroup sends the gets of a group, they are merged into a KvBatchGet if there are more
+// than one, and the response is split to each get.
+func (b *batchConn) sendGroup(group []*batchEntry) {
+	ctx, cancel := batchContext(group)
+	defer cancel()
+	if len(group) == 1 {
+		resp, err := b.client.Client.SendReq(ctx, b.addr, group[0].req)
+		if err == nil && resp.Get == nil {
+			err = ErrBodyMissing
+		}
+		if err != nil {
+			group[0].finish(nil, errors.Trace(err))
+			return
+		}
+		group[0].finish(resp.Get, nil)
+		return
+	}
+
+	first := group[0].req
+	keys := make([][]byte, 0, len(group))
+	seen := make(map[string]struct{}, len(group))
+	for _, entry := range group {
+		key := entry.req.Get.GetKey()
+		if _, ok := seen[string(key)]; ok {
+			continue
+		}
+		seen[string(key)] = struct{}{}
+		keys = append(keys, key)
+	}
+	req := &tikvrpc.Request{
+		Type:        tikvrpc.CmdBatchGet,
+		Priority:    first.Priority,
+		ReplicaRead: first.ReplicaRead,
+		BatchGet: &kvrpcpb.BatchGetRequest{
+			Context: first.Get.GetContext(),
+			Keys:    keys,
+			Version: first.Get.GetVersion(),
+		},
+	}
+	resp, err := b.client.Client.SendReq(ctx, b.addr, req)
+	if err == nil && resp.BatchGet == nil {
+		err = ErrBodyMissing
+	}
+	if err != nil {
+		for _, entry := range group {
+			entry.finish(nil, errors.Trace(err))
+		}
+		return
+	}
+	batchResp := resp.BatchGet
+	pairs := make(map[string]*kvrpcpb.KvPair, len(batchResp.Pairs))
+	for _, pair := range batchResp.Pairs {
+		pairs[string(pair.GetKey())] = pair
+	}
+	for _, entry := range group {
+		getResp := &kvrpcpb.GetResponse{RegionError: batchResp.RegionError}
+		if pair, ok := pairs[string(entry.req.Get.GetKey())]; ok && getResp.RegionError == nil {
+			getResp.Error = pair.GetError()
+			getResp.Value = pair.GetValue()
+		}
+		entry.finish(getResp, nil)
+	}
+}
+
+// batchContext returns the context of the request sent for a group, which lasts until
+// the latest deadline of the gets in it.
+func batchContext(group []*batchEntry) (goctx.Context, goctx.CancelFunc) {
+	var deadline time.Time
+	for _, entry := range group {
+		d, ok := entry.ctx.Deadline()
+		if !ok {
+			return goctx.WithCancel(goctx.Background())
+		}
+		if d.After(deadline) {
+			deadline = d
+		}
+	}
+	return goctx.WithDeadline(goctx.Background(), deadline)
+}
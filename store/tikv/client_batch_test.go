@@ -0,0 +1,124 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tikv
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/juju/errors"
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/kvrpcpb"
+	"github.com/pingcap/tidb/config"
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/store/tikv/tikvrpc"
+	goctx "golang.org/x/net/context"
+)
+
+type testBatchClientSuite struct{}
+
+var _ = Suite(&testBatchClientSuite{})
+
+// countClient counts the requests by type.
+type countClient struct {
+	Client
+	gets      int64
+	batchGets int64
+}
+
+func (c *countClient) SendReq(ctx goctx.Context, addr string, req *tikvrpc.Request) (*tikvrpc.Response, error) {
+	switch req.Type {
+	case tikvrpc.CmdGet:
+		atomic.AddInt64(&c.gets, 1)
+	case tikvrpc.CmdBatchGet:
+		atomic.AddInt64(&c.batchGets, 1)
+	}
+	return c.Client.SendReq(ctx, addr, req)
+}
+
+func (s *testBatchClientSuite) TestDisabled(c *C) {
+	client := &countClient{}
+	c.Assert(newBatchClient(client, config.TiKVClient{MaxBatchSize: 0}), Equals, client)
+}
+
+func (s *testBatchClientSuite) TestCoalesceGets(c *C) {
+	var client *countClient
+	store, err := NewMockTikvStore(WithHijackClient(func(inner Client) Client {
+		client = &countClient{Client: inner}
+		return newBatchClient(client, config.TiKVClient{
+			MaxBatchSize:     64,
+			MaxBatchWaitTime: "100ms",
+		})
+	}))
+	c.Assert(err, IsNil)
+	defer store.Close()
+
+	const count = 32
+	txn, err := store.Begin()
+	c.Assert(err, IsNil)
+	for i := 0; i < count; i++ {
+		c.Assert(txn.Set(kv.Key(fmt.Sprintf("key%d", i)), []byte(fmt.Sprintf("value%d", i))), IsNil)
+	}
+	c.Assert(txn.Commit(), IsNil)
+
+	ver, err := store.CurrentVersion()
+	c.Assert(err, IsNil)
+	snapshot, err := store.GetSnapshot(ver)
+	c.Assert(err, IsNil)
+	var wg sync.WaitGroup
+	errs := make([]error, count+1)
+	values := make([][]byte, count+1)
+	// The last key doesn't exist.
+	for i := 0; i <= count; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			values[i], errs[i] = snapshot.Get(kv.Key(fmt.Sprintf("key%d", i)))
+		}(i)
+	}
+	wg.Wait()
+	for i := 0; i < count; i++ {
+		c.Assert(errs[i], IsNil)
+		c.Assert(string(values[i]), Equals, fmt.Sprintf("value%d", i))
+	}
+	c.Assert(kv.IsErrNotFound(errs[count]), IsTrue)
+	c.Assert(atomic.LoadInt64(&client.batchGets), Greater, int64(0))
+	c.Assert(atomic.LoadInt64(&client.gets)+atomic.LoadInt64(&client.batchGets), Less, int64(count+1))
+}
+
+func (s *testBatchClientSuite) TestCanceledAndClosed(c *C) {
+	client := newBatchClient(&countClient{Client: &closeClient{}}, config.TiKVClient{MaxBatchSize: 8})
+	req := &tikvrpc.Request{
+		Type: tikvrpc.CmdGet,
+		Get:  &kvrpcpb.GetRequest{Key: []byte("key"), Context: &kvrpcpb.Context{}},
+	}
+	ctx, cancel := goctx.WithCancel(goctx.Background())
+	cancel()
+	_, err := client.SendReq(ctx, "store1", req)
+	c.Assert(errors.Cause(err), Equals, goctx.Canceled)
+
+	c.Assert(client.Close(), IsNil)
+	_, err = client.SendReq(goctx.Background(), "store1", req)
+	c.Assert(errors.Cause(err), Equals, errBatchClientClosed)
+}
+
+// closeClient is a Client that only supports Close.
+type closeClient struct {
+	Client
+}
+
+func (c *closeClient) Close() error {
+	return nil
+}
@@ -47,6 +47,15 @@ func (c *CopClient) IsRequestTypeSupported(reqType, subType int64) bool {
 		return c.supportExpr(tipb.ExprType(subType))
 	case kv.ReqTypeAnalyze:
 		return c.store.mock
+	case kv.ReqTypeReplicaRead:
+		// The Context of the vendored kvproto has no replica read flag, TiKV rejects the reads
+		// on the followers.
+		return c.store.mock
+	case kv.ReqTypePessimisticTxn:
+		// The vendored kvproto has no pessimistic lock commands, only mock-tikv serves them.
+		return c.store.mock
+	case kv.ReqTypeAsyncCommit, kv.ReqTypeOnePC:
+		return supportAsyncCommit(c.store)
 	}
 	return false
 }
@@ -446,6 +455,7 @@ func (it *copIterator) Next() ([]byte, error) {
 func (it *copIterator) handleTask(bo *Backoffer, task *copTask) []copResponse {
 	coprocessorCounter.WithLabelValues("handle_task").Inc()
 	sender := NewRegionRequestSender(it.store.regionCache, it.store.client, pbIsolationLevel(it.req.IsolationLevel))
+	sender.replicaRead = it.req.ReplicaRead
 	for {
 		select {
 		case <-it.finished:
@@ -462,12 +472,24 @@ func (it *copIterator) handleTask(bo *Backoffer, task *copTask) []copResponse {
 				Ranges: task.ranges.toPBRanges(),
 			},
 		}
-		resp, err := sender.SendReq(bo, req, task.region, readTimeoutMedium)
+		var cacheKey []byte
+		var cacheValue *copCacheValue
+		if it.store.copCache != nil {
+			cacheKey = buildCopCacheKey(it.req, task.region, task.ranges)
+		}
+		if cacheKey != nil {
+			req.CopCache = &tikvrpc.CopCacheRequest{}
+			if cacheValue = it.store.copCache.get(cacheKey); cacheValue != nil {
+				req.CopCache.CacheIfMatchVersion = cacheValue.dataVersion
+			}
+		}
+		startTime := time.Now()
+		resp, err := sender.SendReq(bo, req, task.region, ReadTimeoutMedium)
 		if err != nil {
 			return []copResponse{{err: errors.Trace(err)}}
 		}
 		if regionErr := resp.Cop.GetRegionError(); regionErr != nil {
-			err = bo.Backoff(boRegionMiss, errors.New(regionErr.String()))
+			err = bo.Backoff(BoRegionMiss, errors.New(regionErr.String()))
 			if err != nil {
 				return []copResponse{{err: errors.Trace(err)}}
 			}
@@ -475,7 +497,7 @@ func (it *copIterator) handleTask(bo *Backoffer, task *copTask) []copResponse {
 		}
 		if e := resp.Cop.GetLocked(); e != nil {
 			log.Debugf("coprocessor encounters lock: %v", e)
-			ok, err1 := it.store.lockResolver.ResolveLocks(bo, []*Lock{newLock(e)})
+			ok, err1 := it.store.lockResolver.ResolveLocks(bo, []*Lock{NewLock(e)})
 			if err1 != nil {
 				return []copResponse{{err: errors.Trace(err1)}}
 			}
@@ -493,10 +515,37 @@ func (it *copIterator) handleTask(bo *Backoffer, task *copTask) []copResponse {
 			return []copResponse{{err: errors.Trace(err)}}
 		}
 		task.storeAddr = sender.storeAddr
+		if cacheKey != nil {
+			it.handleCopCache(cacheKey, cacheValue, resp, time.Since(startTime))
+		}
 		return []copResponse{{Response: resp.Cop}}
 	}
 }
 
+// handleCopCache serves the response from the cache on a cache hit, or caches the
+// response if it can be cached.
+func (it *copIterator) handleCopCache(key []byte, cached *copCacheValue, resp *tikvrpc.Response, processTime time.Duration) {
+	cacheResp := resp.CopCache
+	if cacheResp == nil {
+		return
+	}
+	if cacheResp.IsCacheHit && cached != nil {
+		copCacheCounter.WithLabelValues("hit").Inc()
+		resp.Cop.Data = cached.data
+		return
+	}
+	copCacheCounter.WithLabelValues("miss").Inc()
+	data := []byte(resp.Cop.Data)
+	if !cacheResp.CanBeCached || !it.store.copCache.checkAdmission(len(data), processTime) {
+		return
+	}
+	it.store.copCache.set(&copCacheValue{
+		key:         string(key),
+		data:        data,
+		dataVersion: cacheResp.CacheLastVersion,
+	})
+}
+
 // handleRegionErrorTask handles current task. It may be split into multiple tasks (in region split scenario).
 func (it *copIterator) handleRegionErrorTask(bo *Backoffer, task *copTask) []copResponse {
 	coprocessorCounter.WithLabelValues("rebuild_task").Inc()
@@ -0,0 +1,150 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tikv
+
+import (
+	"container/list"
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pingcap/tidb/config"
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tipb/go-tipb"
+)
+
+// copCache caches the coprocessor responses of the regions. A response is keyed by the
+// request and the region with its version, and it's valid until the data version of the
+// region reported by the storage changes, so the storage checks the version and returns
+// a cache hit without executing the request if the data isn't changed.
+type copCache struct {
+	capacity                int
+	admissionMaxResultSize  int
+	admissionMinProcessTime time.Duration
+
+	mu struct {
+		sync.Mutex
+		size    int
+		entries map[string]*list.Element
+		// lru holds the entries, the least recently used one is at the back.
+		lru *list.List
+	}
+}
+
+// copCacheValue is a cached coprocessor response.
+type copCacheValue struct {
+	key         string
+	data        []byte
+	dataVersion uint64
+}
+
+func (v *copCacheValue) size() int {
+	return len(v.key) + len(v.data)
+}
+
+const bytesPerMB = 1024 * 1024
+
+// newCopCache creates a copCache by the config, it returns nil if the cache is disabled.
+func newCopCache(cfg config.CoprocessorCache) *copCache {
+	if !cfg.Enable || cfg.CapacityMB <= 0 {
+		return nil
+	}
+	c := &copCache{
+		capacity:                int(cfg.CapacityMB * bytesPerMB),
+		admissionMaxResultSize:  int(cfg.AdmissionMaxResultMB * bytesPerMB),
+		admissionMinProcessTime: time.Duration(cfg.AdmissionMinProcessMs) * time.Millisecond,
+	}
+	c.mu.entries = make(map[string]*list.Element)
+	c.mu.lru = list.New()
+	return c
+}
+
+// buildCopCacheKey builds the cache key of a request on a region, it returns nil if the
+// response of the request can't be cached. The start ts is excluded from the key, the
+// storage checks whether a cached response is valid at the start ts.
+func buildCopCacheKey(req *kv.Request, region RegionVerID, ranges *copRanges) []byte {
+	if req.Tp != kv.ReqTypeDAG {
+		return nil
+	}
+	dagReq := new(tipb.DAGRequest)
+	if err := proto.Unmarshal(req.Data, dagReq); err != nil {
+		return nil
+	}
+	dagReq.StartTs = 0
+	data, err := dagReq.Marshal()
+	if err != nil {
+		return nil
+	}
+	var buf [8]byte
+	key := make([]byte, 0, 24+len(data)+ranges.len()*16)
+	for _, v := range []uint64{region.id, region.confVer, region.ver} {
+		binary.BigEndian.PutUint64(buf[:], v)
+		key = append(key, buf[:]...)
+	}
+	key = appendLengthPrefixed(key, data)
+	ranges.do(func(r *kv.KeyRange) {
+		key = appendLengthPrefixed(key, r.StartKey)
+		key = appendLengthPrefixed(key, r.EndKey)
+	})
+	return key
+}
+
+func appendLengthPrefixed(b []byte, data []byte) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], uint64(len(data)))
+	b = append(b, buf[:n]...)
+	return append(b, data...)
+}
+
+// get returns the cached response of the key, or nil if it's not cached.
+func (c *copCache) get(key []byte) *copCacheValue {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.mu.entries[string(key)]
+	if !ok {
+		return nil
+	}
+	c.mu.lru.MoveToFront(e)
+	return e.Value.(*copCacheValue)
+}
+
+// checkAdmission checks whether a response of the size processed in the time can be cached.
+func (c *copCache) checkAdmission(dataSize int, processTime time.Duration) bool {
+	return dataSize <= c.admissionMaxResultSize && processTime >= c.admissionMinProcessTime
+}
+
+// set caches the response, the least recently used ones are evicted if the cache is full.
+func (c *copCache) set(value *copCacheValue) {
+	if value.size() > c.capacity {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.mu.entries[value.key]; ok {
+		c.removeElement(e)
+	}
+	for c.mu.size+value.size() > c.capacity {
+		c.removeElement(c.mu.lru.Back())
+		copCacheCounter.WithLabelValues("evict").Inc()
+	}
+	c.mu.entries[value.key] = c.mu.lru.PushFront(value)
+	c.mu.size += value.size()
+}
+
+func (c *copCache) removeElement(e *list.Element) {
+	value := c.mu.lru.Remove(e).(*copCacheValue)
+	delete(c.mu.entries, value.key)
+	c.mu.size -= value.size()
+}
@@ -0,0 +1,171 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tikv
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/config"
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/store/tikv/tikvrpc"
+	"github.com/pingcap/tidb/tablecodec"
+	"github.com/pingcap/tidb/util/types"
+	"github.com/pingcap/tipb/go-tipb"
+	goctx "golang.org/x/net/context"
+)
+
+type testCopCacheSuite struct{}
+
+var _ = Suite(&testCopCacheSuite{})
+
+func (s *testCopCacheSuite) TestDisabled(c *C) {
+	c.Assert(newCopCache(config.CoprocessorCache{Enable: false, CapacityMB: 1}), IsNil)
+	c.Assert(newCopCache(config.CoprocessorCache{Enable: true, CapacityMB: 0}), IsNil)
+}
+
+func (s *testCopCacheSuite) TestAdmission(c *C) {
+	cache := newCopCache(config.CoprocessorCache{
+		Enable:                true,
+		CapacityMB:            1,
+		AdmissionMaxResultMB:  0.5,
+		AdmissionMinProcessMs: 5,
+	})
+	c.Assert(cache.checkAdmission(1024, 10*time.Millisecond), IsTrue)
+	c.Assert(cache.checkAdmission(1024, time.Millisecond), IsFalse)
+	c.Assert(cache.checkAdmission(bytesPerMB, 10*time.Millisecond), IsFalse)
+}
+
+func (s *testCopCacheSuite) TestLRU(c *C) {
+	cache := newCopCache(config.CoprocessorCache{Enable: true, CapacityMB: 1})
+	cache.capacity = 30
+	value := func(key string, size int, ver uint64) *copCacheValue {
+		return &copCacheValue{key: key, data: make([]byte, size), dataVersion: ver}
+	}
+
+	cache.set(value("a", 9, 1))
+	cache.set(value("b", 9, 1))
+	cache.set(value("c", 9, 1))
+	c.Assert(cache.mu.size, Equals, 30)
+	// The access of a makes b the least recently used one.
+	c.Assert(cache.get([]byte("a")), NotNil)
+	cache.set(value("d", 9, 1))
+	c.Assert(cache.get([]byte("b")), IsNil)
+	c.Assert(cache.get([]byte("a")), NotNil)
+	c.Assert(cache.get([]byte("c")), NotNil)
+	c.Assert(cache.get([]byte("d")), NotNil)
+
+	// Overwriting a key replaces the entry.
+	cache.set(value("a", 4, 2))
+	c.Assert(cache.get([]byte("a")).dataVersion, Equals, uint64(2))
+	c.Assert(cache.mu.size, Equals, 25)
+	c.Assert(cache.mu.lru.Len(), Equals, 3)
+
+	// A value larger than the capacity is never cached.
+	cache.set(value("e", 40, 1))
+	c.Assert(cache.get([]byte("e")), IsNil)
+	c.Assert(cache.mu.lru.Len(), Equals, 3)
+}
+
+// copCacheClient counts the coprocessor responses served from the cache.
+type copCacheClient struct {
+	Client
+	hits int64
+}
+
+func (c *copCacheClient) SendReq(ctx goctx.Context, addr string, req *tikvrpc.Request) (*tikvrpc.Response, error) {
+	resp, err := c.Client.SendReq(ctx, addr, req)
+	if err == nil && resp.CopCache != nil && resp.CopCache.IsCacheHit {
+		atomic.AddInt64(&c.hits, 1)
+	}
+	return resp, err
+}
+
+func (s *testCopCacheSuite) TestCopCache(c *C) {
+	var client *copCacheClient
+	store, err := NewMockTikvStore(WithHijackClient(func(inner Client) Client {
+		client = &copCacheClient{Client: inner}
+		return client
+	}))
+	c.Assert(err, IsNil)
+	defer store.Close()
+	tikvStore := store.(*tikvStore)
+	tikvStore.copCache = newCopCache(config.CoprocessorCache{Enable: true, CapacityMB: 1, AdmissionMaxResultMB: 1})
+
+	const tableID = 1
+	putRow := func(handle int64) {
+		txn, err1 := store.Begin()
+		c.Assert(err1, IsNil)
+		value, err1 := tablecodec.EncodeRow([]types.Datum{types.NewIntDatum(handle)}, []int64{2}, time.UTC)
+		c.Assert(err1, IsNil)
+		c.Assert(txn.Set(tablecodec.EncodeRowKeyWithHandle(tableID, handle), value), IsNil)
+		c.Assert(txn.Commit(), IsNil)
+	}
+	scan := func() []byte {
+		ver, err1 := store.CurrentVersion()
+		c.Assert(err1, IsNil)
+		dag := &tipb.DAGRequest{
+			StartTs: ver.Ver,
+			Executors: []*tipb.Executor{{
+				Tp: tipb.ExecType_TypeTableScan,
+				TblScan: &tipb.TableScan{
+					TableId: tableID,
+					Columns: []*tipb.ColumnInfo{{ColumnId: 1, Tp: int32(mysql.TypeLonglong), PkHandle: true}},
+				},
+			}},
+			OutputOffsets: []uint32{0},
+		}
+		data, err1 := dag.Marshal()
+		c.Assert(err1, IsNil)
+		req := &kv.Request{
+			Tp:   kv.ReqTypeDAG,
+			Data: data,
+			KeyRanges: []kv.KeyRange{{
+				StartKey: tablecodec.EncodeRowKeyWithHandle(tableID, math.MinInt64),
+				EndKey:   tablecodec.EncodeRowKeyWithHandle(tableID, math.MaxInt64),
+			}},
+			Concurrency: 1,
+		}
+		resp := store.GetClient().Send(goctx.Background(), req)
+		c.Assert(resp, NotNil)
+		defer resp.Close()
+		var result []byte
+		for {
+			d, err1 := resp.Next()
+			c.Assert(err1, IsNil)
+			if d == nil {
+				break
+			}
+			result = append(result, d...)
+		}
+		return result
+	}
+
+	putRow(1)
+	first := scan()
+	c.Assert(atomic.LoadInt64(&client.hits), Equals, int64(0))
+	c.Assert(scan(), DeepEquals, first)
+	c.Assert(atomic.LoadInt64(&client.hits), Equals, int64(1))
+
+	// A write changes the data version of the region, the cached response is invalid.
+	putRow(2)
+	second := scan()
+	c.Assert(atomic.LoadInt64(&client.hits), Equals, int64(1))
+	c.Assert(second, Not(DeepEquals), first)
+	c.Assert(scan(), DeepEquals, second)
+	c.Assert(atomic.LoadInt64(&client.hits), Equals, int64(2))
+}
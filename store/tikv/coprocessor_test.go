@@ -162,3 +162,12 @@ func (s *testCoprocessorSuite) checkEqual(c *C, copRanges *copRanges, ranges []k
 		}
 	}
 }
+
+func (s *testCoprocessorSuite) TestFeatureSupported(c *C) {
+	for _, reqType := range []int64{kv.ReqTypeReplicaRead, kv.ReqTypePessimisticTxn, kv.ReqTypeAsyncCommit, kv.ReqTypeOnePC} {
+		client := &CopClient{store: &tikvStore{mock: true}}
+		c.Assert(client.IsRequestTypeSupported(reqType, kv.ReqSubTypeBasic), IsTrue)
+		client = &CopClient{store: &tikvStore{}}
+		c.Assert(client.IsRequestTypeSupported(reqType, kv.ReqSubTypeBasic), IsFalse)
+	}
+}
@@ -17,13 +17,19 @@ import (
 	"github.com/juju/errors"
 )
 
+// ErrBodyMissing response body is missing error.
+var ErrBodyMissing = errors.New("response body is missing")
+
 var (
 	// errInnerRetryable if caller can retry this directly then return this error.
 	errInnerRetryable = errors.New("try again innerly")
 	// errInvalidResponse represents response message is invalid.
 	errInvalidResponse = errors.New("invalid response")
-	// errBodyMissing response body is missing error
-	errBodyMissing = errors.New("response body is missing")
+	// errPrimaryLockNotFound means the primary lock of the transaction is gone, the
+	// transaction is committed or rolled back.
+	errPrimaryLockNotFound = errors.New("primary lock not found")
+	// errUnsupportedCmd means the request type is not supported by TiKV yet.
+	errUnsupportedCmd = errors.New("request type is not supported by TiKV yet")
 )
 
 // TiDB decides whether to retry transaction by checking if error message contains
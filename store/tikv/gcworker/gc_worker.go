@@ -11,7 +11,7 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-package tikv
+package gcworker
 
 import (
 	"bytes"
@@ -28,6 +28,7 @@ import (
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/meta"
 	"github.com/pingcap/tidb/privilege"
+	"github.com/pingcap/tidb/store/tikv"
 	"github.com/pingcap/tidb/store/tikv/oracle"
 	"github.com/pingcap/tidb/store/tikv/tikvrpc"
 	goctx "golang.org/x/net/context"
@@ -37,15 +38,16 @@ import (
 type GCWorker struct {
 	uuid        string
 	desc        string
-	store       *tikvStore
+	store       tikv.Storage
 	gcIsRunning bool
 	lastFinish  time.Time
 	cancel      goctx.CancelFunc
 	done        chan error
 }
 
-// NewGCWorker creates a GCWorker instance.
-func NewGCWorker(store kv.Storage) (*GCWorker, error) {
+// NewGCWorker creates a GCWorker instance and starts it, it's registered as
+// tikv.NewGCHandlerFunc by the server.
+func NewGCWorker(store tikv.Storage) (tikv.GCHandler, error) {
 	ver, err := store.CurrentVersion()
 	if err != nil {
 		return nil, errors.Trace(err)
@@ -57,7 +59,7 @@ func NewGCWorker(store kv.Storage) (*GCWorker, error) {
 	worker := &GCWorker{
 		uuid:        strconv.FormatUint(ver.Ver, 16),
 		desc:        fmt.Sprintf("host:%s, pid:%d, start at %s", hostName, os.Getpid(), time.Now()),
-		store:       store.(*tikvStore),
+		store:       store,
 		gcIsRunning: false,
 		lastFinish:  time.Now(),
 		done:        make(chan error),
@@ -74,7 +76,7 @@ func (w *GCWorker) Close() {
 }
 
 const (
-	gcTimeFormat = "20060102-15:04:05 -0700 MST"
+	gcTimeFormat = ddl.GCTimeFormat
 
 	gcWorkerTickInterval = time.Minute
 	gcWorkerLease        = time.Minute * 2
@@ -92,6 +94,13 @@ const (
 	gcSafePointKey    = "tikv_gc_safe_point"
 )
 
+// Maximum total sleep time(in ms) of the GC jobs.
+const (
+	gcMaxBackoff            = 100000
+	gcResolveLockMaxBackoff = 100000
+	gcDeleteRangeMaxBackoff = 100000
+)
+
 var gcVariableComments = map[string]string{
 	gcLeaderUUIDKey:  "Current GC worker leader UUID. (DO NOT EDIT)",
 	gcLeaderDescKey:  "Host name and pid of current GC leader. (DO NOT EDIT)",
@@ -270,7 +279,7 @@ func (w *GCWorker) calculateNewSafePoint(now time.Time) (*time.Time, error) {
 
 // RunGCJob sends GC command to KV. it is exported for testing purpose, do not use it with GCWorker at the same time.
 func RunGCJob(ctx goctx.Context, store kv.Storage, safePoint uint64, identifier string) error {
-	s, ok := store.(*tikvStore)
+	s, ok := store.(tikv.Storage)
 	if !ok {
 		return errors.New("should use tikv driver")
 	}
@@ -315,7 +324,7 @@ func (w *GCWorker) deleteRanges(ctx goctx.Context, safePoint uint64) error {
 		return errors.Trace(err)
 	}
 
-	bo := NewBackoffer(gcDeleteRangeMaxBackoff, goctx.Background())
+	bo := tikv.NewBackoffer(gcDeleteRangeMaxBackoff, goctx.Background())
 	log.Infof("[gc worker] %s start delete %v ranges", w.uuid, len(ranges))
 	startTime := time.Now()
 	regions := 0
@@ -328,7 +337,7 @@ func (w *GCWorker) deleteRanges(ctx goctx.Context, safePoint uint64) error {
 			default:
 			}
 
-			loc, err := w.store.regionCache.LocateKey(bo, startKey)
+			loc, err := w.store.GetRegionCache().LocateKey(bo, startKey)
 			if err != nil {
 				return errors.Trace(err)
 			}
@@ -346,7 +355,7 @@ func (w *GCWorker) deleteRanges(ctx goctx.Context, safePoint uint64) error {
 				},
 			}
 
-			resp, err := w.store.SendReq(bo, req, loc.Region, readTimeoutMedium)
+			resp, err := w.store.SendReq(bo, req, loc.Region, tikv.ReadTimeoutMedium)
 			if err != nil {
 				return errors.Trace(err)
 			}
@@ -355,7 +364,7 @@ func (w *GCWorker) deleteRanges(ctx goctx.Context, safePoint uint64) error {
 				return errors.Trace(err)
 			}
 			if regionErr != nil {
-				err = bo.Backoff(boRegionMiss, errors.New(regionErr.String()))
+				err = bo.Backoff(tikv.BoRegionMiss, errors.New(regionErr.String()))
 				if err != nil {
 					return errors.Trace(err)
 				}
@@ -363,7 +372,7 @@ func (w *GCWorker) deleteRanges(ctx goctx.Context, safePoint uint64) error {
 			}
 			deleteRangeResp := resp.DeleteRange
 			if deleteRangeResp == nil {
-				return errors.Trace(errBodyMissing)
+				return errors.Trace(tikv.ErrBodyMissing)
 			}
 			if err := deleteRangeResp.GetError(); err != "" {
 				return errors.Errorf("unexpected delete range err: %v", err)
@@ -386,7 +395,7 @@ func (w *GCWorker) deleteRanges(ctx goctx.Context, safePoint uint64) error {
 	return nil
 }
 
-func resolveLocks(ctx goctx.Context, store *tikvStore, safePoint uint64, identifier string) error {
+func resolveLocks(ctx goctx.Context, store tikv.Storage, safePoint uint64, identifier string) error {
 	gcWorkerCounter.WithLabelValues("resolve_locks").Inc()
 	req := &tikvrpc.Request{
 		Type: tikvrpc.CmdScanLock,
@@ -394,7 +403,7 @@ func resolveLocks(ctx goctx.Context, store *tikvStore, safePoint uint64, identif
 			MaxVersion: safePoint,
 		},
 	}
-	bo := NewBackoffer(gcResolveLockMaxBackoff, goctx.Background())
+	bo := tikv.NewBackoffer(gcResolveLockMaxBackoff, goctx.Background())
 
 	log.Infof("[gc worker] %s start resolve locks, safePoint: %v.", identifier, safePoint)
 	startTime := time.Now()
@@ -408,11 +417,11 @@ func resolveLocks(ctx goctx.Context, store *tikvStore, safePoint uint64, identif
 		default:
 		}
 
-		loc, err := store.regionCache.LocateKey(bo, key)
+		loc, err := store.GetRegionCache().LocateKey(bo, key)
 		if err != nil {
 			return errors.Trace(err)
 		}
-		resp, err := store.SendReq(bo, req, loc.Region, readTimeoutMedium)
+		resp, err := store.SendReq(bo, req, loc.Region, tikv.ReadTimeoutMedium)
 		if err != nil {
 			return errors.Trace(err)
 		}
@@ -421,7 +430,7 @@ func resolveLocks(ctx goctx.Context, store *tikvStore, safePoint uint64, identif
 			return errors.Trace(err)
 		}
 		if regionErr != nil {
-			err = bo.Backoff(boRegionMiss, errors.New(regionErr.String()))
+			err = bo.Backoff(tikv.BoRegionMiss, errors.New(regionErr.String()))
 			if err != nil {
 				return errors.Trace(err)
 			}
@@ -429,22 +438,22 @@ func resolveLocks(ctx goctx.Context, store *tikvStore, safePoint uint64, identif
 		}
 		locksResp := resp.ScanLock
 		if locksResp == nil {
-			return errors.Trace(errBodyMissing)
+			return errors.Trace(tikv.ErrBodyMissing)
 		}
 		if locksResp.GetError() != nil {
 			return errors.Errorf("unexpected scanlock error: %s", locksResp)
 		}
 		locksInfo := locksResp.GetLocks()
-		locks := make([]*Lock, len(locksInfo))
+		locks := make([]*tikv.Lock, len(locksInfo))
 		for i := range locksInfo {
-			locks[i] = newLock(locksInfo[i])
+			locks[i] = tikv.NewLock(locksInfo[i])
 		}
-		ok, err1 := store.lockResolver.ResolveLocks(bo, locks)
+		ok, err1 := store.GetLockResolver().ResolveLocks(bo, locks)
 		if err1 != nil {
 			return errors.Trace(err1)
 		}
 		if !ok {
-			err = bo.Backoff(boTxnLock, errors.Errorf("remain locks: %d", len(locks)))
+			err = bo.Backoff(tikv.BoTxnLock, errors.Errorf("remain locks: %d", len(locks)))
 			if err != nil {
 				return errors.Trace(err)
 			}
@@ -462,7 +471,7 @@ func resolveLocks(ctx goctx.Context, store *tikvStore, safePoint uint64, identif
 	return nil
 }
 
-func doGC(ctx goctx.Context, store *tikvStore, safePoint uint64, identifier string) error {
+func doGC(ctx goctx.Context, store tikv.Storage, safePoint uint64, identifier string) error {
 	gcWorkerCounter.WithLabelValues("do_gc").Inc()
 
 	req := &tikvrpc.Request{
@@ -471,7 +480,7 @@ func doGC(ctx goctx.Context, store *tikvStore, safePoint uint64, identifier stri
 			SafePoint: safePoint,
 		},
 	}
-	bo := NewBackoffer(gcMaxBackoff, goctx.Background())
+	bo := tikv.NewBackoffer(gcMaxBackoff, goctx.Background())
 
 	log.Infof("[gc worker] %s start gc, safePoint: %v.", identifier, safePoint)
 	startTime := time.Now()
@@ -485,11 +494,11 @@ func doGC(ctx goctx.Context, store *tikvStore, safePoint uint64, identifier stri
 		default:
 		}
 
-		loc, err := store.regionCache.LocateKey(bo, key)
+		loc, err := store.GetRegionCache().LocateKey(bo, key)
 		if err != nil {
 			return errors.Trace(err)
 		}
-		resp, err := store.SendReq(bo, req, loc.Region, readTimeoutLong)
+		resp, err := store.SendReq(bo, req, loc.Region, tikv.ReadTimeoutLong)
 		if err != nil {
 			return errors.Trace(err)
 		}
@@ -498,7 +507,7 @@ func doGC(ctx goctx.Context, store *tikvStore, safePoint uint64, identifier stri
 			return errors.Trace(err)
 		}
 		if regionErr != nil {
-			err = bo.Backoff(boRegionMiss, errors.New(regionErr.String()))
+			err = bo.Backoff(tikv.BoRegionMiss, errors.New(regionErr.String()))
 			if err != nil {
 				return errors.Trace(err)
 			}
@@ -506,7 +515,7 @@ func doGC(ctx goctx.Context, store *tikvStore, safePoint uint64, identifier stri
 		}
 		gcResp := resp.GC
 		if gcResp == nil {
-			return errors.Trace(errBodyMissing)
+			return errors.Trace(tikv.ErrBodyMissing)
 		}
 		if gcResp.GetError() != nil {
 			return errors.Errorf("unexpected gc error: %s", gcResp.GetError())
@@ -690,7 +699,7 @@ func NewMockGCWorker(store kv.Storage) (*MockGCWorker, error) {
 	worker := GCWorker{
 		uuid:        strconv.FormatUint(ver.Ver, 16),
 		desc:        fmt.Sprintf("host:%s, pid:%d, start at %s", hostName, os.Getpid(), time.Now()),
-		store:       store.(*tikvStore),
+		store:       store.(tikv.Storage),
 		gcIsRunning: false,
 		lastFinish:  time.Now(),
 		done:        make(chan error),
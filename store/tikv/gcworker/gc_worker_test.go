@@ -11,33 +11,43 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-package tikv
+package gcworker
 
 import (
 	"math"
+	"testing"
 	"time"
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb"
+	"github.com/pingcap/tidb/store/tikv"
+	"github.com/pingcap/tidb/store/tikv/oracle/oracles"
 )
 
+func TestT(t *testing.T) {
+	CustomVerboseFlag = true
+	TestingT(t)
+}
+
 type testGCWorkerSuite struct {
-	store    *tikvStore
-	oracle   *mockOracle
+	store    tikv.Storage
+	oracle   *oracles.MockOracle
 	gcWorker *GCWorker
 }
 
 var _ = Suite(&testGCWorkerSuite{})
 
 func (s *testGCWorkerSuite) SetUpTest(c *C) {
-	s.store = newTestStore(c)
-	s.oracle = &mockOracle{}
-	s.store.oracle = s.oracle
-	_, err := tidb.BootstrapSession(s.store)
+	store, err := tikv.NewMockTikvStore()
+	c.Assert(err, IsNil)
+	s.store = store.(tikv.Storage)
+	s.oracle = &oracles.MockOracle{}
+	s.store.SetOracle(s.oracle)
+	_, err = tidb.BootstrapSession(s.store)
 	c.Assert(err, IsNil)
 	gcWorker, err := NewGCWorker(s.store)
 	c.Assert(err, IsNil)
-	s.gcWorker = gcWorker
+	s.gcWorker = gcWorker.(*GCWorker)
 }
 
 func (s *testGCWorkerSuite) TearDownTest(c *C) {
@@ -54,7 +64,7 @@ func (s *testGCWorkerSuite) TestGetOracleTime(c *C) {
 	c.Assert(err, IsNil)
 	s.timeEqual(c, time.Now(), t1, time.Millisecond*10)
 
-	s.oracle.addOffset(time.Second * 10)
+	s.oracle.AddOffset(time.Second * 10)
 	t2, err := s.gcWorker.getOracleTime()
 	c.Assert(err, IsNil)
 	s.timeEqual(c, t2, t1.Add(time.Second*10), time.Millisecond*10)
@@ -76,11 +86,11 @@ func (s *testGCWorkerSuite) TestPrepareGC(c *C) {
 	// Change GC run interval.
 	err = s.gcWorker.saveDuration(gcRunIntervalKey, time.Minute*5)
 	c.Assert(err, IsNil)
-	s.oracle.addOffset(time.Minute * 4)
+	s.oracle.AddOffset(time.Minute * 4)
 	ok, _, err = s.gcWorker.prepare()
 	c.Assert(err, IsNil)
 	c.Assert(ok, IsFalse)
-	s.oracle.addOffset(time.Minute * 2)
+	s.oracle.AddOffset(time.Minute * 2)
 	ok, _, err = s.gcWorker.prepare()
 	c.Assert(err, IsNil)
 	c.Assert(ok, IsTrue)
@@ -88,11 +98,11 @@ func (s *testGCWorkerSuite) TestPrepareGC(c *C) {
 	// Change GC life time.
 	err = s.gcWorker.saveDuration(gcLifeTimeKey, time.Minute*30)
 	c.Assert(err, IsNil)
-	s.oracle.addOffset(time.Minute * 5)
+	s.oracle.AddOffset(time.Minute * 5)
 	ok, _, err = s.gcWorker.prepare()
 	c.Assert(err, IsNil)
 	c.Assert(ok, IsFalse)
-	s.oracle.addOffset(time.Minute * 40)
+	s.oracle.AddOffset(time.Minute * 40)
 	now, err = s.gcWorker.getOracleTime()
 	c.Assert(err, IsNil)
 	ok, _, err = s.gcWorker.prepare()
@@ -25,6 +25,7 @@ import (
 	"github.com/juju/errors"
 	"github.com/pingcap/kvproto/pkg/kvrpcpb"
 	"github.com/pingcap/pd/pd-client"
+	"github.com/pingcap/tidb/config"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/store/tikv/mock-tikv"
 	"github.com/pingcap/tidb/store/tikv/oracle"
@@ -69,7 +70,8 @@ func (d Driver) Open(path string) (kv.Storage, error) {
 		return store, nil
 	}
 
-	s, err := newTikvStore(uuid, &codecPDClient{pdCli}, newRPCClient(), !disableGC)
+	client := newBatchClient(newRPCClient(), config.GetGlobalConfig().TiKVClient)
+	s, err := newTikvStore(uuid, &codecPDClient{pdCli}, client, !disableGC)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
@@ -97,6 +99,31 @@ func (d MockDriver) Open(path string) (kv.Storage, error) {
 // update oracle's lastTS every 2000ms.
 var oracleUpdateInterval = 2000
 
+// Storage represents the kv.Storage runs on TiKV, it exposes the internals used by the
+// components built outside the package like the GC worker.
+type Storage interface {
+	kv.Storage
+	// GetRegionCache gets the RegionCache.
+	GetRegionCache() *RegionCache
+	// SendReq sends a request to the region.
+	SendReq(bo *Backoffer, req *tikvrpc.Request, regionID RegionVerID, timeout time.Duration) (*tikvrpc.Response, error)
+	// GetLockResolver gets the LockResolver.
+	GetLockResolver() *LockResolver
+	// SetOracle sets the oracle, it's for test only.
+	SetOracle(oracle oracle.Oracle)
+}
+
+// GCHandler runs the garbage collection in the background.
+type GCHandler interface {
+	// Close stops the background goroutines.
+	Close()
+}
+
+// NewGCHandlerFunc creates the GCHandler of a store. The GC worker depends on the SQL
+// layer to access the system tables, so it's registered by the server to keep the package
+// free from it, the GC isn't started if it's nil.
+var NewGCHandlerFunc func(store Storage) (GCHandler, error)
+
 type tikvStore struct {
 	clusterID    uint64
 	uuid         string
@@ -105,10 +132,12 @@ type tikvStore struct {
 	pdClient     pd.Client
 	regionCache  *RegionCache
 	lockResolver *LockResolver
-	gcWorker     *GCWorker
+	gcWorker     GCHandler
 	etcdAddrs    []string
 	mock         bool
 	enableGC     bool
+	// copCache is nil if the coprocessor cache is disabled.
+	copCache *copCache
 }
 
 func newTikvStore(uuid string, pdClient pd.Client, client Client, enableGC bool) (*tikvStore, error) {
@@ -123,6 +152,7 @@ func newTikvStore(uuid string, pdClient pd.Client, client Client, enableGC bool)
 		client:      client,
 		pdClient:    pdClient,
 		regionCache: NewRegionCache(pdClient),
+		copCache:    newCopCache(config.GetGlobalConfig().CoprocessorCache),
 	}
 	store.lockResolver = newLockResolver(store)
 	store.enableGC = enableGC
@@ -135,11 +165,11 @@ func (s *tikvStore) EtcdAddrs() []string {
 
 // StartGCWorker starts GC worker, it's called in BootstrapSession, don't call this function more than once.
 func (s *tikvStore) StartGCWorker() error {
-	if !s.enableGC {
+	if !s.enableGC || NewGCHandlerFunc == nil {
 		return nil
 	}
 
-	gcWorker, err := NewGCWorker(s)
+	gcWorker, err := NewGCHandlerFunc(s)
 	if err != nil {
 		return errors.Trace(err)
 	}
@@ -331,6 +361,14 @@ func (s *tikvStore) GetRegionCache() *RegionCache {
 	return s.regionCache
 }
 
+func (s *tikvStore) GetLockResolver() *LockResolver {
+	return s.lockResolver
+}
+
+func (s *tikvStore) SetOracle(oracle oracle.Oracle) {
+	s.oracle = oracle
+}
+
 // ParseEtcdAddr parses path to etcd address list
 func ParseEtcdAddr(path string) (etcdAddrs []string, err error) {
 	etcdAddrs, _, err = parsePath(path)
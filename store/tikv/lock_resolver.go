@@ -16,6 +16,7 @@ package tikv
 import (
 	"container/list"
 	"fmt"
+	"math"
 	"sync"
 
 	log "github.com/Sirupsen/logrus"
@@ -92,7 +93,8 @@ type Lock struct {
 	TTL     uint64
 }
 
-func newLock(l *kvrpcpb.LockInfo) *Lock {
+// NewLock creates a new *Lock.
+func NewLock(l *kvrpcpb.LockInfo) *Lock {
 	ttl := l.GetLockTtl()
 	if ttl == 0 {
 		ttl = defaultLockTTL
@@ -146,8 +148,9 @@ func (lr *LockResolver) ResolveLocks(bo *Backoffer, locks []*Lock) (ok bool, err
 	lockResolverCounter.WithLabelValues("resolve").Inc()
 
 	var expiredLocks []*Lock
+	primaryTTLs := make(map[uint64]uint64)
 	for _, l := range locks {
-		if lr.store.oracle.IsExpired(l.TxnID, l.TTL) {
+		if lr.store.oracle.IsExpired(l.TxnID, l.TTL) && lr.isPrimaryExpired(bo, l, primaryTTLs) {
 			lockResolverCounter.WithLabelValues("expired").Inc()
 			expiredLocks = append(expiredLocks, l)
 		} else {
@@ -181,6 +184,36 @@ func (lr *LockResolver) ResolveLocks(bo *Backoffer, locks []*Lock) (ok bool, err
 	return len(expiredLocks) == len(locks), nil
 }
 
+// unknownPrimaryTTL marks the primary locks whose ttls fail to be queried.
+const unknownPrimaryTTL = math.MaxUint64
+
+// isPrimaryExpired checks the ttl of the primary lock, which may have been extended by
+// the heartbeats of the txn. primaryTTLs caches the ttls by txn.
+func (lr *LockResolver) isPrimaryExpired(bo *Backoffer, l *Lock, primaryTTLs map[uint64]uint64) bool {
+	if !supportTxnHeartBeat(lr.store) {
+		// The ttl of the lock is never extended.
+		return true
+	}
+	ttl, ok := primaryTTLs[l.TxnID]
+	if !ok {
+		var err error
+		ttl, err = sendTxnHeartBeat(bo, lr.store, l.Primary, l.TxnID, 0)
+		if errors.Cause(err) == errPrimaryLockNotFound {
+			// The txn is committed or rolled back, the lock is resolved by its status.
+			ttl = 0
+		} else if err != nil {
+			log.Debugf("[kv] query primary lock ttl err: %v, tid: %d", err, l.TxnID)
+			ttl = unknownPrimaryTTL
+		}
+		primaryTTLs[l.TxnID] = ttl
+	}
+	if ttl == unknownPrimaryTTL {
+		// The lock is taken as alive until the next try.
+		return false
+	}
+	return ttl <= l.TTL || lr.store.oracle.IsExpired(l.TxnID, ttl)
+}
+
 // GetTxnStatus queries tikv-server for a txn's status (commit/rollback).
 // If the primary key is still locked, it will launch a Rollback to abort it.
 // To avoid unnecessarily aborting too many txns, it is wiser to wait a few
@@ -220,7 +253,7 @@ func (lr *LockResolver) getTxnStatus(bo *Backoffer, txnID uint64, primary []byte
 			return status, errors.Trace(err)
 		}
 		if regionErr != nil {
-			err = bo.Backoff(boRegionMiss, errors.New(regionErr.String()))
+			err = bo.Backoff(BoRegionMiss, errors.New(regionErr.String()))
 			if err != nil {
 				return status, errors.Trace(err)
 			}
@@ -228,9 +261,18 @@ func (lr *LockResolver) getTxnStatus(bo *Backoffer, txnID uint64, primary []byte
 		}
 		cmdResp := resp.Cleanup
 		if cmdResp == nil {
-			return status, errors.Trace(errBodyMissing)
+			return status, errors.Trace(ErrBodyMissing)
 		}
 		if keyErr := cmdResp.GetError(); keyErr != nil {
+			if keyErr.GetLocked() != nil && supportAsyncCommit(lr.store) {
+				// The primary lock is an async commit lock, which can't be cleaned up alone.
+				status, err = lr.getAsyncCommitTxnStatus(bo, txnID, primary)
+				if err != nil {
+					return status, errors.Trace(err)
+				}
+				lr.saveResolved(txnID, status)
+				return status, nil
+			}
 			err = errors.Errorf("unexpected cleanup err: %s, tid: %v", keyErr, txnID)
 			log.Error(err)
 			return status, err
@@ -274,7 +316,7 @@ func (lr *LockResolver) resolveLock(bo *Backoffer, l *Lock, status TxnStatus, cl
 			return errors.Trace(err)
 		}
 		if regionErr != nil {
-			err = bo.Backoff(boRegionMiss, errors.New(regionErr.String()))
+			err = bo.Backoff(BoRegionMiss, errors.New(regionErr.String()))
 			if err != nil {
 				return errors.Trace(err)
 			}
@@ -282,7 +324,7 @@ func (lr *LockResolver) resolveLock(bo *Backoffer, l *Lock, status TxnStatus, cl
 		}
 		cmdResp := resp.ResolveLock
 		if cmdResp == nil {
-			return errors.Trace(errBodyMissing)
+			return errors.Trace(ErrBodyMissing)
 		}
 		if keyErr := cmdResp.GetError(); keyErr != nil {
 			err = errors.Errorf("unexpected resolve err: %s, lock: %v", keyErr, l)
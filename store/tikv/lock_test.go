@@ -57,6 +57,7 @@ func (s *testLockSuite) lockKey(c *C, key, value, primaryKey, primaryValue []byt
 	tpc, err := newTwoPhaseCommitter(txn)
 	c.Assert(err, IsNil)
 	tpc.keys = [][]byte{primaryKey, key}
+	tpc.primaryKey = primaryKey
 
 	ctx := goctx.Background()
 	err = tpc.prewriteKeys(NewBackoffer(prewriteMaxBackoff, ctx), tpc.keys)
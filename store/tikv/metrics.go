@@ -104,31 +104,22 @@ var (
 			Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 18),
 		})
 
-	gcWorkerCounter = prometheus.NewCounterVec(
+	copCacheCounter = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Namespace: "tidb",
 			Subsystem: "tikvclient",
-			Name:      "gc_worker_actions_total",
-			Help:      "Counter of gc worker actions.",
+			Name:      "cop_cache_count",
+			Help:      "Counter of coprocessor cache actions.",
 		}, []string{"type"})
 
-	gcHistogram = prometheus.NewHistogramVec(
+	batchGetSizeHistogram = prometheus.NewHistogram(
 		prometheus.HistogramOpts{
 			Namespace: "tidb",
 			Subsystem: "tikvclient",
-			Name:      "gc_seconds",
-			Help:      "Bucketed histogram of gc duration.",
-			Buckets:   prometheus.ExponentialBuckets(1, 2, 13),
-		}, []string{"stage"})
-
-	gcConfigGauge = prometheus.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Namespace: "tidb",
-			Subsystem: "tikvclient",
-			Name:      "gc_config",
-			Help:      "Gauge of GC configs.",
-		}, []string{"type"},
-	)
+			Name:      "batch_get_size",
+			Help:      "Number of the point gets collected in a batch.",
+			Buckets:   prometheus.ExponentialBuckets(1, 2, 10),
+		})
 
 	lockResolverCounter = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
@@ -223,9 +214,8 @@ func init() {
 	prometheus.MustRegister(connPoolHistogram)
 	prometheus.MustRegister(coprocessorCounter)
 	prometheus.MustRegister(coprocessorHistogram)
-	prometheus.MustRegister(gcWorkerCounter)
-	prometheus.MustRegister(gcConfigGauge)
-	prometheus.MustRegister(gcHistogram)
+	prometheus.MustRegister(copCacheCounter)
+	prometheus.MustRegister(batchGetSizeHistogram)
 	prometheus.MustRegister(lockResolverCounter)
 	prometheus.MustRegister(regionErrorCounter)
 	prometheus.MustRegister(txnWriteKVCountHistogram)
@@ -60,6 +60,7 @@ func (h *rpcHandler) handleAnalyzeIndexReq(req *coprocessor.Request, analyzeReq
 		IndexScan:      &tipb.IndexScan{Desc: false},
 	}
 	statsBuilder := statistics.NewSortedBuilder(flagsToStatementContext(analyzeReq.Flags), analyzeReq.IdxReq.BucketSize, 0)
+	statsBuilder.EnableCMSketch(statistics.DefaultCMSketchDepth, statistics.DefaultCMSketchWidth, statistics.DefaultNumTopN)
 	for {
 		_, values, err := e.Next()
 		if err != nil {
@@ -78,7 +79,12 @@ func (h *rpcHandler) handleAnalyzeIndexReq(req *coprocessor.Request, analyzeReq
 		}
 	}
 	hg := statistics.HistogramToProto(statsBuilder.Hist())
-	data, err := proto.Marshal(&tipb.AnalyzeIndexResp{Hist: hg})
+	resp := &tipb.AnalyzeIndexResp{Hist: hg}
+	err := statistics.SetIndexRespCMSketch(resp, statsBuilder.CMSketch())
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	data, err := proto.Marshal(resp)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
@@ -120,6 +126,8 @@ func (h *rpcHandler) handleAnalyzeColumnsReq(req *coprocessor.Request, analyzeRe
 		MaxBucketSize: colReq.BucketSize,
 		MaxSketchSize: colReq.SketchSize,
 		MaxSampleSize: colReq.SampleSize,
+		CMSketchDepth: statistics.DefaultCMSketchDepth,
+		CMSketchWidth: statistics.DefaultCMSketchWidth,
 	}
 	collectors, pkBuilder, err := builder.CollectSamplesAndEstimateNDVs()
 	if err != nil {
@@ -130,7 +138,11 @@ func (h *rpcHandler) handleAnalyzeColumnsReq(req *coprocessor.Request, analyzeRe
 		colResp.PkHist = statistics.HistogramToProto(pkBuilder.Hist())
 	}
 	for _, c := range collectors {
-		colResp.Collectors = append(colResp.Collectors, statistics.SampleCollectorToProto(c))
+		pc, err := statistics.SampleCollectorToProto(c)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		colResp.Collectors = append(colResp.Collectors, pc)
 	}
 	data, err := proto.Marshal(colResp)
 	if err != nil {
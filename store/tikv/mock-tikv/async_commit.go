@@ -0,0 +1,129 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mocktikv
+
+import (
+	"math"
+	"sync/atomic"
+
+	"github.com/pingcap/kvproto/pkg/kvrpcpb"
+)
+
+// AsyncCommitOption is the option to prewrite the mutations of an async commit or
+// 1PC transaction.
+type AsyncCommitOption struct {
+	UseAsyncCommit bool
+	// Secondaries are set on the primary lock of an async commit transaction.
+	Secondaries [][]byte
+	TryOnePC    bool
+	MinCommitTS uint64
+	// MaxCommitTS is the upper bound of the commit ts, 0 means no bound.
+	MaxCommitTS uint64
+}
+
+// TxnStatus is the status of a transaction queried by its primary key.
+type TxnStatus struct {
+	// CommitTS is the commit ts if the transaction is committed.
+	CommitTS uint64
+	// LockTTL is the ttl of the primary lock if it's still locked, the other fields
+	// describe the lock then.
+	LockTTL        uint64
+	UseAsyncCommit bool
+	Secondaries    [][]byte
+	MinCommitTS    uint64
+}
+
+// SecondaryLocksStatus is the status of the secondary keys of an async commit transaction.
+type SecondaryLocksStatus struct {
+	// Locks are the locks of the transaction on the keys.
+	Locks []*kvrpcpb.LockInfo
+	// MinCommitTS is the max min commit ts of the locks.
+	MinCommitTS uint64
+	// CommitTS is the commit ts of the transaction if any of the keys is committed.
+	CommitTS uint64
+}
+
+// errCommitTSTooLarge is returned when the commit ts of an async commit or 1PC transaction
+// exceeds its max commit ts, the transaction should be retried.
+var errCommitTSTooLarge = ErrRetryable("commit ts too large")
+
+// errCommitTSExpired is returned when a lock is committed with a ts less than its min
+// commit ts.
+var errCommitTSExpired = ErrAbort("commit ts expired")
+
+// readTSTracker tracks the max ts of the reads. The commit ts of an async commit
+// transaction is calculated by the storage, it must be larger than the ts of any read
+// which may have missed the data of the transaction.
+type readTSTracker struct {
+	maxReadTS uint64
+}
+
+func (t *readTSTracker) updateMaxReadTS(ts uint64) {
+	// The reads at the max ts don't read data by snapshot.
+	if ts == math.MaxUint64 {
+		return
+	}
+	for {
+		old := atomic.LoadUint64(&t.maxReadTS)
+		if ts <= old || atomic.CompareAndSwapUint64(&t.maxReadTS, old, ts) {
+			return
+		}
+	}
+}
+
+// minCommitTS returns the min commit ts of the locks of a transaction prewritten now.
+func (t *readTSTracker) minCommitTS(startTS, minCommitTS uint64) uint64 {
+	ts := atomic.LoadUint64(&t.maxReadTS) + 1
+	if ts < startTS+1 {
+		ts = startTS + 1
+	}
+	if ts < minCommitTS {
+		ts = minCommitTS
+	}
+	return ts
+}
+
+// setAsyncCommit makes l an async commit lock.
+func (l *mvccLock) setAsyncCommit(key []byte, opt AsyncCommitOption, minCommitTS uint64) {
+	l.useAsyncCommit = true
+	l.minCommitTS = minCommitTS
+	if string(key) == string(l.primary) {
+		l.secondaries = opt.Secondaries
+	}
+}
+
+// isSkippedBy checks whether a read at ts can ignore the lock, the transaction of an
+// async commit lock is committed after its min commit ts.
+func (l *mvccLock) isSkippedBy(ts uint64) bool {
+	return l.op == opPessimisticLock || l.startTS > ts || (l.useAsyncCommit && l.minCommitTS > ts)
+}
+
+// txnStatus returns the TxnStatus of a transaction which is still locked.
+func (l *mvccLock) txnStatus() TxnStatus {
+	return TxnStatus{
+		LockTTL:        l.ttl,
+		UseAsyncCommit: l.useAsyncCommit,
+		Secondaries:    l.secondaries,
+		MinCommitTS:    l.minCommitTS,
+	}
+}
+
+func (l *mvccLock) lockInfo(key []byte) *kvrpcpb.LockInfo {
+	return &kvrpcpb.LockInfo{
+		Key:         key,
+		PrimaryLock: l.primary,
+		LockVersion: l.startTS,
+		LockTtl:     l.ttl,
+	}
+}
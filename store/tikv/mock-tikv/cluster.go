@@ -16,6 +16,7 @@ package mocktikv
 import (
 	"bytes"
 	"math"
+	"sort"
 	"sync"
 
 	"github.com/golang/protobuf/proto"
@@ -34,7 +35,7 @@ import (
 //    a group, each group elects a Leader to provide services.
 // 3) Store: A Store is a storage/service node. Try to think it as a TiKV server
 //    process. Only the store with request's Region's leader Peer could respond
-//    to client's request.
+//    to client's request, except that the followers respond to replica reads.
 type Cluster struct {
 	sync.RWMutex
 	id      uint64
@@ -175,6 +176,16 @@ func (c *Cluster) AddStore(storeID uint64, addr string) {
 	c.stores[storeID] = newStore(storeID, addr)
 }
 
+// UpdateStoreLabels sets the labels of a Store.
+func (c *Cluster) UpdateStoreLabels(storeID uint64, labels []*metapb.StoreLabel) {
+	c.Lock()
+	defer c.Unlock()
+
+	if store := c.stores[storeID]; store != nil {
+		store.meta.Labels = labels
+	}
+}
+
 // RemoveStore removes a Store from the cluster.
 func (c *Cluster) RemoveStore(storeID uint64) {
 	c.Lock()
@@ -295,8 +306,8 @@ func (c *Cluster) Merge(regionID1, regionID2 uint64) {
 	delete(c.regions, regionID2)
 }
 
-// SplitTable evenly splits the data in table into count regions.
-// Only works for single store.
+// SplitTable evenly splits the data in table into count regions. The new
+// regions have a peer on every store, the leader is on the first store.
 func (c *Cluster) SplitTable(mvccStore *MvccStore, tableID int64, count int) {
 	tableStart := tablecodec.GenTableRecordPrefix(tableID)
 	tableEnd := tableStart.PrefixNext()
@@ -304,7 +315,6 @@ func (c *Cluster) SplitTable(mvccStore *MvccStore, tableID int64, count int) {
 }
 
 // SplitIndex evenly splits the data in index into count regions.
-// Only works for single store.
 func (c *Cluster) SplitIndex(mvccStore *MvccStore, tableID, indexID int64, count int) {
 	indexStart := tablecodec.EncodeTableIndexPrefix(tableID, indexID)
 	indexEnd := indexStart.PrefixNext()
@@ -343,8 +353,7 @@ func (c *Cluster) getEntriesGroupByRegions(mvccStore *MvccStore, start, end Mvcc
 
 func (c *Cluster) createNewRegions(regionPairs [][]Pair, start, end MvccKey) {
 	for i := range regionPairs {
-		peerID := c.allocID()
-		newRegion := newRegion(c.allocID(), []uint64{c.firstStoreID()}, []uint64{peerID}, peerID)
+		newRegion := c.newRegionOnAllStores()
 		var regionStartKey, regionEndKey MvccKey
 		if i == 0 {
 			regionStartKey = start
@@ -379,8 +388,7 @@ func (c *Cluster) evacuateOldRegionRanges(start, end MvccKey) {
 			// A single Region covers table data, split into two regions that do not overlap table data.
 			oldEnd := oldRegion.Meta.EndKey
 			oldRegion.updateKeyRange(oldRegion.Meta.StartKey, start)
-			peerID := c.allocID()
-			newRegion := newRegion(c.allocID(), []uint64{c.firstStoreID()}, []uint64{peerID}, peerID)
+			newRegion := c.newRegionOnAllStores()
 			newRegion.updateKeyRange(end, oldEnd)
 			c.regions[newRegion.Meta.Id] = newRegion
 		} else if startCmp < 0 {
@@ -391,11 +399,23 @@ func (c *Cluster) evacuateOldRegionRanges(start, end MvccKey) {
 	}
 }
 
-func (c *Cluster) firstStoreID() uint64 {
+// newRegionOnAllStores creates a Region with a Peer on every Store, the leader
+// is the Peer on the Store with the smallest ID.
+func (c *Cluster) newRegionOnAllStores() *Region {
+	storeIDs := make([]uint64, 0, len(c.stores))
 	for id := range c.stores {
-		return id
+		storeIDs = append(storeIDs, id)
+	}
+	sort.Slice(storeIDs, func(i, j int) bool { return storeIDs[i] < storeIDs[j] })
+	peerIDs := make([]uint64, 0, len(storeIDs))
+	for range storeIDs {
+		peerIDs = append(peerIDs, c.allocID())
+	}
+	var leaderPeerID uint64
+	if len(peerIDs) > 0 {
+		leaderPeerID = peerIDs[0]
 	}
-	return 0
+	return newRegion(c.allocID(), storeIDs, peerIDs, leaderPeerID)
 }
 
 // getRegionsCoverRange gets regions in the cluster that has intersection with [start, end).
@@ -116,3 +116,46 @@ func (s *testClusterSuite) TestClusterSplit(c *C) {
 	}
 	c.Assert(allIndexMap, HasLen, 1000)
 }
+
+func (s *testClusterSuite) TestClusterSplitMultiStores(c *C) {
+	cluster := mocktikv.NewCluster()
+	storeIDs, _, _, _ := mocktikv.BootstrapWithMultiStores(cluster, 3)
+	mvccStore := mocktikv.NewMvccStore()
+	store, err := tikv.NewMockTikvStore(
+		tikv.WithCluster(cluster),
+		tikv.WithMVCCStore(mvccStore),
+	)
+	c.Assert(err, IsNil)
+
+	tblID := int64(1)
+	txn, err := store.Begin()
+	c.Assert(err, IsNil)
+	for i := int64(0); i < 100; i++ {
+		c.Assert(txn.Set(tablecodec.EncodeRowKeyWithHandle(tblID, i), []byte{'v'}), IsNil)
+	}
+	c.Assert(txn.Commit(), IsNil)
+
+	// The new regions have a peer on every store.
+	cluster.SplitTable(mvccStore, tblID, 10)
+	recordPrefix := tablecodec.GenTableRecordPrefix(tblID)
+	for _, region := range cluster.GetAllRegions() {
+		if !bytes.HasPrefix(mocktikv.MvccKey(region.Meta.StartKey).Raw(), recordPrefix) {
+			continue
+		}
+		c.Assert(region.Meta.Peers, HasLen, 3)
+		meta, leader := cluster.GetRegion(region.Meta.Id)
+		c.Assert(meta.Peers[0].GetId(), Equals, leader)
+		c.Assert(meta.Peers[0].GetStoreId(), Equals, storeIDs[0])
+	}
+
+	// The followers serve the replica reads.
+	txn, err = store.Begin()
+	c.Assert(err, IsNil)
+	txn.SetOption(kv.ReplicaRead, kv.ReplicaReadFollower)
+	for i := int64(0); i < 100; i++ {
+		val, err := txn.Get(tablecodec.EncodeRowKeyWithHandle(tblID, i))
+		c.Assert(err, IsNil)
+		c.Assert(val, BytesEquals, []byte{'v'})
+	}
+	c.Assert(txn.Rollback(), IsNil)
+}
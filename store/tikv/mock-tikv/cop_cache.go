@@ -0,0 +1,45 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mocktikv
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/juju/errors"
+	"github.com/pingcap/kvproto/pkg/coprocessor"
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/store/tikv/tikvrpc"
+	"github.com/pingcap/tipb/go-tipb"
+)
+
+// checkCopCache reports the data version of the region for the coprocessor cache of the
+// client. The data version is the max commit ts of the region, a response is valid until
+// it changes if the region has no lock and no version newer than the start ts of the
+// request, because a later commit always gets a larger commit ts.
+func (h *rpcHandler) checkCopCache(req *coprocessor.Request, cacheReq *tikvrpc.CopCacheRequest) (*tikvrpc.CopCacheResponse, error) {
+	versioner, ok := h.mvccStore.(MVCCDataVersioner)
+	if !ok || req.GetTp() != kv.ReqTypeDAG {
+		return nil, nil
+	}
+	dagReq := new(tipb.DAGRequest)
+	if err := proto.Unmarshal(req.Data, dagReq); err != nil {
+		return nil, errors.Trace(err)
+	}
+	version, locked := versioner.DataVersion(h.startKey, h.endKey)
+	resp := &tikvrpc.CopCacheResponse{
+		CanBeCached:      !locked && version > 0 && version <= dagReq.StartTs,
+		CacheLastVersion: version,
+	}
+	resp.IsCacheHit = resp.CanBeCached && cacheReq.CacheIfMatchVersion == version
+	return resp, nil
+}
@@ -0,0 +1,168 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mocktikv
+
+import (
+	"hash/crc64"
+	"sync"
+	"time"
+)
+
+// Detector detects deadlocks with the wait-for graph of the transactions.
+// An edge txn1 -> txn2 means txn1 is waiting for a lock held by txn2.
+type Detector struct {
+	mu         sync.Mutex
+	waitForMap map[uint64][]txnKeyHashPair
+}
+
+type txnKeyHashPair struct {
+	txn     uint64
+	keyHash uint64
+}
+
+// NewDetector creates a Detector.
+func NewDetector() *Detector {
+	return &Detector{
+		waitForMap: make(map[uint64][]txnKeyHashPair),
+	}
+}
+
+// Detect checks whether sourceTxn waiting for waitForTxn leads to a deadlock.
+// If not, the wait-for edge is added to the graph.
+func (d *Detector) Detect(sourceTxn, waitForTxn, keyHash uint64) *ErrDeadlock {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if err := d.doDetect(sourceTxn, waitForTxn); err != nil {
+		return err
+	}
+	d.waitForMap[sourceTxn] = append(d.waitForMap[sourceTxn], txnKeyHashPair{txn: waitForTxn, keyHash: keyHash})
+	return nil
+}
+
+// doDetect searches the graph for a path from waitForTxn to sourceTxn.
+func (d *Detector) doDetect(sourceTxn, waitForTxn uint64) *ErrDeadlock {
+	visited := make(map[uint64]struct{})
+	stack := []uint64{waitForTxn}
+	for len(stack) > 0 {
+		txn := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if _, ok := visited[txn]; ok {
+			continue
+		}
+		visited[txn] = struct{}{}
+		for _, next := range d.waitForMap[txn] {
+			if next.txn == sourceTxn {
+				return &ErrDeadlock{DeadlockKeyHash: next.keyHash}
+			}
+			stack = append(stack, next.txn)
+		}
+	}
+	return nil
+}
+
+// CleanUp removes all the wait-for edges of txn.
+func (d *Detector) CleanUp(txn uint64) {
+	d.mu.Lock()
+	delete(d.waitForMap, txn)
+	d.mu.Unlock()
+}
+
+// CleanUpWaitFor removes the wait-for edge from txn to waitForTxn on the key.
+func (d *Detector) CleanUpWaitFor(txn, waitForTxn, keyHash uint64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	pairs := d.waitForMap[txn]
+	for i, pair := range pairs {
+		if pair.txn == waitForTxn && pair.keyHash == keyHash {
+			pairs = append(pairs[:i], pairs[i+1:]...)
+			break
+		}
+	}
+	if len(pairs) == 0 {
+		delete(d.waitForMap, txn)
+	} else {
+		d.waitForMap[txn] = pairs
+	}
+}
+
+var keyHashTable = crc64.MakeTable(crc64.ECMA)
+
+func hashKey(key []byte) uint64 {
+	return crc64.Checksum(key, keyHashTable)
+}
+
+// lockWaiter wakes up the pessimistic lock requests waiting for the locks of a
+// transaction when the transaction releases its locks.
+type lockWaiter struct {
+	mu sync.Mutex
+	// epoch is increased every time a transaction releases its locks.
+	epoch   uint64
+	waiters map[uint64][]chan struct{}
+}
+
+func newLockWaiter() *lockWaiter {
+	return &lockWaiter{
+		waiters: make(map[uint64][]chan struct{}),
+	}
+}
+
+func (w *lockWaiter) currentEpoch() uint64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.epoch
+}
+
+// waitFor waits until the transaction lockTS releases its locks or the timeout is reached.
+// It returns at once if any transaction has released its locks since epoch, because
+// the lock may be released before we start to wait.
+func (w *lockWaiter) waitFor(lockTS uint64, epoch uint64, timeout time.Duration) {
+	w.mu.Lock()
+	if w.epoch != epoch {
+		w.mu.Unlock()
+		return
+	}
+	ch := make(chan struct{})
+	w.waiters[lockTS] = append(w.waiters[lockTS], ch)
+	w.mu.Unlock()
+
+	select {
+	case <-ch:
+	case <-time.After(timeout):
+		w.mu.Lock()
+		chs := w.waiters[lockTS]
+		for i, c := range chs {
+			if c == ch {
+				w.waiters[lockTS] = append(chs[:i], chs[i+1:]...)
+				break
+			}
+		}
+		if len(w.waiters[lockTS]) == 0 {
+			delete(w.waiters, lockTS)
+		}
+		w.mu.Unlock()
+	}
+}
+
+// wakeUp wakes up the requests waiting for the locks of the transaction startTS.
+func (w *lockWaiter) wakeUp(startTS uint64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.epoch++
+	for _, ch := range w.waiters[startTS] {
+		close(ch)
+	}
+	delete(w.waiters, startTS)
+}
@@ -50,3 +50,15 @@ type ErrAlreadyCommitted uint64
 func (e ErrAlreadyCommitted) Error() string {
 	return fmt.Sprint("txn already committed")
 }
+
+// ErrDeadlock is returned when the transaction waiting for a lock forms a cycle in
+// the wait-for graph.
+type ErrDeadlock struct {
+	LockKey         []byte
+	LockTS          uint64
+	DeadlockKeyHash uint64
+}
+
+func (e *ErrDeadlock) Error() string {
+	return fmt.Sprintf("deadlock, key: %q, lockTS: %v", e.LockKey, e.LockTS)
+}
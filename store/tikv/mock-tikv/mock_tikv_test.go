@@ -16,6 +16,7 @@ package mocktikv
 import (
 	"strings"
 	"testing"
+	"time"
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/kvproto/pkg/kvrpcpb"
@@ -421,6 +422,169 @@ func (s *testMockTiKVSuite) TestRC(c *C) {
 	s.mustGetRC(c, "key", 20, "v1")
 }
 
+func (s *testMockTiKVSuite) mustPessimisticLockOK(c *C, keys [][]byte, primary string, startTS, forUpdateTS uint64) {
+	errs := s.store.PessimisticLock(keys, []byte(primary), startTS, forUpdateTS, 0)
+	for _, err := range errs {
+		c.Assert(err, IsNil)
+	}
+}
+
+func (s *testMockTiKVSuite) TestPessimisticLock(c *C) {
+	s.mustPutOK(c, "k1", "v1", 1, 2)
+	s.mustPessimisticLockOK(c, [][]byte{[]byte("k1"), []byte("k2")}, "k1", 5, 5)
+	// Locking again is idempotent.
+	s.mustPessimisticLockOK(c, [][]byte{[]byte("k1")}, "k1", 5, 6)
+
+	// Pessimistic locks don't block reads.
+	s.mustGetOK(c, "k1", 10, "v1")
+	s.mustGetNone(c, "k2", 10)
+
+	// Other transactions can't lock or prewrite the keys.
+	errs := s.store.PessimisticLock([][]byte{[]byte("k3"), []byte("k1")}, []byte("k3"), 6, 6, 0)
+	c.Assert(errs[0], IsNil)
+	_, ok := errs[1].(*ErrLocked)
+	c.Assert(ok, IsTrue)
+	// No lock is written if any key fails.
+	s.mustPessimisticLockOK(c, [][]byte{[]byte("k3")}, "k3", 7, 7)
+	c.Assert(s.store.PessimisticRollback([][]byte{[]byte("k3")}, 7, 7), IsNil)
+	errs = s.store.Prewrite(putMutations("k2", "v6"), []byte("k2"), 6, 0)
+	_, ok = errs[0].(*ErrLocked)
+	c.Assert(ok, IsTrue)
+
+	// The owner prewrites and commits over its pessimistic locks.
+	mutations := putMutations("k1", "v5")
+	mutations = append(mutations, &kvrpcpb.Mutation{Op: kvrpcpb.Op_Lock, Key: []byte("k2")})
+	s.mustPrewriteOK(c, mutations, "k1", 5)
+	s.mustCommitOK(c, [][]byte{[]byte("k1"), []byte("k2")}, 5, 8)
+	s.mustGetOK(c, "k1", 9, "v5")
+	s.mustGetNone(c, "k2", 9)
+}
+
+func (s *testMockTiKVSuite) TestPessimisticLockConflict(c *C) {
+	s.mustPutOK(c, "k1", "v1", 10, 12)
+	// A write committed after forUpdateTS is a write conflict.
+	errs := s.store.PessimisticLock([][]byte{[]byte("k1")}, []byte("k1"), 9, 11, 0)
+	s.mustWriteWriteConflict(c, errs, 0)
+	s.mustPessimisticLockOK(c, [][]byte{[]byte("k1")}, "k1", 9, 13)
+
+	// The lock is released by PessimisticRollback.
+	err := s.store.PessimisticRollback([][]byte{[]byte("k1")}, 9, 13)
+	c.Assert(err, IsNil)
+	s.mustPessimisticLockOK(c, [][]byte{[]byte("k1")}, "k1", 14, 14)
+
+	// A rolled back transaction can't lock the key again.
+	s.mustRollbackOK(c, [][]byte{[]byte("k1")}, 14)
+	errs = s.store.PessimisticLock([][]byte{[]byte("k1")}, []byte("k1"), 14, 15, 0)
+	c.Assert(errs[0], NotNil)
+}
+
+func (s *testMockTiKVSuite) TestTxnHeartBeat(c *C) {
+	s.mustPrewriteOK(c, putMutations("pk", "pv", "k", "v"), "pk", 5)
+	ttl, err := s.store.TxnHeartBeat([]byte("pk"), 5, 100)
+	c.Assert(err, IsNil)
+	c.Assert(ttl, Equals, uint64(100))
+	// The ttl is never decreased.
+	ttl, err = s.store.TxnHeartBeat([]byte("pk"), 5, 50)
+	c.Assert(err, IsNil)
+	c.Assert(ttl, Equals, uint64(100))
+	// The lock is not held by the txn.
+	_, err = s.store.TxnHeartBeat([]byte("pk"), 6, 100)
+	c.Assert(err, NotNil)
+
+	s.mustCommitOK(c, [][]byte{[]byte("pk"), []byte("k")}, 5, 10)
+	_, err = s.store.TxnHeartBeat([]byte("pk"), 5, 200)
+	c.Assert(err, NotNil)
+}
+
+func (s *testMockTiKVSuite) TestAsyncPrewrite(c *C) {
+	s.mustPutOK(c, "k1", "v1", 1, 2)
+	s.mustGetOK(c, "k1", 10, "v1")
+
+	// The min commit ts is larger than the max read ts.
+	opt := AsyncCommitOption{UseAsyncCommit: true, Secondaries: [][]byte{[]byte("k1")}}
+	minCommitTS, errs := s.store.AsyncPrewrite(putMutations("pk", "pv", "k1", "v2"), []byte("pk"), 5, 100, opt)
+	for _, err := range errs {
+		c.Assert(err, IsNil)
+	}
+	c.Assert(minCommitTS, Equals, uint64(11))
+	// The reads before the min commit ts skip the locks.
+	s.mustGetOK(c, "k1", 10, "v1")
+	s.mustGetErr(c, "k1", 20)
+
+	status, err := s.store.CheckTxnStatus([]byte("pk"), 5)
+	c.Assert(err, IsNil)
+	c.Assert(status.LockTTL, Equals, uint64(100))
+	c.Assert(status.UseAsyncCommit, IsTrue)
+	c.Assert(status.Secondaries, DeepEquals, [][]byte{[]byte("k1")})
+	c.Assert(status.MinCommitTS, Equals, uint64(11))
+	locks, err := s.store.CheckSecondaryLocks([][]byte{[]byte("k1")}, 5)
+	c.Assert(err, IsNil)
+	c.Assert(locks.Locks, HasLen, 1)
+	c.Assert(locks.MinCommitTS, Equals, uint64(11))
+
+	// The commit ts can't be less than the min commit ts.
+	s.mustCommitErr(c, [][]byte{[]byte("pk")}, 5, 10)
+	s.mustCommitOK(c, [][]byte{[]byte("pk"), []byte("k1")}, 5, 11)
+	s.mustGetOK(c, "k1", 11, "v2")
+	status, err = s.store.CheckTxnStatus([]byte("pk"), 5)
+	c.Assert(err, IsNil)
+	c.Assert(status.CommitTS, Equals, uint64(11))
+
+	// The min commit ts exceeds the max commit ts.
+	s.mustGetNone(c, "k2", 30)
+	opt = AsyncCommitOption{UseAsyncCommit: true, MaxCommitTS: 28}
+	_, errs = s.store.AsyncPrewrite(putMutations("k2", "v2"), []byte("k2"), 25, 100, opt)
+	c.Assert(errs[0], NotNil)
+	s.mustGetNone(c, "k2", 40)
+}
+
+func (s *testMockTiKVSuite) TestCheckSecondaryLocks(c *C) {
+	opt := AsyncCommitOption{UseAsyncCommit: true, Secondaries: [][]byte{[]byte("k1"), []byte("k2")}}
+	_, errs := s.store.AsyncPrewrite(putMutations("pk", "pv", "k1", "v1"), []byte("pk"), 5, 100, opt)
+	for _, err := range errs {
+		c.Assert(err, IsNil)
+	}
+	// "k2" isn't prewritten, the transaction is rolled back.
+	status, err := s.store.CheckSecondaryLocks([][]byte{[]byte("k1"), []byte("k2")}, 5)
+	c.Assert(err, IsNil)
+	c.Assert(status.Locks, HasLen, 0)
+	c.Assert(status.CommitTS, Equals, uint64(0))
+	errs = s.store.Prewrite(putMutations("k2", "v2"), []byte("pk"), 5, 0)
+	c.Assert(errs[0], NotNil)
+}
+
+func (s *testMockTiKVSuite) TestOnePC(c *C) {
+	opt := AsyncCommitOption{TryOnePC: true}
+	commitTS, errs := s.store.AsyncPrewrite(putMutations("k1", "v1", "k2", "v2"), []byte("k1"), 5, 100, opt)
+	for _, err := range errs {
+		c.Assert(err, IsNil)
+	}
+	c.Assert(commitTS, Equals, uint64(6))
+	s.mustGetNone(c, "k1", 5)
+	s.mustGetOK(c, "k1", 6, "v1")
+	s.mustGetOK(c, "k2", 6, "v2")
+	locks, err := s.store.ScanLock(nil, nil, 10)
+	c.Assert(err, IsNil)
+	c.Assert(locks, HasLen, 0)
+}
+
+func (s testMarshal) TestDeadlockDetector(c *C) {
+	d := NewDetector()
+	c.Assert(d.Detect(1, 2, 100), IsNil)
+	c.Assert(d.Detect(2, 3, 200), IsNil)
+	err := d.Detect(3, 1, 300)
+	c.Assert(err, NotNil)
+	// The hash is of the key held by the source txn in the cycle.
+	c.Assert(err.DeadlockKeyHash, Equals, uint64(200))
+	// The edge isn't added when a deadlock is found.
+	c.Assert(d.Detect(3, 4, 400), IsNil)
+
+	d.CleanUpWaitFor(2, 3, 200)
+	c.Assert(d.Detect(3, 1, 300), IsNil)
+	d.CleanUp(3)
+	c.Assert(d.Detect(2, 3, 200), IsNil)
+}
+
 func (s testMarshal) TestMarshalmvccLock(c *C) {
 	l := mvccLock{
 		startTS: 47,
@@ -462,3 +626,36 @@ func (s testMarshal) TestMarshalmvccValue(c *C) {
 	c.Assert(v.commitTS, Equals, v1.commitTS)
 	c.Assert(string(v.value), Equals, string(v.value))
 }
+
+func (s *testMvccStore) TestRawTTL(c *C) {
+	now := time.Now()
+	rawNow = func() time.Time { return now }
+	defer func() { rawNow = time.Now }()
+
+	store := s.store.(RawKV)
+	store.RawPutTTL([]byte("k1"), []byte("v1"), 10)
+	store.RawPut([]byte("k2"), []byte("v2"))
+	ttl, ok := store.RawGetKeyTTL([]byte("k1"))
+	c.Assert(ok, IsTrue)
+	c.Assert(ttl, Equals, uint64(10))
+
+	now = now.Add(9500 * time.Millisecond)
+	ttl, ok = store.RawGetKeyTTL([]byte("k1"))
+	c.Assert(ok, IsTrue)
+	c.Assert(ttl, Equals, uint64(1))
+
+	now = now.Add(time.Second)
+	_, ok = store.RawGetKeyTTL([]byte("k1"))
+	c.Assert(ok, IsFalse)
+	c.Assert(store.RawGet([]byte("k1")), IsNil)
+	c.Assert(store.RawScan(nil, nil, 10), HasLen, 1)
+	c.Assert(store.RawReverseScan(nil, nil, 10), HasLen, 1)
+
+	// The expired key is taken as not existing.
+	_, prevNotExist, succeed := store.RawCompareAndSwap([]byte("k1"), nil, true, []byte("v3"), 0)
+	c.Assert(prevNotExist, IsTrue)
+	c.Assert(succeed, IsTrue)
+	ttl, ok = store.RawGetKeyTTL([]byte("k1"))
+	c.Assert(ok, IsTrue)
+	c.Assert(ttl, Equals, uint64(0))
+}
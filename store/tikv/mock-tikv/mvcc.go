@@ -19,6 +19,7 @@ import (
 	"io"
 	"sort"
 	"sync"
+	"time"
 
 	"github.com/juju/errors"
 	"github.com/petar/GoLLRB/llrb"
@@ -41,12 +42,21 @@ type mvccValue struct {
 	value     []byte
 }
 
+// opPessimisticLock is the op of the locks acquired by the pessimistic transactions before prewrite.
+// A pessimistic lock doesn't block reads, it's replaced by the prewrite lock when the transaction commits.
+const opPessimisticLock kvrpcpb.Op = 4
+
 type mvccLock struct {
-	startTS uint64
-	primary []byte
-	value   []byte
-	op      kvrpcpb.Op
-	ttl     uint64
+	startTS     uint64
+	primary     []byte
+	value       []byte
+	op          kvrpcpb.Op
+	ttl         uint64
+	forUpdateTS uint64
+	// The fields of the async commit locks.
+	useAsyncCommit bool
+	secondaries    [][]byte
+	minCommitTS    uint64
 }
 
 type mvccEntry struct {
@@ -66,6 +76,13 @@ func (l *mvccLock) MarshalBinary() ([]byte, error) {
 	mh.WriteSlice(&buf, l.value)
 	mh.WriteNumber(&buf, l.op)
 	mh.WriteNumber(&buf, l.ttl)
+	mh.WriteNumber(&buf, l.forUpdateTS)
+	mh.WriteNumber(&buf, l.useAsyncCommit)
+	mh.WriteNumber(&buf, l.minCommitTS)
+	mh.WriteNumber(&buf, uint64(len(l.secondaries)))
+	for _, k := range l.secondaries {
+		mh.WriteSlice(&buf, k)
+	}
 	return buf.Bytes(), errors.Trace(mh.err)
 }
 
@@ -78,6 +95,21 @@ func (l *mvccLock) UnmarshalBinary(data []byte) error {
 	mh.ReadSlice(buf, &l.value)
 	mh.ReadNumber(buf, &l.op)
 	mh.ReadNumber(buf, &l.ttl)
+	// The locks written by the old versions don't have forUpdateTS.
+	if buf.Len() > 0 {
+		mh.ReadNumber(buf, &l.forUpdateTS)
+	}
+	if buf.Len() > 0 {
+		mh.ReadNumber(buf, &l.useAsyncCommit)
+		mh.ReadNumber(buf, &l.minCommitTS)
+		var n uint64
+		mh.ReadNumber(buf, &n)
+		for i := uint64(0); i < n && mh.err == nil; i++ {
+			var k []byte
+			mh.ReadSlice(buf, &k)
+			l.secondaries = append(l.secondaries, k)
+		}
+	}
 	return errors.Trace(mh.err)
 }
 
@@ -210,11 +242,16 @@ func (e *mvccEntry) Clone() *mvccEntry {
 	}
 	if e.lock != nil {
 		entry.lock = &mvccLock{
-			startTS: e.lock.startTS,
-			primary: append([]byte(nil), e.lock.primary...),
-			value:   append([]byte(nil), e.lock.value...),
-			op:      e.lock.op,
-			ttl:     e.lock.ttl,
+			startTS:     e.lock.startTS,
+			primary:     append([]byte(nil), e.lock.primary...),
+			value:       append([]byte(nil), e.lock.value...),
+			op:          e.lock.op,
+			ttl:         e.lock.ttl,
+			forUpdateTS: e.lock.forUpdateTS,
+
+			useAsyncCommit: e.lock.useAsyncCommit,
+			secondaries:    e.lock.secondaries,
+			minCommitTS:    e.lock.minCommitTS,
 		}
 	}
 	return &entry
@@ -235,7 +272,7 @@ func (e *mvccEntry) lockErr() error {
 
 func (e *mvccEntry) Get(ts uint64, isoLevel kvrpcpb.IsolationLevel) ([]byte, error) {
 	if isoLevel == kvrpcpb.IsolationLevel_SI {
-		if e.lock != nil && e.lock.startTS <= ts {
+		if e.lock != nil && !e.lock.isSkippedBy(ts) {
 			return nil, e.lockErr()
 		}
 	}
@@ -248,23 +285,28 @@ func (e *mvccEntry) Get(ts uint64, isoLevel kvrpcpb.IsolationLevel) ([]byte, err
 }
 
 func (e *mvccEntry) Prewrite(mutation *kvrpcpb.Mutation, startTS uint64, primary []byte, ttl uint64) error {
-	if len(e.values) > 0 {
-		if e.values[0].commitTS >= startTS {
-			return ErrRetryable("write conflict")
-		}
-	}
+	var forUpdateTS uint64
 	if e.lock != nil {
 		if e.lock.startTS != startTS {
 			return e.lockErr()
 		}
-		return nil
+		if e.lock.op != opPessimisticLock {
+			return nil
+		}
+		// The write conflict has been checked when the pessimistic lock is acquired.
+		forUpdateTS = e.lock.forUpdateTS
+	} else if len(e.values) > 0 {
+		if e.values[0].commitTS >= startTS {
+			return ErrRetryable("write conflict")
+		}
 	}
 	e.lock = &mvccLock{
-		startTS: startTS,
-		primary: primary,
-		value:   mutation.Value,
-		op:      mutation.GetOp(),
-		ttl:     ttl,
+		startTS:     startTS,
+		primary:     primary,
+		value:       mutation.Value,
+		op:          mutation.GetOp(),
+		ttl:         ttl,
+		forUpdateTS: forUpdateTS,
 	}
 	return nil
 }
@@ -285,7 +327,10 @@ func (e *mvccEntry) Commit(startTS, commitTS uint64) error {
 		}
 		return ErrRetryable("txn not found")
 	}
-	if e.lock.op != kvrpcpb.Op_Lock {
+	if commitTS < e.lock.minCommitTS {
+		return errCommitTSExpired
+	}
+	if e.lock.op != kvrpcpb.Op_Lock && e.lock.op != opPessimisticLock {
 		var valueType mvccValueType
 		if e.lock.op == kvrpcpb.Op_Put {
 			valueType = typePut
@@ -334,6 +379,58 @@ func (e *mvccEntry) Rollback(startTS uint64) error {
 	return nil
 }
 
+// PessimisticLock acquires the pessimistic lock of the entry. It returns a write conflict
+// error if the entry is written after forUpdateTS.
+func (e *mvccEntry) PessimisticLock(startTS, forUpdateTS uint64, primary []byte, ttl uint64) error {
+	if e.lock != nil {
+		if e.lock.startTS != startTS {
+			return e.lockErr()
+		}
+		return nil
+	}
+	for _, v := range e.values {
+		if v.startTS == startTS && v.valueType == typeRollback {
+			return ErrAbort("txn already rolled back")
+		}
+		if v.valueType == typeRollback {
+			continue
+		}
+		if v.commitTS > forUpdateTS {
+			return ErrRetryable("write conflict")
+		}
+		break
+	}
+	e.lock = &mvccLock{
+		startTS:     startTS,
+		primary:     primary,
+		op:          opPessimisticLock,
+		ttl:         ttl,
+		forUpdateTS: forUpdateTS,
+	}
+	return nil
+}
+
+// PessimisticRollback releases the pessimistic lock of the entry if it's acquired by
+// the transaction no later than forUpdateTS.
+func (e *mvccEntry) PessimisticRollback(startTS, forUpdateTS uint64) {
+	if e.lock != nil && e.lock.startTS == startTS && e.lock.op == opPessimisticLock &&
+		e.lock.forUpdateTS <= forUpdateTS {
+		e.lock = nil
+	}
+}
+
+// TxnHeartBeat raises the ttl of the lock to adviseTTL if the lock is held by the
+// transaction, it returns the ttl of the lock.
+func (e *mvccEntry) TxnHeartBeat(startTS, adviseTTL uint64) (uint64, error) {
+	if e.lock == nil || e.lock.startTS != startTS {
+		return 0, ErrAbort("txn not found")
+	}
+	if adviseTTL > e.lock.ttl {
+		e.lock.ttl = adviseTTL
+	}
+	return e.lock.ttl, nil
+}
+
 func (e *mvccEntry) addValue(v mvccValue) {
 	i := sort.Search(len(e.values), func(i int) bool { return e.values[i].commitTS <= v.commitTS })
 	if i >= len(e.values) {
@@ -400,6 +497,8 @@ func (e *mvccEntry) dumpMvccInfo() *kvrpcpb.MvccInfo {
 type rawEntry struct {
 	key   []byte
 	value []byte
+	// expireAt is the time the entry expires, it's zero if the entry never expires.
+	expireAt time.Time
 }
 
 func newRawEntry(key []byte) *rawEntry {
@@ -412,6 +511,14 @@ func (e *rawEntry) Less(than llrb.Item) bool {
 	return bytes.Compare(e.key, than.(*rawEntry).key) < 0
 }
 
+func (e *rawEntry) expired(now time.Time) bool {
+	return !e.expireAt.IsZero() && !now.Before(e.expireAt)
+}
+
+// rawNow returns the current time for the ttls of the raw entries, tests replace it to
+// make the entries expire.
+var rawNow = time.Now
+
 // MVCCStore is a mvcc key-value storage.
 type MVCCStore interface {
 	Get(key []byte, startTS uint64, isoLevel kvrpcpb.IsolationLevel) ([]byte, error)
@@ -421,6 +528,12 @@ type MVCCStore interface {
 	Prewrite(mutations []*kvrpcpb.Mutation, primary []byte, startTS uint64, ttl uint64) []error
 	Commit(keys [][]byte, startTS, commitTS uint64) error
 	Rollback(keys [][]byte, startTS uint64) error
+	PessimisticLock(keys [][]byte, primary []byte, startTS, forUpdateTS uint64, ttl uint64) []error
+	PessimisticRollback(keys [][]byte, startTS, forUpdateTS uint64) error
+	TxnHeartBeat(primary []byte, startTS uint64, adviseTTL uint64) (uint64, error)
+	AsyncPrewrite(mutations []*kvrpcpb.Mutation, primary []byte, startTS uint64, ttl uint64, opt AsyncCommitOption) (uint64, []error)
+	CheckTxnStatus(primary []byte, startTS uint64) (TxnStatus, error)
+	CheckSecondaryLocks(keys [][]byte, startTS uint64) (SecondaryLocksStatus, error)
 	Cleanup(key []byte, startTS uint64) error
 	ScanLock(startKey, endKey []byte, maxTS uint64) ([]*kvrpcpb.LockInfo, error)
 	ResolveLock(startKey, endKey []byte, startTS, commitTS uint64) error
@@ -430,8 +543,26 @@ type MVCCStore interface {
 type RawKV interface {
 	RawGet(key []byte) []byte
 	RawScan(startKey, endKey []byte, limit int) []Pair
+	// RawReverseScan reads the pairs in [startKey, endKey) in the descending order.
+	RawReverseScan(startKey, endKey []byte, limit int) []Pair
 	RawPut(key, value []byte)
+	// RawPutTTL puts the pair which expires after the ttl in seconds, 0 means it never expires.
+	RawPutTTL(key, value []byte, ttl uint64)
 	RawDelete(key []byte)
+	RawDeleteRange(startKey, endKey []byte)
+	// RawCompareAndSwap puts the value if the current value of the key equals previousValue,
+	// or the key doesn't exist if previousNotExist is set. It returns the value before.
+	RawCompareAndSwap(key, previousValue []byte, previousNotExist bool, value []byte, ttl uint64) (prev []byte, prevNotExist bool, succeed bool)
+	// RawGetKeyTTL returns the remaining ttl in seconds of the key, 0 means it never expires.
+	RawGetKeyTTL(key []byte) (ttl uint64, found bool)
+}
+
+// MVCCDataVersioner reports the data versions of the key ranges, it's used by the
+// coprocessor cache.
+type MVCCDataVersioner interface {
+	// DataVersion returns the max commit ts of the versions in [startKey, endKey), and
+	// whether any key in the range is locked.
+	DataVersion(startKey, endKey []byte) (uint64, bool)
 }
 
 // MVCCDebugger is for debugging.
@@ -443,6 +574,7 @@ type MVCCDebugger interface {
 // MvccStore is an in-memory, multi-versioned, transaction-supported kv storage.
 type MvccStore struct {
 	sync.RWMutex
+	readTSTracker
 	tree  *llrb.LLRB
 	rawkv *llrb.LLRB
 }
@@ -460,6 +592,7 @@ func (s *MvccStore) Get(key []byte, startTS uint64, isoLevel kvrpcpb.IsolationLe
 	s.RLock()
 	defer s.RUnlock()
 
+	s.updateMaxReadTS(startTS)
 	return s.get(NewMvccKey(key), startTS, isoLevel)
 }
 
@@ -483,6 +616,7 @@ func (s *MvccStore) BatchGet(ks [][]byte, startTS uint64, isoLevel kvrpcpb.Isola
 	s.RLock()
 	defer s.RUnlock()
 
+	s.updateMaxReadTS(startTS)
 	var pairs []Pair
 	for _, k := range ks {
 		val, err := s.get(NewMvccKey(k), startTS, isoLevel)
@@ -508,6 +642,7 @@ func (s *MvccStore) Scan(startKey, endKey []byte, limit int, startTS uint64, iso
 	s.RLock()
 	defer s.RUnlock()
 
+	s.updateMaxReadTS(startTS)
 	startKey = NewMvccKey(startKey)
 	endKey = NewMvccKey(endKey)
 
@@ -540,6 +675,7 @@ func (s *MvccStore) ReverseScan(startKey, endKey []byte, limit int, startTS uint
 	s.RLock()
 	defer s.RUnlock()
 
+	s.updateMaxReadTS(startTS)
 	startKey = NewMvccKey(startKey)
 	endKey = NewMvccKey(endKey)
 
@@ -616,12 +752,177 @@ func (s *MvccStore) Commit(keys [][]byte, startTS, commitTS uint64) error {
 	return nil
 }
 
+// PessimisticLock acquires the pessimistic locks of keys. The locks are acquired only if
+// all of them can be acquired.
+func (s *MvccStore) PessimisticLock(keys [][]byte, primary []byte, startTS, forUpdateTS uint64, ttl uint64) []error {
+	s.Lock()
+	defer s.Unlock()
+
+	anyError := false
+	errs := make([]error, 0, len(keys))
+	ents := make([]*mvccEntry, 0, len(keys))
+	for _, k := range keys {
+		entry := s.getOrNewEntry(NewMvccKey(k))
+		err := entry.PessimisticLock(startTS, forUpdateTS, primary, ttl)
+		if err != nil {
+			anyError = true
+		}
+		errs = append(errs, err)
+		ents = append(ents, entry)
+	}
+	if !anyError {
+		s.submit(ents...)
+	}
+	return errs
+}
+
+// PessimisticRollback releases the pessimistic locks of keys.
+func (s *MvccStore) PessimisticRollback(keys [][]byte, startTS, forUpdateTS uint64) error {
+	s.Lock()
+	defer s.Unlock()
+
+	var ents []*mvccEntry
+	for _, k := range keys {
+		item := s.tree.Get(newEntry(NewMvccKey(k)))
+		if item == nil {
+			continue
+		}
+		entry := item.(*mvccEntry).Clone()
+		entry.PessimisticRollback(startTS, forUpdateTS)
+		ents = append(ents, entry)
+	}
+	s.submit(ents...)
+	return nil
+}
+
+// TxnHeartBeat raises the ttl of the primary lock of a transaction.
+func (s *MvccStore) TxnHeartBeat(primary []byte, startTS uint64, adviseTTL uint64) (uint64, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	item := s.tree.Get(newEntry(NewMvccKey(primary)))
+	if item == nil {
+		return 0, ErrAbort("txn not found")
+	}
+	entry := item.(*mvccEntry).Clone()
+	ttl, err := entry.TxnHeartBeat(startTS, adviseTTL)
+	if err != nil {
+		return 0, err
+	}
+	s.submit(entry)
+	return ttl, nil
+}
+
+// AsyncPrewrite prewrites the mutations of an async commit or 1PC transaction. It
+// returns the min commit ts of the locks, or the commit ts if the mutations are committed
+// by 1PC.
+func (s *MvccStore) AsyncPrewrite(mutations []*kvrpcpb.Mutation, primary []byte, startTS uint64, ttl uint64, opt AsyncCommitOption) (uint64, []error) {
+	s.Lock()
+	defer s.Unlock()
+
+	minCommitTS := s.minCommitTS(startTS, opt.MinCommitTS)
+	anyError := false
+	errs := make([]error, 0, len(mutations))
+	ents := make([]*mvccEntry, 0, len(mutations))
+	for _, m := range mutations {
+		entry := s.getOrNewEntry(NewMvccKey(m.Key))
+		err := entry.Prewrite(m, startTS, primary, ttl)
+		if err != nil {
+			anyError = true
+		} else if entry.lock.forUpdateTS >= minCommitTS {
+			minCommitTS = entry.lock.forUpdateTS + 1
+		}
+		errs = append(errs, err)
+		ents = append(ents, entry)
+	}
+	if anyError {
+		return 0, errs
+	}
+	if opt.MaxCommitTS > 0 && minCommitTS > opt.MaxCommitTS {
+		return 0, []error{errCommitTSTooLarge}
+	}
+	for i, entry := range ents {
+		if opt.TryOnePC {
+			if err := entry.Commit(startTS, minCommitTS); err != nil {
+				return 0, []error{err}
+			}
+		} else {
+			entry.lock.setAsyncCommit(mutations[i].Key, opt, minCommitTS)
+		}
+	}
+	s.submit(ents...)
+	return minCommitTS, errs
+}
+
+// CheckTxnStatus checks the status of a transaction by its primary key, the primary key
+// is rolled back if the transaction isn't prewritten on it.
+func (s *MvccStore) CheckTxnStatus(primary []byte, startTS uint64) (TxnStatus, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	entry := s.getOrNewEntry(NewMvccKey(primary))
+	if entry.lock != nil && entry.lock.startTS == startTS {
+		return entry.lock.txnStatus(), nil
+	}
+	if c := entry.getTxnCommitInfo(startTS); c != nil {
+		if c.valueType == typeRollback {
+			return TxnStatus{}, nil
+		}
+		return TxnStatus{CommitTS: c.commitTS}, nil
+	}
+	if err := entry.Rollback(startTS); err != nil {
+		return TxnStatus{}, err
+	}
+	s.submit(entry)
+	return TxnStatus{}, nil
+}
+
+// CheckSecondaryLocks checks the secondary locks of an async commit transaction, the
+// keys not prewritten by the transaction are rolled back.
+func (s *MvccStore) CheckSecondaryLocks(keys [][]byte, startTS uint64) (SecondaryLocksStatus, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	var (
+		status SecondaryLocksStatus
+		ents   []*mvccEntry
+	)
+	for _, k := range keys {
+		entry := s.getOrNewEntry(NewMvccKey(k))
+		if l := entry.lock; l != nil && l.startTS == startTS && l.op != opPessimisticLock {
+			status.Locks = append(status.Locks, l.lockInfo(k))
+			if l.minCommitTS > status.MinCommitTS {
+				status.MinCommitTS = l.minCommitTS
+			}
+			continue
+		}
+		if c := entry.getTxnCommitInfo(startTS); c != nil && c.valueType != typeRollback {
+			return SecondaryLocksStatus{CommitTS: c.commitTS}, nil
+		}
+		if err := entry.Rollback(startTS); err != nil {
+			return SecondaryLocksStatus{}, err
+		}
+		ents = append(ents, entry)
+	}
+	if len(ents) > 0 {
+		// The transaction is rolled back.
+		s.submit(ents...)
+		status.Locks, status.MinCommitTS = nil, 0
+	}
+	return status, nil
+}
+
 // Cleanup cleanups a lock, often used when resolving a expired lock.
 func (s *MvccStore) Cleanup(key []byte, startTS uint64) error {
 	s.Lock()
 	defer s.Unlock()
 
 	entry := s.getOrNewEntry(NewMvccKey(key))
+	if entry.lock != nil && entry.lock.startTS == startTS && entry.lock.useAsyncCommit {
+		// The transaction of an async commit lock is committed once all its keys are
+		// prewritten, its status is checked by the secondary locks.
+		return entry.lockErr()
+	}
 	err := entry.Rollback(startTS)
 	if err != nil {
 		return err
@@ -672,6 +973,34 @@ func (s *MvccStore) ScanLock(startKey, endKey []byte, maxTS uint64) ([]*kvrpcpb.
 	return locks, nil
 }
 
+// DataVersion implements the MVCCDataVersioner interface.
+func (s *MvccStore) DataVersion(startKey, endKey []byte) (uint64, bool) {
+	s.RLock()
+	defer s.RUnlock()
+
+	var (
+		version uint64
+		locked  bool
+	)
+	iterator := func(item llrb.Item) bool {
+		ent := item.(*mvccEntry)
+		if !regionContains(startKey, endKey, ent.key) {
+			return false
+		}
+		if ent.lock != nil {
+			locked = true
+			return false
+		}
+		// The values are sorted by commit ts in descending order.
+		if len(ent.values) > 0 && ent.values[0].commitTS > version {
+			version = ent.values[0].commitTS
+		}
+		return true
+	}
+	s.tree.AscendGreaterOrEqual(newEntry(startKey), iterator)
+	return version, locked
+}
+
 // ResolveLock resolves all orphan locks belong to a transaction.
 func (s *MvccStore) ResolveLock(startKey, endKey []byte, startTS, commitTS uint64) error {
 	s.Lock()
@@ -710,27 +1039,51 @@ func (s *MvccStore) RawGet(key []byte) []byte {
 	s.RLock()
 	defer s.RUnlock()
 
-	entry := s.rawkv.Get(newRawEntry(key))
+	entry := s.getRawEntry(key)
 	if entry == nil {
 		return nil
 	}
-	return entry.(*rawEntry).value
+	return entry.value
+}
+
+// getRawEntry returns the entry of the key, or nil if it doesn't exist or is expired.
+func (s *MvccStore) getRawEntry(key []byte) *rawEntry {
+	item := s.rawkv.Get(newRawEntry(key))
+	if item == nil || item.(*rawEntry).expired(rawNow()) {
+		return nil
+	}
+	return item.(*rawEntry)
 }
 
 // RawPut stores a key-value pair.
 func (s *MvccStore) RawPut(key, value []byte) {
+	s.RawPutTTL(key, value, 0)
+}
+
+// RawPutTTL stores a key-value pair which expires after the ttl in seconds.
+func (s *MvccStore) RawPutTTL(key, value []byte, ttl uint64) {
 	s.Lock()
 	defer s.Unlock()
+	s.rawPut(key, value, ttl)
+}
+
+func (s *MvccStore) rawPut(key, value []byte, ttl uint64) {
 	if value == nil {
 		value = []byte{}
 	}
-	entry := s.rawkv.Get(newRawEntry(key))
-	if entry != nil {
-		entry.(*rawEntry).value = value
+	var expireAt time.Time
+	if ttl > 0 {
+		expireAt = rawNow().Add(time.Duration(ttl) * time.Second)
+	}
+	item := s.rawkv.Get(newRawEntry(key))
+	if item != nil {
+		entry := item.(*rawEntry)
+		entry.value, entry.expireAt = value, expireAt
 	} else {
 		s.rawkv.ReplaceOrInsert(&rawEntry{
-			key:   key,
-			value: value,
+			key:      key,
+			value:    value,
+			expireAt: expireAt,
 		})
 	}
 }
@@ -742,30 +1095,127 @@ func (s *MvccStore) RawDelete(key []byte) {
 	s.rawkv.Delete(newRawEntry(key))
 }
 
+// RawDeleteRange deletes the pairs in [startKey, endKey).
+func (s *MvccStore) RawDeleteRange(startKey, endKey []byte) {
+	s.Lock()
+	defer s.Unlock()
+
+	var deleted []llrb.Item
+	s.rawkv.AscendGreaterOrEqual(newRawEntry(startKey), func(item llrb.Item) bool {
+		if !regionContains(startKey, endKey, item.(*rawEntry).key) {
+			return false
+		}
+		deleted = append(deleted, item)
+		return true
+	})
+	for _, item := range deleted {
+		s.rawkv.Delete(item)
+	}
+}
+
 // RawScan reads up to a limited number of rawkv Pairs.
 func (s *MvccStore) RawScan(startKey, endKey []byte, limit int) []Pair {
 	s.RLock()
 	defer s.RUnlock()
 
 	var pairs []Pair
+	now := rawNow()
 	iterator := func(item llrb.Item) bool {
 		if len(pairs) >= limit {
 			return false
 		}
-		k := item.(*rawEntry).key
-		if !regionContains(startKey, endKey, k) {
+		entry := item.(*rawEntry)
+		if !regionContains(startKey, endKey, entry.key) {
 			return false
 		}
-		pairs = append(pairs, Pair{
-			Key:   k,
-			Value: item.(*rawEntry).value,
-		})
+		if !entry.expired(now) {
+			pairs = append(pairs, Pair{
+				Key:   entry.key,
+				Value: entry.value,
+			})
+		}
 		return true
 	}
 	s.rawkv.AscendGreaterOrEqual(newRawEntry(startKey), iterator)
 	return pairs
 }
 
+// RawReverseScan reads up to a limited number of rawkv Pairs in [startKey, endKey) in
+// the descending order.
+func (s *MvccStore) RawReverseScan(startKey, endKey []byte, limit int) []Pair {
+	s.RLock()
+	defer s.RUnlock()
+
+	var pairs []Pair
+	now := rawNow()
+	iterator := func(item llrb.Item) bool {
+		if len(pairs) >= limit {
+			return false
+		}
+		entry := item.(*rawEntry)
+		if bytes.Compare(entry.key, startKey) < 0 {
+			return false
+		}
+		// The entry equal to endKey is the first one visited if it exists.
+		if len(endKey) > 0 && bytes.Compare(entry.key, endKey) >= 0 {
+			return true
+		}
+		if !entry.expired(now) {
+			pairs = append(pairs, Pair{
+				Key:   entry.key,
+				Value: entry.value,
+			})
+		}
+		return true
+	}
+	pivot := s.rawkv.Max()
+	if len(endKey) > 0 {
+		pivot = newRawEntry(endKey)
+	}
+	if pivot != nil {
+		s.rawkv.DescendLessOrEqual(pivot, iterator)
+	}
+	return pairs
+}
+
+// RawCompareAndSwap puts the value if the current value of the key is the expected one.
+func (s *MvccStore) RawCompareAndSwap(key, previousValue []byte, previousNotExist bool, value []byte, ttl uint64) ([]byte, bool, bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	entry := s.getRawEntry(key)
+	if entry == nil {
+		if !previousNotExist {
+			return nil, true, false
+		}
+	} else if previousNotExist || !bytes.Equal(entry.value, previousValue) {
+		return entry.value, false, false
+	}
+	var prev []byte
+	if entry != nil {
+		prev = entry.value
+	}
+	s.rawPut(key, value, ttl)
+	return prev, entry == nil, true
+}
+
+// RawGetKeyTTL returns the remaining ttl in seconds of the key.
+func (s *MvccStore) RawGetKeyTTL(key []byte) (uint64, bool) {
+	s.RLock()
+	defer s.RUnlock()
+
+	entry := s.getRawEntry(key)
+	if entry == nil {
+		return 0, false
+	}
+	if entry.expireAt.IsZero() {
+		return 0, true
+	}
+	// Round up, so a key about to expire still has a ttl.
+	remain := entry.expireAt.Sub(rawNow())
+	return uint64((remain + time.Second - 1) / time.Second), true
+}
+
 // MvccGetByStartTS gets mvcc info for the primary key with startTS
 func (s *MvccStore) MvccGetByStartTS(startKey, endKey []byte, starTS uint64) (*kvrpcpb.MvccInfo, []byte) {
 	s.RLock()
@@ -53,6 +53,7 @@ type MVCCLevelDB struct {
 	// EOF
 	db *leveldb.DB
 	mu sync.RWMutex
+	readTSTracker
 }
 
 var lockVer uint64 = math.MaxUint64
@@ -285,6 +286,7 @@ func (mvcc *MVCCLevelDB) Get(key []byte, startTS uint64, isoLevel kvrpcpb.Isolat
 	mvcc.mu.RLock()
 	defer mvcc.mu.RUnlock()
 
+	mvcc.updateMaxReadTS(startTS)
 	return mvcc.getValue(key, startTS, isoLevel)
 }
 
@@ -305,7 +307,7 @@ func getValue(iter *Iterator, key []byte, startTS uint64, isoLevel kvrpcpb.Isola
 		return nil, errors.Trace(err)
 	}
 	if ok {
-		if isoLevel == kvrpcpb.IsolationLevel_SI && dec1.lock.startTS <= startTS {
+		if isoLevel == kvrpcpb.IsolationLevel_SI && !dec1.lock.isSkippedBy(startTS) {
 			return nil, dec1.lock.lockErr(key)
 		}
 	}
@@ -340,6 +342,7 @@ func (mvcc *MVCCLevelDB) BatchGet(ks [][]byte, startTS uint64, isoLevel kvrpcpb.
 	mvcc.mu.RLock()
 	defer mvcc.mu.RUnlock()
 
+	mvcc.updateMaxReadTS(startTS)
 	var pairs []Pair
 	for _, k := range ks {
 		v, err := mvcc.getValue(k, startTS, isoLevel)
@@ -360,6 +363,7 @@ func (mvcc *MVCCLevelDB) Scan(startKey, endKey []byte, limit int, startTS uint64
 	mvcc.mu.RLock()
 	defer mvcc.mu.RUnlock()
 
+	mvcc.updateMaxReadTS(startTS)
 	iter, currKey, err := newScanIterator(mvcc.db, startKey, endKey)
 	defer iter.Release()
 	if err != nil {
@@ -400,6 +404,7 @@ func (mvcc *MVCCLevelDB) ReverseScan(startKey, endKey []byte, limit int, startTS
 	mvcc.mu.RLock()
 	defer mvcc.mu.RUnlock()
 
+	mvcc.updateMaxReadTS(startTS)
 	var mvccEnd []byte
 	if len(endKey) != 0 {
 		mvccEnd = mvccEncode(endKey, lockVer)
@@ -508,6 +513,15 @@ func (mvcc *MVCCLevelDB) Prewrite(mutations []*kvrpcpb.Mutation, primary []byte,
 }
 
 func prewriteMutation(db *leveldb.DB, batch *leveldb.Batch, mutation *kvrpcpb.Mutation, startTS uint64, primary []byte, ttl uint64) error {
+	lock, err := prewriteLock(db, mutation, startTS, primary, ttl)
+	if err != nil {
+		return err
+	}
+	return errors.Trace(putLock(batch, mutation.Key, lock))
+}
+
+// prewriteLock returns the lock of the mutation after prewrite.
+func prewriteLock(db *leveldb.DB, mutation *kvrpcpb.Mutation, startTS uint64, primary []byte, ttl uint64) (*mvccLock, error) {
 	startKey := mvccEncode(mutation.Key, lockVer)
 	iter := newIterator(db, &util.Range{
 		Start: startKey,
@@ -519,40 +533,48 @@ func prewriteMutation(db *leveldb.DB, batch *leveldb.Batch, mutation *kvrpcpb.Mu
 	}
 	ok, err := dec.Decode(iter)
 	if err != nil {
-		return errors.Trace(err)
+		return nil, errors.Trace(err)
 	}
+	var forUpdateTS uint64
 	if ok {
 		if dec.lock.startTS != startTS {
-			return dec.lock.lockErr(mutation.Key)
+			return nil, dec.lock.lockErr(mutation.Key)
+		}
+		if dec.lock.op != opPessimisticLock {
+			return &dec.lock, nil
+		}
+		// The write conflict has been checked when the pessimistic lock is acquired.
+		forUpdateTS = dec.lock.forUpdateTS
+	} else {
+		dec1 := valueDecoder{
+			expectKey: mutation.Key,
+		}
+		ok, err = dec1.Decode(iter)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		// Note that it's a write conflict here, even if the value is a rollback one.
+		if ok && dec1.value.commitTS >= startTS {
+			return nil, ErrRetryable("write conflict")
 		}
-		return nil
 	}
 
-	dec1 := valueDecoder{
-		expectKey: mutation.Key,
-	}
-	ok, err = dec1.Decode(iter)
-	if err != nil {
-		return errors.Trace(err)
-	}
-	// Note that it's a write conflict here, even if the value is a rollback one.
-	if ok && dec1.value.commitTS >= startTS {
-		return ErrRetryable("write conflict")
-	}
+	return &mvccLock{
+		startTS:     startTS,
+		primary:     primary,
+		value:       mutation.Value,
+		op:          mutation.GetOp(),
+		ttl:         ttl,
+		forUpdateTS: forUpdateTS,
+	}, nil
+}
 
-	lock := mvccLock{
-		startTS: startTS,
-		primary: primary,
-		value:   mutation.Value,
-		op:      mutation.GetOp(),
-		ttl:     ttl,
-	}
-	writeKey := mvccEncode(mutation.Key, lockVer)
+func putLock(batch *leveldb.Batch, key []byte, lock *mvccLock) error {
 	writeValue, err := lock.MarshalBinary()
 	if err != nil {
 		return errors.Trace(err)
 	}
-	batch.Put(writeKey, writeValue)
+	batch.Put(mvccEncode(key, lockVer), writeValue)
 	return nil
 }
 
@@ -598,6 +620,9 @@ func commitKey(db *leveldb.DB, batch *leveldb.Batch, key []byte, startTS, commit
 		}
 		return ErrRetryable("txn not found")
 	}
+	if commitTS < dec.lock.minCommitTS {
+		return errCommitTSExpired
+	}
 
 	if err = commitLock(batch, dec.lock, key, startTS, commitTS); err != nil {
 		return errors.Trace(err)
@@ -606,7 +631,7 @@ func commitKey(db *leveldb.DB, batch *leveldb.Batch, key []byte, startTS, commit
 }
 
 func commitLock(batch *leveldb.Batch, lock mvccLock, key []byte, startTS, commitTS uint64) error {
-	if lock.op != kvrpcpb.Op_Lock {
+	if lock.op != kvrpcpb.Op_Lock && lock.op != opPessimisticLock {
 		var valueType mvccValueType
 		if lock.op == kvrpcpb.Op_Put {
 			valueType = typePut
@@ -630,6 +655,282 @@ func commitLock(batch *leveldb.Batch, lock mvccLock, key []byte, startTS, commit
 	return nil
 }
 
+// PessimisticLock implements the MVCCStore interface.
+func (mvcc *MVCCLevelDB) PessimisticLock(keys [][]byte, primary []byte, startTS, forUpdateTS uint64, ttl uint64) []error {
+	mvcc.mu.Lock()
+	defer mvcc.mu.Unlock()
+
+	anyError := false
+	batch := &leveldb.Batch{}
+	errs := make([]error, 0, len(keys))
+	for _, k := range keys {
+		err := pessimisticLockKey(mvcc.db, batch, k, primary, startTS, forUpdateTS, ttl)
+		errs = append(errs, err)
+		if err != nil {
+			anyError = true
+		}
+	}
+	if anyError {
+		return errs
+	}
+	if err := mvcc.db.Write(batch, nil); err != nil {
+		return []error{errors.Trace(err)}
+	}
+	return errs
+}
+
+func pessimisticLockKey(db *leveldb.DB, batch *leveldb.Batch, key []byte, primary []byte, startTS, forUpdateTS uint64, ttl uint64) error {
+	startKey := mvccEncode(key, lockVer)
+	iter := newIterator(db, &util.Range{
+		Start: startKey,
+	})
+	defer iter.Release()
+
+	dec := lockDecoder{
+		expectKey: key,
+	}
+	ok, err := dec.Decode(iter)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if ok {
+		if dec.lock.startTS != startTS {
+			return dec.lock.lockErr(key)
+		}
+		return nil
+	}
+
+	for iter.Valid() {
+		dec1 := valueDecoder{
+			expectKey: key,
+		}
+		ok, err = dec1.Decode(iter)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if !ok {
+			break
+		}
+		if dec1.value.startTS == startTS && dec1.value.valueType == typeRollback {
+			return ErrAbort("txn already rolled back")
+		}
+		if dec1.value.valueType == typeRollback {
+			continue
+		}
+		if dec1.value.commitTS > forUpdateTS {
+			return ErrRetryable("write conflict")
+		}
+		break
+	}
+
+	lock := mvccLock{
+		startTS:     startTS,
+		primary:     primary,
+		op:          opPessimisticLock,
+		ttl:         ttl,
+		forUpdateTS: forUpdateTS,
+	}
+	writeValue, err := lock.MarshalBinary()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	batch.Put(startKey, writeValue)
+	return nil
+}
+
+// PessimisticRollback implements the MVCCStore interface.
+func (mvcc *MVCCLevelDB) PessimisticRollback(keys [][]byte, startTS, forUpdateTS uint64) error {
+	mvcc.mu.Lock()
+	defer mvcc.mu.Unlock()
+
+	batch := &leveldb.Batch{}
+	for _, k := range keys {
+		err := pessimisticRollbackKey(mvcc.db, batch, k, startTS, forUpdateTS)
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return mvcc.db.Write(batch, nil)
+}
+
+func pessimisticRollbackKey(db *leveldb.DB, batch *leveldb.Batch, key []byte, startTS, forUpdateTS uint64) error {
+	startKey := mvccEncode(key, lockVer)
+	iter := newIterator(db, &util.Range{
+		Start: startKey,
+	})
+	defer iter.Release()
+
+	dec := lockDecoder{
+		expectKey: key,
+	}
+	ok, err := dec.Decode(iter)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if ok && dec.lock.startTS == startTS && dec.lock.op == opPessimisticLock && dec.lock.forUpdateTS <= forUpdateTS {
+		batch.Delete(startKey)
+	}
+	return nil
+}
+
+// TxnHeartBeat implements the MVCCStore interface.
+func (mvcc *MVCCLevelDB) TxnHeartBeat(primary []byte, startTS uint64, adviseTTL uint64) (uint64, error) {
+	mvcc.mu.Lock()
+	defer mvcc.mu.Unlock()
+
+	startKey := mvccEncode(primary, lockVer)
+	iter := newIterator(mvcc.db, &util.Range{
+		Start: startKey,
+	})
+	defer iter.Release()
+
+	dec := lockDecoder{
+		expectKey: primary,
+	}
+	ok, err := dec.Decode(iter)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	if !ok || dec.lock.startTS != startTS {
+		return 0, ErrAbort("txn not found")
+	}
+	if adviseTTL <= dec.lock.ttl {
+		return dec.lock.ttl, nil
+	}
+	dec.lock.ttl = adviseTTL
+	writeValue, err := dec.lock.MarshalBinary()
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	if err = mvcc.db.Put(startKey, writeValue, nil); err != nil {
+		return 0, errors.Trace(err)
+	}
+	return adviseTTL, nil
+}
+
+// AsyncPrewrite implements the MVCCStore interface.
+func (mvcc *MVCCLevelDB) AsyncPrewrite(mutations []*kvrpcpb.Mutation, primary []byte, startTS uint64, ttl uint64, opt AsyncCommitOption) (uint64, []error) {
+	mvcc.mu.Lock()
+	defer mvcc.mu.Unlock()
+
+	minCommitTS := mvcc.minCommitTS(startTS, opt.MinCommitTS)
+	anyError := false
+	errs := make([]error, 0, len(mutations))
+	locks := make([]*mvccLock, 0, len(mutations))
+	for _, m := range mutations {
+		lock, err := prewriteLock(mvcc.db, m, startTS, primary, ttl)
+		errs = append(errs, err)
+		if err != nil {
+			anyError = true
+			continue
+		}
+		if lock.forUpdateTS >= minCommitTS {
+			minCommitTS = lock.forUpdateTS + 1
+		}
+		locks = append(locks, lock)
+	}
+	if anyError {
+		return 0, errs
+	}
+	if opt.MaxCommitTS > 0 && minCommitTS > opt.MaxCommitTS {
+		return 0, []error{errCommitTSTooLarge}
+	}
+	batch := &leveldb.Batch{}
+	for i, lock := range locks {
+		key := mutations[i].Key
+		var err error
+		if opt.TryOnePC {
+			err = commitLock(batch, *lock, key, startTS, minCommitTS)
+		} else {
+			lock.setAsyncCommit(key, opt, minCommitTS)
+			err = putLock(batch, key, lock)
+		}
+		if err != nil {
+			return 0, []error{errors.Trace(err)}
+		}
+	}
+	if err := mvcc.db.Write(batch, nil); err != nil {
+		return 0, []error{errors.Trace(err)}
+	}
+	return minCommitTS, errs
+}
+
+// CheckTxnStatus implements the MVCCStore interface.
+func (mvcc *MVCCLevelDB) CheckTxnStatus(primary []byte, startTS uint64) (TxnStatus, error) {
+	mvcc.mu.Lock()
+	defer mvcc.mu.Unlock()
+
+	lock, err := getLock(mvcc.db, primary)
+	if err != nil {
+		return TxnStatus{}, errors.Trace(err)
+	}
+	if lock != nil && lock.startTS == startTS {
+		return lock.txnStatus(), nil
+	}
+	batch := &leveldb.Batch{}
+	err = rollbackKey(mvcc.db, batch, primary, startTS)
+	if commitTS, ok := errors.Cause(err).(ErrAlreadyCommitted); ok {
+		return TxnStatus{CommitTS: uint64(commitTS)}, nil
+	}
+	if err != nil {
+		return TxnStatus{}, errors.Trace(err)
+	}
+	return TxnStatus{}, errors.Trace(mvcc.db.Write(batch, nil))
+}
+
+// CheckSecondaryLocks implements the MVCCStore interface.
+func (mvcc *MVCCLevelDB) CheckSecondaryLocks(keys [][]byte, startTS uint64) (SecondaryLocksStatus, error) {
+	mvcc.mu.Lock()
+	defer mvcc.mu.Unlock()
+
+	var status SecondaryLocksStatus
+	rolledBack := false
+	batch := &leveldb.Batch{}
+	for _, k := range keys {
+		lock, err := getLock(mvcc.db, k)
+		if err != nil {
+			return SecondaryLocksStatus{}, errors.Trace(err)
+		}
+		if lock != nil && lock.startTS == startTS && lock.op != opPessimisticLock {
+			status.Locks = append(status.Locks, lock.lockInfo(k))
+			if lock.minCommitTS > status.MinCommitTS {
+				status.MinCommitTS = lock.minCommitTS
+			}
+			continue
+		}
+		err = rollbackKey(mvcc.db, batch, k, startTS)
+		if commitTS, ok := errors.Cause(err).(ErrAlreadyCommitted); ok {
+			return SecondaryLocksStatus{CommitTS: uint64(commitTS)}, nil
+		}
+		if err != nil {
+			return SecondaryLocksStatus{}, errors.Trace(err)
+		}
+		rolledBack = true
+	}
+	if rolledBack {
+		// The transaction is rolled back.
+		return SecondaryLocksStatus{}, errors.Trace(mvcc.db.Write(batch, nil))
+	}
+	return status, nil
+}
+
+// getLock returns the lock of the key, or nil if the key isn't locked.
+func getLock(db *leveldb.DB, key []byte) (*mvccLock, error) {
+	iter := newIterator(db, &util.Range{
+		Start: mvccEncode(key, lockVer),
+	})
+	defer iter.Release()
+
+	dec := lockDecoder{
+		expectKey: key,
+	}
+	ok, err := dec.Decode(iter)
+	if err != nil || !ok {
+		return nil, errors.Trace(err)
+	}
+	return &dec.lock, nil
+}
+
 // Rollback implements the MVCCStore interface.
 func (mvcc *MVCCLevelDB) Rollback(keys [][]byte, startTS uint64) error {
 	mvcc.mu.Lock()
@@ -737,8 +1038,17 @@ func (mvcc *MVCCLevelDB) Cleanup(key []byte, startTS uint64) error {
 	mvcc.mu.Lock()
 	defer mvcc.mu.Unlock()
 
+	lock, err := getLock(mvcc.db, key)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if lock != nil && lock.startTS == startTS && lock.useAsyncCommit {
+		// The transaction of an async commit lock is committed once all its keys are
+		// prewritten, its status is checked by the secondary locks.
+		return lock.lockErr(key)
+	}
 	batch := &leveldb.Batch{}
-	err := rollbackKey(mvcc.db, batch, key, startTS)
+	err = rollbackKey(mvcc.db, batch, key, startTS)
 	if err != nil {
 		return errors.Trace(err)
 	}
@@ -781,6 +1091,42 @@ func (mvcc *MVCCLevelDB) ScanLock(startKey, endKey []byte, maxTS uint64) ([]*kvr
 	return locks, nil
 }
 
+// DataVersion implements the MVCCDataVersioner interface.
+func (mvcc *MVCCLevelDB) DataVersion(startKey, endKey []byte) (uint64, bool) {
+	mvcc.mu.RLock()
+	defer mvcc.mu.RUnlock()
+
+	iter, currKey, err := newScanIterator(mvcc.db, startKey, endKey)
+	defer iter.Release()
+	if err != nil {
+		// The version is unknown, take the range as locked so it's not cached.
+		return 0, true
+	}
+	var version uint64
+	for iter.Valid() {
+		lockDec := lockDecoder{expectKey: currKey}
+		ok, err := lockDec.Decode(iter)
+		if err != nil || ok {
+			return 0, true
+		}
+		// The values are sorted by commit ts in descending order.
+		valueDec := valueDecoder{expectKey: currKey}
+		ok, err = valueDec.Decode(iter)
+		if err != nil {
+			return 0, true
+		}
+		if ok && valueDec.value.commitTS > version {
+			version = valueDec.value.commitTS
+		}
+		skip := skipDecoder{currKey: currKey}
+		if _, err = skip.Decode(iter); err != nil {
+			return 0, true
+		}
+		currKey = skip.currKey
+	}
+	return version, false
+}
+
 // ResolveLock implements the MVCCStore interface.
 func (mvcc *MVCCLevelDB) ResolveLock(startKey, endKey []byte, startTS, commitTS uint64) error {
 	mvcc.mu.Lock()
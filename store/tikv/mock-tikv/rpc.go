@@ -14,7 +14,9 @@
 package mocktikv
 
 import (
+	"bytes"
 	"io"
+	"time"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/juju/errors"
@@ -102,6 +104,10 @@ type rpcHandler struct {
 	rawEndKey   []byte
 	// Used for current request.
 	isolationLevel kvrpcpb.IsolationLevel
+	replicaRead    bool
+	// Used for pessimistic lock requests.
+	detector   *Detector
+	lockWaiter *lockWaiter
 }
 
 func (h *rpcHandler) checkRequestContext(ctx *kvrpcpb.Context) *errorpb.Error {
@@ -149,8 +155,9 @@ func (h *rpcHandler) checkRequestContext(ctx *kvrpcpb.Context) *errorpb.Error {
 			},
 		}
 	}
-	// The Peer on the Store is not leader.
-	if storePeer.GetId() != leaderPeer.GetId() {
+	// The Peer on the Store is not leader. The followers serve the replica reads,
+	// mock-tikv has no raft inside so they read the same data as the leader.
+	if storePeer.GetId() != leaderPeer.GetId() && !h.replicaRead {
 		return &errorpb.Error{
 			Message: proto.String("not leader"),
 			NotLeader: &errorpb.NotLeader{
@@ -249,6 +256,7 @@ func (h *rpcHandler) handleKvCommit(req *kvrpcpb.CommitRequest) *kvrpcpb.CommitR
 	if err != nil {
 		resp.Error = convertToKeyError(err)
 	}
+	h.releaseLocks(req.GetStartVersion())
 	return &resp
 }
 
@@ -258,6 +266,7 @@ func (h *rpcHandler) handleKvCleanup(req *kvrpcpb.CleanupRequest) *kvrpcpb.Clean
 	}
 	var resp kvrpcpb.CleanupResponse
 	err := h.mvccStore.Cleanup(req.Key, req.GetStartVersion())
+	h.releaseLocks(req.GetStartVersion())
 	if err != nil {
 		if commitTS, ok := errors.Cause(err).(ErrAlreadyCommitted); ok {
 			resp.CommitVersion = uint64(commitTS)
@@ -310,6 +319,7 @@ func (h *rpcHandler) handleMvccGetByStartTS(req *kvrpcpb.MvccGetByStartTsRequest
 
 func (h *rpcHandler) handleKvBatchRollback(req *kvrpcpb.BatchRollbackRequest) *kvrpcpb.BatchRollbackResponse {
 	err := h.mvccStore.Rollback(req.Keys, req.StartVersion)
+	h.releaseLocks(req.StartVersion)
 	if err != nil {
 		return &kvrpcpb.BatchRollbackResponse{
 			Error: convertToKeyError(err),
@@ -332,6 +342,7 @@ func (h *rpcHandler) handleKvScanLock(req *kvrpcpb.ScanLockRequest) *kvrpcpb.Sca
 
 func (h *rpcHandler) handleKvResolveLock(req *kvrpcpb.ResolveLockRequest) *kvrpcpb.ResolveLockResponse {
 	err := h.mvccStore.ResolveLock(h.startKey, h.endKey, req.GetStartVersion(), req.GetCommitVersion())
+	h.releaseLocks(req.GetStartVersion())
 	if err != nil {
 		return &kvrpcpb.ResolveLockResponse{
 			Error: convertToKeyError(err),
@@ -340,6 +351,149 @@ func (h *rpcHandler) handleKvResolveLock(req *kvrpcpb.ResolveLockRequest) *kvrpc
 	return &kvrpcpb.ResolveLockResponse{}
 }
 
+func (h *rpcHandler) handleKvPessimisticLock(req *tikvrpc.PessimisticLockRequest) *tikvrpc.PessimisticLockResponse {
+	for _, k := range req.Keys {
+		if !h.checkKeyInRegion(k) {
+			panic("KvPessimisticLock: key not in region")
+		}
+	}
+	startTS := req.StartVersion
+	epoch := h.lockWaiter.currentEpoch()
+	errs := h.mvccStore.PessimisticLock(req.Keys, req.PrimaryLock, startTS, req.ForUpdateTs, req.LockTtl)
+	for i, err := range errs {
+		locked, ok := errors.Cause(err).(*ErrLocked)
+		if !ok || req.WaitTimeout <= 0 {
+			continue
+		}
+		keyHash := hashKey(req.Keys[i])
+		if deadlock := h.detector.Detect(startTS, locked.StartTS, keyHash); deadlock != nil {
+			return &tikvrpc.PessimisticLockResponse{
+				Deadlock: &tikvrpc.Deadlock{
+					LockTs:          locked.StartTS,
+					LockKey:         req.Keys[i],
+					DeadlockKeyHash: deadlock.DeadlockKeyHash,
+				},
+			}
+		}
+		// Wait for the lock to be released, the client retries the request then.
+		h.lockWaiter.waitFor(locked.StartTS, epoch, time.Duration(req.WaitTimeout)*time.Millisecond)
+		h.detector.CleanUpWaitFor(startTS, locked.StartTS, keyHash)
+		break
+	}
+	return &tikvrpc.PessimisticLockResponse{
+		Errors: convertToKeyErrors(errs),
+	}
+}
+
+func (h *rpcHandler) handleKvPessimisticRollback(req *tikvrpc.PessimisticRollbackRequest) *tikvrpc.PessimisticRollbackResponse {
+	for _, k := range req.Keys {
+		if !h.checkKeyInRegion(k) {
+			panic("KvPessimisticRollback: key not in region")
+		}
+	}
+	err := h.mvccStore.PessimisticRollback(req.Keys, req.StartVersion, req.ForUpdateTs)
+	h.releaseLocks(req.StartVersion)
+	if err != nil {
+		return &tikvrpc.PessimisticRollbackResponse{
+			Errors: []*kvrpcpb.KeyError{convertToKeyError(err)},
+		}
+	}
+	return &tikvrpc.PessimisticRollbackResponse{}
+}
+
+func (h *rpcHandler) handleKvTxnHeartBeat(req *tikvrpc.TxnHeartBeatRequest) *tikvrpc.TxnHeartBeatResponse {
+	if !h.checkKeyInRegion(req.PrimaryLock) {
+		panic("KvTxnHeartBeat: key not in region")
+	}
+	ttl, err := h.mvccStore.TxnHeartBeat(req.PrimaryLock, req.StartVersion, req.AdviseLockTtl)
+	if err != nil {
+		return &tikvrpc.TxnHeartBeatResponse{
+			Error: convertToKeyError(err),
+		}
+	}
+	return &tikvrpc.TxnHeartBeatResponse{LockTtl: ttl}
+}
+
+func (h *rpcHandler) handleKvAsyncPrewrite(req *tikvrpc.AsyncPrewriteRequest) *tikvrpc.AsyncPrewriteResponse {
+	for _, m := range req.Mutations {
+		if !h.checkKeyInRegion(m.Key) {
+			panic("KvAsyncPrewrite: key not in region")
+		}
+	}
+	opt := AsyncCommitOption{
+		UseAsyncCommit: req.UseAsyncCommit,
+		Secondaries:    req.Secondaries,
+		TryOnePC:       req.TryOnePc,
+		MinCommitTS:    req.MinCommitTs,
+		MaxCommitTS:    req.MaxCommitTs,
+	}
+	ts, errs := h.mvccStore.AsyncPrewrite(req.Mutations, req.PrimaryLock, req.StartVersion, req.LockTtl, opt)
+	resp := &tikvrpc.AsyncPrewriteResponse{
+		Errors: convertToKeyErrors(errs),
+	}
+	if len(resp.Errors) > 0 {
+		return resp
+	}
+	if req.TryOnePc {
+		resp.OnePcCommitTs = ts
+		h.releaseLocks(req.StartVersion)
+	} else {
+		resp.MinCommitTs = ts
+	}
+	return resp
+}
+
+func (h *rpcHandler) handleKvCheckTxnStatus(req *tikvrpc.CheckTxnStatusRequest) *tikvrpc.CheckTxnStatusResponse {
+	if !h.checkKeyInRegion(req.PrimaryKey) {
+		panic("KvCheckTxnStatus: key not in region")
+	}
+	status, err := h.mvccStore.CheckTxnStatus(req.PrimaryKey, req.LockTs)
+	if err != nil {
+		return &tikvrpc.CheckTxnStatusResponse{
+			Error: convertToKeyError(err),
+		}
+	}
+	if status.LockTTL == 0 {
+		h.releaseLocks(req.LockTs)
+	}
+	return &tikvrpc.CheckTxnStatusResponse{
+		CommitVersion:  status.CommitTS,
+		LockTtl:        status.LockTTL,
+		UseAsyncCommit: status.UseAsyncCommit,
+		Secondaries:    status.Secondaries,
+		MinCommitTs:    status.MinCommitTS,
+	}
+}
+
+func (h *rpcHandler) handleKvCheckSecondaryLocks(req *tikvrpc.CheckSecondaryLocksRequest) *tikvrpc.CheckSecondaryLocksResponse {
+	for _, k := range req.Keys {
+		if !h.checkKeyInRegion(k) {
+			panic("KvCheckSecondaryLocks: key not in region")
+		}
+	}
+	status, err := h.mvccStore.CheckSecondaryLocks(req.Keys, req.StartVersion)
+	if err != nil {
+		return &tikvrpc.CheckSecondaryLocksResponse{
+			Error: convertToKeyError(err),
+		}
+	}
+	if len(status.Locks) == 0 {
+		h.releaseLocks(req.StartVersion)
+	}
+	return &tikvrpc.CheckSecondaryLocksResponse{
+		Locks:       status.Locks,
+		MinCommitTs: status.MinCommitTS,
+		CommitTs:    status.CommitTS,
+	}
+}
+
+// releaseLocks wakes up the pessimistic lock requests waiting for the locks of
+// the transaction startTS after its locks are released.
+func (h *rpcHandler) releaseLocks(startTS uint64) {
+	h.detector.CleanUp(startTS)
+	h.lockWaiter.wakeUp(startTS)
+}
+
 func (h *rpcHandler) handleKvDeleteRange(req *kvrpcpb.DeleteRangeRequest) *kvrpcpb.DeleteRangeResponse {
 	return &kvrpcpb.DeleteRangeResponse{
 		Error: "not implemented",
@@ -396,18 +550,165 @@ func (h *rpcHandler) handleKvRawScan(req *kvrpcpb.RawScanRequest) *kvrpcpb.RawSc
 	}
 }
 
+func (h *rpcHandler) checkRawKeysInRegion(keys [][]byte) bool {
+	for _, k := range keys {
+		if !regionContains(h.startKey, h.endKey, k) {
+			return false
+		}
+	}
+	return true
+}
+
+// rawRangeEnd limits the end key of a range to the region, an empty key means the end of
+// the key space.
+func (h *rpcHandler) rawRangeEnd(endKey []byte) []byte {
+	if len(endKey) == 0 || (len(h.endKey) > 0 && bytes.Compare(endKey, h.endKey) > 0) {
+		return h.endKey
+	}
+	return endKey
+}
+
+func (h *rpcHandler) handleKvRawBatchGet(req *tikvrpc.RawBatchGetRequest) *tikvrpc.RawBatchGetResponse {
+	kv, ok := h.mvccStore.(RawKV)
+	if !ok {
+		errStr := "not implemented"
+		return &tikvrpc.RawBatchGetResponse{
+			RegionError: &errorpb.Error{
+				Message: &errStr,
+			},
+		}
+	}
+	if !h.checkRawKeysInRegion(req.Keys) {
+		panic("KvRawBatchGet: key not in region")
+	}
+	var pairs []*kvrpcpb.KvPair
+	for _, k := range req.Keys {
+		if v := kv.RawGet(k); v != nil {
+			pairs = append(pairs, &kvrpcpb.KvPair{Key: k, Value: v})
+		}
+	}
+	return &tikvrpc.RawBatchGetResponse{Pairs: pairs}
+}
+
+func (h *rpcHandler) handleKvRawBatchPut(req *tikvrpc.RawBatchPutRequest) *tikvrpc.RawBatchPutResponse {
+	kv, ok := h.mvccStore.(RawKV)
+	if !ok {
+		return &tikvrpc.RawBatchPutResponse{
+			Error: "not implemented",
+		}
+	}
+	keys := make([][]byte, 0, len(req.Pairs))
+	for _, p := range req.Pairs {
+		keys = append(keys, p.Key)
+	}
+	if !h.checkRawKeysInRegion(keys) {
+		panic("KvRawBatchPut: key not in region")
+	}
+	for i, p := range req.Pairs {
+		var ttl uint64
+		if len(req.Ttls) > 0 {
+			ttl = req.Ttls[i]
+		}
+		kv.RawPutTTL(p.Key, p.Value, ttl)
+	}
+	return &tikvrpc.RawBatchPutResponse{}
+}
+
+func (h *rpcHandler) handleKvRawBatchDelete(req *tikvrpc.RawBatchDeleteRequest) *tikvrpc.RawBatchDeleteResponse {
+	kv, ok := h.mvccStore.(RawKV)
+	if !ok {
+		return &tikvrpc.RawBatchDeleteResponse{
+			Error: "not implemented",
+		}
+	}
+	if !h.checkRawKeysInRegion(req.Keys) {
+		panic("KvRawBatchDelete: key not in region")
+	}
+	for _, k := range req.Keys {
+		kv.RawDelete(k)
+	}
+	return &tikvrpc.RawBatchDeleteResponse{}
+}
+
+func (h *rpcHandler) handleKvRawDeleteRange(req *tikvrpc.RawDeleteRangeRequest) *tikvrpc.RawDeleteRangeResponse {
+	kv, ok := h.mvccStore.(RawKV)
+	if !ok {
+		return &tikvrpc.RawDeleteRangeResponse{
+			Error: "not implemented",
+		}
+	}
+	kv.RawDeleteRange(maxStartKey(req.StartKey, h.startKey), h.rawRangeEnd(req.EndKey))
+	return &tikvrpc.RawDeleteRangeResponse{}
+}
+
+func (h *rpcHandler) handleKvRawReverseScan(req *tikvrpc.RawReverseScanRequest) *tikvrpc.RawReverseScanResponse {
+	kv, ok := h.mvccStore.(RawKV)
+	if !ok {
+		errStr := "not implemented"
+		return &tikvrpc.RawReverseScanResponse{
+			RegionError: &errorpb.Error{
+				Message: &errStr,
+			},
+		}
+	}
+	pairs := kv.RawReverseScan(maxStartKey(req.EndKey, h.startKey), h.rawRangeEnd(req.StartKey), int(req.Limit))
+	return &tikvrpc.RawReverseScanResponse{
+		Kvs: convertToPbPairs(pairs),
+	}
+}
+
+func (h *rpcHandler) handleKvRawCompareAndSwap(req *tikvrpc.RawCompareAndSwapRequest) *tikvrpc.RawCompareAndSwapResponse {
+	kv, ok := h.mvccStore.(RawKV)
+	if !ok {
+		return &tikvrpc.RawCompareAndSwapResponse{
+			Error: "not implemented",
+		}
+	}
+	if !h.checkRawKeysInRegion([][]byte{req.Key}) {
+		panic("KvRawCompareAndSwap: key not in region")
+	}
+	prev, prevNotExist, succeed := kv.RawCompareAndSwap(req.Key, req.PreviousValue, req.PreviousNotExist, req.Value, req.Ttl)
+	return &tikvrpc.RawCompareAndSwapResponse{
+		Succeed:          succeed,
+		PreviousValue:    prev,
+		PreviousNotExist: prevNotExist,
+	}
+}
+
+func (h *rpcHandler) handleKvRawGetKeyTTL(req *tikvrpc.RawGetKeyTTLRequest) *tikvrpc.RawGetKeyTTLResponse {
+	kv, ok := h.mvccStore.(RawKV)
+	if !ok {
+		return &tikvrpc.RawGetKeyTTLResponse{
+			Error: "not implemented",
+		}
+	}
+	if !h.checkRawKeysInRegion([][]byte{req.Key}) {
+		panic("KvRawGetKeyTTL: key not in region")
+	}
+	ttl, found := kv.RawGetKeyTTL(req.Key)
+	return &tikvrpc.RawGetKeyTTLResponse{
+		Ttl:      ttl,
+		NotFound: !found,
+	}
+}
+
 // RPCClient sends kv RPC calls to mock cluster.
 type RPCClient struct {
 	Cluster   *Cluster
 	MvccStore MVCCStore
+
+	detector   *Detector
+	lockWaiter *lockWaiter
 }
 
 // NewRPCClient creates an RPCClient.
 // Note that close the RPCClient may close the underlying MvccStore.
 func NewRPCClient(cluster *Cluster, mvccStore MVCCStore) *RPCClient {
 	return &RPCClient{
-		Cluster:   cluster,
-		MvccStore: mvccStore,
+		Cluster:    cluster,
+		MvccStore:  mvccStore,
+		detector:   NewDetector(),
+		lockWaiter: newLockWaiter(),
 	}
 }
 
@@ -439,7 +740,9 @@ func (c *RPCClient) checkArgs(ctx goctx.Context, addr string) (*rpcHandler, erro
 		cluster:   c.Cluster,
 		mvccStore: c.MvccStore,
 		// set store id for current request
-		storeID: store.GetId(),
+		storeID:    store.GetId(),
+		detector:   c.detector,
+		lockWaiter: c.lockWaiter,
 	}
 	return handler, nil
 }
@@ -450,6 +753,7 @@ func (c *RPCClient) SendReq(ctx goctx.Context, addr string, req *tikvrpc.Request
 	if err != nil {
 		return nil, err
 	}
+	handler.replicaRead = req.ReplicaRead
 	reqCtx, err := req.GetContext()
 	if err != nil {
 		return nil, err
@@ -507,6 +811,48 @@ func (c *RPCClient) SendReq(ctx goctx.Context, addr string, req *tikvrpc.Request
 			return resp, nil
 		}
 		resp.BatchRollback = handler.handleKvBatchRollback(r)
+	case tikvrpc.CmdPessimisticLock:
+		r := req.PessimisticLock
+		if err := handler.checkRequest(reqCtx, r.Size()); err != nil {
+			resp.PessimisticLock = &tikvrpc.PessimisticLockResponse{RegionError: err}
+			return resp, nil
+		}
+		resp.PessimisticLock = handler.handleKvPessimisticLock(r)
+	case tikvrpc.CmdPessimisticRollback:
+		r := req.PessimisticRollback
+		if err := handler.checkRequest(reqCtx, r.Size()); err != nil {
+			resp.PessimisticRollback = &tikvrpc.PessimisticRollbackResponse{RegionError: err}
+			return resp, nil
+		}
+		resp.PessimisticRollback = handler.handleKvPessimisticRollback(r)
+	case tikvrpc.CmdTxnHeartBeat:
+		r := req.TxnHeartBeat
+		if err := handler.checkRequest(reqCtx, r.Size()); err != nil {
+			resp.TxnHeartBeat = &tikvrpc.TxnHeartBeatResponse{RegionError: err}
+			return resp, nil
+		}
+		resp.TxnHeartBeat = handler.handleKvTxnHeartBeat(r)
+	case tikvrpc.CmdAsyncPrewrite:
+		r := req.AsyncPrewrite
+		if err := handler.checkRequest(reqCtx, r.Size()); err != nil {
+			resp.AsyncPrewrite = &tikvrpc.AsyncPrewriteResponse{RegionError: err}
+			return resp, nil
+		}
+		resp.AsyncPrewrite = handler.handleKvAsyncPrewrite(r)
+	case tikvrpc.CmdCheckTxnStatus:
+		r := req.CheckTxnStatus
+		if err := handler.checkRequest(reqCtx, r.Size()); err != nil {
+			resp.CheckTxnStatus = &tikvrpc.CheckTxnStatusResponse{RegionError: err}
+			return resp, nil
+		}
+		resp.CheckTxnStatus = handler.handleKvCheckTxnStatus(r)
+	case tikvrpc.CmdCheckSecondaryLocks:
+		r := req.CheckSecondaryLocks
+		if err := handler.checkRequest(reqCtx, r.Size()); err != nil {
+			resp.CheckSecondaryLocks = &tikvrpc.CheckSecondaryLocksResponse{RegionError: err}
+			return resp, nil
+		}
+		resp.CheckSecondaryLocks = handler.handleKvCheckSecondaryLocks(r)
 	case tikvrpc.CmdScanLock:
 		r := req.ScanLock
 		if err := handler.checkRequest(reqCtx, r.Size()); err != nil {
@@ -563,6 +909,55 @@ func (c *RPCClient) SendReq(ctx goctx.Context, addr string, req *tikvrpc.Request
 			return resp, nil
 		}
 		resp.RawScan = handler.handleKvRawScan(r)
+	case tikvrpc.CmdRawBatchGet:
+		r := req.RawBatchGet
+		if err := handler.checkRequest(reqCtx, r.Size()); err != nil {
+			resp.RawBatchGet = &tikvrpc.RawBatchGetResponse{RegionError: err}
+			return resp, nil
+		}
+		resp.RawBatchGet = handler.handleKvRawBatchGet(r)
+	case tikvrpc.CmdRawBatchPut:
+		r := req.RawBatchPut
+		if err := handler.checkRequest(reqCtx, r.Size()); err != nil {
+			resp.RawBatchPut = &tikvrpc.RawBatchPutResponse{RegionError: err}
+			return resp, nil
+		}
+		resp.RawBatchPut = handler.handleKvRawBatchPut(r)
+	case tikvrpc.CmdRawBatchDelete:
+		r := req.RawBatchDelete
+		if err := handler.checkRequest(reqCtx, r.Size()); err != nil {
+			resp.RawBatchDelete = &tikvrpc.RawBatchDeleteResponse{RegionError: err}
+			return resp, nil
+		}
+		resp.RawBatchDelete = handler.handleKvRawBatchDelete(r)
+	case tikvrpc.CmdRawDeleteRange:
+		r := req.RawDeleteRange
+		if err := handler.checkRequest(reqCtx, r.Size()); err != nil {
+			resp.RawDeleteRange = &tikvrpc.RawDeleteRangeResponse{RegionError: err}
+			return resp, nil
+		}
+		resp.RawDeleteRange = handler.handleKvRawDeleteRange(r)
+	case tikvrpc.CmdRawReverseScan:
+		r := req.RawReverseScan
+		if err := handler.checkRequest(reqCtx, r.Size()); err != nil {
+			resp.RawReverseScan = &tikvrpc.RawReverseScanResponse{RegionError: err}
+			return resp, nil
+		}
+		resp.RawReverseScan = handler.handleKvRawReverseScan(r)
+	case tikvrpc.CmdRawCompareAndSwap:
+		r := req.RawCompareAndSwap
+		if err := handler.checkRequest(reqCtx, r.Size()); err != nil {
+			resp.RawCompareAndSwap = &tikvrpc.RawCompareAndSwapResponse{RegionError: err}
+			return resp, nil
+		}
+		resp.RawCompareAndSwap = handler.handleKvRawCompareAndSwap(r)
+	case tikvrpc.CmdRawGetKeyTTL:
+		r := req.RawGetKeyTTL
+		if err := handler.checkRequest(reqCtx, r.Size()); err != nil {
+			resp.RawGetKeyTTL = &tikvrpc.RawGetKeyTTLResponse{RegionError: err}
+			return resp, nil
+		}
+		resp.RawGetKeyTTL = handler.handleKvRawGetKeyTTL(r)
 	case tikvrpc.CmdCop:
 		r := req.Cop
 		if err := handler.checkRequestContext(reqCtx); err != nil {
@@ -573,6 +968,16 @@ func (c *RPCClient) SendReq(ctx goctx.Context, addr string, req *tikvrpc.Request
 		handler.rawEndKey = MvccKey(handler.endKey).Raw()
 		var res *coprocessor.Response
 		var err error
+		if req.CopCache != nil {
+			resp.CopCache, err = handler.checkCopCache(r, req.CopCache)
+			if err != nil {
+				return nil, err
+			}
+			if resp.CopCache != nil && resp.CopCache.IsCacheHit {
+				resp.Cop = &coprocessor.Response{}
+				break
+			}
+		}
 		if r.GetTp() == kv.ReqTypeDAG {
 			res, err = handler.handleCopDAGRequest(r)
 		} else {
@@ -0,0 +1,253 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tikv
+
+import (
+	"bytes"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/coreos/etcd/pkg/monotime"
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/store/tikv/tikvrpc"
+	goctx "golang.org/x/net/context"
+)
+
+var _ kv.PessimisticTxn = (*tikvTxn)(nil)
+
+// pessimisticLockTTL is the ttl (in ms) of the pessimistic locks, counted from the
+// time the locks are acquired. The pessimistic mode is only supported by mock-tikv,
+// where the primary lock is kept alive by the ttlManager until the transaction ends.
+const pessimisticLockTTL = 20000
+
+// pessimisticLockMaxBackoff is the max total backoff time (in ms) of acquiring the
+// pessimistic locks, the time waiting for locks is not counted.
+const pessimisticLockMaxBackoff = 20000
+
+// StartStmt implements the kv.PessimisticTxn interface.
+func (txn *tikvTxn) StartStmt(forUpdateTS uint64, lockWaitDeadline time.Time) {
+	txn.forUpdateTS = forUpdateTS
+	txn.lockWaitDeadline = lockWaitDeadline
+	txn.snapshot.version = kv.NewVersion(forUpdateTS)
+	txn.stmtBuf = kv.NewBufferStore(txn.us)
+	txn.stmtLockKeys = nil
+}
+
+// FinishStmt implements the kv.PessimisticTxn interface.
+func (txn *tikvTxn) FinishStmt(commit bool) error {
+	stmtBuf, stmtLockKeys := txn.stmtBuf, txn.stmtLockKeys
+	txn.stmtBuf = nil
+	txn.stmtLockKeys = nil
+	txn.snapshot.version = kv.NewVersion(txn.startTS)
+	if !commit || stmtBuf == nil {
+		return nil
+	}
+	txn.lockKeys = append(txn.lockKeys, stmtLockKeys...)
+	return errors.Trace(stmtBuf.SaveTo(txn.us))
+}
+
+// lockStmtKeys acquires the pessimistic locks of the keys at forUpdateTS before the
+// current statement writes or locks them. A key committed by another transaction
+// after forUpdateTS fails the lock with a retryable error, so the statement is
+// executed again with a new forUpdateTS.
+func (txn *tikvTxn) lockStmtKeys(keys ...kv.Key) error {
+	var lockKeys [][]byte
+	for _, k := range keys {
+		if _, ok := txn.lockedKeys[string(k)]; ok {
+			continue
+		}
+		lockKeys = append(lockKeys, append([]byte(nil), k...))
+	}
+	if len(lockKeys) == 0 {
+		return nil
+	}
+
+	txnCmdCounter.WithLabelValues("pessimistic_lock").Inc()
+	if txn.primaryKey == nil {
+		txn.primaryKey = lockKeys[0]
+	}
+	bo := NewBackoffer(pessimisticLockMaxBackoff, goctx.Background())
+	err := txn.pessimisticLockKeys(bo, lockKeys, txn.lockWaitDeadline)
+	if _, ok := txn.lockedKeys[string(txn.primaryKey)]; !ok {
+		// The primary key is not locked, choose another one next time.
+		txn.primaryKey = nil
+	} else {
+		txn.ttlManager.run(txn, txn.primaryKey)
+	}
+	return errors.Trace(err)
+}
+
+func (txn *tikvTxn) pessimisticLockKeys(bo *Backoffer, keys [][]byte, deadline time.Time) error {
+	groups, firstRegion, err := txn.store.regionCache.GroupKeysByRegion(bo, keys)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	// Lock the primary key first, so the other locks always point to a locked primary.
+	batches := appendBatchBySize(nil, firstRegion, groups[firstRegion], keySize, txnCommitBatchSize)
+	delete(groups, firstRegion)
+	for id, g := range groups {
+		batches = appendBatchBySize(batches, id, g, keySize, txnCommitBatchSize)
+	}
+	for _, batch := range batches {
+		if err = txn.pessimisticLockSingleBatch(bo, batch, deadline); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+func (txn *tikvTxn) pessimisticLockSingleBatch(bo *Backoffer, batch batchKeys, deadline time.Time) error {
+	for {
+		waitTimeout := deadline.Sub(time.Now())
+		if waitTimeout <= 0 {
+			return errors.Trace(kv.ErrLockWaitTimeout)
+		}
+		elapsed := uint64(time.Duration(monotime.Now()-txn.startTime) / time.Millisecond)
+		req := &tikvrpc.Request{
+			Type:     tikvrpc.CmdPessimisticLock,
+			Priority: txn.snapshot.priority,
+			PessimisticLock: &tikvrpc.PessimisticLockRequest{
+				Keys:         batch.keys,
+				PrimaryLock:  txn.primaryKey,
+				StartVersion: txn.startTS,
+				ForUpdateTs:  txn.forUpdateTS,
+				LockTtl:      elapsed + pessimisticLockTTL,
+				WaitTimeout:  int64(waitTimeout / time.Millisecond),
+			},
+		}
+		resp, err := txn.store.SendReq(bo, req, batch.region, readTimeoutShort+waitTimeout)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		regionErr, err := resp.GetRegionError()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if regionErr != nil {
+			err = bo.Backoff(BoRegionMiss, errors.New(regionErr.String()))
+			if err != nil {
+				return errors.Trace(err)
+			}
+			err = txn.pessimisticLockKeys(bo, batch.keys, deadline)
+			return errors.Trace(err)
+		}
+		lockResp := resp.PessimisticLock
+		if lockResp == nil {
+			return errors.Trace(ErrBodyMissing)
+		}
+		if deadlock := lockResp.Deadlock; deadlock != nil {
+			log.Infof("[kv] txn %d deadlocks on key %q held by txn %d", txn.startTS, deadlock.LockKey, deadlock.LockTs)
+			return errors.Trace(kv.ErrDeadlock)
+		}
+		keyErrs := lockResp.Errors
+		if len(keyErrs) == 0 {
+			for _, k := range batch.keys {
+				txn.lockedKeys[string(k)] = struct{}{}
+			}
+			return nil
+		}
+		var locks []*Lock
+		for _, keyErr := range keyErrs {
+			lock, err1 := extractLockFromKeyErr(keyErr)
+			if err1 != nil {
+				return errors.Trace(err1)
+			}
+			locks = append(locks, lock)
+		}
+		// The request has waited for the locks already, resolve them if they are
+		// expired and try again.
+		if _, err = txn.store.lockResolver.ResolveLocks(bo, locks); err != nil {
+			return errors.Trace(err)
+		}
+	}
+}
+
+// pessimisticRollback releases the pessimistic locks of the transaction. It's
+// best-effort, the locks left are resolved by others after they expire.
+func (txn *tikvTxn) pessimisticRollback() {
+	if len(txn.lockedKeys) == 0 {
+		return
+	}
+	keys := make([][]byte, 0, len(txn.lockedKeys))
+	for k := range txn.lockedKeys {
+		keys = append(keys, []byte(k))
+	}
+	bo := NewBackoffer(cleanupMaxBackoff, goctx.Background())
+	if err := txn.pessimisticRollbackKeys(bo, keys); err != nil {
+		log.Infof("[kv] pessimistic rollback err: %v, tid: %d", err, txn.startTS)
+	}
+}
+
+func (txn *tikvTxn) pessimisticRollbackKeys(bo *Backoffer, keys [][]byte) error {
+	groups, _, err := txn.store.regionCache.GroupKeysByRegion(bo, keys)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for id, g := range groups {
+		batch := batchKeys{region: id, keys: g}
+		if err = txn.pessimisticRollbackSingleBatch(bo, batch); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+func (txn *tikvTxn) pessimisticRollbackSingleBatch(bo *Backoffer, batch batchKeys) error {
+	req := &tikvrpc.Request{
+		Type: tikvrpc.CmdPessimisticRollback,
+		PessimisticRollback: &tikvrpc.PessimisticRollbackRequest{
+			Keys:         batch.keys,
+			StartVersion: txn.startTS,
+			ForUpdateTs:  txn.forUpdateTS,
+		},
+	}
+	resp, err := txn.store.SendReq(bo, req, batch.region, readTimeoutShort)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	regionErr, err := resp.GetRegionError()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if regionErr != nil {
+		err = bo.Backoff(BoRegionMiss, errors.New(regionErr.String()))
+		if err != nil {
+			return errors.Trace(err)
+		}
+		err = txn.pessimisticRollbackKeys(bo, batch.keys)
+		return errors.Trace(err)
+	}
+	if resp.PessimisticRollback == nil {
+		return errors.Trace(ErrBodyMissing)
+	}
+	if keyErrs := resp.PessimisticRollback.Errors; len(keyErrs) > 0 {
+		return errors.Errorf("pessimistic rollback failed: %s", keyErrs[0])
+	}
+	return nil
+}
+
+// primaryFirst moves the primary key to the front of keys.
+func primaryFirst(keys [][]byte, primary []byte) {
+	for i, k := range keys {
+		if bytes.Equal(k, primary) {
+			keys[0], keys[i] = keys[i], keys[0]
+			return
+		}
+	}
+}
+
+func keySize(key []byte) int {
+	return len(key)
+}
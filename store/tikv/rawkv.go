@@ -14,6 +14,8 @@
 package tikv
 
 import (
+	"bytes"
+	"sync"
 	"time"
 
 	"github.com/juju/errors"
@@ -30,8 +32,15 @@ var (
 	ErrMaxScanLimitExceeded = errors.New("limit should be less than MaxRawKVScanLimit")
 )
 
+const (
+	// rawBatchPairCount is the max number of the keys in a batch get or delete request.
+	rawBatchPairCount = 512
+	// rawBatchPutSize is the max size in bytes of the pairs in a batch put request.
+	rawBatchPutSize = 16 * 1024
+)
+
 // RawKVClient is a client of TiKV server which is used as a key-value storage,
-// only GET/PUT/DELETE commands are supported.
+// only GET/PUT/DELETE commands and their batch, range and ttl variants are supported.
 type RawKVClient struct {
 	clusterID   uint64
 	regionCache *RegionCache
@@ -81,7 +90,7 @@ func (c *RawKVClient) Get(key []byte) ([]byte, error) {
 	}
 	cmdResp := resp.RawGet
 	if cmdResp == nil {
-		return nil, errors.Trace(errBodyMissing)
+		return nil, errors.Trace(ErrBodyMissing)
 	}
 	if cmdResp.GetError() != "" {
 		return nil, errors.New(cmdResp.GetError())
@@ -116,7 +125,7 @@ func (c *RawKVClient) Put(key, value []byte) error {
 	}
 	cmdResp := resp.RawPut
 	if cmdResp == nil {
-		return errors.Trace(errBodyMissing)
+		return errors.Trace(ErrBodyMissing)
 	}
 	if cmdResp.GetError() != "" {
 		return errors.New(cmdResp.GetError())
@@ -141,7 +150,7 @@ func (c *RawKVClient) Delete(key []byte) error {
 	}
 	cmdResp := resp.RawDelete
 	if cmdResp == nil {
-		return errors.Trace(errBodyMissing)
+		return errors.Trace(ErrBodyMissing)
 	}
 	if cmdResp.GetError() != "" {
 		return errors.New(cmdResp.GetError())
@@ -173,7 +182,7 @@ func (c *RawKVClient) Scan(startKey []byte, limit int) (keys [][]byte, values []
 		}
 		cmdResp := resp.RawScan
 		if cmdResp == nil {
-			return nil, nil, errors.Trace(errBodyMissing)
+			return nil, nil, errors.Trace(ErrBodyMissing)
 		}
 		for _, pair := range cmdResp.Kvs {
 			keys = append(keys, pair.Key)
@@ -187,11 +196,278 @@ func (c *RawKVClient) Scan(startKey []byte, limit int) (keys [][]byte, values []
 	return
 }
 
+// BatchGet queries the values of the keys, the value of a key not found is nil.
+func (c *RawKVClient) BatchGet(keys [][]byte) ([][]byte, error) {
+	start := time.Now()
+	defer func() { rawkvCmdHistogram.WithLabelValues("batch_get").Observe(time.Since(start).Seconds()) }()
+
+	var mu sync.Mutex
+	found := make(map[string][]byte, len(keys))
+	bo := NewBackoffer(rawkvMaxBackoff, goctx.Background())
+	err := c.sendBatchReq(bo, keys, countKeySize, rawBatchPairCount, func(keys [][]byte) *tikvrpc.Request {
+		return &tikvrpc.Request{
+			Type:        tikvrpc.CmdRawBatchGet,
+			RawBatchGet: &tikvrpc.RawBatchGetRequest{Keys: keys},
+		}
+	}, func(resp *tikvrpc.Response) error {
+		cmdResp := resp.RawBatchGet
+		if cmdResp == nil {
+			return errors.Trace(ErrBodyMissing)
+		}
+		mu.Lock()
+		for _, pair := range cmdResp.Pairs {
+			found[string(pair.Key)] = pair.Value
+		}
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	values := make([][]byte, len(keys))
+	for i, key := range keys {
+		if v := found[string(key)]; len(v) > 0 {
+			values[i] = v
+		}
+	}
+	return values, nil
+}
+
+// PutWithTTL stores a key-value pair which expires after the ttl in seconds, 0 means it
+// never expires.
+func (c *RawKVClient) PutWithTTL(key, value []byte, ttl uint64) error {
+	return errors.Trace(c.BatchPutWithTTL([][]byte{key}, [][]byte{value}, []uint64{ttl}))
+}
+
+// BatchPut stores the key-value pairs.
+func (c *RawKVClient) BatchPut(keys, values [][]byte) error {
+	return errors.Trace(c.BatchPutWithTTL(keys, values, nil))
+}
+
+// BatchPutWithTTL stores the key-value pairs with their ttls in seconds, 0 means a pair
+// never expires. ttls can be nil if none of the pairs expires. If a key appears more than
+// once, the last pair wins.
+func (c *RawKVClient) BatchPutWithTTL(keys, values [][]byte, ttls []uint64) error {
+	start := time.Now()
+	defer func() { rawkvCmdHistogram.WithLabelValues("batch_put").Observe(time.Since(start).Seconds()) }()
+
+	if len(keys) != len(values) || (ttls != nil && len(ttls) != len(keys)) {
+		return errors.New("the lengths of the keys, values and ttls don't match")
+	}
+	pairs := make(map[string]int, len(keys))
+	for i, key := range keys {
+		if len(values[i]) == 0 {
+			return errors.New("empty value is not supported")
+		}
+		rawkvSizeHistogram.WithLabelValues("key").Observe(float64(len(key)))
+		rawkvSizeHistogram.WithLabelValues("value").Observe(float64(len(values[i])))
+		pairs[string(key)] = i
+	}
+
+	bo := NewBackoffer(rawkvMaxBackoff, goctx.Background())
+	sizeFn := func(key []byte) int {
+		return len(key) + len(values[pairs[string(key)]])
+	}
+	return errors.Trace(c.sendBatchReq(bo, keys, sizeFn, rawBatchPutSize, func(keys [][]byte) *tikvrpc.Request {
+		req := &tikvrpc.RawBatchPutRequest{Pairs: make([]*kvrpcpb.KvPair, 0, len(keys))}
+		for _, key := range keys {
+			i := pairs[string(key)]
+			req.Pairs = append(req.Pairs, &kvrpcpb.KvPair{Key: key, Value: values[i]})
+			if ttls != nil {
+				req.Ttls = append(req.Ttls, ttls[i])
+			}
+		}
+		return &tikvrpc.Request{
+			Type:        tikvrpc.CmdRawBatchPut,
+			RawBatchPut: req,
+		}
+	}, func(resp *tikvrpc.Response) error {
+		cmdResp := resp.RawBatchPut
+		if cmdResp == nil {
+			return errors.Trace(ErrBodyMissing)
+		}
+		if cmdResp.Error != "" {
+			return errors.New(cmdResp.Error)
+		}
+		return nil
+	}))
+}
+
+// BatchDelete deletes the key-value pairs of the keys.
+func (c *RawKVClient) BatchDelete(keys [][]byte) error {
+	start := time.Now()
+	defer func() { rawkvCmdHistogram.WithLabelValues("batch_delete").Observe(time.Since(start).Seconds()) }()
+
+	bo := NewBackoffer(rawkvMaxBackoff, goctx.Background())
+	return errors.Trace(c.sendBatchReq(bo, keys, countKeySize, rawBatchPairCount, func(keys [][]byte) *tikvrpc.Request {
+		return &tikvrpc.Request{
+			Type:           tikvrpc.CmdRawBatchDelete,
+			RawBatchDelete: &tikvrpc.RawBatchDeleteRequest{Keys: keys},
+		}
+	}, func(resp *tikvrpc.Response) error {
+		cmdResp := resp.RawBatchDelete
+		if cmdResp == nil {
+			return errors.Trace(ErrBodyMissing)
+		}
+		if cmdResp.Error != "" {
+			return errors.New(cmdResp.Error)
+		}
+		return nil
+	}))
+}
+
+// DeleteRange deletes the key-value pairs in [startKey, endKey), an empty endKey means
+// the end of the key space.
+func (c *RawKVClient) DeleteRange(startKey, endKey []byte) error {
+	start := time.Now()
+	defer func() { rawkvCmdHistogram.WithLabelValues("delete_range").Observe(time.Since(start).Seconds()) }()
+
+	for {
+		req := &tikvrpc.Request{
+			Type: tikvrpc.CmdRawDeleteRange,
+			RawDeleteRange: &tikvrpc.RawDeleteRangeRequest{
+				StartKey: startKey,
+				EndKey:   endKey,
+			},
+		}
+		resp, loc, err := c.sendReq(startKey, req)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		cmdResp := resp.RawDeleteRange
+		if cmdResp == nil {
+			return errors.Trace(ErrBodyMissing)
+		}
+		if cmdResp.Error != "" {
+			return errors.New(cmdResp.Error)
+		}
+		if len(loc.EndKey) == 0 || (len(endKey) > 0 && bytes.Compare(loc.EndKey, endKey) >= 0) {
+			return nil
+		}
+		startKey = loc.EndKey
+	}
+}
+
+// ReverseScan queries continuous kv pairs in the descending order, starts from startKey
+// (exclusive) down to endKey (inclusive), up to limit pairs. An empty startKey means the
+// end of the key space.
+func (c *RawKVClient) ReverseScan(startKey, endKey []byte, limit int) (keys [][]byte, values [][]byte, err error) {
+	start := time.Now()
+	defer func() { rawkvCmdHistogram.WithLabelValues("raw_reverse_scan").Observe(time.Since(start).Seconds()) }()
+
+	if limit > MaxRawKVScanLimit {
+		return nil, nil, errors.Trace(ErrMaxScanLimitExceeded)
+	}
+
+	for len(keys) < limit {
+		req := &tikvrpc.Request{
+			Type: tikvrpc.CmdRawReverseScan,
+			RawReverseScan: &tikvrpc.RawReverseScanRequest{
+				StartKey: startKey,
+				EndKey:   endKey,
+				Limit:    uint32(limit - len(keys)),
+			},
+		}
+		upperKey := startKey
+		resp, loc, err := c.sendReqByLocate(req, func(bo *Backoffer) (*KeyLocation, error) {
+			return c.regionCache.LocateEndKey(bo, upperKey)
+		})
+		if err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+		cmdResp := resp.RawReverseScan
+		if cmdResp == nil {
+			return nil, nil, errors.Trace(ErrBodyMissing)
+		}
+		for _, pair := range cmdResp.Kvs {
+			keys = append(keys, pair.Key)
+			values = append(values, pair.Value)
+		}
+		startKey = loc.StartKey
+		if len(startKey) == 0 || bytes.Compare(startKey, endKey) <= 0 {
+			break
+		}
+	}
+	return
+}
+
+// CompareAndSwap puts the new value of the key if its current value is previousValue, a
+// nil previousValue means the key doesn't exist. It returns the value before the call,
+// which is nil if the key doesn't exist, and whether the new value is put.
+func (c *RawKVClient) CompareAndSwap(key, previousValue, newValue []byte) ([]byte, bool, error) {
+	start := time.Now()
+	defer func() { rawkvCmdHistogram.WithLabelValues("cas").Observe(time.Since(start).Seconds()) }()
+
+	if len(newValue) == 0 {
+		return nil, false, errors.New("empty value is not supported")
+	}
+	req := &tikvrpc.Request{
+		Type: tikvrpc.CmdRawCompareAndSwap,
+		RawCompareAndSwap: &tikvrpc.RawCompareAndSwapRequest{
+			Key:              key,
+			Value:            newValue,
+			PreviousValue:    previousValue,
+			PreviousNotExist: previousValue == nil,
+		},
+	}
+	resp, _, err := c.sendReq(key, req)
+	if err != nil {
+		return nil, false, errors.Trace(err)
+	}
+	cmdResp := resp.RawCompareAndSwap
+	if cmdResp == nil {
+		return nil, false, errors.Trace(ErrBodyMissing)
+	}
+	if cmdResp.Error != "" {
+		return nil, false, errors.New(cmdResp.Error)
+	}
+	if cmdResp.PreviousNotExist {
+		return nil, cmdResp.Succeed, nil
+	}
+	return cmdResp.PreviousValue, cmdResp.Succeed, nil
+}
+
+// GetKeyTTL returns the remaining ttl in seconds of the key, 0 means it never expires.
+// It returns nil if the key doesn't exist.
+func (c *RawKVClient) GetKeyTTL(key []byte) (*uint64, error) {
+	start := time.Now()
+	defer func() { rawkvCmdHistogram.WithLabelValues("get_key_ttl").Observe(time.Since(start).Seconds()) }()
+
+	req := &tikvrpc.Request{
+		Type:         tikvrpc.CmdRawGetKeyTTL,
+		RawGetKeyTTL: &tikvrpc.RawGetKeyTTLRequest{Key: key},
+	}
+	resp, _, err := c.sendReq(key, req)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	cmdResp := resp.RawGetKeyTTL
+	if cmdResp == nil {
+		return nil, errors.Trace(ErrBodyMissing)
+	}
+	if cmdResp.Error != "" {
+		return nil, errors.New(cmdResp.Error)
+	}
+	if cmdResp.NotFound {
+		return nil, nil
+	}
+	ttl := cmdResp.Ttl
+	return &ttl, nil
+}
+
 func (c *RawKVClient) sendReq(key []byte, req *tikvrpc.Request) (*tikvrpc.Response, *KeyLocation, error) {
+	return c.sendReqByLocate(req, func(bo *Backoffer) (*KeyLocation, error) {
+		return c.regionCache.LocateKey(bo, key)
+	})
+}
+
+// sendReqByLocate sends the request to the region returned by locate, it retries on the
+// region errors.
+func (c *RawKVClient) sendReqByLocate(req *tikvrpc.Request, locate func(*Backoffer) (*KeyLocation, error)) (*tikvrpc.Response, *KeyLocation, error) {
 	bo := NewBackoffer(rawkvMaxBackoff, goctx.Background())
 	sender := NewRegionRequestSender(c.regionCache, c.rpcClient, kvrpcpb.IsolationLevel_SI)
 	for {
-		loc, err := c.regionCache.LocateKey(bo, key)
+		loc, err := locate(bo)
 		if err != nil {
 			return nil, nil, errors.Trace(err)
 		}
@@ -204,7 +480,7 @@ func (c *RawKVClient) sendReq(key []byte, req *tikvrpc.Request) (*tikvrpc.Respon
 			return nil, nil, errors.Trace(err)
 		}
 		if regionErr != nil {
-			err := bo.Backoff(boRegionMiss, errors.New(regionErr.String()))
+			err := bo.Backoff(BoRegionMiss, errors.New(regionErr.String()))
 			if err != nil {
 				return nil, nil, errors.Trace(err)
 			}
@@ -213,3 +489,68 @@ func (c *RawKVClient) sendReq(key []byte, req *tikvrpc.Request) (*tikvrpc.Respon
 		return resp, loc, nil
 	}
 }
+
+func countKeySize([]byte) int {
+	return 1
+}
+
+// sendBatchReq groups the keys by region and splits the groups into batches by the size,
+// then sends the requests built by build for the batches in parallel. handle is called
+// concurrently on the responses. It returns the first error of the batches.
+func (c *RawKVClient) sendBatchReq(bo *Backoffer, keys [][]byte, sizeFn func([]byte) int, limit int,
+	build func([][]byte) *tikvrpc.Request, handle func(*tikvrpc.Response) error) error {
+	groups, _, err := c.regionCache.GroupKeysByRegion(bo, keys)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	var batches []batchKeys
+	for region, groupKeys := range groups {
+		batches = appendBatchBySize(batches, region, groupKeys, sizeFn, limit)
+	}
+	if len(batches) == 1 {
+		return errors.Trace(c.doBatchReq(bo, batches[0], sizeFn, limit, build, handle))
+	}
+
+	// Stop sending other requests after receiving the first error.
+	backoffer, cancel := bo.Fork()
+	defer cancel()
+	ch := make(chan error, len(batches))
+	for _, batch := range batches {
+		go func(batch batchKeys) {
+			singleBatchBackoffer, singleBatchCancel := backoffer.Fork()
+			defer singleBatchCancel()
+			ch <- c.doBatchReq(singleBatchBackoffer, batch, sizeFn, limit, build, handle)
+		}(batch)
+	}
+	for i := 0; i < len(batches); i++ {
+		if e := <-ch; e != nil {
+			cancel()
+			if err == nil {
+				err = e
+			}
+		}
+	}
+	return errors.Trace(err)
+}
+
+func (c *RawKVClient) doBatchReq(bo *Backoffer, batch batchKeys, sizeFn func([]byte) int, limit int,
+	build func([][]byte) *tikvrpc.Request, handle func(*tikvrpc.Response) error) error {
+	sender := NewRegionRequestSender(c.regionCache, c.rpcClient, kvrpcpb.IsolationLevel_SI)
+	resp, err := sender.SendReq(bo, build(batch.keys), batch.region, readTimeoutShort)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	regionErr, err := resp.GetRegionError()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if regionErr != nil {
+		err = bo.Backoff(BoRegionMiss, errors.New(regionErr.String()))
+		if err != nil {
+			return errors.Trace(err)
+		}
+		// The region may be split or merged, the keys are grouped again.
+		return errors.Trace(c.sendBatchReq(bo, batch.keys, sizeFn, limit, build, handle))
+	}
+	return errors.Trace(handle(resp))
+}
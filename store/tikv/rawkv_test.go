@@ -127,3 +127,134 @@ func (s *testRawKVSuite) TestScan(c *C) {
 	split("k2", "k5")
 	check()
 }
+
+func (s *testRawKVSuite) split(c *C, regionKey, splitKey string) {
+	loc, err := s.client.regionCache.LocateKey(s.bo, []byte(regionKey))
+	c.Assert(err, IsNil)
+	newRegionID, peerID := s.cluster.AllocID(), s.cluster.AllocID()
+	s.cluster.SplitRaw(loc.Region.id, newRegionID, []byte(splitKey), []uint64{peerID}, peerID)
+}
+
+func (s *testRawKVSuite) mustReverseScan(c *C, startKey, endKey string, limit int, expect ...string) {
+	keys, values, err := s.client.ReverseScan([]byte(startKey), []byte(endKey), limit)
+	c.Assert(err, IsNil)
+	c.Assert(len(keys)*2, Equals, len(expect))
+	for i := range keys {
+		c.Assert(string(keys[i]), Equals, expect[i*2])
+		c.Assert(string(values[i]), Equals, expect[i*2+1])
+	}
+}
+
+func (s *testRawKVSuite) TestBatch(c *C) {
+	keys := [][]byte{[]byte("k1"), []byte("k3"), []byte("k5"), []byte("k7")}
+	values := [][]byte{[]byte("v1"), []byte("v3"), []byte("v5"), []byte("v7")}
+
+	check := func() {
+		c.Assert(s.client.BatchPut(keys, values), IsNil)
+		got, err := s.client.BatchGet(append(keys, []byte("k9")))
+		c.Assert(err, IsNil)
+		c.Assert(got, DeepEquals, append(values, nil))
+
+		c.Assert(s.client.BatchDelete(keys[1:3]), IsNil)
+		got, err = s.client.BatchGet(keys)
+		c.Assert(err, IsNil)
+		c.Assert(got, DeepEquals, [][]byte{[]byte("v1"), nil, nil, []byte("v7")})
+	}
+
+	check()
+	// The stale regions in the cache make the keys grouped again.
+	s.split(c, "k", "k2")
+	s.split(c, "k2", "k5")
+	check()
+
+	err := s.client.BatchPut(keys, values[:1])
+	c.Assert(err, NotNil)
+	err = s.client.BatchPut(keys[:1], [][]byte{{}})
+	c.Assert(err, NotNil)
+}
+
+func (s *testRawKVSuite) TestDeleteRange(c *C) {
+	for _, k := range []string{"k1", "k3", "k5", "k7"} {
+		s.mustPut(c, []byte(k), []byte("v"+k[1:]))
+	}
+	s.split(c, "k", "k2")
+	s.split(c, "k2", "k5")
+
+	c.Assert(s.client.DeleteRange([]byte("k2"), []byte("k6")), IsNil)
+	s.mustScan(c, "", 10, "k1", "v1", "k7", "v7")
+	c.Assert(s.client.DeleteRange([]byte("k1"), nil), IsNil)
+	s.mustScan(c, "", 10)
+}
+
+func (s *testRawKVSuite) TestReverseScan(c *C) {
+	s.mustPut(c, []byte("k1"), []byte("v1"))
+	s.mustPut(c, []byte("k3"), []byte("v3"))
+	s.mustPut(c, []byte("k5"), []byte("v5"))
+	s.mustPut(c, []byte("k7"), []byte("v7"))
+
+	check := func() {
+		s.mustReverseScan(c, "", "", 1, "k7", "v7")
+		s.mustReverseScan(c, "k7", "", 2, "k5", "v5", "k3", "v3")
+		s.mustReverseScan(c, "", "", 10, "k7", "v7", "k5", "v5", "k3", "v3", "k1", "v1")
+		s.mustReverseScan(c, "k6", "k3", 10, "k5", "v5", "k3", "v3")
+		s.mustReverseScan(c, "k5", "k4", 10)
+	}
+
+	check()
+	s.split(c, "k", "k2")
+	check()
+	s.split(c, "k2", "k5")
+	check()
+}
+
+func (s *testRawKVSuite) TestCompareAndSwap(c *C) {
+	key := []byte("key")
+	prev, ok, err := s.client.CompareAndSwap(key, nil, []byte("v1"))
+	c.Assert(err, IsNil)
+	c.Assert(ok, IsTrue)
+	c.Assert(prev, IsNil)
+	s.mustGet(c, key, []byte("v1"))
+
+	prev, ok, err = s.client.CompareAndSwap(key, nil, []byte("v2"))
+	c.Assert(err, IsNil)
+	c.Assert(ok, IsFalse)
+	c.Assert(prev, BytesEquals, []byte("v1"))
+
+	prev, ok, err = s.client.CompareAndSwap(key, []byte("v0"), []byte("v2"))
+	c.Assert(err, IsNil)
+	c.Assert(ok, IsFalse)
+	c.Assert(prev, BytesEquals, []byte("v1"))
+	s.mustGet(c, key, []byte("v1"))
+
+	prev, ok, err = s.client.CompareAndSwap(key, []byte("v1"), []byte("v2"))
+	c.Assert(err, IsNil)
+	c.Assert(ok, IsTrue)
+	c.Assert(prev, BytesEquals, []byte("v1"))
+	s.mustGet(c, key, []byte("v2"))
+}
+
+func (s *testRawKVSuite) TestTTL(c *C) {
+	ttl, err := s.client.GetKeyTTL([]byte("k1"))
+	c.Assert(err, IsNil)
+	c.Assert(ttl, IsNil)
+
+	s.mustPut(c, []byte("k1"), []byte("v1"))
+	ttl, err = s.client.GetKeyTTL([]byte("k1"))
+	c.Assert(err, IsNil)
+	c.Assert(*ttl, Equals, uint64(0))
+
+	c.Assert(s.client.PutWithTTL([]byte("k2"), []byte("v2"), 100), IsNil)
+	ttl, err = s.client.GetKeyTTL([]byte("k2"))
+	c.Assert(err, IsNil)
+	c.Assert(*ttl > 0 && *ttl <= 100, IsTrue)
+
+	keys := [][]byte{[]byte("k3"), []byte("k4")}
+	values := [][]byte{[]byte("v3"), []byte("v4")}
+	c.Assert(s.client.BatchPutWithTTL(keys, values, []uint64{0, 50}), IsNil)
+	ttl, err = s.client.GetKeyTTL([]byte("k3"))
+	c.Assert(err, IsNil)
+	c.Assert(*ttl, Equals, uint64(0))
+	ttl, err = s.client.GetKeyTTL([]byte("k4"))
+	c.Assert(err, IsNil)
+	c.Assert(*ttl > 0 && *ttl <= 50, IsTrue)
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/pingcap/kvproto/pkg/kvrpcpb"
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/pd/pd-client"
+	"github.com/pingcap/tidb/kv"
 	goctx "golang.org/x/net/context"
 )
 
@@ -51,11 +52,17 @@ func NewRegionCache(pdClient pd.Client) *RegionCache {
 	return c
 }
 
+// LocalLabels are the location labels of the tidb-server. The closest replica reads
+// prefer the replicas on the stores with the same labels.
+var LocalLabels map[string]string
+
 // RPCContext contains data that is needed to send RPC to a region.
 type RPCContext struct {
 	Region RegionVerID
 	KVCtx  *kvrpcpb.Context
 	Addr   string
+	// ReplicaRead is true if the peer isn't the leader, the request is a replica read.
+	ReplicaRead bool
 }
 
 // GetStoreID returns StoreID.
@@ -67,8 +74,9 @@ func (c *RPCContext) GetStoreID() uint64 {
 }
 
 // GetRPCContext returns RPCContext for a region. If it returns nil, the region
-// must be out of date and already dropped from cache.
-func (c *RegionCache) GetRPCContext(bo *Backoffer, id RegionVerID) (*RPCContext, error) {
+// must be out of date and already dropped from cache. The peer is selected by
+// replicaRead, seed picks one of the candidate followers.
+func (c *RegionCache) GetRPCContext(bo *Backoffer, id RegionVerID, replicaRead kv.ReplicaReadType, seed uint32) (*RPCContext, error) {
 	c.mu.RLock()
 	region, ok := c.mu.regions[id]
 	if !ok {
@@ -76,9 +84,35 @@ func (c *RegionCache) GetRPCContext(bo *Backoffer, id RegionVerID) (*RPCContext,
 		return nil, nil
 	}
 	kvCtx := region.GetContext()
+	var followers []*metapb.Peer
+	if replicaRead.IsFollowerRead() {
+		followers = region.followers()
+	}
 	c.mu.RUnlock()
 
-	addr, err := c.GetStoreAddr(bo, kvCtx.GetPeer().GetStoreId())
+	leader := kvCtx.GetPeer()
+	peer, err := c.selectReplica(bo, leader, followers, replicaRead, seed)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if peer != leader {
+		addr, err := c.GetStoreAddr(bo, peer.GetStoreId())
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		// The follower's store is not found, the leader serves the read.
+		if addr != "" {
+			kvCtx.Peer = peer
+			return &RPCContext{
+				Region:      id,
+				KVCtx:       kvCtx,
+				Addr:        addr,
+				ReplicaRead: true,
+			}, nil
+		}
+	}
+
+	addr, err := c.GetStoreAddr(bo, leader.GetStoreId())
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
@@ -94,6 +128,39 @@ func (c *RegionCache) GetRPCContext(bo *Backoffer, id RegionVerID) (*RPCContext,
 	}, nil
 }
 
+// selectReplica selects the peer to serve a read from the leader and the reachable
+// followers of a region.
+func (c *RegionCache) selectReplica(bo *Backoffer, leader *metapb.Peer, followers []*metapb.Peer, replicaRead kv.ReplicaReadType, seed uint32) (*metapb.Peer, error) {
+	switch replicaRead {
+	case kv.ReplicaReadFollower:
+		if len(followers) > 0 {
+			return followers[seed%uint32(len(followers))], nil
+		}
+	case kv.ReplicaReadMixed:
+		peers := append([]*metapb.Peer{leader}, followers...)
+		return peers[seed%uint32(len(peers))], nil
+	case kv.ReplicaReadClosest:
+		if len(LocalLabels) == 0 {
+			return leader, nil
+		}
+		// The leader is preferred if it's close, the followers are tried in turn.
+		peers := []*metapb.Peer{leader}
+		for i := range followers {
+			peers = append(peers, followers[(uint32(i)+seed)%uint32(len(followers))])
+		}
+		for _, p := range peers {
+			store, err := c.getStore(bo, p.GetStoreId())
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			if store != nil && store.matchLabels(LocalLabels) {
+				return p, nil
+			}
+		}
+	}
+	return leader, nil
+}
+
 // KeyLocation is the region and range that a key is located.
 type KeyLocation struct {
 	Region   RegionVerID
@@ -136,6 +203,31 @@ func (c *RegionCache) LocateKey(bo *Backoffer, key []byte) (*KeyLocation, error)
 	}, nil
 }
 
+// LocateEndKey searches for the region containing the keys just before the key, which is
+// the end key of a range. An empty key means the end of the key space.
+func (c *RegionCache) LocateEndKey(bo *Backoffer, key []byte) (*KeyLocation, error) {
+	loc, err := c.LocateKey(bo, prevKey(key))
+	// The regions between the previous key and the key are skipped.
+	for err == nil && len(loc.EndKey) > 0 && (len(key) == 0 || bytes.Compare(loc.EndKey, key) < 0) {
+		loc, err = c.LocateKey(bo, loc.EndKey)
+	}
+	return loc, errors.Trace(err)
+}
+
+// prevKey returns a key less than the key, it's the greatest one if the key ends with 0.
+func prevKey(key []byte) []byte {
+	if len(key) == 0 {
+		// Any key works, the regions are walked forward to the last one.
+		return []byte{0xff}
+	}
+	if key[len(key)-1] == 0 {
+		return append([]byte(nil), key[:len(key)-1]...)
+	}
+	prev := append([]byte(nil), key...)
+	prev[len(prev)-1]--
+	return prev
+}
+
 // LocateRegionByID searches for the region with ID
 func (c *RegionCache) LocateRegionByID(bo *Backoffer, regionID uint64) (*KeyLocation, error) {
 	c.mu.RLock()
@@ -346,29 +438,49 @@ func (c *RegionCache) loadRegionByID(bo *Backoffer, regionID uint64) (*Region, e
 // GetStoreAddr returns a tikv server's address by its storeID. It checks cache
 // first, sends request to pd server when necessary.
 func (c *RegionCache) GetStoreAddr(bo *Backoffer, id uint64) (string, error) {
+	store, err := c.getStore(bo, id)
+	if err != nil || store == nil {
+		return "", errors.Trace(err)
+	}
+	return store.Addr, nil
+}
+
+// getStore returns a tikv server by its storeID, it returns nil if the store is not
+// found.
+func (c *RegionCache) getStore(bo *Backoffer, id uint64) (*Store, error) {
 	c.storeMu.RLock()
 	if store, ok := c.storeMu.stores[id]; ok {
 		c.storeMu.RUnlock()
-		return store.Addr, nil
+		return store, nil
 	}
 	c.storeMu.RUnlock()
-	return c.ReloadStoreAddr(bo, id)
+	return c.reloadStore(bo, id)
 }
 
 // ReloadStoreAddr reloads store's address.
 func (c *RegionCache) ReloadStoreAddr(bo *Backoffer, id uint64) (string, error) {
-	addr, err := c.loadStoreAddr(bo, id)
-	if err != nil || addr == "" {
+	store, err := c.reloadStore(bo, id)
+	if err != nil || store == nil {
 		return "", errors.Trace(err)
 	}
+	return store.Addr, nil
+}
+
+func (c *RegionCache) reloadStore(bo *Backoffer, id uint64) (*Store, error) {
+	meta, err := c.loadStore(bo, id)
+	if err != nil || meta.GetAddress() == "" {
+		return nil, errors.Trace(err)
+	}
 
+	store := &Store{
+		ID:     id,
+		Addr:   meta.GetAddress(),
+		Labels: meta.GetLabels(),
+	}
 	c.storeMu.Lock()
 	defer c.storeMu.Unlock()
-	c.storeMu.stores[id] = &Store{
-		ID:   id,
-		Addr: addr,
-	}
-	return addr, nil
+	c.storeMu.stores[id] = store
+	return store, nil
 }
 
 // ClearStoreByID clears store from cache with storeID.
@@ -378,23 +490,20 @@ func (c *RegionCache) ClearStoreByID(id uint64) {
 	delete(c.storeMu.stores, id)
 }
 
-func (c *RegionCache) loadStoreAddr(bo *Backoffer, id uint64) (string, error) {
+func (c *RegionCache) loadStore(bo *Backoffer, id uint64) (*metapb.Store, error) {
 	for {
 		store, err := c.pdClient.GetStore(bo.ctx, id)
 		if err != nil {
 			if errors.Cause(err) == goctx.Canceled {
-				return "", errors.Trace(err)
+				return nil, errors.Trace(err)
 			}
 			err = errors.Errorf("loadStore from PD failed, id: %d, err: %v", id, err)
 			if err = bo.Backoff(boPDRPC, err); err != nil {
-				return "", errors.Trace(err)
+				return nil, errors.Trace(err)
 			}
 			continue
 		}
-		if store == nil {
-			return "", nil
-		}
-		return store.GetAddress(), nil
+		return store, nil
 	}
 }
 
@@ -540,15 +649,16 @@ func (r *Region) GetContext() *kvrpcpb.Context {
 // It returns false if all peers are unreachable.
 func (r *Region) OnRequestFail(storeID uint64) bool {
 	if r.peer.GetStoreId() != storeID {
+		// A follower fails, it's skipped by the following replica reads.
+		if !r.isUnreachable(storeID) {
+			r.unreachableStores = append(r.unreachableStores, storeID)
+		}
 		return true
 	}
 	r.unreachableStores = append(r.unreachableStores, storeID)
-L:
 	for _, p := range r.meta.Peers {
-		for _, id := range r.unreachableStores {
-			if p.GetStoreId() == id {
-				continue L
-			}
+		if r.isUnreachable(p.GetStoreId()) {
+			continue
 		}
 		r.peer = p
 		return true
@@ -556,6 +666,26 @@ L:
 	return false
 }
 
+func (r *Region) isUnreachable(storeID uint64) bool {
+	for _, id := range r.unreachableStores {
+		if id == storeID {
+			return true
+		}
+	}
+	return false
+}
+
+// followers returns the reachable peers except the leader.
+func (r *Region) followers() []*metapb.Peer {
+	var peers []*metapb.Peer
+	for _, p := range r.meta.Peers {
+		if p.GetStoreId() != r.peer.GetStoreId() && !r.isUnreachable(p.GetStoreId()) {
+			peers = append(peers, p)
+		}
+	}
+	return peers
+}
+
 // SwitchPeer switches current peer to the one on specific store. It returns
 // false if no peer matches the storeID.
 func (r *Region) SwitchPeer(storeID uint64) bool {
@@ -575,8 +705,26 @@ func (r *Region) Contains(key []byte) bool {
 		(bytes.Compare(key, r.meta.GetEndKey()) < 0 || len(r.meta.GetEndKey()) == 0)
 }
 
-// Store contains a tikv server's address.
+// Store contains a tikv server's address and labels.
 type Store struct {
-	ID   uint64
-	Addr string
+	ID     uint64
+	Addr   string
+	Labels []*metapb.StoreLabel
+}
+
+// matchLabels checks if the store has all the labels.
+func (s *Store) matchLabels(labels map[string]string) bool {
+	for k, v := range labels {
+		matched := false
+		for _, l := range s.Labels {
+			if l.GetKey() == k && l.GetValue() == v {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
 }
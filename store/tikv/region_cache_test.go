@@ -19,6 +19,8 @@ import (
 	"time"
 
 	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/store/tikv/mock-tikv"
 	goctx "golang.org/x/net/context"
 )
@@ -70,7 +72,7 @@ func (s *testRegionCacheSuite) getRegion(c *C, key []byte) *Region {
 func (s *testRegionCacheSuite) getAddr(c *C, key []byte) string {
 	loc, err := s.cache.LocateKey(s.bo, key)
 	c.Assert(err, IsNil)
-	ctx, err := s.cache.GetRPCContext(s.bo, loc.Region)
+	ctx, err := s.cache.GetRPCContext(s.bo, loc.Region, kv.ReplicaReadLeader, 0)
 	c.Assert(err, IsNil)
 	if ctx == nil {
 		return ""
@@ -91,7 +93,7 @@ func (s *testRegionCacheSuite) TestDropStore(c *C) {
 	s.cluster.RemoveStore(s.store1)
 	loc, err := s.cache.LocateKey(bo, []byte("a"))
 	c.Assert(err, IsNil)
-	ctx, err := s.cache.GetRPCContext(bo, loc.Region)
+	ctx, err := s.cache.GetRPCContext(bo, loc.Region, kv.ReplicaReadLeader, 0)
 	c.Assert(err, IsNil)
 	c.Assert(ctx, IsNil)
 	s.checkCache(c, 0)
@@ -243,12 +245,12 @@ func (s *testRegionCacheSuite) TestRequestFail(c *C) {
 	region := s.getRegion(c, []byte("a"))
 	c.Assert(region.unreachableStores, HasLen, 0)
 
-	ctx, _ := s.cache.GetRPCContext(s.bo, region.VerID())
+	ctx, _ := s.cache.GetRPCContext(s.bo, region.VerID(), kv.ReplicaReadLeader, 0)
 	s.cache.OnRequestFail(ctx, errors.New("test error"))
 	region = s.getRegion(c, []byte("a"))
 	c.Assert(region.unreachableStores, DeepEquals, []uint64{s.store1})
 
-	ctx, _ = s.cache.GetRPCContext(s.bo, region.VerID())
+	ctx, _ = s.cache.GetRPCContext(s.bo, region.VerID(), kv.ReplicaReadLeader, 0)
 	s.cache.OnRequestFail(ctx, errors.New("test error"))
 	region = s.getRegion(c, []byte("a"))
 	// Out of range of Peers, so get Region again and pick Stores[0] as leader.
@@ -270,7 +272,7 @@ func (s *testRegionCacheSuite) TestRequestFail2(c *C) {
 	c.Assert(loc2.Region.id, Equals, region2)
 
 	// Request should fail on region1.
-	ctx, _ := s.cache.GetRPCContext(s.bo, loc1.Region)
+	ctx, _ := s.cache.GetRPCContext(s.bo, loc1.Region, kv.ReplicaReadLeader, 0)
 	c.Assert(s.cache.storeMu.stores, HasLen, 1)
 	s.checkCache(c, 2)
 	s.cache.OnRequestFail(ctx, errors.New("test error"))
@@ -280,6 +282,43 @@ func (s *testRegionCacheSuite) TestRequestFail2(c *C) {
 	s.checkCache(c, 1)
 }
 
+func (s *testRegionCacheSuite) TestReplicaRead(c *C) {
+	loc, err := s.cache.LocateKey(s.bo, []byte("a"))
+	c.Assert(err, IsNil)
+	checkReplica := func(replicaRead kv.ReplicaReadType, seed uint32, storeID uint64) {
+		ctx, err := s.cache.GetRPCContext(s.bo, loc.Region, replicaRead, seed)
+		c.Assert(err, IsNil)
+		c.Assert(ctx.Addr, Equals, s.storeAddr(storeID))
+		c.Assert(ctx.GetStoreID(), Equals, storeID)
+		c.Assert(ctx.ReplicaRead, Equals, storeID != s.store1)
+	}
+
+	checkReplica(kv.ReplicaReadLeader, 1, s.store1)
+	checkReplica(kv.ReplicaReadFollower, 0, s.store2)
+	checkReplica(kv.ReplicaReadFollower, 1, s.store2)
+	checkReplica(kv.ReplicaReadMixed, 0, s.store1)
+	checkReplica(kv.ReplicaReadMixed, 1, s.store2)
+
+	// The closest replica is selected by the labels.
+	defer func(labels map[string]string) { LocalLabels = labels }(LocalLabels)
+	checkReplica(kv.ReplicaReadClosest, 0, s.store1)
+	s.cluster.UpdateStoreLabels(s.store2, []*metapb.StoreLabel{{Key: "zone", Value: "z2"}})
+	s.cache.ClearStoreByID(s.store2)
+	LocalLabels = map[string]string{"zone": "z2"}
+	checkReplica(kv.ReplicaReadClosest, 0, s.store2)
+	LocalLabels = map[string]string{"zone": "z3"}
+	checkReplica(kv.ReplicaReadClosest, 0, s.store1)
+
+	// The failed follower is skipped, the leader serves the follower reads.
+	ctx, err := s.cache.GetRPCContext(s.bo, loc.Region, kv.ReplicaReadFollower, 0)
+	c.Assert(err, IsNil)
+	s.cache.OnRequestFail(ctx, errors.New("test error"))
+	region := s.getRegion(c, []byte("a"))
+	c.Assert(region.unreachableStores, DeepEquals, []uint64{s.store2})
+	c.Assert(region.peer.GetStoreId(), Equals, s.store1)
+	checkReplica(kv.ReplicaReadFollower, 0, s.store1)
+}
+
 func (s *testRegionCacheSuite) TestUpdateStoreAddr(c *C) {
 	client := &RawKVClient{
 		clusterID:   0,
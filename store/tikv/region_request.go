@@ -14,6 +14,7 @@
 package tikv
 
 import (
+	"math/rand"
 	"time"
 
 	log "github.com/Sirupsen/logrus"
@@ -47,21 +48,28 @@ type RegionRequestSender struct {
 	client         Client
 	isolationLevel kvrpcpb.IsolationLevel
 	storeAddr      string
+	// replicaRead is the type of the replicas the requests are sent to, it's only
+	// set for the read requests.
+	replicaRead kv.ReplicaReadType
+	// replicaReadSeed picks the follower, it's changed when the follower fails so
+	// another one is tried.
+	replicaReadSeed uint32
 }
 
 // NewRegionRequestSender creates a new sender.
 func NewRegionRequestSender(regionCache *RegionCache, client Client, isolationLevel kvrpcpb.IsolationLevel) *RegionRequestSender {
 	return &RegionRequestSender{
-		regionCache:    regionCache,
-		client:         client,
-		isolationLevel: isolationLevel,
+		regionCache:     regionCache,
+		client:          client,
+		isolationLevel:  isolationLevel,
+		replicaReadSeed: rand.Uint32(),
 	}
 }
 
 // SendReq sends a request to tikv server.
 func (s *RegionRequestSender) SendReq(bo *Backoffer, req *tikvrpc.Request, regionID RegionVerID, timeout time.Duration) (*tikvrpc.Response, error) {
 	for {
-		ctx, err := s.regionCache.GetRPCContext(bo, regionID)
+		ctx, err := s.regionCache.GetRPCContext(bo, regionID, s.replicaRead, s.replicaReadSeed)
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
@@ -77,6 +85,7 @@ func (s *RegionRequestSender) SendReq(bo *Backoffer, req *tikvrpc.Request, regio
 
 		s.storeAddr = ctx.Addr
 		ctx.KVCtx.IsolationLevel = s.isolationLevel
+		req.ReplicaRead = ctx.ReplicaRead
 		resp, retry, err := s.sendReqToRegion(bo, ctx, req, timeout)
 		if err != nil {
 			return nil, errors.Trace(err)
@@ -110,6 +119,10 @@ func (s *RegionRequestSender) sendReqToRegion(bo *Backoffer, ctx *RPCContext, re
 	defer cancel()
 	resp, err = s.client.SendReq(context, ctx.Addr, req)
 	if err != nil {
+		// The request never reaches TiKV, retrying doesn't help.
+		if errors.Cause(err) == errUnsupportedCmd {
+			return nil, false, errors.Trace(err)
+		}
 		if e := s.onSendFail(bo, ctx, err); e != nil {
 			return nil, false, errors.Trace(e)
 		}
@@ -136,6 +149,11 @@ func (s *RegionRequestSender) onSendFail(bo *Backoffer, ctx *RPCContext, err err
 	}
 
 	s.regionCache.OnRequestFail(ctx, err)
+	if ctx.ReplicaRead {
+		// The failed follower is skipped, try another replica without backoff.
+		s.replicaReadSeed++
+		return nil
+	}
 
 	// Retry on request failure when it's not canceled.
 	// When a store is not available, the leader of related region should be elected quickly.
@@ -148,11 +166,18 @@ func (s *RegionRequestSender) onSendFail(bo *Backoffer, ctx *RPCContext, err err
 func (s *RegionRequestSender) onRegionError(bo *Backoffer, ctx *RPCContext, regionErr *errorpb.Error) (retry bool, err error) {
 	reportRegionError(regionErr)
 	if notLeader := regionErr.GetNotLeader(); notLeader != nil {
+		if ctx.ReplicaRead {
+			// The follower doesn't serve the read, e.g. the storage doesn't support
+			// replica read. The read falls back to the leader.
+			log.Debugf("tikv reports `NotLeader` for replica read: %s, ctx: %s, retry on leader", notLeader, ctx.KVCtx)
+			s.replicaRead = kv.ReplicaReadLeader
+			return true, nil
+		}
 		// Retry if error is `NotLeader`.
 		log.Debugf("tikv reports `NotLeader`: %s, ctx: %s, retry later", notLeader, ctx.KVCtx)
 		s.regionCache.UpdateLeader(ctx.Region, notLeader.GetLeader().GetStoreId())
 		if notLeader.GetLeader() == nil {
-			err = bo.Backoff(boRegionMiss, errors.Errorf("not leader: %v, ctx: %s", notLeader, ctx.KVCtx))
+			err = bo.Backoff(BoRegionMiss, errors.Errorf("not leader: %v, ctx: %s", notLeader, ctx.KVCtx))
 			if err != nil {
 				return false, errors.Trace(err)
 			}
@@ -173,6 +198,10 @@ func (s *RegionRequestSender) onRegionError(bo *Backoffer, ctx *RPCContext, regi
 		return false, errors.Trace(err)
 	}
 	if regionErr.GetServerIsBusy() != nil {
+		if ctx.ReplicaRead {
+			// Another replica may be less busy.
+			s.replicaReadSeed++
+		}
 		log.Warnf("tikv reports `ServerIsBusy`, reason: %s, ctx: %s, retry later", regionErr.GetServerIsBusy().GetReason(), ctx.KVCtx)
 		err = bo.Backoff(boServerBusy, errors.Errorf("server is busy, ctx: %s", ctx.KVCtx))
 		if err != nil {
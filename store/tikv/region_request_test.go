@@ -14,6 +14,7 @@
 package tikv
 
 import (
+	"fmt"
 	"net"
 	"strings"
 	"sync"
@@ -24,6 +25,7 @@ import (
 	"github.com/pingcap/kvproto/pkg/coprocessor"
 	"github.com/pingcap/kvproto/pkg/kvrpcpb"
 	"github.com/pingcap/kvproto/pkg/tikvpb"
+	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/store/tikv/mock-tikv"
 	"github.com/pingcap/tidb/store/tikv/tikvrpc"
 	goctx "golang.org/x/net/context"
@@ -88,6 +90,55 @@ func (s *testRegionRequestSuite) TestOnSendFailedWithStoreRestart(c *C) {
 	c.Assert(resp.RawPut, NotNil)
 }
 
+// noReplicaReadClient simulates a storage that doesn't support replica read.
+type noReplicaReadClient struct {
+	Client
+}
+
+func (c *noReplicaReadClient) SendReq(ctx goctx.Context, addr string, req *tikvrpc.Request) (*tikvrpc.Response, error) {
+	r := *req
+	r.ReplicaRead = false
+	return c.Client.SendReq(ctx, addr, &r)
+}
+
+func (s *testRegionRequestSuite) TestReplicaRead(c *C) {
+	ids := s.cluster.AllocIDs(2)
+	follower := ids[0]
+	s.cluster.AddStore(follower, "follower")
+	s.cluster.AddPeer(s.region, follower, ids[1])
+	region, err := s.cache.LocateRegionByID(s.bo, s.region)
+	c.Assert(err, IsNil)
+	req := &tikvrpc.Request{
+		Type:   tikvrpc.CmdRawGet,
+		RawGet: &kvrpcpb.RawGetRequest{Key: []byte("key")},
+	}
+
+	sender := s.regionRequestSender
+	sender.replicaRead = kv.ReplicaReadFollower
+	resp, err := sender.SendReq(s.bo, req, region.Region, time.Second)
+	c.Assert(err, IsNil)
+	c.Assert(resp.RawGet, NotNil)
+	c.Assert(sender.storeAddr, Equals, "follower")
+
+	// The read falls back to the leader if the follower rejects it.
+	sender = NewRegionRequestSender(s.cache, &noReplicaReadClient{mocktikv.NewRPCClient(s.cluster, s.mvccStore)}, kvrpcpb.IsolationLevel_SI)
+	sender.replicaRead = kv.ReplicaReadFollower
+	resp, err = sender.SendReq(s.bo, req, region.Region, time.Second)
+	c.Assert(err, IsNil)
+	c.Assert(resp.RawGet, NotNil)
+	c.Assert(sender.storeAddr, Equals, fmt.Sprintf("store%d", s.store))
+	c.Assert(sender.replicaRead, Equals, kv.ReplicaReadLeader)
+
+	// The read is sent to the leader if the follower fails.
+	s.cluster.StopStore(follower)
+	sender = s.regionRequestSender
+	sender.replicaRead = kv.ReplicaReadFollower
+	resp, err = sender.SendReq(s.bo, req, region.Region, time.Second)
+	c.Assert(err, IsNil)
+	c.Assert(resp.RawGet, NotNil)
+	c.Assert(sender.storeAddr, Equals, fmt.Sprintf("store%d", s.store))
+}
+
 func (s *testRegionRequestSuite) TestOnSendFailedWithCancelled(c *C) {
 	req := &tikvrpc.Request{
 		Type: tikvrpc.CmdRawPut,
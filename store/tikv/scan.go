@@ -131,7 +131,7 @@ func (s *Scanner) resolveCurrentLock(bo *Backoffer) error {
 
 func (s *Scanner) getData(bo *Backoffer) error {
 	log.Debugf("txn getData nextStartKey[%q], txn %d", s.nextStartKey, s.startTS())
-	sender := NewRegionRequestSender(s.snapshot.store.regionCache, s.snapshot.store.client, pbIsolationLevel(s.snapshot.isolationLevel))
+	sender := s.snapshot.newRequestSender()
 
 	for {
 		loc, err := s.snapshot.store.regionCache.LocateKey(bo, s.nextStartKey)
@@ -147,7 +147,7 @@ func (s *Scanner) getData(bo *Backoffer) error {
 				Version:  s.startTS(),
 			},
 		}
-		resp, err := sender.SendReq(bo, req, loc.Region, readTimeoutMedium)
+		resp, err := sender.SendReq(bo, req, loc.Region, ReadTimeoutMedium)
 		if err != nil {
 			return errors.Trace(err)
 		}
@@ -157,7 +157,7 @@ func (s *Scanner) getData(bo *Backoffer) error {
 		}
 		if regionErr != nil {
 			log.Debugf("scanner getData failed: %s", regionErr)
-			err = bo.Backoff(boRegionMiss, errors.New(regionErr.String()))
+			err = bo.Backoff(BoRegionMiss, errors.New(regionErr.String()))
 			if err != nil {
 				return errors.Trace(err)
 			}
@@ -165,7 +165,7 @@ func (s *Scanner) getData(bo *Backoffer) error {
 		}
 		cmdScanResp := resp.Scan
 		if cmdScanResp == nil {
-			return errors.Trace(errBodyMissing)
+			return errors.Trace(ErrBodyMissing)
 		}
 
 		kvPairs := cmdScanResp.Pairs
@@ -0,0 +1,173 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tikvrpc
+
+import (
+	"github.com/pingcap/kvproto/pkg/errorpb"
+	"github.com/pingcap/kvproto/pkg/kvrpcpb"
+)
+
+// The kvproto we depend on doesn't define the messages of async commit and one-phase
+// commit, so they are defined here. Only the mock-tikv handles them for now.
+
+// AsyncPrewriteRequest is a PrewriteRequest with the fields of async commit and
+// one-phase commit.
+type AsyncPrewriteRequest struct {
+	Context      *kvrpcpb.Context
+	Mutations    []*kvrpcpb.Mutation
+	PrimaryLock  []byte
+	StartVersion uint64
+	LockTtl      uint64
+	// UseAsyncCommit makes the locks async commit locks, the transaction is committed
+	// once all the keys are prewritten.
+	UseAsyncCommit bool
+	// Secondaries are the other keys of an async commit transaction, they are only set
+	// in the request containing the primary key.
+	Secondaries [][]byte
+	// TryOnePc commits the mutations directly if they are all the mutations of the
+	// transaction.
+	TryOnePc bool
+	// MinCommitTs is the lower bound of the commit ts the server calculates.
+	MinCommitTs uint64
+	// MaxCommitTs is the upper bound of the commit ts, the request fails if the commit
+	// ts exceeds it.
+	MaxCommitTs uint64
+}
+
+// GetContext returns the rpc context of the request.
+func (m *AsyncPrewriteRequest) GetContext() *kvrpcpb.Context {
+	if m != nil {
+		return m.Context
+	}
+	return nil
+}
+
+// Size returns the approximate size of the request.
+func (m *AsyncPrewriteRequest) Size() int {
+	n := len(m.PrimaryLock) + 40
+	for _, mut := range m.Mutations {
+		n += mut.Size()
+	}
+	for _, k := range m.Secondaries {
+		n += len(k)
+	}
+	return n
+}
+
+// AsyncPrewriteResponse is the response of AsyncPrewriteRequest.
+type AsyncPrewriteResponse struct {
+	RegionError *errorpb.Error
+	Errors      []*kvrpcpb.KeyError
+	// MinCommitTs is the min commit ts of the async commit locks, the commit ts of
+	// the transaction is the max of all the MinCommitTs.
+	MinCommitTs uint64
+	// OnePcCommitTs is the commit ts of the transaction if it's committed by 1PC.
+	OnePcCommitTs uint64
+}
+
+// GetRegionError returns the region error of the response.
+func (m *AsyncPrewriteResponse) GetRegionError() *errorpb.Error {
+	if m != nil {
+		return m.RegionError
+	}
+	return nil
+}
+
+// CheckTxnStatusRequest queries the status of a transaction by its primary key, the
+// primary lock is rolled back if the transaction isn't prewritten on it.
+type CheckTxnStatusRequest struct {
+	Context    *kvrpcpb.Context
+	PrimaryKey []byte
+	LockTs     uint64
+}
+
+// GetContext returns the rpc context of the request.
+func (m *CheckTxnStatusRequest) GetContext() *kvrpcpb.Context {
+	if m != nil {
+		return m.Context
+	}
+	return nil
+}
+
+// Size returns the approximate size of the request.
+func (m *CheckTxnStatusRequest) Size() int {
+	return len(m.PrimaryKey) + 8
+}
+
+// CheckTxnStatusResponse is the response of CheckTxnStatusRequest.
+type CheckTxnStatusResponse struct {
+	RegionError *errorpb.Error
+	Error       *kvrpcpb.KeyError
+	// CommitVersion is the commit ts if the transaction is committed.
+	CommitVersion uint64
+	// LockTtl is the ttl of the primary lock if it's still locked, the other fields
+	// describe the lock then.
+	LockTtl        uint64
+	UseAsyncCommit bool
+	Secondaries    [][]byte
+	MinCommitTs    uint64
+}
+
+// GetRegionError returns the region error of the response.
+func (m *CheckTxnStatusResponse) GetRegionError() *errorpb.Error {
+	if m != nil {
+		return m.RegionError
+	}
+	return nil
+}
+
+// CheckSecondaryLocksRequest checks the secondary locks of an async commit transaction,
+// a key not locked by the transaction is rolled back so it can't be prewritten later.
+type CheckSecondaryLocksRequest struct {
+	Context      *kvrpcpb.Context
+	Keys         [][]byte
+	StartVersion uint64
+}
+
+// GetContext returns the rpc context of the request.
+func (m *CheckSecondaryLocksRequest) GetContext() *kvrpcpb.Context {
+	if m != nil {
+		return m.Context
+	}
+	return nil
+}
+
+// Size returns the approximate size of the request.
+func (m *CheckSecondaryLocksRequest) Size() int {
+	n := 8
+	for _, k := range m.Keys {
+		n += len(k)
+	}
+	return n
+}
+
+// CheckSecondaryLocksResponse is the response of CheckSecondaryLocksRequest.
+type CheckSecondaryLocksResponse struct {
+	RegionError *errorpb.Error
+	Error       *kvrpcpb.KeyError
+	// Locks are the locks of the transaction on the keys.
+	Locks []*kvrpcpb.LockInfo
+	// MinCommitTs is the max min commit ts of Locks.
+	MinCommitTs uint64
+	// CommitTs is the commit ts of the transaction if any of the keys is committed.
+	CommitTs uint64
+}
+
+// GetRegionError returns the region error of the response.
+func (m *CheckSecondaryLocksResponse) GetRegionError() *errorpb.Error {
+	if m != nil {
+		return m.RegionError
+	}
+	return nil
+}
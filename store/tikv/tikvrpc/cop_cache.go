@@ -0,0 +1,35 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tikvrpc
+
+// CopCacheRequest asks the storage to report the data version of the region with a
+// coprocessor request, so the client can cache the response. The coprocessor.Request
+// in use has no such fields, so TiKV ignores it and only mock-tikv reports the versions.
+type CopCacheRequest struct {
+	// CacheIfMatchVersion is the data version of the response cached by the client, the
+	// storage returns a cache hit without executing the request if the data of the region
+	// isn't changed since. It's 0 if there is no cached response.
+	CacheIfMatchVersion uint64
+}
+
+// CopCacheResponse is the data version part of a coprocessor response.
+type CopCacheResponse struct {
+	// IsCacheHit is set if the cached response of the client is still valid, the response
+	// has no data then.
+	IsCacheHit bool
+	// CanBeCached is set if the response stays valid until the data version changes.
+	CanBeCached bool
+	// CacheLastVersion is the data version of the region when the request is executed.
+	CacheLastVersion uint64
+}
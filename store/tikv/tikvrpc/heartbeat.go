@@ -0,0 +1,60 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tikvrpc
+
+import (
+	"github.com/pingcap/kvproto/pkg/errorpb"
+	"github.com/pingcap/kvproto/pkg/kvrpcpb"
+)
+
+// TxnHeartBeatRequest extends the ttl of the primary lock of a transaction, so the
+// locks of a long running transaction are not resolved by others. Like the pessimistic
+// lock commands, it's only handled by the mock-tikv for now.
+type TxnHeartBeatRequest struct {
+	Context      *kvrpcpb.Context
+	PrimaryLock  []byte
+	StartVersion uint64
+	// AdviseLockTtl is the new ttl in milliseconds, it's ignored if it's less than
+	// the current one.
+	AdviseLockTtl uint64
+}
+
+// GetContext returns the rpc context of the request.
+func (m *TxnHeartBeatRequest) GetContext() *kvrpcpb.Context {
+	if m != nil {
+		return m.Context
+	}
+	return nil
+}
+
+// Size returns the approximate size of the request.
+func (m *TxnHeartBeatRequest) Size() int {
+	return len(m.PrimaryLock) + 16
+}
+
+// TxnHeartBeatResponse is the response of TxnHeartBeatRequest.
+type TxnHeartBeatResponse struct {
+	RegionError *errorpb.Error
+	Error       *kvrpcpb.KeyError
+	// LockTtl is the ttl of the primary lock after the request.
+	LockTtl uint64
+}
+
+// GetRegionError returns the region error of the response.
+func (m *TxnHeartBeatResponse) GetRegionError() *errorpb.Error {
+	if m != nil {
+		return m.RegionError
+	}
+	return nil
+}
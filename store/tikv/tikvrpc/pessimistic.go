@@ -0,0 +1,116 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tikvrpc
+
+import (
+	"github.com/pingcap/kvproto/pkg/errorpb"
+	"github.com/pingcap/kvproto/pkg/kvrpcpb"
+)
+
+// The kvproto we depend on doesn't define the messages of the pessimistic lock commands,
+// so they are defined here. Only the mock-tikv handles them for now.
+
+// PessimisticLockRequest acquires the pessimistic locks of keys for a transaction.
+type PessimisticLockRequest struct {
+	Context      *kvrpcpb.Context
+	Keys         [][]byte
+	PrimaryLock  []byte
+	StartVersion uint64
+	// ForUpdateTs is the ts the statement reads data at. A write committed after it
+	// is a write conflict.
+	ForUpdateTs uint64
+	LockTtl     uint64
+	// WaitTimeout is the max time in milliseconds to wait for the locks held by other
+	// transactions, 0 means no wait.
+	WaitTimeout int64
+}
+
+// GetContext returns the rpc context of the request.
+func (m *PessimisticLockRequest) GetContext() *kvrpcpb.Context {
+	if m != nil {
+		return m.Context
+	}
+	return nil
+}
+
+// Size returns the approximate size of the request.
+func (m *PessimisticLockRequest) Size() int {
+	n := len(m.PrimaryLock) + 32
+	for _, k := range m.Keys {
+		n += len(k)
+	}
+	return n
+}
+
+// Deadlock is returned when the transaction waiting for a lock forms a cycle in
+// the wait-for graph.
+type Deadlock struct {
+	LockTs          uint64
+	LockKey         []byte
+	DeadlockKeyHash uint64
+}
+
+// PessimisticLockResponse is the response of PessimisticLockRequest.
+type PessimisticLockResponse struct {
+	RegionError *errorpb.Error
+	Errors      []*kvrpcpb.KeyError
+	Deadlock    *Deadlock
+}
+
+// GetRegionError returns the region error of the response.
+func (m *PessimisticLockResponse) GetRegionError() *errorpb.Error {
+	if m != nil {
+		return m.RegionError
+	}
+	return nil
+}
+
+// PessimisticRollbackRequest releases the pessimistic locks of keys for a transaction.
+type PessimisticRollbackRequest struct {
+	Context      *kvrpcpb.Context
+	Keys         [][]byte
+	StartVersion uint64
+	ForUpdateTs  uint64
+}
+
+// GetContext returns the rpc context of the request.
+func (m *PessimisticRollbackRequest) GetContext() *kvrpcpb.Context {
+	if m != nil {
+		return m.Context
+	}
+	return nil
+}
+
+// Size returns the approximate size of the request.
+func (m *PessimisticRollbackRequest) Size() int {
+	n := 16
+	for _, k := range m.Keys {
+		n += len(k)
+	}
+	return n
+}
+
+// PessimisticRollbackResponse is the response of PessimisticRollbackRequest.
+type PessimisticRollbackResponse struct {
+	RegionError *errorpb.Error
+	Errors      []*kvrpcpb.KeyError
+}
+
+// GetRegionError returns the region error of the response.
+func (m *PessimisticRollbackResponse) GetRegionError() *errorpb.Error {
+	if m != nil {
+		return m.RegionError
+	}
+	return nil
+}
@@ -0,0 +1,289 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tikvrpc
+
+import (
+	"github.com/pingcap/kvproto/pkg/errorpb"
+	"github.com/pingcap/kvproto/pkg/kvrpcpb"
+)
+
+// The raw commands below are not in the kvrpcpb in use, like the pessimistic lock
+// commands they are only handled by the mock-tikv for now. The keys of a request must
+// be in the region of its context.
+
+// RawBatchGetRequest gets the values of the keys.
+type RawBatchGetRequest struct {
+	Context *kvrpcpb.Context
+	Keys    [][]byte
+}
+
+// GetContext returns the rpc context of the request.
+func (m *RawBatchGetRequest) GetContext() *kvrpcpb.Context {
+	if m != nil {
+		return m.Context
+	}
+	return nil
+}
+
+// Size returns the approximate size of the request.
+func (m *RawBatchGetRequest) Size() int {
+	var size int
+	for _, k := range m.Keys {
+		size += len(k)
+	}
+	return size
+}
+
+// RawBatchGetResponse is the response of RawBatchGetRequest, the keys not found are
+// not in the pairs.
+type RawBatchGetResponse struct {
+	RegionError *errorpb.Error
+	Pairs       []*kvrpcpb.KvPair
+}
+
+// GetRegionError returns the region error of the response.
+func (m *RawBatchGetResponse) GetRegionError() *errorpb.Error {
+	if m != nil {
+		return m.RegionError
+	}
+	return nil
+}
+
+// RawBatchPutRequest puts the pairs.
+type RawBatchPutRequest struct {
+	Context *kvrpcpb.Context
+	Pairs   []*kvrpcpb.KvPair
+	// Ttls are the ttls in seconds of the pairs, 0 means the pair never expires. It's
+	// empty if none of the pairs expires.
+	Ttls []uint64
+}
+
+// GetContext returns the rpc context of the request.
+func (m *RawBatchPutRequest) GetContext() *kvrpcpb.Context {
+	if m != nil {
+		return m.Context
+	}
+	return nil
+}
+
+// Size returns the approximate size of the request.
+func (m *RawBatchPutRequest) Size() int {
+	var size int
+	for _, p := range m.Pairs {
+		size += len(p.Key) + len(p.Value)
+	}
+	return size + len(m.Ttls)*8
+}
+
+// RawBatchPutResponse is the response of RawBatchPutRequest.
+type RawBatchPutResponse struct {
+	RegionError *errorpb.Error
+	Error       string
+}
+
+// GetRegionError returns the region error of the response.
+func (m *RawBatchPutResponse) GetRegionError() *errorpb.Error {
+	if m != nil {
+		return m.RegionError
+	}
+	return nil
+}
+
+// RawBatchDeleteRequest deletes the keys.
+type RawBatchDeleteRequest struct {
+	Context *kvrpcpb.Context
+	Keys    [][]byte
+}
+
+// GetContext returns the rpc context of the request.
+func (m *RawBatchDeleteRequest) GetContext() *kvrpcpb.Context {
+	if m != nil {
+		return m.Context
+	}
+	return nil
+}
+
+// Size returns the approximate size of the request.
+func (m *RawBatchDeleteRequest) Size() int {
+	var size int
+	for _, k := range m.Keys {
+		size += len(k)
+	}
+	return size
+}
+
+// RawBatchDeleteResponse is the response of RawBatchDeleteRequest.
+type RawBatchDeleteResponse struct {
+	RegionError *errorpb.Error
+	Error       string
+}
+
+// GetRegionError returns the region error of the response.
+func (m *RawBatchDeleteResponse) GetRegionError() *errorpb.Error {
+	if m != nil {
+		return m.RegionError
+	}
+	return nil
+}
+
+// RawDeleteRangeRequest deletes the keys in [StartKey, EndKey) of the region.
+type RawDeleteRangeRequest struct {
+	Context  *kvrpcpb.Context
+	StartKey []byte
+	EndKey   []byte
+}
+
+// GetContext returns the rpc context of the request.
+func (m *RawDeleteRangeRequest) GetContext() *kvrpcpb.Context {
+	if m != nil {
+		return m.Context
+	}
+	return nil
+}
+
+// Size returns the approximate size of the request.
+func (m *RawDeleteRangeRequest) Size() int {
+	return len(m.StartKey) + len(m.EndKey)
+}
+
+// RawDeleteRangeResponse is the response of RawDeleteRangeRequest.
+type RawDeleteRangeResponse struct {
+	RegionError *errorpb.Error
+	Error       string
+}
+
+// GetRegionError returns the region error of the response.
+func (m *RawDeleteRangeResponse) GetRegionError() *errorpb.Error {
+	if m != nil {
+		return m.RegionError
+	}
+	return nil
+}
+
+// RawReverseScanRequest scans the pairs of the region in the descending order, from
+// StartKey (exclusive) down to EndKey (inclusive). An empty StartKey means the end of
+// the region.
+type RawReverseScanRequest struct {
+	Context  *kvrpcpb.Context
+	StartKey []byte
+	EndKey   []byte
+	Limit    uint32
+}
+
+// GetContext returns the rpc context of the request.
+func (m *RawReverseScanRequest) GetContext() *kvrpcpb.Context {
+	if m != nil {
+		return m.Context
+	}
+	return nil
+}
+
+// Size returns the approximate size of the request.
+func (m *RawReverseScanRequest) Size() int {
+	return len(m.StartKey) + len(m.EndKey) + 4
+}
+
+// RawReverseScanResponse is the response of RawReverseScanRequest.
+type RawReverseScanResponse struct {
+	RegionError *errorpb.Error
+	Kvs         []*kvrpcpb.KvPair
+}
+
+// GetRegionError returns the region error of the response.
+func (m *RawReverseScanResponse) GetRegionError() *errorpb.Error {
+	if m != nil {
+		return m.RegionError
+	}
+	return nil
+}
+
+// RawCompareAndSwapRequest puts the value of the key only if its current value equals
+// PreviousValue, or it doesn't exist if PreviousNotExist is set.
+type RawCompareAndSwapRequest struct {
+	Context          *kvrpcpb.Context
+	Key              []byte
+	Value            []byte
+	PreviousValue    []byte
+	PreviousNotExist bool
+	// Ttl is the ttl in seconds of the new value, 0 means it never expires.
+	Ttl uint64
+}
+
+// GetContext returns the rpc context of the request.
+func (m *RawCompareAndSwapRequest) GetContext() *kvrpcpb.Context {
+	if m != nil {
+		return m.Context
+	}
+	return nil
+}
+
+// Size returns the approximate size of the request.
+func (m *RawCompareAndSwapRequest) Size() int {
+	return len(m.Key) + len(m.Value) + len(m.PreviousValue) + 9
+}
+
+// RawCompareAndSwapResponse is the response of RawCompareAndSwapRequest.
+type RawCompareAndSwapResponse struct {
+	RegionError *errorpb.Error
+	Error       string
+	// Succeed is set if the value is put.
+	Succeed bool
+	// PreviousValue and PreviousNotExist are the value of the key before the request.
+	PreviousValue    []byte
+	PreviousNotExist bool
+}
+
+// GetRegionError returns the region error of the response.
+func (m *RawCompareAndSwapResponse) GetRegionError() *errorpb.Error {
+	if m != nil {
+		return m.RegionError
+	}
+	return nil
+}
+
+// RawGetKeyTTLRequest gets the remaining ttl of the key.
+type RawGetKeyTTLRequest struct {
+	Context *kvrpcpb.Context
+	Key     []byte
+}
+
+// GetContext returns the rpc context of the request.
+func (m *RawGetKeyTTLRequest) GetContext() *kvrpcpb.Context {
+	if m != nil {
+		return m.Context
+	}
+	return nil
+}
+
+// Size returns the approximate size of the request.
+func (m *RawGetKeyTTLRequest) Size() int {
+	return len(m.Key)
+}
+
+// RawGetKeyTTLResponse is the response of RawGetKeyTTLRequest.
+type RawGetKeyTTLResponse struct {
+	RegionError *errorpb.Error
+	Error       string
+	// Ttl is the remaining ttl in seconds, 0 means the key never expires.
+	Ttl      uint64
+	NotFound bool
+}
+
+// GetRegionError returns the region error of the response.
+func (m *RawGetKeyTTLResponse) GetRegionError() *errorpb.Error {
+	if m != nil {
+		return m.RegionError
+	}
+	return nil
+}
@@ -37,11 +37,24 @@ const (
 	CmdResolveLock
 	CmdGC
 	CmdDeleteRange
+	CmdPessimisticLock
+	CmdPessimisticRollback
+	CmdTxnHeartBeat
+	CmdAsyncPrewrite
+	CmdCheckTxnStatus
+	CmdCheckSecondaryLocks
 
 	CmdRawGet CmdType = 256 + iota
 	CmdRawPut
 	CmdRawDelete
 	CmdRawScan
+	CmdRawBatchGet
+	CmdRawBatchPut
+	CmdRawBatchDelete
+	CmdRawDeleteRange
+	CmdRawReverseScan
+	CmdRawCompareAndSwap
+	CmdRawGetKeyTTL
 
 	CmdCop CmdType = 512 + iota
 
@@ -71,6 +84,28 @@ type Request struct {
 	Cop              *coprocessor.Request
 	MvccGetByKey     *kvrpcpb.MvccGetByKeyRequest
 	MvccGetByStartTs *kvrpcpb.MvccGetByStartTsRequest
+
+	PessimisticLock     *PessimisticLockRequest
+	PessimisticRollback *PessimisticRollbackRequest
+	TxnHeartBeat        *TxnHeartBeatRequest
+	AsyncPrewrite       *AsyncPrewriteRequest
+	CheckTxnStatus      *CheckTxnStatusRequest
+	CheckSecondaryLocks *CheckSecondaryLocksRequest
+
+	RawBatchGet       *RawBatchGetRequest
+	RawBatchPut       *RawBatchPutRequest
+	RawBatchDelete    *RawBatchDeleteRequest
+	RawDeleteRange    *RawDeleteRangeRequest
+	RawReverseScan    *RawReverseScanRequest
+	RawCompareAndSwap *RawCompareAndSwapRequest
+	RawGetKeyTTL      *RawGetKeyTTLRequest
+
+	// ReplicaRead marks a read request sent to a follower. The kvrpcpb.Context in use
+	// doesn't carry the flag, so TiKV rejects such a request with NotLeader and the read
+	// falls back to the leader, only mock-tikv serves it on the follower.
+	ReplicaRead bool
+	// CopCache asks for the data version of a coprocessor request, see CopCacheRequest.
+	CopCache *CopCacheRequest
 }
 
 // GetContext returns the rpc context for the underlying concrete request.
@@ -113,6 +148,32 @@ func (req *Request) GetContext() (*kvrpcpb.Context, error) {
 		c = req.MvccGetByKey.GetContext()
 	case CmdMvccGetByStartTs:
 		c = req.MvccGetByStartTs.GetContext()
+	case CmdPessimisticLock:
+		c = req.PessimisticLock.GetContext()
+	case CmdPessimisticRollback:
+		c = req.PessimisticRollback.GetContext()
+	case CmdTxnHeartBeat:
+		c = req.TxnHeartBeat.GetContext()
+	case CmdAsyncPrewrite:
+		c = req.AsyncPrewrite.GetContext()
+	case CmdCheckTxnStatus:
+		c = req.CheckTxnStatus.GetContext()
+	case CmdCheckSecondaryLocks:
+		c = req.CheckSecondaryLocks.GetContext()
+	case CmdRawBatchGet:
+		c = req.RawBatchGet.GetContext()
+	case CmdRawBatchPut:
+		c = req.RawBatchPut.GetContext()
+	case CmdRawBatchDelete:
+		c = req.RawBatchDelete.GetContext()
+	case CmdRawDeleteRange:
+		c = req.RawDeleteRange.GetContext()
+	case CmdRawReverseScan:
+		c = req.RawReverseScan.GetContext()
+	case CmdRawCompareAndSwap:
+		c = req.RawCompareAndSwap.GetContext()
+	case CmdRawGetKeyTTL:
+		c = req.RawGetKeyTTL.GetContext()
 	default:
 		return nil, fmt.Errorf("invalid request type %v", req.Type)
 	}
@@ -140,6 +201,25 @@ type Response struct {
 	Cop              *coprocessor.Response
 	MvccGetByKey     *kvrpcpb.MvccGetByKeyResponse
 	MvccGetByStartTS *kvrpcpb.MvccGetByStartTsResponse
+
+	PessimisticLock     *PessimisticLockResponse
+	PessimisticRollback *PessimisticRollbackResponse
+	TxnHeartBeat        *TxnHeartBeatResponse
+	AsyncPrewrite       *AsyncPrewriteResponse
+	CheckTxnStatus      *CheckTxnStatusResponse
+	CheckSecondaryLocks *CheckSecondaryLocksResponse
+
+	RawBatchGet       *RawBatchGetResponse
+	RawBatchPut       *RawBatchPutResponse
+	RawBatchDelete    *RawBatchDeleteResponse
+	RawDeleteRange    *RawDeleteRangeResponse
+	RawReverseScan    *RawReverseScanResponse
+	RawCompareAndSwap *RawCompareAndSwapResponse
+	RawGetKeyTTL      *RawGetKeyTTLResponse
+
+	// CopCache is the data version of a coprocessor response, it's nil if the storage
+	// doesn't report it.
+	CopCache *CopCacheResponse
 }
 
 // SetContext set the Context field for the given req to the specified ctx.
@@ -182,6 +262,32 @@ func SetContext(req *Request, ctx *kvrpcpb.Context) error {
 		req.MvccGetByKey.Context = ctx
 	case CmdMvccGetByStartTs:
 		req.MvccGetByStartTs.Context = ctx
+	case CmdPessimisticLock:
+		req.PessimisticLock.Context = ctx
+	case CmdPessimisticRollback:
+		req.PessimisticRollback.Context = ctx
+	case CmdTxnHeartBeat:
+		req.TxnHeartBeat.Context = ctx
+	case CmdAsyncPrewrite:
+		req.AsyncPrewrite.Context = ctx
+	case CmdCheckTxnStatus:
+		req.CheckTxnStatus.Context = ctx
+	case CmdCheckSecondaryLocks:
+		req.CheckSecondaryLocks.Context = ctx
+	case CmdRawBatchGet:
+		req.RawBatchGet.Context = ctx
+	case CmdRawBatchPut:
+		req.RawBatchPut.Context = ctx
+	case CmdRawBatchDelete:
+		req.RawBatchDelete.Context = ctx
+	case CmdRawDeleteRange:
+		req.RawDeleteRange.Context = ctx
+	case CmdRawReverseScan:
+		req.RawReverseScan.Context = ctx
+	case CmdRawCompareAndSwap:
+		req.RawCompareAndSwap.Context = ctx
+	case CmdRawGetKeyTTL:
+		req.RawGetKeyTTL.Context = ctx
 	default:
 		return fmt.Errorf("invalid request type %v", req.Type)
 	}
@@ -266,6 +372,58 @@ func GenRegionErrorResp(req *Request, e *errorpb.Error) (*Response, error) {
 		resp.MvccGetByStartTS = &kvrpcpb.MvccGetByStartTsResponse{
 			RegionError: e,
 		}
+	case CmdPessimisticLock:
+		resp.PessimisticLock = &PessimisticLockResponse{
+			RegionError: e,
+		}
+	case CmdPessimisticRollback:
+		resp.PessimisticRollback = &PessimisticRollbackResponse{
+			RegionError: e,
+		}
+	case CmdTxnHeartBeat:
+		resp.TxnHeartBeat = &TxnHeartBeatResponse{
+			RegionError: e,
+		}
+	case CmdAsyncPrewrite:
+		resp.AsyncPrewrite = &AsyncPrewriteResponse{
+			RegionError: e,
+		}
+	case CmdCheckTxnStatus:
+		resp.CheckTxnStatus = &CheckTxnStatusResponse{
+			RegionError: e,
+		}
+	case CmdCheckSecondaryLocks:
+		resp.CheckSecondaryLocks = &CheckSecondaryLocksResponse{
+			RegionError: e,
+		}
+	case CmdRawBatchGet:
+		resp.RawBatchGet = &RawBatchGetResponse{
+			RegionError: e,
+		}
+	case CmdRawBatchPut:
+		resp.RawBatchPut = &RawBatchPutResponse{
+			RegionError: e,
+		}
+	case CmdRawBatchDelete:
+		resp.RawBatchDelete = &RawBatchDeleteResponse{
+			RegionError: e,
+		}
+	case CmdRawDeleteRange:
+		resp.RawDeleteRange = &RawDeleteRangeResponse{
+			RegionError: e,
+		}
+	case CmdRawReverseScan:
+		resp.RawReverseScan = &RawReverseScanResponse{
+			RegionError: e,
+		}
+	case CmdRawCompareAndSwap:
+		resp.RawCompareAndSwap = &RawCompareAndSwapResponse{
+			RegionError: e,
+		}
+	case CmdRawGetKeyTTL:
+		resp.RawGetKeyTTL = &RawGetKeyTTLResponse{
+			RegionError: e,
+		}
 	default:
 		return nil, fmt.Errorf("invalid request type %v", req.Type)
 	}
@@ -312,6 +470,32 @@ func (resp *Response) GetRegionError() (*errorpb.Error, error) {
 		e = resp.MvccGetByKey.GetRegionError()
 	case CmdMvccGetByStartTs:
 		e = resp.MvccGetByStartTS.GetRegionError()
+	case CmdPessimisticLock:
+		e = resp.PessimisticLock.GetRegionError()
+	case CmdPessimisticRollback:
+		e = resp.PessimisticRollback.GetRegionError()
+	case CmdTxnHeartBeat:
+		e = resp.TxnHeartBeat.GetRegionError()
+	case CmdAsyncPrewrite:
+		e = resp.AsyncPrewrite.GetRegionError()
+	case CmdCheckTxnStatus:
+		e = resp.CheckTxnStatus.GetRegionError()
+	case CmdCheckSecondaryLocks:
+		e = resp.CheckSecondaryLocks.GetRegionError()
+	case CmdRawBatchGet:
+		e = resp.RawBatchGet.GetRegionError()
+	case CmdRawBatchPut:
+		e = resp.RawBatchPut.GetRegionError()
+	case CmdRawBatchDelete:
+		e = resp.RawBatchDelete.GetRegionError()
+	case CmdRawDeleteRange:
+		e = resp.RawDeleteRange.GetRegionError()
+	case CmdRawReverseScan:
+		e = resp.RawReverseScan.GetRegionError()
+	case CmdRawCompareAndSwap:
+		e = resp.RawCompareAndSwap.GetRegionError()
+	case CmdRawGetKeyTTL:
+		e = resp.RawGetKeyTTL.GetRegionError()
 	default:
 		return nil, fmt.Errorf("invalid response type %v", resp.Type)
 	}
@@ -0,0 +1,156 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tikv
+
+import (
+	"sync/atomic"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/coreos/etcd/pkg/monotime"
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/store/tikv/tikvrpc"
+	goctx "golang.org/x/net/context"
+)
+
+// managedLockTTL is the ttl (in ms) a heartbeat keeps the primary lock alive for.
+const managedLockTTL = 20000
+
+// ttlManagedTxnSize is the size of a transaction above which its primary lock is
+// kept alive during the commit, because prewriting the keys may take longer than
+// the lock ttl.
+const ttlManagedTxnSize = 16 * 1024 * 1024
+
+const txnHeartBeatMaxBackoff = 10000
+
+// ttlManagerInterval is the interval of the heartbeats, it's a variable for tests.
+var ttlManagerInterval = 10 * time.Second
+
+const (
+	stateUninitialized uint32 = iota
+	stateRunning
+	stateClosed
+)
+
+// ttlManager keeps the primary lock of a transaction alive by extending its ttl
+// periodically, so the locks of a long running transaction are not resolved by
+// others. It's started when the primary key is locked, and closed when the
+// transaction is committed or rolled back.
+//
+// The vendored kvproto has no heartbeat command, so it only runs on mock-tikv.
+// On TiKV the locks expire after the ttl set when they are written.
+type ttlManager struct {
+	state uint32
+	ch    chan struct{}
+}
+
+// supportTxnHeartBeat checks whether the store serves the heartbeats of the transactions.
+func supportTxnHeartBeat(store *tikvStore) bool {
+	return store.mock
+}
+
+func (tm *ttlManager) run(txn *tikvTxn, primary []byte) {
+	if !supportTxnHeartBeat(txn.store) {
+		return
+	}
+	if !atomic.CompareAndSwapUint32(&tm.state, stateUninitialized, stateRunning) {
+		return
+	}
+	tm.ch = make(chan struct{})
+	go tm.keepAlive(txn, primary, tm.ch)
+}
+
+func (tm *ttlManager) close() {
+	if !atomic.CompareAndSwapUint32(&tm.state, stateRunning, stateClosed) {
+		return
+	}
+	close(tm.ch)
+}
+
+func (tm *ttlManager) keepAlive(txn *tikvTxn, primary []byte, closeCh chan struct{}) {
+	ticker := time.NewTicker(ttlManagerInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-closeCh:
+			return
+		case <-ticker.C:
+			// The transaction fails to commit anyway.
+			if txn.store.oracle.IsExpired(txn.startTS, maxTxnTimeUse) {
+				log.Warnf("[kv] txn %d takes too much time, stop the heartbeat", txn.startTS)
+				return
+			}
+			elapsed := uint64(time.Duration(monotime.Now()-txn.startTime) / time.Millisecond)
+			bo := NewBackoffer(txnHeartBeatMaxBackoff, goctx.Background())
+			ttl, err := sendTxnHeartBeat(bo, txn.store, primary, txn.startTS, elapsed+managedLockTTL)
+			if errors.Cause(err) == errPrimaryLockNotFound {
+				// The transaction is committed or rolled back.
+				log.Infof("[kv] txn %d stops the heartbeat: %v", txn.startTS, err)
+				return
+			}
+			if err != nil {
+				// Try again in the next tick, the lock outlives a few failed heartbeats.
+				log.Warnf("[kv] txn %d heartbeat err: %v", txn.startTS, err)
+				continue
+			}
+			log.Debugf("[kv] txn %d heartbeat, primary lock ttl: %d", txn.startTS, ttl)
+		}
+	}
+}
+
+// sendTxnHeartBeat extends the ttl of the primary lock to adviseTTL, it returns the
+// ttl of the lock after that. An adviseTTL 0 just queries the ttl. It returns
+// errPrimaryLockNotFound if the primary lock of the transaction is gone.
+func sendTxnHeartBeat(bo *Backoffer, store *tikvStore, primary []byte, startTS, adviseTTL uint64) (uint64, error) {
+	req := &tikvrpc.Request{
+		Type: tikvrpc.CmdTxnHeartBeat,
+		TxnHeartBeat: &tikvrpc.TxnHeartBeatRequest{
+			PrimaryLock:   primary,
+			StartVersion:  startTS,
+			AdviseLockTtl: adviseTTL,
+		},
+	}
+	for {
+		loc, err := store.regionCache.LocateKey(bo, primary)
+		if err != nil {
+			return 0, errors.Trace(err)
+		}
+		resp, err := store.SendReq(bo, req, loc.Region, readTimeoutShort)
+		if err != nil {
+			return 0, errors.Trace(err)
+		}
+		regionErr, err := resp.GetRegionError()
+		if err != nil {
+			return 0, errors.Trace(err)
+		}
+		if regionErr != nil {
+			err = bo.Backoff(BoRegionMiss, errors.New(regionErr.String()))
+			if err != nil {
+				return 0, errors.Trace(err)
+			}
+			continue
+		}
+		cmdResp := resp.TxnHeartBeat
+		if cmdResp == nil {
+			return 0, errors.Trace(ErrBodyMissing)
+		}
+		if keyErr := cmdResp.Error; keyErr != nil {
+			if keyErr.Abort != "" {
+				return 0, errors.Annotatef(errPrimaryLockNotFound, "txn %d: %s", startTS, keyErr.Abort)
+			}
+			return 0, errors.Errorf("txn %d heartbeat fails: %s", startTS, keyErr)
+		}
+		return cmdResp.LockTtl, nil
+	}
+}
@@ -27,6 +27,8 @@ import (
 
 var (
 	_ kv.Transaction = (*tikvTxn)(nil)
+	_ kv.Stager      = (*tikvTxn)(nil)
+	_ kv.BatchGetter = (*tikvTxn)(nil)
 )
 
 // tikvTxn implements kv.Transaction.
@@ -40,6 +42,23 @@ type tikvTxn struct {
 	valid     bool
 	lockKeys  [][]byte
 	dirty     bool
+	// stagingLockKeys are the lengths of lockKeys when the staging layers are created.
+	stagingLockKeys []int
+	// committing is set when the committer starts to execute, the committer releases
+	// the buffer then, because the keys may be committed in background.
+	committing bool
+	ttlManager ttlManager
+
+	// Fields for the pessimistic mode.
+	pessimistic      bool
+	forUpdateTS      uint64
+	lockWaitDeadline time.Time
+	primaryKey       []byte
+	lockedKeys       map[string]struct{}
+	// stmtBuf stages the writes of the current statement, it's nil when no
+	// statement is running.
+	stmtBuf      *kv.BufferStore
+	stmtLockKeys [][]byte
 }
 
 func newTiKVTxn(store *tikvStore) (*tikvTxn, error) {
@@ -56,12 +75,13 @@ func newTikvTxnWithStartTS(store *tikvStore, startTS uint64) (*tikvTxn, error) {
 	ver := kv.NewVersion(startTS)
 	snapshot := newTiKVSnapshot(store, ver)
 	return &tikvTxn{
-		snapshot:  snapshot,
-		us:        kv.NewUnionStore(snapshot),
-		store:     store,
-		startTS:   startTS,
-		startTime: monotime.Now(),
-		valid:     true,
+		snapshot:   snapshot,
+		us:         kv.NewUnionStore(snapshot),
+		store:      store,
+		startTS:    startTS,
+		startTime:  monotime.Now(),
+		valid:      true,
+		lockedKeys: make(map[string]struct{}),
 	}, nil
 }
 
@@ -71,7 +91,7 @@ func (txn *tikvTxn) Get(k kv.Key) ([]byte, error) {
 	start := time.Now()
 	defer func() { txnCmdHistogram.WithLabelValues("get").Observe(time.Since(start).Seconds()) }()
 
-	ret, err := txn.us.Get(k)
+	ret, err := txn.retrieverMutator().Get(k)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
@@ -82,7 +102,26 @@ func (txn *tikvTxn) Set(k kv.Key, v []byte) error {
 	txnCmdCounter.WithLabelValues("set").Inc()
 
 	txn.dirty = true
-	return txn.us.Set(k, v)
+	if txn.stmtBuf != nil {
+		if err := txn.lockStmtKeys(k); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return txn.retrieverMutator().Set(k, v)
+}
+
+// BatchGet implements the kv.BatchGetter interface.
+func (txn *tikvTxn) BatchGet(keys []kv.Key) (map[string][]byte, error) {
+	txnCmdCounter.WithLabelValues("batch_get").Inc()
+	start := time.Now()
+	defer func() { txnCmdHistogram.WithLabelValues("batch_get").Observe(time.Since(start).Seconds()) }()
+
+	if txn.stmtBuf != nil {
+		values, err := kv.BatchGetWithBuffer(txn.stmtBuf.MemBuffer, keys, txn.us.BatchGet)
+		return values, errors.Trace(err)
+	}
+	values, err := txn.us.BatchGet(keys)
+	return values, errors.Trace(err)
 }
 
 func (txn *tikvTxn) String() string {
@@ -94,7 +133,7 @@ func (txn *tikvTxn) Seek(k kv.Key) (kv.Iterator, error) {
 	start := time.Now()
 	defer func() { txnCmdHistogram.WithLabelValues("seek").Observe(time.Since(start).Seconds()) }()
 
-	return txn.us.Seek(k)
+	return txn.retrieverMutator().Seek(k)
 }
 
 // SeekReverse creates a reversed Iterator positioned on the first entry which key is less than k.
@@ -103,14 +142,28 @@ func (txn *tikvTxn) SeekReverse(k kv.Key) (kv.Iterator, error) {
 	start := time.Now()
 	defer func() { txnCmdHistogram.WithLabelValues("seek_reverse").Observe(time.Since(start).Seconds()) }()
 
-	return txn.us.SeekReverse(k)
+	return txn.retrieverMutator().SeekReverse(k)
 }
 
 func (txn *tikvTxn) Delete(k kv.Key) error {
 	txnCmdCounter.WithLabelValues("delete").Inc()
 
 	txn.dirty = true
-	return txn.us.Delete(k)
+	if txn.stmtBuf != nil {
+		if err := txn.lockStmtKeys(k); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return txn.retrieverMutator().Delete(k)
+}
+
+// retrieverMutator returns the buffer of the current statement in the pessimistic
+// mode, or the union store otherwise.
+func (txn *tikvTxn) retrieverMutator() kv.RetrieverMutator {
+	if txn.stmtBuf != nil {
+		return txn.stmtBuf
+	}
+	return txn.us
 }
 
 func (txn *tikvTxn) SetOption(opt kv.Option, val interface{}) {
@@ -120,13 +173,22 @@ func (txn *tikvTxn) SetOption(opt kv.Option, val interface{}) {
 		txn.snapshot.isolationLevel = val.(kv.IsoLevel)
 	case kv.Priority:
 		txn.snapshot.priority = kvPriorityToCommandPri(val.(int))
+	case kv.Pessimistic:
+		txn.pessimistic = val.(bool)
+	case kv.ReplicaRead:
+		txn.snapshot.replicaRead = val.(kv.ReplicaReadType)
 	}
 }
 
 func (txn *tikvTxn) DelOption(opt kv.Option) {
 	txn.us.DelOption(opt)
-	if opt == kv.IsolationLevel {
+	switch opt {
+	case kv.IsolationLevel:
 		txn.snapshot.isolationLevel = kv.SI
+	case kv.Pessimistic:
+		txn.pessimistic = false
+	case kv.ReplicaRead:
+		txn.snapshot.replicaRead = kv.ReplicaReadLeader
 	}
 }
 
@@ -141,18 +203,23 @@ func (txn *tikvTxn) Commit() error {
 	defer func() { txnCmdHistogram.WithLabelValues("commit").Observe(time.Since(start).Seconds()) }()
 
 	if err := txn.us.CheckLazyConditionPairs(); err != nil {
+		txn.pessimisticRollback()
 		return errors.Trace(err)
 	}
 
 	committer, err := newTwoPhaseCommitter(txn)
 	if err != nil {
+		txn.pessimisticRollback()
 		return errors.Trace(err)
 	}
 	if committer == nil {
 		return nil
 	}
+	txn.committing = true
 	err = committer.execute()
 	if err != nil {
+		// The keys not prewritten are still locked pessimistically.
+		txn.pessimisticRollback()
 		committer.writeFinishBinlog(binlog.BinlogType_Rollback, 0)
 		return errors.Trace(err)
 	}
@@ -163,6 +230,10 @@ func (txn *tikvTxn) Commit() error {
 
 func (txn *tikvTxn) close() error {
 	txn.valid = false
+	txn.ttlManager.close()
+	if !txn.committing {
+		txn.us.Release()
+	}
 	return nil
 }
 
@@ -171,6 +242,7 @@ func (txn *tikvTxn) Rollback() error {
 		return kv.ErrInvalidTxn
 	}
 	txn.close()
+	txn.pessimisticRollback()
 	log.Infof("[kv] Rollback txn %d", txn.StartTS())
 	txnCmdCounter.WithLabelValues("rollback").Inc()
 
@@ -179,12 +251,48 @@ func (txn *tikvTxn) Rollback() error {
 
 func (txn *tikvTxn) LockKeys(keys ...kv.Key) error {
 	txnCmdCounter.WithLabelValues("lock_keys").Inc()
+	if txn.stmtBuf != nil {
+		if err := txn.lockStmtKeys(keys...); err != nil {
+			return errors.Trace(err)
+		}
+	}
 	for _, key := range keys {
-		txn.lockKeys = append(txn.lockKeys, key)
+		if txn.stmtBuf != nil {
+			txn.stmtLockKeys = append(txn.stmtLockKeys, key)
+		} else {
+			txn.lockKeys = append(txn.lockKeys, key)
+		}
 	}
 	return nil
 }
 
+// Staging implements the kv.Stager interface.
+func (txn *tikvTxn) Staging() kv.StagingHandle {
+	h := txn.us.Staging()
+	txn.stagingLockKeys = append(txn.stagingLockKeys[:h-1], len(txn.lockKeys))
+	return h
+}
+
+// ReleaseStaging implements the kv.Stager interface.
+func (txn *tikvTxn) ReleaseStaging(h kv.StagingHandle) {
+	if int(h) <= 0 || int(h) > len(txn.stagingLockKeys) {
+		return
+	}
+	txn.stagingLockKeys = txn.stagingLockKeys[:h-1]
+	txn.us.ReleaseStaging(h)
+}
+
+// CleanupStaging implements the kv.Stager interface. The keys locked in the
+// pessimistic mode are kept locked until the transaction ends.
+func (txn *tikvTxn) CleanupStaging(h kv.StagingHandle) error {
+	if int(h) <= 0 || int(h) > len(txn.stagingLockKeys) {
+		return nil
+	}
+	txn.lockKeys = txn.lockKeys[:txn.stagingLockKeys[h-1]]
+	txn.stagingLockKeys = txn.stagingLockKeys[:h-1]
+	return errors.Trace(txn.us.CleanupStaging(h))
+}
+
 func (txn *tikvTxn) IsReadOnly() bool {
 	return !txn.dirty
 }
@@ -198,9 +306,15 @@ func (txn *tikvTxn) Valid() bool {
 }
 
 func (txn *tikvTxn) Len() int {
+	if txn.stmtBuf != nil {
+		return txn.us.Len() + txn.stmtBuf.Len()
+	}
 	return txn.us.Len()
 }
 
 func (txn *tikvTxn) Size() int {
+	if txn.stmtBuf != nil {
+		return txn.us.Size() + txn.stmtBuf.Size()
+	}
 	return txn.us.Size()
 }
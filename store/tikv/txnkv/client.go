@@ -0,0 +1,131 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package txnkv is a transactional key-value client of TiKV, which runs the transactions
+// of the tikv store without the SQL layer.
+package txnkv
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/store/tikv"
+)
+
+// ErrPessimisticNotSupported is returned when the pessimistic mode is configured but the
+// storage doesn't support it.
+var ErrPessimisticNotSupported = errors.New("pessimistic transaction is not supported by the storage")
+
+// Config is the config of the transactions of a Client.
+type Config struct {
+	// IsolationLevel is the isolation level of the reads, the default is SI.
+	IsolationLevel kv.IsoLevel
+	// Pessimistic makes the transactions lock the keys when they are written or locked,
+	// instead of detecting the write conflicts at commit.
+	Pessimistic bool
+	// LockWaitTimeout is the max time a write of a pessimistic transaction waits for the
+	// locks held by other transactions, the write fails with kv.ErrLockWaitTimeout after it.
+	LockWaitTimeout time.Duration
+	// MaxRetry is the max number of the retries of a transaction run by Client.Update when
+	// it fails with a retryable error, like a write conflict.
+	MaxRetry int
+}
+
+// DefaultConfig returns the default config, the transactions are optimistic in SI.
+func DefaultConfig() Config {
+	return Config{
+		IsolationLevel:  kv.SI,
+		LockWaitTimeout: 50 * time.Second,
+		MaxRetry:        10,
+	}
+}
+
+// Client is a transactional key-value client of TiKV.
+type Client struct {
+	store kv.Storage
+	cfg   Config
+}
+
+// NewClient creates a client with PD cluster addrs.
+func NewClient(pdAddrs []string, cfg Config) (*Client, error) {
+	store, err := tikv.Driver{}.Open(fmt.Sprintf("tikv://%s", strings.Join(pdAddrs, ",")))
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return newClient(store, cfg)
+}
+
+// NewMockClient creates a client on mock-tikv, it's for test.
+func NewMockClient(cfg Config, options ...tikv.MockTiKVStoreOption) (*Client, error) {
+	store, err := tikv.NewMockTikvStore(options...)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return newClient(store, cfg)
+}
+
+func newClient(store kv.Storage, cfg Config) (*Client, error) {
+	if cfg.Pessimistic && !store.GetClient().IsRequestTypeSupported(kv.ReqTypePessimisticTxn, kv.ReqSubTypeBasic) {
+		store.Close()
+		return nil, errors.Trace(ErrPessimisticNotSupported)
+	}
+	return &Client{store: store, cfg: cfg}, nil
+}
+
+// Close closes the client and its storage.
+func (c *Client) Close() error {
+	return errors.Trace(c.store.Close())
+}
+
+// Begin starts a new transaction.
+func (c *Client) Begin() (*Transaction, error) {
+	txn, err := c.store.Begin()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	txn.SetOption(kv.IsolationLevel, c.cfg.IsolationLevel)
+	if c.cfg.Pessimistic {
+		txn.SetOption(kv.Pessimistic, true)
+	}
+	return &Transaction{txn: txn, client: c}, nil
+}
+
+// Update runs f in a new transaction and commits it. The transaction is run again if it
+// fails with a retryable error, at most Config.MaxRetry times. The transaction is rolled
+// back if f returns an error.
+func (c *Client) Update(f func(txn *Transaction) error) error {
+	for i := 0; ; i++ {
+		txn, err := c.Begin()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		err = f(txn)
+		if err == nil {
+			err = txn.Commit()
+		} else {
+			txn.Rollback()
+		}
+		if err == nil {
+			return nil
+		}
+		if !kv.IsRetryableError(err) || i >= c.cfg.MaxRetry {
+			return errors.Trace(err)
+		}
+		log.Warnf("[txnkv] retry txn %d for error: %v", txn.StartTS(), err)
+		kv.BackOff(i)
+	}
+}
@@ -0,0 +1,179 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package txnkv
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/kv"
+)
+
+// Transaction is a transaction of a Client, the writes are buffered until it's committed
+// by the two-phase commit. It's not thread safe.
+type Transaction struct {
+	txn    kv.Transaction
+	client *Client
+}
+
+// StartTS returns the start timestamp of the transaction.
+func (t *Transaction) StartTS() uint64 {
+	return t.txn.StartTS()
+}
+
+// Get gets the value of the key. When the key does not exist, it returns `nil, nil`.
+func (t *Transaction) Get(key []byte) ([]byte, error) {
+	v, err := t.txn.Get(key)
+	if kv.IsErrNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return v, nil
+}
+
+// BatchGet gets the values of the keys, the keys that don't exist are not in the result.
+func (t *Transaction) BatchGet(keys [][]byte) (map[string][]byte, error) {
+	kvKeys := make([]kv.Key, 0, len(keys))
+	for _, k := range keys {
+		kvKeys = append(kvKeys, k)
+	}
+	if getter, ok := t.txn.(kv.BatchGetter); ok {
+		values, err := getter.BatchGet(kvKeys)
+		return values, errors.Trace(err)
+	}
+	values := make(map[string][]byte, len(keys))
+	for _, k := range kvKeys {
+		v, err := t.txn.Get(k)
+		if kv.IsErrNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		values[string(k)] = v
+	}
+	return values, nil
+}
+
+// Set sets the value of the key, the value must not be empty.
+func (t *Transaction) Set(key []byte, value []byte) error {
+	return t.write(func() error {
+		return t.txn.Set(key, value)
+	})
+}
+
+// Delete deletes the key.
+func (t *Transaction) Delete(key []byte) error {
+	return t.write(func() error {
+		return t.txn.Delete(key)
+	})
+}
+
+// LockKeys locks the keys, a pessimistic transaction acquires the locks immediately, and an
+// optimistic transaction fails at commit if any of the keys is written by others after
+// it starts.
+func (t *Transaction) LockKeys(keys ...[]byte) error {
+	kvKeys := make([]kv.Key, 0, len(keys))
+	for _, k := range keys {
+		kvKeys = append(kvKeys, k)
+	}
+	return t.write(func() error {
+		return t.txn.LockKeys(kvKeys...)
+	})
+}
+
+// write runs the write f. In the pessimistic mode it's run as a statement that locks the keys
+// at a new for update ts, and it's run again at a newer one if it fails for a write conflict,
+// until the lock wait timeout.
+func (t *Transaction) write(f func() error) error {
+	txn, ok := t.txn.(kv.PessimisticTxn)
+	if !t.client.cfg.Pessimistic || !ok {
+		return errors.Trace(f())
+	}
+	deadline := time.Now().Add(t.client.cfg.LockWaitTimeout)
+	for {
+		forUpdateTS, err := t.client.store.CurrentVersion()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		txn.StartStmt(forUpdateTS.Ver, deadline)
+		err = f()
+		if err == nil {
+			return errors.Trace(txn.FinishStmt(true))
+		}
+		if err1 := txn.FinishStmt(false); err1 != nil {
+			return errors.Trace(err1)
+		}
+		if kv.ErrDeadlock.Equal(err) || !kv.IsRetryableError(err) || time.Now().After(deadline) {
+			return errors.Trace(err)
+		}
+	}
+}
+
+// Iter creates an Iterator of the keys in [startKey, endKey) in ascending order, an empty
+// endKey means there's no upper bound. The Iterator must be closed after use.
+func (t *Transaction) Iter(startKey, endKey []byte) (*Iterator, error) {
+	iter, err := t.txn.Seek(startKey)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &Iterator{iter: iter, endKey: endKey}, nil
+}
+
+// Commit commits the transaction by the two-phase commit.
+func (t *Transaction) Commit() error {
+	return errors.Trace(t.txn.Commit())
+}
+
+// Rollback discards the transaction, the pessimistic locks are released.
+func (t *Transaction) Rollback() error {
+	return errors.Trace(t.txn.Rollback())
+}
+
+// Iterator iterates the pairs of a range of a transaction.
+type Iterator struct {
+	iter   kv.Iterator
+	endKey []byte
+}
+
+// Valid returns whether the Iterator is positioned on a pair in the range.
+func (it *Iterator) Valid() bool {
+	if !it.iter.Valid() {
+		return false
+	}
+	return len(it.endKey) == 0 || bytes.Compare(it.iter.Key(), it.endKey) < 0
+}
+
+// Key returns the key of the current pair.
+func (it *Iterator) Key() []byte {
+	return it.iter.Key()
+}
+
+// Value returns the value of the current pair.
+func (it *Iterator) Value() []byte {
+	return it.iter.Value()
+}
+
+// Next moves the Iterator to the next pair.
+func (it *Iterator) Next() error {
+	return errors.Trace(it.iter.Next())
+}
+
+// Close closes the Iterator.
+func (it *Iterator) Close() {
+	it.iter.Close()
+}
@@ -0,0 +1,161 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package txnkv
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/juju/errors"
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/kv"
+)
+
+func TestT(t *testing.T) {
+	CustomVerboseFlag = true
+	TestingT(t)
+}
+
+type testTxnKVSuite struct{}
+
+var _ = Suite(&testTxnKVSuite{})
+
+func (s *testTxnKVSuite) TestBasic(c *C) {
+	client, err := NewMockClient(DefaultConfig())
+	c.Assert(err, IsNil)
+	defer client.Close()
+
+	txn, err := client.Begin()
+	c.Assert(err, IsNil)
+	for i := 0; i < 5; i++ {
+		c.Assert(txn.Set([]byte(fmt.Sprintf("k%d", i)), []byte(fmt.Sprintf("v%d", i))), IsNil)
+	}
+	c.Assert(txn.Commit(), IsNil)
+
+	txn, err = client.Begin()
+	c.Assert(err, IsNil)
+	c.Assert(txn.Delete([]byte("k1")), IsNil)
+	c.Assert(txn.Set([]byte("k2"), []byte("v22")), IsNil)
+	v, err := txn.Get([]byte("k2"))
+	c.Assert(err, IsNil)
+	c.Assert(string(v), Equals, "v22")
+	v, err = txn.Get([]byte("k1"))
+	c.Assert(err, IsNil)
+	c.Assert(v, IsNil)
+	values, err := txn.BatchGet([][]byte{[]byte("k0"), []byte("k1"), []byte("k2"), []byte("k9")})
+	c.Assert(err, IsNil)
+	c.Assert(values, DeepEquals, map[string][]byte{"k0": []byte("v0"), "k2": []byte("v22")})
+
+	iter, err := txn.Iter([]byte("k1"), []byte("k4"))
+	c.Assert(err, IsNil)
+	var keys []string
+	for iter.Valid() {
+		keys = append(keys, string(iter.Key()))
+		c.Assert(iter.Next(), IsNil)
+	}
+	iter.Close()
+	c.Assert(keys, DeepEquals, []string{"k2", "k3"})
+	c.Assert(txn.Commit(), IsNil)
+
+	txn, err = client.Begin()
+	c.Assert(err, IsNil)
+	values, err = txn.BatchGet([][]byte{[]byte("k1"), []byte("k2")})
+	c.Assert(err, IsNil)
+	c.Assert(values, DeepEquals, map[string][]byte{"k2": []byte("v22")})
+	c.Assert(txn.Rollback(), IsNil)
+}
+
+func (s *testTxnKVSuite) TestUpdateRetry(c *C) {
+	cfg := DefaultConfig()
+	cfg.MaxRetry = 100
+	client, err := NewMockClient(cfg)
+	c.Assert(err, IsNil)
+	defer client.Close()
+
+	key := []byte("counter")
+	incr := func(txn *Transaction) error {
+		v, err1 := txn.Get(key)
+		if err1 != nil {
+			return errors.Trace(err1)
+		}
+		n := 0
+		if v != nil {
+			n, err1 = strconv.Atoi(string(v))
+			if err1 != nil {
+				return errors.Trace(err1)
+			}
+		}
+		return txn.Set(key, []byte(strconv.Itoa(n+1)))
+	}
+	const workers, count = 4, 5
+	var wg sync.WaitGroup
+	errs := make([]error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < count && errs[i] == nil; j++ {
+				errs[i] = client.Update(incr)
+			}
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		c.Assert(err, IsNil)
+	}
+	txn, err := client.Begin()
+	c.Assert(err, IsNil)
+	v, err := txn.Get(key)
+	c.Assert(err, IsNil)
+	c.Assert(string(v), Equals, strconv.Itoa(workers*count))
+
+	// The errors that are not retryable are returned.
+	errStop := errors.New("stop")
+	c.Assert(errors.Cause(client.Update(func(*Transaction) error { return errStop })), Equals, errStop)
+}
+
+func (s *testTxnKVSuite) TestPessimistic(c *C) {
+	cfg := DefaultConfig()
+	cfg.Pessimistic = true
+	cfg.LockWaitTimeout = 100 * time.Millisecond
+	client, err := NewMockClient(cfg)
+	c.Assert(err, IsNil)
+	defer client.Close()
+
+	key := []byte("key")
+	txn1, err := client.Begin()
+	c.Assert(err, IsNil)
+	c.Assert(txn1.Set(key, []byte("v1")), IsNil)
+
+	// The lock of txn1 is acquired at the write, txn2 waits for it until the timeout.
+	txn2, err := client.Begin()
+	c.Assert(err, IsNil)
+	err = txn2.Set(key, []byte("v2"))
+	c.Assert(kv.ErrLockWaitTimeout.Equal(err), IsTrue, Commentf("%v", err))
+
+	// txn2 locks the key at a newer version after txn1 commits, so it doesn't conflict
+	// with txn1 though it starts earlier.
+	c.Assert(txn1.Commit(), IsNil)
+	c.Assert(txn2.Set(key, []byte("v2")), IsNil)
+	c.Assert(txn2.Commit(), IsNil)
+
+	txn, err := client.Begin()
+	c.Assert(err, IsNil)
+	v, err := txn.Get(key)
+	c.Assert(err, IsNil)
+	c.Assert(string(v), Equals, "v2")
+}
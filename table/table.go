@@ -67,6 +67,8 @@ var (
 	ErrInvalidRecordKey = terror.ClassTable.New(codeInvalidRecordKey, "invalid record key")
 	// ErrTruncateWrongValue returns for truncate wrong value for field.
 	ErrTruncateWrongValue = terror.ClassTable.New(codeTruncateWrongValue, "Incorrect value")
+	// ErrCheckConstraintViolated returns for a row which violates a check constraint.
+	ErrCheckConstraintViolated = terror.ClassTable.New(codeCheckConstraintViolated, mysql.MySQLErrName[mysql.ErrCheckConstraintViolated])
 )
 
 // RecordIterFunc is used for low-level record iteration.
@@ -165,6 +167,8 @@ const (
 	codeDuplicateColumn    = 1110
 	codeNoDefaultValue     = 1364
 	codeTruncateWrongValue = 1366
+
+	codeCheckConstraintViolated = 3819
 )
 
 // Slice is used for table sorting.
@@ -185,6 +189,8 @@ func init() {
 		codeDuplicateColumn:    mysql.ErrFieldSpecifiedTwice,
 		codeNoDefaultValue:     mysql.ErrNoDefaultForField,
 		codeTruncateWrongValue: mysql.ErrTruncatedWrongValueForField,
+
+		codeCheckConstraintViolated: mysql.ErrCheckConstraintViolated,
 	}
 	terror.ErrClassToMySQLCodes[terror.ClassTable] = tableMySQLErrCodes
 }
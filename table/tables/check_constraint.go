@@ -0,0 +1,89 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tables
+
+import (
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/table"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// CheckConstraintExpr is a check constraint with its expression built for evaluation.
+type CheckConstraintExpr struct {
+	*model.CheckConstraintInfo
+	Expr expression.Expression
+}
+
+// BuildCheckConstraintExpr builds the expression of the check constraint,
+// the columns in it are resolved by the offsets of the public columns of t.
+func BuildCheckConstraintExpr(ctx context.Context, t table.Table, info *model.CheckConstraintInfo) (*CheckConstraintExpr, error) {
+	node, err := ParseGeneratedExpr(info.ExprString, t.Meta())
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	cols := t.Cols()
+	colInfos := make([]*model.ColumnInfo, 0, len(cols))
+	for _, col := range cols {
+		colInfos = append(colInfos, col.ToInfo())
+	}
+	schema := expression.NewSchema(expression.ColumnInfos2Columns(t.Meta().Name, colInfos)...)
+	expr, err := expression.RewriteAstExpr(node, schema, ctx)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &CheckConstraintExpr{CheckConstraintInfo: info, Expr: expr}, nil
+}
+
+// BuildCheckConstraintExprs builds the expressions of the check constraints which are enforced on writes.
+// A check constraint is enforced once it's in write only state.
+func BuildCheckConstraintExprs(ctx context.Context, t table.Table) ([]*CheckConstraintExpr, error) {
+	var checks []*CheckConstraintExpr
+	for _, info := range t.Meta().CheckConstraints {
+		if info.State == model.StateNone {
+			continue
+		}
+		check, err := BuildCheckConstraintExpr(ctx, t, info)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		checks = append(checks, check)
+	}
+	return checks, nil
+}
+
+// VerifyCheckConstraints returns an error if the row violates any of the check constraints.
+// A check constraint is satisfied when its expression evaluates to TRUE or NULL.
+func VerifyCheckConstraints(ctx context.Context, checks []*CheckConstraintExpr, row []types.Datum) error {
+	sc := ctx.GetSessionVars().StmtCtx
+	for _, check := range checks {
+		val, err := check.Expr.Eval(row)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if val.IsNull() {
+			continue
+		}
+		ok, err := val.ToBool(sc)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if ok == 0 {
+			return table.ErrCheckConstraintViolated.GenByArgs(check.Name.O)
+		}
+	}
+	return nil
+}
@@ -86,3 +86,14 @@ func simpleResolveName(node ast.ExprNode, tblInfo *model.TableInfo) (ast.ExprNod
 	}
 	return node, nil
 }
+
+// ParseGeneratedExpr parses the generation expression of a column in tblInfo,
+// and resolves all column names in it.
+func ParseGeneratedExpr(expr string, tblInfo *model.TableInfo) (ast.ExprNode, error) {
+	node, err := parseExpression(expr)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	node, err = simpleResolveName(node, tblInfo)
+	return node, errors.Trace(err)
+}
@@ -78,11 +78,7 @@ func TableFromMeta(alloc autoid.Allocator, tblInfo *model.TableInfo) (table.Tabl
 
 		col := table.ToColumn(colInfo)
 		if col.IsGenerated() {
-			expr, err := parseExpression(colInfo.GeneratedExprString)
-			if err != nil {
-				return nil, errors.Trace(err)
-			}
-			expr, err = simpleResolveName(expr, tblInfo)
+			expr, err := ParseGeneratedExpr(colInfo.GeneratedExprString, tblInfo)
 			if err != nil {
 				return nil, errors.Trace(err)
 			}
@@ -38,6 +38,7 @@ import (
 	"github.com/pingcap/tidb/sessionctx/binloginfo"
 	"github.com/pingcap/tidb/store/localstore/boltdb"
 	"github.com/pingcap/tidb/store/tikv"
+	"github.com/pingcap/tidb/store/tikv/gcworker"
 	"github.com/pingcap/tidb/util/printer"
 	"github.com/pingcap/tidb/util/systimemon"
 	"github.com/pingcap/tidb/x-server"
@@ -138,6 +139,7 @@ func registerStores() {
 	tidb.RegisterLocalStore("boltdb", boltdb.Driver{})
 	tidb.RegisterStore("tikv", tikv.Driver{})
 	tidb.RegisterStore("mocktikv", tikv.MockDriver{})
+	tikv.NewGCHandlerFunc = gcworker.NewGCWorker
 }
 
 func createStoreAndDomain() {
@@ -321,6 +323,9 @@ func setGlobalVars() {
 	tidb.SetCommitRetryLimit(cfg.Performance.RetryLimit)
 	plan.JoinConcurrency = cfg.Performance.JoinConcurrency
 	plan.AllowCartesianProduct = cfg.Performance.CrossJoin
+	kv.TxnTotalSizeLimit = cfg.Performance.TxnTotalSizeLimit
+	kv.TxnMemBufferSpillSize = int(cfg.Performance.MemBufferSpillSize)
+	tikv.LocalLabels = cfg.Labels
 	privileges.SkipWithGrant = cfg.Security.SkipGrantTable
 }
 